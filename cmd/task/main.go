@@ -34,6 +34,31 @@ func taskCommands() []*cmd.Command {
 			Description: "Creates expenses from due recurrent expenses",
 			Run:         runTask(task.CopyDueRecurrentExpenses),
 		},
+		{
+			Name:        "copy_due_recurrent_incomes",
+			Description: "Creates incomes from due recurrent incomes",
+			Run:         runTask(task.CopyDueRecurrentIncomes),
+		},
+		{
+			Name:        "purge_trash",
+			Description: "Deletes trashed rows older than TRASH_RETENTION_DAYS (default 30)",
+			Run:         runTask(task.PurgeTrash),
+		},
+		{
+			Name:        "restore_backup",
+			Description: "Prompts and restores a backup archive into an empty account",
+			Run:         runTask(task.RestoreBackup),
+		},
+		{
+			Name:        "import_exchange_rates",
+			Description: "Prompts and imports exchange rates from a CSV file",
+			Run:         runTask(task.ImportExchangeRates),
+		},
+		{
+			Name:        "import_reference_foods",
+			Description: "Prompts and imports a USDA or Open Food Facts dump into the reference food catalogue",
+			Run:         runTask(task.ImportReferenceFoods),
+		},
 		{
 			Name:        "test",
 			Description: "Runs testing code",
@@ -119,3 +119,30 @@ func TestUserScopedQueryPlans(t *testing.T) {
 		})
 	}
 }
+
+// TestReferenceFoodSearchPlan pins the catalogue search to range walks of the
+// name and brand indexes. The catalogue runs to millions of rows, so a search
+// that falls back to scanning it would outlast the request.
+func TestReferenceFoodSearchPlan(t *testing.T) {
+	ctx := t.Context()
+	sqlDB := openMigrated(t)
+
+	plan := queryPlan(ctx, t, sqlDB, `SELECT "id" FROM "reference_foods"
+	WHERE "id" IN (
+	  SELECT "id" FROM (
+	    SELECT "id" FROM "reference_foods" WHERE "name" LIKE ?1 ESCAPE '\'
+	    ORDER BY "name" COLLATE NOCASE, "id" LIMIT ?2
+	  )
+	  UNION
+	  SELECT "id" FROM (
+	    SELECT "id" FROM "reference_foods" WHERE "brand" LIKE ?1 ESCAPE '\'
+	    ORDER BY "brand" COLLATE NOCASE, "name" COLLATE NOCASE, "id" LIMIT ?2
+	  )
+	)
+	ORDER BY "name" COLLATE NOCASE, "id"
+	LIMIT ?2`, "oat%", 20)
+
+	require.NotContains(t, plan, "SCAN reference_foods", "plan was:\n%s", plan)
+	require.Contains(t, plan, "idx_reference_foods_name (name>? AND name<?)", "plan was:\n%s", plan)
+	require.Contains(t, plan, "idx_reference_foods_brand_name (brand>? AND brand<?)", "plan was:\n%s", plan)
+}
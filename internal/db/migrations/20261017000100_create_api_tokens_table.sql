@@ -0,0 +1,35 @@
+-- +goose Up
+-- Personal bearer tokens for non-browser clients. Like "invitation_codes", only
+-- a bcrypt hash of the token is kept; the SHA-256 fingerprint is what a request
+-- is looked up by, since a bcrypt hash cannot be searched. Each area carries its
+-- own scope: 'none', 'read', or 'write' (which includes read).
+CREATE TABLE IF NOT EXISTS "api_tokens" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "name" TEXT NOT NULL,
+  "token_hash" BLOB NOT NULL,
+  "token_fingerprint" TEXT NOT NULL,
+  "expenses_scope" TEXT NOT NULL DEFAULT 'none'
+    CHECK ("expenses_scope" IN ('none', 'read', 'write')),
+  "macros_scope" TEXT NOT NULL DEFAULT 'none'
+    CHECK ("macros_scope" IN ('none', 'read', 'write')),
+  "moods_scope" TEXT NOT NULL DEFAULT 'none'
+    CHECK ("moods_scope" IN ('none', 'read', 'write')),
+  "last_used_at" INTEGER,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_api_tokens_token_fingerprint"
+ON "api_tokens" ("token_fingerprint");
+
+CREATE INDEX IF NOT EXISTS "idx_api_tokens_user_id" ON "api_tokens" ("user_id");
+
+PRAGMA user_version = 31;
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_api_tokens_user_id";
+DROP INDEX IF EXISTS "uq_api_tokens_token_fingerprint";
+DROP TABLE IF EXISTS "api_tokens";
+
+PRAGMA user_version = 30;
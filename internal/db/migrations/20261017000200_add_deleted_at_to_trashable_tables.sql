@@ -0,0 +1,57 @@
+-- +goose Up
+-- "deleted_at" marks a row as in the trash. Every list, count and total skips
+-- such rows; the trash page restores them by clearing the column, and the
+-- purge_trash task deletes them for good once they are old enough. Taggings of
+-- a trashed expense or mood entry are left in place until the purge.
+ALTER TABLE "expenses" ADD COLUMN "deleted_at" INTEGER;
+ALTER TABLE "macro_entries" ADD COLUMN "deleted_at" INTEGER;
+ALTER TABLE "foods" ADD COLUMN "deleted_at" INTEGER;
+ALTER TABLE "mood_entries" ADD COLUMN "deleted_at" INTEGER;
+
+-- A trashed food must not block a new one with the same name. Restoring it
+-- while a live namesake exists fails on this index instead.
+DROP INDEX IF EXISTS "uq_foods_user_lower_name";
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_foods_user_lower_name"
+ON "foods" ("user_id", lower("name")) WHERE "deleted_at" IS NULL;
+
+-- Partial indexes hold only trashed rows, so they stay small and serve both
+-- the per-user trash page and the purge task's age cutoff.
+CREATE INDEX IF NOT EXISTS "idx_expenses_trash"
+ON "expenses" ("user_id", "deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "idx_macro_entries_trash"
+ON "macro_entries" ("user_id", "deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "idx_foods_trash"
+ON "foods" ("user_id", "deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "idx_mood_entries_trash"
+ON "mood_entries" ("user_id", "deleted_at") WHERE "deleted_at" IS NOT NULL;
+
+PRAGMA user_version = 32;
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_mood_entries_trash";
+DROP INDEX IF EXISTS "idx_foods_trash";
+DROP INDEX IF EXISTS "idx_macro_entries_trash";
+DROP INDEX IF EXISTS "idx_expenses_trash";
+
+-- Without the column a trashed row would come back to life, so the trash is
+-- emptied first.
+DELETE FROM "taggings"
+WHERE ("taggable_type" = 'expense'
+       AND "taggable_id" IN (SELECT "id" FROM "expenses" WHERE "deleted_at" IS NOT NULL))
+   OR ("taggable_type" = 'mood_entry'
+       AND "taggable_id" IN (SELECT "id" FROM "mood_entries" WHERE "deleted_at" IS NOT NULL));
+DELETE FROM "expenses" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "macro_entries" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "foods" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "mood_entries" WHERE "deleted_at" IS NOT NULL;
+
+DROP INDEX IF EXISTS "uq_foods_user_lower_name";
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_foods_user_lower_name"
+ON "foods" ("user_id", lower("name"));
+
+ALTER TABLE "mood_entries" DROP COLUMN "deleted_at";
+ALTER TABLE "foods" DROP COLUMN "deleted_at";
+ALTER TABLE "macro_entries" DROP COLUMN "deleted_at";
+ALTER TABLE "expenses" DROP COLUMN "deleted_at";
+
+PRAGMA user_version = 31;
@@ -0,0 +1,134 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- Categories become user-owned. The global "name" and "uid" UNIQUE constraints
+-- can only go with a table rebuild, and dropping the old table with foreign
+-- keys on would cascade into every expense, so the rebuild runs with them off
+-- and inside its own transaction instead of goose's.
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+CREATE TABLE "categories_new" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "name" TEXT NOT NULL,
+  "uid" TEXT NOT NULL,
+  "archived_at" INTEGER,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+);
+
+-- Every existing user gets a copy of every seeded category.
+INSERT INTO "categories_new" ("user_id", "name", "uid", "created_at", "updated_at")
+SELECT "u"."id", "c"."name", "c"."uid", "c"."created_at", "c"."updated_at"
+FROM "users" AS "u" CROSS JOIN "categories" AS "c"
+ORDER BY "u"."id", "c"."id";
+
+-- Repoint each row at its owner's copy, trashed and archived rows included.
+UPDATE "expenses" SET "category_id" = (
+  SELECT "n"."id" FROM "categories_new" AS "n"
+  JOIN "categories" AS "c" ON "c"."uid" = "n"."uid"
+  WHERE "c"."id" = "expenses"."category_id" AND "n"."user_id" = "expenses"."user_id"
+);
+UPDATE "recurrent_expenses" SET "category_id" = (
+  SELECT "n"."id" FROM "categories_new" AS "n"
+  JOIN "categories" AS "c" ON "c"."uid" = "n"."uid"
+  WHERE "c"."id" = "recurrent_expenses"."category_id" AND "n"."user_id" = "recurrent_expenses"."user_id"
+);
+UPDATE "expense_budgets" SET "category_id" = (
+  SELECT "n"."id" FROM "categories_new" AS "n"
+  JOIN "categories" AS "c" ON "c"."uid" = "n"."uid"
+  WHERE "c"."id" = "expense_budgets"."category_id" AND "n"."user_id" = "expense_budgets"."user_id"
+);
+UPDATE "expense_category_mappings" SET "category_id" = (
+  SELECT "n"."id" FROM "categories_new" AS "n"
+  JOIN "categories" AS "c" ON "c"."uid" = "n"."uid"
+  WHERE "c"."id" = "expense_category_mappings"."category_id"
+    AND "n"."user_id" = "expense_category_mappings"."user_id"
+);
+
+DROP TABLE "categories";
+ALTER TABLE "categories_new" RENAME TO "categories";
+
+-- Names are unique per user regardless of case, matching how the import and
+-- quick-add lookups compare them. "uid" stays unique per user because backups
+-- match categories on it.
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_categories_user_lower_name"
+ON "categories" ("user_id", lower("name"));
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_categories_user_uid"
+ON "categories" ("user_id", "uid");
+
+-- With foreign keys off nothing stopped a row from being left pointing at a
+-- category that is gone, so the rebuild is checked before it commits: the
+-- CHECK fails, and the migration with it, when foreign_key_check finds any.
+CREATE TEMP TABLE "foreign_key_violations" (
+  "count" INTEGER NOT NULL CHECK ("count" = 0)
+);
+INSERT INTO "foreign_key_violations" SELECT count(*) FROM pragma_foreign_key_check;
+DROP TABLE "foreign_key_violations";
+
+PRAGMA user_version = 33;
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+-- Collapses the per-user copies back into one shared row per "uid". Archiving
+-- has no shared equivalent and is lost.
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+CREATE TABLE "categories_old" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "name" TEXT NOT NULL UNIQUE,
+  "uid" TEXT NOT NULL UNIQUE,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+);
+
+INSERT INTO "categories_old" ("name", "uid", "created_at", "updated_at")
+SELECT "name", "uid", MIN("created_at"), MAX("updated_at")
+FROM "categories"
+GROUP BY "uid"
+ORDER BY MIN("id");
+
+UPDATE "expenses" SET "category_id" = (
+  SELECT "o"."id" FROM "categories_old" AS "o"
+  JOIN "categories" AS "c" ON "c"."uid" = "o"."uid"
+  WHERE "c"."id" = "expenses"."category_id"
+);
+UPDATE "recurrent_expenses" SET "category_id" = (
+  SELECT "o"."id" FROM "categories_old" AS "o"
+  JOIN "categories" AS "c" ON "c"."uid" = "o"."uid"
+  WHERE "c"."id" = "recurrent_expenses"."category_id"
+);
+UPDATE "expense_budgets" SET "category_id" = (
+  SELECT "o"."id" FROM "categories_old" AS "o"
+  JOIN "categories" AS "c" ON "c"."uid" = "o"."uid"
+  WHERE "c"."id" = "expense_budgets"."category_id"
+);
+UPDATE "expense_category_mappings" SET "category_id" = (
+  SELECT "o"."id" FROM "categories_old" AS "o"
+  JOIN "categories" AS "c" ON "c"."uid" = "o"."uid"
+  WHERE "c"."id" = "expense_category_mappings"."category_id"
+);
+
+DROP TABLE "categories";
+ALTER TABLE "categories_old" RENAME TO "categories";
+
+-- With foreign keys off nothing stopped a row from being left pointing at a
+-- category that is gone, so the rebuild is checked before it commits: the
+-- CHECK fails, and the migration with it, when foreign_key_check finds any.
+CREATE TEMP TABLE "foreign_key_violations" (
+  "count" INTEGER NOT NULL CHECK ("count" = 0)
+);
+INSERT INTO "foreign_key_violations" SELECT count(*) FROM pragma_foreign_key_check;
+DROP TABLE "foreign_key_violations";
+
+PRAGMA user_version = 32;
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
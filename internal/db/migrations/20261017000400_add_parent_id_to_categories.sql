@@ -0,0 +1,59 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- A category may sit under one parent, one level deep: Transportation › Fuel.
+-- Deleting the parent turns its children back into top-level categories
+-- rather than taking them along.
+BEGIN;
+
+ALTER TABLE "categories" ADD COLUMN "parent_id" INTEGER
+REFERENCES "categories"("id") ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS "idx_categories_parent_id"
+ON "categories" ("parent_id") WHERE "parent_id" IS NOT NULL;
+
+PRAGMA user_version = 34;
+
+COMMIT;
+
+-- +goose Down
+-- SQLite cannot drop a column that is part of a foreign key, so the table is
+-- rebuilt without it, with foreign keys off for the same reason as the rebuild
+-- that made categories per user.
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+CREATE TABLE "categories_old" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "name" TEXT NOT NULL,
+  "uid" TEXT NOT NULL,
+  "archived_at" INTEGER,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+);
+
+INSERT INTO "categories_old" ("id", "user_id", "name", "uid", "archived_at", "created_at", "updated_at")
+SELECT "id", "user_id", "name", "uid", "archived_at", "created_at", "updated_at" FROM "categories";
+
+DROP TABLE "categories";
+ALTER TABLE "categories_old" RENAME TO "categories";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_categories_user_lower_name"
+ON "categories" ("user_id", lower("name"));
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_categories_user_uid"
+ON "categories" ("user_id", "uid");
+
+-- The rebuild ran with foreign keys off; fail it rather than commit a
+-- dangling reference. See 20261017000300.
+CREATE TEMP TABLE "foreign_key_violations" (
+  "count" INTEGER NOT NULL CHECK ("count" = 0)
+);
+INSERT INTO "foreign_key_violations" SELECT count(*) FROM pragma_foreign_key_check;
+DROP TABLE "foreign_key_violations";
+
+PRAGMA user_version = 33;
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Amounts stay integer minor units; "currency" says which ISO 4217 currency
+-- they are in. Every row so far was entered in dollars, which is also the home
+-- currency each existing account starts with.
+ALTER TABLE "users" ADD COLUMN "home_currency" TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE "expenses" ADD COLUMN "currency" TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE "recurrent_expenses" ADD COLUMN "currency" TEXT NOT NULL DEFAULT 'USD';
+
+-- One unit of "base_currency" is worth "rate" units of "quote_currency" on
+-- "date", a UTC midnight. The table is shared by every account and filled by
+-- the import_exchange_rates task; totals read a pair in either direction.
+CREATE TABLE IF NOT EXISTS "exchange_rates" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "base_currency" TEXT NOT NULL,
+  "quote_currency" TEXT NOT NULL,
+  "date" INTEGER NOT NULL,
+  "rate" REAL NOT NULL CHECK ("rate" > 0),
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  UNIQUE ("base_currency", "quote_currency", "date")
+);
+
+PRAGMA user_version = 35;
+
+-- +goose Down
+DROP TABLE IF EXISTS "exchange_rates";
+
+ALTER TABLE "recurrent_expenses" DROP COLUMN "currency";
+ALTER TABLE "expenses" DROP COLUMN "currency";
+ALTER TABLE "users" DROP COLUMN "home_currency";
+
+PRAGMA user_version = 34;
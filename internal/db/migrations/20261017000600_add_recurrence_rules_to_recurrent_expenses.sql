@@ -0,0 +1,22 @@
+-- +goose Up
+-- "period" becomes the interval of a rule: every "period" days, weeks, months
+-- or years. "anchor_day" is the day of the month monthly and yearly rules land
+-- on, clamped to the month's last day. Existing rows were all monthly copies
+-- dated the 1st, which the defaults keep. "starts_at" and "ends_at" are
+-- optional UTC midnights bounding the occurrences.
+ALTER TABLE "recurrent_expenses" ADD COLUMN "frequency" TEXT NOT NULL DEFAULT 'monthly'
+  CHECK ("frequency" IN ('daily', 'weekly', 'monthly', 'yearly'));
+ALTER TABLE "recurrent_expenses" ADD COLUMN "anchor_day" INTEGER NOT NULL DEFAULT 1
+  CHECK ("anchor_day" BETWEEN 1 AND 31);
+ALTER TABLE "recurrent_expenses" ADD COLUMN "starts_at" INTEGER;
+ALTER TABLE "recurrent_expenses" ADD COLUMN "ends_at" INTEGER;
+
+PRAGMA user_version = 36;
+
+-- +goose Down
+ALTER TABLE "recurrent_expenses" DROP COLUMN "ends_at";
+ALTER TABLE "recurrent_expenses" DROP COLUMN "starts_at";
+ALTER TABLE "recurrent_expenses" DROP COLUMN "anchor_day";
+ALTER TABLE "recurrent_expenses" DROP COLUMN "frequency";
+
+PRAGMA user_version = 35;
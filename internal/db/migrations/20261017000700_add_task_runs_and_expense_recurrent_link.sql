@@ -0,0 +1,96 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- One row per run of a scheduled task. "finished_at" stays NULL while the run
+-- is going, or for good if the process died mid-run. Each row the run could
+-- not process gets a "task_run_failures" row with the error it hit.
+-- "recurrent_expense_id" on expenses points an expense at the recurrent
+-- expense that generated it; deleting the recurrent expense keeps the expense.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "task_runs" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "name" TEXT NOT NULL,
+  "started_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "finished_at" INTEGER,
+  "succeeded_count" INTEGER NOT NULL DEFAULT 0,
+  "failed_count" INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS "idx_task_runs_name_started_at"
+ON "task_runs" ("name", "started_at");
+
+CREATE TABLE IF NOT EXISTS "task_run_failures" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "task_run_id" INTEGER NOT NULL REFERENCES "task_runs"("id") ON DELETE CASCADE,
+  "subject_id" INTEGER NOT NULL,
+  "error" TEXT NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+);
+
+CREATE INDEX IF NOT EXISTS "idx_task_run_failures_task_run_id"
+ON "task_run_failures" ("task_run_id");
+
+ALTER TABLE "expenses" ADD COLUMN "recurrent_expense_id" INTEGER
+REFERENCES "recurrent_expenses"("id") ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS "idx_expenses_recurrent_expense_id"
+ON "expenses" ("recurrent_expense_id") WHERE "recurrent_expense_id" IS NOT NULL;
+
+PRAGMA user_version = 37;
+
+COMMIT;
+
+-- +goose Down
+-- SQLite cannot drop a column that is part of a foreign key, so expenses is
+-- rebuilt without it, with foreign keys off while the old table is dropped.
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+DROP TABLE IF EXISTS "task_run_failures";
+DROP TABLE IF EXISTS "task_runs";
+
+CREATE TABLE "expenses_old" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "category_id" INTEGER NOT NULL REFERENCES "categories"("id") ON DELETE CASCADE,
+  "description" TEXT NOT NULL,
+  "amount" INTEGER NOT NULL,
+  "date" INTEGER NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "deleted_at" INTEGER,
+  "currency" TEXT NOT NULL DEFAULT 'USD'
+);
+
+INSERT INTO "expenses_old"
+  ("id", "user_id", "category_id", "description", "amount", "date", "created_at", "updated_at",
+   "deleted_at", "currency")
+SELECT "id", "user_id", "category_id", "description", "amount", "date", "created_at", "updated_at",
+       "deleted_at", "currency"
+FROM "expenses";
+
+DROP TABLE "expenses";
+ALTER TABLE "expenses_old" RENAME TO "expenses";
+
+CREATE INDEX IF NOT EXISTS "idx_expenses_category_id" ON "expenses" ("category_id");
+CREATE INDEX IF NOT EXISTS "idx_expenses_user_date"
+ON "expenses" ("user_id", "date");
+CREATE INDEX IF NOT EXISTS "idx_expenses_user_created_at"
+ON "expenses" ("user_id", "created_at");
+CREATE INDEX IF NOT EXISTS "idx_expenses_trash"
+ON "expenses" ("user_id", "deleted_at") WHERE "deleted_at" IS NOT NULL;
+
+-- The rebuild ran with foreign keys off; fail it rather than commit a
+-- dangling reference. See 20261017000300.
+CREATE TEMP TABLE "foreign_key_violations" (
+  "count" INTEGER NOT NULL CHECK ("count" = 0)
+);
+INSERT INTO "foreign_key_violations" SELECT count(*) FROM pragma_foreign_key_check;
+DROP TABLE "foreign_key_violations";
+
+PRAGMA user_version = 36;
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- An estimate recurrent expense does not post its copies: each due occurrence
+-- becomes a "pending_expenses" row until the user confirms it with the actual
+-- amount, which creates the expense, or skips it. The occurrence is counted
+-- when the pending row is created, so a skipped one still uses up the limit.
+ALTER TABLE "recurrent_expenses" ADD COLUMN "is_estimate" INTEGER NOT NULL DEFAULT 0
+  CHECK ("is_estimate" IN (0, 1));
+
+CREATE TABLE IF NOT EXISTS "pending_expenses" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "recurrent_expense_id" INTEGER NOT NULL REFERENCES "recurrent_expenses"("id") ON DELETE CASCADE,
+  "date" INTEGER NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  UNIQUE ("recurrent_expense_id", "date")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_pending_expenses_user_id_date"
+ON "pending_expenses" ("user_id", "date");
+
+PRAGMA user_version = 38;
+
+-- +goose Down
+DROP TABLE IF EXISTS "pending_expenses";
+
+ALTER TABLE "recurrent_expenses" DROP COLUMN "is_estimate";
+
+PRAGMA user_version = 37;
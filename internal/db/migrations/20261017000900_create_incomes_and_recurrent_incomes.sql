@@ -0,0 +1,55 @@
+-- +goose Up
+-- Incomes mirror expenses without a category: "source" is free text such as
+-- an employer or a client. Recurrent incomes carry the same rule columns as
+-- recurrent expenses and are copied by their own task, linking each generated
+-- income back the way expenses link to their recurrent expense.
+CREATE TABLE IF NOT EXISTS "recurrent_incomes" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "source" TEXT NOT NULL,
+  "amount" INTEGER NOT NULL,
+  "currency" TEXT NOT NULL DEFAULT 'USD',
+  "period" INTEGER NOT NULL DEFAULT 1,
+  "frequency" TEXT NOT NULL DEFAULT 'monthly'
+    CHECK ("frequency" IN ('daily', 'weekly', 'monthly', 'yearly')),
+  "anchor_day" INTEGER NOT NULL DEFAULT 1
+    CHECK ("anchor_day" BETWEEN 1 AND 31),
+  "starts_at" INTEGER,
+  "ends_at" INTEGER,
+  "occurrence_limit" INTEGER NOT NULL DEFAULT 0,
+  "occurrence_count" INTEGER NOT NULL DEFAULT 0,
+  "last_copy_created_at" INTEGER,
+  "archived_at" INTEGER,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+);
+
+CREATE INDEX IF NOT EXISTS "idx_recurrent_incomes_user_id"
+ON "recurrent_incomes" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "incomes" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "source" TEXT NOT NULL,
+  "amount" INTEGER NOT NULL,
+  "currency" TEXT NOT NULL DEFAULT 'USD',
+  "date" INTEGER NOT NULL,
+  "recurrent_income_id" INTEGER REFERENCES "recurrent_incomes"("id") ON DELETE SET NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+);
+
+CREATE INDEX IF NOT EXISTS "idx_incomes_user_id_date"
+ON "incomes" ("user_id", "date");
+
+CREATE INDEX IF NOT EXISTS "idx_incomes_recurrent_income_id"
+ON "incomes" ("recurrent_income_id") WHERE "recurrent_income_id" IS NOT NULL;
+
+PRAGMA user_version = 39;
+
+-- +goose Down
+DROP TABLE IF EXISTS "incomes";
+
+DROP TABLE IF EXISTS "recurrent_incomes";
+
+PRAGMA user_version = 38;
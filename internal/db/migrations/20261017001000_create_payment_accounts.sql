@@ -0,0 +1,132 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- A payment account is where money is paid from: a checking account, a credit
+-- card or a cash wallet. Expenses and recurrent expenses may name one; deleting
+-- the account keeps them, unassigned. "reconciled_through" is the last day the
+-- user checked the account's ledger against a statement.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "payment_accounts" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "name" TEXT NOT NULL,
+  "kind" TEXT NOT NULL DEFAULT 'checking'
+    CHECK ("kind" IN ('checking', 'credit_card', 'cash')),
+  "currency" TEXT NOT NULL DEFAULT 'USD',
+  "opening_balance" INTEGER NOT NULL DEFAULT 0,
+  "reconciled_through" INTEGER,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_payment_accounts_user_lower_name"
+ON "payment_accounts" ("user_id", lower("name"));
+
+ALTER TABLE "expenses" ADD COLUMN "payment_account_id" INTEGER
+REFERENCES "payment_accounts"("id") ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS "idx_expenses_payment_account_id_date"
+ON "expenses" ("payment_account_id", "date") WHERE "payment_account_id" IS NOT NULL;
+
+ALTER TABLE "recurrent_expenses" ADD COLUMN "payment_account_id" INTEGER
+REFERENCES "payment_accounts"("id") ON DELETE SET NULL;
+
+PRAGMA user_version = 40;
+
+COMMIT;
+
+-- +goose Down
+-- SQLite cannot drop a column that is part of a foreign key, so both tables
+-- are rebuilt without it, with foreign keys off while the old ones are dropped.
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+CREATE TABLE "expenses_old" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "category_id" INTEGER NOT NULL REFERENCES "categories"("id") ON DELETE CASCADE,
+  "description" TEXT NOT NULL,
+  "amount" INTEGER NOT NULL,
+  "date" INTEGER NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "deleted_at" INTEGER,
+  "currency" TEXT NOT NULL DEFAULT 'USD',
+  "recurrent_expense_id" INTEGER REFERENCES "recurrent_expenses"("id") ON DELETE SET NULL
+);
+
+INSERT INTO "expenses_old"
+  ("id", "user_id", "category_id", "description", "amount", "date", "created_at", "updated_at",
+   "deleted_at", "currency", "recurrent_expense_id")
+SELECT "id", "user_id", "category_id", "description", "amount", "date", "created_at", "updated_at",
+       "deleted_at", "currency", "recurrent_expense_id"
+FROM "expenses";
+
+DROP TABLE "expenses";
+ALTER TABLE "expenses_old" RENAME TO "expenses";
+
+CREATE INDEX IF NOT EXISTS "idx_expenses_category_id" ON "expenses" ("category_id");
+CREATE INDEX IF NOT EXISTS "idx_expenses_user_date"
+ON "expenses" ("user_id", "date");
+CREATE INDEX IF NOT EXISTS "idx_expenses_user_created_at"
+ON "expenses" ("user_id", "created_at");
+CREATE INDEX IF NOT EXISTS "idx_expenses_trash"
+ON "expenses" ("user_id", "deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "idx_expenses_recurrent_expense_id"
+ON "expenses" ("recurrent_expense_id") WHERE "recurrent_expense_id" IS NOT NULL;
+
+CREATE TABLE "recurrent_expenses_old" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "category_id" INTEGER NOT NULL REFERENCES "categories"("id") ON DELETE CASCADE,
+  "description" TEXT NOT NULL,
+  "amount" INTEGER NOT NULL,
+  "period" INTEGER NOT NULL DEFAULT 1,
+  "last_copy_created_at" INTEGER,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "occurrence_limit" INTEGER NOT NULL DEFAULT 0,
+  "occurrence_count" INTEGER NOT NULL DEFAULT 0,
+  "archived_at" INTEGER,
+  "currency" TEXT NOT NULL DEFAULT 'USD',
+  "frequency" TEXT NOT NULL DEFAULT 'monthly'
+    CHECK ("frequency" IN ('daily', 'weekly', 'monthly', 'yearly')),
+  "anchor_day" INTEGER NOT NULL DEFAULT 1
+    CHECK ("anchor_day" BETWEEN 1 AND 31),
+  "starts_at" INTEGER,
+  "ends_at" INTEGER,
+  "is_estimate" INTEGER NOT NULL DEFAULT 0
+    CHECK ("is_estimate" IN (0, 1))
+);
+
+INSERT INTO "recurrent_expenses_old"
+  ("id", "user_id", "category_id", "description", "amount", "period", "last_copy_created_at",
+   "created_at", "updated_at", "occurrence_limit", "occurrence_count", "archived_at", "currency",
+   "frequency", "anchor_day", "starts_at", "ends_at", "is_estimate")
+SELECT "id", "user_id", "category_id", "description", "amount", "period", "last_copy_created_at",
+       "created_at", "updated_at", "occurrence_limit", "occurrence_count", "archived_at", "currency",
+       "frequency", "anchor_day", "starts_at", "ends_at", "is_estimate"
+FROM "recurrent_expenses";
+
+DROP TABLE "recurrent_expenses";
+ALTER TABLE "recurrent_expenses_old" RENAME TO "recurrent_expenses";
+
+CREATE INDEX IF NOT EXISTS "idx_recurrent_expenses_user_id" ON "recurrent_expenses" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_recurrent_expenses_category_id" ON "recurrent_expenses" ("category_id");
+
+DROP TABLE IF EXISTS "payment_accounts";
+
+-- The rebuild ran with foreign keys off; fail it rather than commit a
+-- dangling reference. See 20261017000300.
+CREATE TEMP TABLE "foreign_key_violations" (
+  "count" INTEGER NOT NULL CHECK ("count" = 0)
+);
+INSERT INTO "foreign_key_violations" SELECT count(*) FROM pragma_foreign_key_check;
+DROP TABLE "foreign_key_violations";
+
+PRAGMA user_version = 39;
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
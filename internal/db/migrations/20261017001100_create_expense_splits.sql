@@ -0,0 +1,29 @@
+-- +goose Up
+-- A split line files part of an expense under its own category, so a single
+-- receipt can cover several. An expense without splits counts wholly toward
+-- its own category; one with splits counts only through them, and their
+-- amounts add up to the expense's. "user_id" mirrors the expense's owner so
+-- split tags resolve through the same user-scoped joins as other taggables.
+CREATE TABLE IF NOT EXISTS "expense_splits" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "expense_id" INTEGER NOT NULL REFERENCES "expenses"("id") ON DELETE CASCADE,
+  "category_id" INTEGER NOT NULL REFERENCES "categories"("id") ON DELETE CASCADE,
+  "amount" INTEGER NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  CHECK ("amount" > 0)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_expense_splits_expense_id" ON "expense_splits" ("expense_id");
+
+CREATE INDEX IF NOT EXISTS "idx_expense_splits_category_id" ON "expense_splits" ("category_id");
+
+PRAGMA user_version = 41;
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_expense_splits_category_id";
+DROP INDEX IF EXISTS "idx_expense_splits_expense_id";
+DROP TABLE IF EXISTS "expense_splits";
+
+PRAGMA user_version = 40;
@@ -0,0 +1,97 @@
+-- +goose Up
+-- Budgets become versioned: each row is the amount in force from
+-- "effective_from" (a 'YYYY-MM' month) until the next version of the same
+-- budget, so raising a budget leaves the months before it measured against the
+-- old amount. A version of zero ends the budget from that month on. A NULL
+-- "category_id" is the whole-month budget across every category. With
+-- "rollover" set, what a month leaves unspent (or overspent) carries into the
+-- next month's amount.
+--
+-- Nothing references "expense_budgets", so the table is rebuilt in place.
+-- Existing rows become the first version, dated on the month they were last
+-- saved.
+CREATE TABLE "expense_budgets_new" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "category_id" INTEGER REFERENCES "categories"("id") ON DELETE CASCADE,
+  "amount" INTEGER NOT NULL,
+  "rollover" INTEGER NOT NULL DEFAULT 0,
+  "effective_from" TEXT NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  CHECK ("amount" >= 0),
+  CHECK ("rollover" IN (0, 1))
+);
+
+INSERT INTO "expense_budgets_new"
+  ("id", "user_id", "category_id", "amount", "rollover", "effective_from", "created_at", "updated_at")
+SELECT "id", "user_id", "category_id", "amount", 0, strftime('%Y-%m', "updated_at", 'unixepoch'),
+       "created_at", "updated_at"
+FROM "expense_budgets";
+
+DROP TABLE "expense_budgets";
+ALTER TABLE "expense_budgets_new" RENAME TO "expense_budgets";
+
+-- COALESCE folds the whole-month budget onto one key; a plain NULL would never
+-- conflict with itself. The upserts name the same expression.
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_expense_budgets_user_category_month"
+ON "expense_budgets" ("user_id", COALESCE("category_id", 0), "effective_from");
+
+CREATE INDEX IF NOT EXISTS "idx_expense_budgets_category_id" ON "expense_budgets" ("category_id");
+
+-- In-app notifications. "dedupe_key" names the event a notification is about,
+-- so raising the same one twice is a no-op rather than a second row.
+CREATE TABLE IF NOT EXISTS "notifications" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "kind" TEXT NOT NULL,
+  "message" TEXT NOT NULL,
+  "link" TEXT NOT NULL DEFAULT '',
+  "dedupe_key" TEXT NOT NULL,
+  "read_at" INTEGER,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_notifications_user_dedupe_key"
+ON "notifications" ("user_id", "dedupe_key");
+
+CREATE INDEX IF NOT EXISTS "idx_notifications_user_unread"
+ON "notifications" ("user_id", "created_at") WHERE "read_at" IS NULL;
+
+PRAGMA user_version = 42;
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_notifications_user_unread";
+DROP INDEX IF EXISTS "uq_notifications_user_dedupe_key";
+DROP TABLE IF EXISTS "notifications";
+
+-- Only the category budgets survive, each at the version in force now.
+CREATE TABLE "expense_budgets_old" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "category_id" INTEGER NOT NULL REFERENCES "categories"("id") ON DELETE CASCADE,
+  "amount" INTEGER NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now'))
+);
+
+INSERT INTO "expense_budgets_old" ("id", "user_id", "category_id", "amount", "created_at", "updated_at")
+SELECT "b"."id", "b"."user_id", "b"."category_id", "b"."amount", "b"."created_at", "b"."updated_at"
+FROM "expense_budgets" AS "b"
+WHERE "b"."category_id" IS NOT NULL AND "b"."amount" > 0 AND "b"."id" = (
+  SELECT "v"."id" FROM "expense_budgets" AS "v"
+  WHERE "v"."category_id" = "b"."category_id"
+  ORDER BY "v"."effective_from" <= strftime('%Y-%m','now') DESC,
+    CASE WHEN "v"."effective_from" <= strftime('%Y-%m','now') THEN "v"."effective_from" END DESC,
+    "v"."effective_from"
+  LIMIT 1
+);
+
+DROP TABLE "expense_budgets";
+ALTER TABLE "expense_budgets_old" RENAME TO "expense_budgets";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_expense_budgets_user_category"
+ON "expense_budgets" ("user_id", "category_id");
+
+PRAGMA user_version = 41;
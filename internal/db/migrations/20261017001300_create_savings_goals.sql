@@ -0,0 +1,45 @@
+-- +goose Up
+-- A savings goal is an amount to put aside by a target date, in the owner's
+-- home currency. Contributions record each time money was set aside toward
+-- it; the goal's progress is their sum. Deleting a goal deletes them.
+CREATE TABLE IF NOT EXISTS "savings_goals" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "name" TEXT NOT NULL,
+  "target_amount" INTEGER NOT NULL,
+  "target_date" INTEGER NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  CHECK ("target_amount" > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_savings_goals_user_lower_name"
+ON "savings_goals" ("user_id", lower("name"));
+
+CREATE TABLE IF NOT EXISTS "savings_contributions" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "savings_goal_id" INTEGER NOT NULL REFERENCES "savings_goals"("id") ON DELETE CASCADE,
+  "amount" INTEGER NOT NULL,
+  "date" INTEGER NOT NULL,
+  "note" TEXT NOT NULL DEFAULT '',
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  CHECK ("amount" > 0)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_savings_contributions_goal_date"
+ON "savings_contributions" ("savings_goal_id", "date");
+
+CREATE INDEX IF NOT EXISTS "idx_savings_contributions_user_id" ON "savings_contributions" ("user_id");
+
+PRAGMA user_version = 43;
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_savings_contributions_user_id";
+DROP INDEX IF EXISTS "idx_savings_contributions_goal_date";
+DROP TABLE IF EXISTS "savings_contributions";
+DROP INDEX IF EXISTS "uq_savings_goals_user_lower_name";
+DROP TABLE IF EXISTS "savings_goals";
+
+PRAGMA user_version = 42;
@@ -0,0 +1,49 @@
+-- +goose Up
+-- A recipe is a dish made from foods in the library. Each ingredient is a
+-- quantity in grams of one food; the recipe's macros are not stored but summed
+-- from its foods on every read, so editing a food changes every recipe that
+-- uses it. "servings" is how many portions the whole recipe yields.
+CREATE TABLE IF NOT EXISTS "recipes" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "name" TEXT NOT NULL,
+  "servings" REAL NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  CHECK ("servings" > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_recipes_user_lower_name"
+ON "recipes" ("user_id", lower("name"));
+
+-- A food appears once per recipe. Purging a food from the trash drops it from
+-- the recipes that used it.
+CREATE TABLE IF NOT EXISTS "recipe_ingredients" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "recipe_id" INTEGER NOT NULL REFERENCES "recipes"("id") ON DELETE CASCADE,
+  "food_id" INTEGER NOT NULL REFERENCES "foods"("id") ON DELETE CASCADE,
+  "quantity_g" REAL NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  CHECK ("quantity_g" > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_recipe_ingredients_recipe_food"
+ON "recipe_ingredients" ("recipe_id", "food_id");
+
+CREATE INDEX IF NOT EXISTS "idx_recipe_ingredients_food_id" ON "recipe_ingredients" ("food_id");
+
+CREATE INDEX IF NOT EXISTS "idx_recipe_ingredients_user_id" ON "recipe_ingredients" ("user_id");
+
+PRAGMA user_version = 44;
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_recipe_ingredients_user_id";
+DROP INDEX IF EXISTS "idx_recipe_ingredients_food_id";
+DROP INDEX IF EXISTS "uq_recipe_ingredients_recipe_food";
+DROP TABLE IF EXISTS "recipe_ingredients";
+DROP INDEX IF EXISTS "uq_recipes_user_lower_name";
+DROP TABLE IF EXISTS "recipes";
+
+PRAGMA user_version = 43;
@@ -0,0 +1,43 @@
+-- +goose Up
+-- A food's nutrients are given for "base_amount" of "base_unit", which keeps
+-- the old per-100 g meaning as the default. "density_g_per_ml" is optional and
+-- only needed to convert between a mass and a volume.
+ALTER TABLE "foods" ADD COLUMN "base_amount" REAL NOT NULL DEFAULT 100
+  CHECK ("base_amount" > 0);
+ALTER TABLE "foods" ADD COLUMN "base_unit" TEXT NOT NULL DEFAULT 'g'
+  CHECK ("base_unit" IN ('g', 'kg', 'oz', 'lb', 'ml', 'l'));
+ALTER TABLE "foods" ADD COLUMN "density_g_per_ml" REAL
+  CHECK ("density_g_per_ml" IS NULL OR "density_g_per_ml" > 0);
+
+-- A serving names an amount of a food, "1 cup" = 185 g. It goes with its food
+-- when the food is purged from the trash.
+CREATE TABLE IF NOT EXISTS "food_servings" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "food_id" INTEGER NOT NULL REFERENCES "foods"("id") ON DELETE CASCADE,
+  "name" TEXT NOT NULL,
+  "amount" REAL NOT NULL,
+  "unit" TEXT NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  CHECK ("amount" > 0),
+  CHECK ("unit" IN ('g', 'kg', 'oz', 'lb', 'ml', 'l'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_food_servings_food_lower_name"
+ON "food_servings" ("food_id", lower("name"));
+
+CREATE INDEX IF NOT EXISTS "idx_food_servings_user_id" ON "food_servings" ("user_id");
+
+PRAGMA user_version = 45;
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_food_servings_user_id";
+DROP INDEX IF EXISTS "uq_food_servings_food_lower_name";
+DROP TABLE IF EXISTS "food_servings";
+
+ALTER TABLE "foods" DROP COLUMN "density_g_per_ml";
+ALTER TABLE "foods" DROP COLUMN "base_unit";
+ALTER TABLE "foods" DROP COLUMN "base_amount";
+
+PRAGMA user_version = 44;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- "reference_foods" is a shared, read-only catalogue loaded from nutrition
+-- database dumps by the import_reference_foods task. Rows belong to no user;
+-- copying one into the food library creates an ordinary "foods" row. Values
+-- are per 100 g, as both sources publish them. A row is keyed by its source
+-- and the source's own id, so re-importing a newer dump updates it in place.
+CREATE TABLE IF NOT EXISTS "reference_foods" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "source" TEXT NOT NULL,
+  "source_id" TEXT NOT NULL,
+  "name" TEXT NOT NULL,
+  "brand" TEXT NOT NULL DEFAULT '',
+  "kcal" REAL NOT NULL DEFAULT 0,
+  "protein_g" REAL NOT NULL DEFAULT 0,
+  "carbs_g" REAL NOT NULL DEFAULT 0,
+  "fat_g" REAL NOT NULL DEFAULT 0,
+  "fiber_g" REAL NOT NULL DEFAULT 0,
+  "sodium_g" REAL NOT NULL DEFAULT 0,
+  "saturated_fat_g" REAL NOT NULL DEFAULT 0,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  CHECK ("source" IN ('usda', 'off'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_reference_foods_source_id"
+ON "reference_foods" ("source", "source_id");
+
+-- Searches order by name, so a walk of this index can stop at the first page
+-- of matches instead of sorting every row that matched.
+CREATE INDEX IF NOT EXISTS "idx_reference_foods_name"
+ON "reference_foods" ("name" COLLATE NOCASE);
+
+PRAGMA user_version = 46;
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_reference_foods_name";
+DROP INDEX IF EXISTS "uq_reference_foods_source_id";
+DROP TABLE IF EXISTS "reference_foods";
+
+PRAGMA user_version = 45;
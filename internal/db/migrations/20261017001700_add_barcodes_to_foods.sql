@@ -0,0 +1,24 @@
+-- +goose Up
+-- Barcodes are stored as digits only, normalized to GTIN-13 where the code
+-- allows it, so a UPC-A typed by hand and an EAN-13 from a scanner match.
+-- Trashed foods keep their barcode, so the index is not unique and restoring
+-- one never fails over it; logic refuses a second live food with the code.
+ALTER TABLE "foods" ADD COLUMN "barcode" TEXT;
+ALTER TABLE "reference_foods" ADD COLUMN "barcode" TEXT;
+
+CREATE INDEX IF NOT EXISTS "idx_foods_user_barcode"
+ON "foods" ("user_id", "barcode") WHERE "barcode" IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS "idx_reference_foods_barcode"
+ON "reference_foods" ("barcode") WHERE "barcode" IS NOT NULL;
+
+PRAGMA user_version = 47;
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_reference_foods_barcode";
+DROP INDEX IF EXISTS "idx_foods_user_barcode";
+
+ALTER TABLE "reference_foods" DROP COLUMN "barcode";
+ALTER TABLE "foods" DROP COLUMN "barcode";
+
+PRAGMA user_version = 46;
@@ -0,0 +1,51 @@
+-- +goose Up
+-- A meal template is a named list of foods eaten together, logged in one go.
+-- Unlike a recipe it is not one dish: applying it writes a macro entry per
+-- food, measured from the food as it stands then. "meal_type" is the meal the
+-- entries go under unless the user picks another when applying it.
+CREATE TABLE IF NOT EXISTS "meal_templates" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "name" TEXT NOT NULL,
+  "meal_type" TEXT NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  CHECK ("meal_type" IN ('breakfast', 'lunch', 'dinner', 'snack', 'other'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_meal_templates_user_lower_name"
+ON "meal_templates" ("user_id", lower("name"));
+
+-- An item is a quantity of one food in any unit the food converts to. A food
+-- appears once per template, and purging it from the trash drops it here too.
+CREATE TABLE IF NOT EXISTS "meal_template_items" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "meal_template_id" INTEGER NOT NULL REFERENCES "meal_templates"("id") ON DELETE CASCADE,
+  "food_id" INTEGER NOT NULL REFERENCES "foods"("id") ON DELETE CASCADE,
+  "quantity" REAL NOT NULL,
+  "unit" TEXT NOT NULL,
+  "created_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  "updated_at" INTEGER NOT NULL DEFAULT (strftime('%s','now')),
+  CHECK ("quantity" > 0),
+  CHECK ("unit" IN ('g', 'kg', 'oz', 'lb', 'ml', 'l'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_meal_template_items_template_food"
+ON "meal_template_items" ("meal_template_id", "food_id");
+
+CREATE INDEX IF NOT EXISTS "idx_meal_template_items_food_id" ON "meal_template_items" ("food_id");
+
+CREATE INDEX IF NOT EXISTS "idx_meal_template_items_user_id" ON "meal_template_items" ("user_id");
+
+PRAGMA user_version = 48;
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_meal_template_items_user_id";
+DROP INDEX IF EXISTS "idx_meal_template_items_food_id";
+DROP INDEX IF EXISTS "uq_meal_template_items_template_food";
+DROP TABLE IF EXISTS "meal_template_items";
+DROP INDEX IF EXISTS "uq_meal_templates_user_lower_name";
+DROP TABLE IF EXISTS "meal_templates";
+
+PRAGMA user_version = 47;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- The import_reference_foods task stages a USDA FoodData Central download in
+-- these tables and joins its files here, since the branded set runs to
+-- millions of foods and would not fit in memory. They are emptied before and
+-- after every import, so they hold nothing between runs. "id" keeps the order
+-- food.csv listed the foods in, so the catalogue is written in that order.
+CREATE TABLE IF NOT EXISTS "usda_import_foods" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "fdc_id" TEXT NOT NULL,
+  "name" TEXT NOT NULL,
+  "brand" TEXT NOT NULL DEFAULT '',
+  "barcode" TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uq_usda_import_foods_fdc_id"
+ON "usda_import_foods" ("fdc_id");
+
+-- Only the nutrients the food library tracks are staged.
+CREATE TABLE IF NOT EXISTS "usda_import_nutrients" (
+  "fdc_id" TEXT NOT NULL,
+  "nutrient_id" INTEGER NOT NULL,
+  "amount" REAL NOT NULL,
+  PRIMARY KEY ("fdc_id", "nutrient_id")
+) WITHOUT ROWID;
+
+PRAGMA user_version = 49;
+
+-- +goose Down
+DROP TABLE IF EXISTS "usda_import_nutrients";
+DROP INDEX IF EXISTS "uq_usda_import_foods_fdc_id";
+DROP TABLE IF EXISTS "usda_import_foods";
+
+PRAGMA user_version = 48;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Catalogue searches match names and brands by their start, so each is a
+-- range walk of an index. Brand matches come back sorted by name within the
+-- brand, which this index holds too, so the walk stops at the search limit.
+CREATE INDEX IF NOT EXISTS "idx_reference_foods_brand_name"
+ON "reference_foods" ("brand" COLLATE NOCASE, "name" COLLATE NOCASE);
+
+PRAGMA user_version = 50;
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_reference_foods_brand_name";
+
+PRAGMA user_version = 49;
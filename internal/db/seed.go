@@ -3,7 +3,6 @@ package db
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/ad9311/ninete/internal/logic"
@@ -41,11 +40,6 @@ func RunSeeds() error {
 			seedUsers,
 			true,
 		},
-		{
-			"category",
-			seedCategories,
-			false,
-		},
 		{
 			"admin",
 			func(s *logic.Store) error { return seedAdmin(s, queries) },
@@ -108,43 +102,6 @@ func seedUsers(s *logic.Store) error {
 	return nil
 }
 
-func CategoryNames() []string {
-	return []string{
-		"Housing",
-		"Transportation",
-		"Groceries",
-		"Food Delivery",
-		"Healthcare",
-		"Personal Care",
-		"Entertainment",
-		"Shopping",
-		"Online Shopping",
-		"Travel",
-		"Financial",
-		"Pets",
-		"Taxes",
-		"Subscriptions",
-		"Other",
-		"Utilities",
-		"Restaurants",
-		"Hobbies",
-	}
-}
-
-func seedCategories(s *logic.Store) error {
-	ctx, cancel := newContext()
-	defer cancel()
-
-	for _, c := range CategoryNames() {
-		_, err := s.CreateCategory(ctx, c)
-		if err != nil && !strings.Contains(err.Error(), "UNIQUE constraint failed: categories.") {
-			return err
-		}
-	}
-
-	return nil
-}
-
 func seedAdmin(s *logic.Store, q repo.Queries) error {
 	ctx, cancel := newContext()
 	defer cancel()
@@ -176,6 +133,11 @@ func seedExpenses(s *logic.Store, userID int) error {
 		return nil
 	}
 
+	categories, err := s.FindCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	descriptions := []string{
 		"Rent", "Electricity bill", "Grocery run", "Netflix", "Spotify",
 		"Gym membership", "Restaurant dinner", "Taxi ride", "Coffee shop", "Flight ticket",
@@ -207,7 +169,7 @@ func seedExpenses(s *logic.Store, userID int) error {
 
 		if _, err := s.CreateExpense(ctx, userID, logic.ExpenseParams{
 			ExpenseBaseParams: logic.ExpenseBaseParams{
-				CategoryID:  (i % len(CategoryNames())) + 1,
+				CategoryID:  categories[i%len(categories)].ID,
 				Description: descriptions[i%len(descriptions)],
 				Amount:      amounts[i%len(amounts)],
 			},
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// APIPrefix is the path every JSON route lives under. The middleware in serve
+// checks it to answer with JSON instead of a redirect or an HTML error page.
+const APIPrefix = "/api/"
+
+// IsAPIRequest reports whether r targets the JSON API.
+func IsAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, APIPrefix)
+}
+
+type apiFieldError struct {
+	Field string `json:"field"`
+	Tag   string `json:"tag"`
+}
+
+// apiErrorBody is the body of every non-2xx API response. Fields is only set
+// when the request failed validation.
+type apiErrorBody struct {
+	Error  string          `json:"error"`
+	Fields []apiFieldError `json:"fields,omitempty"`
+}
+
+type apiPagination struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	TotalCount int `json:"total_count"`
+	TotalPages int `json:"total_pages"`
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.app.Logger.Errorf("failed to write JSON response: %v", err)
+	}
+}
+
+// writeJSONErr answers with err as an apiErrorBody. Validation failures become
+// a 422 listing each rejected field; status is used for everything else.
+func (h *Handler) writeJSONErr(w http.ResponseWriter, status int, err error) {
+	var valErr *logic.ValidationError
+	if errors.As(err, &valErr) {
+		fields := make([]apiFieldError, 0, len(valErr.Fields))
+		for _, f := range valErr.Fields {
+			fields = append(fields, apiFieldError{Field: apiFieldName(f.Field), Tag: f.Tag})
+		}
+
+		h.writeJSON(w, http.StatusUnprocessableEntity, apiErrorBody{
+			Error:  logic.ErrValidationFailed.Error(),
+			Fields: fields,
+		})
+
+		return
+	}
+
+	h.writeJSON(w, status, apiErrorBody{Error: err.Error()})
+}
+
+// writeJSONInternalErr logs err and answers with a generic 500, so a driver
+// message never reaches an API client.
+func (h *Handler) writeJSONInternalErr(w http.ResponseWriter, err error) {
+	h.app.Logger.Errorf("api request failed: %v", err)
+	h.writeJSON(w, http.StatusInternalServerError, apiErrorBody{Error: ErrAPIInternal.Error()})
+}
+
+// writeJSONQueryErr answers a failed listing query. A rejected sort, filter or
+// page value is the client's mistake; anything else is ours.
+func (h *Handler) writeJSONQueryErr(w http.ResponseWriter, err error) {
+	if errors.Is(err, repo.ErrInvalidField) ||
+		errors.Is(err, repo.ErrInvalidSortOrder) ||
+		errors.Is(err, repo.ErrInvalidPagination) {
+		h.writeJSONErr(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	h.writeJSONInternalErr(w, err)
+}
+
+// decodeJSONBody reads exactly one JSON object into dst. Unknown fields are
+// rejected so a misspelled key fails loudly instead of saving a zero value.
+func decodeJSONBody(r *http.Request, dst any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return fmt.Errorf("%w: %w", ErrParseJSON, err)
+	}
+
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: body must hold a single JSON object", ErrParseJSON)
+	}
+
+	return nil
+}
+
+// apiFieldName turns a validator field name such as "CategoryID" into the
+// snake_case key the API accepts, "category_id".
+func apiFieldName(field string) string {
+	runes := []rune(field)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (nextLower && unicode.IsUpper(runes[i-1])) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
+
+func (h *Handler) APINotFound(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, http.StatusNotFound, apiErrorBody{Error: ErrAPINotFound.Error()})
+}
+
+func (h *Handler) APIMethodNotAllowed(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, http.StatusMethodNotAllowed, apiErrorBody{Error: ErrNotAllowed.Error()})
+}
+
+// APIUnauthorized answers an unauthenticated API request. Scripts cannot follow
+// the login redirect the HTML routes use, so they get a 401 instead.
+func (h *Handler) APIUnauthorized(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, http.StatusUnauthorized, apiErrorBody{Error: ErrAPIUnauthorized.Error()})
+}
+
+// APIInternalError logs err and answers with a generic 500, for middleware
+// outside this package that fails before a handler runs.
+func (h *Handler) APIInternalError(w http.ResponseWriter, _ *http.Request, err error) {
+	h.writeJSONInternalErr(w, err)
+}
+
+// APICSRFFailure answers an API write that nosurf rejected.
+func (h *Handler) APICSRFFailure(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, http.StatusForbidden, apiErrorBody{Error: ErrAPICSRF.Error()})
+}
+
+// APITokenFrom returns the token that authenticated r, if any. Requests made
+// with a session cookie have none.
+func APITokenFrom(r *http.Request) (*repo.APIToken, bool) {
+	token, ok := r.Context().Value(KeyAPIToken).(*repo.APIToken)
+
+	return token, ok && token != nil
+}
+
+// APIScope limits token-authenticated requests to the areas their token grants.
+// Safe methods need read access and everything else needs write. A signed-in
+// browser session is not scoped, so those requests pass straight through.
+func (h *Handler) APIScope(area string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := APITokenFrom(r)
+			if ok && !logic.APITokenAllows(*token, area, !isSafeMethod(r.Method)) {
+				h.writeJSON(w, http.StatusForbidden, apiErrorBody{Error: ErrAPIScope.Error()})
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -7,15 +7,33 @@ const (
 	KeyCurrentUser      = ContextKey("userID")
 	KeyTemplateData     = ContextKey("templateData")
 	KeyCSPNonce         = ContextKey("cspNonce")
+	KeyCategory         = ContextKey("categoryID")
 	KeyExpense          = ContextKey("expenseID")
 	KeyRecurrentExpense = ContextKey("recurrentExpenseID")
+	KeyIncome           = ContextKey("incomeID")
+	KeyRecurrentIncome  = ContextKey("recurrentIncomeID")
+	KeyPaymentAccount   = ContextKey("paymentAccountID")
+	KeySavingsGoal      = ContextKey("savingsGoalID")
 	KeyMacroEntry       = ContextKey("macroEntryID")
 	KeyFood             = ContextKey("foodID")
+	KeyRecipe           = ContextKey("recipeID")
+	KeyMealTemplate     = ContextKey("mealTemplateID")
 	KeyMoodEntry        = ContextKey("moodEntryID")
+	KeyAPIToken         = ContextKey("apiToken")
 
 	// Session keys used in the session store for auth state.
 	SessionIsUserSignedIn = "isUserSignedIn"
 	SessionUserID         = "userID"
+
+	// SessionNewAPIToken carries a freshly minted token across the redirect
+	// back to /account. It is popped on the first read, so the raw value is
+	// shown exactly once.
+	SessionNewAPIToken = "newAPIToken"
+
+	// SessionFlash and SessionFlashUndo carry the message and undo action shown
+	// after a delete. Both are popped by the page the delete redirects to.
+	SessionFlash     = "flash"
+	SessionFlashUndo = "flashUndo"
 )
 
 // -------------------------------------------------------------- //
@@ -27,12 +45,18 @@ const (
 	// Account templates.
 	AccountIndex TemplateName = "account/index"
 
+	// Category templates.
+	CategoriesIndex TemplateName = "categories/index"
+
 	// Dashboard templates.
 	DashboardIndex TemplateName = "dashboard/index"
 
 	// Exports templates.
 	ExportsIndex TemplateName = "exports/index"
 
+	// Trash templates.
+	TrashIndex TemplateName = "trash/index"
+
 	// Auth templates.
 	LoginIndex    TemplateName = "login/index"
 	RegisterIndex TemplateName = "register/index"
@@ -45,6 +69,10 @@ const (
 	ExpensesStats   TemplateName = "expenses/stats"
 	ExpensesBudgets TemplateName = "expenses/budgets"
 
+	ExpensesImport        TemplateName = "expenses/import"
+	ExpensesImportPreview TemplateName = "expenses/import_preview"
+	ExpensesDuplicates    TemplateName = "expenses/duplicates"
+
 	// Recurrent expense templates.
 	RecurrentExpensesIndex TemplateName = "recurrent_expenses/index"
 	RecurrentExpensesNew   TemplateName = "recurrent_expenses/new"
@@ -52,6 +80,34 @@ const (
 	RecurrentExpensesShow  TemplateName = "recurrent_expenses/show"
 
 	RecurrentExpensesArchived TemplateName = "recurrent_expenses/archived"
+	RecurrentExpensesForecast TemplateName = "recurrent_expenses/forecast"
+
+	// Income templates.
+	IncomesIndex TemplateName = "incomes/index"
+	IncomesNew   TemplateName = "incomes/new"
+	IncomesEdit  TemplateName = "incomes/edit"
+	IncomesShow  TemplateName = "incomes/show"
+
+	// Recurrent income templates.
+	RecurrentIncomesIndex TemplateName = "recurrent_incomes/index"
+	RecurrentIncomesNew   TemplateName = "recurrent_incomes/new"
+	RecurrentIncomesEdit  TemplateName = "recurrent_incomes/edit"
+	RecurrentIncomesShow  TemplateName = "recurrent_incomes/show"
+
+	// Payment account templates.
+	PaymentAccountsIndex TemplateName = "payment_accounts/index"
+	PaymentAccountsNew   TemplateName = "payment_accounts/new"
+	PaymentAccountsEdit  TemplateName = "payment_accounts/edit"
+	PaymentAccountsShow  TemplateName = "payment_accounts/show"
+
+	// Savings goal templates.
+	SavingsGoalsIndex TemplateName = "savings_goals/index"
+	SavingsGoalsNew   TemplateName = "savings_goals/new"
+	SavingsGoalsEdit  TemplateName = "savings_goals/edit"
+	SavingsGoalsShow  TemplateName = "savings_goals/show"
+
+	// Cash flow templates.
+	CashFlowIndex TemplateName = "cash_flow/index"
 
 	// Macro templates.
 	MacrosIndex TemplateName = "macros/index"
@@ -67,6 +123,18 @@ const (
 	FoodsEdit  TemplateName = "foods/edit"
 	FoodsShow  TemplateName = "foods/show"
 
+	// Recipe templates.
+	RecipesIndex TemplateName = "recipes/index"
+	RecipesNew   TemplateName = "recipes/new"
+	RecipesEdit  TemplateName = "recipes/edit"
+	RecipesShow  TemplateName = "recipes/show"
+
+	// Meal template templates.
+	MealTemplatesIndex TemplateName = "meal_templates/index"
+	MealTemplatesNew   TemplateName = "meal_templates/new"
+	MealTemplatesEdit  TemplateName = "meal_templates/edit"
+	MealTemplatesShow  TemplateName = "meal_templates/show"
+
 	// Mood entry templates.
 	MoodEntriesIndex TemplateName = "mood_entries/index"
 	MoodEntriesNew   TemplateName = "mood_entries/new"
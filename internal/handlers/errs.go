@@ -23,4 +23,17 @@ var (
 	ErrUnknownDateRange    = errors.New("unknown date range")
 	ErrBudgetCategoryField = errors.New("invalid budget field name")
 	ErrSearchTermTooLong   = errors.New("search terms must be at most 50 characters")
+
+	ErrParseJSON       = errors.New("failed to parse JSON body")
+	ErrAPINotFound     = errors.New("resource not found")
+	ErrAPIUnauthorized = errors.New("authentication required")
+	ErrAPICSRF         = errors.New("missing or invalid CSRF token")
+	ErrAPIInternal     = errors.New("internal server error")
+	ErrUnknownCategory = errors.New("unknown category")
+	ErrAPIScope        = errors.New("api token does not grant access to this area")
+
+	ErrUnknownPaymentAccount = errors.New("unknown payment account")
+
+	ErrImportNoFile  = errors.New("choose a CSV file to import")
+	ErrRestoreNoFile = errors.New("choose a backup archive to restore")
 )
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -52,6 +53,8 @@ type expenseSearch struct {
 	// DateField is the column the date bounds apply to: searchFieldBilled or
 	// searchFieldCreated.
 	DateField string
+	// PaymentAccountID narrows the list to one account; 0 means any.
+	PaymentAccountID int
 
 	fromUnix int64
 	toUnix   int64
@@ -73,6 +76,12 @@ func parseExpenseSearch(r *http.Request) (expenseSearch, error) {
 		explicitRange: q.Get("date_range") != "",
 	}
 
+	// Like category_id, an unreadable account id is ignored rather than
+	// rejected, so a stale link falls back to every account.
+	if paymentAccountID, _ := strconv.Atoi(q.Get("payment_account_id")); paymentAccountID > 0 {
+		search.PaymentAccountID = paymentAccountID
+	}
+
 	if utf8.RuneCountInString(search.Query) > searchTermMax ||
 		utf8.RuneCountInString(search.Tag) > searchTermMax {
 		return search, ErrSearchTermTooLong
@@ -175,5 +184,9 @@ func (s expenseSearch) apply(opts *repo.QueryOptions, userID int) {
 		fields = append(fields, repo.ExpenseTagFilter(userID, s.Tag))
 	}
 
+	if s.PaymentAccountID > 0 {
+		fields = append(fields, repo.FilterField{Name: "payment_account_id", Value: s.PaymentAccountID, Operator: "="})
+	}
+
 	opts.Filters.FilterFields = fields
 }
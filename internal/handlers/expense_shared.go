@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/ad9311/ninete/internal/logic"
 	"github.com/ad9311/ninete/internal/prog"
 	"github.com/ad9311/ninete/internal/repo"
 )
@@ -15,6 +16,9 @@ type expenseFormBase struct {
 	CategoryID  int
 	Description string
 	Amount      uint64
+	Currency    string
+	// PaymentAccountID is nil when the form picked no account.
+	PaymentAccountID *int
 }
 
 type dateRange struct {
@@ -149,27 +153,32 @@ func parseExpenseFormBase(r *http.Request) (expenseFormBase, error) {
 		return base, err
 	}
 
+	if value := r.FormValue("payment_account_id"); value != "" {
+		paymentAccountID, err := prog.ParseID(value, "Payment Account ID")
+		if err != nil {
+			return base, err
+		}
+		base.PaymentAccountID = &paymentAccountID
+	}
+
 	base.CategoryID = categoryID
 	base.Description = r.FormValue("description")
 	base.Amount = amount
+	base.Currency = r.FormValue("currency")
 
 	return base, nil
 }
 
 func (h *Handler) findCategories(
 	ctx context.Context,
+	userID int,
 ) ([]repo.Category, map[int]string, error) {
-	categories, err := h.store.FindCategories(ctx)
+	categories, err := h.store.FindCategories(ctx, userID)
 	if err != nil {
 		return categories, nil, err
 	}
 
-	categoryNameByID := make(map[int]string, len(categories))
-	for _, category := range categories {
-		categoryNameByID[category.ID] = category.Name
-	}
-
-	return categories, categoryNameByID, nil
+	return categories, logic.CategoryPathNames(categories), nil
 }
 
 func (h *Handler) findCategoriesOrErr(
@@ -177,7 +186,7 @@ func (h *Handler) findCategoriesOrErr(
 	r *http.Request,
 	tmpl TemplateName,
 ) ([]repo.Category, map[int]string, bool) {
-	categories, nameByID, err := h.findCategories(r.Context())
+	categories, nameByID, err := h.findCategories(r.Context(), getCurrentUser(r).ID)
 	if err != nil {
 		h.renderErr(w, r, http.StatusInternalServerError, tmpl, err)
 
@@ -195,6 +204,27 @@ func categoryNameOrUnknown(nameByID map[int]string, categoryID int) string {
 	return "Unknown"
 }
 
+// setPaymentAccountsData loads the accounts the expense forms and filters offer.
+// A failed load is only logged: the form then carries the current account in a
+// hidden input, so saving it does not drop the link.
+func (h *Handler) setPaymentAccountsData(r *http.Request, data map[string]any) {
+	accounts, err := h.store.FindPaymentAccounts(r.Context(), getCurrentUser(r).ID)
+	if err != nil {
+		h.app.Logger.Errorf("failed to load payment accounts: %v", err)
+	}
+
+	data["paymentAccounts"] = accounts
+}
+
+// setPaymentAccountIDData flattens the form's account to 0 for none, as the
+// category picker does with ParentID, so the template can compare it.
+func setPaymentAccountIDData(data map[string]any, paymentAccountID *int) {
+	data["paymentAccountID"] = 0
+	if paymentAccountID != nil {
+		data["paymentAccountID"] = *paymentAccountID
+	}
+}
+
 func setResourceFormData(
 	data map[string]any,
 	categories []repo.Category,
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+
+	"github.com/ad9311/ninete/internal/logic"
 )
 
 func (h *Handler) GetAccount(w http.ResponseWriter, r *http.Request) {
@@ -16,7 +19,18 @@ func (h *Handler) GetAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tokens, err := h.store.FindAPITokens(ctx, user.ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, AccountIndex, err)
+
+		return
+	}
+
 	data["counts"] = counts
+	data["apiTokens"] = toAPITokenRows(tokens)
+	data["newAPIToken"] = h.session.PopString(ctx, SessionNewAPIToken)
+	h.setFlashData(ctx, data)
+	setAPITokenFormData(data, logic.APITokenParams{})
 
 	h.render(w, http.StatusOK, AccountIndex, data)
 }
@@ -31,6 +45,8 @@ func (h *Handler) PostAccountDeleteExpenses(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	h.flashTrashedAll(ctx, "your expenses")
+
 	http.Redirect(w, r, "/account", http.StatusSeeOther)
 }
 
@@ -47,6 +63,58 @@ func (h *Handler) PostAccountDeleteRecurrentExpenses(w http.ResponseWriter, r *h
 	http.Redirect(w, r, "/account", http.StatusSeeOther)
 }
 
+func (h *Handler) PostAccountDeleteIncomes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	if err := h.store.DeleteAllIncomes(ctx, user.ID); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func (h *Handler) PostAccountDeleteRecurrentIncomes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	if err := h.store.DeleteAllRecurrentIncomes(ctx, user.ID); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func (h *Handler) PostAccountDeletePaymentAccounts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	if err := h.store.DeleteAllPaymentAccounts(ctx, user.ID); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func (h *Handler) PostAccountDeleteSavingsGoals(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	if err := h.store.DeleteAllSavingsGoals(ctx, user.ID); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
 func (h *Handler) PostAccountDeleteMacroEntries(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user := getCurrentUser(r)
@@ -57,6 +125,8 @@ func (h *Handler) PostAccountDeleteMacroEntries(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	h.flashTrashedAll(ctx, "your macro entries")
+
 	http.Redirect(w, r, "/account", http.StatusSeeOther)
 }
 
@@ -96,6 +166,34 @@ func (h *Handler) PostAccountDeleteFoods(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	h.flashTrashedAll(ctx, "your foods")
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func (h *Handler) PostAccountDeleteRecipes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	if err := h.store.DeleteAllRecipes(ctx, user.ID); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func (h *Handler) PostAccountDeleteMealTemplates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	if err := h.store.DeleteAllMealTemplates(ctx, user.ID); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
 	http.Redirect(w, r, "/account", http.StatusSeeOther)
 }
 
@@ -109,6 +207,8 @@ func (h *Handler) PostAccountDeleteMoodEntries(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	h.flashTrashedAll(ctx, "your mood entries")
+
 	http.Redirect(w, r, "/account", http.StatusSeeOther)
 }
 
@@ -135,5 +235,38 @@ func (h *Handler) PostAccountDeleteAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.flashTrashedAll(ctx, "your expenses, macro entries, foods and mood entries")
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+// PostAccountHomeCurrency changes the currency stats, budgets and the
+// dashboard are totalled in. Expenses keep the currency they were entered in.
+func (h *Handler) PostAccountHomeCurrency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	if err := r.ParseForm(); err != nil {
+		h.renderHomeCurrencyErr(w, r, fmt.Errorf("%w: %w", ErrParseForm, err))
+
+		return
+	}
+
+	if err := h.store.SetHomeCurrency(ctx, user.ID, r.FormValue("home_currency")); err != nil {
+		h.renderHomeCurrencyErr(w, r, err)
+
+		return
+	}
+
 	http.Redirect(w, r, "/account", http.StatusSeeOther)
 }
+
+func (h *Handler) renderHomeCurrencyErr(w http.ResponseWriter, r *http.Request, err error) {
+	data := h.tmplData(r)
+
+	h.setAccountErrData(r, data)
+	data["homeCurrencyError"] = err.Error()
+	setAPITokenFormData(data, logic.APITokenParams{})
+
+	h.render(w, http.StatusBadRequest, AccountIndex, data)
+}
@@ -3,6 +3,7 @@ package handlers_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/ad9311/ninete/internal/spec"
@@ -32,7 +33,7 @@ func TestGetAccount(t *testing.T) {
 			name: "should_render_account_page_with_counts",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "acct_page_1", "acct_page_1@example.com", "acct_password_1")
-				category := s.CreateCategory(t, "acct page category")
+				category := s.CreateCategory(t, user.ID, "acct page category")
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "acct page expense", 500, 1735689600))
 				cookies := s.AuthCookies(t, "acct_page_1@example.com", "acct_password_1")
 
@@ -59,7 +60,7 @@ func TestPostAccountDeleteExpenses(t *testing.T) {
 	handler := s.WrappedHandler()
 
 	user := s.CreateAuthUser(t, "acct_del_exp", "acct_del_exp@example.com", "acct_password_1")
-	category := s.CreateCategory(t, "acct del exp category")
+	category := s.CreateCategory(t, user.ID, "acct del exp category")
 	s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "expense to wipe", 500, 1735689600))
 	cookies := s.AuthCookies(t, "acct_del_exp@example.com", "acct_password_1")
 	csrfToken, cookies := s.CSRFFrom(t, "/account", cookies)
@@ -82,11 +83,12 @@ func TestPostAccountDeleteAll(t *testing.T) {
 
 	user := s.CreateAuthUser(t, "acct_del_all", "acct_del_all@example.com", "acct_password_1")
 	otherUser := s.CreateAuthUser(t, "acct_del_all_other", "acct_del_all_other@example.com", "acct_password_2")
-	category := s.CreateCategory(t, "acct del all category")
+	category := s.CreateCategory(t, user.ID, "acct del all category")
+	otherCategory := s.CreateCategory(t, otherUser.ID, "acct del all category")
 
 	s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "mine", 500, 1735689600))
 	s.CreateMoodEntry(t, user.ID, newMoodEntryParamsH("Happy", "mine", 1735689600, []string{"acct_wipe_tag"}))
-	s.CreateExpense(t, otherUser.ID, newExpenseParams(category.ID, "theirs", 600, 1735689600))
+	s.CreateExpense(t, otherUser.ID, newExpenseParams(otherCategory.ID, "theirs", 600, 1735689600))
 
 	cookies := s.AuthCookies(t, "acct_del_all@example.com", "acct_password_1")
 	csrfToken, cookies := s.CSRFFrom(t, "/account", cookies)
@@ -109,3 +111,29 @@ func TestPostAccountDeleteAll(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 1, otherCount)
 }
+
+func TestPostAccountHomeCurrency(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	user := s.CreateAuthUser(t, "acct_home_cur", "acct_home_cur@example.com", "acct_password_1")
+	cookies := s.AuthCookies(t, "acct_home_cur@example.com", "acct_password_1")
+	csrfToken, cookies := s.CSRFFrom(t, "/account", cookies)
+
+	form := url.Values{"home_currency": {"xyz"}}
+	req := spec.NewPostRequest("/account/home-currency", form.Encode(), cookies, csrfToken)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	form = url.Values{"home_currency": {"eur"}}
+	req = spec.NewPostRequest("/account/home-currency", form.Encode(), cookies, csrfToken)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	require.Equal(t, "/account", rec.Header().Get("Location"))
+
+	found, err := s.Store.FindUser(t.Context(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, "EUR", found.HomeCurrency)
+}
@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// apiExpense is the JSON shape of an expense. Amounts are cents and dates are
+// Unix seconds, the same units the forms submit. Currency is an ISO 4217 code.
+type apiExpense struct {
+	ID          int      `json:"id"`
+	CategoryID  int      `json:"category_id"`
+	Description string   `json:"description"`
+	Amount      uint64   `json:"amount"`
+	Currency    string   `json:"currency"`
+	Date        int64    `json:"date"`
+	CreatedAt   int64    `json:"created_at"`
+	UpdatedAt   int64    `json:"updated_at"`
+	Tags        []string `json:"tags"`
+	// PaymentAccountID is null when the expense has no account.
+	PaymentAccountID *int `json:"payment_account_id"`
+	// Splits is empty unless the expense is split across categories.
+	Splits []apiExpenseSplit `json:"splits"`
+}
+
+type apiExpenseSplit struct {
+	CategoryID int      `json:"category_id"`
+	Amount     uint64   `json:"amount"`
+	Tags       []string `json:"tags"`
+}
+
+// apiExpenseBody is what create and update accept. Update replaces the whole
+// expense, tags included, exactly like the edit form. An omitted currency
+// means the home currency on create and leaves it unchanged on update. An
+// omitted payment_account_id means no account, on update too, and omitted
+// splits leave the expense unsplit.
+type apiExpenseBody struct {
+	CategoryID       int               `json:"category_id"`
+	Description      string            `json:"description"`
+	Amount           uint64            `json:"amount"`
+	Currency         string            `json:"currency"`
+	Date             int64             `json:"date"`
+	Tags             []string          `json:"tags"`
+	PaymentAccountID *int              `json:"payment_account_id"`
+	Splits           []apiExpenseSplit `json:"splits"`
+}
+
+// ----------------------------------------------------------------------------- //
+// Context Middleware
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) APIExpenseContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user := getCurrentUser(r)
+
+		id, err := prog.ParseID(chi.URLParam(r, "id"), "Expense")
+		if err != nil {
+			h.APINotFound(w, r)
+
+			return
+		}
+
+		expense, err := h.store.FindExpense(ctx, id, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			h.APINotFound(w, r)
+
+			return
+		}
+		if err != nil {
+			h.writeJSONInternalErr(w, err)
+
+			return
+		}
+
+		ctx = context.WithValue(ctx, KeyExpense, &expense)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+// GetAPIExpenses lists expenses with the query parameters the expenses page
+// accepts: category_id, payment_account_id, date_range, q, tag, date_from,
+// date_to, date_field, sort_field, sort_order, page and per_page.
+func (h *Handler) GetAPIExpenses(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	search, err := parseExpenseSearch(r)
+	if err != nil {
+		h.writeJSONErr(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	opts := userScopedQueryOpts(r, user.ID, repo.Sorting{Field: "date", Order: "DESC"}, "this_month")
+	search.apply(&opts, user.ID)
+
+	totalCount, err := h.store.CountExpenses(ctx, opts.Filters)
+	if err != nil {
+		h.writeJSONQueryErr(w, err)
+
+		return
+	}
+
+	expenses, err := h.store.FindExpenses(ctx, opts)
+	if err != nil {
+		h.writeJSONQueryErr(w, err)
+
+		return
+	}
+
+	expenseIDs := make([]int, 0, len(expenses))
+	for _, expense := range expenses {
+		expenseIDs = append(expenseIDs, expense.ID)
+	}
+
+	tagRows, err := h.store.FindTagRows(ctx, repo.TaggableTypeExpense, "expenses", expenseIDs, user.ID)
+	if err != nil {
+		h.writeJSONInternalErr(w, err)
+
+		return
+	}
+	tagNames := repo.TagNamesByTargetID(tagRows)
+
+	splitsByID, err := h.store.FindExpenseSplitsByExpenseID(ctx, expenseIDs, user.ID)
+	if err != nil {
+		h.writeJSONInternalErr(w, err)
+
+		return
+	}
+
+	rows := make([]apiExpense, 0, len(expenses))
+	for _, expense := range expenses {
+		rows = append(rows, toAPIExpense(expense, tagNames[expense.ID], splitsByID[expense.ID]))
+	}
+
+	pagination := newPaginationData(r, opts, totalCount, "this_month")
+
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"expenses": rows,
+		"pagination": apiPagination{
+			Page:       pagination.CurrentPage,
+			PerPage:    pagination.PerPage,
+			TotalCount: pagination.TotalCount,
+			TotalPages: pagination.TotalPages,
+		},
+	})
+}
+
+func (h *Handler) GetAPIExpense(w http.ResponseWriter, r *http.Request) {
+	expense := getExpense(r)
+
+	h.writeAPIExpense(w, r, http.StatusOK, *expense)
+}
+
+func (h *Handler) PostAPIExpenses(w http.ResponseWriter, r *http.Request) {
+	params, err := parseAPIExpenseBody(r)
+	if err != nil {
+		h.writeJSONErr(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	expense, err := h.store.CreateExpense(r.Context(), getCurrentUser(r).ID, params)
+	if err != nil {
+		h.writeAPIExpenseSaveErr(w, err)
+
+		return
+	}
+
+	h.writeAPIExpense(w, r, http.StatusCreated, expense)
+}
+
+func (h *Handler) PutAPIExpense(w http.ResponseWriter, r *http.Request) {
+	expense := getExpense(r)
+
+	params, err := parseAPIExpenseBody(r)
+	if err != nil {
+		h.writeJSONErr(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	updated, err := h.store.UpdateExpense(r.Context(), expense.ID, getCurrentUser(r).ID, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.APINotFound(w, r)
+
+			return
+		}
+		h.writeAPIExpenseSaveErr(w, err)
+
+		return
+	}
+
+	h.writeAPIExpense(w, r, http.StatusOK, updated)
+}
+
+func (h *Handler) DeleteAPIExpense(w http.ResponseWriter, r *http.Request) {
+	expense := getExpense(r)
+
+	_, err := h.store.DeleteExpense(r.Context(), expense.ID, getCurrentUser(r).ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.APINotFound(w, r)
+
+			return
+		}
+		h.writeJSONInternalErr(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+func parseAPIExpenseBody(r *http.Request) (logic.ExpenseParams, error) {
+	var body apiExpenseBody
+	if err := decodeJSONBody(r, &body); err != nil {
+		return logic.ExpenseParams{}, err
+	}
+
+	splits := make([]logic.ExpenseSplitParams, 0, len(body.Splits))
+	for _, split := range body.Splits {
+		splits = append(splits, logic.ExpenseSplitParams{
+			CategoryID: split.CategoryID,
+			Amount:     split.Amount,
+			Tags:       split.Tags,
+		})
+	}
+
+	return logic.ExpenseParams{
+		ExpenseBaseParams: logic.ExpenseBaseParams{
+			CategoryID:  body.CategoryID,
+			Description: body.Description,
+			Amount:      body.Amount,
+			Currency:    body.Currency,
+
+			PaymentAccountID: body.PaymentAccountID,
+		},
+		Date:   body.Date,
+		Tags:   body.Tags,
+		Splits: splits,
+	}, nil
+}
+
+// writeAPIExpense answers with expense, its tags and its splits as stored, so
+// the client sees the normalized tag names rather than what it sent.
+func (h *Handler) writeAPIExpense(w http.ResponseWriter, r *http.Request, status int, expense repo.Expense) {
+	ctx := r.Context()
+	userID := getCurrentUser(r).ID
+
+	tags, err := h.store.FindExpenseTags(ctx, expense.ID, userID)
+	if err != nil {
+		h.writeJSONInternalErr(w, err)
+
+		return
+	}
+
+	splits, err := h.store.FindExpenseSplits(ctx, expense.ID, userID)
+	if err != nil {
+		h.writeJSONInternalErr(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, status, toAPIExpense(expense, logic.ExtractTagNames(tags), splits))
+}
+
+func (h *Handler) writeAPIExpenseSaveErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, logic.ErrValidationFailed):
+		h.writeJSONErr(w, http.StatusUnprocessableEntity, err)
+	case errors.Is(err, logic.ErrUnknownCategory):
+		// Covers both a made-up id and another account's category, so a client
+		// cannot tell them apart.
+		h.writeJSONErr(w, http.StatusUnprocessableEntity, ErrUnknownCategory)
+	case errors.Is(err, logic.ErrUnknownPaymentAccount):
+		h.writeJSONErr(w, http.StatusUnprocessableEntity, ErrUnknownPaymentAccount)
+	case errors.Is(err, logic.ErrExpenseSplitsTotal):
+		h.writeJSONErr(w, http.StatusUnprocessableEntity, err)
+	default:
+		h.writeJSONInternalErr(w, err)
+	}
+}
+
+func toAPIExpense(expense repo.Expense, tags []string, splits []logic.ExpenseSplit) apiExpense {
+	if tags == nil {
+		tags = []string{}
+	}
+
+	apiSplits := make([]apiExpenseSplit, 0, len(splits))
+	for _, split := range splits {
+		splitTags := split.Tags
+		if splitTags == nil {
+			splitTags = []string{}
+		}
+		apiSplits = append(apiSplits, apiExpenseSplit{
+			CategoryID: split.CategoryID,
+			Amount:     split.Amount,
+			Tags:       splitTags,
+		})
+	}
+
+	return apiExpense{
+		ID:          expense.ID,
+		CategoryID:  expense.CategoryID,
+		Description: expense.Description,
+		Amount:      expense.Amount,
+		Currency:    expense.Currency,
+		Date:        expense.Date,
+		CreatedAt:   expense.CreatedAt,
+		UpdatedAt:   expense.UpdatedAt,
+		Tags:        tags,
+		Splits:      apiSplits,
+
+		PaymentAccountID: expense.PaymentAccountID,
+	}
+}
@@ -0,0 +1,446 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+type apiExpenseResponse struct {
+	ID          int      `json:"id"`
+	CategoryID  int      `json:"category_id"`
+	Description string   `json:"description"`
+	Amount      uint64   `json:"amount"`
+	Currency    string   `json:"currency"`
+	Date        int64    `json:"date"`
+	Tags        []string `json:"tags"`
+}
+
+type apiExpensesResponse struct {
+	Expenses   []apiExpenseResponse `json:"expenses"`
+	Pagination struct {
+		Page       int `json:"page"`
+		PerPage    int `json:"per_page"`
+		TotalCount int `json:"total_count"`
+		TotalPages int `json:"total_pages"`
+	} `json:"pagination"`
+}
+
+type apiErrorResponse struct {
+	Error  string `json:"error"`
+	Fields []struct {
+		Field string `json:"field"`
+		Tag   string `json:"tag"`
+	} `json:"fields"`
+}
+
+func decodeAPIBody(t *testing.T, rec *httptest.ResponseRecorder, dst any) {
+	t.Helper()
+
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), dst), rec.Body.String())
+}
+
+func TestGetAPIExpenses(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_return_401_json_when_unauthenticated",
+			fn: func(t *testing.T) {
+				req := spec.NewGetRequest("/api/v1/expenses", nil)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+				var body apiErrorResponse
+				decodeAPIBody(t, rec, &body)
+				require.NotEmpty(t, body.Error)
+			},
+		},
+		{
+			name: "should_list_only_own_expenses_with_tags_and_pagination",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_list_1", "api_list_1@example.com", "api_password_1")
+				other := s.CreateAuthUser(t, "api_list_2", "api_list_2@example.com", "api_password_2")
+				category := s.CreateCategory(t, user.ID, "api_list_cat_1")
+				otherCategory := s.CreateCategory(t, other.ID, "api_list_cat_1")
+				params := newExpenseParams(category.ID, "Own api expense", 1250, time.Now().Unix())
+				params.Tags = []string{"work"}
+				own := s.CreateExpense(t, user.ID, params)
+				s.CreateExpense(t, other.ID, newExpenseParams(otherCategory.ID, "Other api expense", 900, time.Now().Unix()))
+				cookies := s.AuthCookies(t, "api_list_1@example.com", "api_password_1")
+
+				req := spec.NewGetRequest("/api/v1/expenses", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var body apiExpensesResponse
+				decodeAPIBody(t, rec, &body)
+				require.Len(t, body.Expenses, 1)
+				require.Equal(t, own.ID, body.Expenses[0].ID)
+				require.Equal(t, uint64(1250), body.Expenses[0].Amount)
+				require.Equal(t, []string{"work"}, body.Expenses[0].Tags)
+				require.Equal(t, 1, body.Pagination.Page)
+				require.Equal(t, 1, body.Pagination.TotalCount)
+				require.Equal(t, 1, body.Pagination.TotalPages)
+			},
+		},
+		{
+			name: "should_apply_the_expense_search_filters",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_list_3", "api_list_3@example.com", "api_password_3")
+				category := s.CreateCategory(t, user.ID, "api_list_cat_2")
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Coffee beans", 800, time.Now().Unix()))
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Train ticket", 300, time.Now().Unix()))
+				cookies := s.AuthCookies(t, "api_list_3@example.com", "api_password_3")
+
+				req := spec.NewGetRequest("/api/v1/expenses?q=coffee", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var body apiExpensesResponse
+				decodeAPIBody(t, rec, &body)
+				require.Len(t, body.Expenses, 1)
+				require.Equal(t, "Coffee beans", body.Expenses[0].Description)
+			},
+		},
+		{
+			name: "should_return_400_for_an_invalid_sort_field",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "api_list_4", "api_list_4@example.com", "api_password_4")
+				cookies := s.AuthCookies(t, "api_list_4@example.com", "api_password_4")
+
+				req := spec.NewGetRequest("/api/v1/expenses?sort_field=password&sort_order=ASC", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "should_return_400_for_an_invalid_search_date",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "api_list_5", "api_list_5@example.com", "api_password_5")
+				cookies := s.AuthCookies(t, "api_list_5@example.com", "api_password_5")
+
+				req := spec.NewGetRequest("/api/v1/expenses?date_from=yesterday", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "should_return_json_404_for_an_unknown_api_route",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "api_list_6", "api_list_6@example.com", "api_password_6")
+				cookies := s.AuthCookies(t, "api_list_6@example.com", "api_password_6")
+
+				req := spec.NewGetRequest("/api/v1/nothing-here", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+
+				var body apiErrorResponse
+				decodeAPIBody(t, rec, &body)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestGetAPIExpense(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_return_the_expense",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_show_1", "api_show_1@example.com", "api_password_1")
+				category := s.CreateCategory(t, user.ID, "api_show_cat_1")
+				expense := s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Shown api expense", 700, 1_700_000_000))
+				cookies := s.AuthCookies(t, "api_show_1@example.com", "api_password_1")
+
+				req := spec.NewGetRequest(fmt.Sprintf("/api/v1/expenses/%d", expense.ID), cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var body apiExpenseResponse
+				decodeAPIBody(t, rec, &body)
+				require.Equal(t, expense.ID, body.ID)
+				require.Equal(t, category.ID, body.CategoryID)
+				require.Equal(t, int64(1_700_000_000), body.Date)
+				require.Equal(t, []string{}, body.Tags)
+			},
+		},
+		{
+			name: "should_return_404_for_another_users_expense",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "api_show_2", "api_show_2@example.com", "api_password_2")
+				other := s.CreateAuthUser(t, "api_show_3", "api_show_3@example.com", "api_password_3")
+				category := s.CreateCategory(t, other.ID, "api_show_cat_2")
+				expense := s.CreateExpense(t, other.ID, newExpenseParams(category.ID, "Hidden api expense", 700, time.Now().Unix()))
+				cookies := s.AuthCookies(t, "api_show_2@example.com", "api_password_2")
+
+				req := spec.NewGetRequest(fmt.Sprintf("/api/v1/expenses/%d", expense.ID), cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestPostAPIExpenses(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_expense_and_return_201",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_create_1", "api_create_1@example.com", "api_password_1")
+				category := s.CreateCategory(t, user.ID, "api_create_cat_1")
+				cookies := s.AuthCookies(t, "api_create_1@example.com", "api_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+
+				body := fmt.Sprintf(
+					`{"category_id":%d,"description":"Api lunch","amount":1500,"date":1700000000,"tags":["Food"]}`,
+					category.ID,
+				)
+				req := spec.NewJSONRequest(http.MethodPost, "/api/v1/expenses", body, cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+				var created apiExpenseResponse
+				decodeAPIBody(t, rec, &created)
+				require.Positive(t, created.ID)
+				require.Equal(t, "Api lunch", created.Description)
+				require.Equal(t, uint64(1500), created.Amount)
+				require.Equal(t, "USD", created.Currency)
+				require.Len(t, created.Tags, 1)
+			},
+		},
+		{
+			name: "should_store_the_given_currency",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_create_fx", "api_create_fx@example.com", "api_password_fx")
+				category := s.CreateCategory(t, user.ID, "api_create_cat_fx")
+				cookies := s.AuthCookies(t, "api_create_fx@example.com", "api_password_fx")
+				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+
+				body := fmt.Sprintf(
+					`{"category_id":%d,"description":"Api croissant","amount":450,"currency":"eur","date":1700000000}`,
+					category.ID,
+				)
+				req := spec.NewJSONRequest(http.MethodPost, "/api/v1/expenses", body, cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+				var created apiExpenseResponse
+				decodeAPIBody(t, rec, &created)
+				require.Equal(t, "EUR", created.Currency)
+
+				body = fmt.Sprintf(
+					`{"category_id":%d,"description":"Api croissant","amount":450,"currency":"EURO","date":1700000000}`,
+					category.ID,
+				)
+				req = spec.NewJSONRequest(http.MethodPost, "/api/v1/expenses", body, cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			},
+		},
+		{
+			name: "should_return_422_with_field_errors_for_invalid_expense",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_create_2", "api_create_2@example.com", "api_password_2")
+				category := s.CreateCategory(t, user.ID, "api_create_cat_2")
+				cookies := s.AuthCookies(t, "api_create_2@example.com", "api_password_2")
+				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+
+				body := fmt.Sprintf(`{"category_id":%d,"description":"ab","amount":0,"date":1700000000}`, category.ID)
+				req := spec.NewJSONRequest(http.MethodPost, "/api/v1/expenses", body, cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+				var errBody apiErrorResponse
+				decodeAPIBody(t, rec, &errBody)
+				fields := map[string]string{}
+				for _, f := range errBody.Fields {
+					fields[f.Field] = f.Tag
+				}
+				require.Equal(t, "min", fields["description"])
+				require.Equal(t, "required", fields["amount"])
+			},
+		},
+		{
+			name: "should_return_422_for_an_unknown_category",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "api_create_3", "api_create_3@example.com", "api_password_3")
+				cookies := s.AuthCookies(t, "api_create_3@example.com", "api_password_3")
+				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+
+				body := `{"category_id":999999,"description":"Orphan","amount":100,"date":1700000000}`
+				req := spec.NewJSONRequest(http.MethodPost, "/api/v1/expenses", body, cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			},
+		},
+		{
+			name: "should_return_400_for_unknown_json_fields",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "api_create_4", "api_create_4@example.com", "api_password_4")
+				cookies := s.AuthCookies(t, "api_create_4@example.com", "api_password_4")
+				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+
+				body := `{"descripton":"Typo","amount":100}`
+				req := spec.NewJSONRequest(http.MethodPost, "/api/v1/expenses", body, cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "should_return_403_json_without_csrf_token",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_create_5", "api_create_5@example.com", "api_password_5")
+				category := s.CreateCategory(t, user.ID, "api_create_cat_5")
+				cookies := s.AuthCookies(t, "api_create_5@example.com", "api_password_5")
+
+				body := fmt.Sprintf(`{"category_id":%d,"description":"No token","amount":100,"date":1700000000}`, category.ID)
+				req := spec.NewJSONRequest(http.MethodPost, "/api/v1/expenses", body, cookies, "")
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusForbidden, rec.Code)
+
+				var errBody apiErrorResponse
+				decodeAPIBody(t, rec, &errBody)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestPutAPIExpense(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_replace_the_expense",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_update_1", "api_update_1@example.com", "api_password_1")
+				category := s.CreateCategory(t, user.ID, "api_update_cat_1")
+				expense := s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Before update", 100, time.Now().Unix()))
+				cookies := s.AuthCookies(t, "api_update_1@example.com", "api_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+
+				body := fmt.Sprintf(`{"category_id":%d,"description":"After update","amount":250,"date":1700000000}`, category.ID)
+				url := fmt.Sprintf("/api/v1/expenses/%d", expense.ID)
+				req := spec.NewJSONRequest(http.MethodPut, url, body, cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+				var updated apiExpenseResponse
+				decodeAPIBody(t, rec, &updated)
+				require.Equal(t, expense.ID, updated.ID)
+				require.Equal(t, "After update", updated.Description)
+				require.Equal(t, uint64(250), updated.Amount)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestDeleteAPIExpense(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_delete_the_expense_and_return_204",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_delete_1", "api_delete_1@example.com", "api_password_1")
+				category := s.CreateCategory(t, user.ID, "api_delete_cat_1")
+				expense := s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Delete via api", 100, time.Now().Unix()))
+				cookies := s.AuthCookies(t, "api_delete_1@example.com", "api_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+				url := fmt.Sprintf("/api/v1/expenses/%d", expense.ID)
+
+				req := spec.NewJSONRequest(http.MethodDelete, url, "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNoContent, rec.Code)
+
+				req = spec.NewGetRequest(url, cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
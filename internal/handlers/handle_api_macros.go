@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// apiMacroEntry is the JSON shape of a macro entry. Nutrients are grams except
+// kcal, and date is Unix seconds.
+type apiMacroEntry struct {
+	ID            int     `json:"id"`
+	Name          string  `json:"name"`
+	Kcal          float64 `json:"kcal"`
+	ProteinG      float64 `json:"protein_g"`
+	CarbsG        float64 `json:"carbs_g"`
+	FatG          float64 `json:"fat_g"`
+	FiberG        float64 `json:"fiber_g"`
+	SodiumG       float64 `json:"sodium_g"`
+	SaturatedFatG float64 `json:"saturated_fat_g"`
+	Date          int64   `json:"date"`
+	MealType      string  `json:"meal_type"`
+	CreatedAt     int64   `json:"created_at"`
+	UpdatedAt     int64   `json:"updated_at"`
+}
+
+type apiMacroEntryBody struct {
+	Name          string  `json:"name"`
+	Kcal          float64 `json:"kcal"`
+	ProteinG      float64 `json:"protein_g"`
+	CarbsG        float64 `json:"carbs_g"`
+	FatG          float64 `json:"fat_g"`
+	FiberG        float64 `json:"fiber_g"`
+	SodiumG       float64 `json:"sodium_g"`
+	SaturatedFatG float64 `json:"saturated_fat_g"`
+	Date          int64   `json:"date"`
+	MealType      string  `json:"meal_type"`
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+// GetAPIMacroEntries lists the entries of one UTC day, given as date in
+// YYYY-MM-DD and today when omitted, oldest first.
+func (h *Handler) GetAPIMacroEntries(w http.ResponseWriter, r *http.Request) {
+	dateStr := r.URL.Query().Get("date")
+	if dateStr != "" {
+		if _, err := time.Parse("2006-01-02", dateStr); err != nil {
+			h.writeJSONErr(w, http.StatusBadRequest, ErrSearchDateFormat)
+
+			return
+		}
+	}
+	dayStart, nextDayStart, selectedDate := computeDayWindow(dateStr)
+
+	entries, err := h.store.FindMacroEntries(r.Context(), repo.QueryOptions{
+		Filters: repo.Filters{
+			FilterFields: []repo.FilterField{
+				{Name: "user_id", Value: getCurrentUser(r).ID, Operator: "="},
+				{Name: "date", Value: dayStart, Operator: ">="},
+				{Name: "date", Value: nextDayStart, Operator: "<"},
+			},
+			Connector: "AND",
+		},
+		Sorting: repo.Sorting{Field: "date", Order: "ASC"},
+	})
+	if err != nil {
+		h.writeJSONQueryErr(w, err)
+
+		return
+	}
+
+	rows := make([]apiMacroEntry, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, toAPIMacroEntry(entry))
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"date":    selectedDate,
+		"entries": rows,
+	})
+}
+
+func (h *Handler) PostAPIMacroEntries(w http.ResponseWriter, r *http.Request) {
+	var body apiMacroEntryBody
+	if err := decodeJSONBody(r, &body); err != nil {
+		h.writeJSONErr(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	entry, err := h.store.CreateMacroEntry(r.Context(), getCurrentUser(r).ID, logic.MacroEntryParams{
+		Name:          body.Name,
+		Kcal:          body.Kcal,
+		ProteinG:      body.ProteinG,
+		CarbsG:        body.CarbsG,
+		FatG:          body.FatG,
+		Date:          body.Date,
+		MealType:      body.MealType,
+		FiberG:        body.FiberG,
+		SodiumG:       body.SodiumG,
+		SaturatedFatG: body.SaturatedFatG,
+	})
+	if err != nil {
+		if errors.Is(err, logic.ErrValidationFailed) {
+			h.writeJSONErr(w, http.StatusUnprocessableEntity, err)
+
+			return
+		}
+		h.writeJSONInternalErr(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, toAPIMacroEntry(entry))
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+func toAPIMacroEntry(entry repo.MacroEntry) apiMacroEntry {
+	return apiMacroEntry{
+		ID:            entry.ID,
+		Name:          entry.Name,
+		Kcal:          entry.Kcal,
+		ProteinG:      entry.ProteinG,
+		CarbsG:        entry.CarbsG,
+		FatG:          entry.FatG,
+		FiberG:        entry.FiberG,
+		SodiumG:       entry.SodiumG,
+		SaturatedFatG: entry.SaturatedFatG,
+		Date:          entry.Date,
+		MealType:      entry.MealType,
+		CreatedAt:     entry.CreatedAt,
+		UpdatedAt:     entry.UpdatedAt,
+	}
+}
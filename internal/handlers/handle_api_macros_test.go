@@ -0,0 +1,130 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+type apiMacroEntriesResponse struct {
+	Date    string `json:"date"`
+	Entries []struct {
+		ID       int     `json:"id"`
+		Name     string  `json:"name"`
+		Kcal     float64 `json:"kcal"`
+		MealType string  `json:"meal_type"`
+	} `json:"entries"`
+}
+
+func TestAPIMacroEntries(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	macrosToken := func(t *testing.T, userID int, scope string) string {
+		t.Helper()
+
+		_, rawToken, err := s.Store.CreateAPIToken(t.Context(), userID, logic.APITokenParams{
+			Name:          "macros token",
+			ExpensesScope: logic.APITokenScopeNone,
+			MacrosScope:   scope,
+			MoodsScope:    logic.APITokenScopeNone,
+		})
+		require.NoError(t, err)
+
+		return rawToken
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_and_list_a_days_entries_with_a_write_token",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_macro_1", "api_macro_1@example.com", "api_macro_password_1")
+				rawToken := macrosToken(t, user.ID, logic.APITokenScopeWrite)
+				date := time.Date(2026, time.March, 2, 8, 0, 0, 0, time.UTC)
+
+				body := fmt.Sprintf(
+					`{"name":"Porridge","kcal":310,"protein_g":11,"date":%d,"meal_type":"breakfast"}`,
+					date.Unix(),
+				)
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/macros", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, withBearer(req, rawToken))
+
+				require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+				req = withBearer(spec.NewGetRequest("/api/v1/macros?date=2026-03-02", nil), rawToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+				var list apiMacroEntriesResponse
+				decodeAPIBody(t, rec, &list)
+				require.Equal(t, "2026-03-02", list.Date)
+				require.Len(t, list.Entries, 1)
+				require.Equal(t, "Porridge", list.Entries[0].Name)
+				require.InDelta(t, 310.0, list.Entries[0].Kcal, 0.001)
+				require.Equal(t, "breakfast", list.Entries[0].MealType)
+			},
+		},
+		{
+			name: "should_return_422_for_an_invalid_entry",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_macro_2", "api_macro_2@example.com", "api_macro_password_2")
+				rawToken := macrosToken(t, user.ID, logic.APITokenScopeWrite)
+
+				body := `{"name":"","kcal":10,"date":1772438400,"meal_type":"brunch"}`
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/macros", strings.NewReader(body))
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, withBearer(req, rawToken))
+
+				require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+				var errBody apiErrorResponse
+				decodeAPIBody(t, rec, &errBody)
+				require.NotEmpty(t, errBody.Fields)
+			},
+		},
+		{
+			name: "should_return_400_for_an_invalid_date",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_macro_3", "api_macro_3@example.com", "api_macro_password_3")
+				rawToken := macrosToken(t, user.ID, logic.APITokenScopeRead)
+
+				req := withBearer(spec.NewGetRequest("/api/v1/macros?date=03/02/2026", nil), rawToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "should_forbid_macros_to_a_token_without_the_scope",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_macro_4", "api_macro_4@example.com", "api_macro_password_4")
+				_, rawToken := s.CreateAPIToken(t, user.ID, logic.APITokenScopeWrite)
+
+				req := withBearer(spec.NewGetRequest("/api/v1/macros", nil), rawToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// apiMoodEntry is the JSON shape of a mood entry. LoggedAt is Unix seconds.
+type apiMoodEntry struct {
+	ID        int      `json:"id"`
+	Mood      string   `json:"mood"`
+	Notes     string   `json:"notes"`
+	LoggedAt  int64    `json:"logged_at"`
+	CreatedAt int64    `json:"created_at"`
+	UpdatedAt int64    `json:"updated_at"`
+	Tags      []string `json:"tags"`
+}
+
+type apiMoodEntryBody struct {
+	Mood     string   `json:"mood"`
+	Notes    string   `json:"notes"`
+	LoggedAt int64    `json:"logged_at"`
+	Tags     []string `json:"tags"`
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+// GetAPIMoodEntries lists mood entries newest first, with the page and
+// per_page parameters the moods page accepts.
+func (h *Handler) GetAPIMoodEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+	q := r.URL.Query()
+
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	opts := repo.QueryOptions{
+		Filters: repo.Filters{
+			FilterFields: []repo.FilterField{
+				{Name: "user_id", Value: user.ID, Operator: "="},
+			},
+			Connector: "AND",
+		},
+		Sorting: repo.Sorting{Field: "logged_at", Order: "DESC"},
+		Pagination: repo.Pagination{
+			Page:    page,
+			PerPage: normalizePerPage(q.Get("per_page")),
+		},
+	}
+
+	totalCount, err := h.store.CountMoodEntries(ctx, opts.Filters)
+	if err != nil {
+		h.writeJSONQueryErr(w, err)
+
+		return
+	}
+
+	entries, err := h.store.ListMoodEntries(ctx, opts)
+	if err != nil {
+		h.writeJSONQueryErr(w, err)
+
+		return
+	}
+
+	entryIDs := make([]int, 0, len(entries))
+	for _, e := range entries {
+		entryIDs = append(entryIDs, e.ID)
+	}
+
+	tagRows, err := h.store.FindTagRows(ctx, repo.TaggableTypeMoodEntry, "mood_entries", entryIDs, user.ID)
+	if err != nil {
+		h.writeJSONInternalErr(w, err)
+
+		return
+	}
+	tagNames := repo.TagNamesByTargetID(tagRows)
+
+	rows := make([]apiMoodEntry, 0, len(entries))
+	for _, e := range entries {
+		rows = append(rows, toAPIMoodEntry(e, tagNames[e.ID]))
+	}
+
+	pagination := newPaginationData(r, opts, totalCount, "")
+
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"mood_entries": rows,
+		"pagination": apiPagination{
+			Page:       pagination.CurrentPage,
+			PerPage:    pagination.PerPage,
+			TotalCount: pagination.TotalCount,
+			TotalPages: pagination.TotalPages,
+		},
+	})
+}
+
+func (h *Handler) PostAPIMoodEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := getCurrentUser(r).ID
+
+	var body apiMoodEntryBody
+	if err := decodeJSONBody(r, &body); err != nil {
+		h.writeJSONErr(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	entry, err := h.store.CreateMoodEntry(ctx, userID, logic.MoodEntryParams{
+		Mood:     body.Mood,
+		Notes:    body.Notes,
+		LoggedAt: body.LoggedAt,
+		Tags:     body.Tags,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, logic.ErrValidationFailed), errors.Is(err, logic.ErrInvalidMood):
+			h.writeJSONErr(w, http.StatusUnprocessableEntity, err)
+		default:
+			h.writeJSONInternalErr(w, err)
+		}
+
+		return
+	}
+
+	// Answer with the tags as stored, so the client sees the normalized names.
+	tags, err := h.store.FindMoodEntryTags(ctx, entry.ID, userID)
+	if err != nil {
+		h.writeJSONInternalErr(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, toAPIMoodEntry(entry, logic.ExtractTagNames(tags)))
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+func toAPIMoodEntry(entry repo.MoodEntry, tags []string) apiMoodEntry {
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return apiMoodEntry{
+		ID:        entry.ID,
+		Mood:      entry.Mood,
+		Notes:     entry.Notes,
+		LoggedAt:  entry.LoggedAt,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+		Tags:      tags,
+	}
+}
@@ -0,0 +1,112 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+type apiMoodEntriesResponse struct {
+	MoodEntries []struct {
+		Mood     string   `json:"mood"`
+		LoggedAt int64    `json:"logged_at"`
+		Tags     []string `json:"tags"`
+	} `json:"mood_entries"`
+	Pagination struct {
+		TotalCount int `json:"total_count"`
+	} `json:"pagination"`
+}
+
+func TestAPIMoodEntries(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_and_list_mood_entries_with_a_write_token",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_mood_1", "api_mood_1@example.com", "api_mood_password_1")
+				_, rawToken, err := s.Store.CreateAPIToken(t.Context(), user.ID, logic.APITokenParams{
+					Name:          "moods token",
+					ExpensesScope: logic.APITokenScopeNone,
+					MacrosScope:   logic.APITokenScopeNone,
+					MoodsScope:    logic.APITokenScopeWrite,
+				})
+				require.NoError(t, err)
+
+				body := `{"mood":"Calm","notes":"Walked","logged_at":1772438400,"tags":["Outdoors"]}`
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/moods", strings.NewReader(body))
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, withBearer(req, rawToken))
+
+				require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+				req = withBearer(spec.NewGetRequest("/api/v1/moods", nil), rawToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+				var list apiMoodEntriesResponse
+				decodeAPIBody(t, rec, &list)
+				require.Equal(t, 1, list.Pagination.TotalCount)
+				require.Len(t, list.MoodEntries, 1)
+				require.Equal(t, "Calm", list.MoodEntries[0].Mood)
+				require.Equal(t, int64(1772438400), list.MoodEntries[0].LoggedAt)
+				require.Len(t, list.MoodEntries[0].Tags, 1)
+			},
+		},
+		{
+			name: "should_return_422_for_an_unknown_mood",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_mood_2", "api_mood_2@example.com", "api_mood_password_2")
+				_, rawToken, err := s.Store.CreateAPIToken(t.Context(), user.ID, logic.APITokenParams{
+					Name:          "moods token",
+					ExpensesScope: logic.APITokenScopeNone,
+					MacrosScope:   logic.APITokenScopeNone,
+					MoodsScope:    logic.APITokenScopeWrite,
+				})
+				require.NoError(t, err)
+
+				body := `{"mood":"Sleepy-ish","logged_at":1772438400}`
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/moods", strings.NewReader(body))
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, withBearer(req, rawToken))
+
+				require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			},
+		},
+		{
+			name: "should_forbid_writes_with_a_read_token",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_mood_3", "api_mood_3@example.com", "api_mood_password_3")
+				_, rawToken := s.CreateAPIToken(t, user.ID, logic.APITokenScopeNone)
+
+				req := withBearer(spec.NewGetRequest("/api/v1/moods", nil), rawToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				body := `{"mood":"Calm","logged_at":1772438400}`
+				req = httptest.NewRequest(http.MethodPost, "/api/v1/moods", strings.NewReader(body))
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, withBearer(req, rawToken))
+
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
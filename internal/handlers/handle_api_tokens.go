@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+type apiTokenRow struct {
+	ID            int
+	Name          string
+	ExpensesScope string
+	MacrosScope   string
+	MoodsScope    string
+	CreatedAt     int64
+	// LastUsedAt is 0 for a token that has never authenticated a request.
+	LastUsedAt int64
+}
+
+// apiTokenScopeChoices are the scope options the account form offers per area.
+var apiTokenScopeChoices = []string{ //nolint:gochecknoglobals // static option list
+	logic.APITokenScopeNone,
+	logic.APITokenScopeRead,
+	logic.APITokenScopeWrite,
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) PostAccountAPITokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	if err := r.ParseForm(); err != nil {
+		h.renderAccountErr(w, r, logic.APITokenParams{}, fmt.Errorf("%w: %w", ErrParseForm, err))
+
+		return
+	}
+
+	params := logic.APITokenParams{
+		Name:          r.FormValue("name"),
+		ExpensesScope: r.FormValue("expenses_scope"),
+		MacrosScope:   r.FormValue("macros_scope"),
+		MoodsScope:    r.FormValue("moods_scope"),
+	}
+
+	_, rawToken, err := h.store.CreateAPIToken(ctx, user.ID, params)
+	if err != nil {
+		h.renderAccountErr(w, r, params, err)
+
+		return
+	}
+
+	h.session.Put(ctx, SessionNewAPIToken, rawToken)
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func (h *Handler) PostAccountAPITokensDelete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	id, err := prog.ParseID(chi.URLParam(r, "id"), "API token")
+	if err != nil {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	if err := h.store.DeleteAPIToken(ctx, id, user.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+// renderAccountErr re-renders the account page with the token form filled back
+// in, so a rejected token does not lose what the user picked.
+func (h *Handler) renderAccountErr(
+	w http.ResponseWriter,
+	r *http.Request,
+	params logic.APITokenParams,
+	err error,
+) {
+	data := h.tmplData(r)
+
+	h.setAccountErrData(r, data)
+	data["error"] = err.Error()
+	setAPITokenFormData(data, params)
+
+	h.render(w, http.StatusBadRequest, AccountIndex, data)
+}
+
+// setAccountErrData loads what the account page shows around a rejected form.
+// A failure here is logged rather than rendered, so the page still explains
+// the original error.
+func (h *Handler) setAccountErrData(r *http.Request, data map[string]any) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	counts, countsErr := h.store.FindAccountDataCounts(ctx, user.ID)
+	if countsErr != nil {
+		h.app.Logger.Errorf("failed to load account counts: %v", countsErr)
+	}
+
+	tokens, tokensErr := h.store.FindAPITokens(ctx, user.ID)
+	if tokensErr != nil {
+		h.app.Logger.Errorf("failed to load api tokens: %v", tokensErr)
+	}
+
+	data["counts"] = counts
+	data["apiTokens"] = toAPITokenRows(tokens)
+	data["newAPIToken"] = ""
+}
+
+func setAPITokenFormData(data map[string]any, params logic.APITokenParams) {
+	if params.ExpensesScope == "" {
+		params.ExpensesScope = logic.APITokenScopeNone
+	}
+	if params.MacrosScope == "" {
+		params.MacrosScope = logic.APITokenScopeNone
+	}
+	if params.MoodsScope == "" {
+		params.MoodsScope = logic.APITokenScopeNone
+	}
+
+	data["apiTokenForm"] = params
+	data["apiTokenScopes"] = apiTokenScopeChoices
+}
+
+func toAPITokenRows(tokens []repo.APIToken) []apiTokenRow {
+	rows := make([]apiTokenRow, 0, len(tokens))
+	for _, t := range tokens {
+		row := apiTokenRow{
+			ID:            t.ID,
+			Name:          t.Name,
+			ExpensesScope: t.ExpensesScope,
+			MacrosScope:   t.MacrosScope,
+			MoodsScope:    t.MoodsScope,
+			CreatedAt:     t.CreatedAt,
+		}
+		if t.LastUsedAt != nil {
+			row.LastUsedAt = *t.LastUsedAt
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
@@ -0,0 +1,248 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+var rawAPITokenRE = regexp.MustCompile(`ninete_[A-Za-z0-9_-]+`)
+
+func withBearer(req *http.Request, rawToken string) *http.Request {
+	req.Header.Set("Authorization", "Bearer "+rawToken)
+
+	return req
+}
+
+func TestPostAccountAPITokens(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_show_the_new_token_once",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "tok_page_1", "tok_page_1@example.com", "tok_password_1")
+				cookies := s.AuthCookies(t, "tok_page_1@example.com", "tok_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/account", cookies)
+
+				form := url.Values{
+					"name":           {"Phone shortcut"},
+					"expenses_scope": {"write"},
+					"macros_scope":   {"none"},
+					"moods_scope":    {"read"},
+				}
+				req := spec.NewPostRequest("/account/api-tokens", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/account", rec.Header().Get("Location"))
+
+				req = spec.NewGetRequest("/account", cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Phone shortcut")
+				require.Regexp(t, rawAPITokenRE, rec.Body.String())
+
+				req = spec.NewGetRequest("/account", cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.NotRegexp(t, rawAPITokenRE, rec.Body.String())
+			},
+		},
+		{
+			name: "should_rerender_with_error_when_no_scope_is_granted",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "tok_page_2", "tok_page_2@example.com", "tok_password_2")
+				cookies := s.AuthCookies(t, "tok_page_2@example.com", "tok_password_2")
+				csrfToken, cookies := s.CSRFFrom(t, "/account", cookies)
+
+				form := url.Values{
+					"name":           {"Useless"},
+					"expenses_scope": {"none"},
+					"macros_scope":   {"none"},
+					"moods_scope":    {"none"},
+				}
+				req := spec.NewPostRequest("/account/api-tokens", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), logic.ErrAPITokenNoScope.Error())
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestPostAccountAPITokensDelete(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_revoke_the_token",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "tok_revoke_1", "tok_revoke_1@example.com", "tok_password_1")
+				token, rawToken := s.CreateAPIToken(t, user.ID, logic.APITokenScopeRead)
+				cookies := s.AuthCookies(t, "tok_revoke_1@example.com", "tok_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/account", cookies)
+
+				path := fmt.Sprintf("/account/api-tokens/%d/delete", token.ID)
+				req := spec.NewPostRequest(path, "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				req = withBearer(spec.NewGetRequest("/api/v1/expenses", nil), rawToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "should_not_revoke_another_users_token",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "tok_revoke_2", "tok_revoke_2@example.com", "tok_password_2")
+				other := s.CreateAuthUser(t, "tok_revoke_3", "tok_revoke_3@example.com", "tok_password_3")
+				token, _ := s.CreateAPIToken(t, other.ID, logic.APITokenScopeRead)
+				cookies := s.AuthCookies(t, "tok_revoke_2@example.com", "tok_password_2")
+				csrfToken, cookies := s.CSRFFrom(t, "/account", cookies)
+
+				path := fmt.Sprintf("/account/api-tokens/%d/delete", token.ID)
+				req := spec.NewPostRequest(path, "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestAPITokenAuthentication(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_list_expenses_with_a_read_token_and_no_cookies",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "tok_auth_1", "tok_auth_1@example.com", "tok_password_1")
+				category := s.CreateCategory(t, user.ID, "tok_auth_cat_1")
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Token visible", 400, time.Now().Unix()))
+				_, rawToken := s.CreateAPIToken(t, user.ID, logic.APITokenScopeRead)
+
+				req := withBearer(spec.NewGetRequest("/api/v1/expenses", nil), rawToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				require.Contains(t, rec.Body.String(), "Token visible")
+			},
+		},
+		{
+			name: "should_create_expense_with_a_write_token_and_no_csrf_token",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "tok_auth_2", "tok_auth_2@example.com", "tok_password_2")
+				category := s.CreateCategory(t, user.ID, "tok_auth_cat_2")
+				_, rawToken := s.CreateAPIToken(t, user.ID, logic.APITokenScopeWrite)
+
+				body := fmt.Sprintf(`{"category_id":%d,"description":"From script","amount":100,"date":1700000000}`, category.ID)
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/expenses", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, withBearer(req, rawToken))
+
+				require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			},
+		},
+		{
+			name: "should_forbid_writes_with_a_read_token",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "tok_auth_3", "tok_auth_3@example.com", "tok_password_3")
+				category := s.CreateCategory(t, user.ID, "tok_auth_cat_3")
+				_, rawToken := s.CreateAPIToken(t, user.ID, logic.APITokenScopeRead)
+
+				body := fmt.Sprintf(`{"category_id":%d,"description":"Not allowed","amount":100,"date":1700000000}`, category.ID)
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/expenses", strings.NewReader(body))
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, withBearer(req, rawToken))
+
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name: "should_forbid_reads_in_an_area_the_token_does_not_cover",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "tok_auth_4", "tok_auth_4@example.com", "tok_password_4")
+				_, rawToken := s.CreateAPIToken(t, user.ID, logic.APITokenScopeNone)
+
+				req := withBearer(spec.NewGetRequest("/api/v1/expenses", nil), rawToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name: "should_reject_an_invalid_token_with_401",
+			fn: func(t *testing.T) {
+				req := withBearer(spec.NewGetRequest("/api/v1/expenses", nil), "ninete_bogus")
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "should_ignore_tokens_on_html_routes",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "tok_auth_5", "tok_auth_5@example.com", "tok_password_5")
+				_, rawToken := s.CreateAPIToken(t, user.ID, logic.APITokenScopeWrite)
+
+				req := withBearer(spec.NewGetRequest("/expenses", nil), rawToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/login", rec.Header().Get("Location"))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
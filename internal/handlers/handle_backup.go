@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+)
+
+// restoreInputErrors are the restore failures caused by the archive or the
+// account's state rather than by the server.
+var restoreInputErrors = []error{ //nolint:gochecknoglobals // static lookup table
+	logic.ErrBackupArchive,
+	logic.ErrBackupFormat,
+	logic.ErrBackupTooLarge,
+	logic.ErrBackupNewerSchema,
+	logic.ErrBackupDangling,
+	logic.ErrRestoreNotEmpty,
+	ErrRestoreNoFile,
+	ErrParseForm,
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+// GetExportsBackup streams the account's backup archive. As with the other
+// downloads, a failure after the headers are sent can only be logged.
+func (h *Handler) GetExportsBackup(w http.ResponseWriter, r *http.Request) {
+	user := getCurrentUser(r)
+
+	now := time.Now().UTC().Unix()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="ninete-backup-%d.zip"`, now))
+	w.WriteHeader(http.StatusOK)
+
+	if err := h.store.WriteBackup(r.Context(), w, user.ID); err != nil {
+		h.app.Logger.Errorf("failed to write backup: %v", err)
+	}
+}
+
+// PostAccountRestore restores an uploaded backup archive into the account.
+// Uploads share the 1 MB request body limit; larger archives go through the
+// restore_backup task.
+func (h *Handler) PostAccountRestore(w http.ResponseWriter, r *http.Request) {
+	user := getCurrentUser(r)
+
+	if err := r.ParseMultipartForm(maxImportFormMemory); err != nil {
+		h.renderRestoreErr(w, r, fmt.Errorf("%w: %w", ErrParseForm, err))
+
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.renderRestoreErr(w, r, ErrRestoreNoFile)
+
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if _, err := h.store.RestoreBackup(r.Context(), user.ID, file, header.Size); err != nil {
+		h.renderRestoreErr(w, r, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) renderRestoreErr(w http.ResponseWriter, r *http.Request, err error) {
+	data := h.tmplData(r)
+
+	status := http.StatusInternalServerError
+	for _, target := range restoreInputErrors {
+		if errors.Is(err, target) {
+			status = http.StatusBadRequest
+
+			break
+		}
+	}
+
+	h.setAccountErrData(r, data)
+	data["restoreError"] = err.Error()
+	setAPITokenFormData(data, logic.APITokenParams{})
+
+	h.render(w, status, AccountIndex, data)
+}
@@ -0,0 +1,154 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetExportsBackup(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_redirect_to_login_when_unauthenticated",
+			fn: func(t *testing.T) {
+				req := spec.NewGetRequest("/exports/backup.zip", nil)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/login", rec.Header().Get("Location"))
+			},
+		},
+		{
+			name: "should_return_a_zip_with_one_file_per_table",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "backup_dl_1", "backup_dl_1@example.com", "backup_password_1")
+				cookies := s.AuthCookies(t, "backup_dl_1@example.com", "backup_password_1")
+
+				req := spec.NewGetRequest("/exports/backup.zip", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+				require.Contains(t, rec.Header().Get("Content-Disposition"), "ninete-backup-")
+
+				body := rec.Body.Bytes()
+				zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+				require.NoError(t, err)
+
+				names := make([]string, 0, len(zr.File))
+				for _, f := range zr.File {
+					names = append(names, f.Name)
+				}
+				require.Contains(t, names, "manifest.json")
+				require.Contains(t, names, "expenses.json")
+				require.Contains(t, names, "taggings.json")
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestPostAccountRestore(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	source := s.CreateAuthUser(t, "restore_h_1", "restore_h_1@example.com", "restore_password_1")
+	category := s.CreateCategory(t, source.ID, "restore_h_category")
+	s.CreateExpense(t, source.ID, logic.ExpenseParams{
+		ExpenseBaseParams: logic.ExpenseBaseParams{
+			CategoryID:  category.ID,
+			Description: "restored lunch",
+			Amount:      1250,
+		},
+		Date: 1735689600,
+		Tags: []string{"food"},
+	})
+
+	var archive bytes.Buffer
+	require.NoError(t, s.Store.WriteBackup(t.Context(), &archive, source.ID))
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_restore_into_an_empty_account",
+			fn: func(t *testing.T) {
+				target := s.CreateAuthUser(t, "restore_h_2", "restore_h_2@example.com", "restore_password_2")
+				cookies := s.AuthCookies(t, "restore_h_2@example.com", "restore_password_2")
+				csrfToken, cookies := s.CSRFFrom(t, "/account", cookies)
+
+				req := spec.NewUploadRequest(
+					t, "/account/restore", "file", "backup.zip", archive.String(), cookies, csrfToken,
+				)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/account", rec.Header().Get("Location"))
+
+				count, err := s.Queries.CountExpensesByUser(t.Context(), target.ID)
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+			},
+		},
+		{
+			name: "should_refuse_an_account_with_data",
+			fn: func(t *testing.T) {
+				cookies := s.AuthCookies(t, "restore_h_1@example.com", "restore_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/account", cookies)
+
+				req := spec.NewUploadRequest(
+					t, "/account/restore", "file", "backup.zip", archive.String(), cookies, csrfToken,
+				)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "restore needs an empty account")
+
+				count, err := s.Queries.CountExpensesByUser(t.Context(), source.ID)
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+			},
+		},
+		{
+			name: "should_reject_a_file_that_is_not_an_archive",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "restore_h_3", "restore_h_3@example.com", "restore_password_3")
+				cookies := s.AuthCookies(t, "restore_h_3@example.com", "restore_password_3")
+				csrfToken, cookies := s.CSRFFrom(t, "/account", cookies)
+
+				req := spec.NewUploadRequest(
+					t, "/account/restore", "file", "backup.zip", "not a zip", cookies, csrfToken,
+				)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "not a readable backup archive")
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
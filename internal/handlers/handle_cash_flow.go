@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+)
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+// GetCashFlow sets income against expenses for each of the last
+// ?months=6|12|24 calendar months.
+func (h *Handler) GetCashFlow(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+	months := cashFlowMonths(r.URL.Query().Get("months"))
+
+	flow, err := h.store.FindCashFlow(r.Context(), user.ID, user.HomeCurrency, time.Now(), months)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, CashFlowIndex, err)
+
+		return
+	}
+
+	// Newest month first, the way every other list in the app reads.
+	rows := slices.Clone(flow.Months)
+	slices.Reverse(rows)
+
+	data["months"] = months
+	data["periods"] = logic.CashFlowPeriods()
+	data["cashFlow"] = flow
+	data["rows"] = rows
+
+	h.render(w, http.StatusOK, CashFlowIndex, data)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+// buildDashboardCashFlow is the current month of the cash-flow report.
+func (h *Handler) buildDashboardCashFlow(
+	w http.ResponseWriter,
+	r *http.Request,
+	user *logic.User,
+) (logic.CashFlow, bool) {
+	flow, err := h.store.FindCashFlow(r.Context(), user.ID, user.HomeCurrency, time.Now(), 1)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, DashboardIndex, err)
+
+		return flow, false
+	}
+
+	return flow, true
+}
+
+// cashFlowMonths reads the period, falling back to the default for anything
+// the page does not offer.
+func cashFlowMonths(value string) int {
+	months, err := strconv.Atoi(value)
+	if err != nil || !slices.Contains(logic.CashFlowPeriods(), months) {
+		return logic.DefaultCashFlowMonths
+	}
+
+	return months
+}
@@ -0,0 +1,87 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCashFlow(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_redirect_to_login_when_unauthenticated",
+			fn: func(t *testing.T) {
+				req := spec.NewGetRequest("/cash-flow", nil)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/login", rec.Header().Get("Location"))
+			},
+		},
+		{
+			name: "should_select_the_requested_months",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "cash_flow_h_1", "cash_flow_h_1@example.com", "cash_flow_password_1")
+				cookies := s.AuthCookies(t, "cash_flow_h_1@example.com", "cash_flow_password_1")
+
+				req := spec.NewGetRequest("/cash-flow?months=6", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Regexp(t, regexp.MustCompile(`<option value="6"\s+selected`), rec.Body.String())
+			},
+		},
+		{
+			name: "should_fall_back_to_the_default_months",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "cash_flow_h_2", "cash_flow_h_2@example.com", "cash_flow_password_2")
+				cookies := s.AuthCookies(t, "cash_flow_h_2@example.com", "cash_flow_password_2")
+
+				req := spec.NewGetRequest("/cash-flow?months=7", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Regexp(t, regexp.MustCompile(`<option value="12"\s+selected`), rec.Body.String())
+			},
+		},
+		{
+			name: "should_show_this_months_cash_flow_on_the_dashboard",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "cash_flow_h_3", "cash_flow_h_3@example.com", "cash_flow_password_3")
+				_, err := s.Store.CreateIncome(t.Context(), user.ID, logic.IncomeParams{
+					Source: "Payroll",
+					Amount: 100000,
+					Date:   time.Now().Unix(),
+				})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "cash_flow_h_3@example.com", "cash_flow_password_3")
+
+				req := spec.NewGetRequest("/dashboard", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "This month's cash flow")
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
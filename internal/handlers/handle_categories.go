@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// categoryRow is one category on the categories page with the number of rows
+// filed under it, so the user can see what a merge or archive affects.
+// ParentCategoryID is ParentID flattened to 0 for the parent picker.
+type categoryRow struct {
+	repo.Category
+	Expenses          int
+	RecurrentExpenses int
+	HasChildren       bool
+	ParentCategoryID  int
+}
+
+// ----------------------------------------------------------------------------- //
+// Context Middleware
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) CategoryContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user := getCurrentUser(r)
+		id, err := prog.ParseID(chi.URLParam(r, "id"), "Category")
+		if err != nil {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		category, err := h.store.FindCategory(ctx, id, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		if err != nil {
+			h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+			return
+		}
+
+		ctx = context.WithValue(ctx, KeyCategory, &category)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+
+	if err := h.setCategoriesData(ctx, data, user.ID); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, CategoriesIndex, err)
+
+		return
+	}
+
+	h.render(w, http.StatusOK, CategoriesIndex, data)
+}
+
+func (h *Handler) PostCategories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	if err := r.ParseForm(); err != nil {
+		h.renderCategoriesErr(w, r, fmt.Errorf("%w: %w", ErrParseForm, err))
+
+		return
+	}
+
+	_, err := h.store.CreateCategory(ctx, user.ID, logic.CategoryParams{Name: r.FormValue("name")})
+	if err != nil {
+		h.renderCategoriesErr(w, r, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/categories", http.StatusSeeOther)
+}
+
+func (h *Handler) PostCategoryUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+	category := getCategory(r)
+
+	if err := r.ParseForm(); err != nil {
+		h.renderCategoriesErr(w, r, fmt.Errorf("%w: %w", ErrParseForm, err))
+
+		return
+	}
+
+	_, err := h.store.RenameCategory(ctx, category.ID, user.ID, logic.CategoryParams{Name: r.FormValue("name")})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderCategoriesErr(w, r, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/categories", http.StatusSeeOther)
+}
+
+func (h *Handler) PostCategoryArchive(w http.ResponseWriter, r *http.Request) {
+	h.setCategoryArchived(w, r, true)
+}
+
+func (h *Handler) PostCategoryUnarchive(w http.ResponseWriter, r *http.Request) {
+	h.setCategoryArchived(w, r, false)
+}
+
+func (h *Handler) PostCategoryMerge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+	category := getCategory(r)
+
+	if err := r.ParseForm(); err != nil {
+		h.renderCategoriesErr(w, r, fmt.Errorf("%w: %w", ErrParseForm, err))
+
+		return
+	}
+
+	targetID, err := prog.ParseID(r.FormValue("target_id"), "Category")
+	if err != nil {
+		h.renderCategoriesErr(w, r, logic.ErrUnknownCategory)
+
+		return
+	}
+
+	if err := h.store.MergeCategory(ctx, user.ID, category.ID, targetID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderCategoriesErr(w, r, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/categories", http.StatusSeeOther)
+}
+
+// PostCategoryParent files the category under the chosen parent. An empty
+// parent_id makes it top-level again.
+func (h *Handler) PostCategoryParent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+	category := getCategory(r)
+
+	if err := r.ParseForm(); err != nil {
+		h.renderCategoriesErr(w, r, fmt.Errorf("%w: %w", ErrParseForm, err))
+
+		return
+	}
+
+	var parentID *int
+	if value := r.FormValue("parent_id"); value != "" {
+		id, err := prog.ParseID(value, "Category")
+		if err != nil {
+			h.renderCategoriesErr(w, r, logic.ErrUnknownCategory)
+
+			return
+		}
+		parentID = &id
+	}
+
+	if _, err := h.store.SetCategoryParent(ctx, category.ID, user.ID, parentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderCategoriesErr(w, r, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/categories", http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) setCategoryArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+	category := getCategory(r)
+
+	var err error
+	if archived {
+		_, err = h.store.ArchiveCategory(ctx, category.ID, user.ID)
+	} else {
+		_, err = h.store.UnarchiveCategory(ctx, category.ID, user.ID)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/categories", http.StatusSeeOther)
+}
+
+func (h *Handler) renderCategoriesErr(w http.ResponseWriter, r *http.Request, err error) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+
+	if loadErr := h.setCategoriesData(ctx, data, user.ID); loadErr != nil {
+		h.app.Logger.Errorf("failed to load categories: %v", loadErr)
+	}
+	data["error"] = err.Error()
+
+	h.render(w, http.StatusBadRequest, CategoriesIndex, data)
+}
+
+func (h *Handler) setCategoriesData(ctx context.Context, data map[string]any, userID int) error {
+	categories, err := h.store.FindCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	usage, err := h.store.FindCategoryUsage(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	hasChildren := make(map[int]bool, len(categories))
+	for _, c := range categories {
+		if c.ParentID != nil {
+			hasChildren[*c.ParentID] = true
+		}
+	}
+
+	rows := make([]categoryRow, 0, len(categories))
+	for _, c := range categories {
+		row := categoryRow{
+			Category:          c,
+			Expenses:          usage[c.ID].Expenses,
+			RecurrentExpenses: usage[c.ID].RecurrentExpenses,
+			HasChildren:       hasChildren[c.ID],
+		}
+		if c.ParentID != nil {
+			row.ParentCategoryID = *c.ParentID
+		}
+
+		rows = append(rows, row)
+	}
+
+	data["categories"] = rows
+
+	return nil
+}
+
+func getCategory(r *http.Request) *repo.Category {
+	category, ok := r.Context().Value(KeyCategory).(*repo.Category)
+
+	if !ok {
+		panic("failed to get category context")
+	}
+
+	return category
+}
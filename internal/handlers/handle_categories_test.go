@@ -0,0 +1,213 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoriesFlow(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_redirect_to_login_when_unauthenticated",
+			fn: func(t *testing.T) {
+				req := spec.NewGetRequest("/categories", nil)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/login", rec.Header().Get("Location"))
+			},
+		},
+		{
+			name: "should_list_the_default_categories_and_create_one",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "cat_h_1", "cat_h_1@example.com", "cat_password_1")
+				cookies := s.AuthCookies(t, "cat_h_1@example.com", "cat_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/categories", cookies)
+
+				form := url.Values{"name": {"Coffee Shops"}}
+				req := spec.NewPostRequest("/categories", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/categories", rec.Header().Get("Location"))
+
+				req = spec.NewGetRequest("/categories", cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Groceries")
+				require.Contains(t, rec.Body.String(), "Coffee Shops")
+			},
+		},
+		{
+			name: "should_reject_a_duplicate_name",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "cat_h_2", "cat_h_2@example.com", "cat_password_2")
+				cookies := s.AuthCookies(t, "cat_h_2@example.com", "cat_password_2")
+				csrfToken, cookies := s.CSRFFrom(t, "/categories", cookies)
+
+				form := url.Values{"name": {"groceries"}}
+				req := spec.NewPostRequest("/categories", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "you already have a category with this name")
+			},
+		},
+		{
+			name: "should_hide_an_archived_category_from_the_new_expense_form",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "cat_h_3", "cat_h_3@example.com", "cat_password_3")
+				category := s.CreateCategory(t, user.ID, "Archived Hobby")
+				cookies := s.AuthCookies(t, "cat_h_3@example.com", "cat_password_3")
+				csrfToken, cookies := s.CSRFFrom(t, "/categories", cookies)
+
+				req := spec.NewPostRequest(fmt.Sprintf("/categories/%d/archive", category.ID), "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				req = spec.NewGetRequest("/expenses/new", cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.NotContains(t, rec.Body.String(), "Archived Hobby")
+
+				req = spec.NewPostRequest(fmt.Sprintf("/categories/%d/unarchive", category.ID), "", cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				req = spec.NewGetRequest("/expenses/new", cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Contains(t, rec.Body.String(), "Archived Hobby")
+			},
+		},
+		{
+			name: "should_rename_and_merge",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "cat_h_4", "cat_h_4@example.com", "cat_password_4")
+				source := s.CreateCategory(t, user.ID, "Cafe")
+				target := s.CreateCategory(t, user.ID, "Eating Out")
+				expense := s.CreateExpense(t, user.ID, newExpenseParams(source.ID, "Espresso", 300, time.Now().Unix()))
+				cookies := s.AuthCookies(t, "cat_h_4@example.com", "cat_password_4")
+				csrfToken, cookies := s.CSRFFrom(t, "/categories", cookies)
+
+				form := url.Values{"name": {"Cafes"}}
+				req := spec.NewPostRequest(fmt.Sprintf("/categories/%d", source.ID), form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				renamed, err := s.Store.FindCategory(t.Context(), source.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, "Cafes", renamed.Name)
+
+				form = url.Values{"target_id": {fmt.Sprint(target.ID)}}
+				req = spec.NewPostRequest(fmt.Sprintf("/categories/%d/merge", source.ID), form.Encode(), cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				found, err := s.Store.FindExpense(t.Context(), expense.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, target.ID, found.CategoryID)
+			},
+		},
+		{
+			name: "should_return_not_found_for_another_users_category",
+			fn: func(t *testing.T) {
+				owner := s.CreateAuthUser(t, "cat_h_5", "cat_h_5@example.com", "cat_password_5")
+				category := s.CreateCategory(t, owner.ID, "Private")
+				s.CreateAuthUser(t, "cat_h_6", "cat_h_6@example.com", "cat_password_6")
+				cookies := s.AuthCookies(t, "cat_h_6@example.com", "cat_password_6")
+				csrfToken, cookies := s.CSRFFrom(t, "/categories", cookies)
+
+				req := spec.NewPostRequest(fmt.Sprintf("/categories/%d/archive", category.ID), "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name: "should_refuse_to_merge_into_another_users_category",
+			fn: func(t *testing.T) {
+				owner := s.CreateAuthUser(t, "cat_h_7", "cat_h_7@example.com", "cat_password_7")
+				foreign := s.CreateCategory(t, owner.ID, "Theirs")
+				user := s.CreateAuthUser(t, "cat_h_8", "cat_h_8@example.com", "cat_password_8")
+				source := s.CreateCategory(t, user.ID, "Mine")
+				cookies := s.AuthCookies(t, "cat_h_8@example.com", "cat_password_8")
+				csrfToken, cookies := s.CSRFFrom(t, "/categories", cookies)
+
+				form := url.Values{"target_id": {fmt.Sprint(foreign.ID)}}
+				req := spec.NewPostRequest(fmt.Sprintf("/categories/%d/merge", source.ID), form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "unknown category")
+
+				_, err := s.Store.FindCategory(t.Context(), source.ID, user.ID)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "should_set_and_reject_a_parent",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "cat_h_9", "cat_h_9@example.com", "cat_password_9")
+				parent := s.CreateCategory(t, user.ID, "Getting Around")
+				child := s.CreateCategory(t, user.ID, "Fuel")
+				cookies := s.AuthCookies(t, "cat_h_9@example.com", "cat_password_9")
+				csrfToken, cookies := s.CSRFFrom(t, "/categories", cookies)
+
+				form := url.Values{"parent_id": {fmt.Sprint(parent.ID)}}
+				req := spec.NewPostRequest(fmt.Sprintf("/categories/%d/parent", child.ID), form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				found, err := s.Store.FindCategory(t.Context(), child.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, &parent.ID, found.ParentID)
+
+				form = url.Values{"parent_id": {fmt.Sprint(child.ID)}}
+				req = spec.NewPostRequest(fmt.Sprintf("/categories/%d/parent", parent.ID), form.Encode(), cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "categories nest only one level deep")
+
+				form = url.Values{"parent_id": {""}}
+				req = spec.NewPostRequest(fmt.Sprintf("/categories/%d/parent", child.ID), form.Encode(), cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				found, err = s.Store.FindCategory(t.Context(), child.ID, user.ID)
+				require.NoError(t, err)
+				require.Nil(t, found.ParentID)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"sort"
 
+	"github.com/ad9311/ninete/internal/logic"
 	"github.com/ad9311/ninete/internal/repo"
 )
 
@@ -28,7 +29,32 @@ func (h *Handler) GetDashboard(w http.ResponseWriter, r *http.Request) {
 	data := h.tmplData(r)
 	user := getCurrentUser(r)
 
-	summary, ok := h.buildDashboardSummary(w, r, user.ID)
+	summary, ok := h.buildDashboardSummary(w, r, user)
+	if !ok {
+		return
+	}
+
+	savingsGoals, ok := h.buildDashboardSavingsGoals(w, r, user)
+	if !ok {
+		return
+	}
+
+	cashFlow, ok := h.buildDashboardCashFlow(w, r, user)
+	if !ok {
+		return
+	}
+
+	bills, ok := h.buildDashboardBills(w, r, user)
+	if !ok {
+		return
+	}
+
+	pendingExpenses, ok := h.buildDashboardPendingExpenses(w, r, user)
+	if !ok {
+		return
+	}
+
+	notifications, ok := h.buildDashboardNotifications(w, r, user)
 	if !ok {
 		return
 	}
@@ -39,12 +65,23 @@ func (h *Handler) GetDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data["summary"] = summary
+	data["savingsGoals"] = savingsGoals
+	data["cashFlow"] = cashFlow
+	data["bills"] = bills
+	data["pendingExpenses"] = pendingExpenses
+	data["notifications"] = notifications
 	data["macros"] = macros
 
 	h.render(w, http.StatusOK, DashboardIndex, data)
 }
 
-func (h *Handler) buildDashboardSummary(w http.ResponseWriter, r *http.Request, userID int) (dashboardSummary, bool) {
+// buildDashboardSummary totals this month and last in the user's home
+// currency.
+func (h *Handler) buildDashboardSummary(
+	w http.ResponseWriter,
+	r *http.Request,
+	user *logic.User,
+) (dashboardSummary, bool) {
 	ctx := r.Context()
 
 	tzOffset := parseTZOffset(r)
@@ -53,7 +90,7 @@ func (h *Handler) buildDashboardSummary(w http.ResponseWriter, r *http.Request,
 
 	thisFilters := repo.Filters{
 		FilterFields: []repo.FilterField{
-			{Name: "user_id", Value: userID, Operator: "="},
+			{Name: "user_id", Value: user.ID, Operator: "="},
 			{Name: "date", Value: thisDR.start, Operator: ">="},
 			{Name: "date", Value: thisDR.end, Operator: "<"},
 		},
@@ -61,21 +98,21 @@ func (h *Handler) buildDashboardSummary(w http.ResponseWriter, r *http.Request,
 	}
 	lastFilters := repo.Filters{
 		FilterFields: []repo.FilterField{
-			{Name: "user_id", Value: userID, Operator: "="},
+			{Name: "user_id", Value: user.ID, Operator: "="},
 			{Name: "date", Value: lastDR.start, Operator: ">="},
 			{Name: "date", Value: lastDR.end, Operator: "<"},
 		},
 		Connector: "AND",
 	}
 
-	thisTotals, err := h.store.FindExpensesCategoryTotals(ctx, thisFilters)
+	thisTotals, err := h.store.FindExpensesCategoryTotals(ctx, thisFilters, user.HomeCurrency, false)
 	if err != nil {
 		h.renderErr(w, r, http.StatusInternalServerError, DashboardIndex, err)
 
 		return dashboardSummary{}, false
 	}
 
-	lastTotals, err := h.store.FindExpensesCategoryTotals(ctx, lastFilters)
+	lastTotals, err := h.store.FindExpensesCategoryTotals(ctx, lastFilters, user.HomeCurrency, false)
 	if err != nil {
 		h.renderErr(w, r, http.StatusInternalServerError, DashboardIndex, err)
 
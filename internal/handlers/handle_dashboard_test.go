@@ -3,6 +3,7 @@ package handlers_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -47,7 +48,7 @@ func TestGetDashboard(t *testing.T) {
 			name: "should_show_this_month_expense_total",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "dash_user_2", "dash_user_2@example.com", "dash_password_2")
-				category := s.CreateCategory(t, "dash_cat_1")
+				category := s.CreateCategory(t, user.ID, "dash_cat_1")
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Dash expense", 2500, time.Now().Unix()))
 				cookies := s.AuthCookies(t, "dash_user_2@example.com", "dash_password_2")
 
@@ -59,6 +60,30 @@ func TestGetDashboard(t *testing.T) {
 				require.Contains(t, rec.Body.String(), "$25.00")
 			},
 		},
+		{
+			name: "should_convert_the_total_into_the_home_currency",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "dash_user_fx", "dash_user_fx@example.com", "dash_password_fx")
+				require.NoError(t, s.Store.SetHomeCurrency(t.Context(), user.ID, "EUR"))
+				_, err := s.Store.ImportExchangeRates(t.Context(), strings.NewReader(
+					"date,base_currency,quote_currency,rate\n2020-01-01,EUR,USD,1.25\n",
+				))
+				require.NoError(t, err)
+
+				category := s.CreateCategory(t, user.ID, "dash_cat_fx")
+				params := newExpenseParams(category.ID, "Dash dollars", 2000, time.Now().Unix())
+				params.Currency = "USD"
+				s.CreateExpense(t, user.ID, params)
+				cookies := s.AuthCookies(t, "dash_user_fx@example.com", "dash_password_fx")
+
+				req := spec.NewGetRequest("/dashboard", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "16.00 EUR")
+			},
+		},
 		{
 			name: "should_show_no_macro_goals_prompt_when_goals_not_set",
 			fn: func(t *testing.T) {
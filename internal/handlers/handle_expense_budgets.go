@@ -8,22 +8,36 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ad9311/ninete/internal/logic"
 	"github.com/ad9311/ninete/internal/prog"
 	"github.com/ad9311/ninete/internal/repo"
 )
 
-const budgetFieldPrefix = "budget_"
-
-// budgetMonthLayout matches strftime('%Y-%m') in selectExpensesCategoryMonthTotals.
-const budgetMonthLayout = "2006-01"
+const (
+	budgetFieldPrefix   = "budget_"
+	rolloverFieldPrefix = "rollover_"
+	// budgetTotalField and rolloverTotalField carry the whole-month budget,
+	// which has no category id to suffix.
+	budgetTotalField   = "budget_total"
+	rolloverTotalField = "rollover_total"
+	// budgetTotalName labels the whole-month budget wherever a category name
+	// would go.
+	budgetTotalName = "All categories"
+)
 
-// budgetRow is one category on the budgets page. Months, MonthsOver,
-// MonthCount and AvgPerMonth are filled in budgetModeMonths only.
+// budgetRow is one category on the budgets page, or the whole-month budget.
+// Budget is the amount in force and Carry what rollover brought in from the
+// month before; Left and the percents are measured against their sum. In
+// budgetModeMonths, Budget is the latest month's amount and the Months carry
+// their own. Months, MonthsOver, MonthCount and AvgPerMonth are filled in
+// budgetModeMonths only.
 type budgetRow struct {
 	CategoryName string
 	Total        uint64
 	HasBudget    bool
 	Budget       uint64
+	Carry        int64
+	Rollover     bool
 	Left         int64
 	Pct          int
 	BarPct       int
@@ -37,17 +51,41 @@ type budgetRow struct {
 type budgetMonthRow struct {
 	Month  string
 	Total  uint64
+	Budget uint64
+	Carry  int64
 	Pct    int
 	BarPct int
 	Over   bool
 }
 
 // budgetEditRow is one row of the edit form, which lists every category so a
-// category with neither budget nor spend can still be given one.
+// category with neither budget nor spend can still be given one. A zero
+// CategoryID is the whole-month budget.
 type budgetEditRow struct {
 	CategoryID int
 	Name       string
 	Amount     uint64
+	Rollover   bool
+}
+
+// budgetVersionRow is one entry of the budget history, newest first.
+type budgetVersionRow struct {
+	EffectiveFrom string
+	Name          string
+	Amount        uint64
+	Rollover      bool
+}
+
+// budgetsPage is everything the budgets page renders besides the form error.
+type budgetsPage struct {
+	rangeKey      string
+	mode          budgetMode
+	rows          []budgetRow
+	totalRow      *budgetRow
+	editRows      []budgetEditRow
+	totalEditRow  budgetEditRow
+	versionRows   []budgetVersionRow
+	effectiveFrom string
 }
 
 // ----------------------------------------------------------------------------- //
@@ -55,18 +93,13 @@ type budgetEditRow struct {
 // ----------------------------------------------------------------------------- //
 
 func (h *Handler) GetExpensesBudgets(w http.ResponseWriter, r *http.Request) {
-	data := h.tmplData(r)
-
-	rangeKey, mode, rows, editRows, ok := h.buildBudgetsPage(w, r)
+	page, ok := h.buildBudgetsPage(w, r)
 	if !ok {
 		return
 	}
 
-	data["budgetMode"] = string(mode)
-	data["dateRange"] = rangeKey
-	data["dateRanges"] = budgetDateRanges
-	data["rows"] = rows
-	data["editRows"] = editRows
+	data := h.tmplData(r)
+	setBudgetsPageData(data, page)
 
 	h.render(w, http.StatusOK, ExpensesBudgets, data)
 }
@@ -75,14 +108,14 @@ func (h *Handler) PostExpensesBudgets(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user := getCurrentUser(r)
 
-	amountByCategoryID, err := parseExpenseBudgetsForm(r)
+	month, params, err := parseExpenseBudgetsForm(r)
 	if err != nil {
 		h.renderBudgetsErr(w, r, err)
 
 		return
 	}
 
-	if err := h.store.SaveExpenseBudgets(ctx, user.ID, amountByCategoryID); err != nil {
+	if err := h.store.SaveExpenseBudgetsFrom(ctx, user.ID, month, params); err != nil {
 		h.renderBudgetsErr(w, r, err)
 
 		return
@@ -103,10 +136,7 @@ func (h *Handler) PostExpensesBudgets(w http.ResponseWriter, r *http.Request) {
 
 // buildBudgetsPage loads everything the budgets page renders. It reports false
 // once it has written an error response of its own.
-func (h *Handler) buildBudgetsPage(
-	w http.ResponseWriter,
-	r *http.Request,
-) (string, budgetMode, []budgetRow, []budgetEditRow, bool) {
+func (h *Handler) buildBudgetsPage(w http.ResponseWriter, r *http.Request) (budgetsPage, bool) {
 	ctx := r.Context()
 	user := getCurrentUser(r)
 
@@ -123,7 +153,7 @@ func (h *Handler) buildBudgetsPage(
 	if !ok {
 		h.renderErr(w, r, http.StatusInternalServerError, ExpensesBudgets, ErrUnknownDateRange)
 
-		return "", "", nil, nil, false
+		return budgetsPage{}, false
 	}
 
 	filters.FilterFields = append(filters.FilterFields,
@@ -131,55 +161,99 @@ func (h *Handler) buildBudgetsPage(
 		repo.FilterField{Name: "date", Value: dr.end, Operator: "<"},
 	)
 
-	monthTotals, err := h.store.FindExpensesCategoryMonthTotals(ctx, filters)
+	leafTotals, err := h.store.FindExpensesCategoryMonthTotals(ctx, filters, user.HomeCurrency, false)
 	if err != nil {
 		h.renderErr(w, r, http.StatusInternalServerError, ExpensesBudgets, err)
 
-		return "", "", nil, nil, false
+		return budgetsPage{}, false
 	}
 
-	budgets, err := h.store.FindExpenseBudgets(ctx, user.ID)
+	rolledUpTotals, err := h.store.FindExpensesCategoryMonthTotals(ctx, filters, user.HomeCurrency, true)
 	if err != nil {
 		h.renderErr(w, r, http.StatusInternalServerError, ExpensesBudgets, err)
 
-		return "", "", nil, nil, false
+		return budgetsPage{}, false
 	}
 
 	categories, categoryNameByID, ok := h.findCategoriesOrErr(w, r, ExpensesBudgets)
 	if !ok {
-		return "", "", nil, nil, false
+		return budgetsPage{}, false
+	}
+
+	monthTotals := budgetMonthTotals(categories, leafTotals, rolledUpTotals)
+	monthKeys := withSpentMonths(budgetMonths(dr, time.Now()), monthTotals)
+
+	measured, err := h.store.MeasureExpenseBudgets(ctx, user.ID, user.HomeCurrency, monthKeys)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ExpensesBudgets, err)
+
+		return budgetsPage{}, false
+	}
+
+	versions, err := h.store.FindExpenseBudgetVersions(ctx, user.ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ExpensesBudgets, err)
+
+		return budgetsPage{}, false
 	}
 
-	budgetByCategoryID := make(map[int]uint64, len(budgets))
-	for _, b := range budgets {
-		budgetByCategoryID[b.CategoryID] = b.Amount
+	budgetsByKey := make(map[int]map[string]logic.ExpenseBudgetMonth)
+	for _, m := range measured {
+		key := 0
+		if m.CategoryID != nil {
+			key = *m.CategoryID
+		}
+		if budgetsByKey[key] == nil {
+			budgetsByKey[key] = make(map[string]logic.ExpenseBudgetMonth)
+		}
+		budgetsByKey[key][m.Month] = m
 	}
 
-	rows := buildBudgetRows(monthTotals, budgetByCategoryID, categoryNameByID, mode, budgetMonths(dr, time.Now()))
-	editRows := buildBudgetEditRows(categories, budgetByCategoryID)
+	page := budgetsPage{
+		rangeKey:      rangeKey,
+		mode:          mode,
+		rows:          buildBudgetRows(monthTotals, budgetsByKey, categoryNameByID, mode, monthKeys),
+		versionRows:   buildBudgetVersionRows(versions, categoryNameByID),
+		effectiveFrom: time.Now().UTC().Format(logic.BudgetMonthLayout),
+	}
 
-	return rangeKey, mode, rows, editRows, true
+	if total, ok := budgetsByKey[0]; ok {
+		row := buildBudgetRow(budgetTotalName, wholeMonthTotals(leafTotals), total, mode, monthKeys)
+		page.totalRow = &row
+	}
+
+	page.editRows, page.totalEditRow = buildBudgetEditRows(categories, categoryNameByID, versions, page.effectiveFrom)
+
+	return page, true
 }
 
 // renderBudgetsErr re-renders the page with the form error shown. The page data
 // is rebuilt from scratch: the failed submission changed nothing.
 func (h *Handler) renderBudgetsErr(w http.ResponseWriter, r *http.Request, err error) {
-	rangeKey, mode, rows, editRows, ok := h.buildBudgetsPage(w, r)
+	page, ok := h.buildBudgetsPage(w, r)
 	if !ok {
 		return
 	}
 
 	data := h.tmplData(r)
-	data["budgetMode"] = string(mode)
-	data["dateRange"] = rangeKey
-	data["dateRanges"] = budgetDateRanges
-	data["rows"] = rows
-	data["editRows"] = editRows
+	setBudgetsPageData(data, page)
 	data["error"] = err.Error()
 
 	h.render(w, http.StatusBadRequest, ExpensesBudgets, data)
 }
 
+func setBudgetsPageData(data map[string]any, page budgetsPage) {
+	data["budgetMode"] = string(page.mode)
+	data["dateRange"] = page.rangeKey
+	data["dateRanges"] = budgetDateRanges
+	data["rows"] = page.rows
+	data["totalRow"] = page.totalRow
+	data["editRows"] = page.editRows
+	data["totalEditRow"] = page.totalEditRow
+	data["versionRows"] = page.versionRows
+	data["effectiveFrom"] = page.effectiveFrom
+}
+
 // budgetRangeKey reads the requested range. A GET carries it in the query; the
 // POST form posts it in the body, and the failed-submission re-render has to
 // find it there or the page snaps back to this_month.
@@ -210,75 +284,76 @@ func budgetMonths(dr dateRange, now time.Time) []string {
 
 	months := make([]string, 0, 12)
 	for m := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC); !m.After(last); m = m.AddDate(0, 1, 0) {
-		months = append(months, m.Format(budgetMonthLayout))
+		months = append(months, m.Format(logic.BudgetMonthLayout))
 	}
 
 	if len(months) == 0 {
-		months = append(months, start.Format(budgetMonthLayout))
+		months = append(months, start.Format(logic.BudgetMonthLayout))
 	}
 
 	return months
 }
 
+// budgetMonthTotals picks the totals each category's budget is measured
+// against: a parent's budget caps everything under it, so parents take the
+// rolled-up totals and every other category its own.
+func budgetMonthTotals(
+	categories []repo.Category,
+	leafTotals, rolledUpTotals []repo.ExpenseCategoryMonthTotal,
+) []repo.ExpenseCategoryMonthTotal {
+	isParent := make(map[int]bool, len(categories))
+	for _, c := range categories {
+		if c.ParentID != nil {
+			isParent[*c.ParentID] = true
+		}
+	}
+
+	totals := make([]repo.ExpenseCategoryMonthTotal, 0, len(leafTotals))
+	for _, t := range leafTotals {
+		if !isParent[t.CategoryID] {
+			totals = append(totals, t)
+		}
+	}
+	for _, t := range rolledUpTotals {
+		if isParent[t.CategoryID] {
+			totals = append(totals, t)
+		}
+	}
+
+	return totals
+}
+
 func buildBudgetRows(
 	monthTotals []repo.ExpenseCategoryMonthTotal,
-	budgetByCategoryID map[int]uint64,
+	budgetsByKey map[int]map[string]logic.ExpenseBudgetMonth,
 	categoryNameByID map[int]string,
 	mode budgetMode,
 	monthKeys []string,
 ) []budgetRow {
-	totalByCategoryID := make(map[int]uint64, len(monthTotals))
 	monthsByCategoryID := make(map[int][]repo.ExpenseCategoryMonthTotal, len(monthTotals))
-
 	for _, t := range monthTotals {
-		totalByCategoryID[t.CategoryID] += t.Total
 		monthsByCategoryID[t.CategoryID] = append(monthsByCategoryID[t.CategoryID], t)
 	}
 
-	monthKeys = withSpentMonths(monthKeys, monthTotals)
-	monthCount := len(monthKeys)
-
-	categoryIDs := make([]int, 0, len(totalByCategoryID)+len(budgetByCategoryID))
-	for categoryID := range totalByCategoryID {
+	categoryIDs := make([]int, 0, len(monthsByCategoryID)+len(budgetsByKey))
+	for categoryID := range monthsByCategoryID {
 		categoryIDs = append(categoryIDs, categoryID)
 	}
-	for categoryID := range budgetByCategoryID {
-		if _, seen := totalByCategoryID[categoryID]; !seen {
+	for categoryID := range budgetsByKey {
+		if _, seen := monthsByCategoryID[categoryID]; !seen && categoryID != 0 {
 			categoryIDs = append(categoryIDs, categoryID)
 		}
 	}
 
 	rows := make([]budgetRow, 0, len(categoryIDs))
 	for _, categoryID := range categoryIDs {
-		budget := budgetByCategoryID[categoryID]
-		total := totalByCategoryID[categoryID]
-
-		row := budgetRow{
-			CategoryName: categoryNameOrUnknown(categoryNameByID, categoryID),
-			Total:        total,
-			HasBudget:    budget > 0,
-			Budget:       budget,
-			MonthCount:   monthCount,
-		}
-
-		if row.HasBudget {
-			row.Left = budgetLeft(budget, total)
-			row.Pct, row.BarPct = budgetPercent(total, budget)
-
-			if mode == budgetModeMonths {
-				row.Months, row.MonthsOver = buildBudgetMonthRows(monthsByCategoryID[categoryID], monthKeys, budget)
-				row.AvgPerMonth = total / uint64(monthCount) //nolint:gosec // budgetMonths returns at least one month
-				// Budget is a monthly amount, so a multi-month total is not
-				// comparable to it — $700 spent over six months against a $500
-				// monthly budget is well under. Only a month that individually
-				// exceeded the budget makes the row over.
-				row.Over = row.MonthsOver > 0
-			} else {
-				row.Over = total > budget
-			}
-		}
-
-		rows = append(rows, row)
+		rows = append(rows, buildBudgetRow(
+			categoryNameOrUnknown(categoryNameByID, categoryID),
+			monthsByCategoryID[categoryID],
+			budgetsByKey[categoryID],
+			mode,
+			monthKeys,
+		))
 	}
 
 	sort.Slice(rows, func(i, j int) bool {
@@ -288,6 +363,64 @@ func buildBudgetRows(
 	return rows
 }
 
+// buildBudgetRow measures one budget's spending against the months it is in
+// force. budgetByMonth is empty for a category without a budget, which still
+// gets a row for its spending.
+func buildBudgetRow(
+	name string,
+	totals []repo.ExpenseCategoryMonthTotal,
+	budgetByMonth map[string]logic.ExpenseBudgetMonth,
+	mode budgetMode,
+	monthKeys []string,
+) budgetRow {
+	var total uint64
+	for _, t := range totals {
+		total += t.Total
+	}
+
+	monthCount := len(monthKeys)
+	row := budgetRow{
+		CategoryName: name,
+		Total:        total,
+		HasBudget:    len(budgetByMonth) > 0,
+		MonthCount:   monthCount,
+	}
+	if !row.HasBudget {
+		return row
+	}
+
+	if mode == budgetModeMonths {
+		latest := budgetByMonth[latestBudgetMonth(budgetByMonth)]
+		row.Budget = latest.Amount
+		row.Rollover = latest.Rollover
+		row.Months, row.MonthsOver = buildBudgetMonthRows(totals, monthKeys, budgetByMonth)
+		row.AvgPerMonth = total / uint64(monthCount) //nolint:gosec // budgetMonths returns at least one month
+		// Budget is a monthly amount, so a multi-month total is not
+		// comparable to it — $700 spent over six months against a $500
+		// monthly budget is well under. Only a month that individually
+		// exceeded the budget makes the row over.
+		row.Over = row.MonthsOver > 0
+
+		return row
+	}
+
+	// A single-month range has one month key, the month itself.
+	month := budgetByMonth[monthKeys[0]]
+	available := month.Available()
+	row.Budget = month.Amount
+	row.Carry = month.Carry
+	row.Rollover = month.Rollover
+	row.HasBudget = month.Amount > 0
+	if !row.HasBudget {
+		return row
+	}
+	row.Left = budgetLeft(available, total)
+	row.Pct, row.BarPct = budgetPercent(total, available)
+	row.Over = budgetOver(total, available)
+
+	return row
+}
+
 // withSpentMonths adds any month that carries spending but falls outside the
 // clamped month list, so no expense is counted in a row total without a bar to
 // account for it. An expense may be dated ahead of today — a purchase made now
@@ -317,11 +450,12 @@ func withSpentMonths(monthKeys []string, totals []repo.ExpenseCategoryMonthTotal
 
 // buildBudgetMonthRows renders one bar per month in range, not per month that
 // happened to have spending. A month with nothing spent is under budget and has
-// to appear, or the list contradicts the "N of M months" count beside it.
+// to appear, or the list contradicts the "N of M months" count beside it. Each
+// month is measured against the budget in force that month, carry included.
 func buildBudgetMonthRows(
 	totals []repo.ExpenseCategoryMonthTotal,
 	monthKeys []string,
-	budget uint64,
+	budgetByMonth map[string]logic.ExpenseBudgetMonth,
 ) ([]budgetMonthRow, int) {
 	totalByMonth := make(map[string]uint64, len(totals))
 	for _, t := range totals {
@@ -333,13 +467,17 @@ func buildBudgetMonthRows(
 
 	for _, key := range monthKeys {
 		total := totalByMonth[key]
-		pct, barPct := budgetPercent(total, budget)
+		budget, ok := budgetByMonth[key]
 		month := budgetMonthRow{
 			Month:  key,
 			Total:  total,
-			Pct:    pct,
-			BarPct: barPct,
-			Over:   total > budget,
+			Budget: budget.Amount,
+			Carry:  budget.Carry,
+		}
+
+		if ok {
+			month.Pct, month.BarPct = budgetPercent(total, budget.Available())
+			month.Over = budgetOver(total, budget.Available())
 		}
 
 		if month.Over {
@@ -352,26 +490,55 @@ func buildBudgetMonthRows(
 	return months, over
 }
 
+// latestBudgetMonth returns the last month a budget is in force in.
+func latestBudgetMonth(budgetByMonth map[string]logic.ExpenseBudgetMonth) string {
+	latest := ""
+	for month := range budgetByMonth {
+		latest = max(latest, month)
+	}
+
+	return latest
+}
+
+// wholeMonthTotals folds the leaf totals into one total per month, which the
+// whole-month budget is measured against. Leaf totals count every expense
+// once; the rolled-up ones would count a child's twice, under it and under its
+// parent.
+func wholeMonthTotals(leafTotals []repo.ExpenseCategoryMonthTotal) []repo.ExpenseCategoryMonthTotal {
+	totalByMonth := make(map[string]uint64)
+	for _, t := range leafTotals {
+		totalByMonth[t.Month] += t.Total
+	}
+
+	totals := make([]repo.ExpenseCategoryMonthTotal, 0, len(totalByMonth))
+	for month, total := range totalByMonth {
+		totals = append(totals, repo.ExpenseCategoryMonthTotal{Month: month, Total: total})
+	}
+
+	return totals
+}
+
 // budgetLeft is the signed remainder of a budget. Both operands are cent
 // amounts one person entered by hand, so neither half of the subtraction can
 // approach the int64 range.
-func budgetLeft(budget, total uint64) int64 {
-	if budget >= total {
-		return int64(budget - total) //nolint:gosec // cent amount, far below int64 max
-	}
+func budgetLeft(available int64, total uint64) int64 {
+	return available - int64(total) //nolint:gosec // cent amount, far below int64 max
+}
 
-	return -int64(total - budget) //nolint:gosec // cent amount, far below int64 max
+func budgetOver(total uint64, available int64) bool {
+	return int64(total) > available //nolint:gosec // cent amount, far below int64 max
 }
 
 // budgetPercent returns the true percent and the percent clamped to 100 for the
-// <progress> element. A zero budget never reaches here — a cleared amount is
-// deleted rather than stored — but it is guarded anyway, since it would divide.
-func budgetPercent(total, budget uint64) (int, int) {
-	if budget == 0 {
-		return 0, 0
+// <progress> element. available is the month's budget plus its carry, which an
+// overspent earlier month can bring to zero or below; nothing is left to
+// spend then, so the bar reads full.
+func budgetPercent(total uint64, available int64) (int, int) {
+	if available <= 0 {
+		return 100, 100
 	}
 
-	pct := int(total * 100 / budget) //nolint:gosec // both operands are page-sized cent amounts
+	pct := int(int64(total) * 100 / available) //nolint:gosec // both operands are page-sized cent amounts
 	if pct > 100 {
 		return pct, 100
 	}
@@ -379,13 +546,42 @@ func budgetPercent(total, budget uint64) (int, int) {
 	return pct, pct
 }
 
-func buildBudgetEditRows(categories []repo.Category, budgetByCategoryID map[int]uint64) []budgetEditRow {
+// buildBudgetEditRows prefills the form with the budgets in force in month,
+// and returns the whole-month budget's row apart. It leaves out archived
+// categories unless they still carry a budget, which the user must be able to
+// clear.
+func buildBudgetEditRows(
+	categories []repo.Category,
+	categoryNameByID map[int]string,
+	versions []repo.ExpenseBudget,
+	month string,
+) ([]budgetEditRow, budgetEditRow) {
+	inForce := make(map[int]repo.ExpenseBudget)
+	for _, v := range versions {
+		if v.EffectiveFrom > month {
+			continue
+		}
+
+		key := 0
+		if v.CategoryID != nil {
+			key = *v.CategoryID
+		}
+		// Versions arrive oldest first within each budget.
+		inForce[key] = v
+	}
+
 	rows := make([]budgetEditRow, 0, len(categories))
 	for _, category := range categories {
+		budget := inForce[category.ID]
+		if category.ArchivedAt != nil && budget.Amount == 0 {
+			continue
+		}
+
 		rows = append(rows, budgetEditRow{
 			CategoryID: category.ID,
-			Name:       category.Name,
-			Amount:     budgetByCategoryID[category.ID],
+			Name:       categoryNameByID[category.ID],
+			Amount:     budget.Amount,
+			Rollover:   budget.Rollover,
 		})
 	}
 
@@ -393,26 +589,70 @@ func buildBudgetEditRows(categories []repo.Category, budgetByCategoryID map[int]
 		return rows[i].Name < rows[j].Name
 	})
 
+	total := inForce[0]
+
+	return rows, budgetEditRow{Name: budgetTotalName, Amount: total.Amount, Rollover: total.Rollover}
+}
+
+// buildBudgetVersionRows lists every budget version, newest first, so the
+// user can see when each amount took effect.
+func buildBudgetVersionRows(versions []repo.ExpenseBudget, categoryNameByID map[int]string) []budgetVersionRow {
+	rows := make([]budgetVersionRow, 0, len(versions))
+	for _, v := range versions {
+		name := budgetTotalName
+		if v.CategoryID != nil {
+			name = categoryNameOrUnknown(categoryNameByID, *v.CategoryID)
+		}
+
+		rows = append(rows, budgetVersionRow{
+			EffectiveFrom: v.EffectiveFrom,
+			Name:          name,
+			Amount:        v.Amount,
+			Rollover:      v.Rollover,
+		})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].EffectiveFrom != rows[j].EffectiveFrom {
+			return rows[i].EffectiveFrom > rows[j].EffectiveFrom
+		}
+
+		return rows[i].Name < rows[j].Name
+	})
+
 	return rows
 }
 
-// parseExpenseBudgetsForm reads the budget_<categoryID> fields. An empty field
-// means no budget and arrives as zero, which SaveExpenseBudgets deletes.
-func parseExpenseBudgetsForm(r *http.Request) (map[int]uint64, error) {
+// parseExpenseBudgetsForm reads the budget_<categoryID> and rollover_<categoryID>
+// fields, the whole-month budget_total and rollover_total, and the month they
+// apply from, which defaults to the current one. An empty amount means no
+// budget and arrives as zero, which ends the budget.
+func parseExpenseBudgetsForm(r *http.Request) (string, []logic.ExpenseBudgetParams, error) {
 	if err := r.ParseForm(); err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrParseForm, err)
+		return "", nil, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	month := strings.TrimSpace(r.FormValue("effective_from"))
+	if month == "" {
+		month = time.Now().UTC().Format(logic.BudgetMonthLayout)
 	}
 
-	amountByCategoryID := make(map[int]uint64)
+	var params []logic.ExpenseBudgetParams
 
 	for field, values := range r.Form {
 		if !strings.HasPrefix(field, budgetFieldPrefix) {
 			continue
 		}
 
-		categoryID, err := strconv.Atoi(strings.TrimPrefix(field, budgetFieldPrefix))
-		if err != nil || categoryID < 1 {
-			return nil, ErrBudgetCategoryField
+		categoryID := 0
+		rolloverField := rolloverTotalField
+		if field != budgetTotalField {
+			id, err := strconv.Atoi(strings.TrimPrefix(field, budgetFieldPrefix))
+			if err != nil || id < 1 {
+				return "", nil, ErrBudgetCategoryField
+			}
+			categoryID = id
+			rolloverField = rolloverFieldPrefix + strconv.Itoa(id)
 		}
 
 		raw := ""
@@ -420,19 +660,21 @@ func parseExpenseBudgetsForm(r *http.Request) (map[int]uint64, error) {
 			raw = strings.TrimSpace(values[0])
 		}
 
-		if raw == "" {
-			amountByCategoryID[categoryID] = 0
-
-			continue
-		}
-
-		amount, err := prog.ParseAmount(raw)
-		if err != nil {
-			return nil, err
+		var amount uint64
+		if raw != "" {
+			parsed, err := prog.ParseAmount(raw)
+			if err != nil {
+				return "", nil, err
+			}
+			amount = parsed
 		}
 
-		amountByCategoryID[categoryID] = amount
+		params = append(params, logic.ExpenseBudgetParams{
+			CategoryID: categoryID,
+			Amount:     amount,
+			Rollover:   r.Form.Get(rolloverField) != "",
+		})
 	}
 
-	return amountByCategoryID, nil
+	return month, params, nil
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ad9311/ninete/internal/logic"
 	"github.com/ad9311/ninete/internal/spec"
 	"github.com/stretchr/testify/require"
 )
@@ -34,7 +35,7 @@ func TestGetExpensesBudgets(t *testing.T) {
 			name: "should_render_single_month_columns_for_this_month",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "budget_month", "budget_month@example.com", "budget_password_1")
-				category := s.CreateCategory(t, "budget month category")
+				category := s.CreateCategory(t, user.ID, "budget month category")
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "budget month expense", 60000, monthStart(0)))
 				s.SaveExpenseBudgets(t, user.ID, map[int]uint64{category.ID: 50000})
 				cookies := s.AuthCookies(t, "budget_month@example.com", "budget_password_1")
@@ -53,11 +54,37 @@ func TestGetExpensesBudgets(t *testing.T) {
 				require.Contains(t, body, "-$100.00")
 			},
 		},
+		{
+			name: "should_measure_a_parent_budget_against_its_children",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "budget_parent", "budget_parent@example.com", "budget_password_9")
+				parent := s.CreateCategory(t, user.ID, "budget parent category")
+				child := s.CreateCategory(t, user.ID, "budget child category")
+				_, err := s.Store.SetCategoryParent(t.Context(), child.ID, user.ID, &parent.ID)
+				require.NoError(t, err)
+				s.CreateExpense(t, user.ID, newExpenseParams(parent.ID, "budget parent expense", 20000, monthStart(0)))
+				s.CreateExpense(t, user.ID, newExpenseParams(child.ID, "budget child expense", 40000, monthStart(0)))
+				s.SaveExpenseBudgets(t, user.ID, map[int]uint64{parent.ID: 50000, child.ID: 80000})
+				cookies := s.AuthCookies(t, "budget_parent@example.com", "budget_password_9")
+
+				req := spec.NewGetRequest("/expenses/budgets", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				body := rec.Body.String()
+				require.Contains(t, body, "budget parent category › budget child category")
+				// The parent takes in its child: 600 against 500 is 120%; the
+				// child alone is 400 against 800.
+				require.Contains(t, body, "120%")
+				require.Contains(t, body, "50%")
+			},
+		},
 		{
 			name: "should_render_per_month_rows_for_six_months",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "budget_months", "budget_months@example.com", "budget_password_2")
-				category := s.CreateCategory(t, "budget months category")
+				category := s.CreateCategory(t, user.ID, "budget months category")
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "budget months expense a", 40000, monthStart(-1)))
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "budget months expense b", 70000, monthStart(-2)))
 				s.SaveExpenseBudgets(t, user.ID, map[int]uint64{category.ID: 50000})
@@ -82,7 +109,7 @@ func TestGetExpensesBudgets(t *testing.T) {
 			name: "should_not_flag_a_multi_month_row_whose_every_month_stayed_under",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "budget_under", "budget_under@example.com", "budget_password_5")
-				category := s.CreateCategory(t, "budget under category")
+				category := s.CreateCategory(t, user.ID, "budget under category")
 				// 60000 total against a 50000 monthly budget, but 30000 in each
 				// of two months: never over in any single month.
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "budget under a", 30000, monthStart(-1)))
@@ -107,7 +134,7 @@ func TestGetExpensesBudgets(t *testing.T) {
 			name: "should_count_exactly_six_months_for_six_months",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "budget_six", "budget_six@example.com", "budget_password_6")
-				category := s.CreateCategory(t, "budget six category")
+				category := s.CreateCategory(t, user.ID, "budget six category")
 				s.SaveExpenseBudgets(t, user.ID, map[int]uint64{category.ID: 50000})
 				cookies := s.AuthCookies(t, "budget_six@example.com", "budget_password_6")
 
@@ -132,7 +159,7 @@ func TestGetExpensesBudgets(t *testing.T) {
 			name: "should_count_only_elapsed_months_for_this_year",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "budget_year", "budget_year@example.com", "budget_password_7")
-				category := s.CreateCategory(t, "budget year category")
+				category := s.CreateCategory(t, user.ID, "budget year category")
 				s.SaveExpenseBudgets(t, user.ID, map[int]uint64{category.ID: 50000})
 				cookies := s.AuthCookies(t, "budget_year@example.com", "budget_password_7")
 
@@ -149,7 +176,7 @@ func TestGetExpensesBudgets(t *testing.T) {
 			name: "should_fall_back_to_this_month_for_an_unsupported_range",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "budget_range", "budget_range@example.com", "budget_password_3")
-				category := s.CreateCategory(t, "budget range category")
+				category := s.CreateCategory(t, user.ID, "budget range category")
 				s.SaveExpenseBudgets(t, user.ID, map[int]uint64{category.ID: 50000})
 				cookies := s.AuthCookies(t, "budget_range@example.com", "budget_password_3")
 
@@ -177,7 +204,7 @@ func TestPostExpensesBudgets(t *testing.T) {
 	handler := s.WrappedHandler()
 
 	user := s.CreateAuthUser(t, "budget_post", "budget_post@example.com", "budget_password_4")
-	category := s.CreateCategory(t, "budget post category")
+	category := s.CreateCategory(t, user.ID, "budget post category")
 	cookies := s.AuthCookies(t, "budget_post@example.com", "budget_password_4")
 
 	cases := []struct {
@@ -258,6 +285,55 @@ func TestPostExpensesBudgets(t *testing.T) {
 				require.NotContains(t, body, "<th>Left</th>")
 			},
 		},
+		{
+			name: "should_save_a_whole_month_budget_with_rollover_from_a_month",
+			fn: func(t *testing.T) {
+				csrfToken, formCookies := s.CSRFFrom(t, "/expenses/budgets", cookies)
+				body := fmt.Sprintf(
+					"budget_total=80000&rollover_total=1&budget_%d=&effective_from=%s&date_range=this_month",
+					category.ID,
+					monthLabel(0),
+				)
+
+				req := spec.NewPostRequest("/expenses/budgets", body, formCookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				versions, err := s.Store.FindExpenseBudgetVersions(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Len(t, versions, 1)
+				require.Nil(t, versions[0].CategoryID)
+				require.Equal(t, uint64(80000), versions[0].Amount)
+				require.True(t, versions[0].Rollover)
+				require.Equal(t, monthLabel(0), versions[0].EffectiveFrom)
+
+				req = spec.NewGetRequest("/expenses/budgets", cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				page := rec.Body.String()
+				require.Contains(t, page, "All categories")
+				require.Contains(t, page, "Budget history")
+				require.Contains(t, page, "$800.00")
+			},
+		},
+		{
+			name: "should_reject_a_malformed_effective_month",
+			fn: func(t *testing.T) {
+				csrfToken, formCookies := s.CSRFFrom(t, "/expenses/budgets", cookies)
+				body := fmt.Sprintf("budget_%d=100&effective_from=2026-13", category.ID)
+
+				req := spec.NewPostRequest("/expenses/budgets", body, formCookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), logic.ErrBudgetMonth.Error())
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// expenseDuplicateCluster is one group on the review page. Expenses are oldest
+// first; merging keeps the first.
+type expenseDuplicateCluster struct {
+	Expenses []expenseRow
+	KeepID   int
+	DropIDs  []int
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) GetExpensesDuplicates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+
+	clusters, err := h.store.FindDuplicateExpenseClusters(ctx, user.ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ExpensesDuplicates, err)
+
+		return
+	}
+
+	_, categoryNameByID, ok := h.findCategoriesOrErr(w, r, ExpensesDuplicates)
+	if !ok {
+		return
+	}
+
+	var expenseIDs []int
+	for _, cluster := range clusters {
+		for _, expense := range cluster {
+			expenseIDs = append(expenseIDs, expense.ID)
+		}
+	}
+
+	tagRows, err := h.store.FindTagRows(ctx, repo.TaggableTypeExpense, "expenses", expenseIDs, user.ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ExpensesDuplicates, err)
+
+		return
+	}
+	tagNames := repo.TagNamesByTargetID(tagRows)
+
+	rows := make([]expenseDuplicateCluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		row := expenseDuplicateCluster{KeepID: cluster[0].ID}
+		for i, expense := range cluster {
+			row.Expenses = append(row.Expenses, expenseRow{
+				ID:           expense.ID,
+				CategoryName: categoryNameOrUnknown(categoryNameByID, expense.CategoryID),
+				Description:  expense.Description,
+				Amount:       expense.Amount,
+				Currency:     expense.Currency,
+				Date:         expense.Date,
+				CreatedAt:    expense.CreatedAt,
+				Tags:         tagNames[expense.ID],
+			})
+			if i > 0 {
+				row.DropIDs = append(row.DropIDs, expense.ID)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	data["clusters"] = rows
+
+	h.render(w, http.StatusOK, ExpensesDuplicates, data)
+}
+
+// PostExpensesDuplicatesMerge keeps one expense of a cluster and folds the
+// others into it.
+func (h *Handler) PostExpensesDuplicatesMerge(w http.ResponseWriter, r *http.Request) {
+	user := getCurrentUser(r)
+
+	if err := r.ParseForm(); err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, ExpensesDuplicates, fmt.Errorf("%w: %w", ErrParseForm, err))
+
+		return
+	}
+
+	keepID, err := prog.ParseID(r.FormValue("keep_id"), "Expense ID")
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, ExpensesDuplicates, err)
+
+		return
+	}
+
+	dropIDs := make([]int, 0, len(r.Form["drop_ids"]))
+	for _, raw := range r.Form["drop_ids"] {
+		id, err := prog.ParseID(raw, "Expense ID")
+		if err != nil {
+			h.renderErr(w, r, http.StatusBadRequest, ExpensesDuplicates, err)
+
+			return
+		}
+		dropIDs = append(dropIDs, id)
+	}
+
+	err = h.store.MergeDuplicateExpenses(r.Context(), user.ID, keepID, dropIDs)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		if errors.Is(err, logic.ErrNotDuplicates) {
+			h.renderErr(w, r, http.StatusBadRequest, ExpensesDuplicates, err)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ExpensesDuplicates, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/expenses/duplicates", http.StatusSeeOther)
+}
+
+// setDuplicateData fills the prompt shown when a new expense matches existing
+// ones. categoryID carries a quick-add category choice through the re-render.
+func setDuplicateData(
+	data map[string]any,
+	matches []repo.Expense,
+	categories []repo.Category,
+	categoryID int,
+) {
+	categoryNameByID := make(map[int]string, len(categories))
+	for _, category := range categories {
+		categoryNameByID[category.ID] = category.Name
+	}
+
+	rows := make([]expenseRow, 0, len(matches))
+	for _, expense := range matches {
+		rows = append(rows, expenseRow{
+			ID:           expense.ID,
+			CategoryName: categoryNameOrUnknown(categoryNameByID, expense.CategoryID),
+			Description:  expense.Description,
+			Amount:       expense.Amount,
+			Currency:     expense.Currency,
+			Date:         expense.Date,
+			CreatedAt:    expense.CreatedAt,
+		})
+	}
+
+	data["duplicates"] = rows
+	data["quickCategoryID"] = categoryID
+}
@@ -0,0 +1,190 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostExpensesDuplicatePrompt(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	user := s.CreateAuthUser(t, "dup_h_1", "dup_h_1@example.com", "dup_password_1")
+	category := s.CreateCategory(t, user.ID, "dup_h_cat_1")
+	cookies := s.AuthCookies(t, "dup_h_1@example.com", "dup_password_1")
+	csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+
+	s.CreateExpense(t, user.ID, logic.ExpenseParams{
+		ExpenseBaseParams: logic.ExpenseBaseParams{
+			CategoryID:  category.ID,
+			Description: "Electric bill",
+			Amount:      4200,
+		},
+		Date: 1768435200, // 2026-01-15
+	})
+
+	form := func(action string) url.Values {
+		values := url.Values{
+			"category_id": {fmt.Sprintf("%d", category.ID)},
+			"description": {"electric bill"},
+			"amount":      {"4200"},
+			"date":        {"2026-01-15T00:00:00Z"},
+		}
+		if action != "" {
+			values.Set("duplicate_action", action)
+		}
+
+		return values
+	}
+
+	countExpenses := func(t *testing.T) int {
+		count, err := s.Queries.CountExpensesByUser(t.Context(), user.ID)
+		require.NoError(t, err)
+
+		return count
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_ask_before_saving_a_duplicate",
+			fn: func(t *testing.T) {
+				req := spec.NewPostRequest("/expenses", form("").Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+				require.Contains(t, rec.Body.String(), "Save anyway")
+				require.Equal(t, 1, countExpenses(t))
+			},
+		},
+		{
+			name: "should_skip_when_chosen",
+			fn: func(t *testing.T) {
+				req := spec.NewPostRequest("/expenses", form("skip").Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, 1, countExpenses(t))
+			},
+		},
+		{
+			name: "should_ask_on_quick_add_and_keep_the_category",
+			fn: func(t *testing.T) {
+				quick := url.Values{
+					"quick_input": {"Electric bill, 42, 2026-01-15"},
+					"category_id": {fmt.Sprintf("%d", category.ID)},
+				}
+				req := spec.NewPostRequest("/expenses/quick", quick.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+				require.Contains(t, rec.Body.String(), fmt.Sprintf(`name="category_id" value="%d"`, category.ID))
+			},
+		},
+		{
+			name: "should_force_create_when_chosen",
+			fn: func(t *testing.T) {
+				req := spec.NewPostRequest("/expenses", form("force").Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, 2, countExpenses(t))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestExpensesDuplicatesPage(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	user := s.CreateAuthUser(t, "dup_h_2", "dup_h_2@example.com", "dup_password_2")
+	other := s.CreateAuthUser(t, "dup_h_3", "dup_h_3@example.com", "dup_password_3")
+	category := s.CreateCategory(t, user.ID, "dup_h_cat_2")
+	cookies := s.AuthCookies(t, "dup_h_2@example.com", "dup_password_2")
+	csrfToken, cookies := s.CSRFFrom(t, "/expenses/duplicates", cookies)
+
+	params := logic.ExpenseParams{
+		ExpenseBaseParams: logic.ExpenseBaseParams{
+			CategoryID:  category.ID,
+			Description: "Water bill",
+			Amount:      3100,
+		},
+		Date: 1768435200,
+	}
+	keep := s.CreateExpense(t, user.ID, params)
+	drop := s.CreateExpense(t, user.ID, params)
+	params.CategoryID = s.CreateCategory(t, other.ID, "dup_h_cat_2").ID
+	foreign := s.CreateExpense(t, other.ID, params)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_list_clusters",
+			fn: func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, spec.NewGetRequest("/expenses/duplicates", cookies))
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Water bill")
+				require.Contains(t, rec.Body.String(), fmt.Sprintf(`name="drop_ids" value="%d"`, drop.ID))
+			},
+		},
+		{
+			name: "should_not_merge_another_users_expense",
+			fn: func(t *testing.T) {
+				form := url.Values{
+					"keep_id":  {fmt.Sprintf("%d", keep.ID)},
+					"drop_ids": {fmt.Sprintf("%d", foreign.ID)},
+				}
+				req := spec.NewPostRequest("/expenses/duplicates/merge", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name: "should_merge_and_redirect",
+			fn: func(t *testing.T) {
+				form := url.Values{
+					"keep_id":  {fmt.Sprintf("%d", keep.ID)},
+					"drop_ids": {fmt.Sprintf("%d", drop.ID)},
+				}
+				req := spec.NewPostRequest("/expenses/duplicates/merge", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/expenses/duplicates", rec.Header().Get("Location"))
+
+				count, err := s.Queries.CountExpensesByUser(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+)
+
+// maxImportFormMemory is how much of a multipart upload is held in memory
+// before spilling to disk. The body limit middleware caps uploads at 1 MB, so
+// in practice the whole file stays in memory.
+const maxImportFormMemory = 1 << 20
+
+type expenseImportPreviewRow struct {
+	Line         int
+	Description  string
+	Amount       uint64
+	Date         int64
+	CategoryName string
+	Tags         []string
+	Error        string
+	Duplicate    bool
+}
+
+// importDuplicateActions are the choices the preview offers for rows that
+// match existing expenses. Asking again is not one of them.
+var importDuplicateActions = []struct { //nolint:gochecknoglobals // static option list
+	Value string
+	Label string
+}{
+	{logic.DuplicateActionSkip, "Skip them"},
+	{logic.DuplicateActionMerge, "Merge their tags into the existing expense"},
+	{logic.DuplicateActionForce, "Import them anyway"},
+}
+
+type expenseImportColumn struct {
+	Index int
+	Name  string
+}
+
+// expenseImportField is one column select on the preview page.
+type expenseImportField struct {
+	Label    string
+	Name     string
+	Selected int
+	Optional bool
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) GetExpensesImport(w http.ResponseWriter, r *http.Request) {
+	h.renderPage(w, r, http.StatusOK, ExpensesImport)
+}
+
+// PostExpensesImportPreview reads an uploaded CSV, or the CSV carried over from
+// a previous preview, and shows every row as it would be imported. Nothing is
+// written; the preview page posts the same CSV and mapping to PostExpensesImport.
+func (h *Handler) PostExpensesImportPreview(w http.ResponseWriter, r *http.Request) {
+	csvText, csvData, err := parseImportUpload(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, ExpensesImport, err)
+
+		return
+	}
+
+	mapping, err := parseImportMapping(r, csvData.Header)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, ExpensesImport, err)
+
+		return
+	}
+
+	h.renderImportPreview(w, r, http.StatusOK, csvText, csvData, mapping, nil)
+}
+
+// PostExpensesImport commits a previewed CSV. If the import is refused the
+// preview is shown again with the reason, so the mapping can be adjusted.
+func (h *Handler) PostExpensesImport(w http.ResponseWriter, r *http.Request) {
+	user := getCurrentUser(r)
+
+	csvText, csvData, err := parseImportUpload(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, ExpensesImport, err)
+
+		return
+	}
+
+	mapping, err := parseImportMapping(r, csvData.Header)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, ExpensesImport, err)
+
+		return
+	}
+
+	skipInvalid := r.FormValue("skip_invalid") == "on"
+	_, err = h.store.ImportExpenses(
+		r.Context(), user.ID, csvData, mapping, parseTZOffset(r), skipInvalid, r.FormValue("duplicate_action"),
+	)
+	if err != nil {
+		h.renderImportPreview(w, r, http.StatusBadRequest, csvText, csvData, mapping, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/expenses", http.StatusSeeOther)
+}
+
+// renderImportPreview resolves the rows for display and renders the preview
+// page. A non-nil err is shown above the table.
+func (h *Handler) renderImportPreview(
+	w http.ResponseWriter,
+	r *http.Request,
+	status int,
+	csvText string,
+	csvData logic.ExpenseImportCSV,
+	mapping logic.ExpenseImportMapping,
+	err error,
+) {
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+
+	categories, categoryNameByID, ok := h.findCategoriesOrErr(w, r, ExpensesImport)
+	if !ok {
+		return
+	}
+
+	// An unusable mapping still shows the page, with no rows, so the columns
+	// can be picked by hand when the header gave no hint.
+	rows, previewErr := h.store.PreviewExpenseImport(r.Context(), user.ID, csvData, mapping, parseTZOffset(r))
+	if previewErr != nil {
+		status = http.StatusBadRequest
+		err = previewErr
+	}
+
+	previewRows := make([]expenseImportPreviewRow, 0, len(rows))
+	invalidCount, duplicateCount := 0, 0
+	for _, row := range rows {
+		if row.Duplicate {
+			duplicateCount++
+		}
+		previewRow := expenseImportPreviewRow{
+			Line:        row.Line,
+			Description: row.Params.Description,
+			Amount:      row.Params.Amount,
+			Date:        row.Params.Date,
+			Tags:        row.Params.Tags,
+			Duplicate:   row.Duplicate,
+		}
+		if row.Params.CategoryID != 0 {
+			previewRow.CategoryName = categoryNameOrUnknown(categoryNameByID, row.Params.CategoryID)
+		}
+		if row.Err != nil {
+			previewRow.Error = row.Err.Error()
+			invalidCount++
+		}
+		previewRows = append(previewRows, previewRow)
+	}
+
+	columns := make([]expenseImportColumn, 0, len(csvData.Header))
+	for i, name := range csvData.Header {
+		columns = append(columns, expenseImportColumn{Index: i, Name: name})
+	}
+
+	data["csvData"] = csvText
+	data["columns"] = columns
+	data["mapping"] = mapping
+	data["mappingFields"] = []expenseImportField{
+		{"Description", "col_description", mapping.Description, false},
+		{"Amount", "col_amount", mapping.Amount, false},
+		{"Date", "col_date", mapping.Date, false},
+		{"Category", "col_category", mapping.Category, true},
+		{"Tags", "col_tags", mapping.Tags, true},
+	}
+	data["categories"] = categories
+	data["rows"] = previewRows
+	data["validCount"] = len(previewRows) - invalidCount
+	data["invalidCount"] = invalidCount
+	data["duplicateCount"] = duplicateCount
+	data["duplicateAction"] = r.FormValue("duplicate_action")
+	data["duplicateActions"] = importDuplicateActions
+	data["skipInvalid"] = r.FormValue("skip_invalid") == "on"
+	if err != nil {
+		data["error"] = err.Error()
+	}
+
+	h.render(w, status, ExpensesImportPreview, data)
+}
+
+// parseImportUpload returns the CSV text and its parsed form. A fresh upload
+// arrives as the "file" part; once previewed, the text travels back and forth
+// in the "csv_data" field so the user does not pick the file again.
+func parseImportUpload(r *http.Request) (string, logic.ExpenseImportCSV, error) {
+	if err := r.ParseMultipartForm(maxImportFormMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return "", logic.ExpenseImportCSV{}, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	csvText := r.FormValue("csv_data")
+	if file, _, err := r.FormFile("file"); err == nil {
+		defer func() {
+			_ = file.Close()
+		}()
+
+		raw, readErr := io.ReadAll(file)
+		if readErr != nil {
+			return "", logic.ExpenseImportCSV{}, fmt.Errorf("%w: %w", ErrParseForm, readErr)
+		}
+		csvText = string(raw)
+	}
+
+	if strings.TrimSpace(csvText) == "" {
+		return "", logic.ExpenseImportCSV{}, ErrImportNoFile
+	}
+
+	csvData, err := logic.ParseExpenseImportCSV(strings.NewReader(csvText))
+	if err != nil {
+		return "", csvData, err
+	}
+
+	return csvText, csvData, nil
+}
+
+// parseImportMapping reads the column choices from the preview form. A fresh
+// upload has none yet, so the columns are guessed from the header instead.
+func parseImportMapping(r *http.Request, header []string) (logic.ExpenseImportMapping, error) {
+	if _, ok := r.Form["col_description"]; !ok {
+		return logic.GuessExpenseImportMapping(header), nil
+	}
+
+	var mapping logic.ExpenseImportMapping
+	fields := []struct {
+		name string
+		dst  *int
+	}{
+		{"col_description", &mapping.Description},
+		{"col_amount", &mapping.Amount},
+		{"col_date", &mapping.Date},
+		{"col_category", &mapping.Category},
+		{"col_tags", &mapping.Tags},
+	}
+	for _, field := range fields {
+		column, err := parseImportColumn(r.FormValue(field.name))
+		if err != nil {
+			return mapping, fmt.Errorf("%w %q: %w", ErrParseField, field.name, err)
+		}
+		*field.dst = column
+	}
+
+	if raw := r.FormValue("fallback_category_id"); raw != "" {
+		fallbackID, err := prog.ParseID(raw, "Category ID")
+		if err != nil {
+			return mapping, err
+		}
+		mapping.FallbackCategoryID = fallbackID
+	}
+
+	return mapping, nil
+}
+
+func parseImportColumn(raw string) (int, error) {
+	if raw == "" {
+		return logic.ExpenseImportColumnNone, nil
+	}
+
+	return strconv.Atoi(raw)
+}
@@ -0,0 +1,140 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+const importHandlerCSV = "Date,Description,Amount,Category\n" +
+	"2026-06-12,Import train,12.00,Import Handler Travel\n" +
+	"2026-06-13,Import taxi,20.50,Import Handler Travel\n"
+
+func TestPostExpensesImportPreview(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	user := s.CreateAuthUser(t, "import_h_1", "import_h_1@example.com", "import_password_1")
+	s.CreateCategory(t, user.ID, "Import Handler Travel")
+	cookies := s.AuthCookies(t, "import_h_1@example.com", "import_password_1")
+	csrfToken, cookies := s.CSRFFrom(t, "/expenses/import", cookies)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_preview_an_uploaded_file",
+			fn: func(t *testing.T) {
+				req := spec.NewUploadRequest(
+					t, "/expenses/import/preview", "file", "bank.csv", importHandlerCSV, cookies, csrfToken,
+				)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Import taxi")
+				require.Contains(t, rec.Body.String(), "2 rows ready to import, 0 invalid.")
+			},
+		},
+		{
+			name: "should_show_mapping_error_without_failing_the_page",
+			fn: func(t *testing.T) {
+				form := url.Values{
+					"csv_data":        {importHandlerCSV},
+					"col_description": {"1"},
+					"col_amount":      {"2"},
+					"col_date":        {""},
+				}
+				req := spec.NewPostRequest("/expenses/import/preview", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "must each be mapped to a column")
+			},
+		},
+		{
+			name: "should_reject_an_empty_upload",
+			fn: func(t *testing.T) {
+				req := spec.NewPostRequest("/expenses/import/preview", "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "choose a CSV file to import")
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestPostExpensesImport(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	user := s.CreateAuthUser(t, "import_h_2", "import_h_2@example.com", "import_password_2")
+	category := s.CreateCategory(t, user.ID, "Import Handler Fallback")
+	cookies := s.AuthCookies(t, "import_h_2@example.com", "import_password_2")
+	csrfToken, cookies := s.CSRFFrom(t, "/expenses/import", cookies)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_rerender_preview_when_rows_are_invalid",
+			fn: func(t *testing.T) {
+				form := url.Values{
+					"csv_data": {importHandlerCSV + "2026-06-14,Import broken,abc,Import Handler Travel\n"},
+				}
+				req := spec.NewPostRequest("/expenses/import", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "some rows are invalid")
+
+				count, err := s.Queries.CountExpensesByUser(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Equal(t, 0, count)
+			},
+		},
+		{
+			name: "should_import_and_redirect",
+			fn: func(t *testing.T) {
+				form := url.Values{
+					"csv_data":             {importHandlerCSV},
+					"col_description":      {"1"},
+					"col_amount":           {"2"},
+					"col_date":             {"0"},
+					"col_category":         {""},
+					"col_tags":             {""},
+					"fallback_category_id": {fmt.Sprintf("%d", category.ID)},
+				}
+				req := spec.NewPostRequest("/expenses/import", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/expenses", rec.Header().Get("Location"))
+
+				count, err := s.Queries.CountExpensesByUser(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Equal(t, 2, count)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/ad9311/ninete/internal/logic"
 	"github.com/ad9311/ninete/internal/prog"
@@ -19,14 +21,42 @@ type expenseRow struct {
 	CategoryName string
 	Description  string
 	Amount       uint64
+	Currency     string
 	Date         int64
 	CreatedAt    int64
 	Tags         []string
+	// RecurrentExpenseID is set on expenses a recurrent expense generated.
+	RecurrentExpenseID *int
+	// PaymentAccount is the account the expense was paid from, when it has one.
+	PaymentAccount *repo.PaymentAccount
+	Splits         []expenseSplitRow
 }
 
+type expenseSplitRow struct {
+	CategoryName string
+	Amount       uint64
+	Tags         []string
+}
+
+// expenseSplitFormRow is one line of the split fieldset. Tags are the raw
+// semicolon-separated input.
+type expenseSplitFormRow struct {
+	CategoryID int
+	Amount     uint64
+	Tags       string
+}
+
+// expenseSplitFormRows is how many split lines the form offers at least;
+// lines left blank are ignored.
+const expenseSplitFormRows = 3
+
+// expenseCategoryRow is one line of the stats table. HasChildren marks a
+// parent whose rolled-up total can be drilled into.
 type expenseCategoryRow struct {
+	CategoryID   int
 	CategoryName string
 	Total        uint64
+	HasChildren  bool
 }
 
 // ----------------------------------------------------------------------------- //
@@ -70,6 +100,7 @@ func (h *Handler) ExpenseContext(next http.Handler) http.Handler {
 func (h *Handler) GetExpenses(w http.ResponseWriter, r *http.Request) {
 	data := h.tmplData(r)
 	user := getCurrentUser(r)
+	h.setFlashData(r.Context(), data)
 
 	search, err := parseExpenseSearch(r)
 	if err != nil {
@@ -99,6 +130,7 @@ func (h *Handler) GetExpenses(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
+	h.setPaymentAccountsData(r, data)
 
 	rows := make([]expenseRow, 0, len(expenses))
 	expenseIDs := make([]int, 0, len(expenses))
@@ -120,6 +152,7 @@ func (h *Handler) GetExpenses(w http.ResponseWriter, r *http.Request) {
 			CategoryName: categoryNameOrUnknown(categoryNameByID, expense.CategoryID),
 			Description:  expense.Description,
 			Amount:       expense.Amount,
+			Currency:     expense.Currency,
 			Date:         expense.Date,
 			CreatedAt:    expense.CreatedAt,
 			Tags:         expenseTagNames[expense.ID],
@@ -146,10 +179,11 @@ func (h *Handler) renderExpensesSearchErr(
 	err error,
 ) {
 	data := h.tmplData(r)
-	categories, _, categoriesErr := h.findCategories(r.Context())
+	categories, _, categoriesErr := h.findCategories(r.Context(), getCurrentUser(r).ID)
 	if categoriesErr != nil {
 		h.app.Logger.Errorf("failed to load categories: %v", categoriesErr)
 	}
+	h.setPaymentAccountsData(r, data)
 
 	opts := userScopedQueryOpts(r, getCurrentUser(r).ID, repo.Sorting{Field: "date", Order: "DESC"}, "this_month")
 	pagination := newPaginationData(r, opts, 0, "this_month")
@@ -170,6 +204,7 @@ func applySearchToPagination(pagination *PaginationData, search expenseSearch) {
 	pagination.DateFrom = search.DateFrom
 	pagination.DateTo = search.DateTo
 	pagination.DateField = search.dateField()
+	pagination.PaymentAccountID = search.PaymentAccountID
 
 	// The search replaced the preset range; reflect that in the range select.
 	if search.clearsPresetRange() {
@@ -195,14 +230,45 @@ func (h *Handler) GetExpense(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var paymentAccount *repo.PaymentAccount
+	if expense.PaymentAccountID != nil {
+		account, err := h.store.FindPaymentAccount(ctx, *expense.PaymentAccountID, user.ID)
+		if err != nil {
+			h.renderErr(w, r, http.StatusInternalServerError, ExpensesShow, err)
+
+			return
+		}
+		paymentAccount = &account
+	}
+
+	splits, err := h.store.FindExpenseSplits(ctx, expense.ID, user.ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ExpensesShow, err)
+
+		return
+	}
+	splitRows := make([]expenseSplitRow, 0, len(splits))
+	for _, split := range splits {
+		splitRows = append(splitRows, expenseSplitRow{
+			CategoryName: categoryNameOrUnknown(categoryNameByID, split.CategoryID),
+			Amount:       split.Amount,
+			Tags:         split.Tags,
+		})
+	}
+
 	data["expense"] = expenseRow{
 		ID:           expense.ID,
 		CategoryName: categoryNameOrUnknown(categoryNameByID, expense.CategoryID),
 		Description:  expense.Description,
 		Amount:       expense.Amount,
+		Currency:     expense.Currency,
 		Date:         expense.Date,
 		CreatedAt:    expense.CreatedAt,
 		Tags:         logic.ExtractTagNames(expenseTags),
+
+		RecurrentExpenseID: expense.RecurrentExpenseID,
+		PaymentAccount:     paymentAccount,
+		Splits:             splitRows,
 	}
 
 	h.render(w, http.StatusOK, ExpensesShow, data)
@@ -218,6 +284,7 @@ func (h *Handler) GetExpensesNew(w http.ResponseWriter, r *http.Request) {
 
 	setExpenseFormData(data, categories, repo.Expense{}, "")
 	setQuickFormData(data, categories, "", false)
+	h.setPaymentAccountsData(r, data)
 
 	h.render(w, http.StatusOK, ExpensesNew, data)
 }
@@ -239,6 +306,23 @@ func (h *Handler) GetExpensesEdit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	setExpenseFormData(data, categories, *expense, logic.JoinTagNames(logic.ExtractTagNames(expenseTags)))
+	h.setPaymentAccountsData(r, data)
+
+	splits, err := h.store.FindExpenseSplits(ctx, expense.ID, getCurrentUser(r).ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ExpensesEdit, err)
+
+		return
+	}
+	splitParams := make([]logic.ExpenseSplitParams, 0, len(splits))
+	for _, split := range splits {
+		splitParams = append(splitParams, logic.ExpenseSplitParams{
+			CategoryID: split.CategoryID,
+			Amount:     split.Amount,
+			Tags:       split.Tags,
+		})
+	}
+	setExpenseSplitsData(data, splitParams)
 
 	h.render(w, http.StatusOK, ExpensesEdit, data)
 }
@@ -248,9 +332,10 @@ func (h *Handler) PostExpenses(w http.ResponseWriter, r *http.Request) {
 	data := h.tmplData(r)
 	rawTagsInput := r.FormValue("tags")
 
-	categories, _, categoriesErr := h.findCategories(ctx)
+	categories, _, categoriesErr := h.findCategories(ctx, getCurrentUser(r).ID)
 	setExpenseFormData(data, categories, repo.Expense{}, rawTagsInput)
 	setQuickFormData(data, categories, "", false)
+	h.setPaymentAccountsData(r, data)
 
 	params, err := parseExpenseForm(r)
 	if err != nil {
@@ -261,14 +346,29 @@ func (h *Handler) PostExpenses(w http.ResponseWriter, r *http.Request) {
 
 	user := getCurrentUser(r)
 
-	_, err = h.store.CreateExpense(ctx, user.ID, params)
+	_, err = h.store.CreateExpenseChecked(ctx, user.ID, params, r.FormValue("duplicate_action"))
 	if err != nil {
 		setExpenseFormData(data, categories, repo.Expense{
 			CategoryID:  params.CategoryID,
 			Description: params.Description,
 			Amount:      params.Amount,
 			Date:        params.Date,
+			Currency:    params.Currency,
+
+			PaymentAccountID: params.PaymentAccountID,
 		}, logic.JoinTagNames(params.Tags))
+		setExpenseSplitsData(data, params.Splits)
+
+		var dupErr *logic.DuplicateExpenseError
+		if errors.As(err, &dupErr) {
+			setDuplicateData(data, dupErr.Matches, categories, 0)
+			data["error"] = err.Error()
+			// Turbo only renders a form response on non-2xx; 422 signals "re-render".
+			h.render(w, http.StatusUnprocessableEntity, ExpensesNew, data)
+
+			return
+		}
+
 		h.renderErr(w, r, http.StatusBadRequest, ExpensesNew, err)
 
 		return
@@ -288,8 +388,9 @@ func (h *Handler) PostExpensesUpdate(w http.ResponseWriter, r *http.Request) {
 	expense := *getExpense(r)
 	rawTagsInput := r.FormValue("tags")
 
-	categories, _, categoriesErr := h.findCategories(ctx)
+	categories, _, categoriesErr := h.findCategories(ctx, user.ID)
 	setExpenseFormData(data, categories, expense, rawTagsInput)
+	h.setPaymentAccountsData(r, data)
 
 	params, err := parseExpenseForm(r)
 	if err != nil {
@@ -310,7 +411,12 @@ func (h *Handler) PostExpensesUpdate(w http.ResponseWriter, r *http.Request) {
 		expense.Description = params.Description
 		expense.Amount = params.Amount
 		expense.Date = params.Date
+		if params.Currency != "" {
+			expense.Currency = params.Currency
+		}
+		expense.PaymentAccountID = params.PaymentAccountID
 		setExpenseFormData(data, categories, expense, logic.JoinTagNames(params.Tags))
+		setExpenseSplitsData(data, params.Splits)
 		h.renderErr(w, r, http.StatusBadRequest, ExpensesEdit, err)
 
 		return
@@ -340,6 +446,7 @@ func (h *Handler) PostExpensesDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.flashTrashed(ctx, repo.TrashKindExpense, expense.ID)
 	http.Redirect(w, r, "/expenses", http.StatusSeeOther)
 }
 
@@ -367,15 +474,30 @@ func (h *Handler) GetExpensesStats(w http.ResponseWriter, r *http.Request) {
 		)
 	}
 
-	totals, err := h.store.FindExpensesCategoryTotals(ctx, filters)
-	if err != nil {
-		h.renderErr(w, r, http.StatusInternalServerError, ExpensesStats, err)
-
+	categories, categoryNameByID, ok := h.findCategoriesOrErr(w, r, ExpensesStats)
+	if !ok {
 		return
 	}
 
-	_, categoryNameByID, ok := h.findCategoriesOrErr(w, r, ExpensesStats)
-	if !ok {
+	// Top-level categories show their children rolled in. Drilling into a
+	// parent lists its own spending and each child's apart instead.
+	parentID, _ := strconv.Atoi(q.Get("category_id"))
+	parentByID := make(map[int]int, len(categories))
+	hasChildren := make(map[int]bool, len(categories))
+	for _, c := range categories {
+		if c.ParentID != nil {
+			parentByID[c.ID] = *c.ParentID
+			hasChildren[*c.ParentID] = true
+		}
+	}
+	if !hasChildren[parentID] {
+		parentID = 0
+	}
+
+	totals, err := h.store.FindExpensesCategoryTotals(ctx, filters, user.HomeCurrency, parentID == 0)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ExpensesStats, err)
+
 		return
 	}
 
@@ -390,9 +512,15 @@ func (h *Handler) GetExpensesStats(w http.ResponseWriter, r *http.Request) {
 
 	rows := make([]expenseCategoryRow, 0, len(totals))
 	for _, t := range totals {
+		if parentID != 0 && t.CategoryID != parentID && parentByID[t.CategoryID] != parentID {
+			continue
+		}
+
 		rows = append(rows, expenseCategoryRow{
+			CategoryID:   t.CategoryID,
 			CategoryName: categoryNameOrUnknown(categoryNameByID, t.CategoryID),
 			Total:        t.Total,
+			HasChildren:  parentID == 0 && hasChildren[t.CategoryID],
 		})
 	}
 
@@ -431,10 +559,12 @@ func (h *Handler) GetExpensesStats(w http.ResponseWriter, r *http.Request) {
 
 	data["rows"] = rows
 	data["chartData"] = string(chartDataBytes)
+	data["parentName"] = categoryNameByID[parentID]
 	data["pagination"] = PaginationData{
-		SortField: sortField,
-		SortOrder: sortOrder,
-		DateRange: dateRangeKey,
+		SortField:  sortField,
+		SortOrder:  sortOrder,
+		CategoryID: parentID,
+		DateRange:  dateRangeKey,
 	}
 
 	h.render(w, http.StatusOK, ExpensesStats, data)
@@ -459,12 +589,60 @@ func parseExpenseForm(r *http.Request) (logic.ExpenseParams, error) {
 	params.CategoryID = base.CategoryID
 	params.Description = base.Description
 	params.Amount = base.Amount
+	params.Currency = base.Currency
+	params.PaymentAccountID = base.PaymentAccountID
 	params.Date = date
 	params.Tags = logic.ParseTagNames(r.FormValue("tags"))
 
+	params.Splits, err = parseExpenseSplitsForm(r)
+	if err != nil {
+		return params, err
+	}
+
 	return params, nil
 }
 
+// parseExpenseSplitsForm reads the split lines, which arrive as parallel
+// split_category_id, split_amount and split_tags values, one of each per
+// line. A line with neither a category nor an amount is skipped.
+func parseExpenseSplitsForm(r *http.Request) ([]logic.ExpenseSplitParams, error) {
+	categoryIDs := r.Form["split_category_id"]
+	amounts := r.Form["split_amount"]
+	tags := r.Form["split_tags"]
+
+	var splits []logic.ExpenseSplitParams
+
+	for i, rawCategoryID := range categoryIDs {
+		var rawAmount, rawTags string
+		if i < len(amounts) {
+			rawAmount = amounts[i]
+		}
+		if i < len(tags) {
+			rawTags = tags[i]
+		}
+		if strings.TrimSpace(rawCategoryID) == "" && strings.TrimSpace(rawAmount) == "" {
+			continue
+		}
+
+		categoryID, err := prog.ParseID(rawCategoryID, "Split category ID")
+		if err != nil {
+			return nil, err
+		}
+		amount, err := prog.ParseAmount(rawAmount)
+		if err != nil {
+			return nil, err
+		}
+
+		splits = append(splits, logic.ExpenseSplitParams{
+			CategoryID: categoryID,
+			Amount:     amount,
+			Tags:       logic.ParseTagNames(rawTags),
+		})
+	}
+
+	return splits, nil
+}
+
 func setExpenseFormData(
 	data map[string]any,
 	categories []repo.Category,
@@ -472,7 +650,28 @@ func setExpenseFormData(
 	tagsInput string,
 ) {
 	setResourceFormData(data, categories, "expense", expense)
+	setPaymentAccountIDData(data, expense.PaymentAccountID)
 	data["tagsInput"] = tagsInput
+	setExpenseSplitsData(data, nil)
+}
+
+// setExpenseSplitsData fills the split fieldset with splits, padded with blank
+// lines up to expenseSplitFormRows.
+func setExpenseSplitsData(data map[string]any, splits []logic.ExpenseSplitParams) {
+	rows := make([]expenseSplitFormRow, 0, max(len(splits), expenseSplitFormRows))
+	for _, split := range splits {
+		rows = append(rows, expenseSplitFormRow{
+			CategoryID: split.CategoryID,
+			Amount:     split.Amount,
+			Tags:       logic.JoinTagNames(split.Tags),
+		})
+	}
+	for len(rows) < expenseSplitFormRows {
+		rows = append(rows, expenseSplitFormRow{})
+	}
+
+	data["splitRows"] = rows
+	data["splitOpen"] = len(splits) > 0
 }
 
 func getExpense(r *http.Request) *repo.Expense {
@@ -50,7 +50,7 @@ func TestGetExpenses(t *testing.T) {
 			name: "should_display_expense_description_in_body",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "exp_list_2", "exp_list_2@example.com", "exp_password_2")
-				category := s.CreateCategory(t, "exp_list_cat_1")
+				category := s.CreateCategory(t, user.ID, "exp_list_cat_1")
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Visible expense item", 500, time.Now().Unix()))
 				cookies := s.AuthCookies(t, "exp_list_2@example.com", "exp_password_2")
 
@@ -119,8 +119,8 @@ func TestPostExpenses(t *testing.T) {
 		{
 			name: "should_redirect_to_expenses_with_valid_form",
 			fn: func(t *testing.T) {
-				s.CreateAuthUser(t, "exp_post_1", "exp_post_1@example.com", "exp_password_1")
-				category := s.CreateCategory(t, "exp_post_cat_1")
+				user := s.CreateAuthUser(t, "exp_post_1", "exp_post_1@example.com", "exp_password_1")
+				category := s.CreateCategory(t, user.ID, "exp_post_cat_1")
 				cookies := s.AuthCookies(t, "exp_post_1@example.com", "exp_password_1")
 				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
 
@@ -158,6 +158,97 @@ func TestPostExpenses(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, rec.Code)
 			},
 		},
+		{
+			name: "should_save_split_lines_and_skip_blank_ones",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "exp_post_3", "exp_post_3@example.com", "exp_password_3")
+				pantry := s.CreateCategory(t, user.ID, "exp_post_cat_pantry")
+				cleaning := s.CreateCategory(t, user.ID, "exp_post_cat_cleaning")
+				cookies := s.AuthCookies(t, "exp_post_3@example.com", "exp_password_3")
+				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+
+				form := url.Values{
+					"category_id":       {fmt.Sprintf("%d", pantry.ID)},
+					"description":       {"Supermarket run"},
+					"amount":            {"5000"},
+					"date":              {"2026-01-15T00:00:00Z"},
+					"split_category_id": {fmt.Sprintf("%d", pantry.ID), fmt.Sprintf("%d", cleaning.ID), ""},
+					"split_amount":      {"3500", "1500", ""},
+					"split_tags":        {"weekly", "", ""},
+				}
+				req := spec.NewPostRequest("/expenses", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				expenses, err := s.Store.ExportExpenses(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Len(t, expenses, 1)
+
+				req = spec.NewGetRequest(fmt.Sprintf("/expenses/%d", expenses[0].ID), cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "exp_post_cat_cleaning")
+				require.Contains(t, rec.Body.String(), "$35.00")
+				require.Contains(t, rec.Body.String(), "weekly")
+			},
+		},
+		{
+			name: "should_rerender_the_splits_when_they_do_not_add_up",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "exp_post_4", "exp_post_4@example.com", "exp_password_4")
+				category := s.CreateCategory(t, user.ID, "exp_post_cat_4")
+				cookies := s.AuthCookies(t, "exp_post_4@example.com", "exp_password_4")
+				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+
+				form := url.Values{
+					"category_id":       {fmt.Sprintf("%d", category.ID)},
+					"description":       {"Short split"},
+					"amount":            {"5000"},
+					"date":              {"2026-01-15T00:00:00Z"},
+					"split_category_id": {fmt.Sprintf("%d", category.ID)},
+					"split_amount":      {"4000"},
+					"split_tags":        {""},
+				}
+				req := spec.NewPostRequest("/expenses", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "split amounts must add up")
+				require.Contains(t, rec.Body.String(), `value="4000"`)
+			},
+		},
+		{
+			name: "should_accept_a_currency_typed_in_lowercase",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "exp_post_5", "exp_post_5@example.com", "exp_password_5")
+				category := s.CreateCategory(t, user.ID, "exp_post_cat_5")
+				cookies := s.AuthCookies(t, "exp_post_5@example.com", "exp_password_5")
+				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+
+				form := url.Values{
+					"category_id": {fmt.Sprintf("%d", category.ID)},
+					"description": {"Lowercase currency"},
+					"amount":      {"1200"},
+					"currency":    {"eur"},
+					"date":        {"2026-01-15T00:00:00Z"},
+				}
+				req := spec.NewPostRequest("/expenses", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code, rec.Body.String())
+
+				exported, err := s.Store.ExportExpenses(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Len(t, exported, 1)
+				require.Equal(t, "EUR", exported[0].Currency)
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -177,7 +268,7 @@ func TestGetExpense(t *testing.T) {
 			name: "should_render_expense_show_page",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "exp_show_1", "exp_show_1@example.com", "exp_password_1")
-				category := s.CreateCategory(t, "exp_show_cat_1")
+				category := s.CreateCategory(t, user.ID, "exp_show_cat_1")
 				expense := s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Show expense detail", 1200, 1700000000))
 				cookies := s.AuthCookies(t, "exp_show_1@example.com", "exp_password_1")
 
@@ -221,7 +312,7 @@ func TestGetExpensesEdit(t *testing.T) {
 			name: "should_render_edit_page_for_existing_expense",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "exp_edit_1", "exp_edit_1@example.com", "exp_password_1")
-				category := s.CreateCategory(t, "exp_edit_cat_1")
+				category := s.CreateCategory(t, user.ID, "exp_edit_cat_1")
 				expense := s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Edit this expense", 800, 1700000000))
 				cookies := s.AuthCookies(t, "exp_edit_1@example.com", "exp_password_1")
 
@@ -264,7 +355,7 @@ func TestPostExpensesUpdate(t *testing.T) {
 			name: "should_redirect_to_expenses_after_valid_update",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "exp_update_1", "exp_update_1@example.com", "exp_password_1")
-				category := s.CreateCategory(t, "exp_update_cat_1")
+				category := s.CreateCategory(t, user.ID, "exp_update_cat_1")
 				expense := s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Before update", 500, 1700000000))
 				cookies := s.AuthCookies(t, "exp_update_1@example.com", "exp_password_1")
 				csrfToken, cookies := s.CSRFFrom(t, fmt.Sprintf("/expenses/%d/edit", expense.ID), cookies)
@@ -322,7 +413,7 @@ func TestPostExpensesDelete(t *testing.T) {
 			name: "should_redirect_to_expenses_after_valid_delete",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "exp_delete_1", "exp_delete_1@example.com", "exp_password_1")
-				category := s.CreateCategory(t, "exp_delete_cat_1")
+				category := s.CreateCategory(t, user.ID, "exp_delete_cat_1")
 				expense := s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Delete me", 300, 1700000000))
 				cookies := s.AuthCookies(t, "exp_delete_1@example.com", "exp_password_1")
 				csrfToken, cookies := s.CSRFFrom(t, fmt.Sprintf("/expenses/%d", expense.ID), cookies)
@@ -392,7 +483,7 @@ func TestGetExpensesStats(t *testing.T) {
 			name: "should_display_category_total_in_body",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "exp_stats_2", "exp_stats_2@example.com", "exp_password_2")
-				category := s.CreateCategory(t, "exp_stats_cat_1")
+				category := s.CreateCategory(t, user.ID, "exp_stats_cat_1")
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "stats expense 1", 5000, time.Now().Unix()))
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "stats expense 2", 3000, time.Now().Unix()))
 				cookies := s.AuthCookies(t, "exp_stats_2@example.com", "exp_password_2")
@@ -405,12 +496,42 @@ func TestGetExpensesStats(t *testing.T) {
 				require.Contains(t, rec.Body.String(), "$80.00")
 			},
 		},
+		{
+			name: "should_roll_up_and_drill_into_a_parent",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "exp_stats_5", "exp_stats_5@example.com", "exp_password_5")
+				parent := s.CreateCategory(t, user.ID, "exp_stats_parent")
+				child := s.CreateCategory(t, user.ID, "exp_stats_child")
+				_, err := s.Store.SetCategoryParent(t.Context(), child.ID, user.ID, &parent.ID)
+				require.NoError(t, err)
+				s.CreateExpense(t, user.ID, newExpenseParams(parent.ID, "stats parent expense", 1000, time.Now().Unix()))
+				s.CreateExpense(t, user.ID, newExpenseParams(child.ID, "stats child expense", 2500, time.Now().Unix()))
+				cookies := s.AuthCookies(t, "exp_stats_5@example.com", "exp_password_5")
+
+				req := spec.NewGetRequest("/expenses/stats", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "$35.00")
+				require.NotContains(t, rec.Body.String(), "exp_stats_child")
+
+				req = spec.NewGetRequest(fmt.Sprintf("/expenses/stats?category_id=%d", parent.ID), cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "exp_stats_parent › exp_stats_child")
+				require.Contains(t, rec.Body.String(), "$25.00")
+				require.Contains(t, rec.Body.String(), "$10.00")
+			},
+		},
 		{
 			name: "should_not_show_other_user_totals",
 			fn: func(t *testing.T) {
 				s.CreateAuthUser(t, "exp_stats_3", "exp_stats_3@example.com", "exp_password_3")
 				otherUser := s.CreateAuthUser(t, "exp_stats_4", "exp_stats_4@example.com", "exp_password_4")
-				category := s.CreateCategory(t, "exp_stats_cat_2")
+				category := s.CreateCategory(t, otherUser.ID, "exp_stats_cat_2")
 				s.CreateExpense(t, otherUser.ID, newExpenseParams(category.ID, "other user expense", 9999900, 1736467200))
 				cookies := s.AuthCookies(t, "exp_stats_3@example.com", "exp_password_3")
 
@@ -434,7 +555,7 @@ func TestGetExpensesSearch(t *testing.T) {
 	handler := s.WrappedHandler()
 
 	user := s.CreateAuthUser(t, "exp_search_1", "exp_search_1@example.com", "exp_search_pass_1")
-	category := s.CreateCategory(t, "exp_search_cat_1")
+	category := s.CreateCategory(t, user.ID, "exp_search_cat_1")
 	cookies := s.AuthCookies(t, "exp_search_1@example.com", "exp_search_pass_1")
 
 	jan := time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC).Unix()
@@ -603,7 +724,8 @@ func TestGetExpensesSearch(t *testing.T) {
 			name: "should_not_leak_other_users_tagged_expenses",
 			fn: func(t *testing.T) {
 				other := s.CreateAuthUser(t, "exp_search_2", "exp_search_2@example.com", "exp_search_pass_2")
-				otherExpense := newExpenseParams(category.ID, "Other user trip", 999, jan)
+				otherCategory := s.CreateCategory(t, other.ID, "exp_search_cat_1")
+				otherExpense := newExpenseParams(otherCategory.ID, "Other user trip", 999, jan)
 				otherExpense.Tags = []string{"travel"}
 				s.CreateExpense(t, other.ID, otherExpense)
 
@@ -626,7 +748,7 @@ func TestGetExpensesSearchDateField(t *testing.T) {
 	handler := s.WrappedHandler()
 
 	user := s.CreateAuthUser(t, "exp_df_1", "exp_df_1@example.com", "exp_df_pass_1")
-	category := s.CreateCategory(t, "exp_df_cat_1")
+	category := s.CreateCategory(t, user.ID, "exp_df_cat_1")
 	cookies := s.AuthCookies(t, "exp_df_1@example.com", "exp_df_pass_1")
 
 	// Billed long ago, but created now — the two columns disagree, which is the
@@ -734,7 +856,7 @@ func TestGetExpensesPerPage(t *testing.T) {
 	handler := s.WrappedHandler()
 
 	user := s.CreateAuthUser(t, "exp_pp_1", "exp_pp_1@example.com", "exp_pp_pass_1")
-	category := s.CreateCategory(t, "exp_pp_cat_1")
+	category := s.CreateCategory(t, user.ID, "exp_pp_cat_1")
 	cookies := s.AuthCookies(t, "exp_pp_1@example.com", "exp_pp_pass_1")
 
 	// 20 expenses, newest first by date, so item 00 is newest and item 19 oldest.
@@ -4,11 +4,58 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/go-chi/chi/v5"
 )
 
+// exportAreaLink is one row of the exports page.
+type exportAreaLink struct {
+	Area  string
+	Label string
+}
+
 func (h *Handler) GetExports(w http.ResponseWriter, r *http.Request) {
-	h.renderPage(w, r, http.StatusOK, ExportsIndex)
+	data := h.tmplData(r)
+
+	areas := make([]exportAreaLink, 0, len(logic.ExportAreas()))
+	for _, area := range logic.ExportAreas() {
+		areas = append(areas, exportAreaLink{Area: area, Label: strings.ReplaceAll(area, "_", " ")})
+	}
+	data["areas"] = areas
+
+	h.render(w, http.StatusOK, ExportsIndex, data)
+}
+
+// GetExportsArea streams one area as CSV or NDJSON. The area and format are
+// checked before any header is written; after that a failure can only be
+// logged, since the response is already on its way.
+func (h *Handler) GetExportsArea(w http.ResponseWriter, r *http.Request) {
+	user := getCurrentUser(r)
+	area := chi.URLParam(r, "area")
+	format := chi.URLParam(r, "format")
+
+	if err := logic.ValidateExport(area, format); err != nil {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == logic.ExportFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	now := time.Now().UTC().Unix()
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%d.%s"`, area, now, format))
+	w.WriteHeader(http.StatusOK)
+
+	if err := h.store.StreamExport(r.Context(), w, user.ID, area, format); err != nil {
+		h.app.Logger.Errorf("failed to write %s export: %v", area, err)
+	}
 }
 
 func (h *Handler) GetExportsExpenses(w http.ResponseWriter, r *http.Request) {
@@ -73,7 +73,7 @@ func TestGetExportsExpenses(t *testing.T) {
 			name: "should_return_json_with_expense_payload",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "exp_dl_1", "exp_dl_1@example.com", "exp_password_1")
-				category := s.CreateCategory(t, "exp_cat_1")
+				category := s.CreateCategory(t, user.ID, "exp_cat_1")
 				s.CreateExpense(t, user.ID, logic.ExpenseParams{
 					ExpenseBaseParams: logic.ExpenseBaseParams{
 						CategoryID:  category.ID,
@@ -119,7 +119,7 @@ func TestGetExportsExpenses(t *testing.T) {
 			fn: func(t *testing.T) {
 				s.CreateAuthUser(t, "exp_dl_2", "exp_dl_2@example.com", "exp_password_2")
 				otherUser := s.CreateAuthUser(t, "exp_dl_3", "exp_dl_3@example.com", "exp_password_3")
-				category := s.CreateCategory(t, "exp_cat_2")
+				category := s.CreateCategory(t, otherUser.ID, "exp_cat_2")
 				s.CreateExpense(t, otherUser.ID, logic.ExpenseParams{
 					ExpenseBaseParams: logic.ExpenseBaseParams{
 						CategoryID:  category.ID,
@@ -144,3 +144,79 @@ func TestGetExportsExpenses(t *testing.T) {
 		t.Run(tc.name, tc.fn)
 	}
 }
+
+func TestGetExportsArea(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_redirect_to_login_when_unauthenticated",
+			fn: func(t *testing.T) {
+				req := spec.NewGetRequest("/exports/tags.csv", nil)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/login", rec.Header().Get("Location"))
+			},
+		},
+		{
+			name: "should_return_csv_attachment",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "exp_area_1", "exp_area_1@example.com", "exp_password_1")
+				s.CreateTag(t, user.ID, "exp_area_tag")
+				cookies := s.AuthCookies(t, "exp_area_1@example.com", "exp_password_1")
+
+				req := spec.NewGetRequest("/exports/tags.csv", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+				require.Contains(t, rec.Header().Get("Content-Disposition"), "tags-")
+				require.Contains(t, rec.Body.String(), "id,name,created_at,updated_at\n")
+				require.Contains(t, rec.Body.String(), ",exp_area_tag,")
+			},
+		},
+		{
+			name: "should_return_ndjson_without_other_users_rows",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "exp_area_2", "exp_area_2@example.com", "exp_password_2")
+				otherUser := s.CreateAuthUser(t, "exp_area_3", "exp_area_3@example.com", "exp_password_3")
+				s.CreateTag(t, otherUser.ID, "exp_area_private")
+				cookies := s.AuthCookies(t, "exp_area_2@example.com", "exp_password_2")
+
+				req := spec.NewGetRequest("/exports/tags.ndjson", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+				require.NotContains(t, rec.Body.String(), "exp_area_private")
+			},
+		},
+		{
+			name: "should_return_not_found_for_unknown_area_or_format",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "exp_area_4", "exp_area_4@example.com", "exp_password_4")
+				cookies := s.AuthCookies(t, "exp_area_4@example.com", "exp_password_4")
+
+				for _, url := range []string{"/exports/users.csv", "/exports/tags.xml"} {
+					req := spec.NewGetRequest(url, cookies)
+					rec := httptest.NewRecorder()
+					handler.ServeHTTP(rec, req)
+
+					require.Equal(t, http.StatusNotFound, rec.Code, url)
+				}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
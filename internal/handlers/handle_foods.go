@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/ad9311/ninete/internal/logic"
 	"github.com/ad9311/ninete/internal/prog"
@@ -53,6 +54,7 @@ func (h *Handler) GetFoods(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	data := h.tmplData(r)
 	user := getCurrentUser(r)
+	h.setFlashData(ctx, data)
 
 	q := r.URL.Query()
 	sortField := q.Get("sort_field")
@@ -88,9 +90,16 @@ func (h *Handler) GetFoods(w http.ResponseWriter, r *http.Request) {
 	h.render(w, http.StatusOK, FoodsIndex, data)
 }
 
+// GetFoodsNew shows the new food form, with the barcode filled in when a
+// barcode lookup found nothing and sent the user here.
 func (h *Handler) GetFoodsNew(w http.ResponseWriter, r *http.Request) {
 	data := h.tmplData(r)
-	data["food"] = repo.Food{}
+	data["food"] = repo.Food{
+		BaseAmount: logic.DefaultFoodBaseAmount,
+		BaseUnit:   logic.DefaultFoodBaseUnit,
+		Barcode:    barcodeField(r.URL.Query().Get("barcode")),
+	}
+	data["units"] = logic.Units()
 
 	h.render(w, http.StatusOK, FoodsNew, data)
 }
@@ -100,9 +109,11 @@ func (h *Handler) PostFoods(w http.ResponseWriter, r *http.Request) {
 	data := h.tmplData(r)
 	user := getCurrentUser(r)
 
+	data["units"] = logic.Units()
+
 	params, err := parseFoodForm(r)
 	if err != nil {
-		data["food"] = repo.Food{}
+		data["food"] = repo.Food{BaseAmount: logic.DefaultFoodBaseAmount, BaseUnit: logic.DefaultFoodBaseUnit}
 		h.renderErr(w, r, http.StatusBadRequest, FoodsNew, err)
 
 		return
@@ -119,6 +130,10 @@ func (h *Handler) PostFoods(w http.ResponseWriter, r *http.Request) {
 			FiberG:        params.FiberG,
 			SodiumG:       params.SodiumG,
 			SaturatedFatG: params.SaturatedFatG,
+			BaseAmount:    params.BaseAmount,
+			BaseUnit:      params.BaseUnit,
+			DensityGPerML: densityField(params.DensityGPerML),
+			Barcode:       barcodeField(params.Barcode),
 		}
 		h.renderErr(w, r, http.StatusBadRequest, FoodsNew, err)
 
@@ -128,9 +143,15 @@ func (h *Handler) PostFoods(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, fmt.Sprintf("/foods/%d", food.ID), http.StatusSeeOther)
 }
 
+// GetFood shows the food with its servings and the form to name another.
 func (h *Handler) GetFood(w http.ResponseWriter, r *http.Request) {
 	data := h.tmplData(r)
-	data["food"] = getFood(r)
+
+	if err := h.setFoodShowData(r.Context(), data, *getFood(r)); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, FoodsShow, err)
+
+		return
+	}
 
 	h.render(w, http.StatusOK, FoodsShow, data)
 }
@@ -138,6 +159,7 @@ func (h *Handler) GetFood(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetFoodEdit(w http.ResponseWriter, r *http.Request) {
 	data := h.tmplData(r)
 	data["food"] = getFood(r)
+	data["units"] = logic.Units()
 
 	h.render(w, http.StatusOK, FoodsEdit, data)
 }
@@ -148,6 +170,8 @@ func (h *Handler) PostFoodUpdate(w http.ResponseWriter, r *http.Request) {
 	user := getCurrentUser(r)
 	food := *getFood(r)
 
+	data["units"] = logic.Units()
+
 	params, err := parseFoodForm(r)
 	if err != nil {
 		data["food"] = food
@@ -172,6 +196,10 @@ func (h *Handler) PostFoodUpdate(w http.ResponseWriter, r *http.Request) {
 		food.FiberG = params.FiberG
 		food.SodiumG = params.SodiumG
 		food.SaturatedFatG = params.SaturatedFatG
+		food.BaseAmount = params.BaseAmount
+		food.BaseUnit = params.BaseUnit
+		food.DensityGPerML = densityField(params.DensityGPerML)
+		food.Barcode = barcodeField(params.Barcode)
 		data["food"] = food
 		h.renderErr(w, r, http.StatusBadRequest, FoodsEdit, err)
 
@@ -198,13 +226,198 @@ func (h *Handler) PostFoodDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.flashTrashed(ctx, repo.TrashKindFood, food.ID)
 	http.Redirect(w, r, "/foods", http.StatusSeeOther)
 }
 
+func (h *Handler) PostFoodServings(w http.ResponseWriter, r *http.Request) {
+	food := getFood(r)
+
+	params, err := parseFoodServingForm(r)
+	if err == nil {
+		_, err = h.store.AddFoodServing(r.Context(), *food, params)
+	}
+	if err != nil {
+		data := h.tmplData(r)
+		if showErr := h.setFoodShowData(r.Context(), data, *food); showErr != nil {
+			h.app.Logger.Errorf("failed to load food: %v", showErr)
+		}
+		data["serving"] = params
+		h.renderErr(w, r, http.StatusBadRequest, FoodsShow, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/foods/%d", food.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostFoodServingDelete(w http.ResponseWriter, r *http.Request) {
+	food := getFood(r)
+
+	id, err := prog.ParseID(chi.URLParam(r, "servingID"), "Food Serving")
+	if err != nil {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	if err := h.store.DeleteFoodServing(r.Context(), id, food.ID, getCurrentUser(r).ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/foods/%d", food.ID), http.StatusSeeOther)
+}
+
+// PostReferenceFoodCopy adds a reference food found from the macro entry form
+// to the user's library and returns to the form to log it.
+func (h *Handler) PostReferenceFoodCopy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+
+	id, err := prog.ParseID(chi.URLParam(r, "referenceID"), "Reference Food")
+	if err != nil {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	food, err := h.store.CopyReferenceFood(ctx, user.ID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		h.NotFound(w, r)
+
+		return
+	}
+	if err != nil {
+		data["entry"] = repo.MacroEntry{}
+		h.renderErr(w, r, http.StatusBadRequest, MacrosNew, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/macros/new?from_food=%d", food.ID), http.StatusSeeOther)
+}
+
+// barcodeLookup is the answer to a barcode lookup. Match is "food",
+// "reference_food" or "none"; the URL fields say what the macro form can do
+// next: log the food, copy the reference food (a POST) or create a food.
+type barcodeLookup struct {
+	Barcode   string   `json:"barcode"`
+	Match     string   `json:"match"`
+	Food      *apiFood `json:"food,omitempty"`
+	LogURL    string   `json:"log_url,omitempty"`
+	CopyURL   string   `json:"copy_url,omitempty"`
+	CreateURL string   `json:"create_url,omitempty"`
+}
+
+// apiFood is a food or reference food as a barcode lookup returns it, with
+// nutrients for BaseAmount of BaseUnit.
+type apiFood struct {
+	ID            int     `json:"id"`
+	Name          string  `json:"name"`
+	Brand         string  `json:"brand,omitempty"`
+	Kcal          float64 `json:"kcal"`
+	ProteinG      float64 `json:"protein_g"`
+	CarbsG        float64 `json:"carbs_g"`
+	FatG          float64 `json:"fat_g"`
+	FiberG        float64 `json:"fiber_g"`
+	SodiumG       float64 `json:"sodium_g"`
+	SaturatedFatG float64 `json:"saturated_fat_g"`
+	BaseAmount    float64 `json:"base_amount"`
+	BaseUnit      string  `json:"base_unit"`
+}
+
+// GetFoodBarcode looks a scanned or typed barcode up for the macro entry
+// form, answering in JSON. When nothing matches it answers 404 with the URL
+// of a new food form that already has the barcode.
+func (h *Handler) GetFoodBarcode(w http.ResponseWriter, r *http.Request) {
+	user := getCurrentUser(r)
+
+	match, err := h.store.LookupBarcode(r.Context(), user.ID, chi.URLParam(r, "code"))
+	if errors.Is(err, logic.ErrInvalidBarcode) {
+		h.writeJSONErr(w, http.StatusBadRequest, err)
+
+		return
+	}
+	if err != nil {
+		h.writeJSONInternalErr(w, err)
+
+		return
+	}
+
+	body := barcodeLookup{Barcode: match.Barcode}
+	switch {
+	case match.Food != nil:
+		f := match.Food
+		body.Match = "food"
+		body.Food = &apiFood{
+			ID:            f.ID,
+			Name:          f.Name,
+			Kcal:          f.Kcal,
+			ProteinG:      f.ProteinG,
+			CarbsG:        f.CarbsG,
+			FatG:          f.FatG,
+			FiberG:        f.FiberG,
+			SodiumG:       f.SodiumG,
+			SaturatedFatG: f.SaturatedFatG,
+			BaseAmount:    f.BaseAmount,
+			BaseUnit:      f.BaseUnit,
+		}
+		body.LogURL = fmt.Sprintf("/macros/new?from_food=%d", f.ID)
+	case match.ReferenceFood != nil:
+		rf := match.ReferenceFood
+		body.Match = "reference_food"
+		body.Food = &apiFood{
+			ID:            rf.ID,
+			Name:          rf.Name,
+			Brand:         rf.Brand,
+			Kcal:          rf.Kcal,
+			ProteinG:      rf.ProteinG,
+			CarbsG:        rf.CarbsG,
+			FatG:          rf.FatG,
+			FiberG:        rf.FiberG,
+			SodiumG:       rf.SodiumG,
+			SaturatedFatG: rf.SaturatedFatG,
+			BaseAmount:    logic.DefaultFoodBaseAmount,
+			BaseUnit:      logic.UnitGram,
+		}
+		body.CopyURL = fmt.Sprintf("/foods/reference/%d/copy", rf.ID)
+	default:
+		body.Match = "none"
+		body.CreateURL = "/foods/new?barcode=" + url.QueryEscape(match.Barcode)
+		h.writeJSON(w, http.StatusNotFound, body)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, body)
+}
+
 // ----------------------------------------------------------------------------- //
 // Unexported Functions and Helpers
 // ----------------------------------------------------------------------------- //
 
+func (h *Handler) setFoodShowData(ctx context.Context, data map[string]any, food repo.Food) error {
+	servings, err := h.store.FindFoodServings(ctx, food.ID, food.UserID)
+	if err != nil {
+		return err
+	}
+
+	data["food"] = food
+	data["servings"] = servings
+	data["units"] = logic.Units()
+	data["serving"] = logic.FoodServingParams{Amount: 1, Unit: food.BaseUnit}
+
+	return nil
+}
+
 func parseFoodForm(r *http.Request) (logic.FoodParams, error) {
 	var params logic.FoodParams
 
@@ -247,6 +460,16 @@ func parseFoodForm(r *http.Request) (logic.FoodParams, error) {
 		return params, err
 	}
 
+	baseAmount, err := parseFloatFieldDefault(r, "base_amount")
+	if err != nil {
+		return params, err
+	}
+
+	density, err := parseFloatFieldDefault(r, "density_g_per_ml")
+	if err != nil {
+		return params, err
+	}
+
 	params.Name = r.FormValue("name")
 	params.Kcal = kcal
 	params.ProteinG = proteinG
@@ -255,10 +478,51 @@ func parseFoodForm(r *http.Request) (logic.FoodParams, error) {
 	params.FiberG = fiberG
 	params.SodiumG = sodiumG
 	params.SaturatedFatG = saturatedFatG
+	params.BaseAmount = baseAmount
+	params.BaseUnit = r.FormValue("base_unit")
+	params.DensityGPerML = density
+	params.Barcode = r.FormValue("barcode")
 
 	return params, nil
 }
 
+func parseFoodServingForm(r *http.Request) (logic.FoodServingParams, error) {
+	var params logic.FoodServingParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	params.Name = r.FormValue("name")
+	params.Unit = r.FormValue("unit")
+
+	amount, err := parseFloatField(r, "amount")
+	if err != nil {
+		return params, err
+	}
+	params.Amount = amount
+
+	return params, nil
+}
+
+// densityField turns a form's density back into the optional column value, so
+// a re-rendered form shows what was typed.
+func densityField(density float64) *float64 {
+	if density <= 0 {
+		return nil
+	}
+
+	return &density
+}
+
+func barcodeField(barcode string) *string {
+	if barcode == "" {
+		return nil
+	}
+
+	return &barcode
+}
+
 func getFood(r *http.Request) *repo.Food {
 	food, ok := r.Context().Value(KeyFood).(*repo.Food)
 
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/ad9311/ninete/internal/logic"
@@ -303,6 +304,250 @@ func TestPostFoodDelete(t *testing.T) {
 	}
 }
 
+func TestPostFoodServings(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_add_and_delete_a_serving",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "food_srv_1", "food_srv_1@example.com", "food_password_1")
+				food := s.CreateFood(t, user.ID, newFoodParams("Rice"))
+				cookies := s.AuthCookies(t, "food_srv_1@example.com", "food_password_1")
+				foodURL := fmt.Sprintf("/foods/%d", food.ID)
+				csrfToken, cookies := s.CSRFFrom(t, foodURL, cookies)
+
+				form := url.Values{"name": {"1 cup"}, "amount": {"185"}, "unit": {"g"}}
+				req := spec.NewPostRequest(foodURL+"/servings", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, foodURL, rec.Header().Get("Location"))
+
+				servings, err := s.Store.FindFoodServings(t.Context(), food.ID, user.ID)
+				require.NoError(t, err)
+				require.Len(t, servings, 1)
+				require.Equal(t, "1 cup", servings[0].Name)
+
+				req = spec.NewPostRequest(
+					fmt.Sprintf("%s/servings/%d/delete", foodURL, servings[0].ID),
+					"", cookies, csrfToken,
+				)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				servings, err = s.Store.FindFoodServings(t.Context(), food.ID, user.ID)
+				require.NoError(t, err)
+				require.Empty(t, servings)
+			},
+		},
+		{
+			name: "should_render_errors_for_a_volume_serving_without_density",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "food_srv_2", "food_srv_2@example.com", "food_password_2")
+				food := s.CreateFood(t, user.ID, newFoodParams("Flour"))
+				cookies := s.AuthCookies(t, "food_srv_2@example.com", "food_password_2")
+				foodURL := fmt.Sprintf("/foods/%d", food.ID)
+				csrfToken, cookies := s.CSRFFrom(t, foodURL, cookies)
+
+				form := url.Values{"name": {"1 cup"}, "amount": {"240"}, "unit": {"ml"}}
+				req := spec.NewPostRequest(foodURL+"/servings", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "needs the food&#39;s density")
+			},
+		},
+		{
+			name: "should_return_not_found_for_other_user_food",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "food_srv_3", "food_srv_3@example.com", "food_password_3")
+				otherUser := s.CreateAuthUser(t, "food_srv_4", "food_srv_4@example.com", "food_password_4")
+				food := s.CreateFood(t, otherUser.ID, newFoodParams("Not mine"))
+				cookies := s.AuthCookies(t, "food_srv_3@example.com", "food_password_3")
+				csrfToken, cookies := s.CSRFFrom(t, "/foods", cookies)
+
+				form := url.Values{"name": {"1 cup"}, "amount": {"185"}, "unit": {"g"}}
+				req := spec.NewPostRequest(
+					fmt.Sprintf("/foods/%d/servings", food.ID),
+					form.Encode(), cookies, csrfToken,
+				)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestPostReferenceFoodCopy(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_find_a_reference_food_and_copy_it_into_the_library",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "food_ref_1", "food_ref_1@example.com", "food_password_1")
+				s.CreateFood(t, user.ID, newFoodParams("Reftest bread"))
+				_, err := s.Store.ImportOpenFoodFacts(t.Context(), strings.NewReader(
+					`{"code":"500001","product_name":"Reftest hummus","brands":"Dip Co",`+
+						`"nutriments":{"energy-kcal_100g":166,"proteins_100g":8}}`,
+				))
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "food_ref_1@example.com", "food_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/macros/new", cookies)
+
+				req := spec.NewGetRequest("/macros/new?q=reftest", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Reftest bread")
+				require.Contains(t, rec.Body.String(), "Reftest hummus")
+
+				search, err := s.Store.SearchFoods(t.Context(), user.ID, "reftest hummus")
+				require.NoError(t, err)
+				require.Len(t, search.ReferenceFoods, 1)
+
+				copyURL := fmt.Sprintf("/foods/reference/%d/copy", search.ReferenceFoods[0].ID)
+				req = spec.NewPostRequest(copyURL, "", cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				search, err = s.Store.SearchFoods(t.Context(), user.ID, "reftest hummus")
+				require.NoError(t, err)
+				require.Len(t, search.Foods, 1)
+				require.Equal(t, "Reftest hummus (Dip Co)", search.Foods[0].Name)
+				require.Equal(t,
+					fmt.Sprintf("/macros/new?from_food=%d", search.Foods[0].ID),
+					rec.Header().Get("Location"),
+				)
+			},
+		},
+		{
+			name: "should_return_not_found_for_an_unknown_reference_food",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "food_ref_2", "food_ref_2@example.com", "food_password_2")
+				cookies := s.AuthCookies(t, "food_ref_2@example.com", "food_password_2")
+				csrfToken, cookies := s.CSRFFrom(t, "/macros/new", cookies)
+
+				req := spec.NewPostRequest("/foods/reference/999999/copy", "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestGetFoodBarcode(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+	user := s.CreateAuthUser(t, "food_barcode_1", "food_barcode_1@example.com", "food_password_1")
+	cookies := s.AuthCookies(t, "food_barcode_1@example.com", "food_password_1")
+
+	lookup := func(t *testing.T, code string) (int, map[string]any) {
+		t.Helper()
+
+		req := spec.NewGetRequest("/foods/barcode/"+code, cookies)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var body map[string]any
+		decodeAPIBody(t, rec, &body)
+
+		return rec.Code, body
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_return_an_own_food_with_a_link_to_log_it",
+			fn: func(t *testing.T) {
+				params := newFoodParams("Barcodetest yogurt")
+				params.Barcode = "2000000000022"
+				food := s.CreateFood(t, user.ID, params)
+
+				status, body := lookup(t, "2000000000022")
+				require.Equal(t, http.StatusOK, status)
+				require.Equal(t, "food", body["match"])
+				require.Equal(t, fmt.Sprintf("/macros/new?from_food=%d", food.ID), body["log_url"])
+				require.Equal(t, "Barcodetest yogurt", body["food"].(map[string]any)["name"])
+			},
+		},
+		{
+			name: "should_return_a_reference_food_with_a_link_to_copy_it",
+			fn: func(t *testing.T) {
+				_, err := s.Store.ImportOpenFoodFacts(t.Context(), strings.NewReader(
+					`{"code":"73513537","product_name":"Barcodetest tea","brands":"Leaf",`+
+						`"nutriments":{"energy-kcal_100g":1}}`,
+				))
+				require.NoError(t, err)
+
+				status, body := lookup(t, "73513537")
+				require.Equal(t, http.StatusOK, status)
+				require.Equal(t, "reference_food", body["match"])
+				require.Regexp(t, `^/foods/reference/\d+/copy$`, body["copy_url"])
+				require.Equal(t, "Leaf", body["food"].(map[string]any)["brand"])
+			},
+		},
+		{
+			name: "should_offer_a_new_food_with_the_barcode_when_nothing_matches",
+			fn: func(t *testing.T) {
+				status, body := lookup(t, "200000000003-9")
+				require.Equal(t, http.StatusNotFound, status)
+				require.Equal(t, "none", body["match"])
+				require.Equal(t, "/foods/new?barcode=2000000000039", body["create_url"])
+
+				req := spec.NewGetRequest(body["create_url"].(string), cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), `value="2000000000039"`)
+			},
+		},
+		{
+			name: "should_reject_an_invalid_barcode",
+			fn: func(t *testing.T) {
+				status, body := lookup(t, "4006381333932")
+				require.Equal(t, http.StatusBadRequest, status)
+				require.Equal(t, logic.ErrInvalidBarcode.Error(), body["error"])
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
 func newFoodParams(name string) logic.FoodParams {
 	return logic.FoodParams{
 		Name:     name,
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+type incomeRow struct {
+	ID       int
+	Source   string
+	Amount   uint64
+	Currency string
+	Date     int64
+	Tags     []string
+	// RecurrentIncomeID is set on incomes a recurrent income generated.
+	RecurrentIncomeID *int
+}
+
+// ----------------------------------------------------------------------------- //
+// Context Middleware
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) IncomeContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user := getCurrentUser(r)
+		incomeID := chi.URLParam(r, "id")
+
+		id, err := prog.ParseID(incomeID, "Income")
+		if err != nil {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		income, err := h.store.FindIncome(ctx, id, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		if err != nil {
+			h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+			return
+		}
+
+		ctx = context.WithValue(ctx, KeyIncome, &income)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) GetIncomes(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+
+	opts := userScopedQueryOpts(r, user.ID, repo.Sorting{Field: "date", Order: "DESC"}, "this_year")
+
+	totalCount, err := h.store.CountIncomes(r.Context(), opts.Filters)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, IncomesIndex, err)
+
+		return
+	}
+
+	incomes, err := h.store.FindIncomes(r.Context(), opts)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, IncomesIndex, err)
+
+		return
+	}
+
+	incomeIDs := make([]int, 0, len(incomes))
+	for _, income := range incomes {
+		incomeIDs = append(incomeIDs, income.ID)
+	}
+
+	tagRows, err := h.store.FindTagRows(r.Context(), repo.TaggableTypeIncome, "incomes", incomeIDs, user.ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, IncomesIndex, err)
+
+		return
+	}
+	tagNames := repo.TagNamesByTargetID(tagRows)
+
+	rows := make([]incomeRow, 0, len(incomes))
+	for _, income := range incomes {
+		rows = append(rows, newIncomeRow(income, tagNames[income.ID]))
+	}
+
+	data["incomes"] = rows
+	data["pagination"] = newPaginationData(r, opts, totalCount, "this_year")
+	data["basePath"] = "/incomes"
+
+	h.render(w, http.StatusOK, IncomesIndex, data)
+}
+
+func (h *Handler) GetIncome(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	income := getIncome(r)
+
+	tags, err := h.store.FindIncomeTags(r.Context(), income.ID, getCurrentUser(r).ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, IncomesShow, err)
+
+		return
+	}
+
+	data["income"] = newIncomeRow(*income, logic.ExtractTagNames(tags))
+
+	h.render(w, http.StatusOK, IncomesShow, data)
+}
+
+func (h *Handler) GetIncomesNew(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	h.setIncomeFormData(r, data, repo.Income{}, "")
+
+	h.render(w, http.StatusOK, IncomesNew, data)
+}
+
+func (h *Handler) GetIncomesEdit(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	income := getIncome(r)
+
+	tags, err := h.store.FindIncomeTags(r.Context(), income.ID, getCurrentUser(r).ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, IncomesEdit, err)
+
+		return
+	}
+
+	h.setIncomeFormData(r, data, *income, logic.JoinTagNames(logic.ExtractTagNames(tags)))
+
+	h.render(w, http.StatusOK, IncomesEdit, data)
+}
+
+func (h *Handler) PostIncomes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+
+	h.setIncomeFormData(r, data, repo.Income{}, r.FormValue("tags"))
+
+	params, err := parseIncomeForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, IncomesNew, err)
+
+		return
+	}
+
+	user := getCurrentUser(r)
+
+	_, err = h.store.CreateIncome(ctx, user.ID, params)
+	if err != nil {
+		data["income"] = repo.Income{
+			Source:   params.Source,
+			Amount:   params.Amount,
+			Currency: params.Currency,
+			Date:     params.Date,
+		}
+		data["tagsInput"] = logic.JoinTagNames(params.Tags)
+		h.renderErr(w, r, http.StatusBadRequest, IncomesNew, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/incomes", http.StatusSeeOther)
+}
+
+func (h *Handler) PostIncomesUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+	income := *getIncome(r)
+
+	h.setIncomeFormData(r, data, income, r.FormValue("tags"))
+
+	params, err := parseIncomeForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, IncomesEdit, err)
+
+		return
+	}
+
+	_, err = h.store.UpdateIncome(ctx, income.ID, user.ID, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		income.Source = params.Source
+		income.Amount = params.Amount
+		income.Date = params.Date
+		if params.Currency != "" {
+			income.Currency = params.Currency
+		}
+		data["income"] = income
+		data["tagsInput"] = logic.JoinTagNames(params.Tags)
+		h.renderErr(w, r, http.StatusBadRequest, IncomesEdit, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/incomes", http.StatusSeeOther)
+}
+
+func (h *Handler) PostIncomesDelete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+	income := getIncome(r)
+
+	if err := h.store.DeleteIncome(ctx, income.ID, user.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/incomes", http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+func parseIncomeForm(r *http.Request) (logic.IncomeParams, error) {
+	var params logic.IncomeParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	amount, err := prog.ParseAmount(r.FormValue("amount"))
+	if err != nil {
+		return params, err
+	}
+
+	date, err := prog.StringToUnixDate(r.FormValue("date"))
+	if err != nil {
+		return params, err
+	}
+
+	params.Source = r.FormValue("source")
+	params.Amount = amount
+	params.Currency = r.FormValue("currency")
+	params.Date = date
+	params.Tags = logic.ParseTagNames(r.FormValue("tags"))
+
+	return params, nil
+}
+
+// setIncomeFormData also loads the sources the form suggests. Failing to load
+// them only costs the suggestions, so the error is logged and not shown.
+func (h *Handler) setIncomeFormData(r *http.Request, data map[string]any, income repo.Income, tagsInput string) {
+	sources, err := h.store.FindIncomeSources(r.Context(), getCurrentUser(r).ID)
+	if err != nil {
+		h.app.Logger.Errorf("failed to load income sources: %v", err)
+	}
+
+	data["income"] = income
+	data["incomeSources"] = sources
+	data["tagsInput"] = tagsInput
+}
+
+func newIncomeRow(income repo.Income, tags []string) incomeRow {
+	return incomeRow{
+		ID:                income.ID,
+		Source:            income.Source,
+		Amount:            income.Amount,
+		Currency:          income.Currency,
+		Date:              income.Date,
+		Tags:              tags,
+		RecurrentIncomeID: income.RecurrentIncomeID,
+	}
+}
+
+func getIncome(r *http.Request) *repo.Income {
+	income, ok := r.Context().Value(KeyIncome).(*repo.Income)
+
+	if !ok {
+		panic("failed to get income context")
+	}
+
+	return income
+}
@@ -0,0 +1,206 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncomes(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_redirect_to_login_when_unauthenticated",
+			fn: func(t *testing.T) {
+				req := spec.NewGetRequest("/incomes", nil)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/login", rec.Header().Get("Location"))
+			},
+		},
+		{
+			name: "should_create_income_and_list_it",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "income_h_1", "income_h_1@example.com", "income_password_1")
+				cookies := s.AuthCookies(t, "income_h_1@example.com", "income_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/incomes/new", cookies)
+
+				form := url.Values{
+					"source": {"Acme payroll"},
+					"amount": {"250000"},
+					"date":   {today.Format(time.RFC3339)},
+					"tags":   {"salary"},
+				}
+				req := spec.NewPostRequest("/incomes", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/incomes", rec.Header().Get("Location"))
+
+				req = spec.NewGetRequest("/incomes", cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Acme payroll")
+				require.Contains(t, rec.Body.String(), "salary")
+			},
+		},
+		{
+			name: "should_reject_an_income_without_a_source",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "income_h_2", "income_h_2@example.com", "income_password_2")
+				cookies := s.AuthCookies(t, "income_h_2@example.com", "income_password_2")
+				csrfToken, cookies := s.CSRFFrom(t, "/incomes/new", cookies)
+
+				form := url.Values{
+					"source": {""},
+					"amount": {"1000"},
+					"date":   {today.Format(time.RFC3339)},
+				}
+				req := spec.NewPostRequest("/incomes", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "should_not_show_another_users_income",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "income_h_3", "income_h_3@example.com", "income_password_3")
+				owner := s.CreateAuthUser(t, "income_h_4", "income_h_4@example.com", "income_password_4")
+				income, err := s.Store.CreateIncome(t.Context(), owner.ID, logic.IncomeParams{
+					Source: "Private bonus",
+					Amount: 5000,
+					Date:   today.Unix(),
+				})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "income_h_3@example.com", "income_password_3")
+
+				req := spec.NewGetRequest(fmt.Sprintf("/incomes/%d", income.ID), cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name: "should_delete_income",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "income_h_5", "income_h_5@example.com", "income_password_5")
+				income, err := s.Store.CreateIncome(t.Context(), user.ID, logic.IncomeParams{
+					Source: "Refund",
+					Amount: 700,
+					Date:   today.Unix(),
+				})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "income_h_5@example.com", "income_password_5")
+				path := fmt.Sprintf("/incomes/%d", income.ID)
+				csrfToken, cookies := s.CSRFFrom(t, path, cookies)
+
+				req := spec.NewPostRequest(path+"/delete", "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				_, err = s.Store.FindIncome(t.Context(), income.ID, user.ID)
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestRecurrentIncomes(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_recurrent_income_and_show_its_schedule",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "recurrent_income_h_1", "recurrent_income_h_1@example.com", "ri_password_1")
+				cookies := s.AuthCookies(t, "recurrent_income_h_1@example.com", "ri_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/recurrent-incomes/new", cookies)
+
+				form := url.Values{
+					"source":     {"Monthly salary"},
+					"amount":     {"300000"},
+					"period":     {"1"},
+					"frequency":  {"monthly"},
+					"anchor_day": {"25"},
+				}
+				req := spec.NewPostRequest("/recurrent-incomes", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/recurrent-incomes", rec.Header().Get("Location"))
+
+				recurrentIncomes, err := s.Store.FindRecurrentIncomes(t.Context(), repo.QueryOptions{
+					Filters: repo.Filters{
+						FilterFields: []repo.FilterField{{Name: "user_id", Value: user.ID, Operator: "="}},
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, recurrentIncomes, 1)
+
+				req = spec.NewGetRequest(fmt.Sprintf("/recurrent-incomes/%d", recurrentIncomes[0].ID), cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Monthly on day 25")
+				require.Contains(t, rec.Body.String(), "No incomes generated yet.")
+			},
+		},
+		{
+			name: "should_reject_a_zero_period",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "recurrent_income_h_2", "recurrent_income_h_2@example.com", "ri_password_2")
+				cookies := s.AuthCookies(t, "recurrent_income_h_2@example.com", "ri_password_2")
+				csrfToken, cookies := s.CSRFFrom(t, "/recurrent-incomes/new", cookies)
+
+				form := url.Values{
+					"source": {"Broken rule"},
+					"amount": {"1000"},
+					"period": {"0"},
+				}
+				req := spec.NewPostRequest("/recurrent-incomes", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/ad9311/ninete/internal/logic"
@@ -68,6 +67,7 @@ func (h *Handler) GetMacros(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	data := h.tmplData(r)
 	user := getCurrentUser(r)
+	h.setFlashData(ctx, data)
 
 	q := r.URL.Query()
 	dayStart, nextDayStart, selectedDate := computeDayWindow(q.Get("date"))
@@ -125,6 +125,7 @@ func (h *Handler) GetMacros(w http.ResponseWriter, r *http.Request) {
 	data["entries"] = entries
 	data["totals"] = totals
 	data["selectedDate"] = selectedDate
+	data["previousDate"] = time.Unix(dayStart-86400, 0).UTC().Format("2006-01-02")
 	data["hasGoal"] = hasGoal
 	data["sortField"] = sortField
 	data["sortOrder"] = sortOrder
@@ -138,6 +139,9 @@ func (h *Handler) GetMacros(w http.ResponseWriter, r *http.Request) {
 	h.render(w, http.StatusOK, MacrosIndex, data)
 }
 
+// GetMacrosNew shows the macro entry form. With from_food it logs a portion
+// of that food instead, measured in any unit or serving the food converts to;
+// with q it also lists the foods and reference foods matching q.
 func (h *Handler) GetMacrosNew(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	data := h.tmplData(r)
@@ -150,23 +154,26 @@ func (h *Handler) GetMacrosNew(w http.ResponseWriter, r *http.Request) {
 		if err == nil {
 			food, err := h.store.FindFood(ctx, foodID, user.ID)
 			if err == nil {
-				scale := 1.0
-				if amountStr := r.URL.Query().Get("amount"); amountStr != "" {
-					if amount, err := strconv.ParseFloat(amountStr, 64); err == nil && amount > 0 {
-						scale = amount / foodBaseAmountG
-					}
+				if err := h.setFoodPortionData(ctx, data, food, foodPortionFromQuery(r, food)); err != nil {
+					h.renderErr(w, r, http.StatusInternalServerError, MacrosNew, err)
+
+					return
 				}
 
-				entry.Name = food.Name
-				entry.Kcal = roundMacro(food.Kcal * scale)
-				entry.ProteinG = roundMacro(food.ProteinG * scale)
-				entry.CarbsG = roundMacro(food.CarbsG * scale)
-				entry.FatG = roundMacro(food.FatG * scale)
-				entry.FiberG = roundMacro(food.FiberG * scale)
-				entry.SodiumG = roundMacro(food.SodiumG * scale)
-				entry.SaturatedFatG = roundMacro(food.SaturatedFatG * scale)
+				entry = foodEntryFromQuery(r, food)
 			}
 		}
+	} else if term := r.URL.Query().Get("q"); term != "" {
+		search, err := h.store.SearchFoods(ctx, user.ID, term)
+		if err != nil {
+			data["entry"] = entry
+			h.renderErr(w, r, http.StatusInternalServerError, MacrosNew, err)
+
+			return
+		}
+
+		data["search"] = search
+		data["searchTerm"] = term
 	}
 
 	data["entry"] = entry
@@ -180,6 +187,12 @@ func (h *Handler) PostMacros(w http.ResponseWriter, r *http.Request) {
 	data := h.tmplData(r)
 	user := getCurrentUser(r)
 
+	if err := r.ParseForm(); err == nil && r.PostForm.Get("from_food") != "" {
+		h.postMacroFoodPortion(w, r)
+
+		return
+	}
+
 	params, err := parseMacroEntryForm(r)
 	if err != nil {
 		data["entry"] = repo.MacroEntry{}
@@ -284,6 +297,7 @@ func (h *Handler) PostMacroEntryDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.flashTrashed(ctx, repo.TrashKindMacroEntry, entry.ID)
 	http.Redirect(w, r, "/macros", http.StatusSeeOther)
 }
 
@@ -336,6 +350,40 @@ func (h *Handler) PostMacrosGoals(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/macros", http.StatusSeeOther)
 }
 
+// PostMacrosCopyMeal logs the entries of one meal on from_date again on date,
+// the day shown. Either way the day is shown again, with a flash saying what
+// happened.
+func (h *Handler) PostMacrosCopyMeal(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, ErrorIndex, fmt.Errorf("%w: %w", ErrParseForm, err))
+
+		return
+	}
+
+	to, _, toDate := computeDayWindow(r.FormValue("date"))
+	fromDate := r.FormValue("from_date")
+	if fromDate == "" {
+		fromDate = time.Unix(to-86400, 0).UTC().Format("2006-01-02")
+	}
+	from, _, fromDate := computeDayWindow(fromDate)
+	mealType := r.FormValue("meal_type")
+
+	_, err := h.store.CopyMeal(ctx, getCurrentUser(r).ID, logic.MealCopyParams{
+		From:     from,
+		To:       to,
+		MealType: mealType,
+	})
+	if err != nil {
+		h.session.Put(ctx, SessionFlash, fmt.Sprintf("Could not copy the meal: %v.", err))
+	} else {
+		h.session.Put(ctx, SessionFlash, fmt.Sprintf("Copied the %s logged on %s.", mealType, fromDate))
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/macros?date=%s", toDate), http.StatusSeeOther)
+}
+
 // ----------------------------------------------------------------------------- //
 // Unexported Functions and Helpers
 // ----------------------------------------------------------------------------- //
@@ -363,6 +411,60 @@ func computeDayWindow(dateStr string) (dayStart, nextDayStart int64, selectedDat
 	return dayStart, nextDayStart, selectedDate
 }
 
+// postMacroFoodPortion logs the portion of a food picked on the form. The
+// nutrients come from the food, so the form carries no macro fields.
+func (h *Handler) postMacroFoodPortion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+
+	entry := repo.MacroEntry{Name: r.FormValue("name"), MealType: r.FormValue("meal_type")}
+	data["entry"] = entry
+
+	foodID, err := prog.ParseID(r.FormValue("from_food"), "Food")
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, MacrosNew, logic.ErrUnknownFood)
+
+		return
+	}
+
+	food, err := h.store.FindFood(ctx, foodID, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		h.renderErr(w, r, http.StatusBadRequest, MacrosNew, logic.ErrUnknownFood)
+
+		return
+	}
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, MacrosNew, err)
+
+		return
+	}
+
+	portion := foodPortion{Unit: r.FormValue("unit")}
+	params := logic.MacroEntryParams{Name: entry.Name, MealType: entry.MealType}
+
+	portion.Quantity, err = parseFloatField(r, "quantity")
+	if err == nil {
+		params.Date, err = prog.StringToUnixDate(r.FormValue("date"))
+	}
+	if err == nil {
+		_, err = h.store.LogFoodPortion(ctx, food, portion.params(), params)
+	}
+	if err != nil {
+		entry.Date = params.Date
+		data["entry"] = entry
+		if showErr := h.setFoodPortionData(ctx, data, food, portion); showErr != nil {
+			h.app.Logger.Errorf("failed to load food servings: %v", showErr)
+		}
+		h.renderErr(w, r, http.StatusBadRequest, MacrosNew, err)
+
+		return
+	}
+
+	dateStr := time.Unix(params.Date, 0).UTC().Format("2006-01-02")
+	http.Redirect(w, r, fmt.Sprintf("/macros?date=%s", dateStr), http.StatusSeeOther)
+}
+
 func parseMacroEntryForm(r *http.Request) (logic.MacroEntryParams, error) {
 	var params logic.MacroEntryParams
 
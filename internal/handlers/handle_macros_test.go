@@ -196,6 +196,79 @@ func TestPostMacros(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, rec.Code)
 			},
 		},
+		{
+			name: "should_log_a_serving_of_a_food",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "macros_post_5", "macros_post_5@example.com", "macros_pw_5")
+				food := s.CreateFood(t, user.ID, logic.FoodParams{Name: "Rice", Kcal: 130, CarbsG: 28})
+				serving, err := s.Store.AddFoodServing(t.Context(), food, logic.FoodServingParams{
+					Name: "cup", Amount: 185, Unit: logic.UnitGram,
+				})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "macros_post_5@example.com", "macros_pw_5")
+				newURL := fmt.Sprintf("/macros/new?from_food=%d&quantity=2&unit=serving-%d", food.ID, serving.ID)
+				csrfToken, cookies := s.CSRFFrom(t, newURL, cookies)
+
+				req := spec.NewGetRequest(newURL, cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "cup (185 g)")
+				require.Contains(t, rec.Body.String(), "<td>481</td>")
+
+				form := url.Values{
+					"from_food": {fmt.Sprint(food.ID)},
+					"name":      {""},
+					"quantity":  {"2"},
+					"unit":      {fmt.Sprintf("serving-%d", serving.ID)},
+					"date":      {"2026-03-01T00:00:00Z"},
+					"meal_type": {"dinner"},
+				}
+				req = spec.NewPostRequest("/macros", form.Encode(), cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/macros?date=2026-03-01", rec.Header().Get("Location"))
+
+				entries, err := s.Store.FindMacroEntries(t.Context(), repo.QueryOptions{
+					Filters: repo.Filters{
+						FilterFields: []repo.FilterField{{Name: "user_id", Value: user.ID, Operator: "="}},
+						Connector:    "AND",
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, entries, 1)
+				require.Equal(t, "Rice", entries[0].Name)
+				require.InDelta(t, 481.0, entries[0].Kcal, 0.001)
+				require.InDelta(t, 103.6, entries[0].CarbsG, 0.001)
+			},
+		},
+		{
+			name: "should_reject_a_volume_portion_of_a_food_without_density",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "macros_post_6", "macros_post_6@example.com", "macros_pw_6")
+				food := s.CreateFood(t, user.ID, logic.FoodParams{Name: "Oats", Kcal: 389})
+				cookies := s.AuthCookies(t, "macros_post_6@example.com", "macros_pw_6")
+				csrfToken, cookies := s.CSRFFrom(t, "/macros/new", cookies)
+
+				form := url.Values{
+					"from_food": {fmt.Sprint(food.ID)},
+					"quantity":  {"250"},
+					"unit":      {logic.UnitMilliliter},
+					"date":      {"2026-03-01T00:00:00Z"},
+					"meal_type": {"breakfast"},
+				}
+				req := spec.NewPostRequest("/macros", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "needs the food&#39;s density")
+				require.Contains(t, rec.Body.String(), "Oats")
+			},
+		},
 	}
 
 	for _, tc := range cases {
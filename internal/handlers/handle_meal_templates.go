@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// ----------------------------------------------------------------------------- //
+// Context Middleware
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) MealTemplateContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user := getCurrentUser(r)
+
+		id, err := prog.ParseID(chi.URLParam(r, "id"), "Meal Template")
+		if err != nil {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		template, err := h.store.FindMealTemplate(ctx, id, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		if err != nil {
+			h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+			return
+		}
+
+		ctx = context.WithValue(ctx, KeyMealTemplate, &template)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) GetMealTemplates(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	templates, err := h.store.FindMealTemplates(r.Context(), getCurrentUser(r).ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, MealTemplatesIndex, err)
+
+		return
+	}
+
+	data["mealTemplates"] = templates
+
+	h.render(w, http.StatusOK, MealTemplatesIndex, data)
+}
+
+// GetMealTemplate shows the template's foods with their macros, the meal's
+// total, and the forms to add a food and to log the meal.
+func (h *Handler) GetMealTemplate(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	if err := h.setMealTemplateShowData(r.Context(), data, *getMealTemplate(r)); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, MealTemplatesShow, err)
+
+		return
+	}
+
+	h.render(w, http.StatusOK, MealTemplatesShow, data)
+}
+
+func (h *Handler) GetMealTemplatesNew(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	data["mealTemplate"] = repo.MealTemplate{MealType: "breakfast"}
+
+	h.render(w, http.StatusOK, MealTemplatesNew, data)
+}
+
+func (h *Handler) GetMealTemplatesEdit(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	data["mealTemplate"] = *getMealTemplate(r)
+
+	h.render(w, http.StatusOK, MealTemplatesEdit, data)
+}
+
+func (h *Handler) PostMealTemplates(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	data["mealTemplate"] = repo.MealTemplate{MealType: "breakfast"}
+
+	params, err := parseMealTemplateForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, MealTemplatesNew, err)
+
+		return
+	}
+
+	template, err := h.store.CreateMealTemplate(r.Context(), getCurrentUser(r).ID, params)
+	if err != nil {
+		data["mealTemplate"] = repo.MealTemplate{Name: params.Name, MealType: params.MealType}
+		h.renderErr(w, r, http.StatusBadRequest, MealTemplatesNew, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/meal-templates/%d", template.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostMealTemplatesUpdate(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	template := *getMealTemplate(r)
+
+	data["mealTemplate"] = template
+
+	params, err := parseMealTemplateForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, MealTemplatesEdit, err)
+
+		return
+	}
+
+	_, err = h.store.UpdateMealTemplate(r.Context(), template.ID, getCurrentUser(r).ID, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		template.Name = params.Name
+		template.MealType = params.MealType
+		data["mealTemplate"] = template
+		h.renderErr(w, r, http.StatusBadRequest, MealTemplatesEdit, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/meal-templates/%d", template.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostMealTemplatesDelete(w http.ResponseWriter, r *http.Request) {
+	template := getMealTemplate(r)
+
+	if err := h.store.DeleteMealTemplate(r.Context(), template.ID, getCurrentUser(r).ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/meal-templates", http.StatusSeeOther)
+}
+
+// PostMealTemplateItems adds a food to the template, or sets its quantity
+// when the template already has it.
+func (h *Handler) PostMealTemplateItems(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	template := getMealTemplate(r)
+
+	params, err := parseMealTemplateItemForm(r)
+	if err == nil {
+		_, err = h.store.SaveMealTemplateItem(ctx, template.ID, getCurrentUser(r).ID, params)
+	}
+	if err != nil {
+		// MealTemplateContext found the template, so a miss is the food.
+		if errors.Is(err, sql.ErrNoRows) {
+			err = logic.ErrUnknownFood
+		}
+		h.renderMealTemplateErr(w, r, *template, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/meal-templates/%d", template.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostMealTemplateItemDelete(w http.ResponseWriter, r *http.Request) {
+	template := getMealTemplate(r)
+
+	id, err := prog.ParseID(chi.URLParam(r, "itemID"), "Meal Template Item")
+	if err != nil {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	if err := h.store.RemoveMealTemplateItem(r.Context(), id, template.ID, getCurrentUser(r).ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/meal-templates/%d", template.ID), http.StatusSeeOther)
+}
+
+// PostMealTemplateLog logs every food of the template and shows the day the
+// meal was logged on.
+func (h *Handler) PostMealTemplateLog(w http.ResponseWriter, r *http.Request) {
+	template := getMealTemplate(r)
+
+	params, err := parseMealTemplateApplyForm(r)
+	if err == nil {
+		_, err = h.store.ApplyMealTemplate(r.Context(), *template, params)
+	}
+	if err != nil {
+		h.renderMealTemplateErr(w, r, *template, err)
+
+		return
+	}
+
+	dateStr := time.Unix(params.Date, 0).UTC().Format("2006-01-02")
+	http.Redirect(w, r, fmt.Sprintf("/macros?date=%s", dateStr), http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) setMealTemplateShowData(ctx context.Context, data map[string]any, template repo.MealTemplate) error {
+	detail, err := h.store.FindMealTemplateDetail(ctx, template)
+	if err != nil {
+		return err
+	}
+
+	foods, err := h.store.FindFoods(ctx, repo.QueryOptions{
+		Filters: repo.Filters{
+			FilterFields: []repo.FilterField{
+				{Name: "user_id", Value: template.UserID, Operator: "="},
+			},
+		},
+		Sorting: repo.Sorting{Field: "name", Order: "ASC"},
+	})
+	if err != nil {
+		return err
+	}
+
+	data["mealTemplate"] = detail
+	data["foods"] = foods
+	data["units"] = logic.Units()
+
+	return nil
+}
+
+// renderMealTemplateErr re-renders the template page with a form error. A
+// failure to load the page itself is logged, and the form error still shown.
+func (h *Handler) renderMealTemplateErr(w http.ResponseWriter, r *http.Request, template repo.MealTemplate, err error) {
+	data := h.tmplData(r)
+	if showErr := h.setMealTemplateShowData(r.Context(), data, template); showErr != nil {
+		h.app.Logger.Errorf("failed to load meal template: %v", showErr)
+	}
+	h.renderErr(w, r, http.StatusBadRequest, MealTemplatesShow, err)
+}
+
+func parseMealTemplateForm(r *http.Request) (logic.MealTemplateParams, error) {
+	var params logic.MealTemplateParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	params.Name = r.FormValue("name")
+	params.MealType = r.FormValue("meal_type")
+
+	return params, nil
+}
+
+func parseMealTemplateItemForm(r *http.Request) (logic.MealTemplateItemParams, error) {
+	var params logic.MealTemplateItemParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	foodID, err := prog.ParseID(r.FormValue("food_id"), "Food")
+	if err != nil {
+		return params, err
+	}
+
+	quantity, err := parseFloatField(r, "quantity")
+	if err != nil {
+		return params, err
+	}
+
+	params.FoodID = foodID
+	params.Quantity = quantity
+	params.Unit = r.FormValue("unit")
+
+	return params, nil
+}
+
+func parseMealTemplateApplyForm(r *http.Request) (logic.MealTemplateApplyParams, error) {
+	var params logic.MealTemplateApplyParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	date, err := prog.StringToUnixDate(r.FormValue("date"))
+	if err != nil {
+		return params, err
+	}
+
+	params.Date = date
+	params.MealType = r.FormValue("meal_type")
+
+	return params, nil
+}
+
+func getMealTemplate(r *http.Request) *repo.MealTemplate {
+	template, ok := r.Context().Value(KeyMealTemplate).(*repo.MealTemplate)
+
+	if !ok {
+		panic("failed to get meal template context")
+	}
+
+	return template
+}
@@ -0,0 +1,208 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMealTemplates(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	entriesOf := func(t *testing.T, userID int) []repo.MacroEntry {
+		t.Helper()
+
+		entries, err := s.Store.FindMacroEntries(t.Context(), repo.QueryOptions{
+			Filters: repo.Filters{
+				FilterFields: []repo.FilterField{
+					{Name: "user_id", Value: userID, Operator: "="},
+				},
+			},
+			Sorting: repo.Sorting{Field: "date", Order: "ASC"},
+		})
+		require.NoError(t, err)
+
+		return entries
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_build_a_template_and_log_it",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "meal_tmpl_h_1", "meal_tmpl_h_1@example.com", "meal_tmpl_password_1")
+				eggs, err := s.Store.CreateFood(t.Context(), user.ID, newFoodParams("Eggs"))
+				require.NoError(t, err)
+				toast, err := s.Store.CreateFood(t.Context(), user.ID, newFoodParams("Toast"))
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "meal_tmpl_h_1@example.com", "meal_tmpl_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/meal-templates/new", cookies)
+
+				form := url.Values{"name": {"Weekday breakfast"}, "meal_type": {"breakfast"}}
+				req := spec.NewPostRequest("/meal-templates", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				templates, err := s.Store.FindMealTemplates(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Len(t, templates, 1)
+				path := fmt.Sprintf("/meal-templates/%d", templates[0].Template.ID)
+				require.Equal(t, path, rec.Header().Get("Location"))
+
+				for _, item := range []url.Values{
+					{"food_id": {strconv.Itoa(eggs.ID)}, "quantity": {"120"}, "unit": {"g"}},
+					{"food_id": {strconv.Itoa(toast.ID)}, "quantity": {"0.1"}, "unit": {"kg"}},
+				} {
+					req = spec.NewPostRequest(path+"/items", item.Encode(), cookies, csrfToken)
+					rec = httptest.NewRecorder()
+					handler.ServeHTTP(rec, req)
+
+					require.Equal(t, http.StatusSeeOther, rec.Code)
+				}
+
+				req = spec.NewGetRequest(path, cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Eggs")
+				require.Contains(t, rec.Body.String(), "<td>0.1 kg</td>")
+				require.Contains(t, rec.Body.String(), "<td>440</td>")
+
+				date := time.Date(2026, time.April, 9, 7, 30, 0, 0, time.UTC)
+				form = url.Values{"date": {date.Format(time.RFC3339)}, "meal_type": {""}}
+				req = spec.NewPostRequest(path+"/log", form.Encode(), cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/macros?date=2026-04-09", rec.Header().Get("Location"))
+
+				entries := entriesOf(t, user.ID)
+				require.Len(t, entries, 2)
+				for _, e := range entries {
+					require.Equal(t, "breakfast", e.MealType)
+					require.Equal(t, date.Unix(), e.Date)
+				}
+			},
+		},
+		{
+			name: "should_show_an_error_when_logging_an_empty_template",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "meal_tmpl_h_2", "meal_tmpl_h_2@example.com", "meal_tmpl_password_2")
+				template, err := s.Store.CreateMealTemplate(t.Context(), user.ID, logic.MealTemplateParams{
+					Name:     "Nothing yet",
+					MealType: "dinner",
+				})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "meal_tmpl_h_2@example.com", "meal_tmpl_password_2")
+				path := fmt.Sprintf("/meal-templates/%d", template.ID)
+				csrfToken, cookies := s.CSRFFrom(t, path, cookies)
+
+				form := url.Values{"date": {time.Now().UTC().Format(time.RFC3339)}}
+				req := spec.NewPostRequest(path+"/log", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), logic.ErrMealTemplateEmpty.Error())
+				require.Empty(t, entriesOf(t, user.ID))
+			},
+		},
+		{
+			name: "should_not_show_another_users_template",
+			fn: func(t *testing.T) {
+				owner := s.CreateAuthUser(t, "meal_tmpl_h_3", "meal_tmpl_h_3@example.com", "meal_tmpl_password_3")
+				template, err := s.Store.CreateMealTemplate(t.Context(), owner.ID, logic.MealTemplateParams{
+					Name:     "Secret",
+					MealType: "snack",
+				})
+				require.NoError(t, err)
+				s.CreateAuthUser(t, "meal_tmpl_h_4", "meal_tmpl_h_4@example.com", "meal_tmpl_password_4")
+				cookies := s.AuthCookies(t, "meal_tmpl_h_4@example.com", "meal_tmpl_password_4")
+
+				req := spec.NewGetRequest(fmt.Sprintf("/meal-templates/%d", template.ID), cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name: "should_copy_yesterdays_meal_to_the_day_shown",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "meal_tmpl_h_5", "meal_tmpl_h_5@example.com", "meal_tmpl_password_5")
+				yesterday := time.Date(2026, time.April, 8, 12, 15, 0, 0, time.UTC)
+				_, err := s.Store.CreateMacroEntry(t.Context(), user.ID, logic.MacroEntryParams{
+					Name:     "Soup",
+					Kcal:     250,
+					Date:     yesterday.Unix(),
+					MealType: "lunch",
+				})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "meal_tmpl_h_5@example.com", "meal_tmpl_password_5")
+				csrfToken, cookies := s.CSRFFrom(t, "/macros?date=2026-04-09", cookies)
+
+				form := url.Values{"date": {"2026-04-09"}, "meal_type": {"lunch"}}
+				req := spec.NewPostRequest("/macros/copy-meal", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/macros?date=2026-04-09", rec.Header().Get("Location"))
+
+				entries := entriesOf(t, user.ID)
+				require.Len(t, entries, 2)
+				require.Equal(t, "Soup", entries[1].Name)
+				require.Equal(t, yesterday.AddDate(0, 0, 1).Unix(), entries[1].Date)
+
+				req = spec.NewGetRequest(rec.Header().Get("Location"), cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Copied the lunch logged on 2026-04-08.")
+			},
+		},
+		{
+			name: "should_flash_when_there_is_no_meal_to_copy",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "meal_tmpl_h_6", "meal_tmpl_h_6@example.com", "meal_tmpl_password_6")
+				cookies := s.AuthCookies(t, "meal_tmpl_h_6@example.com", "meal_tmpl_password_6")
+				csrfToken, cookies := s.CSRFFrom(t, "/macros", cookies)
+
+				form := url.Values{"date": {"2026-04-09"}, "from_date": {"2026-04-01"}, "meal_type": {"dinner"}}
+				req := spec.NewPostRequest("/macros/copy-meal", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Empty(t, entriesOf(t, user.ID))
+
+				req = spec.NewGetRequest(rec.Header().Get("Location"), cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Contains(t, rec.Body.String(), logic.ErrMealEmpty.Error())
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
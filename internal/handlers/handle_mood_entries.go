@@ -65,6 +65,7 @@ func (h *Handler) MoodEntryContext(next http.Handler) http.Handler {
 func (h *Handler) GetMoodEntries(w http.ResponseWriter, r *http.Request) {
 	data := h.tmplData(r)
 	user := getCurrentUser(r)
+	h.setFlashData(r.Context(), data)
 	q := r.URL.Query()
 
 	sortField := q.Get("sort_field")
@@ -281,6 +282,7 @@ func (h *Handler) PostMoodEntriesDelete(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	h.flashTrashed(ctx, repo.TrashKindMoodEntry, entry.ID)
 	http.Redirect(w, r, "/moods", http.StatusSeeOther)
 }
 
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) PostNotificationRead(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	id, err := prog.ParseID(chi.URLParam(r, "id"), "notification")
+	if err != nil {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	if err := h.store.DismissNotification(ctx, id, user.ID); err != nil {
+		// Dismissed from another tab already, or never the user's.
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+func (h *Handler) PostNotificationsReadAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	if err := h.store.DismissAllNotifications(ctx, user.ID); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+// buildDashboardNotifications lists the user's unread notifications, newest
+// first.
+func (h *Handler) buildDashboardNotifications(
+	w http.ResponseWriter,
+	r *http.Request,
+	user *logic.User,
+) ([]repo.Notification, bool) {
+	notifications, err := h.store.FindUnreadNotifications(r.Context(), user.ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, DashboardIndex, err)
+
+		return nil, false
+	}
+
+	return notifications, true
+}
@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationsFlow(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_list_and_dismiss_a_budget_notification",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "notify_h_1", "notify_h_1@example.com", "notify_password_1")
+				category := s.CreateCategory(t, user.ID, "notify groceries")
+				s.SaveExpenseBudgets(t, user.ID, map[int]uint64{category.ID: 10000})
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "notify expense", 9000, monthStart(0)))
+				cookies := s.AuthCookies(t, "notify_h_1@example.com", "notify_password_1")
+
+				req := spec.NewGetRequest("/dashboard", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "notify groceries has reached 90%")
+
+				notifications, err := s.Store.FindUnreadNotifications(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Len(t, notifications, 1)
+
+				csrfToken, cookies := s.CSRFFrom(t, "/dashboard", cookies)
+				path := fmt.Sprintf("/notifications/%d/read", notifications[0].ID)
+				req = spec.NewPostRequest(path, "", cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/dashboard", rec.Header().Get("Location"))
+
+				req = spec.NewGetRequest("/dashboard", cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.NotContains(t, rec.Body.String(), "notify groceries has reached")
+			},
+		},
+		{
+			name: "should_dismiss_every_notification",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "notify_h_2", "notify_h_2@example.com", "notify_password_2")
+				category := s.CreateCategory(t, user.ID, "notify rent")
+				s.SaveExpenseBudgets(t, user.ID, map[int]uint64{category.ID: 10000})
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "notify one", 8000, monthStart(0)))
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "notify two", 3000, monthStart(0)))
+				cookies := s.AuthCookies(t, "notify_h_2@example.com", "notify_password_2")
+
+				csrfToken, cookies := s.CSRFFrom(t, "/dashboard", cookies)
+				req := spec.NewPostRequest("/notifications/read-all", "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				notifications, err := s.Store.FindUnreadNotifications(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Empty(t, notifications)
+			},
+		},
+		{
+			name: "should_return_not_found_for_another_users_notification",
+			fn: func(t *testing.T) {
+				owner := s.CreateAuthUser(t, "notify_h_3", "notify_h_3@example.com", "notify_password_3")
+				category := s.CreateCategory(t, owner.ID, "notify travel")
+				s.SaveExpenseBudgets(t, owner.ID, map[int]uint64{category.ID: 10000})
+				s.CreateExpense(t, owner.ID, newExpenseParams(category.ID, "notify trip", 12000, monthStart(0)))
+
+				notifications, err := s.Store.FindUnreadNotifications(t.Context(), owner.ID)
+				require.NoError(t, err)
+				require.Len(t, notifications, 1)
+
+				s.CreateAuthUser(t, "notify_h_4", "notify_h_4@example.com", "notify_password_4")
+				cookies := s.AuthCookies(t, "notify_h_4@example.com", "notify_password_4")
+				csrfToken, cookies := s.CSRFFrom(t, "/dashboard", cookies)
+				path := fmt.Sprintf("/notifications/%d/read", notifications[0].ID)
+				req := spec.NewPostRequest(path, "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+var paymentAccountKindLabels = map[string]string{ //nolint:gochecknoglobals // static lookup table
+	"checking":    "Checking",
+	"credit_card": "Credit card",
+	"cash":        "Cash",
+}
+
+type paymentAccountKindOption struct {
+	Value string
+	Label string
+}
+
+type paymentAccountRow struct {
+	repo.PaymentAccount
+	KindLabel string
+}
+
+// ----------------------------------------------------------------------------- //
+// Context Middleware
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) PaymentAccountContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user := getCurrentUser(r)
+
+		id, err := prog.ParseID(chi.URLParam(r, "id"), "Payment Account")
+		if err != nil {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		account, err := h.store.FindPaymentAccount(ctx, id, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		if err != nil {
+			h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+			return
+		}
+
+		ctx = context.WithValue(ctx, KeyPaymentAccount, &account)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) GetPaymentAccounts(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	accounts, err := h.store.FindPaymentAccounts(r.Context(), getCurrentUser(r).ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, PaymentAccountsIndex, err)
+
+		return
+	}
+
+	rows := make([]paymentAccountRow, 0, len(accounts))
+	for _, account := range accounts {
+		rows = append(rows, newPaymentAccountRow(account))
+	}
+
+	data["paymentAccounts"] = rows
+
+	h.render(w, http.StatusOK, PaymentAccountsIndex, data)
+}
+
+// GetPaymentAccount shows the account's ledger: its expenses oldest first with
+// the balance after each, and the form to reconcile it against a statement.
+func (h *Handler) GetPaymentAccount(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	account := getPaymentAccount(r)
+
+	ledger, err := h.store.FindPaymentAccountLedger(r.Context(), account.ID, getCurrentUser(r).ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, PaymentAccountsShow, err)
+
+		return
+	}
+
+	data["paymentAccount"] = newPaymentAccountRow(ledger.Account)
+	data["ledger"] = ledger
+
+	h.render(w, http.StatusOK, PaymentAccountsShow, data)
+}
+
+func (h *Handler) GetPaymentAccountsNew(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	setPaymentAccountFormData(data, repo.PaymentAccount{Kind: "checking"})
+
+	h.render(w, http.StatusOK, PaymentAccountsNew, data)
+}
+
+func (h *Handler) GetPaymentAccountsEdit(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	setPaymentAccountFormData(data, *getPaymentAccount(r))
+
+	h.render(w, http.StatusOK, PaymentAccountsEdit, data)
+}
+
+func (h *Handler) PostPaymentAccounts(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	setPaymentAccountFormData(data, repo.PaymentAccount{Kind: "checking"})
+
+	params, err := parsePaymentAccountForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, PaymentAccountsNew, err)
+
+		return
+	}
+
+	account, err := h.store.CreatePaymentAccount(r.Context(), getCurrentUser(r).ID, params)
+	if err != nil {
+		setPaymentAccountFormData(data, repo.PaymentAccount{
+			Name:           params.Name,
+			Kind:           params.Kind,
+			Currency:       params.Currency,
+			OpeningBalance: params.OpeningBalance,
+		})
+		h.renderErr(w, r, http.StatusBadRequest, PaymentAccountsNew, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/payment-accounts/%d", account.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostPaymentAccountsUpdate(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	account := *getPaymentAccount(r)
+
+	setPaymentAccountFormData(data, account)
+
+	params, err := parsePaymentAccountForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, PaymentAccountsEdit, err)
+
+		return
+	}
+
+	_, err = h.store.UpdatePaymentAccount(r.Context(), account.ID, getCurrentUser(r).ID, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		account.Name = params.Name
+		account.Kind = params.Kind
+		account.OpeningBalance = params.OpeningBalance
+		if params.Currency != "" {
+			account.Currency = params.Currency
+		}
+		setPaymentAccountFormData(data, account)
+		h.renderErr(w, r, http.StatusBadRequest, PaymentAccountsEdit, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/payment-accounts/%d", account.ID), http.StatusSeeOther)
+}
+
+// PostPaymentAccountsReconcile marks the ledger as matching a statement up to
+// and including the submitted date.
+func (h *Handler) PostPaymentAccountsReconcile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+	account := getPaymentAccount(r)
+
+	through, err := parseReconcileForm(r)
+	if err == nil {
+		_, err = h.store.ReconcilePaymentAccount(ctx, account.ID, user.ID, through)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		data := h.tmplData(r)
+		ledger, ledgerErr := h.store.FindPaymentAccountLedger(ctx, account.ID, user.ID)
+		if ledgerErr != nil {
+			h.app.Logger.Errorf("failed to load payment account ledger: %v", ledgerErr)
+		}
+		data["paymentAccount"] = newPaymentAccountRow(*account)
+		data["ledger"] = ledger
+		h.renderErr(w, r, http.StatusBadRequest, PaymentAccountsShow, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/payment-accounts/%d", account.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostPaymentAccountsDelete(w http.ResponseWriter, r *http.Request) {
+	account := getPaymentAccount(r)
+
+	if err := h.store.DeletePaymentAccount(r.Context(), account.ID, getCurrentUser(r).ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/payment-accounts", http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+// parsePaymentAccountForm reads opening_balance as signed cents: a credit card
+// opened with a debt starts below zero. An empty balance is zero.
+func parsePaymentAccountForm(r *http.Request) (logic.PaymentAccountParams, error) {
+	var params logic.PaymentAccountParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	if value := strings.TrimSpace(r.FormValue("opening_balance")); value != "" {
+		balance, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("%w of Opening balance %q", prog.ErrParsing, value)
+		}
+		params.OpeningBalance = balance
+	}
+
+	params.Name = r.FormValue("name")
+	params.Kind = r.FormValue("kind")
+	params.Currency = r.FormValue("currency")
+
+	return params, nil
+}
+
+func parseReconcileForm(r *http.Request) (int64, error) {
+	if err := r.ParseForm(); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	if r.FormValue("date") == "" {
+		return 0, logic.ErrReconcileDate
+	}
+
+	return prog.StringToUnixDate(r.FormValue("date"))
+}
+
+func setPaymentAccountFormData(data map[string]any, account repo.PaymentAccount) {
+	kinds := logic.PaymentAccountKinds()
+	options := make([]paymentAccountKindOption, 0, len(kinds))
+	for _, kind := range kinds {
+		options = append(options, paymentAccountKindOption{Value: kind, Label: paymentAccountKindLabels[kind]})
+	}
+
+	data["paymentAccount"] = account
+	data["paymentAccountKinds"] = options
+}
+
+func newPaymentAccountRow(account repo.PaymentAccount) paymentAccountRow {
+	return paymentAccountRow{PaymentAccount: account, KindLabel: paymentAccountKindLabels[account.Kind]}
+}
+
+func getPaymentAccount(r *http.Request) *repo.PaymentAccount {
+	account, ok := r.Context().Value(KeyPaymentAccount).(*repo.PaymentAccount)
+
+	if !ok {
+		panic("failed to get payment account context")
+	}
+
+	return account
+}
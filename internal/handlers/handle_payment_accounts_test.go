@@ -0,0 +1,193 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentAccounts(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_account_and_show_its_ledger",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "payment_account_h_1", "payment_account_h_1@example.com", "pa_password_1")
+				cookies := s.AuthCookies(t, "payment_account_h_1@example.com", "pa_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/payment-accounts/new", cookies)
+
+				form := url.Values{
+					"name":            {"Travel card"},
+					"kind":            {"credit_card"},
+					"opening_balance": {"-2500"},
+				}
+				req := spec.NewPostRequest("/payment-accounts", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				accounts, err := s.Store.FindPaymentAccounts(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Len(t, accounts, 1)
+				path := fmt.Sprintf("/payment-accounts/%d", accounts[0].ID)
+				require.Equal(t, path, rec.Header().Get("Location"))
+
+				category := s.CreateCategory(t, user.ID, "Trips")
+				s.CreateExpense(t, user.ID, logic.ExpenseParams{
+					ExpenseBaseParams: logic.ExpenseBaseParams{
+						CategoryID:       category.ID,
+						Description:      "Train ticket",
+						Amount:           4000,
+						PaymentAccountID: &accounts[0].ID,
+					},
+					Date: today.Unix(),
+				})
+
+				req = spec.NewGetRequest(path, cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Train ticket")
+				require.Contains(t, rec.Body.String(), "-$65.00")
+				require.Contains(t, rec.Body.String(), "Never reconciled")
+			},
+		},
+		{
+			name: "should_reconcile_through_a_date",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "payment_account_h_2", "payment_account_h_2@example.com", "pa_password_2")
+				account, err := s.Store.CreatePaymentAccount(t.Context(), user.ID, logic.PaymentAccountParams{
+					Name: "Checking",
+					Kind: "checking",
+				})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "payment_account_h_2@example.com", "pa_password_2")
+				path := fmt.Sprintf("/payment-accounts/%d", account.ID)
+				csrfToken, cookies := s.CSRFFrom(t, path, cookies)
+
+				form := url.Values{"date": {today.Format(time.RFC3339)}}
+				req := spec.NewPostRequest(path+"/reconcile", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				account, err = s.Store.FindPaymentAccount(t.Context(), account.ID, user.ID)
+				require.NoError(t, err)
+				require.NotNil(t, account.ReconciledThrough)
+				require.Equal(t, today.Unix(), *account.ReconciledThrough)
+			},
+		},
+		{
+			name: "should_filter_expenses_by_account",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "payment_account_h_3", "payment_account_h_3@example.com", "pa_password_3")
+				account, err := s.Store.CreatePaymentAccount(t.Context(), user.ID, logic.PaymentAccountParams{
+					Name: "Wallet",
+					Kind: "cash",
+				})
+				require.NoError(t, err)
+				category := s.CreateCategory(t, user.ID, "Snacks")
+				s.CreateExpense(t, user.ID, logic.ExpenseParams{
+					ExpenseBaseParams: logic.ExpenseBaseParams{
+						CategoryID:       category.ID,
+						Description:      "Paid in cash",
+						Amount:           300,
+						PaymentAccountID: &account.ID,
+					},
+					Date: today.Unix(),
+				})
+				s.CreateExpense(t, user.ID, logic.ExpenseParams{
+					ExpenseBaseParams: logic.ExpenseBaseParams{
+						CategoryID:  category.ID,
+						Description: "Paid another way",
+						Amount:      400,
+					},
+					Date: today.Unix(),
+				})
+				cookies := s.AuthCookies(t, "payment_account_h_3@example.com", "pa_password_3")
+
+				req := spec.NewGetRequest(
+					fmt.Sprintf("/expenses?date_range=all_time&payment_account_id=%d", account.ID),
+					cookies,
+				)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Paid in cash")
+				require.NotContains(t, rec.Body.String(), "Paid another way")
+			},
+		},
+		{
+			name: "should_save_the_account_chosen_on_the_expense_form",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "payment_account_h_4", "payment_account_h_4@example.com", "pa_password_4")
+				account, err := s.Store.CreatePaymentAccount(t.Context(), user.ID, logic.PaymentAccountParams{
+					Name: "Debit card",
+					Kind: "checking",
+				})
+				require.NoError(t, err)
+				category := s.CreateCategory(t, user.ID, "Books")
+				cookies := s.AuthCookies(t, "payment_account_h_4@example.com", "pa_password_4")
+				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
+
+				form := url.Values{
+					"category_id":        {fmt.Sprint(category.ID)},
+					"description":        {"Novel"},
+					"amount":             {"1800"},
+					"date":               {today.Format(time.RFC3339)},
+					"payment_account_id": {fmt.Sprint(account.ID)},
+				}
+				req := spec.NewPostRequest("/expenses", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				ledger, err := s.Store.FindPaymentAccountLedger(t.Context(), account.ID, user.ID)
+				require.NoError(t, err)
+				require.Len(t, ledger.Entries, 1)
+				require.Equal(t, "Novel", ledger.Entries[0].Description)
+			},
+		},
+		{
+			name: "should_not_show_another_users_account",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "payment_account_h_5", "payment_account_h_5@example.com", "pa_password_5")
+				owner := s.CreateAuthUser(t, "payment_account_h_6", "payment_account_h_6@example.com", "pa_password_6")
+				account, err := s.Store.CreatePaymentAccount(t.Context(), owner.ID, logic.PaymentAccountParams{
+					Name: "Private savings",
+					Kind: "checking",
+				})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "payment_account_h_5@example.com", "pa_password_5")
+
+				req := spec.NewGetRequest(fmt.Sprintf("/payment-accounts/%d", account.ID), cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
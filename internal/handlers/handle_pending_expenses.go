@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/go-chi/chi/v5"
+)
+
+// pendingExpenseRow is one bill in the dashboard inbox. Amount is the
+// recurrent expense's estimate, which the confirm form starts from.
+type pendingExpenseRow struct {
+	ID           int
+	Date         int64
+	Description  string
+	CategoryName string
+	Amount       uint64
+	Currency     string
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) PostPendingExpenseConfirm(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	id, err := prog.ParseID(chi.URLParam(r, "id"), "pending expense")
+	if err != nil {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	amount, err := prog.ParseAmount(r.FormValue("amount"))
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, ErrorIndex, err)
+
+		return
+	}
+
+	_, err = h.store.ConfirmPendingExpense(ctx, id, user.ID, logic.ConfirmPendingExpenseParams{Amount: amount})
+	if err != nil {
+		h.renderPendingExpenseErr(w, r, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+func (h *Handler) PostPendingExpenseSkip(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	id, err := prog.ParseID(chi.URLParam(r, "id"), "pending expense")
+	if err != nil {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	if err := h.store.SkipPendingExpense(ctx, id, user.ID); err != nil {
+		h.renderPendingExpenseErr(w, r, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+// buildDashboardPendingExpenses lists the bills waiting in the inbox.
+func (h *Handler) buildDashboardPendingExpenses(
+	w http.ResponseWriter,
+	r *http.Request,
+	user *logic.User,
+) ([]pendingExpenseRow, bool) {
+	pending, err := h.store.FindPendingExpenses(r.Context(), user.ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, DashboardIndex, err)
+
+		return nil, false
+	}
+	if len(pending) == 0 {
+		return nil, true
+	}
+
+	_, categoryNameByID, ok := h.findCategoriesOrErr(w, r, DashboardIndex)
+	if !ok {
+		return nil, false
+	}
+
+	rows := make([]pendingExpenseRow, 0, len(pending))
+	for _, p := range pending {
+		rows = append(rows, pendingExpenseRow{
+			ID:           p.ID,
+			Date:         p.Date,
+			Description:  p.RecurrentExpense.Description,
+			CategoryName: categoryNameOrUnknown(categoryNameByID, p.RecurrentExpense.CategoryID),
+			Amount:       p.RecurrentExpense.Amount,
+			Currency:     p.RecurrentExpense.Currency,
+		})
+	}
+
+	return rows, true
+}
+
+// renderPendingExpenseErr treats an item that is gone, already confirmed or
+// skipped from another tab included, as not found.
+func (h *Handler) renderPendingExpenseErr(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		h.NotFound(w, r)
+	case errors.Is(err, logic.ErrValidationFailed):
+		h.renderErr(w, r, http.StatusBadRequest, ErrorIndex, err)
+	default:
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+	}
+}
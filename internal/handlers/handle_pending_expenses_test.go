@@ -0,0 +1,130 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingExpensesFlow(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	newPending := func(t *testing.T, userID int, description string) logic.PendingExpense {
+		t.Helper()
+
+		category := s.CreateCategory(t, userID, description+" category")
+		params := newRecurrentExpenseParams(category.ID, description, 4200, 1)
+		params.IsEstimate = true
+		re := s.CreateRecurrentExpense(t, userID, params)
+
+		_, err := s.Store.CopyDueRecurrentExpenses(t.Context(), time.Now())
+		require.NoError(t, err)
+
+		pending, err := s.Store.FindPendingExpenses(t.Context(), userID)
+		require.NoError(t, err)
+		for _, p := range pending {
+			if p.RecurrentExpenseID == re.ID {
+				return p
+			}
+		}
+		require.FailNow(t, "no pending expense for "+description)
+
+		return logic.PendingExpense{}
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_list_and_confirm_a_pending_expense",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "pending_h_1", "pending_h_1@example.com", "pending_password_1")
+				pending := newPending(t, user.ID, "Power bill")
+				cookies := s.AuthCookies(t, "pending_h_1@example.com", "pending_password_1")
+
+				req := spec.NewGetRequest("/dashboard", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Bills to confirm")
+				require.Contains(t, rec.Body.String(), "Power bill")
+
+				csrfToken, cookies := s.CSRFFrom(t, "/dashboard", cookies)
+				form := url.Values{"amount": {"5175"}}
+				path := fmt.Sprintf("/pending-expenses/%d/confirm", pending.ID)
+				req = spec.NewPostRequest(path, form.Encode(), cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/dashboard", rec.Header().Get("Location"))
+
+				expenses, err := s.Store.FindExpenses(t.Context(), repo.QueryOptions{
+					Filters: repo.Filters{
+						FilterFields: []repo.FilterField{
+							{Name: "recurrent_expense_id", Value: pending.RecurrentExpenseID, Operator: "="},
+						},
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, expenses, 1)
+				require.Equal(t, uint64(5175), expenses[0].Amount)
+
+				req = spec.NewGetRequest("/dashboard", cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.NotContains(t, rec.Body.String(), "Bills to confirm")
+			},
+		},
+		{
+			name: "should_skip_a_pending_expense",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "pending_h_2", "pending_h_2@example.com", "pending_password_2")
+				pending := newPending(t, user.ID, "Water bill")
+				cookies := s.AuthCookies(t, "pending_h_2@example.com", "pending_password_2")
+				csrfToken, cookies := s.CSRFFrom(t, "/dashboard", cookies)
+
+				path := fmt.Sprintf("/pending-expenses/%d/skip", pending.ID)
+				req := spec.NewPostRequest(path, "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				remaining, err := s.Store.FindPendingExpenses(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Empty(t, remaining)
+			},
+		},
+		{
+			name: "should_return_not_found_for_another_users_item",
+			fn: func(t *testing.T) {
+				owner := s.CreateAuthUser(t, "pending_h_3", "pending_h_3@example.com", "pending_password_3")
+				pending := newPending(t, owner.ID, "Gas bill")
+				s.CreateAuthUser(t, "pending_h_4", "pending_h_4@example.com", "pending_password_4")
+				cookies := s.AuthCookies(t, "pending_h_4@example.com", "pending_password_4")
+				csrfToken, cookies := s.CSRFFrom(t, "/dashboard", cookies)
+
+				form := url.Values{"amount": {"100"}}
+				path := fmt.Sprintf("/pending-expenses/%d/confirm", pending.ID)
+				req := spec.NewPostRequest(path, form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
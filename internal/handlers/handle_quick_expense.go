@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/ad9311/ninete/internal/logic"
@@ -11,7 +12,9 @@ import (
 // PostExpensesQuick handles the quick-add expense form: a single free-text field
 // ("description, amount, date"). It resolves the category from a remembered
 // mapping; on the first use of a description it re-renders the form asking the
-// user to pick a category, which is then saved for future reuse.
+// user to pick a category, which is then saved for future reuse. A repeat of an
+// existing expense re-renders the form with the matches, asking whether to
+// skip, merge or save it anyway.
 func (h *Handler) PostExpensesQuick(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	data := h.tmplData(r)
@@ -24,9 +27,10 @@ func (h *Handler) PostExpensesQuick(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rawInput := r.FormValue("quick_input")
-	categories, _, categoriesErr := h.findCategories(ctx)
+	categories, _, categoriesErr := h.findCategories(ctx, user.ID)
 	setExpenseFormData(data, categories, repo.Expense{}, "")
 	setQuickFormData(data, categories, rawInput, false)
+	h.setPaymentAccountsData(r, data)
 
 	parsed, err := logic.ParseQuickExpense(rawInput, parseTZOffset(r))
 	if err != nil {
@@ -59,7 +63,18 @@ func (h *Handler) PostExpensesQuick(w http.ResponseWriter, r *http.Request) {
 		categoryID = resolvedID
 	}
 
-	if _, err := h.store.CreateQuickExpense(ctx, user.ID, categoryID, parsed); err != nil {
+	action := r.FormValue("duplicate_action")
+	if _, err := h.store.CreateQuickExpense(ctx, user.ID, categoryID, parsed, action); err != nil {
+		var dupErr *logic.DuplicateExpenseError
+		if errors.As(err, &dupErr) {
+			setDuplicateData(data, dupErr.Matches, categories, categoryID)
+			setQuickFormData(data, categories, rawInput, false)
+			data["error"] = err.Error()
+			h.render(w, http.StatusUnprocessableEntity, ExpensesNew, data)
+
+			return
+		}
+
 		h.renderQuickErr(w, r, rawInput, err)
 
 		return
@@ -76,9 +91,10 @@ func (h *Handler) PostExpensesQuick(w http.ResponseWriter, r *http.Request) {
 // preserving the raw input and showing the error message.
 func (h *Handler) renderQuickErr(w http.ResponseWriter, r *http.Request, rawInput string, err error) {
 	data := h.tmplData(r)
-	categories, _, _ := h.findCategories(r.Context())
+	categories, _, _ := h.findCategories(r.Context(), getCurrentUser(r).ID)
 	setExpenseFormData(data, categories, repo.Expense{}, "")
 	setQuickFormData(data, categories, rawInput, false)
+	h.setPaymentAccountsData(r, data)
 	h.renderErr(w, r, http.StatusBadRequest, ExpensesNew, err)
 }
 
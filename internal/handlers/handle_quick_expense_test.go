@@ -23,8 +23,8 @@ func TestPostExpensesQuick(t *testing.T) {
 		{
 			name: "should_ask_for_category_on_first_use",
 			fn: func(t *testing.T) {
-				s.CreateAuthUser(t, "quick_h_1", "quick_h_1@example.com", "quick_password_1")
-				s.CreateCategory(t, "quick_h_cat_1")
+				user := s.CreateAuthUser(t, "quick_h_1", "quick_h_1@example.com", "quick_password_1")
+				s.CreateCategory(t, user.ID, "quick_h_cat_1")
 				cookies := s.AuthCookies(t, "quick_h_1@example.com", "quick_password_1")
 				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
 
@@ -40,8 +40,8 @@ func TestPostExpensesQuick(t *testing.T) {
 		{
 			name: "should_create_and_redirect_when_category_provided",
 			fn: func(t *testing.T) {
-				s.CreateAuthUser(t, "quick_h_2", "quick_h_2@example.com", "quick_password_2")
-				category := s.CreateCategory(t, "quick_h_cat_2")
+				user := s.CreateAuthUser(t, "quick_h_2", "quick_h_2@example.com", "quick_password_2")
+				category := s.CreateCategory(t, user.ID, "quick_h_cat_2")
 				cookies := s.AuthCookies(t, "quick_h_2@example.com", "quick_password_2")
 				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
 
@@ -60,8 +60,8 @@ func TestPostExpensesQuick(t *testing.T) {
 		{
 			name: "should_reuse_remembered_category_without_asking",
 			fn: func(t *testing.T) {
-				s.CreateAuthUser(t, "quick_h_3", "quick_h_3@example.com", "quick_password_3")
-				category := s.CreateCategory(t, "quick_h_cat_3")
+				user := s.CreateAuthUser(t, "quick_h_3", "quick_h_3@example.com", "quick_password_3")
+				category := s.CreateCategory(t, user.ID, "quick_h_cat_3")
 				cookies := s.AuthCookies(t, "quick_h_3@example.com", "quick_password_3")
 				csrfToken, cookies := s.CSRFFrom(t, "/expenses/new", cookies)
 
@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// ----------------------------------------------------------------------------- //
+// Context Middleware
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) RecipeContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user := getCurrentUser(r)
+
+		id, err := prog.ParseID(chi.URLParam(r, "id"), "Recipe")
+		if err != nil {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		recipe, err := h.store.FindRecipe(ctx, id, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		if err != nil {
+			h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+			return
+		}
+
+		ctx = context.WithValue(ctx, KeyRecipe, &recipe)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) GetRecipes(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	recipes, err := h.store.FindRecipes(r.Context(), getCurrentUser(r).ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, RecipesIndex, err)
+
+		return
+	}
+
+	data["recipes"] = recipes
+
+	h.render(w, http.StatusOK, RecipesIndex, data)
+}
+
+// GetRecipe shows the recipe's ingredients with their macros, the totals per
+// serving, and the forms to add an ingredient and to log a portion.
+func (h *Handler) GetRecipe(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	if err := h.setRecipeShowData(r.Context(), data, *getRecipe(r)); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, RecipesShow, err)
+
+		return
+	}
+
+	h.render(w, http.StatusOK, RecipesShow, data)
+}
+
+func (h *Handler) GetRecipesNew(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	data["recipe"] = repo.Recipe{Servings: 1}
+
+	h.render(w, http.StatusOK, RecipesNew, data)
+}
+
+func (h *Handler) GetRecipesEdit(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	data["recipe"] = *getRecipe(r)
+
+	h.render(w, http.StatusOK, RecipesEdit, data)
+}
+
+func (h *Handler) PostRecipes(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	data["recipe"] = repo.Recipe{Servings: 1}
+
+	params, err := parseRecipeForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, RecipesNew, err)
+
+		return
+	}
+
+	recipe, err := h.store.CreateRecipe(r.Context(), getCurrentUser(r).ID, params)
+	if err != nil {
+		data["recipe"] = repo.Recipe{Name: params.Name, Servings: params.Servings}
+		h.renderErr(w, r, http.StatusBadRequest, RecipesNew, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/recipes/%d", recipe.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostRecipesUpdate(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	recipe := *getRecipe(r)
+
+	data["recipe"] = recipe
+
+	params, err := parseRecipeForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, RecipesEdit, err)
+
+		return
+	}
+
+	_, err = h.store.UpdateRecipe(r.Context(), recipe.ID, getCurrentUser(r).ID, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		recipe.Name = params.Name
+		recipe.Servings = params.Servings
+		data["recipe"] = recipe
+		h.renderErr(w, r, http.StatusBadRequest, RecipesEdit, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/recipes/%d", recipe.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostRecipesDelete(w http.ResponseWriter, r *http.Request) {
+	recipe := getRecipe(r)
+
+	if err := h.store.DeleteRecipe(r.Context(), recipe.ID, getCurrentUser(r).ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/recipes", http.StatusSeeOther)
+}
+
+// PostRecipeIngredients adds a food to the recipe, or sets its quantity when
+// the recipe already has it.
+func (h *Handler) PostRecipeIngredients(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	recipe := getRecipe(r)
+
+	params, err := parseRecipeIngredientForm(r)
+	if err == nil {
+		_, err = h.store.SaveRecipeIngredient(ctx, recipe.ID, getCurrentUser(r).ID, params)
+	}
+	if err != nil {
+		// The recipe was found by RecipeContext, so a miss here is the food:
+		// someone else's, in the trash, or gone.
+		if errors.Is(err, sql.ErrNoRows) {
+			err = logic.ErrUnknownFood
+		}
+		h.renderRecipeErr(w, r, *recipe, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/recipes/%d", recipe.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostRecipeIngredientDelete(w http.ResponseWriter, r *http.Request) {
+	recipe := getRecipe(r)
+
+	id, err := prog.ParseID(chi.URLParam(r, "ingredientID"), "Recipe Ingredient")
+	if err != nil {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	if err := h.store.RemoveRecipeIngredient(r.Context(), id, recipe.ID, getCurrentUser(r).ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/recipes/%d", recipe.ID), http.StatusSeeOther)
+}
+
+// PostRecipeLog logs a portion of the recipe as a macro entry and shows the
+// day it was logged on.
+func (h *Handler) PostRecipeLog(w http.ResponseWriter, r *http.Request) {
+	recipe := getRecipe(r)
+
+	params, err := parseRecipeLogForm(r)
+	if err == nil {
+		_, err = h.store.LogRecipe(r.Context(), *recipe, params)
+	}
+	if err != nil {
+		h.renderRecipeErr(w, r, *recipe, err)
+
+		return
+	}
+
+	dateStr := time.Unix(params.Date, 0).UTC().Format("2006-01-02")
+	http.Redirect(w, r, fmt.Sprintf("/macros?date=%s", dateStr), http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) setRecipeShowData(ctx context.Context, data map[string]any, recipe repo.Recipe) error {
+	detail, err := h.store.FindRecipeDetail(ctx, recipe)
+	if err != nil {
+		return err
+	}
+
+	foods, err := h.store.FindFoods(ctx, repo.QueryOptions{
+		Filters: repo.Filters{
+			FilterFields: []repo.FilterField{
+				{Name: "user_id", Value: recipe.UserID, Operator: "="},
+			},
+		},
+		Sorting: repo.Sorting{Field: "name", Order: "ASC"},
+	})
+	if err != nil {
+		return err
+	}
+
+	data["recipe"] = detail
+	data["foods"] = foods
+
+	return nil
+}
+
+// renderRecipeErr re-renders the recipe page with a form error. A failure to
+// load the page itself is logged, and the form error still shown.
+func (h *Handler) renderRecipeErr(w http.ResponseWriter, r *http.Request, recipe repo.Recipe, err error) {
+	data := h.tmplData(r)
+	if showErr := h.setRecipeShowData(r.Context(), data, recipe); showErr != nil {
+		h.app.Logger.Errorf("failed to load recipe: %v", showErr)
+	}
+	h.renderErr(w, r, http.StatusBadRequest, RecipesShow, err)
+}
+
+func parseRecipeForm(r *http.Request) (logic.RecipeParams, error) {
+	var params logic.RecipeParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	servings, err := parseFloatField(r, "servings")
+	if err != nil {
+		return params, err
+	}
+
+	params.Name = r.FormValue("name")
+	params.Servings = servings
+
+	return params, nil
+}
+
+func parseRecipeIngredientForm(r *http.Request) (logic.RecipeIngredientParams, error) {
+	var params logic.RecipeIngredientParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	foodID, err := prog.ParseID(r.FormValue("food_id"), "Food")
+	if err != nil {
+		return params, err
+	}
+
+	quantity, err := parseFloatField(r, "quantity_g")
+	if err != nil {
+		return params, err
+	}
+
+	params.FoodID = foodID
+	params.QuantityG = quantity
+
+	return params, nil
+}
+
+func parseRecipeLogForm(r *http.Request) (logic.RecipeLogParams, error) {
+	var params logic.RecipeLogParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	servings, err := parseFloatField(r, "servings")
+	if err != nil {
+		return params, err
+	}
+
+	date, err := prog.StringToUnixDate(r.FormValue("date"))
+	if err != nil {
+		return params, err
+	}
+
+	params.Servings = servings
+	params.Date = date
+	params.MealType = r.FormValue("meal_type")
+
+	return params, nil
+}
+
+func getRecipe(r *http.Request) *repo.Recipe {
+	recipe, ok := r.Context().Value(KeyRecipe).(*repo.Recipe)
+
+	if !ok {
+		panic("failed to get recipe context")
+	}
+
+	return recipe
+}
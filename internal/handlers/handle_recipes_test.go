@@ -0,0 +1,137 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecipes(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_build_a_recipe_and_log_a_portion",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "recipe_h_1", "recipe_h_1@example.com", "recipe_password_1")
+				lentils, err := s.Store.CreateFood(t.Context(), user.ID, logic.FoodParams{
+					Name:     "Lentils",
+					Kcal:     116,
+					ProteinG: 9,
+				})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "recipe_h_1@example.com", "recipe_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/recipes/new", cookies)
+
+				form := url.Values{"name": {"Dal"}, "servings": {"4"}}
+				req := spec.NewPostRequest("/recipes", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				recipes, err := s.Store.FindRecipes(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Len(t, recipes, 1)
+				path := fmt.Sprintf("/recipes/%d", recipes[0].Recipe.ID)
+				require.Equal(t, path, rec.Header().Get("Location"))
+
+				form = url.Values{"food_id": {strconv.Itoa(lentils.ID)}, "quantity_g": {"800"}}
+				req = spec.NewPostRequest(path+"/ingredients", form.Encode(), cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				req = spec.NewGetRequest(path, cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Lentils")
+				require.Contains(t, rec.Body.String(), "<td>232</td>")
+
+				date := time.Date(2026, time.April, 9, 0, 0, 0, 0, time.UTC)
+				form = url.Values{
+					"servings":  {"1"},
+					"date":      {date.Format(time.RFC3339)},
+					"meal_type": {"dinner"},
+				}
+				req = spec.NewPostRequest(path+"/log", form.Encode(), cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/macros?date=2026-04-09", rec.Header().Get("Location"))
+
+				entries, err := s.Store.FindMacroEntries(t.Context(), repo.QueryOptions{
+					Filters: repo.Filters{
+						FilterFields: []repo.FilterField{
+							{Name: "user_id", Value: user.ID, Operator: "="},
+						},
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, entries, 1)
+				require.Equal(t, "Dal", entries[0].Name)
+				require.InDelta(t, 232.0, entries[0].Kcal, 0.001)
+			},
+		},
+		{
+			name: "should_reject_another_users_food_as_an_ingredient",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "recipe_h_2", "recipe_h_2@example.com", "recipe_password_2")
+				other := s.CreateAuthUser(t, "recipe_h_3", "recipe_h_3@example.com", "recipe_password_3")
+				foreign, err := s.Store.CreateFood(t.Context(), other.ID, logic.FoodParams{Name: "Foreign", Kcal: 10})
+				require.NoError(t, err)
+				recipe, err := s.Store.CreateRecipe(t.Context(), user.ID, logic.RecipeParams{Name: "Stew", Servings: 2})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "recipe_h_2@example.com", "recipe_password_2")
+				path := fmt.Sprintf("/recipes/%d", recipe.ID)
+				csrfToken, cookies := s.CSRFFrom(t, path, cookies)
+
+				form := url.Values{"food_id": {strconv.Itoa(foreign.ID)}, "quantity_g": {"100"}}
+				req := spec.NewPostRequest(path+"/ingredients", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), logic.ErrUnknownFood.Error())
+				require.Contains(t, rec.Body.String(), "Stew")
+			},
+		},
+		{
+			name: "should_not_show_another_users_recipe",
+			fn: func(t *testing.T) {
+				owner := s.CreateAuthUser(t, "recipe_h_4", "recipe_h_4@example.com", "recipe_password_4")
+				recipe, err := s.Store.CreateRecipe(t.Context(), owner.ID, logic.RecipeParams{Name: "Secret", Servings: 1})
+				require.NoError(t, err)
+				s.CreateAuthUser(t, "recipe_h_5", "recipe_h_5@example.com", "recipe_password_5")
+				cookies := s.AuthCookies(t, "recipe_h_5@example.com", "recipe_password_5")
+
+				req := spec.NewGetRequest(fmt.Sprintf("/recipes/%d", recipe.ID), cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
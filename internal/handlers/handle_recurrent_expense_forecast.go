@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+)
+
+// dashboardBillsLimit caps the upcoming bills listed on the dashboard.
+const dashboardBillsLimit = 5
+
+type forecastBillRow struct {
+	RecurrentExpenseID int
+	Date               int64
+	Description        string
+	CategoryName       string
+	Amount             uint64
+	Currency           string
+	HomeAmount         uint64
+}
+
+type forecastMonthRow struct {
+	Month      string
+	Total      uint64
+	HasBudget  bool
+	Budget     uint64
+	Pct        int
+	BarPct     int
+	Over       bool
+	OverBudget int
+	Categories []forecastCategoryRow
+}
+
+type forecastCategoryRow struct {
+	CategoryName string
+	Total        uint64
+	HasBudget    bool
+	Budget       uint64
+	Pct          int
+	BarPct       int
+	Over         bool
+}
+
+// dashboardBills is the upcoming bills card: the next few bills within the
+// default horizon and the months in it whose bills alone exceed a budget.
+type dashboardBills struct {
+	Days       int
+	Total      uint64
+	Bills      []forecastBillRow
+	MoreBills  int
+	OverMonths []string
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+// GetRecurrentExpensesForecast projects the active recurrent expenses over
+// ?days=30|90|365 and sets each month's bills against the budgets.
+func (h *Handler) GetRecurrentExpensesForecast(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+	days := forecastDays(r.URL.Query().Get("days"))
+
+	forecast, err := h.store.ForecastRecurrentExpenses(ctx, user.ID, user.HomeCurrency, time.Now(), days)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, RecurrentExpensesForecast, err)
+
+		return
+	}
+
+	_, categoryNameByID, ok := h.findCategoriesOrErr(w, r, RecurrentExpensesForecast)
+	if !ok {
+		return
+	}
+
+	data["days"] = days
+	data["horizons"] = logic.ForecastHorizons()
+	data["total"] = forecast.Total
+	data["bills"] = newForecastBillRows(forecast.Bills, categoryNameByID)
+	data["months"] = newForecastMonthRows(forecast.Months, categoryNameByID)
+
+	h.render(w, http.StatusOK, RecurrentExpensesForecast, data)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+// buildDashboardBills forecasts the default horizon for the dashboard card.
+func (h *Handler) buildDashboardBills(
+	w http.ResponseWriter,
+	r *http.Request,
+	user *logic.User,
+) (dashboardBills, bool) {
+	days := logic.ForecastHorizons()[0]
+
+	forecast, err := h.store.ForecastRecurrentExpenses(r.Context(), user.ID, user.HomeCurrency, time.Now(), days)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, DashboardIndex, err)
+
+		return dashboardBills{}, false
+	}
+
+	_, categoryNameByID, ok := h.findCategoriesOrErr(w, r, DashboardIndex)
+	if !ok {
+		return dashboardBills{}, false
+	}
+
+	bills := newForecastBillRows(forecast.Bills, categoryNameByID)
+	card := dashboardBills{Days: days, Total: forecast.Total, Bills: bills}
+	if len(bills) > dashboardBillsLimit {
+		card.Bills = bills[:dashboardBillsLimit]
+		card.MoreBills = len(bills) - dashboardBillsLimit
+	}
+
+	for _, month := range forecast.Months {
+		if month.OverBudget > 0 {
+			card.OverMonths = append(card.OverMonths, month.Month)
+		}
+	}
+
+	return card, true
+}
+
+// forecastDays reads the horizon, falling back to the shortest one for
+// anything the page does not offer.
+func forecastDays(value string) int {
+	horizons := logic.ForecastHorizons()
+
+	days, err := strconv.Atoi(value)
+	if err != nil || !slices.Contains(horizons, days) {
+		return horizons[0]
+	}
+
+	return days
+}
+
+func newForecastBillRows(bills []logic.ForecastBill, categoryNameByID map[int]string) []forecastBillRow {
+	rows := make([]forecastBillRow, 0, len(bills))
+	for _, b := range bills {
+		rows = append(rows, forecastBillRow{
+			RecurrentExpenseID: b.RecurrentExpenseID,
+			Date:               b.Date.Unix(),
+			Description:        b.Description,
+			CategoryName:       categoryNameOrUnknown(categoryNameByID, b.CategoryID),
+			Amount:             b.Amount,
+			Currency:           b.Currency,
+			HomeAmount:         b.HomeAmount,
+		})
+	}
+
+	return rows
+}
+
+func newForecastMonthRows(months []logic.ForecastMonth, categoryNameByID map[int]string) []forecastMonthRow {
+	rows := make([]forecastMonthRow, 0, len(months))
+	for _, m := range months {
+		row := forecastMonthRow{
+			Month:      m.Month,
+			Total:      m.Total,
+			HasBudget:  m.HasBudget,
+			Budget:     m.Budget,
+			Over:       m.Over,
+			OverBudget: m.OverBudget,
+		}
+		if row.HasBudget {
+			row.Pct, row.BarPct = budgetPercent(m.Total, int64(m.Budget)) //nolint:gosec // cent amount
+		}
+
+		for _, c := range m.Categories {
+			category := forecastCategoryRow{
+				CategoryName: categoryNameOrUnknown(categoryNameByID, c.CategoryID),
+				Total:        c.Total,
+				HasBudget:    c.HasBudget,
+				Budget:       c.Budget,
+				Over:         c.Over,
+			}
+			if category.HasBudget {
+				category.Pct, category.BarPct = budgetPercent(c.Total, int64(c.Budget)) //nolint:gosec // cent amount
+			}
+
+			row.Categories = append(row.Categories, category)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
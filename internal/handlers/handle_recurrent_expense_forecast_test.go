@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRecurrentExpensesForecast(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	newWeeklyParams := func(categoryID int, description string, amount uint64) logic.RecurrentExpenseParams {
+		startsAt := time.Now().UTC().AddDate(0, 0, 1).Unix()
+
+		return logic.RecurrentExpenseParams{
+			ExpenseBaseParams: logic.ExpenseBaseParams{
+				CategoryID:  categoryID,
+				Description: description,
+				Amount:      amount,
+			},
+			Period:    1,
+			Frequency: logic.RecurrenceWeekly,
+			StartsAt:  &startsAt,
+		}
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_redirect_to_login_when_unauthenticated",
+			fn: func(t *testing.T) {
+				req := spec.NewGetRequest("/recurrent-expenses/forecast", nil)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/login", rec.Header().Get("Location"))
+			},
+		},
+		{
+			name: "should_list_upcoming_bills_over_the_chosen_horizon",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "forecast_h_1", "forecast_h_1@example.com", "forecast_password_1")
+				category := s.CreateCategory(t, user.ID, "Forecast Gym")
+				s.CreateRecurrentExpense(t, user.ID, newWeeklyParams(category.ID, "Climbing pass", 1500))
+				cookies := s.AuthCookies(t, "forecast_h_1@example.com", "forecast_password_1")
+
+				req := spec.NewGetRequest("/recurrent-expenses/forecast?days=90", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				body := rec.Body.String()
+				require.Contains(t, body, "Climbing pass")
+				require.Contains(t, body, "Forecast Gym")
+				require.Regexp(t, `<option value="90"\s+selected`, body)
+			},
+		},
+		{
+			name: "should_fall_back_to_the_shortest_horizon",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "forecast_h_2", "forecast_h_2@example.com", "forecast_password_2")
+				cookies := s.AuthCookies(t, "forecast_h_2@example.com", "forecast_password_2")
+
+				req := spec.NewGetRequest("/recurrent-expenses/forecast?days=7", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Regexp(t, `<option value="30"\s+selected`, rec.Body.String())
+				require.Contains(t, rec.Body.String(), "No bills due in the next 30 days.")
+			},
+		},
+		{
+			name: "should_show_upcoming_bills_on_the_dashboard",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "forecast_h_3", "forecast_h_3@example.com", "forecast_password_3")
+				category := s.CreateCategory(t, user.ID, "Forecast Streaming")
+				s.CreateRecurrentExpense(t, user.ID, newWeeklyParams(category.ID, "Music service", 999))
+				cookies := s.AuthCookies(t, "forecast_h_3@example.com", "forecast_password_3")
+
+				req := spec.NewGetRequest("/dashboard", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Upcoming bills")
+				require.Contains(t, rec.Body.String(), "Music service")
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/ad9311/ninete/internal/logic"
 	"github.com/ad9311/ninete/internal/prog"
@@ -14,15 +15,23 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+// generatedExpensesLimit caps the generated expenses listed on the detail page.
+const generatedExpensesLimit = 50
+
 type recurrentExpenseRow struct {
 	ID              int
 	CategoryName    string
 	Description     string
 	Amount          uint64
+	Currency        string
 	Period          uint
+	Schedule        string
+	StartsAt        *int64
+	EndsAt          *int64
 	OccurrenceLimit uint
 	OccurrenceCount uint
 	Archived        bool
+	IsEstimate      bool
 	Tags            []string
 }
 
@@ -159,11 +168,20 @@ func (h *Handler) GetRecurrentExpense(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	generated, generatedCount, err := h.findGeneratedExpenses(r, recurrentExpense.ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, RecurrentExpensesShow, err)
+
+		return
+	}
+
 	data["recurrentExpense"] = newRecurrentExpenseRow(
 		*recurrentExpense,
 		categoryNameOrUnknown(categoryNameByID, recurrentExpense.CategoryID),
 		logic.ExtractTagNames(tags),
 	)
+	data["generatedExpenses"] = generated
+	data["generatedCount"] = generatedCount
 
 	h.render(w, http.StatusOK, RecurrentExpensesShow, data)
 }
@@ -176,7 +194,8 @@ func (h *Handler) GetRecurrentExpensesNew(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	setRecurrentExpenseFormData(data, categories, repo.RecurrentExpense{}, "")
+	setRecurrentExpenseFormData(data, categories, newRecurrentExpenseDraft(), "")
+	h.setPaymentAccountsData(r, data)
 
 	h.render(w, http.StatusOK, RecurrentExpensesNew, data)
 }
@@ -203,6 +222,7 @@ func (h *Handler) GetRecurrentExpensesEdit(w http.ResponseWriter, r *http.Reques
 		*recurrentExpense,
 		logic.JoinTagNames(logic.ExtractTagNames(tags)),
 	)
+	h.setPaymentAccountsData(r, data)
 
 	h.render(w, http.StatusOK, RecurrentExpensesEdit, data)
 }
@@ -213,8 +233,9 @@ func (h *Handler) PostRecurrentExpenses(w http.ResponseWriter, r *http.Request)
 
 	rawTagsInput := r.FormValue("tags")
 
-	categories, _, categoriesErr := h.findCategories(ctx)
-	setRecurrentExpenseFormData(data, categories, repo.RecurrentExpense{}, rawTagsInput)
+	categories, _, categoriesErr := h.findCategories(ctx, getCurrentUser(r).ID)
+	setRecurrentExpenseFormData(data, categories, newRecurrentExpenseDraft(), rawTagsInput)
+	h.setPaymentAccountsData(r, data)
 
 	params, err := parseRecurrentExpenseForm(r)
 	if err != nil {
@@ -233,6 +254,14 @@ func (h *Handler) PostRecurrentExpenses(w http.ResponseWriter, r *http.Request)
 			Amount:          params.Amount,
 			Period:          params.Period,
 			OccurrenceLimit: params.OccurrenceLimit,
+			Currency:        params.Currency,
+			Frequency:       params.Frequency,
+			AnchorDay:       params.AnchorDay,
+			StartsAt:        params.StartsAt,
+			EndsAt:          params.EndsAt,
+			IsEstimate:      params.IsEstimate,
+
+			PaymentAccountID: params.PaymentAccountID,
 		}, logic.JoinTagNames(params.Tags))
 		h.renderErr(w, r, http.StatusBadRequest, RecurrentExpensesNew, err)
 
@@ -254,8 +283,9 @@ func (h *Handler) PostRecurrentExpensesUpdate(w http.ResponseWriter, r *http.Req
 
 	rawTagsInput := r.FormValue("tags")
 
-	categories, _, categoriesErr := h.findCategories(ctx)
+	categories, _, categoriesErr := h.findCategories(ctx, user.ID)
 	setRecurrentExpenseFormData(data, categories, recurrentExpense, rawTagsInput)
+	h.setPaymentAccountsData(r, data)
 
 	params, err := parseRecurrentExpenseForm(r)
 	if err != nil {
@@ -275,8 +305,17 @@ func (h *Handler) PostRecurrentExpensesUpdate(w http.ResponseWriter, r *http.Req
 		recurrentExpense.CategoryID = params.CategoryID
 		recurrentExpense.Description = params.Description
 		recurrentExpense.Amount = params.Amount
+		if params.Currency != "" {
+			recurrentExpense.Currency = params.Currency
+		}
 		recurrentExpense.Period = params.Period
 		recurrentExpense.OccurrenceLimit = params.OccurrenceLimit
+		recurrentExpense.Frequency = params.Frequency
+		recurrentExpense.AnchorDay = params.AnchorDay
+		recurrentExpense.StartsAt = params.StartsAt
+		recurrentExpense.EndsAt = params.EndsAt
+		recurrentExpense.IsEstimate = params.IsEstimate
+		recurrentExpense.PaymentAccountID = params.PaymentAccountID
 		setRecurrentExpenseFormData(data, categories, recurrentExpense, logic.JoinTagNames(params.Tags))
 		h.renderErr(w, r, http.StatusBadRequest, RecurrentExpensesEdit, err)
 
@@ -344,27 +383,79 @@ func parseRecurrentExpenseForm(r *http.Request) (logic.RecurrentExpenseParams, e
 		return params, err
 	}
 
-	period, err := prog.ParseID(r.FormValue("period"), "Period")
+	rule, err := parseRecurrenceForm(r)
 	if err != nil {
 		return params, err
 	}
+
+	params.CategoryID = base.CategoryID
+	params.Description = base.Description
+	params.Amount = base.Amount
+	params.Currency = base.Currency
+	params.PaymentAccountID = base.PaymentAccountID
+	params.Period = rule.Period
+	params.Frequency = rule.Frequency
+	params.AnchorDay = rule.AnchorDay
+	params.StartsAt = rule.StartsAt
+	params.EndsAt = rule.EndsAt
+	params.OccurrenceLimit = rule.OccurrenceLimit
+	params.IsEstimate = r.FormValue("is_estimate") == "on"
+	params.Tags = logic.ParseTagNames(r.FormValue("tags"))
+
+	return params, nil
+}
+
+// recurrenceForm holds the schedule inputs recurrent expense and recurrent
+// income forms share.
+type recurrenceForm struct {
+	Period          uint
+	Frequency       string
+	AnchorDay       uint
+	StartsAt        *int64
+	EndsAt          *int64
+	OccurrenceLimit uint
+}
+
+// parseRecurrenceForm expects the form to be parsed already.
+func parseRecurrenceForm(r *http.Request) (recurrenceForm, error) {
+	var rule recurrenceForm
+
+	period, err := prog.ParseID(r.FormValue("period"), "Period")
+	if err != nil {
+		return rule, err
+	}
 	if period < 1 {
-		return params, fmt.Errorf("%w of Period \"%v\", period cannot be lower than 1", prog.ErrParsing, period)
+		return rule, fmt.Errorf("%w of Period \"%v\", period cannot be lower than 1", prog.ErrParsing, period)
 	}
 
 	occurrenceLimit, err := parseOccurrenceLimit(r.FormValue("occurrence_limit"))
 	if err != nil {
-		return params, err
+		return rule, err
 	}
 
-	params.CategoryID = base.CategoryID
-	params.Description = base.Description
-	params.Amount = base.Amount
-	params.Period = uint(period)
-	params.OccurrenceLimit = occurrenceLimit
-	params.Tags = logic.ParseTagNames(r.FormValue("tags"))
+	anchorDay, err := parseAnchorDay(r.FormValue("anchor_day"))
+	if err != nil {
+		return rule, err
+	}
 
-	return params, nil
+	startsAt, err := parseOptionalDate(r.FormValue("starts_on"), "Start date")
+	if err != nil {
+		return rule, err
+	}
+
+	endsAt, err := parseOptionalDate(r.FormValue("ends_on"), "End date")
+	if err != nil {
+		return rule, err
+	}
+
+	rule.Period = uint(period)
+	rule.Frequency = r.FormValue("frequency")
+	rule.AnchorDay = anchorDay
+	rule.StartsAt = startsAt
+	rule.EndsAt = endsAt
+	rule.OccurrenceLimit = occurrenceLimit
+
+	return rule, nil
 }
 
 // parseOccurrenceLimit accepts an empty field as "unlimited" so an existing form
@@ -390,6 +481,71 @@ func parseOccurrenceLimit(value string) (uint, error) {
 	return uint(limit), nil
 }
 
+// parseAnchorDay accepts an empty field as "follow the start date".
+func parseAnchorDay(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	day, err := strconv.Atoi(value)
+	if err != nil || day < 1 || day > 31 {
+		return 0, fmt.Errorf("%w of Day of month \"%v\", it must be between 1 and 31", prog.ErrParsing, value)
+	}
+
+	return uint(day), nil
+}
+
+// parseOptionalDate reads a YYYY-MM-DD date input as a UTC midnight. An empty
+// field means no date.
+func parseOptionalDate(value, fieldName string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w of %s \"%v\"", prog.ErrParsing, fieldName, value)
+	}
+
+	unix := date.Unix()
+
+	return &unix, nil
+}
+
+// newRecurrentExpenseDraft is the blank new form: a copy every month.
+// findGeneratedExpenses returns the latest expenses the recurrent expense
+// generated, newest first, and how many it generated in all. Expenses in the
+// trash are left out of both.
+func (h *Handler) findGeneratedExpenses(r *http.Request, recurrentExpenseID int) ([]repo.Expense, int, error) {
+	filters := repo.Filters{
+		FilterFields: []repo.FilterField{
+			{Name: "user_id", Value: getCurrentUser(r).ID, Operator: "="},
+			{Name: "recurrent_expense_id", Value: recurrentExpenseID, Operator: "="},
+		},
+		Connector: "AND",
+	}
+
+	expenses, err := h.store.FindExpenses(r.Context(), repo.QueryOptions{
+		Filters:    filters,
+		Sorting:    repo.Sorting{Field: "date", Order: "DESC"},
+		Pagination: repo.Pagination{Page: 1, PerPage: generatedExpensesLimit},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, err := h.store.CountExpenses(r.Context(), filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return expenses, count, nil
+}
+
+func newRecurrentExpenseDraft() repo.RecurrentExpense {
+	return repo.RecurrentExpense{Period: 1, Frequency: logic.RecurrenceMonthly}
+}
+
 func newRecurrentExpenseRow(
 	recurrentExpense repo.RecurrentExpense,
 	categoryName string,
@@ -400,10 +556,15 @@ func newRecurrentExpenseRow(
 		CategoryName:    categoryName,
 		Description:     recurrentExpense.Description,
 		Amount:          recurrentExpense.Amount,
+		Currency:        recurrentExpense.Currency,
 		Period:          recurrentExpense.Period,
+		Schedule:        logic.DescribeRecurrence(logic.RecurrentExpenseRecurrence(recurrentExpense)),
+		StartsAt:        recurrentExpense.StartsAt,
+		EndsAt:          recurrentExpense.EndsAt,
 		OccurrenceLimit: recurrentExpense.OccurrenceLimit,
 		OccurrenceCount: recurrentExpense.OccurrenceCount,
 		Archived:        recurrentExpense.ArchivedAt != nil,
+		IsEstimate:      recurrentExpense.IsEstimate,
 		Tags:            tags,
 	}
 }
@@ -415,7 +576,9 @@ func setRecurrentExpenseFormData(
 	tagsInput string,
 ) {
 	setResourceFormData(data, categories, "recurrentExpense", recurrentExpense)
+	setPaymentAccountIDData(data, recurrentExpense.PaymentAccountID)
 	data["tagsInput"] = tagsInput
+	data["frequencies"] = logic.RecurrenceFrequencies()
 }
 
 func getRecurrentExpense(r *http.Request) *repo.RecurrentExpense {
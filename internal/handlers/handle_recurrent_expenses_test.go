@@ -50,7 +50,7 @@ func TestGetRecurrentExpenses(t *testing.T) {
 			name: "should_display_recurrent_expense_description_in_body",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_list_2", "rexp_list_2@example.com", "rexp_password_2")
-				category := s.CreateCategory(t, "rexp_list_cat_1")
+				category := s.CreateCategory(t, user.ID, "rexp_list_cat_1")
 				s.CreateRecurrentExpense(t, user.ID,
 					newRecurrentExpenseParams(category.ID, "Visible recurrent item", 750, 30),
 				)
@@ -121,8 +121,8 @@ func TestPostRecurrentExpenses(t *testing.T) {
 		{
 			name: "should_redirect_to_recurrent_expenses_with_valid_form",
 			fn: func(t *testing.T) {
-				s.CreateAuthUser(t, "rexp_post_1", "rexp_post_1@example.com", "rexp_password_1")
-				category := s.CreateCategory(t, "rexp_post_cat_1")
+				user := s.CreateAuthUser(t, "rexp_post_1", "rexp_post_1@example.com", "rexp_password_1")
+				category := s.CreateCategory(t, user.ID, "rexp_post_cat_1")
 				cookies := s.AuthCookies(t, "rexp_post_1@example.com", "rexp_password_1")
 				csrfToken, cookies := s.CSRFFrom(t, "/recurrent-expenses/new", cookies)
 
@@ -150,11 +150,61 @@ func TestPostRecurrentExpenses(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, rec.Code)
 			},
 		},
+		{
+			name: "should_store_the_recurrence_rule_from_the_form",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "rexp_post_rule", "rexp_post_rule@example.com", "rexp_password_rule")
+				category := s.CreateCategory(t, user.ID, "rexp_post_cat_rule")
+				cookies := s.AuthCookies(t, "rexp_post_rule@example.com", "rexp_password_rule")
+				csrfToken, cookies := s.CSRFFrom(t, "/recurrent-expenses/new", cookies)
+
+				form := recurrentExpenseFormValues(category.ID, "Weekly gym fee", "1500", "2", "")
+				form.Set("frequency", "weekly")
+				form.Set("starts_on", "2026-03-02")
+				form.Set("ends_on", "2026-06-01")
+				req := spec.NewPostRequest("/recurrent-expenses", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				created := findRecurrentExpenseByDescription(t, s, user.ID, "Weekly gym fee")
+				require.Equal(t, "weekly", created.Frequency)
+				require.Equal(t, uint(2), created.Period)
+				require.NotNil(t, created.StartsAt)
+				require.Equal(t, time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC).Unix(), *created.StartsAt)
+				require.NotNil(t, created.EndsAt)
+
+				req = spec.NewGetRequest(fmt.Sprintf("/recurrent-expenses/%d", created.ID), cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "Every 2 weeks")
+			},
+		},
+		{
+			name: "should_reject_an_end_date_before_the_start_date",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "rexp_post_ends", "rexp_post_ends@example.com", "rexp_password_ends")
+				category := s.CreateCategory(t, user.ID, "rexp_post_cat_ends")
+				cookies := s.AuthCookies(t, "rexp_post_ends@example.com", "rexp_password_ends")
+				csrfToken, cookies := s.CSRFFrom(t, "/recurrent-expenses/new", cookies)
+
+				form := recurrentExpenseFormValues(category.ID, "Backwards bill", "1500", "1", "")
+				form.Set("starts_on", "2026-06-01")
+				form.Set("ends_on", "2026-03-01")
+				req := spec.NewPostRequest("/recurrent-expenses", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "the end date cannot be before the start date")
+			},
+		},
 		{
 			name: "should_attach_tags_from_the_form",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_post_3", "rexp_post_3@example.com", "rexp_password_3")
-				category := s.CreateCategory(t, "rexp_post_cat_3")
+				category := s.CreateCategory(t, user.ID, "rexp_post_cat_3")
 				cookies := s.AuthCookies(t, "rexp_post_3@example.com", "rexp_password_3")
 				csrfToken, cookies := s.CSRFFrom(t, "/recurrent-expenses/new", cookies)
 
@@ -214,7 +264,7 @@ func TestGetRecurrentExpense(t *testing.T) {
 			name: "should_render_recurrent_expense_show_page",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_show_1", "rexp_show_1@example.com", "rexp_password_1")
-				category := s.CreateCategory(t, "rexp_show_cat_1")
+				category := s.CreateCategory(t, user.ID, "rexp_show_cat_1")
 				rexp := s.CreateRecurrentExpense(t, user.ID,
 					newRecurrentExpenseParams(category.ID, "Show recurrent detail", 900, 7),
 				)
@@ -228,6 +278,42 @@ func TestGetRecurrentExpense(t *testing.T) {
 				require.Contains(t, rec.Body.String(), "Show recurrent detail")
 			},
 		},
+		{
+			name: "should_list_the_expenses_it_generated",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "rexp_show_3", "rexp_show_3@example.com", "rexp_password_3")
+				category := s.CreateCategory(t, user.ID, "rexp_show_cat_3")
+				rexp := s.CreateRecurrentExpense(t, user.ID,
+					newRecurrentExpenseParams(category.ID, "Generated streaming", 1500, 1),
+				)
+				cookies := s.AuthCookies(t, "rexp_show_3@example.com", "rexp_password_3")
+
+				req := spec.NewGetRequest(fmt.Sprintf("/recurrent-expenses/%d", rexp.ID), cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Contains(t, rec.Body.String(), "No expenses generated yet.")
+
+				_, err := s.Store.CopyDueRecurrentExpenses(t.Context(), time.Now())
+				require.NoError(t, err)
+				expenses, err := s.Store.FindExpenses(t.Context(), repo.QueryOptions{
+					Filters: repo.Filters{
+						FilterFields: []repo.FilterField{
+							{Name: "recurrent_expense_id", Value: rexp.ID, Operator: "="},
+						},
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, expenses, 1)
+
+				req = spec.NewGetRequest(fmt.Sprintf("/recurrent-expenses/%d", rexp.ID), cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.NotContains(t, rec.Body.String(), "No expenses generated yet.")
+				require.Contains(t, rec.Body.String(), fmt.Sprintf("/expenses/%d", expenses[0].ID))
+			},
+		},
 		{
 			name: "should_return_not_found_for_nonexistent_recurrent_expense",
 			fn: func(t *testing.T) {
@@ -260,7 +346,7 @@ func TestGetRecurrentExpensesEdit(t *testing.T) {
 			name: "should_render_edit_page_for_existing_recurrent_expense",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_edit_1", "rexp_edit_1@example.com", "rexp_password_1")
-				category := s.CreateCategory(t, "rexp_edit_cat_1")
+				category := s.CreateCategory(t, user.ID, "rexp_edit_cat_1")
 				rexp := s.CreateRecurrentExpense(t, user.ID,
 					newRecurrentExpenseParams(category.ID, "Edit this recurrent", 400, 14),
 				)
@@ -290,7 +376,7 @@ func TestGetRecurrentExpensesEdit(t *testing.T) {
 			name: "should_prefill_the_tags_input_with_the_current_tags",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_edit_3", "rexp_edit_3@example.com", "rexp_password_3")
-				category := s.CreateCategory(t, "rexp_edit_cat_3")
+				category := s.CreateCategory(t, user.ID, "rexp_edit_cat_3")
 				params := newRecurrentExpenseParams(category.ID, "Prefilled recurrent tags", 400, 14)
 				params.Tags = []string{"prefill_tag"}
 				rexp := s.CreateRecurrentExpense(t, user.ID, params)
@@ -323,7 +409,7 @@ func TestPostRecurrentExpensesUpdate(t *testing.T) {
 			name: "should_redirect_to_recurrent_expenses_after_valid_update",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_update_1", "rexp_update_1@example.com", "rexp_password_1")
-				category := s.CreateCategory(t, "rexp_update_cat_1")
+				category := s.CreateCategory(t, user.ID, "rexp_update_cat_1")
 				rexp := s.CreateRecurrentExpense(t, user.ID,
 					newRecurrentExpenseParams(category.ID, "Before recurrent update", 600, 7),
 				)
@@ -358,7 +444,7 @@ func TestPostRecurrentExpensesUpdate(t *testing.T) {
 			name: "should_replace_tags_from_the_form",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_update_3", "rexp_update_3@example.com", "rexp_password_3")
-				category := s.CreateCategory(t, "rexp_update_cat_3")
+				category := s.CreateCategory(t, user.ID, "rexp_update_cat_3")
 				params := newRecurrentExpenseParams(category.ID, "Retagged recurrent expense", 600, 7)
 				params.Tags = []string{"old_tag"}
 				rexp := s.CreateRecurrentExpense(t, user.ID, params)
@@ -404,7 +490,7 @@ func TestPostRecurrentExpensesDelete(t *testing.T) {
 			name: "should_redirect_to_recurrent_expenses_after_valid_delete",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_delete_1", "rexp_delete_1@example.com", "rexp_password_1")
-				category := s.CreateCategory(t, "rexp_delete_cat_1")
+				category := s.CreateCategory(t, user.ID, "rexp_delete_cat_1")
 				rexp := s.CreateRecurrentExpense(t, user.ID,
 					newRecurrentExpenseParams(category.ID, "Delete recurrent", 200, 30),
 				)
@@ -527,7 +613,7 @@ func TestRecurrentExpenseArchiving(t *testing.T) {
 			name: "should_store_the_occurrence_limit_from_the_form",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_arch_1", "rexp_arch_1@example.com", "rexp_password_1")
-				category := s.CreateCategory(t, "rexp_arch_cat_1")
+				category := s.CreateCategory(t, user.ID, "rexp_arch_cat_1")
 				cookies := s.AuthCookies(t, "rexp_arch_1@example.com", "rexp_password_1")
 				csrfToken, cookies := s.CSRFFrom(t, "/recurrent-expenses/new", cookies)
 
@@ -547,7 +633,7 @@ func TestRecurrentExpenseArchiving(t *testing.T) {
 			name: "should_keep_archived_rows_out_of_the_main_list",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_arch_2", "rexp_arch_2@example.com", "rexp_password_2")
-				category := s.CreateCategory(t, "rexp_arch_cat_2")
+				category := s.CreateCategory(t, user.ID, "rexp_arch_cat_2")
 				archive(t, user.ID, category.ID, "Archived recurrent item 2")
 				cookies := s.AuthCookies(t, "rexp_arch_2@example.com", "rexp_password_2")
 
@@ -563,7 +649,7 @@ func TestRecurrentExpenseArchiving(t *testing.T) {
 			name: "should_list_archived_rows_on_the_archived_page",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_arch_3", "rexp_arch_3@example.com", "rexp_password_3")
-				category := s.CreateCategory(t, "rexp_arch_cat_3")
+				category := s.CreateCategory(t, user.ID, "rexp_arch_cat_3")
 				archive(t, user.ID, category.ID, "Archived recurrent item 3")
 				s.CreateRecurrentExpense(
 					t,
@@ -585,7 +671,7 @@ func TestRecurrentExpenseArchiving(t *testing.T) {
 			name: "should_unarchive_and_redirect_to_the_recurrent_expense",
 			fn: func(t *testing.T) {
 				user := s.CreateAuthUser(t, "rexp_arch_4", "rexp_arch_4@example.com", "rexp_password_4")
-				category := s.CreateCategory(t, "rexp_arch_cat_4")
+				category := s.CreateCategory(t, user.ID, "rexp_arch_cat_4")
 				archived := archive(t, user.ID, category.ID, "Archived recurrent item 4")
 				cookies := s.AuthCookies(t, "rexp_arch_4@example.com", "rexp_password_4")
 				csrfToken, cookies := s.CSRFFrom(t, "/recurrent-expenses/new", cookies)
@@ -613,7 +699,7 @@ func TestRecurrentExpenseArchiving(t *testing.T) {
 			fn: func(t *testing.T) {
 				owner := s.CreateAuthUser(t, "rexp_arch_5", "rexp_arch_5@example.com", "rexp_password_5")
 				s.CreateAuthUser(t, "rexp_arch_6", "rexp_arch_6@example.com", "rexp_password_6")
-				category := s.CreateCategory(t, "rexp_arch_cat_5")
+				category := s.CreateCategory(t, owner.ID, "rexp_arch_cat_5")
 				archived := archive(t, owner.ID, category.ID, "Archived recurrent item 5")
 
 				cookies := s.AuthCookies(t, "rexp_arch_6@example.com", "rexp_password_6")
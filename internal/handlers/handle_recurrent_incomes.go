@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// generatedIncomesLimit caps the generated incomes listed on the detail page.
+const generatedIncomesLimit = 50
+
+type recurrentIncomeRow struct {
+	ID              int
+	Source          string
+	Amount          uint64
+	Currency        string
+	Schedule        string
+	StartsAt        *int64
+	EndsAt          *int64
+	OccurrenceLimit uint
+	OccurrenceCount uint
+	Archived        bool
+	Tags            []string
+}
+
+// ----------------------------------------------------------------------------- //
+// Context Middleware
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) RecurrentIncomeContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user := getCurrentUser(r)
+		recurrentIncomeID := chi.URLParam(r, "id")
+
+		id, err := prog.ParseID(recurrentIncomeID, "Recurrent income")
+		if err != nil {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		recurrentIncome, err := h.store.FindRecurrentIncome(ctx, id, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		if err != nil {
+			h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+			return
+		}
+
+		ctx = context.WithValue(ctx, KeyRecurrentIncome, &recurrentIncome)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+// GetRecurrentIncomes lists active and archived rules together; there are few
+// enough of them that a separate archive page would only get in the way.
+func (h *Handler) GetRecurrentIncomes(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+
+	opts := userScopedQueryOpts(r, user.ID, repo.Sorting{Field: "created_at", Order: "DESC"}, "")
+
+	totalCount, err := h.store.CountRecurrentIncomes(r.Context(), opts.Filters)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, RecurrentIncomesIndex, err)
+
+		return
+	}
+
+	recurrentIncomes, err := h.store.FindRecurrentIncomes(r.Context(), opts)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, RecurrentIncomesIndex, err)
+
+		return
+	}
+
+	recurrentIncomeIDs := make([]int, 0, len(recurrentIncomes))
+	for _, recurrentIncome := range recurrentIncomes {
+		recurrentIncomeIDs = append(recurrentIncomeIDs, recurrentIncome.ID)
+	}
+
+	tagRows, err := h.store.FindTagRows(
+		r.Context(),
+		repo.TaggableTypeRecurrentIncome,
+		"recurrent_incomes",
+		recurrentIncomeIDs,
+		user.ID,
+	)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, RecurrentIncomesIndex, err)
+
+		return
+	}
+	tagNames := repo.TagNamesByTargetID(tagRows)
+
+	rows := make([]recurrentIncomeRow, 0, len(recurrentIncomes))
+	for _, recurrentIncome := range recurrentIncomes {
+		rows = append(rows, newRecurrentIncomeRow(recurrentIncome, tagNames[recurrentIncome.ID]))
+	}
+
+	data["recurrentIncomes"] = rows
+	data["pagination"] = newPaginationData(r, opts, totalCount, "")
+	data["basePath"] = "/recurrent-incomes"
+
+	h.render(w, http.StatusOK, RecurrentIncomesIndex, data)
+}
+
+func (h *Handler) GetRecurrentIncome(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	recurrentIncome := getRecurrentIncome(r)
+
+	tags, err := h.store.FindRecurrentIncomeTags(r.Context(), recurrentIncome.ID, getCurrentUser(r).ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, RecurrentIncomesShow, err)
+
+		return
+	}
+
+	generated, generatedCount, err := h.findGeneratedIncomes(r, recurrentIncome.ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, RecurrentIncomesShow, err)
+
+		return
+	}
+
+	data["recurrentIncome"] = newRecurrentIncomeRow(*recurrentIncome, logic.ExtractTagNames(tags))
+	data["generatedIncomes"] = generated
+	data["generatedCount"] = generatedCount
+
+	h.render(w, http.StatusOK, RecurrentIncomesShow, data)
+}
+
+func (h *Handler) GetRecurrentIncomesNew(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	setRecurrentIncomeFormData(data, newRecurrentIncomeDraft(), "")
+
+	h.render(w, http.StatusOK, RecurrentIncomesNew, data)
+}
+
+func (h *Handler) GetRecurrentIncomesEdit(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	recurrentIncome := getRecurrentIncome(r)
+
+	tags, err := h.store.FindRecurrentIncomeTags(r.Context(), recurrentIncome.ID, getCurrentUser(r).ID)
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, RecurrentIncomesEdit, err)
+
+		return
+	}
+
+	setRecurrentIncomeFormData(data, *recurrentIncome, logic.JoinTagNames(logic.ExtractTagNames(tags)))
+
+	h.render(w, http.StatusOK, RecurrentIncomesEdit, data)
+}
+
+func (h *Handler) PostRecurrentIncomes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+
+	setRecurrentIncomeFormData(data, newRecurrentIncomeDraft(), r.FormValue("tags"))
+
+	params, err := parseRecurrentIncomeForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, RecurrentIncomesNew, err)
+
+		return
+	}
+
+	user := getCurrentUser(r)
+
+	_, err = h.store.CreateRecurrentIncome(ctx, user.ID, params)
+	if err != nil {
+		setRecurrentIncomeFormData(data, repo.RecurrentIncome{
+			Source:          params.Source,
+			Amount:          params.Amount,
+			Currency:        params.Currency,
+			Period:          params.Period,
+			Frequency:       params.Frequency,
+			AnchorDay:       params.AnchorDay,
+			StartsAt:        params.StartsAt,
+			EndsAt:          params.EndsAt,
+			OccurrenceLimit: params.OccurrenceLimit,
+		}, logic.JoinTagNames(params.Tags))
+		h.renderErr(w, r, http.StatusBadRequest, RecurrentIncomesNew, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/recurrent-incomes", http.StatusSeeOther)
+}
+
+func (h *Handler) PostRecurrentIncomesUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+	recurrentIncome := *getRecurrentIncome(r)
+
+	setRecurrentIncomeFormData(data, recurrentIncome, r.FormValue("tags"))
+
+	params, err := parseRecurrentIncomeForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, RecurrentIncomesEdit, err)
+
+		return
+	}
+
+	_, err = h.store.UpdateRecurrentIncome(ctx, recurrentIncome.ID, user.ID, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		recurrentIncome.Source = params.Source
+		recurrentIncome.Amount = params.Amount
+		if params.Currency != "" {
+			recurrentIncome.Currency = params.Currency
+		}
+		recurrentIncome.Period = params.Period
+		recurrentIncome.Frequency = params.Frequency
+		recurrentIncome.AnchorDay = params.AnchorDay
+		recurrentIncome.StartsAt = params.StartsAt
+		recurrentIncome.EndsAt = params.EndsAt
+		recurrentIncome.OccurrenceLimit = params.OccurrenceLimit
+		setRecurrentIncomeFormData(data, recurrentIncome, logic.JoinTagNames(params.Tags))
+		h.renderErr(w, r, http.StatusBadRequest, RecurrentIncomesEdit, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/recurrent-incomes", http.StatusSeeOther)
+}
+
+func (h *Handler) PostRecurrentIncomesDelete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+	recurrentIncome := getRecurrentIncome(r)
+
+	if err := h.store.DeleteRecurrentIncome(ctx, recurrentIncome.ID, user.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/recurrent-incomes", http.StatusSeeOther)
+}
+
+func (h *Handler) PostRecurrentIncomesUnarchive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+	recurrentIncome := getRecurrentIncome(r)
+
+	_, err := h.store.UnarchiveRecurrentIncome(ctx, recurrentIncome.ID, user.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/recurrent-incomes/%d", recurrentIncome.ID), http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+func parseRecurrentIncomeForm(r *http.Request) (logic.RecurrentIncomeParams, error) {
+	var params logic.RecurrentIncomeParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	amount, err := prog.ParseAmount(r.FormValue("amount"))
+	if err != nil {
+		return params, err
+	}
+
+	rule, err := parseRecurrenceForm(r)
+	if err != nil {
+		return params, err
+	}
+
+	params.Source = r.FormValue("source")
+	params.Amount = amount
+	params.Currency = r.FormValue("currency")
+	params.Period = rule.Period
+	params.Frequency = rule.Frequency
+	params.AnchorDay = rule.AnchorDay
+	params.StartsAt = rule.StartsAt
+	params.EndsAt = rule.EndsAt
+	params.OccurrenceLimit = rule.OccurrenceLimit
+	params.Tags = logic.ParseTagNames(r.FormValue("tags"))
+
+	return params, nil
+}
+
+// findGeneratedIncomes returns the latest incomes the recurrent income
+// generated, newest first, and how many it generated in all.
+func (h *Handler) findGeneratedIncomes(r *http.Request, recurrentIncomeID int) ([]repo.Income, int, error) {
+	filters := repo.Filters{
+		FilterFields: []repo.FilterField{
+			{Name: "user_id", Value: getCurrentUser(r).ID, Operator: "="},
+			{Name: "recurrent_income_id", Value: recurrentIncomeID, Operator: "="},
+		},
+		Connector: "AND",
+	}
+
+	incomes, err := h.store.FindIncomes(r.Context(), repo.QueryOptions{
+		Filters:    filters,
+		Sorting:    repo.Sorting{Field: "date", Order: "DESC"},
+		Pagination: repo.Pagination{Page: 1, PerPage: generatedIncomesLimit},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, err := h.store.CountIncomes(r.Context(), filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return incomes, count, nil
+}
+
+// newRecurrentIncomeDraft is the blank new form: an income every month.
+func newRecurrentIncomeDraft() repo.RecurrentIncome {
+	return repo.RecurrentIncome{Period: 1, Frequency: logic.RecurrenceMonthly}
+}
+
+func newRecurrentIncomeRow(recurrentIncome repo.RecurrentIncome, tags []string) recurrentIncomeRow {
+	return recurrentIncomeRow{
+		ID:              recurrentIncome.ID,
+		Source:          recurrentIncome.Source,
+		Amount:          recurrentIncome.Amount,
+		Currency:        recurrentIncome.Currency,
+		Schedule:        logic.DescribeRecurrence(logic.RecurrentIncomeRecurrence(recurrentIncome)),
+		StartsAt:        recurrentIncome.StartsAt,
+		EndsAt:          recurrentIncome.EndsAt,
+		OccurrenceLimit: recurrentIncome.OccurrenceLimit,
+		OccurrenceCount: recurrentIncome.OccurrenceCount,
+		Archived:        recurrentIncome.ArchivedAt != nil,
+		Tags:            tags,
+	}
+}
+
+func setRecurrentIncomeFormData(data map[string]any, recurrentIncome repo.RecurrentIncome, tagsInput string) {
+	data["recurrentIncome"] = recurrentIncome
+	data["tagsInput"] = tagsInput
+	data["frequencies"] = logic.RecurrenceFrequencies()
+}
+
+func getRecurrentIncome(r *http.Request) *repo.RecurrentIncome {
+	recurrentIncome, ok := r.Context().Value(KeyRecurrentIncome).(*repo.RecurrentIncome)
+
+	if !ok {
+		panic("failed to get recurrent income context")
+	}
+
+	return recurrentIncome
+}
@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// dashboardSavingsGoalLimit is how many open goals the dashboard card lists.
+const dashboardSavingsGoalLimit = 3
+
+type savingsGoalRow struct {
+	logic.SavingsGoalProgress
+	Pct    int
+	BarPct int
+}
+
+// ----------------------------------------------------------------------------- //
+// Context Middleware
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) SavingsGoalContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user := getCurrentUser(r)
+
+		id, err := prog.ParseID(chi.URLParam(r, "id"), "Savings Goal")
+		if err != nil {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		goal, err := h.store.FindSavingsGoal(ctx, id, user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		if err != nil {
+			h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+			return
+		}
+
+		ctx = context.WithValue(ctx, KeySavingsGoal, &goal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) GetSavingsGoals(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	goals, err := h.store.FindSavingsGoals(r.Context(), getCurrentUser(r).ID, time.Now())
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, SavingsGoalsIndex, err)
+
+		return
+	}
+
+	rows := make([]savingsGoalRow, 0, len(goals))
+	for _, goal := range goals {
+		rows = append(rows, newSavingsGoalRow(goal))
+	}
+
+	data["savingsGoals"] = rows
+
+	h.render(w, http.StatusOK, SavingsGoalsIndex, data)
+}
+
+// GetSavingsGoal shows the goal's progress, its contributions oldest first
+// and the form to add another.
+func (h *Handler) GetSavingsGoal(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	if err := h.setSavingsGoalShowData(r.Context(), data, *getSavingsGoal(r)); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, SavingsGoalsShow, err)
+
+		return
+	}
+
+	h.render(w, http.StatusOK, SavingsGoalsShow, data)
+}
+
+func (h *Handler) GetSavingsGoalsNew(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	data["savingsGoal"] = repo.SavingsGoal{}
+
+	h.render(w, http.StatusOK, SavingsGoalsNew, data)
+}
+
+func (h *Handler) GetSavingsGoalsEdit(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	data["savingsGoal"] = *getSavingsGoal(r)
+
+	h.render(w, http.StatusOK, SavingsGoalsEdit, data)
+}
+
+func (h *Handler) PostSavingsGoals(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+
+	data["savingsGoal"] = repo.SavingsGoal{}
+
+	params, err := parseSavingsGoalForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, SavingsGoalsNew, err)
+
+		return
+	}
+
+	goal, err := h.store.CreateSavingsGoal(r.Context(), getCurrentUser(r).ID, params)
+	if err != nil {
+		data["savingsGoal"] = repo.SavingsGoal{
+			Name:         params.Name,
+			TargetAmount: params.TargetAmount,
+			TargetDate:   params.TargetDate,
+		}
+		h.renderErr(w, r, http.StatusBadRequest, SavingsGoalsNew, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/savings-goals/%d", goal.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostSavingsGoalsUpdate(w http.ResponseWriter, r *http.Request) {
+	data := h.tmplData(r)
+	goal := *getSavingsGoal(r)
+
+	data["savingsGoal"] = goal
+
+	params, err := parseSavingsGoalForm(r)
+	if err != nil {
+		h.renderErr(w, r, http.StatusBadRequest, SavingsGoalsEdit, err)
+
+		return
+	}
+
+	_, err = h.store.UpdateSavingsGoal(r.Context(), goal.ID, getCurrentUser(r).ID, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		goal.Name = params.Name
+		goal.TargetAmount = params.TargetAmount
+		goal.TargetDate = params.TargetDate
+		data["savingsGoal"] = goal
+		h.renderErr(w, r, http.StatusBadRequest, SavingsGoalsEdit, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/savings-goals/%d", goal.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostSavingsGoalsDelete(w http.ResponseWriter, r *http.Request) {
+	goal := getSavingsGoal(r)
+
+	if err := h.store.DeleteSavingsGoal(r.Context(), goal.ID, getCurrentUser(r).ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/savings-goals", http.StatusSeeOther)
+}
+
+func (h *Handler) PostSavingsGoalContributions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	goal := getSavingsGoal(r)
+
+	params, err := parseSavingsContributionForm(r)
+	if err == nil {
+		_, err = h.store.AddSavingsContribution(ctx, goal.ID, getCurrentUser(r).ID, params)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+
+		data := h.tmplData(r)
+		if showErr := h.setSavingsGoalShowData(ctx, data, *goal); showErr != nil {
+			h.app.Logger.Errorf("failed to load savings goal progress: %v", showErr)
+		}
+		data["contribution"] = params
+		h.renderErr(w, r, http.StatusBadRequest, SavingsGoalsShow, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/savings-goals/%d", goal.ID), http.StatusSeeOther)
+}
+
+func (h *Handler) PostSavingsGoalContributionDelete(w http.ResponseWriter, r *http.Request) {
+	goal := getSavingsGoal(r)
+
+	id, err := prog.ParseID(chi.URLParam(r, "contributionID"), "Savings Contribution")
+	if err != nil {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	if err := h.store.DeleteSavingsContribution(r.Context(), id, goal.ID, getCurrentUser(r).ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/savings-goals/%d", goal.ID), http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) setSavingsGoalShowData(ctx context.Context, data map[string]any, goal repo.SavingsGoal) error {
+	progress, err := h.store.FindSavingsGoalProgress(ctx, goal, time.Now())
+	if err != nil {
+		return err
+	}
+
+	data["savingsGoal"] = newSavingsGoalRow(progress)
+	data["contribution"] = logic.SavingsContributionParams{}
+
+	return nil
+}
+
+// buildDashboardSavingsGoals returns the open goals with the nearest
+// deadlines. Completed goals have nothing left to prompt for.
+func (h *Handler) buildDashboardSavingsGoals(
+	w http.ResponseWriter,
+	r *http.Request,
+	user *logic.User,
+) ([]savingsGoalRow, bool) {
+	goals, err := h.store.FindSavingsGoals(r.Context(), user.ID, time.Now())
+	if err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, DashboardIndex, err)
+
+		return nil, false
+	}
+
+	rows := make([]savingsGoalRow, 0, dashboardSavingsGoalLimit)
+	for _, goal := range goals {
+		if goal.Complete {
+			continue
+		}
+		rows = append(rows, newSavingsGoalRow(goal))
+		if len(rows) == dashboardSavingsGoalLimit {
+			break
+		}
+	}
+
+	return rows, true
+}
+
+// parseSavingsGoalForm reads target_amount as cents and target_date as the
+// date controller's RFC 3339 value.
+func parseSavingsGoalForm(r *http.Request) (logic.SavingsGoalParams, error) {
+	var params logic.SavingsGoalParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	amount, err := prog.ParseAmount(r.FormValue("target_amount"))
+	if err != nil {
+		return params, err
+	}
+
+	targetDate, err := prog.StringToUnixDate(r.FormValue("target_date"))
+	if err != nil {
+		return params, err
+	}
+
+	params.Name = r.FormValue("name")
+	params.TargetAmount = amount
+	params.TargetDate = targetDate
+
+	return params, nil
+}
+
+func parseSavingsContributionForm(r *http.Request) (logic.SavingsContributionParams, error) {
+	var params logic.SavingsContributionParams
+
+	if err := r.ParseForm(); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrParseForm, err)
+	}
+
+	params.Note = r.FormValue("note")
+
+	amount, err := prog.ParseAmount(r.FormValue("amount"))
+	if err != nil {
+		return params, err
+	}
+	params.Amount = amount
+
+	date, err := prog.StringToUnixDate(r.FormValue("date"))
+	if err != nil {
+		return params, err
+	}
+	params.Date = date
+
+	return params, nil
+}
+
+func newSavingsGoalRow(progress logic.SavingsGoalProgress) savingsGoalRow {
+	target := int64(progress.Goal.TargetAmount) //nolint:gosec // cent amount, far below int64 max
+	pct, barPct := budgetPercent(progress.Saved, target)
+
+	return savingsGoalRow{SavingsGoalProgress: progress, Pct: pct, BarPct: barPct}
+}
+
+func getSavingsGoal(r *http.Request) *repo.SavingsGoal {
+	goal, ok := r.Context().Value(KeySavingsGoal).(*repo.SavingsGoal)
+
+	if !ok {
+		panic("failed to get savings goal context")
+	}
+
+	return goal
+}
@@ -0,0 +1,140 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavingsGoals(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_goal_and_record_a_contribution",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "savings_goal_h_1", "savings_goal_h_1@example.com", "sg_password_1")
+				cookies := s.AuthCookies(t, "savings_goal_h_1@example.com", "sg_password_1")
+				csrfToken, cookies := s.CSRFFrom(t, "/savings-goals/new", cookies)
+
+				form := url.Values{
+					"name":          {"New car"},
+					"target_amount": {"1200000"},
+					"target_date":   {today.AddDate(1, 0, 0).Format(time.RFC3339)},
+				}
+				req := spec.NewPostRequest("/savings-goals", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				goals, err := s.Store.FindSavingsGoals(t.Context(), user.ID, time.Now())
+				require.NoError(t, err)
+				require.Len(t, goals, 1)
+				path := fmt.Sprintf("/savings-goals/%d", goals[0].Goal.ID)
+				require.Equal(t, path, rec.Header().Get("Location"))
+
+				form = url.Values{
+					"amount": {"300000"},
+					"date":   {today.Format(time.RFC3339)},
+					"note":   {"Bonus"},
+				}
+				req = spec.NewPostRequest(path+"/contributions", form.Encode(), cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				req = spec.NewGetRequest(path, cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "New car")
+				require.Contains(t, rec.Body.String(), "Bonus")
+				require.Contains(t, rec.Body.String(), "$3,000.00")
+				require.Contains(t, rec.Body.String(), "25%")
+			},
+		},
+		{
+			name: "should_reject_a_contribution_without_an_amount",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "savings_goal_h_2", "savings_goal_h_2@example.com", "sg_password_2")
+				goal, err := s.Store.CreateSavingsGoal(t.Context(), user.ID, logic.SavingsGoalParams{
+					Name:         "Roof",
+					TargetAmount: 500000,
+					TargetDate:   today.AddDate(0, 6, 0).Unix(),
+				})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "savings_goal_h_2@example.com", "sg_password_2")
+				path := fmt.Sprintf("/savings-goals/%d", goal.ID)
+				csrfToken, cookies := s.CSRFFrom(t, path, cookies)
+
+				form := url.Values{"amount": {"0"}, "date": {today.Format(time.RFC3339)}}
+				req := spec.NewPostRequest(path+"/contributions", form.Encode(), cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.Contains(t, rec.Body.String(), "Roof")
+			},
+		},
+		{
+			name: "should_not_show_another_users_goal",
+			fn: func(t *testing.T) {
+				owner := s.CreateAuthUser(t, "savings_goal_h_3", "savings_goal_h_3@example.com", "sg_password_3")
+				goal, err := s.Store.CreateSavingsGoal(t.Context(), owner.ID, logic.SavingsGoalParams{
+					Name:         "Secret",
+					TargetAmount: 10000,
+					TargetDate:   today.AddDate(0, 1, 0).Unix(),
+				})
+				require.NoError(t, err)
+				s.CreateAuthUser(t, "savings_goal_h_4", "savings_goal_h_4@example.com", "sg_password_4")
+				cookies := s.AuthCookies(t, "savings_goal_h_4@example.com", "sg_password_4")
+
+				req := spec.NewGetRequest(fmt.Sprintf("/savings-goals/%d", goal.ID), cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name: "should_list_open_goals_on_the_dashboard",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "savings_goal_h_5", "savings_goal_h_5@example.com", "sg_password_5")
+				_, err := s.Store.CreateSavingsGoal(t.Context(), user.ID, logic.SavingsGoalParams{
+					Name:         "Wedding",
+					TargetAmount: 600000,
+					TargetDate:   today.AddDate(2, 0, 0).Unix(),
+				})
+				require.NoError(t, err)
+				cookies := s.AuthCookies(t, "savings_goal_h_5@example.com", "sg_password_5")
+
+				req := spec.NewGetRequest("/dashboard", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "savings-goals-card-title")
+				require.Contains(t, rec.Body.String(), "Wedding")
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/go-chi/chi/v5"
+)
+
+// trashListPaths is where an undo returns to: the list the row was deleted
+// from.
+var trashListPaths = map[string]string{ //nolint:gochecknoglobals // static lookup table
+	repo.TrashKindExpense:    "/expenses",
+	repo.TrashKindMacroEntry: "/macros",
+	repo.TrashKindFood:       "/foods",
+	repo.TrashKindMoodEntry:  "/moods",
+}
+
+type trashRow struct {
+	Kind      string
+	KindLabel string
+	ID        int
+	Label     string
+	DeletedAt int64
+}
+
+// ----------------------------------------------------------------------------- //
+// Handlers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+
+	h.setFlashData(ctx, data)
+
+	if err := h.setTrashData(ctx, data, user.ID); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, TrashIndex, err)
+
+		return
+	}
+
+	h.render(w, http.StatusOK, TrashIndex, data)
+}
+
+func (h *Handler) PostTrashRestore(w http.ResponseWriter, r *http.Request) {
+	h.restoreFromTrash(w, r, "/trash")
+}
+
+// PostTrashUndo is the flash's undo button: the same restore as the trash
+// page, but it returns to the list the row was deleted from.
+func (h *Handler) PostTrashUndo(w http.ResponseWriter, r *http.Request) {
+	h.restoreFromTrash(w, r, trashListPaths[chi.URLParam(r, "kind")])
+}
+
+func (h *Handler) PostTrashPurge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	kind, id, ok := trashTarget(r)
+	if !ok {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	if _, err := h.store.PurgeFromTrash(ctx, kind, id, user.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/trash", http.StatusSeeOther)
+}
+
+func (h *Handler) PostTrashEmpty(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	if _, err := h.store.EmptyTrash(ctx, user.ID); err != nil {
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, "/trash", http.StatusSeeOther)
+}
+
+// ----------------------------------------------------------------------------- //
+// Unexported Functions and Helpers
+// ----------------------------------------------------------------------------- //
+
+func (h *Handler) restoreFromTrash(w http.ResponseWriter, r *http.Request, redirectTo string) {
+	ctx := r.Context()
+	user := getCurrentUser(r)
+
+	kind, id, ok := trashTarget(r)
+	if !ok {
+		h.NotFound(w, r)
+
+		return
+	}
+
+	if _, err := h.store.RestoreFromTrash(ctx, kind, id, user.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.NotFound(w, r)
+
+			return
+		}
+		if errors.Is(err, logic.ErrTrashFoodNameTaken) {
+			h.renderTrashErr(w, r, http.StatusConflict, err)
+
+			return
+		}
+		h.renderErr(w, r, http.StatusInternalServerError, ErrorIndex, err)
+
+		return
+	}
+
+	http.Redirect(w, r, redirectTo, http.StatusSeeOther)
+}
+
+// trashTarget reads the kind and id of a trash route. An unknown kind is
+// reported the same as a missing row.
+func trashTarget(r *http.Request) (string, int, bool) {
+	kind := chi.URLParam(r, "kind")
+	if !repo.ValidTrashKind(kind) {
+		return "", 0, false
+	}
+
+	id, err := prog.ParseID(chi.URLParam(r, "id"), "trashed row")
+	if err != nil {
+		return "", 0, false
+	}
+
+	return kind, id, true
+}
+
+func (h *Handler) renderTrashErr(w http.ResponseWriter, r *http.Request, status int, err error) {
+	ctx := r.Context()
+	data := h.tmplData(r)
+	user := getCurrentUser(r)
+
+	if loadErr := h.setTrashData(ctx, data, user.ID); loadErr != nil {
+		h.app.Logger.Errorf("failed to load trash: %v", loadErr)
+	}
+	data["error"] = err.Error()
+
+	h.render(w, status, TrashIndex, data)
+}
+
+func (h *Handler) setTrashData(ctx context.Context, data map[string]any, userID int) error {
+	trashed, err := h.store.FindTrash(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]trashRow, 0, len(trashed))
+	for _, t := range trashed {
+		rows = append(rows, trashRow{
+			Kind:      t.Kind,
+			KindLabel: logic.TrashKindLabels[t.Kind],
+			ID:        t.ID,
+			Label:     t.Label,
+			DeletedAt: t.DeletedAt,
+		})
+	}
+
+	data["trash"] = rows
+
+	return nil
+}
+
+// flashTrashed leaves a message and an undo action for the page the delete
+// redirects to.
+func (h *Handler) flashTrashed(ctx context.Context, kind string, id int) {
+	h.session.Put(ctx, SessionFlash, fmt.Sprintf("Moved the %s to the trash.", logic.TrashKindLabels[kind]))
+	h.session.Put(ctx, SessionFlashUndo, fmt.Sprintf("/trash/%s/%d/undo", kind, id))
+}
+
+// flashTrashedAll is flashTrashed for a bulk delete. There is no single row to
+// undo, so the message points at the trash page instead.
+func (h *Handler) flashTrashedAll(ctx context.Context, what string) {
+	h.session.Put(ctx, SessionFlash, fmt.Sprintf("Moved %s to the trash.", what))
+}
+
+// setFlashData pops the flash into the template data. Only the pages a delete
+// redirects to call it, so a flash is never consumed by an unrelated request.
+func (h *Handler) setFlashData(ctx context.Context, data map[string]any) {
+	data["flash"] = h.session.PopString(ctx, SessionFlash)
+	data["flashUndo"] = h.session.PopString(ctx, SessionFlashUndo)
+}
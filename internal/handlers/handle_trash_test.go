@@ -0,0 +1,136 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashFlow(t *testing.T) {
+	s := spec.New(t)
+	handler := s.WrappedHandler()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_redirect_to_login_when_unauthenticated",
+			fn: func(t *testing.T) {
+				req := spec.NewGetRequest("/trash", nil)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/login", rec.Header().Get("Location"))
+			},
+		},
+		{
+			name: "should_offer_undo_after_a_delete_and_restore_the_food",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "trash_h_1", "trash_h_1@example.com", "trash_password_1")
+				food := s.CreateFood(t, user.ID, newFoodParams("Trashed oats"))
+				cookies := s.AuthCookies(t, "trash_h_1@example.com", "trash_password_1")
+				foodURL := fmt.Sprintf("/foods/%d", food.ID)
+				csrfToken, cookies := s.CSRFFrom(t, foodURL, cookies)
+
+				req := spec.NewPostRequest(foodURL+"/delete", "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+
+				req = spec.NewGetRequest("/foods", cookies)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				undoPath := fmt.Sprintf("/trash/food/%d/undo", food.ID)
+				require.Contains(t, rec.Body.String(), "Moved the food to the trash.")
+				require.Contains(t, rec.Body.String(), undoPath)
+				require.NotContains(t, rec.Body.String(), "Trashed oats")
+
+				req = spec.NewPostRequest(undoPath, "", cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/foods", rec.Header().Get("Location"))
+
+				_, err := s.Store.FindFood(t.Context(), food.ID, user.ID)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "should_list_and_purge_trashed_rows",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "trash_h_2", "trash_h_2@example.com", "trash_password_2")
+				entry := s.CreateMoodEntry(t, user.ID, logic.MoodEntryParams{
+					Mood:     "Calm",
+					Notes:    "trash handler note",
+					LoggedAt: 1735689600,
+				})
+				require.NoError(t, s.Store.DeleteMoodEntry(t.Context(), entry.ID, user.ID))
+
+				cookies := s.AuthCookies(t, "trash_h_2@example.com", "trash_password_2")
+				csrfToken, cookies := s.CSRFFrom(t, "/trash", cookies)
+
+				req := spec.NewGetRequest("/trash", cookies)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), fmt.Sprintf("/trash/mood_entry/%d/purge", entry.ID))
+
+				req = spec.NewPostRequest(fmt.Sprintf("/trash/mood_entry/%d/purge", entry.ID), "", cookies, csrfToken)
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Equal(t, "/trash", rec.Header().Get("Location"))
+
+				trashed, err := s.Store.FindTrash(t.Context(), user.ID)
+				require.NoError(t, err)
+				require.Empty(t, trashed)
+			},
+		},
+		{
+			name: "should_return_not_found_for_another_users_row",
+			fn: func(t *testing.T) {
+				other := s.CreateAuthUser(t, "trash_h_3", "trash_h_3@example.com", "trash_password_3")
+				food := s.CreateFood(t, other.ID, newFoodParams("Not my oats"))
+				_, err := s.Store.DeleteFood(t.Context(), food.ID, other.ID)
+				require.NoError(t, err)
+
+				s.CreateAuthUser(t, "trash_h_4", "trash_h_4@example.com", "trash_password_4")
+				cookies := s.AuthCookies(t, "trash_h_4@example.com", "trash_password_4")
+				csrfToken, cookies := s.CSRFFrom(t, "/trash", cookies)
+
+				req := spec.NewPostRequest(fmt.Sprintf("/trash/food/%d/restore", food.ID), "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name: "should_return_not_found_for_an_unknown_kind",
+			fn: func(t *testing.T) {
+				s.CreateAuthUser(t, "trash_h_5", "trash_h_5@example.com", "trash_password_5")
+				cookies := s.AuthCookies(t, "trash_h_5@example.com", "trash_password_5")
+				csrfToken, cookies := s.CSRFFrom(t, "/trash", cookies)
+
+				req := spec.NewPostRequest("/trash/users/1/purge", "", cookies, csrfToken)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -183,3 +183,22 @@ func TestRoundMacro(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIFieldName(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"should_lowercase_a_single_word", "Amount", "amount"},
+		{"should_split_camel_case_words", "OccurrenceLimit", "occurrence_limit"},
+		{"should_keep_a_trailing_acronym_together", "CategoryID", "category_id"},
+		{"should_split_an_acronym_before_a_word", "IDCode", "id_code"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, apiFieldName(tc.in))
+		})
+	}
+}
@@ -1,8 +1,103 @@
 package handlers
 
-import "math"
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
 
-const foodBaseAmountG = 100.0
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// servingUnitPrefix marks a unit select value that names one of the food's
+// servings rather than a unit, as in "serving-12".
+const servingUnitPrefix = "serving-"
+
+// foodPortion is the quantity and unit picked on the macro entry form. Unit
+// is the select's value: a unit, or servingUnitPrefix and a serving id.
+type foodPortion struct {
+	Quantity float64
+	Unit     string
+}
+
+func (p foodPortion) params() logic.FoodPortionParams {
+	params := logic.FoodPortionParams{Quantity: p.Quantity, Unit: p.Unit}
+
+	if idStr, ok := strings.CutPrefix(p.Unit, servingUnitPrefix); ok {
+		id, err := strconv.Atoi(idStr)
+		if err == nil && id > 0 {
+			params.Unit = ""
+			params.ServingID = id
+		}
+	}
+
+	return params
+}
+
+// foodPortionFromQuery reads the portion a link to the form asks for. The
+// older amount parameter is grams; without either the food's base amount is
+// used.
+func foodPortionFromQuery(r *http.Request, food repo.Food) foodPortion {
+	portion := foodPortion{Quantity: food.BaseAmount, Unit: food.BaseUnit}
+	q := r.URL.Query()
+
+	if amount, err := strconv.ParseFloat(q.Get("amount"), 64); err == nil && amount > 0 {
+		portion = foodPortion{Quantity: amount, Unit: logic.UnitGram}
+	}
+	if quantity, err := strconv.ParseFloat(q.Get("quantity"), 64); err == nil && quantity > 0 {
+		portion.Quantity = quantity
+	}
+	if unit := q.Get("unit"); unit != "" {
+		portion.Unit = unit
+	}
+
+	return portion
+}
+
+// foodEntryFromQuery reads the entry fields kept across a recalculation of
+// the form, naming the entry after the food unless it was renamed.
+func foodEntryFromQuery(r *http.Request, food repo.Food) repo.MacroEntry {
+	q := r.URL.Query()
+	entry := repo.MacroEntry{Name: food.Name, MealType: q.Get("meal_type")}
+
+	if name := strings.TrimSpace(q.Get("name")); name != "" {
+		entry.Name = name
+	}
+	if date, err := prog.StringToUnixDate(q.Get("date")); err == nil {
+		entry.Date = date
+	}
+
+	return entry
+}
+
+// setFoodPortionData fills the macro entry form for logging a portion of
+// food, with the nutrients of the portion when it can be measured.
+func (h *Handler) setFoodPortionData(
+	ctx context.Context,
+	data map[string]any,
+	food repo.Food,
+	portion foodPortion,
+) error {
+	servings, err := h.store.FindFoodServings(ctx, food.ID, food.UserID)
+	if err != nil {
+		return err
+	}
+
+	data["food"] = food
+	data["servings"] = servings
+	data["units"] = logic.Units()
+	data["portion"] = portion
+	data["servingUnitPrefix"] = servingUnitPrefix
+
+	if nutrients, err := h.store.MeasureFoodPortion(ctx, food, portion.params()); err == nil {
+		data["nutrients"] = nutrients
+	}
+
+	return nil
+}
 
 func roundMacro(v float64) float64 {
 	return math.Round(v*100) / 100
@@ -69,6 +69,8 @@ type PaginationData struct {
 	DateFrom    string
 	DateTo      string
 	DateField   string
+	// PaymentAccountID is the expense index's account filter.
+	PaymentAccountID int
 }
 
 func userScopedQueryOpts(
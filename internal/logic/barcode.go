@@ -0,0 +1,88 @@
+package logic
+
+import "strings"
+
+// NormalizeBarcode checks a scanned or typed EAN-8, UPC-A, EAN-13 or GTIN-14
+// code and returns the form it is stored and looked up in. UPC-A and GTIN-14
+// codes are the EAN-13 number with one fewer or one more leading zero, so
+// they are padded or trimmed to 13 digits and match however they were typed.
+// Spaces and hyphens are ignored.
+func NormalizeBarcode(code string) (string, error) {
+	code = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+
+		return r
+	}, code)
+
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return "", ErrInvalidBarcode
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidBarcode
+		}
+	}
+
+	if !validCheckDigit(code) {
+		return "", ErrInvalidBarcode
+	}
+
+	switch len(code) {
+	case 12:
+		code = "0" + code
+	case 14:
+		if code[0] != '0' {
+			// A packaging indicator makes this a case of the product, not
+			// the product itself.
+			return code, nil
+		}
+		code = code[1:]
+	}
+
+	return code, nil
+}
+
+// validCheckDigit applies the GTIN mod 10 check. Weights alternate 3 and 1
+// from the digit left of the check digit, which keeps the rule the same for
+// every length.
+func validCheckDigit(code string) bool {
+	last := len(code) - 1
+	sum := 0
+
+	for i := last - 1; i >= 0; i-- {
+		d := int(code[i] - '0')
+		if (last-i)%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+
+	return (10-sum%10)%10 == int(code[last]-'0')
+}
+
+// barcodeOrNil normalizes an optional barcode, leaving a blank one unset.
+func barcodeOrNil(code string) (*string, error) {
+	if strings.TrimSpace(code) == "" {
+		return nil, nil
+	}
+
+	normalized, err := NormalizeBarcode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &normalized, nil
+}
+
+func barcodeOrEmpty(barcode *string) string {
+	if barcode == nil {
+		return ""
+	}
+
+	return *barcode
+}
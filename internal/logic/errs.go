@@ -27,10 +27,91 @@ var (
 
 	ErrInvalidMood = errors.New("invalid mood selection")
 
+	ErrUnknownCategory     = errors.New("unknown category")
+	ErrCategoryNameTaken   = errors.New("you already have a category with this name")
+	ErrCategoryNameInvalid = errors.New("category names need at least one letter or number")
+	ErrCategoryMergeSelf   = errors.New("choose a different category to merge into")
+	ErrCategoryParentSelf  = errors.New("a category cannot be its own parent")
+	ErrCategoryTooDeep     = errors.New("categories nest only one level deep")
+
+	ErrUnknownPaymentAccount   = errors.New("unknown payment account")
+	ErrPaymentAccountNameTaken = errors.New("you already have an account with this name")
+	ErrReconcileDate           = errors.New("choose the statement date to reconcile through")
+
+	ErrExpenseSplitsTotal = errors.New("split amounts must add up to the expense amount")
+
+	ErrBudgetMonth = errors.New("budgets apply from a month, YYYY-MM")
+
+	ErrSavingsGoalNameTaken = errors.New("you already have a savings goal with this name")
+
+	ErrRecipeNameTaken = errors.New("you already have a recipe with this name")
+	ErrRecipeEmpty     = errors.New("add an ingredient before logging this recipe")
+	ErrUnknownFood     = errors.New("unknown food")
+
+	ErrMealTemplateNameTaken = errors.New("you already have a meal template with this name")
+	ErrMealTemplateEmpty     = errors.New("add a food before logging this meal template")
+	ErrMealEmpty             = errors.New("nothing was logged for that meal")
+
+	ErrFoodNameTaken        = errors.New("you already have a food with this name")
+	ErrUnknownUnit          = errors.New("unknown unit")
+	ErrUnitNeedsDensity     = errors.New("converting between weight and volume needs the food's density")
+	ErrFoodServingNameTaken = errors.New("this food already has a serving with this name")
+	ErrFoodBarcodeTaken     = errors.New("you already have a food with this barcode")
+	ErrInvalidBarcode       = errors.New("not a valid EAN, UPC or GTIN barcode")
+
+	ErrReferenceFoodsFile   = errors.New("failed to read the nutrition database dump")
+	ErrReferenceFoodsHeader = errors.New("the USDA CSV is missing a column")
+	ErrReferenceFoodsSource = errors.New("the source must be usda or off")
+
+	ErrInvalidAPIToken  = errors.New("invalid api token")
+	ErrAPITokenVerify   = errors.New("failed to verify api token")
+	ErrAPITokenGenerate = errors.New("failed to generate api token")
+	ErrAPITokenNoScope  = errors.New("an api token needs access to at least one area")
+
 	ErrQuickExpenseFormat      = errors.New("quick expense must be: description, amount, date[, tags]")
 	ErrQuickExpenseDescription = errors.New("description must be between 3 and 50 characters")
 	ErrQuickExpenseAmount      = errors.New("invalid amount")
 	ErrQuickExpenseDate        = errors.New("invalid date")
 	ErrQuickExpenseTags        = errors.New("too many tags, 10 maximum")
 	ErrQuickExpenseTagName     = errors.New("each tag must be at most 20 characters")
+
+	ErrImportCSV              = errors.New("failed to read CSV file")
+	ErrImportEmpty            = errors.New("the file has no rows to import")
+	ErrImportTooManyRows      = errors.New("the file has too many rows, 5000 maximum")
+	ErrImportMapping          = errors.New("description, amount and date must each be mapped to a column")
+	ErrImportFallbackCategory = errors.New("unknown fallback category")
+	ErrImportAmount           = errors.New("invalid amount")
+	ErrImportUnknownCategory  = errors.New("unknown category")
+	ErrImportNoCategory       = errors.New("no category matched and no fallback was chosen")
+	ErrImportInvalidRows      = errors.New("some rows are invalid")
+	ErrImportDuplicates       = errors.New("some rows look like expenses you already have")
+
+	ErrRecurrenceEndsBeforeStart   = errors.New("the end date cannot be before the start date")
+	ErrRecurrentCopiesFailed       = errors.New("some recurrent expenses could not be copied")
+	ErrRecurrentIncomeCopiesFailed = errors.New("some recurrent incomes could not be copied")
+
+	ErrExchangeRatesCSV    = errors.New("failed to read exchange rates CSV")
+	ErrExchangeRatesEmpty  = errors.New("the file has no exchange rates")
+	ErrExchangeRatesHeader = errors.New("the header must be: date,base_currency,quote_currency,rate")
+	ErrExchangeRatesRow    = errors.New("invalid exchange rate")
+	ErrExchangeRateDate    = errors.New("date must be YYYY-MM-DD")
+	ErrExchangeRateValue   = errors.New("rate must be a number")
+
+	ErrUnknownTrashKind   = errors.New("unknown trash kind")
+	ErrTrashFoodNameTaken = errors.New("another food already has this name, rename it before restoring")
+	ErrTrashRetentionDays = errors.New("retention must be zero or more days")
+
+	ErrUnknownExportArea   = errors.New("unknown export area")
+	ErrUnknownExportFormat = errors.New("unknown export format")
+
+	ErrBackupArchive     = errors.New("not a readable backup archive")
+	ErrBackupFormat      = errors.New("unsupported backup format")
+	ErrBackupTooLarge    = errors.New("backup file is too large")
+	ErrBackupNewerSchema = errors.New("backup is from a newer version of the app")
+	ErrBackupDangling    = errors.New("backup refers to a row it does not contain")
+	ErrRestoreNotEmpty   = errors.New("restore needs an empty account; delete all data first")
+
+	ErrPossibleDuplicate      = errors.New("this looks like an expense you already have")
+	ErrInvalidDuplicateAction = errors.New("invalid duplicate action")
+	ErrNotDuplicates          = errors.New("only matching expenses can be merged")
 )
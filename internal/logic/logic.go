@@ -33,20 +33,44 @@ func (s *Store) ValidateStruct(st any) error {
 	return nil
 }
 
+// FieldError is a single failed validation rule: the struct field that was
+// rejected and the validator tag that rejected it.
+type FieldError struct {
+	Field string
+	Tag   string
+}
+
+// ValidationError carries every rule a struct failed. Its message is the same
+// "[Field:tag]" chain the forms flash, and it unwraps to ErrValidationFailed, so
+// callers that only check errors.Is keep working while the JSON API can report
+// the fields one by one.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	chained := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		chained = append(chained, "["+f.Field+":"+f.Tag+"]")
+	}
+
+	return fmt.Sprintf("%s: %s", ErrValidationFailed, strings.Join(chained, ","))
+}
+
+func (*ValidationError) Unwrap() error {
+	return ErrValidationFailed
+}
+
 func fmtValidationErrors(err error) error {
 	valErr, ok := err.(validator.ValidationErrors)
 	if !ok {
 		return ErrValidationAssertion
 	}
 
-	var chained []string
+	fields := make([]FieldError, 0, len(valErr))
 	for _, e := range valErr {
-		msg := "[" + e.Field() + ":" + e.ActualTag() + "]"
-		chained = append(chained, msg)
+		fields = append(fields, FieldError{Field: e.Field(), Tag: e.ActualTag()})
 	}
 
-	errStr := strings.Join(chained, ",")
-	wrappedErr := fmt.Errorf("%w: %s", ErrValidationFailed, errStr)
-
-	return wrappedErr
+	return &ValidationError{Fields: fields}
 }
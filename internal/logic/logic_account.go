@@ -15,7 +15,13 @@ type AccountDataCounts struct {
 	MacroGoals        int
 	ExpenseBudgets    int
 	Foods             int
+	Recipes           int
+	MealTemplates     int
 	MoodEntries       int
+	Incomes           int
+	RecurrentIncomes  int
+	PaymentAccounts   int
+	SavingsGoals      int
 	Tags              int
 }
 
@@ -36,15 +42,33 @@ func (s *Store) FindAccountDataCounts(ctx context.Context, userID int) (AccountD
 	if counts.MacroGoals, err = s.queries.CountMacroGoalsByUser(ctx, userID); err != nil {
 		return counts, err
 	}
-	if counts.ExpenseBudgets, err = s.queries.CountExpenseBudgetsByUser(ctx, userID); err != nil {
+	if counts.ExpenseBudgets, err = s.queries.CountExpenseBudgetsByUser(ctx, userID, currentBudgetMonth()); err != nil {
 		return counts, err
 	}
 	if counts.Foods, err = s.queries.CountFoodsByUser(ctx, userID); err != nil {
 		return counts, err
 	}
+	if counts.Recipes, err = s.queries.CountRecipesByUser(ctx, userID); err != nil {
+		return counts, err
+	}
+	if counts.MealTemplates, err = s.queries.CountMealTemplatesByUser(ctx, userID); err != nil {
+		return counts, err
+	}
 	if counts.MoodEntries, err = s.queries.CountMoodEntriesByUser(ctx, userID); err != nil {
 		return counts, err
 	}
+	if counts.Incomes, err = s.queries.CountIncomesByUser(ctx, userID); err != nil {
+		return counts, err
+	}
+	if counts.RecurrentIncomes, err = s.queries.CountRecurrentIncomesByUser(ctx, userID); err != nil {
+		return counts, err
+	}
+	if counts.PaymentAccounts, err = s.queries.CountPaymentAccountsByUser(ctx, userID); err != nil {
+		return counts, err
+	}
+	if counts.SavingsGoals, err = s.queries.CountSavingsGoalsByUser(ctx, userID); err != nil {
+		return counts, err
+	}
 	if counts.Tags, err = s.queries.CountTagsByUser(ctx, userID); err != nil {
 		return counts, err
 	}
@@ -72,12 +96,35 @@ func (s *Store) DeleteAllUserData(ctx context.Context, userID int) error {
 		if err := tq.DeleteAllExpenseBudgetsByUser(ctx, userID); err != nil {
 			return err
 		}
+		// Budget alerts go too: their dedupe keys outlive the expenses, so a
+		// kept one would stop the same month from alerting again.
+		if err := tq.DeleteAllNotificationsByUser(ctx, userID); err != nil {
+			return err
+		}
 		if err := tq.DeleteAllFoodsByUser(ctx, userID); err != nil {
 			return err
 		}
+		if err := tq.DeleteAllRecipesByUser(ctx, userID); err != nil {
+			return err
+		}
+		if err := tq.DeleteAllMealTemplatesByUser(ctx, userID); err != nil {
+			return err
+		}
 		if err := tq.DeleteAllMoodEntriesByUser(ctx, userID); err != nil {
 			return err
 		}
+		if err := tq.DeleteAllIncomesByUser(ctx, userID); err != nil {
+			return err
+		}
+		if err := tq.DeleteAllRecurrentIncomesByUser(ctx, userID); err != nil {
+			return err
+		}
+		if err := tq.DeleteAllPaymentAccountsByUser(ctx, userID); err != nil {
+			return err
+		}
+		if err := tq.DeleteAllSavingsGoalsByUser(ctx, userID); err != nil {
+			return err
+		}
 
 		return tq.DeleteAllTagsByUser(ctx, userID)
 	})
@@ -15,7 +15,8 @@ func TestDeleteAllExpenses(t *testing.T) {
 
 	user := s.CreateAuthUser(t, "acct_exp_user", "acct_exp_user@example.com", "password_1")
 	otherUser := s.CreateAuthUser(t, "acct_exp_other", "acct_exp_other@example.com", "password_2")
-	category := s.CreateCategory(t, "acct expense category")
+	category := s.CreateCategory(t, user.ID, "acct expense category")
+	otherCategory := s.CreateCategory(t, otherUser.ID, "acct expense category")
 
 	userExpense := s.CreateExpense(
 		t, user.ID,
@@ -23,7 +24,7 @@ func TestDeleteAllExpenses(t *testing.T) {
 	)
 	otherExpense := s.CreateExpense(
 		t, otherUser.ID,
-		newExpenseParams(category.ID, "acct other expense", 600, 1735689600, []string{"acct_tag_b"}),
+		newExpenseParams(otherCategory.ID, "acct other expense", 600, 1735689600, []string{"acct_tag_b"}),
 	)
 
 	err := s.Store.DeleteAllExpenses(ctx, user.ID)
@@ -33,7 +34,15 @@ func TestDeleteAllExpenses(t *testing.T) {
 	require.NoError(t, err)
 	require.Zero(t, userCount)
 
-	// Taggings for the deleted expense must not be orphaned.
+	// The expense went to the trash with its taggings, and purging the trash
+	// must not leave them orphaned.
+	kept, err := s.Queries.CountTaggingsByTarget(ctx, repo.TaggableTypeExpense, userExpense.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, kept)
+
+	_, err = s.Store.EmptyTrash(ctx, user.ID)
+	require.NoError(t, err)
+
 	orphaned, err := s.Queries.CountTaggingsByTarget(ctx, repo.TaggableTypeExpense, userExpense.ID)
 	require.NoError(t, err)
 	require.Zero(t, orphaned)
@@ -76,6 +85,9 @@ func TestDeleteAllMoodEntries(t *testing.T) {
 	require.NoError(t, err)
 	require.Zero(t, userCount)
 
+	_, err = s.Store.EmptyTrash(ctx, user.ID)
+	require.NoError(t, err)
+
 	orphaned, err := s.Queries.CountTaggingsByTarget(ctx, repo.TaggableTypeMoodEntry, userMood.ID)
 	require.NoError(t, err)
 	require.Zero(t, orphaned)
@@ -91,13 +103,14 @@ func TestDeleteAllRecurrentExpenses(t *testing.T) {
 
 	user := s.CreateAuthUser(t, "acct_rec_user", "acct_rec_user@example.com", "password_1")
 	otherUser := s.CreateAuthUser(t, "acct_rec_other", "acct_rec_other@example.com", "password_2")
-	category := s.CreateCategory(t, "acct recurrent expense category")
+	category := s.CreateCategory(t, user.ID, "acct recurrent expense category")
+	otherCategory := s.CreateCategory(t, otherUser.ID, "acct recurrent expense category")
 
 	userParams := newRecurrentExpenseParams(category.ID, "acct user recurrent expense", 700, 1)
 	userParams.Tags = []string{"acct_rec_tag_a"}
 	userRecurrentExpense := s.CreateRecurrentExpense(t, user.ID, userParams)
 
-	otherParams := newRecurrentExpenseParams(category.ID, "acct other recurrent expense", 800, 1)
+	otherParams := newRecurrentExpenseParams(otherCategory.ID, "acct other recurrent expense", 800, 1)
 	otherParams.Tags = []string{"acct_rec_tag_b"}
 	otherRecurrentExpense := s.CreateRecurrentExpense(t, otherUser.ID, otherParams)
 
@@ -142,7 +155,7 @@ func TestDeleteAllTagsCascadesTaggings(t *testing.T) {
 	ctx := t.Context()
 
 	user := s.CreateAuthUser(t, "acct_tag_user", "acct_tag_user@example.com", "password_1")
-	category := s.CreateCategory(t, "acct tag category")
+	category := s.CreateCategory(t, user.ID, "acct tag category")
 
 	expense := s.CreateExpense(
 		t, user.ID,
@@ -168,9 +181,10 @@ func TestDeleteAllUserData(t *testing.T) {
 
 	user := s.CreateAuthUser(t, "acct_all_user", "acct_all_user@example.com", "password_1")
 	otherUser := s.CreateAuthUser(t, "acct_all_other", "acct_all_other@example.com", "password_2")
-	category := s.CreateCategory(t, "acct all category")
 
 	seed := func(userID int, suffix string) {
+		category := s.CreateCategory(t, userID, "acct all category")
+		s.SaveExpenseBudgets(t, userID, map[int]uint64{category.ID: 500})
 		s.CreateExpense(t, userID, newExpenseParams(category.ID, "exp "+suffix, 500, 1735689600, []string{"tag_" + suffix}))
 		s.CreateRecurrentExpense(t, userID, newRecurrentExpenseParams(category.ID, "rec "+suffix, 500, 1))
 		s.CreateMacroEntry(t, userID, newMacroEntryParams("macro "+suffix, 100, 10, 10, 5, 1735689600))
@@ -195,6 +209,10 @@ func TestDeleteAllUserData(t *testing.T) {
 	require.Equal(t, 0, counts.MoodEntries)
 	require.Equal(t, 0, counts.Tags)
 
+	notifications, err := s.Store.FindUnreadNotifications(ctx, user.ID)
+	require.NoError(t, err)
+	require.Empty(t, notifications)
+
 	otherCounts, err := s.Store.FindAccountDataCounts(ctx, otherUser.ID)
 	require.NoError(t, err)
 	require.Equal(t, 1, otherCounts.Expenses)
@@ -205,4 +223,8 @@ func TestDeleteAllUserData(t *testing.T) {
 	require.Equal(t, 1, otherCounts.MoodEntries)
 	// Two tags per seed: one from the expense, one from the mood entry.
 	require.Equal(t, 2, otherCounts.Tags)
+
+	notifications, err = s.Store.FindUnreadNotifications(ctx, otherUser.ID)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
 }
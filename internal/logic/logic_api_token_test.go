@@ -0,0 +1,170 @@
+package logic_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func newAPITokenParams(name, expensesScope string) logic.APITokenParams {
+	return logic.APITokenParams{
+		Name:          name,
+		ExpensesScope: expensesScope,
+		MacrosScope:   logic.APITokenScopeNone,
+		MoodsScope:    logic.APITokenScopeNone,
+	}
+}
+
+func TestCreateAPIToken(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_store_only_a_hash_of_the_token",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_token_1", "api_token_1@example.com", "api_token_password")
+
+				token, rawToken, err := s.Store.CreateAPIToken(
+					ctx, user.ID, newAPITokenParams(" Phone shortcut ", logic.APITokenScopeWrite),
+				)
+				require.NoError(t, err)
+				require.Positive(t, token.ID)
+				require.Equal(t, "Phone shortcut", token.Name)
+				require.True(t, strings.HasPrefix(rawToken, "ninete_"))
+				require.NotContains(t, string(token.TokenHash), rawToken)
+				require.Equal(t, hashString(rawToken), token.TokenFingerprint)
+				require.Nil(t, token.LastUsedAt)
+			},
+		},
+		{
+			name: "should_fail_when_no_area_is_granted",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_token_2", "api_token_2@example.com", "api_token_password")
+
+				_, _, err := s.Store.CreateAPIToken(ctx, user.ID, newAPITokenParams("Nothing", logic.APITokenScopeNone))
+				require.ErrorIs(t, err, logic.ErrAPITokenNoScope)
+			},
+		},
+		{
+			name: "should_fail_validation_for_unknown_scope",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_token_3", "api_token_3@example.com", "api_token_password")
+
+				_, _, err := s.Store.CreateAPIToken(ctx, user.ID, newAPITokenParams("Admin", "admin"))
+				require.ErrorIs(t, err, logic.ErrValidationFailed)
+			},
+		},
+		{
+			name: "should_fail_validation_when_name_is_blank",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_token_4", "api_token_4@example.com", "api_token_password")
+
+				_, _, err := s.Store.CreateAPIToken(ctx, user.ID, newAPITokenParams("   ", logic.APITokenScopeRead))
+				require.ErrorIs(t, err, logic.ErrValidationFailed)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestAuthenticateAPIToken(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_resolve_a_valid_token_and_record_its_use",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_auth_1", "api_auth_1@example.com", "api_token_password")
+				created, rawToken, err := s.Store.CreateAPIToken(
+					ctx, user.ID, newAPITokenParams("Script", logic.APITokenScopeRead),
+				)
+				require.NoError(t, err)
+
+				token, err := s.Store.AuthenticateAPIToken(ctx, rawToken)
+				require.NoError(t, err)
+				require.Equal(t, created.ID, token.ID)
+				require.Equal(t, user.ID, token.UserID)
+
+				tokens, err := s.Store.FindAPITokens(ctx, user.ID)
+				require.NoError(t, err)
+				require.Len(t, tokens, 1)
+				require.NotNil(t, tokens[0].LastUsedAt)
+			},
+		},
+		{
+			name: "should_reject_an_unknown_token",
+			fn: func(t *testing.T) {
+				_, err := s.Store.AuthenticateAPIToken(ctx, "ninete_not-a-real-token")
+				require.ErrorIs(t, err, logic.ErrInvalidAPIToken)
+			},
+		},
+		{
+			name: "should_reject_a_token_without_the_prefix",
+			fn: func(t *testing.T) {
+				_, err := s.Store.AuthenticateAPIToken(ctx, "something-else")
+				require.ErrorIs(t, err, logic.ErrInvalidAPIToken)
+			},
+		},
+		{
+			name: "should_reject_a_revoked_token",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "api_auth_2", "api_auth_2@example.com", "api_token_password")
+				created, rawToken, err := s.Store.CreateAPIToken(
+					ctx, user.ID, newAPITokenParams("Revoked", logic.APITokenScopeRead),
+				)
+				require.NoError(t, err)
+				require.NoError(t, s.Store.DeleteAPIToken(ctx, created.ID, user.ID))
+
+				_, err = s.Store.AuthenticateAPIToken(ctx, rawToken)
+				require.ErrorIs(t, err, logic.ErrInvalidAPIToken)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestAPITokenAllows(t *testing.T) {
+	token := repo.APIToken{
+		ExpensesScope: logic.APITokenScopeWrite,
+		MacrosScope:   logic.APITokenScopeRead,
+		MoodsScope:    logic.APITokenScopeNone,
+	}
+
+	cases := []struct {
+		name  string
+		area  string
+		write bool
+		want  bool
+	}{
+		{"should_allow_read_with_write_scope", logic.APITokenAreaExpenses, false, true},
+		{"should_allow_write_with_write_scope", logic.APITokenAreaExpenses, true, true},
+		{"should_allow_read_with_read_scope", logic.APITokenAreaMacros, false, true},
+		{"should_deny_write_with_read_scope", logic.APITokenAreaMacros, true, false},
+		{"should_deny_read_with_none_scope", logic.APITokenAreaMoods, false, false},
+		{"should_deny_unknown_area", "foods", false, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, logic.APITokenAllows(token, tc.area, tc.write))
+		})
+	}
+}
@@ -0,0 +1,1664 @@
+package logic
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// BackupFormat is bumped whenever a file's shape changes in a way an older
+// restore could not read.
+const BackupFormat = 1
+
+// backupFileMaxBytes caps how much of one archive entry is decompressed, so a
+// small upload cannot expand into an unbounded amount of memory.
+const backupFileMaxBytes = 64 << 20
+
+// Archive entries. Each table file is a JSON array of rows as they were in the
+// database, ids included; the ids only tie rows to each other inside the
+// archive and are replaced on restore.
+const (
+	backupManifestFile             = "manifest.json"
+	backupCategoriesFile           = "categories.json"
+	backupTagsFile                 = "tags.json"
+	backupTaggingsFile             = "taggings.json"
+	backupExpensesFile             = "expenses.json"
+	backupExpenseSplitsFile        = "expense_splits.json"
+	backupRecurrentExpensesFile    = "recurrent_expenses.json"
+	backupPendingExpensesFile      = "pending_expenses.json"
+	backupExpenseBudgetsFile       = "expense_budgets.json"
+	backupCategoryMappingsFile     = "expense_category_mappings.json"
+	backupMacroEntriesFile         = "macro_entries.json"
+	backupMacroGoalsFile           = "macro_goals.json"
+	backupFoodsFile                = "foods.json"
+	backupFoodServingsFile         = "food_servings.json"
+	backupRecipesFile              = "recipes.json"
+	backupRecipeIngredientsFile    = "recipe_ingredients.json"
+	backupMealTemplatesFile        = "meal_templates.json"
+	backupMealTemplateItemsFile    = "meal_template_items.json"
+	backupMoodEntriesFile          = "mood_entries.json"
+	backupIncomesFile              = "incomes.json"
+	backupRecurrentIncomesFile     = "recurrent_incomes.json"
+	backupPaymentAccountsFile      = "payment_accounts.json"
+	backupSavingsGoalsFile         = "savings_goals.json"
+	backupSavingsContributionsFile = "savings_contributions.json"
+)
+
+// BackupManifest describes an archive. MigrationVersion is the schema the rows
+// were read from; a restore refuses archives from a newer schema than its own.
+type BackupManifest struct {
+	Format           int            `json:"format"`
+	MigrationVersion int64          `json:"migration_version"`
+	AppVersion       string         `json:"app_version"`
+	CreatedAt        int64          `json:"created_at"`
+	Rows             map[string]int `json:"rows"`
+}
+
+// BackupCategory records the categories the archive's category ids refer to.
+// A restore matches them to the account's own by UID and creates the ones it
+// lacks, so an archive from before categories were per user still restores.
+type BackupCategory struct {
+	ID         int    `json:"id"`
+	UID        string `json:"uid"`
+	Name       string `json:"name"`
+	ArchivedAt *int64 `json:"archived_at,omitempty"`
+	ParentID   *int   `json:"parent_id,omitempty"`
+}
+
+type BackupTagging struct {
+	ID           int    `json:"id"`
+	TagID        int    `json:"tag_id"`
+	TaggableID   int    `json:"taggable_id"`
+	TaggableType string `json:"taggable_type"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+type BackupExpense struct {
+	ID          int    `json:"id"`
+	CategoryID  int    `json:"category_id"`
+	Description string `json:"description"`
+	Amount      uint64 `json:"amount"`
+	Date        int64  `json:"date"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+	Currency    string `json:"currency,omitempty"`
+	// RecurrentExpenseID is the backup id of the recurrent expense that
+	// generated the expense.
+	RecurrentExpenseID *int `json:"recurrent_expense_id,omitempty"`
+	// PaymentAccountID is the backup id of the account it was paid from.
+	PaymentAccountID *int `json:"payment_account_id,omitempty"`
+}
+
+type BackupExpenseSplit struct {
+	ID         int    `json:"id"`
+	ExpenseID  int    `json:"expense_id"`
+	CategoryID int    `json:"category_id"`
+	Amount     uint64 `json:"amount"`
+	CreatedAt  int64  `json:"created_at"`
+	UpdatedAt  int64  `json:"updated_at"`
+}
+
+type BackupRecurrentExpense struct {
+	ID                int    `json:"id"`
+	CategoryID        int    `json:"category_id"`
+	Description       string `json:"description"`
+	Amount            uint64 `json:"amount"`
+	Period            uint   `json:"period"`
+	LastCopyCreatedAt *int64 `json:"last_copy_created_at"`
+	OccurrenceLimit   uint   `json:"occurrence_limit"`
+	OccurrenceCount   uint   `json:"occurrence_count"`
+	ArchivedAt        *int64 `json:"archived_at"`
+	CreatedAt         int64  `json:"created_at"`
+	UpdatedAt         int64  `json:"updated_at"`
+	Currency          string `json:"currency,omitempty"`
+	Frequency         string `json:"frequency,omitempty"`
+	AnchorDay         uint   `json:"anchor_day,omitempty"`
+	StartsAt          *int64 `json:"starts_at,omitempty"`
+	EndsAt            *int64 `json:"ends_at,omitempty"`
+	IsEstimate        bool   `json:"is_estimate,omitempty"`
+	PaymentAccountID  *int   `json:"payment_account_id,omitempty"`
+}
+
+// rule returns the frequency and anchor day to restore. Archives written
+// before recurrence rules carry neither, and every row then was a monthly
+// copy on the 1st.
+func (e BackupRecurrentExpense) rule() (string, uint) {
+	frequency, anchorDay := e.Frequency, e.AnchorDay
+	if frequency == "" {
+		frequency = RecurrenceMonthly
+	}
+	if anchorDay == 0 {
+		anchorDay = 1
+	}
+
+	return frequency, anchorDay
+}
+
+type BackupPendingExpense struct {
+	ID                 int   `json:"id"`
+	RecurrentExpenseID int   `json:"recurrent_expense_id"`
+	Date               int64 `json:"date"`
+	CreatedAt          int64 `json:"created_at"`
+}
+
+// BackupExpenseBudget is one budget version. A null category_id is the
+// whole-month budget. Backups from before budgets were versioned carry no
+// effective_from; such a budget is restored as starting the month it was last
+// saved.
+type BackupExpenseBudget struct {
+	ID            int    `json:"id"`
+	CategoryID    *int   `json:"category_id"`
+	Amount        uint64 `json:"amount"`
+	Rollover      bool   `json:"rollover"`
+	EffectiveFrom string `json:"effective_from"`
+	CreatedAt     int64  `json:"created_at"`
+	UpdatedAt     int64  `json:"updated_at"`
+}
+
+type BackupCategoryMapping struct {
+	ID             int    `json:"id"`
+	CategoryID     int    `json:"category_id"`
+	DescriptionKey string `json:"description_key"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+}
+
+type BackupMoodEntry struct {
+	ID        int    `json:"id"`
+	Mood      string `json:"mood"`
+	Notes     string `json:"notes"`
+	LoggedAt  int64  `json:"logged_at"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+type BackupIncome struct {
+	ID        int    `json:"id"`
+	Source    string `json:"source"`
+	Amount    uint64 `json:"amount"`
+	Currency  string `json:"currency"`
+	Date      int64  `json:"date"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+	// RecurrentIncomeID is the backup id of the recurrent income that
+	// generated the income.
+	RecurrentIncomeID *int `json:"recurrent_income_id,omitempty"`
+}
+
+type BackupRecurrentIncome struct {
+	ID                int    `json:"id"`
+	Source            string `json:"source"`
+	Amount            uint64 `json:"amount"`
+	Currency          string `json:"currency"`
+	Period            uint   `json:"period"`
+	Frequency         string `json:"frequency"`
+	AnchorDay         uint   `json:"anchor_day"`
+	StartsAt          *int64 `json:"starts_at,omitempty"`
+	EndsAt            *int64 `json:"ends_at,omitempty"`
+	OccurrenceLimit   uint   `json:"occurrence_limit"`
+	OccurrenceCount   uint   `json:"occurrence_count"`
+	LastCopyCreatedAt *int64 `json:"last_copy_created_at"`
+	ArchivedAt        *int64 `json:"archived_at"`
+	CreatedAt         int64  `json:"created_at"`
+	UpdatedAt         int64  `json:"updated_at"`
+}
+
+type BackupPaymentAccount struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	Kind              string `json:"kind"`
+	Currency          string `json:"currency"`
+	OpeningBalance    int64  `json:"opening_balance"`
+	ReconciledThrough *int64 `json:"reconciled_through,omitempty"`
+	CreatedAt         int64  `json:"created_at"`
+	UpdatedAt         int64  `json:"updated_at"`
+}
+
+type BackupSavingsGoal struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	TargetAmount uint64 `json:"target_amount"`
+	TargetDate   int64  `json:"target_date"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+// BackupSavingsContribution points at its goal by the goal's backup id.
+type BackupSavingsContribution struct {
+	SavingsGoalID int    `json:"savings_goal_id"`
+	Amount        uint64 `json:"amount"`
+	Date          int64  `json:"date"`
+	Note          string `json:"note"`
+	CreatedAt     int64  `json:"created_at"`
+	UpdatedAt     int64  `json:"updated_at"`
+}
+
+// BackupFoodServing points at its food by the food's backup id.
+type BackupFoodServing struct {
+	FoodID    int     `json:"food_id"`
+	Name      string  `json:"name"`
+	Amount    float64 `json:"amount"`
+	Unit      string  `json:"unit"`
+	CreatedAt int64   `json:"created_at"`
+	UpdatedAt int64   `json:"updated_at"`
+}
+
+type BackupRecipe struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Servings  float64 `json:"servings"`
+	CreatedAt int64   `json:"created_at"`
+	UpdatedAt int64   `json:"updated_at"`
+}
+
+// BackupRecipeIngredient points at its recipe and food by their backup ids.
+type BackupRecipeIngredient struct {
+	RecipeID  int     `json:"recipe_id"`
+	FoodID    int     `json:"food_id"`
+	QuantityG float64 `json:"quantity_g"`
+	CreatedAt int64   `json:"created_at"`
+	UpdatedAt int64   `json:"updated_at"`
+}
+
+type BackupMealTemplate struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	MealType  string `json:"meal_type"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// BackupMealTemplateItem points at its template and food by their backup ids.
+type BackupMealTemplateItem struct {
+	MealTemplateID int     `json:"meal_template_id"`
+	FoodID         int     `json:"food_id"`
+	Quantity       float64 `json:"quantity"`
+	Unit           string  `json:"unit"`
+	CreatedAt      int64   `json:"created_at"`
+	UpdatedAt      int64   `json:"updated_at"`
+}
+
+// backupData is a decoded archive. Tags, foods, macro entries and goals reuse
+// the export shapes, which already mirror their tables.
+type backupData struct {
+	Manifest          BackupManifest
+	Categories        []BackupCategory
+	Tags              []ExportTag
+	Taggings          []BackupTagging
+	Expenses          []BackupExpense
+	ExpenseSplits     []BackupExpenseSplit
+	RecurrentExpenses []BackupRecurrentExpense
+	PendingExpenses   []BackupPendingExpense
+	ExpenseBudgets    []BackupExpenseBudget
+	CategoryMappings  []BackupCategoryMapping
+	MacroEntries      []ExportMacroEntry
+	MacroGoals        []ExportMacroGoal
+	Foods             []ExportFood
+	FoodServings      []BackupFoodServing
+	Recipes           []BackupRecipe
+	RecipeIngredients []BackupRecipeIngredient
+	MealTemplates     []BackupMealTemplate
+	MealTemplateItems []BackupMealTemplateItem
+	MoodEntries       []BackupMoodEntry
+	Incomes           []BackupIncome
+	RecurrentIncomes  []BackupRecurrentIncome
+	PaymentAccounts   []BackupPaymentAccount
+	SavingsGoals      []BackupSavingsGoal
+	Contributions     []BackupSavingsContribution
+}
+
+// WriteBackup writes a zip of every table the user owns to w. API tokens and
+// sessions are left out: they are credentials, not data, and a restored
+// account signs in and mints tokens afresh.
+func (s *Store) WriteBackup(ctx context.Context, w io.Writer, userID int) error {
+	migrationVersion, err := s.queries.SelectMigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	rows := make(map[string]int)
+
+	categories, err := s.queries.SelectCategoriesByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	rows[backupCategoriesFile], err = writeBackupFile(zw, backupCategoriesFile,
+		func(emit func(BackupCategory) error) error {
+			for _, c := range categories {
+				err := emit(BackupCategory{
+					ID: c.ID, UID: c.UID, Name: c.Name, ArchivedAt: c.ArchivedAt, ParentID: c.ParentID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range s.backupTables(ctx, userID) {
+		rows[file.name], err = file.write(zw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.name, err)
+		}
+	}
+
+	manifest := BackupManifest{
+		Format:           BackupFormat,
+		MigrationVersion: migrationVersion,
+		AppVersion:       prog.Version,
+		CreatedAt:        time.Now().UTC().Unix(),
+		Rows:             rows,
+	}
+
+	f, err := zw.Create(backupManifestFile)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// RestoreBackup recreates an archive's rows for the user in one transaction
+// and returns how many of each were restored. The account must be empty: a
+// restore is for moving data in or recovering from a delete-all, not for
+// merging two histories.
+func (s *Store) RestoreBackup(
+	ctx context.Context,
+	userID int,
+	r io.ReaderAt,
+	size int64,
+) (AccountDataCounts, error) {
+	var counts AccountDataCounts
+
+	data, err := readBackup(r, size)
+	if err != nil {
+		return counts, err
+	}
+
+	migrationVersion, err := s.queries.SelectMigrationVersion(ctx)
+	if err != nil {
+		return counts, err
+	}
+	if data.Manifest.MigrationVersion > migrationVersion {
+		return counts, fmt.Errorf(
+			"%w: archive is at %d, this database at %d",
+			ErrBackupNewerSchema, data.Manifest.MigrationVersion, migrationVersion,
+		)
+	}
+
+	existing, err := s.FindAccountDataCounts(ctx, userID)
+	if err != nil {
+		return counts, err
+	}
+	if existing != (AccountDataCounts{}) {
+		return counts, ErrRestoreNotEmpty
+	}
+
+	categoryIDs, err := s.backupCategoryIDs(ctx, userID, data.Categories)
+	if err != nil {
+		return counts, err
+	}
+
+	err = s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		var txErr error
+
+		counts, txErr = restoreBackupTx(ctx, tq, userID, data, categoryIDs)
+
+		return txErr
+	})
+	if err != nil {
+		return AccountDataCounts{}, err
+	}
+
+	return counts, nil
+}
+
+// ----------------------------------------------------------------------------- //
+// Writing
+// ----------------------------------------------------------------------------- //
+
+type backupTable struct {
+	name  string
+	write func(*zip.Writer) (int, error)
+}
+
+func (s *Store) backupTables(ctx context.Context, userID int) []backupTable {
+	return []backupTable{
+		{backupPaymentAccountsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupPaymentAccountsFile, func(emit func(BackupPaymentAccount) error) error {
+				accounts, err := s.queries.SelectPaymentAccountsByUser(ctx, userID)
+				if err != nil {
+					return err
+				}
+				for _, a := range accounts {
+					err := emit(BackupPaymentAccount{
+						ID:                a.ID,
+						Name:              a.Name,
+						Kind:              a.Kind,
+						Currency:          a.Currency,
+						OpeningBalance:    a.OpeningBalance,
+						ReconciledThrough: a.ReconciledThrough,
+						CreatedAt:         a.CreatedAt,
+						UpdatedAt:         a.UpdatedAt,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}},
+		{backupTagsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupTagsFile, func(emit func(ExportTag) error) error {
+				return s.eachExportTag(ctx, userID, func(record exportRecord) error {
+					return emit(record.(ExportTag))
+				})
+			})
+		}},
+		{backupTaggingsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupTaggingsFile, func(emit func(BackupTagging) error) error {
+				taggings, err := s.queries.SelectTaggingsByUser(ctx, userID)
+				if err != nil {
+					return err
+				}
+				for _, t := range taggings {
+					err := emit(BackupTagging{
+						ID:           t.ID,
+						TagID:        t.TagID,
+						TaggableID:   t.TaggableID,
+						TaggableType: t.TaggableType,
+						CreatedAt:    t.CreatedAt,
+						UpdatedAt:    t.UpdatedAt,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}},
+		{backupExpensesFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupExpensesFile, func(emit func(BackupExpense) error) error {
+				return eachBatch(userID,
+					func(opts repo.QueryOptions) ([]repo.Expense, error) { return s.queries.SelectExpenses(ctx, opts) },
+					func(e repo.Expense) int { return e.ID },
+					func(batch []repo.Expense) error {
+						for _, e := range batch {
+							err := emit(BackupExpense{
+								ID:                 e.ID,
+								CategoryID:         e.CategoryID,
+								Description:        e.Description,
+								Amount:             e.Amount,
+								Date:               e.Date,
+								CreatedAt:          e.CreatedAt,
+								UpdatedAt:          e.UpdatedAt,
+								Currency:           e.Currency,
+								RecurrentExpenseID: e.RecurrentExpenseID,
+								PaymentAccountID:   e.PaymentAccountID,
+							})
+							if err != nil {
+								return err
+							}
+						}
+
+						return nil
+					},
+				)
+			})
+		}},
+		{backupExpenseSplitsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupExpenseSplitsFile, func(emit func(BackupExpenseSplit) error) error {
+				splits, err := s.queries.SelectExpenseSplitsByUser(ctx, userID)
+				if err != nil {
+					return err
+				}
+
+				for _, split := range splits {
+					err := emit(BackupExpenseSplit{
+						ID:         split.ID,
+						ExpenseID:  split.ExpenseID,
+						CategoryID: split.CategoryID,
+						Amount:     split.Amount,
+						CreatedAt:  split.CreatedAt,
+						UpdatedAt:  split.UpdatedAt,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}},
+		{backupRecurrentExpensesFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupRecurrentExpensesFile,
+				func(emit func(BackupRecurrentExpense) error) error {
+					return eachBatch(userID,
+						func(opts repo.QueryOptions) ([]repo.RecurrentExpense, error) {
+							return s.queries.SelectRecurrentExpenses(ctx, opts)
+						},
+						func(e repo.RecurrentExpense) int { return e.ID },
+						func(batch []repo.RecurrentExpense) error {
+							for _, e := range batch {
+								err := emit(BackupRecurrentExpense{
+									ID:                e.ID,
+									CategoryID:        e.CategoryID,
+									Description:       e.Description,
+									Amount:            e.Amount,
+									Period:            e.Period,
+									LastCopyCreatedAt: e.LastCopyCreatedAt,
+									OccurrenceLimit:   e.OccurrenceLimit,
+									OccurrenceCount:   e.OccurrenceCount,
+									ArchivedAt:        e.ArchivedAt,
+									CreatedAt:         e.CreatedAt,
+									UpdatedAt:         e.UpdatedAt,
+									Currency:          e.Currency,
+									Frequency:         e.Frequency,
+									AnchorDay:         e.AnchorDay,
+									StartsAt:          e.StartsAt,
+									EndsAt:            e.EndsAt,
+									IsEstimate:        e.IsEstimate,
+									PaymentAccountID:  e.PaymentAccountID,
+								})
+								if err != nil {
+									return err
+								}
+							}
+
+							return nil
+						},
+					)
+				},
+			)
+		}},
+		{backupPendingExpensesFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupPendingExpensesFile, func(emit func(BackupPendingExpense) error) error {
+				pending, err := s.queries.SelectPendingExpensesByUser(ctx, userID)
+				if err != nil {
+					return err
+				}
+				for _, p := range pending {
+					err := emit(BackupPendingExpense{
+						ID:                 p.ID,
+						RecurrentExpenseID: p.RecurrentExpenseID,
+						Date:               p.Date,
+						CreatedAt:          p.CreatedAt,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}},
+		{backupExpenseBudgetsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupExpenseBudgetsFile, func(emit func(BackupExpenseBudget) error) error {
+				budgets, err := s.queries.SelectExpenseBudgetVersionsByUser(ctx, userID)
+				if err != nil {
+					return err
+				}
+				for _, b := range budgets {
+					err := emit(BackupExpenseBudget{
+						ID:            b.ID,
+						CategoryID:    b.CategoryID,
+						Amount:        b.Amount,
+						Rollover:      b.Rollover,
+						EffectiveFrom: b.EffectiveFrom,
+						CreatedAt:     b.CreatedAt,
+						UpdatedAt:     b.UpdatedAt,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}},
+		{backupCategoryMappingsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupCategoryMappingsFile,
+				func(emit func(BackupCategoryMapping) error) error {
+					mappings, err := s.queries.SelectExpenseCategoryMappingsByUser(ctx, userID)
+					if err != nil {
+						return err
+					}
+					for _, m := range mappings {
+						err := emit(BackupCategoryMapping{
+							ID:             m.ID,
+							CategoryID:     m.CategoryID,
+							DescriptionKey: m.DescriptionKey,
+							CreatedAt:      m.CreatedAt,
+							UpdatedAt:      m.UpdatedAt,
+						})
+						if err != nil {
+							return err
+						}
+					}
+
+					return nil
+				},
+			)
+		}},
+		{backupMacroEntriesFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupMacroEntriesFile, func(emit func(ExportMacroEntry) error) error {
+				return s.eachExportMacroEntry(ctx, userID, func(record exportRecord) error {
+					return emit(record.(ExportMacroEntry))
+				})
+			})
+		}},
+		{backupMacroGoalsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupMacroGoalsFile, func(emit func(ExportMacroGoal) error) error {
+				return s.eachExportMacroGoal(ctx, userID, func(record exportRecord) error {
+					return emit(record.(ExportMacroGoal))
+				})
+			})
+		}},
+		{backupFoodsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupFoodsFile, func(emit func(ExportFood) error) error {
+				return s.eachExportFood(ctx, userID, func(record exportRecord) error {
+					return emit(record.(ExportFood))
+				})
+			})
+		}},
+		{backupMoodEntriesFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupMoodEntriesFile, func(emit func(BackupMoodEntry) error) error {
+				return eachBatch(userID,
+					func(opts repo.QueryOptions) ([]repo.MoodEntry, error) {
+						return s.queries.SelectMoodEntries(ctx, opts)
+					},
+					func(e repo.MoodEntry) int { return e.ID },
+					func(batch []repo.MoodEntry) error {
+						for _, e := range batch {
+							err := emit(BackupMoodEntry{
+								ID:        e.ID,
+								Mood:      e.Mood,
+								Notes:     e.Notes,
+								LoggedAt:  e.LoggedAt,
+								CreatedAt: e.CreatedAt,
+								UpdatedAt: e.UpdatedAt,
+							})
+							if err != nil {
+								return err
+							}
+						}
+
+						return nil
+					},
+				)
+			})
+		}},
+		{backupRecurrentIncomesFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupRecurrentIncomesFile,
+				func(emit func(BackupRecurrentIncome) error) error {
+					return eachBatch(userID,
+						func(opts repo.QueryOptions) ([]repo.RecurrentIncome, error) {
+							return s.queries.SelectRecurrentIncomes(ctx, opts)
+						},
+						func(ri repo.RecurrentIncome) int { return ri.ID },
+						func(batch []repo.RecurrentIncome) error {
+							for _, ri := range batch {
+								err := emit(BackupRecurrentIncome{
+									ID:                ri.ID,
+									Source:            ri.Source,
+									Amount:            ri.Amount,
+									Currency:          ri.Currency,
+									Period:            ri.Period,
+									Frequency:         ri.Frequency,
+									AnchorDay:         ri.AnchorDay,
+									StartsAt:          ri.StartsAt,
+									EndsAt:            ri.EndsAt,
+									OccurrenceLimit:   ri.OccurrenceLimit,
+									OccurrenceCount:   ri.OccurrenceCount,
+									LastCopyCreatedAt: ri.LastCopyCreatedAt,
+									ArchivedAt:        ri.ArchivedAt,
+									CreatedAt:         ri.CreatedAt,
+									UpdatedAt:         ri.UpdatedAt,
+								})
+								if err != nil {
+									return err
+								}
+							}
+
+							return nil
+						},
+					)
+				},
+			)
+		}},
+		{backupIncomesFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupIncomesFile, func(emit func(BackupIncome) error) error {
+				return eachBatch(userID,
+					func(opts repo.QueryOptions) ([]repo.Income, error) { return s.queries.SelectIncomes(ctx, opts) },
+					func(i repo.Income) int { return i.ID },
+					func(batch []repo.Income) error {
+						for _, i := range batch {
+							err := emit(BackupIncome{
+								ID:                i.ID,
+								Source:            i.Source,
+								Amount:            i.Amount,
+								Currency:          i.Currency,
+								Date:              i.Date,
+								CreatedAt:         i.CreatedAt,
+								UpdatedAt:         i.UpdatedAt,
+								RecurrentIncomeID: i.RecurrentIncomeID,
+							})
+							if err != nil {
+								return err
+							}
+						}
+
+						return nil
+					},
+				)
+			})
+		}},
+		{backupFoodServingsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupFoodServingsFile, func(emit func(BackupFoodServing) error) error {
+				servings, err := s.queries.SelectFoodServingsByUser(ctx, userID)
+				if err != nil {
+					return err
+				}
+				for _, fs := range servings {
+					err := emit(BackupFoodServing{
+						FoodID:    fs.FoodID,
+						Name:      fs.Name,
+						Amount:    fs.Amount,
+						Unit:      fs.Unit,
+						CreatedAt: fs.CreatedAt,
+						UpdatedAt: fs.UpdatedAt,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}},
+		{backupRecipesFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupRecipesFile, func(emit func(BackupRecipe) error) error {
+				recipes, err := s.queries.SelectRecipesByUser(ctx, userID)
+				if err != nil {
+					return err
+				}
+				for _, r := range recipes {
+					err := emit(BackupRecipe{
+						ID:        r.ID,
+						Name:      r.Name,
+						Servings:  r.Servings,
+						CreatedAt: r.CreatedAt,
+						UpdatedAt: r.UpdatedAt,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}},
+		{backupRecipeIngredientsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupRecipeIngredientsFile, func(emit func(BackupRecipeIngredient) error) error {
+				ingredients, err := s.queries.SelectRecipeIngredientsByUser(ctx, userID)
+				if err != nil {
+					return err
+				}
+				for _, i := range ingredients {
+					err := emit(BackupRecipeIngredient{
+						RecipeID:  i.RecipeID,
+						FoodID:    i.FoodID,
+						QuantityG: i.QuantityG,
+						CreatedAt: i.CreatedAt,
+						UpdatedAt: i.UpdatedAt,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}},
+		{backupMealTemplatesFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupMealTemplatesFile, func(emit func(BackupMealTemplate) error) error {
+				templates, err := s.queries.SelectMealTemplatesByUser(ctx, userID)
+				if err != nil {
+					return err
+				}
+				for _, m := range templates {
+					err := emit(BackupMealTemplate{
+						ID:        m.ID,
+						Name:      m.Name,
+						MealType:  m.MealType,
+						CreatedAt: m.CreatedAt,
+						UpdatedAt: m.UpdatedAt,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}},
+		{backupMealTemplateItemsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupMealTemplateItemsFile, func(emit func(BackupMealTemplateItem) error) error {
+				items, err := s.queries.SelectMealTemplateItemsByUser(ctx, userID)
+				if err != nil {
+					return err
+				}
+				for _, i := range items {
+					err := emit(BackupMealTemplateItem{
+						MealTemplateID: i.MealTemplateID,
+						FoodID:         i.FoodID,
+						Quantity:       i.Quantity,
+						Unit:           i.Unit,
+						CreatedAt:      i.CreatedAt,
+						UpdatedAt:      i.UpdatedAt,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}},
+		{backupSavingsGoalsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupSavingsGoalsFile, func(emit func(BackupSavingsGoal) error) error {
+				goals, err := s.queries.SelectSavingsGoalsByUser(ctx, userID)
+				if err != nil {
+					return err
+				}
+				for _, g := range goals {
+					err := emit(BackupSavingsGoal{
+						ID:           g.ID,
+						Name:         g.Name,
+						TargetAmount: g.TargetAmount,
+						TargetDate:   g.TargetDate,
+						CreatedAt:    g.CreatedAt,
+						UpdatedAt:    g.UpdatedAt,
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}},
+		{backupSavingsContributionsFile, func(zw *zip.Writer) (int, error) {
+			return writeBackupFile(zw, backupSavingsContributionsFile,
+				func(emit func(BackupSavingsContribution) error) error {
+					contributions, err := s.queries.SelectSavingsContributionsByUser(ctx, userID)
+					if err != nil {
+						return err
+					}
+					for _, c := range contributions {
+						err := emit(BackupSavingsContribution{
+							SavingsGoalID: c.SavingsGoalID,
+							Amount:        c.Amount,
+							Date:          c.Date,
+							Note:          c.Note,
+							CreatedAt:     c.CreatedAt,
+							UpdatedAt:     c.UpdatedAt,
+						})
+						if err != nil {
+							return err
+						}
+					}
+
+					return nil
+				},
+			)
+		}},
+	}
+}
+
+// writeBackupFile writes one archive entry as a JSON array, one row per line,
+// encoding rows as each produces them. It returns the number of rows written.
+func writeBackupFile[T any](zw *zip.Writer, name string, each func(emit func(T) error) error) (int, error) {
+	f, err := zw.Create(name)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := io.WriteString(f, "["); err != nil {
+		return 0, err
+	}
+
+	enc := json.NewEncoder(f)
+	n := 0
+	err = each(func(row T) error {
+		sep := "\n"
+		if n > 0 {
+			sep = ","
+		}
+		if _, err := io.WriteString(f, sep); err != nil {
+			return err
+		}
+		n++
+
+		return enc.Encode(row)
+	})
+	if err != nil {
+		return n, err
+	}
+
+	_, err = io.WriteString(f, "]\n")
+
+	return n, err
+}
+
+// ----------------------------------------------------------------------------- //
+// Restoring
+// ----------------------------------------------------------------------------- //
+
+func readBackup(r io.ReaderAt, size int64) (backupData, error) {
+	var data backupData
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return data, fmt.Errorf("%w: %w", ErrBackupArchive, err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifest, ok := files[backupManifestFile]
+	if !ok {
+		return data, fmt.Errorf("%w: no %s", ErrBackupArchive, backupManifestFile)
+	}
+	if err := decodeBackupFile(manifest, &data.Manifest); err != nil {
+		return data, err
+	}
+	if data.Manifest.Format != BackupFormat {
+		return data, fmt.Errorf("%w: %d", ErrBackupFormat, data.Manifest.Format)
+	}
+
+	// A file the archive lacks restores as empty, so an archive written before
+	// a table existed still restores into a schema that has it.
+	targets := map[string]any{
+		backupCategoriesFile:           &data.Categories,
+		backupTagsFile:                 &data.Tags,
+		backupTaggingsFile:             &data.Taggings,
+		backupExpensesFile:             &data.Expenses,
+		backupExpenseSplitsFile:        &data.ExpenseSplits,
+		backupRecurrentExpensesFile:    &data.RecurrentExpenses,
+		backupPendingExpensesFile:      &data.PendingExpenses,
+		backupExpenseBudgetsFile:       &data.ExpenseBudgets,
+		backupCategoryMappingsFile:     &data.CategoryMappings,
+		backupMacroEntriesFile:         &data.MacroEntries,
+		backupMacroGoalsFile:           &data.MacroGoals,
+		backupFoodsFile:                &data.Foods,
+		backupFoodServingsFile:         &data.FoodServings,
+		backupRecipesFile:              &data.Recipes,
+		backupRecipeIngredientsFile:    &data.RecipeIngredients,
+		backupMealTemplatesFile:        &data.MealTemplates,
+		backupMealTemplateItemsFile:    &data.MealTemplateItems,
+		backupMoodEntriesFile:          &data.MoodEntries,
+		backupIncomesFile:              &data.Incomes,
+		backupRecurrentIncomesFile:     &data.RecurrentIncomes,
+		backupPaymentAccountsFile:      &data.PaymentAccounts,
+		backupSavingsGoalsFile:         &data.SavingsGoals,
+		backupSavingsContributionsFile: &data.Contributions,
+	}
+	for name, target := range targets {
+		f, ok := files[name]
+		if !ok {
+			continue
+		}
+		if err := decodeBackupFile(f, target); err != nil {
+			return data, err
+		}
+	}
+
+	return data, nil
+}
+
+func decodeBackupFile(f *zip.File, target any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrBackupArchive, f.Name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	lr := &io.LimitedReader{R: rc, N: backupFileMaxBytes + 1}
+	if err := json.NewDecoder(lr).Decode(target); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrBackupArchive, f.Name, err)
+	}
+	if lr.N <= 0 {
+		return fmt.Errorf("%w: %s", ErrBackupTooLarge, f.Name)
+	}
+
+	return nil
+}
+
+// backupCategoryIDs maps the archive's category ids to the account's, by UID.
+// A category the account lacks maps to 0 until restoreBackupTx creates it.
+func (s *Store) backupCategoryIDs(
+	ctx context.Context,
+	userID int,
+	categories []BackupCategory,
+) (map[int]int, error) {
+	current, err := s.queries.SelectCategoriesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	idByUID := make(map[string]int, len(current))
+	for _, c := range current {
+		idByUID[c.UID] = c.ID
+	}
+
+	ids := make(map[int]int, len(categories))
+	for _, c := range categories {
+		ids[c.ID] = idByUID[c.UID]
+	}
+
+	return ids, nil
+}
+
+// restoreBackupTx inserts the archive's rows, parents first, recording each
+// row's new id so the taggings that point at it can be rewritten.
+func restoreBackupTx(
+	ctx context.Context,
+	tq *repo.TxQueries,
+	userID int,
+	data backupData,
+	categoryIDs map[int]int,
+) (AccountDataCounts, error) {
+	var counts AccountDataCounts
+
+	createdIDs := make(map[int]bool, len(data.Categories))
+	nestedIDs := make(map[int]bool, len(data.Categories))
+	for _, c := range data.Categories {
+		nestedIDs[c.ID] = c.ParentID != nil
+		if categoryIDs[c.ID] != 0 {
+			continue
+		}
+		if c.Name == "" || c.UID == "" {
+			return counts, fmt.Errorf("%w: category %d has no name", ErrBackupArchive, c.ID)
+		}
+
+		created, err := tq.InsertCategory(ctx, repo.InsertCategoryParams{
+			UserID: userID, Name: c.Name, UID: c.UID, ArchivedAt: c.ArchivedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		categoryIDs[c.ID] = created.ID
+		createdIDs[c.ID] = true
+	}
+
+	// Nesting is restored only between categories this restore created, and
+	// only one level deep. One the account already had keeps its place there.
+	for _, c := range data.Categories {
+		if !createdIDs[c.ID] || c.ParentID == nil || !createdIDs[*c.ParentID] || nestedIDs[*c.ParentID] {
+			continue
+		}
+
+		parentID := categoryIDs[*c.ParentID]
+		if _, err := tq.UpdateCategoryParentID(ctx, categoryIDs[c.ID], userID, &parentID); err != nil {
+			return counts, err
+		}
+	}
+
+	categoryID := func(oldID int) (int, error) {
+		id, ok := categoryIDs[oldID]
+		if !ok {
+			return 0, fmt.Errorf("%w: category %d", ErrBackupDangling, oldID)
+		}
+
+		return id, nil
+	}
+
+	tagIDs := make(map[int]int, len(data.Tags))
+	for _, t := range data.Tags {
+		id, err := tq.RestoreTag(ctx, repo.Tag{
+			UserID: userID, Name: t.Name, CreatedAt: t.CreatedAt, UpdatedAt: t.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		tagIDs[t.ID] = id
+		counts.Tags++
+	}
+
+	// Archives from before expenses carried a currency are read as being in
+	// the account's home currency.
+	homeCurrency, err := tq.SelectUserHomeCurrency(ctx, userID)
+	if err != nil {
+		return counts, err
+	}
+	currencyOrHome := func(currency string) string {
+		if currency == "" {
+			return homeCurrency
+		}
+
+		return currency
+	}
+
+	paymentAccountIDs := make(map[int]int, len(data.PaymentAccounts))
+	for _, a := range data.PaymentAccounts {
+		id, err := tq.RestorePaymentAccount(ctx, repo.PaymentAccount{
+			UserID:            userID,
+			Name:              a.Name,
+			Kind:              a.Kind,
+			Currency:          currencyOrHome(a.Currency),
+			OpeningBalance:    a.OpeningBalance,
+			ReconciledThrough: a.ReconciledThrough,
+			CreatedAt:         a.CreatedAt,
+			UpdatedAt:         a.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		paymentAccountIDs[a.ID] = id
+		counts.PaymentAccounts++
+	}
+
+	// A link to an account the archive does not carry is dropped, as the
+	// recurrent expense links below are.
+	paymentAccountID := func(oldID *int) *int {
+		if oldID == nil {
+			return nil
+		}
+		id, ok := paymentAccountIDs[*oldID]
+		if !ok {
+			return nil
+		}
+
+		return &id
+	}
+
+	targetIDs := map[string]map[int]int{
+		repo.TaggableTypeExpense:          make(map[int]int, len(data.Expenses)),
+		repo.TaggableTypeExpenseSplit:     make(map[int]int, len(data.ExpenseSplits)),
+		repo.TaggableTypeRecurrentExpense: make(map[int]int, len(data.RecurrentExpenses)),
+		repo.TaggableTypeMoodEntry:        make(map[int]int, len(data.MoodEntries)),
+		repo.TaggableTypeIncome:           make(map[int]int, len(data.Incomes)),
+		repo.TaggableTypeRecurrentIncome:  make(map[int]int, len(data.RecurrentIncomes)),
+	}
+
+	for _, e := range data.RecurrentExpenses {
+		catID, err := categoryID(e.CategoryID)
+		if err != nil {
+			return counts, err
+		}
+		frequency, anchorDay := e.rule()
+		id, err := tq.RestoreRecurrentExpense(ctx, repo.RecurrentExpense{
+			UserID:            userID,
+			CategoryID:        catID,
+			Description:       e.Description,
+			Amount:            e.Amount,
+			Period:            e.Period,
+			LastCopyCreatedAt: e.LastCopyCreatedAt,
+			OccurrenceLimit:   e.OccurrenceLimit,
+			OccurrenceCount:   e.OccurrenceCount,
+			ArchivedAt:        e.ArchivedAt,
+			CreatedAt:         e.CreatedAt,
+			UpdatedAt:         e.UpdatedAt,
+			Currency:          currencyOrHome(e.Currency),
+			Frequency:         frequency,
+			AnchorDay:         anchorDay,
+			StartsAt:          e.StartsAt,
+			EndsAt:            e.EndsAt,
+			IsEstimate:        e.IsEstimate,
+			PaymentAccountID:  paymentAccountID(e.PaymentAccountID),
+		})
+		if err != nil {
+			return counts, err
+		}
+		targetIDs[repo.TaggableTypeRecurrentExpense][e.ID] = id
+		counts.RecurrentExpenses++
+	}
+
+	for _, p := range data.PendingExpenses {
+		recurrentExpenseID, ok := targetIDs[repo.TaggableTypeRecurrentExpense][p.RecurrentExpenseID]
+		if !ok {
+			return counts, fmt.Errorf("%w: recurrent expense %d", ErrBackupDangling, p.RecurrentExpenseID)
+		}
+		_, err := tq.RestorePendingExpense(ctx, repo.PendingExpense{
+			UserID:             userID,
+			RecurrentExpenseID: recurrentExpenseID,
+			Date:               p.Date,
+			CreatedAt:          p.CreatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+	}
+
+	// Recurrent expenses go first so each generated expense can point at its
+	// restored generator. A link to one the archive does not carry is dropped.
+	recurrentExpenseID := func(oldID *int) *int {
+		if oldID == nil {
+			return nil
+		}
+		id, ok := targetIDs[repo.TaggableTypeRecurrentExpense][*oldID]
+		if !ok {
+			return nil
+		}
+
+		return &id
+	}
+
+	for _, e := range data.Expenses {
+		catID, err := categoryID(e.CategoryID)
+		if err != nil {
+			return counts, err
+		}
+		id, err := tq.RestoreExpense(ctx, repo.Expense{
+			UserID:             userID,
+			CategoryID:         catID,
+			Description:        e.Description,
+			Amount:             e.Amount,
+			Date:               e.Date,
+			CreatedAt:          e.CreatedAt,
+			UpdatedAt:          e.UpdatedAt,
+			Currency:           currencyOrHome(e.Currency),
+			RecurrentExpenseID: recurrentExpenseID(e.RecurrentExpenseID),
+			PaymentAccountID:   paymentAccountID(e.PaymentAccountID),
+		})
+		if err != nil {
+			return counts, err
+		}
+		targetIDs[repo.TaggableTypeExpense][e.ID] = id
+		counts.Expenses++
+	}
+
+	for _, split := range data.ExpenseSplits {
+		expenseID, ok := targetIDs[repo.TaggableTypeExpense][split.ExpenseID]
+		if !ok {
+			return counts, fmt.Errorf("%w: expense %d", ErrBackupDangling, split.ExpenseID)
+		}
+		catID, err := categoryID(split.CategoryID)
+		if err != nil {
+			return counts, err
+		}
+		id, err := tq.RestoreExpenseSplit(ctx, repo.ExpenseSplit{
+			UserID:     userID,
+			ExpenseID:  expenseID,
+			CategoryID: catID,
+			Amount:     split.Amount,
+			CreatedAt:  split.CreatedAt,
+			UpdatedAt:  split.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		targetIDs[repo.TaggableTypeExpenseSplit][split.ID] = id
+	}
+
+	for _, e := range data.MoodEntries {
+		id, err := tq.RestoreMoodEntry(ctx, repo.MoodEntry{
+			UserID:    userID,
+			Mood:      e.Mood,
+			Notes:     e.Notes,
+			LoggedAt:  e.LoggedAt,
+			CreatedAt: e.CreatedAt,
+			UpdatedAt: e.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		targetIDs[repo.TaggableTypeMoodEntry][e.ID] = id
+		counts.MoodEntries++
+	}
+
+	for _, ri := range data.RecurrentIncomes {
+		id, err := tq.RestoreRecurrentIncome(ctx, repo.RecurrentIncome{
+			UserID:            userID,
+			Source:            ri.Source,
+			Amount:            ri.Amount,
+			Currency:          currencyOrHome(ri.Currency),
+			Period:            ri.Period,
+			Frequency:         ri.Frequency,
+			AnchorDay:         ri.AnchorDay,
+			StartsAt:          ri.StartsAt,
+			EndsAt:            ri.EndsAt,
+			OccurrenceLimit:   ri.OccurrenceLimit,
+			OccurrenceCount:   ri.OccurrenceCount,
+			LastCopyCreatedAt: ri.LastCopyCreatedAt,
+			ArchivedAt:        ri.ArchivedAt,
+			CreatedAt:         ri.CreatedAt,
+			UpdatedAt:         ri.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		targetIDs[repo.TaggableTypeRecurrentIncome][ri.ID] = id
+		counts.RecurrentIncomes++
+	}
+
+	for _, i := range data.Incomes {
+		var recurrentIncomeID *int
+		if i.RecurrentIncomeID != nil {
+			if id, ok := targetIDs[repo.TaggableTypeRecurrentIncome][*i.RecurrentIncomeID]; ok {
+				recurrentIncomeID = &id
+			}
+		}
+
+		id, err := tq.RestoreIncome(ctx, repo.Income{
+			UserID:            userID,
+			Source:            i.Source,
+			Amount:            i.Amount,
+			Currency:          currencyOrHome(i.Currency),
+			Date:              i.Date,
+			CreatedAt:         i.CreatedAt,
+			UpdatedAt:         i.UpdatedAt,
+			RecurrentIncomeID: recurrentIncomeID,
+		})
+		if err != nil {
+			return counts, err
+		}
+		targetIDs[repo.TaggableTypeIncome][i.ID] = id
+		counts.Incomes++
+	}
+
+	for _, t := range data.Taggings {
+		tagID, ok := tagIDs[t.TagID]
+		if !ok {
+			return counts, fmt.Errorf("%w: tag %d", ErrBackupDangling, t.TagID)
+		}
+		taggableID, ok := targetIDs[t.TaggableType][t.TaggableID]
+		if !ok {
+			return counts, fmt.Errorf("%w: %s %d", ErrBackupDangling, t.TaggableType, t.TaggableID)
+		}
+
+		_, err := tq.RestoreTagging(ctx, repo.Tagging{
+			TagID:        tagID,
+			TaggableID:   taggableID,
+			TaggableType: t.TaggableType,
+			CreatedAt:    t.CreatedAt,
+			UpdatedAt:    t.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+	}
+
+	for _, b := range data.ExpenseBudgets {
+		var catID *int
+		if b.CategoryID != nil {
+			id, err := categoryID(*b.CategoryID)
+			if err != nil {
+				return counts, err
+			}
+			catID = &id
+		}
+
+		effectiveFrom := b.EffectiveFrom
+		if effectiveFrom == "" {
+			effectiveFrom = time.Unix(b.UpdatedAt, 0).UTC().Format(BudgetMonthLayout)
+		}
+
+		_, err := tq.RestoreExpenseBudget(ctx, repo.ExpenseBudget{
+			UserID:        userID,
+			CategoryID:    catID,
+			Amount:        b.Amount,
+			Rollover:      b.Rollover,
+			EffectiveFrom: effectiveFrom,
+			CreatedAt:     b.CreatedAt,
+			UpdatedAt:     b.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		counts.ExpenseBudgets++
+	}
+
+	for _, m := range data.CategoryMappings {
+		catID, err := categoryID(m.CategoryID)
+		if err != nil {
+			return counts, err
+		}
+		_, err = tq.RestoreExpenseCategoryMapping(ctx, repo.ExpenseCategoryMapping{
+			UserID:         userID,
+			CategoryID:     catID,
+			DescriptionKey: m.DescriptionKey,
+			CreatedAt:      m.CreatedAt,
+			UpdatedAt:      m.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+	}
+
+	for _, e := range data.MacroEntries {
+		_, err := tq.RestoreMacroEntry(ctx, repo.MacroEntry{
+			UserID:        userID,
+			Name:          e.Name,
+			MealType:      e.MealType,
+			Kcal:          e.Kcal,
+			ProteinG:      e.ProteinG,
+			CarbsG:        e.CarbsG,
+			FatG:          e.FatG,
+			FiberG:        e.FiberG,
+			SodiumG:       e.SodiumG,
+			SaturatedFatG: e.SaturatedFatG,
+			Date:          e.Date,
+			CreatedAt:     e.CreatedAt,
+			UpdatedAt:     e.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		counts.MacroEntries++
+	}
+
+	for _, g := range data.MacroGoals {
+		_, err := tq.RestoreMacroGoal(ctx, repo.MacroGoal{
+			UserID:        userID,
+			Kcal:          g.Kcal,
+			ProteinG:      g.ProteinG,
+			CarbsG:        g.CarbsG,
+			FatG:          g.FatG,
+			FiberG:        g.FiberG,
+			SodiumG:       g.SodiumG,
+			SaturatedFatG: g.SaturatedFatG,
+			CreatedAt:     g.CreatedAt,
+			UpdatedAt:     g.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		counts.MacroGoals++
+	}
+
+	foodIDs := make(map[int]int, len(data.Foods))
+	for _, f := range data.Foods {
+		// Archives written before foods had units give their nutrients per
+		// 100 g, which is what the defaults say.
+		if f.BaseAmount <= 0 || f.BaseUnit == "" {
+			f.BaseAmount, f.BaseUnit = DefaultFoodBaseAmount, DefaultFoodBaseUnit
+		}
+		id, err := tq.RestoreFood(ctx, repo.Food{
+			UserID:        userID,
+			Name:          f.Name,
+			Kcal:          f.Kcal,
+			ProteinG:      f.ProteinG,
+			CarbsG:        f.CarbsG,
+			FatG:          f.FatG,
+			FiberG:        f.FiberG,
+			SodiumG:       f.SodiumG,
+			SaturatedFatG: f.SaturatedFatG,
+			BaseAmount:    f.BaseAmount,
+			BaseUnit:      f.BaseUnit,
+			DensityGPerML: f.DensityGPerML,
+			Barcode:       f.Barcode,
+			CreatedAt:     f.CreatedAt,
+			UpdatedAt:     f.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		foodIDs[f.ID] = id
+		counts.Foods++
+	}
+
+	for _, fs := range data.FoodServings {
+		// Servings of trashed foods are written but their foods are not, so
+		// they are dropped like those foods' recipe ingredients.
+		foodID, ok := foodIDs[fs.FoodID]
+		if !ok {
+			continue
+		}
+		_, err := tq.RestoreFoodServing(ctx, repo.FoodServing{
+			UserID:    userID,
+			FoodID:    foodID,
+			Name:      fs.Name,
+			Amount:    fs.Amount,
+			Unit:      fs.Unit,
+			CreatedAt: fs.CreatedAt,
+			UpdatedAt: fs.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+	}
+
+	recipeIDs := make(map[int]int, len(data.Recipes))
+	for _, r := range data.Recipes {
+		id, err := tq.RestoreRecipe(ctx, repo.Recipe{
+			UserID:    userID,
+			Name:      r.Name,
+			Servings:  r.Servings,
+			CreatedAt: r.CreatedAt,
+			UpdatedAt: r.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		recipeIDs[r.ID] = id
+		counts.Recipes++
+	}
+
+	for _, i := range data.RecipeIngredients {
+		recipeID, ok := recipeIDs[i.RecipeID]
+		if !ok {
+			return counts, fmt.Errorf("%w: recipe %d", ErrBackupDangling, i.RecipeID)
+		}
+		// Trashed foods are not backed up, so an ingredient that used one is
+		// dropped rather than failing the restore.
+		foodID, ok := foodIDs[i.FoodID]
+		if !ok {
+			continue
+		}
+		_, err := tq.RestoreRecipeIngredient(ctx, repo.RecipeIngredient{
+			UserID:    userID,
+			RecipeID:  recipeID,
+			FoodID:    foodID,
+			QuantityG: i.QuantityG,
+			CreatedAt: i.CreatedAt,
+			UpdatedAt: i.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+	}
+
+	mealTemplateIDs := make(map[int]int, len(data.MealTemplates))
+	for _, m := range data.MealTemplates {
+		id, err := tq.RestoreMealTemplate(ctx, repo.MealTemplate{
+			UserID:    userID,
+			Name:      m.Name,
+			MealType:  m.MealType,
+			CreatedAt: m.CreatedAt,
+			UpdatedAt: m.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		mealTemplateIDs[m.ID] = id
+		counts.MealTemplates++
+	}
+
+	for _, i := range data.MealTemplateItems {
+		templateID, ok := mealTemplateIDs[i.MealTemplateID]
+		if !ok {
+			return counts, fmt.Errorf("%w: meal template %d", ErrBackupDangling, i.MealTemplateID)
+		}
+		// As with recipes, an item of a trashed food was not backed up with it.
+		foodID, ok := foodIDs[i.FoodID]
+		if !ok {
+			continue
+		}
+		_, err := tq.RestoreMealTemplateItem(ctx, repo.MealTemplateItem{
+			UserID:         userID,
+			MealTemplateID: templateID,
+			FoodID:         foodID,
+			Quantity:       i.Quantity,
+			Unit:           i.Unit,
+			CreatedAt:      i.CreatedAt,
+			UpdatedAt:      i.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+	}
+
+	savingsGoalIDs := make(map[int]int, len(data.SavingsGoals))
+	for _, g := range data.SavingsGoals {
+		id, err := tq.RestoreSavingsGoal(ctx, repo.SavingsGoal{
+			UserID:       userID,
+			Name:         g.Name,
+			TargetAmount: g.TargetAmount,
+			TargetDate:   g.TargetDate,
+			CreatedAt:    g.CreatedAt,
+			UpdatedAt:    g.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+		savingsGoalIDs[g.ID] = id
+		counts.SavingsGoals++
+	}
+
+	for _, c := range data.Contributions {
+		goalID, ok := savingsGoalIDs[c.SavingsGoalID]
+		if !ok {
+			return counts, fmt.Errorf("%w: savings goal %d", ErrBackupDangling, c.SavingsGoalID)
+		}
+		_, err := tq.RestoreSavingsContribution(ctx, repo.SavingsContribution{
+			UserID:        userID,
+			SavingsGoalID: goalID,
+			Amount:        c.Amount,
+			Date:          c.Date,
+			Note:          c.Note,
+			CreatedAt:     c.CreatedAt,
+			UpdatedAt:     c.UpdatedAt,
+		})
+		if err != nil {
+			return counts, err
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,542 @@
+package logic_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/prog"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	newUser := func(t *testing.T, name string) logic.User {
+		t.Helper()
+
+		return s.CreateUser(t, repo.InsertUserParams{
+			Username:     name,
+			Email:        name + "@example.com",
+			PasswordHash: []byte(name + "_hash"),
+		})
+	}
+
+	seed := func(t *testing.T, userID int) {
+		t.Helper()
+
+		category := s.CreateCategory(t, userID, "backup_category")
+		s.CreateExpense(t, userID,
+			newExpenseParams(category.ID, "backup lunch", 1250, 1735689600, []string{"food", "work"}))
+		s.CreateRecurrentExpense(t, userID, logic.RecurrentExpenseParams{
+			ExpenseBaseParams: logic.ExpenseBaseParams{
+				CategoryID:  category.ID,
+				Description: "backup rent",
+				Amount:      90000,
+			},
+			Period: 1,
+			Tags:   []string{"home"},
+		})
+		s.CreateMoodEntry(t, userID, newMoodEntryParams("Calm", "backup note", 1735689600, []string{"work"}))
+		s.CreateFood(t, userID, newFoodParams("backup oats", 380, 13, 60, 7))
+		s.CreateMacroEntry(t, userID, newMacroEntryParams("backup breakfast", 400, 20, 50, 10, 1735689600))
+		s.SaveMacroGoal(t, userID, logic.MacroGoalParams{Kcal: 2000, ProteinG: 150, CarbsG: 200, FatG: 70})
+		s.SaveExpenseBudgets(t, userID, map[int]uint64{category.ID: 50000})
+	}
+
+	backup := func(t *testing.T, userID int) []byte {
+		t.Helper()
+
+		var buf bytes.Buffer
+		require.NoError(t, s.Store.WriteBackup(ctx, &buf, userID))
+
+		return buf.Bytes()
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_write_manifest_with_versions_and_row_counts",
+			fn: func(t *testing.T) {
+				user := newUser(t, "backup_manifest")
+				seed(t, user.ID)
+
+				archive := backup(t, user.ID)
+				zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+
+				var manifest logic.BackupManifest
+				for _, f := range zr.File {
+					if f.Name != "manifest.json" {
+						continue
+					}
+					rc, err := f.Open()
+					require.NoError(t, err)
+					require.NoError(t, json.NewDecoder(rc).Decode(&manifest))
+					require.NoError(t, rc.Close())
+				}
+
+				require.Equal(t, logic.BackupFormat, manifest.Format)
+				require.Equal(t, prog.Version, manifest.AppVersion)
+				require.Positive(t, manifest.MigrationVersion)
+				require.Equal(t, 1, manifest.Rows["expenses.json"])
+				require.Equal(t, 3, manifest.Rows["tags.json"])
+				require.Equal(t, 4, manifest.Rows["taggings.json"])
+			},
+		},
+		{
+			name: "should_restore_after_delete_all",
+			fn: func(t *testing.T) {
+				user := newUser(t, "backup_roundtrip")
+				seed(t, user.ID)
+
+				before, err := s.Store.ExportExpenses(ctx, user.ID)
+				require.NoError(t, err)
+				archive := backup(t, user.ID)
+
+				require.NoError(t, s.Store.DeleteAllUserData(ctx, user.ID))
+
+				counts, err := s.Store.RestoreBackup(ctx, user.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+				require.Equal(t, logic.AccountDataCounts{
+					Expenses:          1,
+					RecurrentExpenses: 1,
+					MacroEntries:      1,
+					MacroGoals:        1,
+					ExpenseBudgets:    1,
+					Foods:             1,
+					MoodEntries:       1,
+					Tags:              3,
+				}, counts)
+
+				after, err := s.Store.ExportExpenses(ctx, user.ID)
+				require.NoError(t, err)
+				require.Len(t, after, 1)
+				require.Equal(t, before[0].Description, after[0].Description)
+				require.Equal(t, before[0].CreatedAt, after[0].CreatedAt)
+				require.Equal(t, before[0].Tags, after[0].Tags)
+				require.Equal(t, before[0].Category, after[0].Category)
+			},
+		},
+		{
+			name: "should_carry_estimates_and_their_pending_expenses",
+			fn: func(t *testing.T) {
+				source := newUser(t, "backup_pending_source")
+				target := newUser(t, "backup_pending_target")
+				category := s.CreateCategory(t, source.ID, "backup_pending_category")
+				params := newRecurrentExpenseParams(category.ID, "backup power", 7000, 1)
+				params.IsEstimate = true
+				s.CreateRecurrentExpense(t, source.ID, params)
+				_, err := s.Store.CopyDueRecurrentExpenses(ctx, time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC))
+				require.NoError(t, err)
+
+				archive := backup(t, source.ID)
+				_, err = s.Store.RestoreBackup(ctx, target.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+
+				pending, err := s.Store.FindPendingExpenses(ctx, target.ID)
+				require.NoError(t, err)
+				require.Len(t, pending, 1)
+				require.True(t, pending[0].RecurrentExpense.IsEstimate)
+				require.Equal(t, target.ID, pending[0].RecurrentExpense.UserID)
+				require.Equal(t, "backup power", pending[0].RecurrentExpense.Description)
+			},
+		},
+		{
+			name: "should_carry_every_budget_version",
+			fn: func(t *testing.T) {
+				source := newUser(t, "backup_budget_source")
+				target := newUser(t, "backup_budget_target")
+				category := s.CreateCategory(t, source.ID, "backup_budget_category")
+				require.NoError(t, s.Store.SaveExpenseBudgetsFrom(ctx, source.ID, "2026-01", []logic.ExpenseBudgetParams{
+					{CategoryID: category.ID, Amount: 30000, Rollover: true},
+					{Amount: 90000},
+				}))
+				require.NoError(t, s.Store.SaveExpenseBudgetsFrom(ctx, source.ID, "2026-04", []logic.ExpenseBudgetParams{
+					{CategoryID: category.ID, Amount: 35000, Rollover: true},
+				}))
+
+				archive := backup(t, source.ID)
+				_, err := s.Store.RestoreBackup(ctx, target.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+
+				versions, err := s.Store.FindExpenseBudgetVersions(ctx, target.ID)
+				require.NoError(t, err)
+				require.Len(t, versions, 3)
+				require.Nil(t, versions[0].CategoryID)
+				require.Equal(t, uint64(90000), versions[0].Amount)
+				require.NotNil(t, versions[1].CategoryID)
+				require.NotEqual(t, category.ID, *versions[1].CategoryID)
+				require.Equal(t, "2026-01", versions[1].EffectiveFrom)
+				require.True(t, versions[1].Rollover)
+				require.Equal(t, "2026-04", versions[2].EffectiveFrom)
+				require.Equal(t, uint64(35000), versions[2].Amount)
+			},
+		},
+		{
+			name: "should_carry_incomes_linked_to_their_recurrent_income",
+			fn: func(t *testing.T) {
+				source := newUser(t, "backup_income_source")
+				target := newUser(t, "backup_income_target")
+				startsAt := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC).Unix()
+				_, err := s.Store.CreateRecurrentIncome(ctx, source.ID, logic.RecurrentIncomeParams{
+					Source:   "backup salary",
+					Amount:   300000,
+					Period:   1,
+					StartsAt: &startsAt,
+					Tags:     []string{"payroll"},
+				})
+				require.NoError(t, err)
+				_, err = s.Store.CopyDueRecurrentIncomes(ctx, time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC))
+				require.NoError(t, err)
+
+				archive := backup(t, source.ID)
+				counts, err := s.Store.RestoreBackup(ctx, target.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+				require.Equal(t, 1, counts.Incomes)
+				require.Equal(t, 1, counts.RecurrentIncomes)
+
+				recurrentIncomes, err := s.Store.FindRecurrentIncomes(ctx, repo.QueryOptions{
+					Filters: repo.Filters{
+						FilterFields: []repo.FilterField{{Name: "user_id", Value: target.ID, Operator: "="}},
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, recurrentIncomes, 1)
+
+				incomes, err := s.Store.FindIncomes(ctx, repo.QueryOptions{
+					Filters: repo.Filters{
+						FilterFields: []repo.FilterField{{Name: "user_id", Value: target.ID, Operator: "="}},
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, incomes, 1)
+				require.Equal(t, &recurrentIncomes[0].ID, incomes[0].RecurrentIncomeID)
+
+				tags, err := s.Store.FindIncomeTags(ctx, incomes[0].ID, target.ID)
+				require.NoError(t, err)
+				require.Equal(t, []string{"payroll"}, logic.ExtractTagNames(tags))
+			},
+		},
+		{
+			name: "should_carry_payment_accounts_and_their_expenses",
+			fn: func(t *testing.T) {
+				source := newUser(t, "backup_account_source")
+				target := newUser(t, "backup_account_target")
+				category := s.CreateCategory(t, source.ID, "backup_account_category")
+				account, err := s.Store.CreatePaymentAccount(ctx, source.ID, logic.PaymentAccountParams{
+					Name:           "backup visa",
+					Kind:           "credit_card",
+					OpeningBalance: -5000,
+				})
+				require.NoError(t, err)
+				account, err = s.Store.ReconcilePaymentAccount(ctx, account.ID, source.ID, 1735689600)
+				require.NoError(t, err)
+				params := newExpenseParams(category.ID, "backup fuel", 4200, 1735689600, nil)
+				params.PaymentAccountID = &account.ID
+				s.CreateExpense(t, source.ID, params)
+
+				archive := backup(t, source.ID)
+				counts, err := s.Store.RestoreBackup(ctx, target.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+				require.Equal(t, 1, counts.PaymentAccounts)
+
+				accounts, err := s.Store.FindPaymentAccounts(ctx, target.ID)
+				require.NoError(t, err)
+				require.Len(t, accounts, 1)
+				require.NotEqual(t, account.ID, accounts[0].ID)
+				require.Equal(t, int64(-5000), accounts[0].OpeningBalance)
+				require.Equal(t, account.ReconciledThrough, accounts[0].ReconciledThrough)
+
+				ledger, err := s.Store.FindPaymentAccountLedger(ctx, accounts[0].ID, target.ID)
+				require.NoError(t, err)
+				require.Len(t, ledger.Entries, 1)
+				require.Equal(t, int64(-9200), ledger.Balance)
+			},
+		},
+		{
+			name: "should_carry_savings_goals_and_their_contributions",
+			fn: func(t *testing.T) {
+				source := newUser(t, "backup_savings_source")
+				target := newUser(t, "backup_savings_target")
+				targetDate := time.Date(2027, time.June, 1, 0, 0, 0, 0, time.UTC).Unix()
+				goal, err := s.Store.CreateSavingsGoal(ctx, source.ID, logic.SavingsGoalParams{
+					Name:         "backup house deposit",
+					TargetAmount: 2000000,
+					TargetDate:   targetDate,
+				})
+				require.NoError(t, err)
+				_, err = s.Store.AddSavingsContribution(ctx, goal.ID, source.ID, logic.SavingsContributionParams{
+					Amount: 150000,
+					Date:   time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC).Unix(),
+					Note:   "backup first deposit",
+				})
+				require.NoError(t, err)
+
+				archive := backup(t, source.ID)
+				counts, err := s.Store.RestoreBackup(ctx, target.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+				require.Equal(t, 1, counts.SavingsGoals)
+
+				goals, err := s.Store.FindSavingsGoals(ctx, target.ID, time.Now())
+				require.NoError(t, err)
+				require.Len(t, goals, 1)
+				require.NotEqual(t, goal.ID, goals[0].Goal.ID)
+				require.Equal(t, targetDate, goals[0].Goal.TargetDate)
+				require.Equal(t, uint64(150000), goals[0].Saved)
+				require.Len(t, goals[0].Contributions, 1)
+				require.Equal(t, "backup first deposit", goals[0].Contributions[0].Note)
+			},
+		},
+		{
+			name: "should_carry_recipes_and_their_ingredients",
+			fn: func(t *testing.T) {
+				source := newUser(t, "backup_recipe_source")
+				target := newUser(t, "backup_recipe_target")
+				rice, err := s.Store.CreateFood(ctx, source.ID, logic.FoodParams{Name: "backup rice", Kcal: 130})
+				require.NoError(t, err)
+				beans, err := s.Store.CreateFood(ctx, source.ID, logic.FoodParams{Name: "backup beans", Kcal: 120})
+				require.NoError(t, err)
+				recipe, err := s.Store.CreateRecipe(ctx, source.ID, logic.RecipeParams{
+					Name:     "backup rice and beans",
+					Servings: 4,
+				})
+				require.NoError(t, err)
+				for _, food := range []repo.Food{rice, beans} {
+					_, err = s.Store.SaveRecipeIngredient(ctx, recipe.ID, source.ID, logic.RecipeIngredientParams{
+						FoodID:    food.ID,
+						QuantityG: 200,
+					})
+					require.NoError(t, err)
+				}
+				// A trashed food is not backed up, so its ingredient is left out.
+				_, err = s.Store.DeleteFood(ctx, beans.ID, source.ID)
+				require.NoError(t, err)
+
+				archive := backup(t, source.ID)
+				counts, err := s.Store.RestoreBackup(ctx, target.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+				require.Equal(t, 1, counts.Recipes)
+
+				recipes, err := s.Store.FindRecipes(ctx, target.ID)
+				require.NoError(t, err)
+				require.Len(t, recipes, 1)
+				require.NotEqual(t, recipe.ID, recipes[0].Recipe.ID)
+				require.InDelta(t, 4.0, recipes[0].Recipe.Servings, 0.001)
+				require.Len(t, recipes[0].Ingredients, 1)
+				require.Equal(t, "backup rice", recipes[0].Ingredients[0].Food.Name)
+				require.InDelta(t, 65.0, recipes[0].PerServing.Kcal, 0.001)
+			},
+		},
+		{
+			name: "should_carry_food_units_servings_and_barcodes",
+			fn: func(t *testing.T) {
+				source := newUser(t, "backup_serving_source")
+				target := newUser(t, "backup_serving_target")
+				milk, err := s.Store.CreateFood(ctx, source.ID, logic.FoodParams{
+					Name:          "backup milk",
+					Kcal:          122,
+					BaseAmount:    250,
+					BaseUnit:      logic.UnitMilliliter,
+					DensityGPerML: 1.03,
+					Barcode:       "4006381333931",
+				})
+				require.NoError(t, err)
+				_, err = s.Store.AddFoodServing(ctx, milk, logic.FoodServingParams{
+					Name:   "glass",
+					Amount: 200,
+					Unit:   logic.UnitGram,
+				})
+				require.NoError(t, err)
+
+				archive := backup(t, source.ID)
+				_, err = s.Store.RestoreBackup(ctx, target.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+
+				foods, err := s.Store.FindFoods(ctx, repo.QueryOptions{
+					Filters: repo.Filters{
+						FilterFields: []repo.FilterField{{Name: "user_id", Value: target.ID, Operator: "="}},
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, foods, 1)
+				require.InDelta(t, 250.0, foods[0].BaseAmount, 0.001)
+				require.Equal(t, logic.UnitMilliliter, foods[0].BaseUnit)
+				require.NotNil(t, foods[0].DensityGPerML)
+				require.InDelta(t, 1.03, *foods[0].DensityGPerML, 0.001)
+				require.NotNil(t, foods[0].Barcode)
+				require.Equal(t, "4006381333931", *foods[0].Barcode)
+
+				servings, err := s.Store.FindFoodServings(ctx, foods[0].ID, target.ID)
+				require.NoError(t, err)
+				require.Len(t, servings, 1)
+				require.Equal(t, "glass", servings[0].Name)
+				require.InDelta(t, 200.0, servings[0].Amount, 0.001)
+				require.Equal(t, logic.UnitGram, servings[0].Unit)
+			},
+		},
+		{
+			name: "should_carry_meal_templates_and_their_items",
+			fn: func(t *testing.T) {
+				source := newUser(t, "backup_meal_source")
+				target := newUser(t, "backup_meal_target")
+				yogurt, err := s.Store.CreateFood(ctx, source.ID, logic.FoodParams{Name: "backup yogurt", Kcal: 60})
+				require.NoError(t, err)
+				snack, err := s.Store.CreateMealTemplate(ctx, source.ID, logic.MealTemplateParams{
+					Name:     "backup snack",
+					MealType: "snack",
+				})
+				require.NoError(t, err)
+				_, err = s.Store.SaveMealTemplateItem(ctx, snack.ID, source.ID, logic.MealTemplateItemParams{
+					FoodID:   yogurt.ID,
+					Quantity: 0.15,
+					Unit:     logic.UnitKilogram,
+				})
+				require.NoError(t, err)
+
+				archive := backup(t, source.ID)
+				_, err = s.Store.RestoreBackup(ctx, target.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+
+				templates, err := s.Store.FindMealTemplates(ctx, target.ID)
+				require.NoError(t, err)
+				require.Len(t, templates, 1)
+				require.Equal(t, "backup snack", templates[0].Template.Name)
+				require.Equal(t, "snack", templates[0].Template.MealType)
+				require.Len(t, templates[0].Items, 1)
+				require.Equal(t, "backup yogurt", templates[0].Items[0].Food.Name)
+				require.Equal(t, target.ID, templates[0].Items[0].Food.UserID)
+				require.Equal(t, logic.UnitKilogram, templates[0].Items[0].Unit)
+				require.InDelta(t, 90.0, templates[0].Total.Kcal, 0.001)
+			},
+		},
+		{
+			name: "should_carry_expense_splits_and_their_tags",
+			fn: func(t *testing.T) {
+				source := newUser(t, "backup_split_source")
+				target := newUser(t, "backup_split_target")
+				pantry := s.CreateCategory(t, source.ID, "backup_split_pantry")
+				cleaning := s.CreateCategory(t, source.ID, "backup_split_cleaning")
+				params := newExpenseParams(pantry.ID, "backup supermarket", 3000, 1735689600, nil)
+				params.Splits = []logic.ExpenseSplitParams{
+					{CategoryID: pantry.ID, Amount: 2200, Tags: []string{"weekly"}},
+					{CategoryID: cleaning.ID, Amount: 800},
+				}
+				s.CreateExpense(t, source.ID, params)
+
+				archive := backup(t, source.ID)
+				_, err := s.Store.RestoreBackup(ctx, target.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+
+				exported, err := s.Store.ExportExpenses(ctx, target.ID)
+				require.NoError(t, err)
+				require.Len(t, exported, 1)
+				require.Len(t, exported[0].Splits, 2)
+				require.Equal(t, "backup_split_pantry", exported[0].Splits[0].Category.Name)
+				require.Equal(t, []string{"weekly"}, exported[0].Splits[0].Tags)
+				require.Equal(t, "backup_split_cleaning", exported[0].Splits[1].Category.Name)
+				require.Equal(t, uint64(800), exported[0].Splits[1].Amount)
+			},
+		},
+		{
+			name: "should_remap_ids_into_another_account",
+			fn: func(t *testing.T) {
+				source := newUser(t, "backup_source")
+				target := newUser(t, "backup_target")
+				seed(t, source.ID)
+
+				archive := backup(t, source.ID)
+				_, err := s.Store.RestoreBackup(ctx, target.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+
+				entries, err := s.Store.ListMoodEntries(ctx, repo.QueryOptions{
+					Filters: repo.Filters{
+						FilterFields: []repo.FilterField{{Name: "user_id", Value: target.ID, Operator: "="}},
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, entries, 1)
+
+				tags, err := s.Store.FindMoodEntryTags(ctx, entries[0].ID, target.ID)
+				require.NoError(t, err)
+				require.Len(t, tags, 1)
+				require.Equal(t, "work", tags[0].Name)
+				require.Equal(t, target.ID, tags[0].UserID)
+
+				exported, err := s.Store.ExportExpenses(ctx, target.ID)
+				require.NoError(t, err)
+				require.Len(t, exported, 1)
+				require.Equal(t, "backup_category", exported[0].Category.Name)
+
+				sourceCounts, err := s.Store.FindAccountDataCounts(ctx, source.ID)
+				require.NoError(t, err)
+				require.Equal(t, 3, sourceCounts.Tags)
+			},
+		},
+		{
+			name: "should_refuse_an_account_with_data",
+			fn: func(t *testing.T) {
+				user := newUser(t, "backup_not_empty")
+				seed(t, user.ID)
+
+				archive := backup(t, user.ID)
+				_, err := s.Store.RestoreBackup(ctx, user.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.ErrorIs(t, err, logic.ErrRestoreNotEmpty)
+
+				counts, err := s.Store.FindAccountDataCounts(ctx, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, 1, counts.Expenses)
+			},
+		},
+		{
+			name: "should_refuse_an_archive_from_a_newer_schema",
+			fn: func(t *testing.T) {
+				user := newUser(t, "backup_newer")
+
+				archive := newBackupArchive(t, logic.BackupManifest{
+					Format:           logic.BackupFormat,
+					MigrationVersion: 99991231000000,
+				})
+				_, err := s.Store.RestoreBackup(ctx, user.ID, bytes.NewReader(archive), int64(len(archive)))
+				require.ErrorIs(t, err, logic.ErrBackupNewerSchema)
+			},
+		},
+		{
+			name: "should_reject_a_file_that_is_not_an_archive",
+			fn: func(t *testing.T) {
+				user := newUser(t, "backup_garbage")
+
+				garbage := []byte("description,amount\nlunch,12")
+				_, err := s.Store.RestoreBackup(ctx, user.ID, bytes.NewReader(garbage), int64(len(garbage)))
+				require.ErrorIs(t, err, logic.ErrBackupArchive)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+// newBackupArchive builds an archive holding only a manifest, which restores
+// as an empty account.
+func newBackupArchive(t *testing.T, manifest logic.BackupManifest) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("manifest.json")
+	require.NoError(t, err)
+	require.NoError(t, json.NewEncoder(f).Encode(manifest))
+	require.NoError(t, zw.Close())
+
+	return buf.Bytes()
+}
@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// DefaultCashFlowMonths is how many months the cash-flow report covers when
+// none are asked for.
+const DefaultCashFlowMonths = 12
+
+// CashFlowPeriods are the month counts the cash-flow report offers.
+func CashFlowPeriods() []int {
+	return []int{6, 12, 24}
+}
+
+// CashFlow is a user's income against their expenses for each calendar month
+// from From up to Until, current month included. Every amount is in the home
+// currency.
+type CashFlow struct {
+	From     time.Time
+	Until    time.Time
+	Months   []CashFlowMonth
+	Income   uint64
+	Expenses uint64
+	Net      int64
+	// HasIncome and SavingsRate mean what they do on CashFlowMonth, over
+	// the whole period.
+	HasIncome   bool
+	SavingsRate int
+}
+
+type CashFlowMonth struct {
+	Month    string
+	Income   uint64
+	Expenses uint64
+	Net      int64
+	// SavingsRate is Net as a whole percentage of Income, negative when the
+	// month spent more than came in. It is only meaningful when HasIncome.
+	HasIncome   bool
+	SavingsRate int
+}
+
+// FindCashFlow builds the report for the months calendar months ending with
+// the one now falls in, oldest first. Months with no income and no expenses
+// are kept, so gaps show up as gaps. Trashed expenses are left out, as they
+// are from every other total.
+func (s *Store) FindCashFlow(
+	ctx context.Context,
+	userID int,
+	homeCurrency string,
+	now time.Time,
+	months int,
+) (CashFlow, error) {
+	today := utcDay(now.Unix())
+	until := time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	flow := CashFlow{From: until.AddDate(0, -max(months, 1), 0), Until: until}
+
+	filters := repo.Filters{
+		FilterFields: []repo.FilterField{
+			{Name: "user_id", Value: userID, Operator: "="},
+			{Name: "date", Value: flow.From.Unix(), Operator: ">="},
+			{Name: "date", Value: flow.Until.Unix(), Operator: "<"},
+		},
+		Connector: "AND",
+	}
+
+	incomeTotals, err := s.queries.SelectIncomeMonthTotals(ctx, filters, homeCurrency)
+	if err != nil {
+		return flow, err
+	}
+
+	expenseTotals, err := s.queries.SelectExpensesCategoryMonthTotals(ctx, filters, homeCurrency, false)
+	if err != nil {
+		return flow, err
+	}
+
+	incomeByMonth := make(map[string]uint64, len(incomeTotals))
+	for _, t := range incomeTotals {
+		incomeByMonth[t.Month] = t.Total
+	}
+
+	expensesByMonth := map[string]uint64{}
+	for _, t := range expenseTotals {
+		expensesByMonth[t.Month] += t.Total
+	}
+
+	for month := flow.From; month.Before(flow.Until); month = month.AddDate(0, 1, 0) {
+		key := month.Format(ForecastMonthLayout)
+		m := newCashFlowMonth(key, incomeByMonth[key], expensesByMonth[key])
+
+		flow.Months = append(flow.Months, m)
+		flow.Income += m.Income
+		flow.Expenses += m.Expenses
+	}
+
+	total := newCashFlowMonth("", flow.Income, flow.Expenses)
+	flow.Net = total.Net
+	flow.HasIncome = total.HasIncome
+	flow.SavingsRate = total.SavingsRate
+
+	return flow, nil
+}
+
+func newCashFlowMonth(month string, income, expenses uint64) CashFlowMonth {
+	m := CashFlowMonth{
+		Month:    month,
+		Income:   income,
+		Expenses: expenses,
+		Net:      int64(income) - int64(expenses),
+	}
+
+	if income > 0 {
+		m.HasIncome = true
+		m.SavingsRate = int(math.Round(float64(m.Net) * 100 / float64(income)))
+	}
+
+	return m
+}
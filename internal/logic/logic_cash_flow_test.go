@@ -0,0 +1,103 @@
+package logic_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCashFlow(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	now := time.Date(2027, time.March, 15, 12, 0, 0, 0, time.UTC)
+	january := time.Date(2027, time.January, 10, 0, 0, 0, 0, time.UTC).Unix()
+	february := time.Date(2027, time.February, 10, 0, 0, 0, 0, time.UTC).Unix()
+	march := time.Date(2027, time.March, 10, 0, 0, 0, 0, time.UTC).Unix()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_set_income_against_expenses_per_month",
+			fn: func(t *testing.T) {
+				user := s.CreateUser(t, repo.InsertUserParams{
+					Username:     "cash_flow_user_1",
+					Email:        "cash_flow_user_1@example.com",
+					PasswordHash: []byte("cash_flow_user_hash_1"),
+				})
+				category := s.CreateCategory(t, user.ID, "cash flow category 1")
+
+				_, err := s.Store.CreateIncome(ctx, user.ID, logic.IncomeParams{
+					Source: "Salary",
+					Amount: 100000,
+					Date:   january,
+				})
+				require.NoError(t, err)
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "cash flow rent", 40000, january, nil))
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "cash flow food", 20000, february, nil))
+
+				flow, err := s.Store.FindCashFlow(ctx, user.ID, "USD", now, 3)
+				require.NoError(t, err)
+				require.Len(t, flow.Months, 3)
+
+				jan, feb, mar := flow.Months[0], flow.Months[1], flow.Months[2]
+				require.Equal(t, "2027-01", jan.Month)
+				require.Equal(t, uint64(100000), jan.Income)
+				require.Equal(t, uint64(40000), jan.Expenses)
+				require.Equal(t, int64(60000), jan.Net)
+				require.True(t, jan.HasIncome)
+				require.Equal(t, 60, jan.SavingsRate)
+
+				require.Equal(t, int64(-20000), feb.Net)
+				require.False(t, feb.HasIncome)
+
+				require.Equal(t, "2027-03", mar.Month)
+				require.Zero(t, mar.Net)
+
+				require.Equal(t, uint64(100000), flow.Income)
+				require.Equal(t, uint64(60000), flow.Expenses)
+				require.Equal(t, int64(40000), flow.Net)
+				require.Equal(t, 40, flow.SavingsRate)
+			},
+		},
+		{
+			name: "should_convert_foreign_income_into_the_home_currency",
+			fn: func(t *testing.T) {
+				user := s.CreateUser(t, repo.InsertUserParams{
+					Username:     "cash_flow_user_2",
+					Email:        "cash_flow_user_2@example.com",
+					PasswordHash: []byte("cash_flow_user_hash_2"),
+				})
+
+				_, err := s.Store.ImportExchangeRates(ctx, strings.NewReader(
+					"date,base_currency,quote_currency,rate\n2027-03-01,GBP,USD,1.25\n",
+				))
+				require.NoError(t, err)
+
+				_, err = s.Store.CreateIncome(ctx, user.ID, logic.IncomeParams{
+					Source:   "London client",
+					Amount:   10000,
+					Currency: "GBP",
+					Date:     march,
+				})
+				require.NoError(t, err)
+
+				flow, err := s.Store.FindCashFlow(ctx, user.ID, "USD", now, 1)
+				require.NoError(t, err)
+				require.Len(t, flow.Months, 1)
+				require.Equal(t, uint64(12500), flow.Months[0].Income)
+				require.Equal(t, 100, flow.Months[0].SavingsRate)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -2,28 +2,302 @@ package logic
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/ad9311/ninete/internal/prog"
 	"github.com/ad9311/ninete/internal/repo"
 )
 
-func (s *Store) CreateCategory(ctx context.Context, name string) (repo.Category, error) {
+// CategoryParams is a category as submitted by the categories page.
+type CategoryParams struct {
+	Name string `validate:"required,max=50"`
+}
+
+// DefaultCategoryNames is the set every new account starts with. Each user owns
+// their copies and may rename, archive or merge them freely.
+func DefaultCategoryNames() []string {
+	return []string{
+		"Housing",
+		"Transportation",
+		"Groceries",
+		"Food Delivery",
+		"Healthcare",
+		"Personal Care",
+		"Entertainment",
+		"Shopping",
+		"Online Shopping",
+		"Travel",
+		"Financial",
+		"Pets",
+		"Taxes",
+		"Subscriptions",
+		"Other",
+		"Utilities",
+		"Restaurants",
+		"Hobbies",
+	}
+}
+
+func (s *Store) CreateCategory(ctx context.Context, userID int, params CategoryParams) (repo.Category, error) {
 	var category repo.Category
 
-	uid := prog.ToLowerCamel(name)
-	category, err := s.queries.InsertCategory(ctx, name, uid)
+	uid, err := s.validateCategoryParams(&params)
 	if err != nil {
 		return category, err
 	}
 
+	category, err = s.queries.InsertCategory(ctx, repo.InsertCategoryParams{
+		UserID: userID,
+		Name:   params.Name,
+		UID:    uid,
+	})
+	if err != nil {
+		if repo.IsUniqueViolation(err) {
+			return category, ErrCategoryNameTaken
+		}
+
+		return category, err
+	}
+
 	return category, nil
 }
 
-func (s *Store) FindCategories(ctx context.Context) ([]repo.Category, error) {
-	categories, err := s.queries.SelectCategories(ctx)
+// FindCategories returns every category of the user, archived ones included,
+// with each parent followed by its children so pickers read as a tree.
+// Pickers for new rows are expected to skip the archived ones themselves.
+func (s *Store) FindCategories(ctx context.Context, userID int) ([]repo.Category, error) {
+	categories, err := s.queries.SelectCategoriesByUser(ctx, userID)
 	if err != nil {
 		return categories, err
 	}
 
-	return categories, nil
+	childrenByParentID := map[int][]repo.Category{}
+	for _, c := range categories {
+		if c.ParentID != nil {
+			childrenByParentID[*c.ParentID] = append(childrenByParentID[*c.ParentID], c)
+		}
+	}
+
+	tree := make([]repo.Category, 0, len(categories))
+	for _, c := range categories {
+		if c.ParentID == nil {
+			tree = append(tree, c)
+			tree = append(tree, childrenByParentID[c.ID]...)
+		}
+	}
+
+	return tree, nil
+}
+
+// CategoryPathNames names each category the way lists show it: a child reads
+// "Parent › Child", a top-level category just its name.
+func CategoryPathNames(categories []repo.Category) map[int]string {
+	nameByID := make(map[int]string, len(categories))
+	for _, c := range categories {
+		nameByID[c.ID] = c.Name
+	}
+
+	pathByID := make(map[int]string, len(categories))
+	for _, c := range categories {
+		pathByID[c.ID] = c.Name
+		if c.ParentID != nil {
+			pathByID[c.ID] = nameByID[*c.ParentID] + " › " + c.Name
+		}
+	}
+
+	return pathByID
+}
+
+func (s *Store) FindCategory(ctx context.Context, id, userID int) (repo.Category, error) {
+	category, err := s.queries.SelectCategory(ctx, id, userID)
+	if err != nil {
+		return category, err
+	}
+
+	return category, nil
+}
+
+func (s *Store) FindCategoryUsage(ctx context.Context, userID int) (map[int]repo.CategoryUsage, error) {
+	usage, err := s.queries.SelectCategoryUsageByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usageByID := make(map[int]repo.CategoryUsage, len(usage))
+	for _, u := range usage {
+		usageByID[u.CategoryID] = u
+	}
+
+	return usageByID, nil
+}
+
+// RenameCategory changes the name and, with it, the uid backups match on.
+func (s *Store) RenameCategory(
+	ctx context.Context,
+	id, userID int,
+	params CategoryParams,
+) (repo.Category, error) {
+	var category repo.Category
+
+	uid, err := s.validateCategoryParams(&params)
+	if err != nil {
+		return category, err
+	}
+
+	category, err = s.queries.UpdateCategory(ctx, repo.UpdateCategoryParams{
+		ID:     id,
+		UserID: userID,
+		Name:   params.Name,
+		UID:    uid,
+	})
+	if err != nil {
+		if repo.IsUniqueViolation(err) {
+			return category, ErrCategoryNameTaken
+		}
+
+		return category, err
+	}
+
+	return category, nil
+}
+
+// ArchiveCategory hides a category from the pickers for new rows. Everything
+// already filed under it keeps it.
+func (s *Store) ArchiveCategory(ctx context.Context, id, userID int) (repo.Category, error) {
+	now := time.Now().Unix()
+
+	return s.queries.UpdateCategoryArchivedAt(ctx, id, userID, &now)
+}
+
+func (s *Store) UnarchiveCategory(ctx context.Context, id, userID int) (repo.Category, error) {
+	return s.queries.UpdateCategoryArchivedAt(ctx, id, userID, nil)
+}
+
+// SetCategoryParent files a category under parentID, or makes it top-level
+// again when parentID is nil. Only one level is allowed: the parent must be
+// top-level itself and the category must have no children of its own.
+func (s *Store) SetCategoryParent(ctx context.Context, id, userID int, parentID *int) (repo.Category, error) {
+	var category repo.Category
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		if _, err := tq.SelectCategory(ctx, id, userID); err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			if *parentID == id {
+				return ErrCategoryParentSelf
+			}
+
+			parent, err := tq.SelectCategory(ctx, *parentID, userID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrUnknownCategory
+			}
+			if err != nil {
+				return err
+			}
+			if parent.ParentID != nil {
+				return ErrCategoryTooDeep
+			}
+
+			children, err := tq.CountCategoryChildren(ctx, id)
+			if err != nil {
+				return err
+			}
+			if children > 0 {
+				return ErrCategoryTooDeep
+			}
+		}
+
+		var err error
+		category, err = tq.UpdateCategoryParentID(ctx, id, userID, parentID)
+
+		return err
+	})
+
+	return category, err
+}
+
+// MergeCategory folds sourceID into targetID: expenses, trashed ones included,
+// recurrent expenses, budgets, remembered quick-add mappings and child
+// categories all move to the target, and the source is deleted.
+func (s *Store) MergeCategory(ctx context.Context, userID, sourceID, targetID int) error {
+	if sourceID == targetID {
+		return ErrCategoryMergeSelf
+	}
+
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		if _, err := tq.SelectCategory(ctx, sourceID, userID); err != nil {
+			return err
+		}
+		target, err := tq.SelectCategory(ctx, targetID, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUnknownCategory
+		}
+		if err != nil {
+			return err
+		}
+
+		// The source's children would end up two levels down under a target
+		// that is itself someone else's child.
+		if target.ParentID != nil && *target.ParentID != sourceID {
+			children, err := tq.CountCategoryChildren(ctx, sourceID)
+			if err != nil {
+				return err
+			}
+			if children > 0 {
+				return ErrCategoryTooDeep
+			}
+		}
+
+		return tq.MergeCategory(ctx, userID, sourceID, targetID)
+	})
+}
+
+// createDefaultCategoriesTx gives a new account its own copy of
+// DefaultCategoryNames.
+func createDefaultCategoriesTx(ctx context.Context, tq *repo.TxQueries, userID int) error {
+	for _, name := range DefaultCategoryNames() {
+		_, err := tq.InsertCategory(ctx, repo.InsertCategoryParams{
+			UserID: userID,
+			Name:   name,
+			UID:    prog.ToLowerCamel(name),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkCategoryTx rejects a category id the user does not own. Without it the
+// foreign key alone would let a row point at another account's category.
+func checkCategoryTx(ctx context.Context, tq *repo.TxQueries, userID, categoryID int) error {
+	if _, err := tq.SelectCategory(ctx, categoryID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUnknownCategory
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) validateCategoryParams(params *CategoryParams) (string, error) {
+	params.Name = strings.TrimSpace(params.Name)
+	if err := s.ValidateStruct(*params); err != nil {
+		return "", err
+	}
+
+	uid := prog.ToLowerCamel(params.Name)
+	if uid == "" {
+		return "", ErrCategoryNameInvalid
+	}
+
+	return uid, nil
 }
@@ -1,8 +1,11 @@
 package logic_test
 
 import (
+	"database/sql"
 	"testing"
 
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
 	"github.com/ad9311/ninete/internal/spec"
 	"github.com/stretchr/testify/require"
 )
@@ -11,6 +14,16 @@ func TestCreateCategory(t *testing.T) {
 	s := spec.New(t)
 	ctx := t.Context()
 
+	newUser := func(t *testing.T, name string) logic.User {
+		t.Helper()
+
+		return s.CreateUser(t, repo.InsertUserParams{
+			Username:     name,
+			Email:        name + "@example.com",
+			PasswordHash: []byte(name + "_hash"),
+		})
+	}
+
 	cases := []struct {
 		name string
 		fn   func(*testing.T)
@@ -18,32 +31,65 @@ func TestCreateCategory(t *testing.T) {
 		{
 			name: "should_create_category",
 			fn: func(t *testing.T) {
-				category, err := s.Store.CreateCategory(ctx, "new category 1")
+				user := newUser(t, "category_user_1")
+
+				category, err := s.Store.CreateCategory(ctx, user.ID, logic.CategoryParams{Name: " new category 1 "})
 				require.NoError(t, err)
 				require.Positive(t, category.ID)
+				require.Equal(t, user.ID, category.UserID)
 				require.Equal(t, "new category 1", category.Name)
 				require.Equal(t, "newCategory1", category.UID)
+				require.Nil(t, category.ArchivedAt)
 				require.NotZero(t, category.CreatedAt)
 				require.NotZero(t, category.UpdatedAt)
 			},
 		},
 		{
-			name: "should_fail_with_duplicate_name",
+			name: "should_fail_with_duplicate_name_regardless_of_case",
 			fn: func(t *testing.T) {
-				_, err := s.Store.CreateCategory(ctx, "new category 2")
+				user := newUser(t, "category_user_2")
+
+				_, err := s.Store.CreateCategory(ctx, user.ID, logic.CategoryParams{Name: "new category 2"})
 				require.NoError(t, err)
 
-				_, err = s.Store.CreateCategory(ctx, "new category 2")
-				require.Error(t, err)
+				_, err = s.Store.CreateCategory(ctx, user.ID, logic.CategoryParams{Name: "New Category 2"})
+				require.ErrorIs(t, err, logic.ErrCategoryNameTaken)
 			},
 		},
 		{
 			name: "should_fail_with_duplicate_uid",
 			fn: func(t *testing.T) {
-				_, err := s.Store.CreateCategory(ctx, "new-category-3")
+				user := newUser(t, "category_user_3")
+
+				_, err := s.Store.CreateCategory(ctx, user.ID, logic.CategoryParams{Name: "new-category-3"})
+				require.NoError(t, err)
+
+				_, err = s.Store.CreateCategory(ctx, user.ID, logic.CategoryParams{Name: "new category 3"})
+				require.ErrorIs(t, err, logic.ErrCategoryNameTaken)
+			},
+		},
+		{
+			name: "should_allow_the_same_name_for_another_user",
+			fn: func(t *testing.T) {
+				user := newUser(t, "category_user_4")
+				otherUser := newUser(t, "category_user_5")
+
+				_, err := s.Store.CreateCategory(ctx, user.ID, logic.CategoryParams{Name: "new category 4"})
+				require.NoError(t, err)
+
+				_, err = s.Store.CreateCategory(ctx, otherUser.ID, logic.CategoryParams{Name: "new category 4"})
 				require.NoError(t, err)
+			},
+		},
+		{
+			name: "should_fail_with_a_name_without_letters_or_digits",
+			fn: func(t *testing.T) {
+				user := newUser(t, "category_user_6")
 
-				_, err = s.Store.CreateCategory(ctx, "new category 3")
+				_, err := s.Store.CreateCategory(ctx, user.ID, logic.CategoryParams{Name: "---"})
+				require.ErrorIs(t, err, logic.ErrCategoryNameInvalid)
+
+				_, err = s.Store.CreateCategory(ctx, user.ID, logic.CategoryParams{Name: "   "})
 				require.Error(t, err)
 			},
 		},
@@ -63,38 +109,43 @@ func TestFindCategories(t *testing.T) {
 		fn   func(*testing.T)
 	}{
 		{
-			name: "should_find_created_categories",
+			name: "should_give_a_new_user_the_default_categories",
 			fn: func(t *testing.T) {
-				categoryOne := s.CreateCategory(t, "new category 4")
-				categoryTwo := s.CreateCategory(t, "new category 5")
+				user := s.CreateUser(t, repo.InsertUserParams{
+					Username:     "find_category_user_1",
+					Email:        "find_category_user_1@example.com",
+					PasswordHash: []byte("find_category_user_hash_1"),
+				})
 
-				categories, err := s.Store.FindCategories(ctx)
+				categories, err := s.Store.FindCategories(ctx, user.ID)
 				require.NoError(t, err)
-				require.NotEmpty(t, categories)
-
-				var foundOne bool
-				var foundTwo bool
 
+				names := make([]string, 0, len(categories))
 				for _, category := range categories {
-					if category.ID == categoryOne.ID {
-						foundOne = true
-					}
-					if category.ID == categoryTwo.ID {
-						foundTwo = true
-					}
+					require.Equal(t, user.ID, category.UserID)
+					names = append(names, category.Name)
 				}
-
-				require.True(t, foundOne)
-				require.True(t, foundTwo)
+				require.ElementsMatch(t, logic.DefaultCategoryNames(), names)
 			},
 		},
 		{
-			name: "should_return_categories_sorted_by_name",
+			name: "should_return_only_the_users_categories_sorted_by_name",
 			fn: func(t *testing.T) {
-				aCategory := s.CreateCategory(t, "new category 6")
-				bCategory := s.CreateCategory(t, "new category 7")
+				user := s.CreateUser(t, repo.InsertUserParams{
+					Username:     "find_category_user_2",
+					Email:        "find_category_user_2@example.com",
+					PasswordHash: []byte("find_category_user_hash_2"),
+				})
+				otherUser := s.CreateUser(t, repo.InsertUserParams{
+					Username:     "find_category_user_3",
+					Email:        "find_category_user_3@example.com",
+					PasswordHash: []byte("find_category_user_hash_3"),
+				})
+				aCategory := s.CreateCategory(t, user.ID, "new category 6")
+				bCategory := s.CreateCategory(t, user.ID, "new category 7")
+				otherCategory := s.CreateCategory(t, otherUser.ID, "new category 8")
 
-				categories, err := s.Store.FindCategories(ctx)
+				categories, err := s.Store.FindCategories(ctx, user.ID)
 				require.NoError(t, err)
 
 				indexesByID := map[int]int{}
@@ -103,6 +154,237 @@ func TestFindCategories(t *testing.T) {
 				}
 
 				require.Less(t, indexesByID[aCategory.ID], indexesByID[bCategory.ID])
+				require.NotContains(t, indexesByID, otherCategory.ID)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestManageCategory(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	newUser := func(t *testing.T, name string) logic.User {
+		t.Helper()
+
+		return s.CreateUser(t, repo.InsertUserParams{
+			Username:     name,
+			Email:        name + "@example.com",
+			PasswordHash: []byte(name + "_hash"),
+		})
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_rename_and_recompute_the_uid",
+			fn: func(t *testing.T) {
+				user := newUser(t, "manage_category_1")
+				category := s.CreateCategory(t, user.ID, "manage old name")
+
+				renamed, err := s.Store.RenameCategory(ctx, category.ID, user.ID, logic.CategoryParams{Name: "Manage New"})
+				require.NoError(t, err)
+				require.Equal(t, "Manage New", renamed.Name)
+				require.Equal(t, "manageNew", renamed.UID)
+			},
+		},
+		{
+			name: "should_refuse_to_rename_onto_an_existing_name",
+			fn: func(t *testing.T) {
+				user := newUser(t, "manage_category_2")
+				category := s.CreateCategory(t, user.ID, "manage first")
+				s.CreateCategory(t, user.ID, "manage second")
+
+				_, err := s.Store.RenameCategory(ctx, category.ID, user.ID, logic.CategoryParams{Name: "Manage Second"})
+				require.ErrorIs(t, err, logic.ErrCategoryNameTaken)
+			},
+		},
+		{
+			name: "should_not_rename_another_users_category",
+			fn: func(t *testing.T) {
+				owner := newUser(t, "manage_category_3")
+				intruder := newUser(t, "manage_category_4")
+				category := s.CreateCategory(t, owner.ID, "manage owned")
+
+				_, err := s.Store.RenameCategory(ctx, category.ID, intruder.ID, logic.CategoryParams{Name: "Stolen"})
+				require.ErrorIs(t, err, sql.ErrNoRows)
+			},
+		},
+		{
+			name: "should_archive_and_unarchive_keeping_the_expenses",
+			fn: func(t *testing.T) {
+				user := newUser(t, "manage_category_5")
+				category := s.CreateCategory(t, user.ID, "manage archived")
+				expense := s.CreateExpense(t, user.ID,
+					newExpenseParams(category.ID, "manage archived lunch", 1200, 1735689600, nil))
+
+				archived, err := s.Store.ArchiveCategory(ctx, category.ID, user.ID)
+				require.NoError(t, err)
+				require.NotNil(t, archived.ArchivedAt)
+
+				found, err := s.Store.FindExpense(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, category.ID, found.CategoryID)
+
+				unarchived, err := s.Store.UnarchiveCategory(ctx, category.ID, user.ID)
+				require.NoError(t, err)
+				require.Nil(t, unarchived.ArchivedAt)
+			},
+		},
+		{
+			name: "should_merge_every_row_into_the_target",
+			fn: func(t *testing.T) {
+				user := newUser(t, "manage_category_6")
+				source := s.CreateCategory(t, user.ID, "manage merge source")
+				target := s.CreateCategory(t, user.ID, "manage merge target")
+
+				expense := s.CreateExpense(t, user.ID,
+					newExpenseParams(source.ID, "manage merge lunch", 1500, 1735689600, nil))
+				trashed := s.CreateExpense(t, user.ID,
+					newExpenseParams(source.ID, "manage merge dinner", 2500, 1735689600, nil))
+				_, err := s.Store.DeleteExpense(ctx, trashed.ID, user.ID)
+				require.NoError(t, err)
+				recurrent := s.CreateRecurrentExpense(t, user.ID,
+					newRecurrentExpenseParams(source.ID, "manage merge rent", 90000, 1))
+				s.SaveExpenseBudgets(t, user.ID, map[int]uint64{source.ID: 3000, target.ID: 2000})
+				_, err = s.Store.CreateQuickExpense(ctx, user.ID, source.ID, logic.QuickExpenseParsed{
+					Description: "manage merge coffee",
+					Amount:      300,
+					Date:        1735689600,
+				}, logic.DuplicateActionAsk)
+				require.NoError(t, err)
+
+				require.NoError(t, s.Store.MergeCategory(ctx, user.ID, source.ID, target.ID))
+
+				_, err = s.Store.FindCategory(ctx, source.ID, user.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+
+				found, err := s.Store.FindExpense(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, target.ID, found.CategoryID)
+
+				_, err = s.Store.RestoreFromTrash(ctx, repo.TrashKindExpense, trashed.ID, user.ID)
+				require.NoError(t, err)
+				found, err = s.Store.FindExpense(ctx, trashed.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, target.ID, found.CategoryID)
+
+				foundRecurrent, err := s.Store.FindRecurrentExpense(ctx, recurrent.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, target.ID, foundRecurrent.CategoryID)
+
+				budgets, err := s.Store.FindExpenseBudgets(ctx, user.ID)
+				require.NoError(t, err)
+				require.Len(t, budgets, 1)
+				require.NotNil(t, budgets[0].CategoryID)
+				require.Equal(t, target.ID, *budgets[0].CategoryID)
+				require.Equal(t, uint64(5000), budgets[0].Amount)
+
+				categoryID, remembered, err := s.Store.ResolveQuickExpenseCategory(ctx, user.ID, "manage merge coffee")
+				require.NoError(t, err)
+				require.True(t, remembered)
+				require.Equal(t, target.ID, categoryID)
+			},
+		},
+		{
+			name: "should_refuse_to_merge_with_another_users_category",
+			fn: func(t *testing.T) {
+				user := newUser(t, "manage_category_7")
+				otherUser := newUser(t, "manage_category_8")
+				source := s.CreateCategory(t, user.ID, "manage merge mine")
+				foreign := s.CreateCategory(t, otherUser.ID, "manage merge theirs")
+
+				err := s.Store.MergeCategory(ctx, user.ID, source.ID, foreign.ID)
+				require.ErrorIs(t, err, logic.ErrUnknownCategory)
+
+				err = s.Store.MergeCategory(ctx, user.ID, foreign.ID, source.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+
+				err = s.Store.MergeCategory(ctx, user.ID, source.ID, source.ID)
+				require.ErrorIs(t, err, logic.ErrCategoryMergeSelf)
+
+				_, err = s.Store.FindCategory(ctx, foreign.ID, otherUser.ID)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "should_nest_one_level_deep_only",
+			fn: func(t *testing.T) {
+				user := newUser(t, "manage_category_11")
+				otherUser := newUser(t, "manage_category_12")
+				parent := s.CreateCategory(t, user.ID, "manage nest parent")
+				child := s.CreateCategory(t, user.ID, "manage nest child")
+				other := s.CreateCategory(t, user.ID, "manage nest other")
+				foreign := s.CreateCategory(t, otherUser.ID, "manage nest foreign")
+
+				nested, err := s.Store.SetCategoryParent(ctx, child.ID, user.ID, &parent.ID)
+				require.NoError(t, err)
+				require.Equal(t, &parent.ID, nested.ParentID)
+
+				_, err = s.Store.SetCategoryParent(ctx, other.ID, user.ID, &child.ID)
+				require.ErrorIs(t, err, logic.ErrCategoryTooDeep)
+
+				_, err = s.Store.SetCategoryParent(ctx, parent.ID, user.ID, &other.ID)
+				require.ErrorIs(t, err, logic.ErrCategoryTooDeep)
+
+				_, err = s.Store.SetCategoryParent(ctx, other.ID, user.ID, &other.ID)
+				require.ErrorIs(t, err, logic.ErrCategoryParentSelf)
+
+				_, err = s.Store.SetCategoryParent(ctx, other.ID, user.ID, &foreign.ID)
+				require.ErrorIs(t, err, logic.ErrUnknownCategory)
+
+				categories, err := s.Store.FindCategories(ctx, user.ID)
+				require.NoError(t, err)
+				indexesByID := map[int]int{}
+				for i, category := range categories {
+					indexesByID[category.ID] = i
+				}
+				require.Equal(t, indexesByID[parent.ID]+1, indexesByID[child.ID])
+
+				cleared, err := s.Store.SetCategoryParent(ctx, child.ID, user.ID, nil)
+				require.NoError(t, err)
+				require.Nil(t, cleared.ParentID)
+			},
+		},
+		{
+			name: "should_move_the_children_when_merging_a_parent",
+			fn: func(t *testing.T) {
+				user := newUser(t, "manage_category_13")
+				source := s.CreateCategory(t, user.ID, "manage merge parent source")
+				target := s.CreateCategory(t, user.ID, "manage merge parent target")
+				child := s.CreateCategory(t, user.ID, "manage merge parent child")
+				nestedTarget := s.CreateCategory(t, user.ID, "manage merge parent nested")
+				_, err := s.Store.SetCategoryParent(ctx, child.ID, user.ID, &source.ID)
+				require.NoError(t, err)
+				_, err = s.Store.SetCategoryParent(ctx, nestedTarget.ID, user.ID, &target.ID)
+				require.NoError(t, err)
+
+				err = s.Store.MergeCategory(ctx, user.ID, source.ID, nestedTarget.ID)
+				require.ErrorIs(t, err, logic.ErrCategoryTooDeep)
+
+				require.NoError(t, s.Store.MergeCategory(ctx, user.ID, source.ID, target.ID))
+
+				found, err := s.Store.FindCategory(ctx, child.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, &target.ID, found.ParentID)
+			},
+		},
+		{
+			name: "should_refuse_an_expense_in_another_users_category",
+			fn: func(t *testing.T) {
+				user := newUser(t, "manage_category_9")
+				otherUser := newUser(t, "manage_category_10")
+				foreign := s.CreateCategory(t, otherUser.ID, "manage foreign")
+
+				_, err := s.Store.CreateExpense(ctx, user.ID,
+					newExpenseParams(foreign.ID, "manage foreign lunch", 1000, 1735689600, nil))
+				require.ErrorIs(t, err, logic.ErrUnknownCategory)
 			},
 		},
 	}
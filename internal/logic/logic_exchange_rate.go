@@ -0,0 +1,142 @@
+package logic
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// exchangeRatesHeader is the header ImportExchangeRates expects, in order. A
+// row reads "one base_currency is worth rate quote_currency on date".
+var exchangeRatesHeader = []string{ //nolint:gochecknoglobals // static lookup table
+	"date", "base_currency", "quote_currency", "rate",
+}
+
+type ExchangeRateParams struct {
+	BaseCurrency  string  `validate:"required,iso4217,nefield=QuoteCurrency"`
+	QuoteCurrency string  `validate:"required,iso4217"`
+	Date          int64   `validate:"required"`
+	Rate          float64 `validate:"gt=0"`
+}
+
+// ImportExchangeRates loads a CSV of rates into the shared exchange_rates
+// table. Dates are YYYY-MM-DD and stored as UTC midnight, the same day
+// boundary expense dates use. A rate already stored for the pair and day is
+// replaced. Nothing is saved unless every row is valid.
+func (s *Store) ImportExchangeRates(ctx context.Context, r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return 0, ErrExchangeRatesEmpty
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrExchangeRatesCSV, err)
+	}
+
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	if len(header) != len(exchangeRatesHeader) {
+		return 0, ErrExchangeRatesHeader
+	}
+	for i, name := range exchangeRatesHeader {
+		if !strings.EqualFold(strings.TrimSpace(header[i]), name) {
+			return 0, ErrExchangeRatesHeader
+		}
+	}
+
+	var rows []ExchangeRateParams
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrExchangeRatesCSV, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		params, err := s.parseExchangeRateRecord(record)
+		if err != nil {
+			return 0, fmt.Errorf("%w: line %d: %w", ErrExchangeRatesRow, line, err)
+		}
+
+		rows = append(rows, params)
+	}
+
+	if len(rows) == 0 {
+		return 0, ErrExchangeRatesEmpty
+	}
+
+	err = s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		for _, row := range rows {
+			_, err := tq.UpsertExchangeRate(ctx, repo.UpsertExchangeRateParams{
+				BaseCurrency:  row.BaseCurrency,
+				QuoteCurrency: row.QuoteCurrency,
+				Date:          row.Date,
+				Rate:          row.Rate,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(rows), nil
+}
+
+// SetHomeCurrency changes the currency the user's totals are converted to.
+// Stored amounts are left as they are.
+func (s *Store) SetHomeCurrency(ctx context.Context, userID int, currency string) error {
+	params := struct {
+		Currency string `validate:"required,iso4217"`
+	}{Currency: NormalizeCurrency(currency)}
+
+	if err := s.ValidateStruct(params); err != nil {
+		return err
+	}
+
+	return s.queries.UpdateUserHomeCurrency(ctx, userID, params.Currency)
+}
+
+func (s *Store) parseExchangeRateRecord(record []string) (ExchangeRateParams, error) {
+	var params ExchangeRateParams
+
+	date, err := time.Parse(time.DateOnly, record[0])
+	if err != nil {
+		return params, ErrExchangeRateDate
+	}
+
+	// ParseFloat also reads "Inf" and "NaN", which would pass the gt=0 rule
+	// and turn every converted total into nonsense.
+	rate, err := strconv.ParseFloat(record[3], 64)
+	if err != nil || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return params, ErrExchangeRateValue
+	}
+
+	params = ExchangeRateParams{
+		BaseCurrency:  NormalizeCurrency(record[1]),
+		QuoteCurrency: NormalizeCurrency(record[2]),
+		Date:          date.Unix(),
+		Rate:          rate,
+	}
+
+	if err := s.ValidateStruct(params); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
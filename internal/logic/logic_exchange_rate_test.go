@@ -0,0 +1,142 @@
+package logic_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportExchangeRates(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_import_rows_and_replace_an_existing_rate",
+			fn: func(t *testing.T) {
+				count, err := s.Store.ImportExchangeRates(ctx, strings.NewReader(
+					"\ufeffDate,Base_Currency,Quote_Currency,Rate\n2025-03-01,gbp,usd,1.25\n2025-03-02,GBP,USD,1.30\n",
+				))
+				require.NoError(t, err)
+				require.Equal(t, 2, count)
+
+				count, err = s.Store.ImportExchangeRates(ctx, strings.NewReader(
+					"date,base_currency,quote_currency,rate\n2025-03-01,GBP,USD,1.50\n",
+				))
+				require.NoError(t, err)
+				require.Equal(t, 1, count)
+
+				user := s.CreateUser(t, repo.InsertUserParams{
+					Username:     "exchange_rate_user_1",
+					Email:        "exchange_rate_user_1@example.com",
+					PasswordHash: []byte("exchange_rate_hash_1"),
+				})
+				category := s.CreateCategory(t, user.ID, "exchange rate category 1")
+				params := newExpenseParams(category.ID, "fx_import_exp", 1000, 1740787200, nil) // 2025-03-01
+				params.Currency = "GBP"
+				s.CreateExpense(t, user.ID, params)
+
+				totals, err := s.Store.FindExpensesCategoryTotals(ctx, repo.Filters{
+					FilterFields: []repo.FilterField{
+						{Name: "user_id", Value: user.ID, Operator: "="},
+					},
+				}, "USD", false)
+				require.NoError(t, err)
+				require.Len(t, totals, 1)
+				require.Equal(t, uint64(1500), totals[0].Total)
+			},
+		},
+		{
+			name: "should_reject_a_wrong_header",
+			fn: func(t *testing.T) {
+				_, err := s.Store.ImportExchangeRates(ctx, strings.NewReader("date,from,to\n2025-03-01,GBP,USD\n"))
+				require.ErrorIs(t, err, logic.ErrExchangeRatesHeader)
+			},
+		},
+		{
+			name: "should_reject_an_empty_file",
+			fn: func(t *testing.T) {
+				_, err := s.Store.ImportExchangeRates(ctx, strings.NewReader("date,base_currency,quote_currency,rate\n"))
+				require.ErrorIs(t, err, logic.ErrExchangeRatesEmpty)
+			},
+		},
+		{
+			name: "should_save_nothing_when_a_row_is_invalid",
+			fn: func(t *testing.T) {
+				_, err := s.Store.ImportExchangeRates(ctx, strings.NewReader(
+					"date,base_currency,quote_currency,rate\n2025-04-01,CHF,USD,1.1\n2025-04-02,CHF,XYZ,1.1\n",
+				))
+				require.ErrorIs(t, err, logic.ErrExchangeRatesRow)
+				require.ErrorContains(t, err, "line 3")
+
+				_, err = s.Store.ImportExchangeRates(ctx, strings.NewReader(
+					"date,base_currency,quote_currency,rate\n04/01/2025,CHF,USD,1.1\n",
+				))
+				require.ErrorIs(t, err, logic.ErrExchangeRateDate)
+
+				_, err = s.Store.ImportExchangeRates(ctx, strings.NewReader(
+					"date,base_currency,quote_currency,rate\n2025-04-01,CHF,USD,0\n",
+				))
+				require.ErrorIs(t, err, logic.ErrExchangeRatesRow)
+			},
+		},
+		{
+			name: "should_reject_a_rate_that_is_not_a_finite_number",
+			fn: func(t *testing.T) {
+				for _, rate := range []string{"Inf", "+Inf", "-Inf", "NaN"} {
+					_, err := s.Store.ImportExchangeRates(ctx, strings.NewReader(
+						"date,base_currency,quote_currency,rate\n2025-04-01,CHF,USD,"+rate+"\n",
+					))
+					require.ErrorIs(t, err, logic.ErrExchangeRateValue, rate)
+				}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestSetHomeCurrency(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "home_currency_user_1",
+		Email:        "home_currency_user_1@example.com",
+		PasswordHash: []byte("home_currency_hash_1"),
+	})
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_store_a_normalized_code",
+			fn: func(t *testing.T) {
+				require.NoError(t, s.Store.SetHomeCurrency(ctx, user.ID, " eur "))
+
+				found, err := s.Store.FindUser(ctx, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, "EUR", found.HomeCurrency)
+			},
+		},
+		{
+			name: "should_reject_an_unknown_code",
+			fn: func(t *testing.T) {
+				require.Error(t, s.Store.SetHomeCurrency(ctx, user.ID, "XYZ"))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -10,6 +10,9 @@ type ExpenseParams struct {
 	ExpenseBaseParams
 	Date int64    `validate:"required,gt=0"`
 	Tags []string `validate:"-"`
+	// Splits optionally spread the amount over several categories. When set
+	// they must add up to Amount; see checkExpenseSplits.
+	Splits []ExpenseSplitParams `validate:"dive"`
 }
 
 func (s *Store) FindExpenses(ctx context.Context, opts repo.QueryOptions) ([]repo.Expense, error) {
@@ -48,44 +51,26 @@ func (s *Store) FindExpenseTags(ctx context.Context, expenseID, userID int) ([]r
 	return tags, nil
 }
 
+// CreateExpense saves params even when they match an existing expense.
 func (s *Store) CreateExpense(ctx context.Context, userID int, params ExpenseParams) (repo.Expense, error) {
-	var expense repo.Expense
-
-	if err := s.ValidateStruct(params); err != nil {
-		return expense, err
-	}
-
-	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
-		var txErr error
-
-		expense, txErr = tq.InsertExpense(ctx, repo.InsertExpenseParams{
-			UserID:      userID,
-			CategoryID:  params.CategoryID,
-			Description: params.Description,
-			Amount:      params.Amount,
-			Date:        params.Date,
-		})
-		if txErr != nil {
-			return txErr
-		}
-
-		return s.replaceTagsTx(ctx, tq, repo.TaggableTypeExpense, expense.ID, userID, params.Tags)
-	})
-	if err != nil {
-		return expense, err
-	}
-
-	return expense, nil
+	return s.CreateExpenseChecked(ctx, userID, params, DuplicateActionForce)
 }
 
 func (s *Store) UpdateExpense(ctx context.Context, id, userID int, params ExpenseParams) (repo.Expense, error) {
 	var expense repo.Expense
 
-	if err := s.ValidateStruct(params); err != nil {
+	if err := s.validateExpenseParams(&params); err != nil {
 		return expense, err
 	}
 
 	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		if err := checkCategoryTx(ctx, tq, userID, params.CategoryID); err != nil {
+			return err
+		}
+		if err := checkPaymentAccountTx(ctx, tq, userID, params.PaymentAccountID); err != nil {
+			return err
+		}
+
 		var txErr error
 
 		expense, txErr = tq.UpdateExpense(ctx, userID, repo.UpdateExpenseParams{
@@ -94,12 +79,20 @@ func (s *Store) UpdateExpense(ctx context.Context, id, userID int, params Expens
 			Description: params.Description,
 			Amount:      params.Amount,
 			Date:        params.Date,
+			Currency:    params.Currency,
+
+			PaymentAccountID: params.PaymentAccountID,
 		})
 		if txErr != nil {
 			return txErr
 		}
 
-		return s.replaceTagsTx(ctx, tq, repo.TaggableTypeExpense, expense.ID, userID, params.Tags)
+		txErr = s.replaceTagsTx(ctx, tq, repo.TaggableTypeExpense, expense.ID, userID, params.Tags)
+		if txErr != nil {
+			return txErr
+		}
+
+		return s.replaceExpenseSplitsTx(ctx, tq, expense, userID, params.Splits)
 	})
 	if err != nil {
 		return expense, err
@@ -108,6 +101,17 @@ func (s *Store) UpdateExpense(ctx context.Context, id, userID int, params Expens
 	return expense, nil
 }
 
+// validateExpenseParams normalizes the currency before validating, since the
+// iso4217 rule is case-sensitive and forms send the code as typed.
+func (s *Store) validateExpenseParams(params *ExpenseParams) error {
+	params.Currency = NormalizeCurrency(params.Currency)
+	if err := s.ValidateStruct(*params); err != nil {
+		return err
+	}
+
+	return checkExpenseSplits(params.Amount, params.Splits)
+}
+
 func (s *Store) DeleteExpense(ctx context.Context, id, userID int) (int, error) {
 	i, err := s.queries.DeleteExpense(ctx, id, userID)
 	if err != nil {
@@ -123,9 +127,14 @@ func (s *Store) DeleteAllExpenses(ctx context.Context, userID int) error {
 	})
 }
 
+// FindExpensesCategoryTotals totals expenses per category in homeCurrency,
+// converting each at the stored rate for its date. With rollUp a child's
+// expenses count toward its parent instead.
 func (s *Store) FindExpensesCategoryTotals(
 	ctx context.Context,
 	filters repo.Filters,
+	homeCurrency string,
+	rollUp bool,
 ) ([]repo.ExpenseCategoryTotal, error) {
-	return s.queries.SelectExpensesCategoryTotals(ctx, filters)
+	return s.queries.SelectExpensesCategoryTotals(ctx, filters, homeCurrency, rollUp)
 }
@@ -2,60 +2,182 @@ package logic
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"github.com/ad9311/ninete/internal/repo"
 )
 
-// ExpenseBudgetParams is one category's budget as submitted by the budgets
-// form. An Amount of zero means "no budget" and deletes any stored row, so it
-// carries no lower bound — being unsigned, it already has one.
+// BudgetMonthLayout is how budget months are written, matching
+// strftime('%Y-%m') in the category month totals.
+const BudgetMonthLayout = "2006-01"
+
+// ExpenseBudgetParams is one budget as submitted by the budgets form. A zero
+// CategoryID is the whole-month budget across every category. An Amount of
+// zero means "no budget" and ends any stored one from the month it is saved
+// for, so it carries no lower bound — being unsigned, it already has one.
 type ExpenseBudgetParams struct {
-	CategoryID int `validate:"required"`
+	CategoryID int `validate:"gte=0"`
+	Amount     uint64
+	Rollover   bool
+}
+
+// ExpenseBudgetMonth is one budget measured over one calendar month. Amount is
+// the version in force that month and Carry what the month before left over
+// under rollover, negative when it was overspent.
+type ExpenseBudgetMonth struct {
+	CategoryID *int
+	Month      string
 	Amount     uint64
+	Rollover   bool
+	Carry      int64
+	Spent      uint64
 }
 
+// Available is what the month may spend: its amount plus the carry.
+func (m ExpenseBudgetMonth) Available() int64 {
+	return int64(m.Amount) + m.Carry //nolint:gosec // cent amount, far below int64 max
+}
+
+// FindExpenseBudgets returns the budgets in force this month.
 func (s *Store) FindExpenseBudgets(ctx context.Context, userID int) ([]repo.ExpenseBudget, error) {
-	return s.queries.SelectExpenseBudgetsByUser(ctx, userID)
+	return s.queries.SelectExpenseBudgetsByUser(ctx, userID, currentBudgetMonth())
+}
+
+// FindExpenseBudgetVersions returns every version of every budget, grouped by
+// budget and oldest first within each.
+func (s *Store) FindExpenseBudgetVersions(ctx context.Context, userID int) ([]repo.ExpenseBudget, error) {
+	return s.queries.SelectExpenseBudgetVersionsByUser(ctx, userID)
 }
 
+// FindExpensesCategoryMonthTotals is FindExpensesCategoryTotals split by
+// calendar month.
 func (s *Store) FindExpensesCategoryMonthTotals(
 	ctx context.Context,
 	filters repo.Filters,
+	homeCurrency string,
+	rollUp bool,
 ) ([]repo.ExpenseCategoryMonthTotal, error) {
-	return s.queries.SelectExpensesCategoryMonthTotals(ctx, filters)
+	return s.queries.SelectExpensesCategoryMonthTotals(ctx, filters, homeCurrency, rollUp)
+}
+
+// MeasureExpenseBudgets measures every budget over months, which must be
+// BudgetMonthLayout strings, oldest first. A month is left out for a budget
+// with nothing in force in it. Spending is read back as far as the oldest
+// rollover budget began, since each month's carry depends on every month
+// before it.
+func (s *Store) MeasureExpenseBudgets(
+	ctx context.Context,
+	userID int,
+	homeCurrency string,
+	months []string,
+) ([]ExpenseBudgetMonth, error) {
+	return s.measureExpenseBudgetsWith(ctx, userID, homeCurrency, months, nil)
+}
+
+// measureExpenseBudgetsWith is MeasureExpenseBudgets counting projected, per
+// category and month, as spent on top of the stored expenses. The forecast
+// passes its bills so a rollover budget carries what they leave over.
+func (s *Store) measureExpenseBudgetsWith(
+	ctx context.Context,
+	userID int,
+	homeCurrency string,
+	months []string,
+	projected []repo.ExpenseCategoryMonthTotal,
+) ([]ExpenseBudgetMonth, error) {
+	if len(months) == 0 {
+		return nil, nil
+	}
+
+	versions, err := s.queries.SelectExpenseBudgetVersionsByUser(ctx, userID)
+	if err != nil || len(versions) == 0 {
+		return nil, err
+	}
+
+	categories, err := s.queries.SelectCategoriesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	isParent := make(map[int]bool, len(categories))
+	parentByID := make(map[int]int, len(categories))
+	for _, c := range categories {
+		if c.ParentID != nil {
+			isParent[*c.ParentID] = true
+			parentByID[c.ID] = *c.ParentID
+		}
+	}
+
+	filters, err := budgetTotalsFilters(userID, versions, months)
+	if err != nil {
+		return nil, err
+	}
+
+	leafTotals, err := s.queries.SelectExpensesCategoryMonthTotals(ctx, filters, homeCurrency, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rolledUpTotals, err := s.queries.SelectExpensesCategoryMonthTotals(ctx, filters, homeCurrency, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range projected {
+		leafTotals = append(leafTotals, t)
+		if parentID, ok := parentByID[t.CategoryID]; ok {
+			t.CategoryID = parentID
+		}
+		rolledUpTotals = append(rolledUpTotals, t)
+	}
+
+	return measureExpenseBudgets(versions, isParent, leafTotals, rolledUpTotals, months), nil
 }
 
-// SaveExpenseBudgets writes every submitted category in one transaction: a
-// non-zero amount upserts, a zero amount deletes. The form always posts every
-// category, so a field the user cleared arrives here as zero and removes the
-// budget rather than leaving a stale one behind.
+// SaveExpenseBudgets saves category budgets from the current month on,
+// without rollover. See SaveExpenseBudgetsFrom.
 func (s *Store) SaveExpenseBudgets(ctx context.Context, userID int, amountByCategoryID map[int]uint64) error {
 	params := make([]ExpenseBudgetParams, 0, len(amountByCategoryID))
-
 	for categoryID, amount := range amountByCategoryID {
-		p := ExpenseBudgetParams{CategoryID: categoryID, Amount: amount}
+		params = append(params, ExpenseBudgetParams{CategoryID: categoryID, Amount: amount})
+	}
+
+	return s.SaveExpenseBudgetsFrom(ctx, userID, currentBudgetMonth(), params)
+}
+
+// SaveExpenseBudgetsFrom writes every submitted budget as the version in force
+// from month on, in one transaction. Months before it keep the amounts they
+// were measured against, and versions already saved for later months still
+// take over when they start.
+//
+// The form always posts every budget, so a budget that did not change is left
+// alone rather than given a new version, and a field the user cleared arrives
+// here as zero and ends the budget rather than leaving a stale one behind.
+func (s *Store) SaveExpenseBudgetsFrom(
+	ctx context.Context,
+	userID int,
+	month string,
+	params []ExpenseBudgetParams,
+) error {
+	if _, err := time.Parse(BudgetMonthLayout, month); err != nil {
+		return ErrBudgetMonth
+	}
+
+	for _, p := range params {
 		if err := s.ValidateStruct(p); err != nil {
 			return err
 		}
-
-		params = append(params, p)
 	}
 
 	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
-		for _, p := range params {
-			if p.Amount == 0 {
-				if err := tq.DeleteExpenseBudget(ctx, userID, p.CategoryID); err != nil {
-					return err
-				}
-
-				continue
-			}
+		versions, err := tq.SelectExpenseBudgetVersionsByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		versionsByKey := groupBudgetVersions(versions)
 
-			if _, err := tq.UpsertExpenseBudget(ctx, repo.UpsertExpenseBudgetParams{
-				UserID:     userID,
-				CategoryID: p.CategoryID,
-				Amount:     p.Amount,
-			}); err != nil {
+		for _, p := range params {
+			if err := saveExpenseBudgetTx(ctx, tq, userID, month, p, versionsByKey[p.CategoryID]); err != nil {
 				return err
 			}
 		}
@@ -69,3 +191,230 @@ func (s *Store) DeleteAllExpenseBudgets(ctx context.Context, userID int) error {
 		return tq.DeleteAllExpenseBudgetsByUser(ctx, userID)
 	})
 }
+
+// saveExpenseBudgetTx writes one budget's version for month. versions are the
+// budget's stored versions, oldest first.
+func saveExpenseBudgetTx(
+	ctx context.Context,
+	tq *repo.TxQueries,
+	userID int,
+	month string,
+	p ExpenseBudgetParams,
+	versions []repo.ExpenseBudget,
+) error {
+	var categoryID *int
+	if p.CategoryID != 0 {
+		categoryID = &p.CategoryID
+	}
+
+	current, ok := budgetVersionAt(versions, month)
+	if ok && current.Amount == p.Amount && (current.Rollover == p.Rollover || p.Amount == 0) {
+		return nil
+	}
+
+	if p.Amount == 0 {
+		// Ending a budget only needs a version when one was in force the
+		// month before; otherwise dropping this month's is enough.
+		previous, ok := budgetVersionAt(versions, previousBudgetMonth(month))
+		if !ok || previous.Amount == 0 {
+			return tq.DeleteExpenseBudget(ctx, userID, categoryID, month)
+		}
+	}
+
+	if categoryID != nil {
+		if err := checkCategoryTx(ctx, tq, userID, *categoryID); err != nil {
+			return err
+		}
+	}
+
+	_, err := tq.UpsertExpenseBudget(ctx, repo.UpsertExpenseBudgetParams{
+		UserID:        userID,
+		CategoryID:    categoryID,
+		Amount:        p.Amount,
+		Rollover:      p.Rollover && p.Amount > 0,
+		EffectiveFrom: month,
+	})
+
+	return err
+}
+
+// measureExpenseBudgets walks each budget month by month from its first
+// version, or from the first of months when that is earlier, carrying each
+// rollover month's remainder into the next. A month before a budget's first
+// version is measured against that version but never carries: rollover
+// starts when the budget does.
+func measureExpenseBudgets(
+	versions []repo.ExpenseBudget,
+	isParent map[int]bool,
+	leafTotals, rolledUpTotals []repo.ExpenseCategoryMonthTotal,
+	months []string,
+) []ExpenseBudgetMonth {
+	spent := budgetSpent(isParent, leafTotals, rolledUpTotals)
+
+	wanted := make(map[string]bool, len(months))
+	for _, m := range months {
+		wanted[m] = true
+	}
+	last := months[len(months)-1]
+
+	versionsByKey := groupBudgetVersions(versions)
+	keys := make([]int, 0, len(versionsByKey))
+	for key := range versionsByKey {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	var out []ExpenseBudgetMonth
+	for _, key := range keys {
+		group := versionsByKey[key]
+		first := group[0]
+
+		start, err := time.Parse(BudgetMonthLayout, min(first.EffectiveFrom, months[0]))
+		if err != nil {
+			continue
+		}
+
+		var carry int64
+		for t := start; t.Format(BudgetMonthLayout) <= last; t = t.AddDate(0, 1, 0) {
+			m := t.Format(BudgetMonthLayout)
+			v, ok := budgetVersionAt(group, m)
+			if !ok {
+				v = first
+			}
+
+			month := ExpenseBudgetMonth{
+				CategoryID: v.CategoryID,
+				Month:      m,
+				Amount:     v.Amount,
+				Rollover:   v.Rollover,
+				Spent:      spent[key][m],
+			}
+			if v.Amount > 0 {
+				month.Carry = carry
+			}
+
+			if wanted[m] && v.Amount > 0 {
+				out = append(out, month)
+			}
+
+			if ok && v.Amount > 0 && v.Rollover {
+				carry = month.Available() - int64(month.Spent) //nolint:gosec // cent amount, far below int64 max
+			} else {
+				carry = 0
+			}
+		}
+	}
+
+	return out
+}
+
+// budgetSpent keys month totals by budget: a parent category's budget caps
+// everything under it, so parents take the rolled-up totals and every other
+// category its own. Key zero, the whole-month budget, takes every category.
+func budgetSpent(
+	isParent map[int]bool,
+	leafTotals, rolledUpTotals []repo.ExpenseCategoryMonthTotal,
+) map[int]map[string]uint64 {
+	spent := make(map[int]map[string]uint64)
+	add := func(key int, month string, total uint64) {
+		if spent[key] == nil {
+			spent[key] = make(map[string]uint64)
+		}
+		spent[key][month] += total
+	}
+
+	for _, t := range leafTotals {
+		add(0, t.Month, t.Total)
+		if !isParent[t.CategoryID] {
+			add(t.CategoryID, t.Month, t.Total)
+		}
+	}
+	for _, t := range rolledUpTotals {
+		if isParent[t.CategoryID] {
+			add(t.CategoryID, t.Month, t.Total)
+		}
+	}
+
+	return spent
+}
+
+// budgetTotalsFilters scopes the month totals to the span a measurement
+// reads: from the first of months, or the first month of the earliest
+// rollover budget when that is earlier, to the end of the last of months.
+func budgetTotalsFilters(userID int, versions []repo.ExpenseBudget, months []string) (repo.Filters, error) {
+	from := months[0]
+	for _, v := range versions {
+		if v.Rollover && v.EffectiveFrom < from {
+			from = v.EffectiveFrom
+		}
+	}
+
+	start, err := time.Parse(BudgetMonthLayout, from)
+	if err != nil {
+		return repo.Filters{}, ErrBudgetMonth
+	}
+
+	end, err := time.Parse(BudgetMonthLayout, months[len(months)-1])
+	if err != nil {
+		return repo.Filters{}, ErrBudgetMonth
+	}
+
+	return repo.Filters{
+		FilterFields: []repo.FilterField{
+			{Name: "user_id", Value: userID, Operator: "="},
+			{Name: "date", Value: start.Unix(), Operator: ">="},
+			{Name: "date", Value: end.AddDate(0, 1, 0).Unix(), Operator: "<"},
+		},
+		Connector: "AND",
+	}, nil
+}
+
+// groupBudgetVersions keys versions by category, zero for the whole-month
+// budget, keeping each group oldest first.
+func groupBudgetVersions(versions []repo.ExpenseBudget) map[int][]repo.ExpenseBudget {
+	byKey := make(map[int][]repo.ExpenseBudget)
+	for _, v := range versions {
+		key := budgetKey(v.CategoryID)
+		byKey[key] = append(byKey[key], v)
+	}
+
+	for _, group := range byKey {
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].EffectiveFrom < group[j].EffectiveFrom
+		})
+	}
+
+	return byKey
+}
+
+// budgetVersionAt returns the latest of versions, oldest first, starting on or
+// before month.
+func budgetVersionAt(versions []repo.ExpenseBudget, month string) (repo.ExpenseBudget, bool) {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].EffectiveFrom <= month {
+			return versions[i], true
+		}
+	}
+
+	return repo.ExpenseBudget{}, false
+}
+
+func budgetKey(categoryID *int) int {
+	if categoryID == nil {
+		return 0
+	}
+
+	return *categoryID
+}
+
+// previousBudgetMonth returns the month before month, which the caller has
+// already parsed.
+func previousBudgetMonth(month string) string {
+	t, _ := time.Parse(BudgetMonthLayout, month)
+
+	return t.AddDate(0, -1, 0).Format(BudgetMonthLayout)
+}
+
+func currentBudgetMonth() string {
+	return time.Now().UTC().Format(BudgetMonthLayout)
+}
@@ -2,7 +2,9 @@ package logic_test
 
 import (
 	"testing"
+	"time"
 
+	"github.com/ad9311/ninete/internal/logic"
 	"github.com/ad9311/ninete/internal/repo"
 	"github.com/ad9311/ninete/internal/spec"
 	"github.com/stretchr/testify/require"
@@ -14,8 +16,9 @@ func TestExpenseBudgets(t *testing.T) {
 
 	user := s.CreateAuthUser(t, "budget_logic_user", "budget_logic_user@example.com", "budget_password_1")
 	other := s.CreateAuthUser(t, "budget_other_user", "budget_other_user@example.com", "budget_password_2")
-	category := s.CreateCategory(t, "budget logic category")
-	otherCategory := s.CreateCategory(t, "budget logic category two")
+	category := s.CreateCategory(t, user.ID, "budget logic category")
+	otherCategory := s.CreateCategory(t, user.ID, "budget logic category two")
+	foreignCategory := s.CreateCategory(t, other.ID, "budget logic category")
 
 	budgetFor := func(t *testing.T, userID, categoryID int) (repo.ExpenseBudget, bool) {
 		t.Helper()
@@ -24,7 +27,7 @@ func TestExpenseBudgets(t *testing.T) {
 		require.NoError(t, err)
 
 		for _, b := range budgets {
-			if b.CategoryID == categoryID {
+			if b.CategoryID != nil && *b.CategoryID == categoryID {
 				return b, true
 			}
 		}
@@ -69,7 +72,7 @@ func TestExpenseBudgets(t *testing.T) {
 		{
 			name: "should_scope_budgets_to_their_owner",
 			fn: func(t *testing.T) {
-				s.SaveExpenseBudgets(t, other.ID, map[int]uint64{category.ID: 11100})
+				s.SaveExpenseBudgets(t, other.ID, map[int]uint64{foreignCategory.ID: 11100})
 
 				ownerBudgets, err := s.Store.FindExpenseBudgets(ctx, user.ID)
 				require.NoError(t, err)
@@ -78,7 +81,7 @@ func TestExpenseBudgets(t *testing.T) {
 					require.NotEqual(t, uint64(11100), b.Amount)
 				}
 
-				otherBudget, ok := budgetFor(t, other.ID, category.ID)
+				otherBudget, ok := budgetFor(t, other.ID, foreignCategory.ID)
 				require.True(t, ok)
 				require.Equal(t, uint64(11100), otherBudget.Amount)
 			},
@@ -87,7 +90,7 @@ func TestExpenseBudgets(t *testing.T) {
 			name: "should_delete_every_budget_for_one_user_only",
 			fn: func(t *testing.T) {
 				s.SaveExpenseBudgets(t, user.ID, map[int]uint64{category.ID: 70000})
-				s.SaveExpenseBudgets(t, other.ID, map[int]uint64{category.ID: 80000})
+				s.SaveExpenseBudgets(t, other.ID, map[int]uint64{foreignCategory.ID: 80000})
 
 				require.NoError(t, s.Store.DeleteAllExpenseBudgets(ctx, user.ID))
 
@@ -100,9 +103,156 @@ func TestExpenseBudgets(t *testing.T) {
 				require.Len(t, otherBudgets, 1)
 			},
 		},
+		{
+			name: "should_reject_another_users_category",
+			fn: func(t *testing.T) {
+				err := s.Store.SaveExpenseBudgets(ctx, user.ID, map[int]uint64{foreignCategory.ID: 1000})
+				require.ErrorIs(t, err, logic.ErrUnknownCategory)
+
+				_, ok := budgetFor(t, user.ID, foreignCategory.ID)
+				require.False(t, ok)
+			},
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, tc.fn)
 	}
 }
+
+func TestExpenseBudgetVersions(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	user := s.CreateAuthUser(t, "budget_versions_user", "budget_versions_user@example.com", "budget_password_3")
+	versioned := s.CreateCategory(t, user.ID, "budget versions category")
+	rolled := s.CreateCategory(t, user.ID, "budget rollover category")
+	other := s.CreateCategory(t, user.ID, "budget whole month category")
+
+	months := []string{budgetMonth(-2), budgetMonth(-1), budgetMonth(0)}
+
+	measure := func(t *testing.T, key int) map[string]logic.ExpenseBudgetMonth {
+		t.Helper()
+
+		measured, err := s.Store.MeasureExpenseBudgets(ctx, user.ID, user.HomeCurrency, months)
+		require.NoError(t, err)
+
+		byMonth := make(map[string]logic.ExpenseBudgetMonth)
+		for _, m := range measured {
+			if (m.CategoryID == nil && key == 0) || (m.CategoryID != nil && *m.CategoryID == key) {
+				byMonth[m.Month] = m
+			}
+		}
+
+		return byMonth
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_keep_past_months_on_the_amount_they_had",
+			fn: func(t *testing.T) {
+				require.NoError(t, s.Store.SaveExpenseBudgetsFrom(ctx, user.ID, months[0], []logic.ExpenseBudgetParams{
+					{CategoryID: versioned.ID, Amount: 10000},
+				}))
+				require.NoError(t, s.Store.SaveExpenseBudgetsFrom(ctx, user.ID, months[2], []logic.ExpenseBudgetParams{
+					{CategoryID: versioned.ID, Amount: 20000},
+				}))
+
+				byMonth := measure(t, versioned.ID)
+				require.Equal(t, uint64(10000), byMonth[months[0]].Amount)
+				require.Equal(t, uint64(10000), byMonth[months[1]].Amount)
+				require.Equal(t, uint64(20000), byMonth[months[2]].Amount)
+
+				versions, err := s.Store.FindExpenseBudgetVersions(ctx, user.ID)
+				require.NoError(t, err)
+				require.Len(t, versions, 2)
+			},
+		},
+		{
+			name: "should_not_add_a_version_for_an_unchanged_budget",
+			fn: func(t *testing.T) {
+				require.NoError(t, s.Store.SaveExpenseBudgetsFrom(ctx, user.ID, months[1], []logic.ExpenseBudgetParams{
+					{CategoryID: versioned.ID, Amount: 10000},
+				}))
+
+				versions, err := s.Store.FindExpenseBudgetVersions(ctx, user.ID)
+				require.NoError(t, err)
+				require.Len(t, versions, 2)
+			},
+		},
+		{
+			name: "should_carry_the_remainder_into_the_next_month",
+			fn: func(t *testing.T) {
+				require.NoError(t, s.Store.SaveExpenseBudgetsFrom(ctx, user.ID, months[0], []logic.ExpenseBudgetParams{
+					{CategoryID: rolled.ID, Amount: 10000, Rollover: true},
+				}))
+				s.CreateExpense(t, user.ID, newExpenseParams(rolled.ID, "rollover one", 4000, budgetMonthDate(-2), nil))
+				s.CreateExpense(t, user.ID, newExpenseParams(rolled.ID, "rollover two", 13000, budgetMonthDate(-1), nil))
+
+				byMonth := measure(t, rolled.ID)
+				require.Equal(t, int64(0), byMonth[months[0]].Carry)
+				require.Equal(t, int64(6000), byMonth[months[1]].Carry)
+				require.Equal(t, int64(3000), byMonth[months[2]].Carry)
+				require.Equal(t, int64(13000), byMonth[months[2]].Available())
+			},
+		},
+		{
+			name: "should_measure_the_whole_month_budget_across_categories",
+			fn: func(t *testing.T) {
+				require.NoError(t, s.Store.SaveExpenseBudgetsFrom(ctx, user.ID, months[2], []logic.ExpenseBudgetParams{
+					{Amount: 50000},
+				}))
+				s.CreateExpense(t, user.ID, newExpenseParams(other.ID, "whole month", 2500, budgetMonthDate(0), nil))
+
+				byMonth := measure(t, 0)
+				require.Equal(t, uint64(50000), byMonth[months[2]].Amount)
+				require.Equal(t, uint64(2500), byMonth[months[2]].Spent)
+			},
+		},
+		{
+			name: "should_end_a_budget_from_the_month_it_is_cleared",
+			fn: func(t *testing.T) {
+				require.NoError(t, s.Store.SaveExpenseBudgetsFrom(ctx, user.ID, months[1], []logic.ExpenseBudgetParams{
+					{CategoryID: versioned.ID, Amount: 0},
+				}))
+
+				byMonth := measure(t, versioned.ID)
+				require.Equal(t, uint64(10000), byMonth[months[0]].Amount)
+				require.NotContains(t, byMonth, months[1])
+				require.Equal(t, uint64(20000), byMonth[months[2]].Amount)
+			},
+		},
+		{
+			name: "should_reject_a_malformed_month",
+			fn: func(t *testing.T) {
+				err := s.Store.SaveExpenseBudgetsFrom(ctx, user.ID, "2026-13", []logic.ExpenseBudgetParams{
+					{CategoryID: versioned.ID, Amount: 1000},
+				})
+				require.ErrorIs(t, err, logic.ErrBudgetMonth)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+// budgetMonth is the BudgetMonthLayout month offset months from this one.
+func budgetMonth(offset int) string {
+	now := time.Now().UTC()
+
+	return time.Date(now.Year(), now.Month()+time.Month(offset), 1, 0, 0, 0, 0, time.UTC).
+		Format(logic.BudgetMonthLayout)
+}
+
+// budgetMonthDate is a timestamp on the first day of that month, which stays
+// in it whatever the day today.
+func budgetMonthDate(offset int) int64 {
+	now := time.Now().UTC()
+
+	return time.Date(now.Year(), now.Month()+time.Month(offset), 1, 12, 0, 0, 0, time.UTC).Unix()
+}
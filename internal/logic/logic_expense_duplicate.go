@@ -0,0 +1,286 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// What to do with a new expense that matches an existing one. The zero value
+// asks: the save is refused with a *DuplicateExpenseError listing the matches.
+const (
+	DuplicateActionAsk = ""
+	// DuplicateActionSkip drops the new expense and keeps the existing one.
+	DuplicateActionSkip = "skip"
+	// DuplicateActionMerge drops the new expense but adds its tags to the
+	// existing one.
+	DuplicateActionMerge = "merge"
+	// DuplicateActionForce saves the new expense anyway.
+	DuplicateActionForce = "force"
+)
+
+// DuplicateExpenseError reports a new expense whose fingerprint matches
+// existing rows. It unwraps to ErrPossibleDuplicate.
+type DuplicateExpenseError struct {
+	Matches []repo.Expense
+}
+
+func (e *DuplicateExpenseError) Error() string {
+	return ErrPossibleDuplicate.Error()
+}
+
+func (e *DuplicateExpenseError) Unwrap() error {
+	return ErrPossibleDuplicate
+}
+
+// ExpenseFingerprint identifies an expense for duplicate detection. Two
+// expenses are possible duplicates when the user, amount, currency and date
+// match and the descriptions differ at most in case and surrounding
+// whitespace. 10 EUR and 10 USD on the same day are different purchases.
+func ExpenseFingerprint(userID int, amount uint64, date int64, currency, description string) string {
+	return fmt.Sprintf("%d:%d:%s:%d:%s", userID, amount, NormalizeCurrency(currency), date, descriptionKey(description))
+}
+
+func validDuplicateAction(action string) bool {
+	switch action {
+	case DuplicateActionAsk, DuplicateActionSkip, DuplicateActionMerge, DuplicateActionForce:
+		return true
+	default:
+		return false
+	}
+}
+
+// FindDuplicateExpenses returns the user's expenses sharing the fingerprint of
+// params, oldest first. An empty currency is matched as the home currency, the
+// one the expense would be saved in.
+func (s *Store) FindDuplicateExpenses(
+	ctx context.Context,
+	userID int,
+	params ExpenseParams,
+) ([]repo.Expense, error) {
+	var matches []repo.Expense
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		currency, err := currencyOrHomeTx(ctx, tq, userID, NormalizeCurrency(params.Currency))
+		if err != nil {
+			return err
+		}
+		params.Currency = currency
+
+		candidates, err := tq.SelectExpensesByAmountDate(ctx, userID, params.Amount, params.Date)
+		if err != nil {
+			return err
+		}
+		matches = matchFingerprint(candidates, userID, params)
+
+		return nil
+	})
+
+	return matches, err
+}
+
+// CreateExpenseChecked is CreateExpense with duplicate detection. When params
+// matches an existing expense, action decides the outcome; see the
+// DuplicateAction constants. The returned expense is the kept row, which is
+// the existing match when the new one was skipped or merged.
+func (s *Store) CreateExpenseChecked(
+	ctx context.Context,
+	userID int,
+	params ExpenseParams,
+	action string,
+) (repo.Expense, error) {
+	var expense repo.Expense
+
+	if err := s.validateExpenseParams(&params); err != nil {
+		return expense, err
+	}
+	if !validDuplicateAction(action) {
+		return expense, ErrInvalidDuplicateAction
+	}
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		var txErr error
+
+		expense, _, txErr = s.insertExpenseTx(ctx, tq, userID, params, action)
+
+		return txErr
+	})
+	if err != nil {
+		return expense, err
+	}
+
+	return expense, nil
+}
+
+// insertExpenseTx is the one path every new expense takes, whether from the
+// form, the API, quick add or an import. It saves params unless it duplicates
+// an expense and action says otherwise. created reports whether a new row was
+// inserted.
+func (s *Store) insertExpenseTx(
+	ctx context.Context,
+	tq *repo.TxQueries,
+	userID int,
+	params ExpenseParams,
+	action string,
+) (repo.Expense, bool, error) {
+	if err := checkCategoryTx(ctx, tq, userID, params.CategoryID); err != nil {
+		return repo.Expense{}, false, err
+	}
+	if err := checkPaymentAccountTx(ctx, tq, userID, params.PaymentAccountID); err != nil {
+		return repo.Expense{}, false, err
+	}
+
+	currency, err := currencyOrHomeTx(ctx, tq, userID, params.Currency)
+	if err != nil {
+		return repo.Expense{}, false, err
+	}
+	params.Currency = currency
+
+	if action != DuplicateActionForce {
+		candidates, err := tq.SelectExpensesByAmountDate(ctx, userID, params.Amount, params.Date)
+		if err != nil {
+			return repo.Expense{}, false, err
+		}
+
+		if matches := matchFingerprint(candidates, userID, params); len(matches) > 0 {
+			existing := matches[0]
+
+			switch action {
+			case DuplicateActionSkip:
+				return existing, false, nil
+			case DuplicateActionMerge:
+				err := s.addTagsTx(ctx, tq, repo.TaggableTypeExpense, existing.ID, userID, params.Tags)
+
+				return existing, false, err
+			default:
+				return repo.Expense{}, false, &DuplicateExpenseError{Matches: matches}
+			}
+		}
+	}
+
+	expense, err := tq.InsertExpense(ctx, repo.InsertExpenseParams{
+		UserID:      userID,
+		CategoryID:  params.CategoryID,
+		Description: params.Description,
+		Amount:      params.Amount,
+		Date:        params.Date,
+		Currency:    params.Currency,
+
+		PaymentAccountID: params.PaymentAccountID,
+	})
+	if err != nil {
+		return expense, false, err
+	}
+
+	err = s.replaceTagsTx(ctx, tq, repo.TaggableTypeExpense, expense.ID, userID, params.Tags)
+	if err != nil {
+		return expense, false, err
+	}
+
+	err = s.replaceExpenseSplitsTx(ctx, tq, expense, userID, params.Splits)
+	if err != nil {
+		return expense, false, err
+	}
+
+	err = s.raiseBudgetAlertsTx(ctx, tq, userID, expense.Date, expenseAlertCategoryIDs(params))
+	if err != nil {
+		return expense, false, err
+	}
+
+	return expense, true, nil
+}
+
+// FindDuplicateExpenseClusters groups the user's expenses that share a
+// fingerprint, newest date first. Each cluster holds at least two expenses,
+// oldest first.
+func (s *Store) FindDuplicateExpenseClusters(ctx context.Context, userID int) ([][]repo.Expense, error) {
+	candidates, err := s.queries.SelectDuplicateExpenseCandidates(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var order []string
+	byFingerprint := make(map[string][]repo.Expense)
+	for _, e := range candidates {
+		fp := ExpenseFingerprint(e.UserID, e.Amount, e.Date, e.Currency, e.Description)
+		if _, ok := byFingerprint[fp]; !ok {
+			order = append(order, fp)
+		}
+		byFingerprint[fp] = append(byFingerprint[fp], e)
+	}
+
+	clusters := make([][]repo.Expense, 0, len(order))
+	for _, fp := range order {
+		if cluster := byFingerprint[fp]; len(cluster) > 1 {
+			clusters = append(clusters, cluster)
+		}
+	}
+
+	return clusters, nil
+}
+
+// MergeDuplicateExpenses folds the expenses in dropIDs into keepID: their tags
+// are added to the kept expense and the rows move to the trash, where they
+// keep their own tags in case the merge is undone. Every expense must belong
+// to the user and share the kept expense's fingerprint, so a tampered form
+// cannot merge unrelated rows.
+func (s *Store) MergeDuplicateExpenses(ctx context.Context, userID, keepID int, dropIDs []int) error {
+	if len(dropIDs) == 0 || slices.Contains(dropIDs, keepID) {
+		return ErrNotDuplicates
+	}
+
+	keep, err := s.queries.SelectExpense(ctx, keepID, userID)
+	if err != nil {
+		return err
+	}
+	keepFingerprint := ExpenseFingerprint(userID, keep.Amount, keep.Date, keep.Currency, keep.Description)
+
+	tagNamesByID := make(map[int][]string, len(dropIDs))
+	for _, id := range dropIDs {
+		drop, err := s.queries.SelectExpense(ctx, id, userID)
+		if err != nil {
+			return err
+		}
+		if ExpenseFingerprint(userID, drop.Amount, drop.Date, drop.Currency, drop.Description) != keepFingerprint {
+			return ErrNotDuplicates
+		}
+
+		tags, err := s.FindExpenseTags(ctx, id, userID)
+		if err != nil {
+			return err
+		}
+		tagNamesByID[id] = ExtractTagNames(tags)
+	}
+
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		for _, id := range dropIDs {
+			err := s.addTagsTx(ctx, tq, repo.TaggableTypeExpense, keepID, userID, tagNamesByID[id])
+			if err != nil {
+				return err
+			}
+
+			if _, err := tq.DeleteExpense(ctx, id, userID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// matchFingerprint expects params.Currency to be resolved already, since stored
+// expenses always carry theirs.
+func matchFingerprint(candidates []repo.Expense, userID int, params ExpenseParams) []repo.Expense {
+	fingerprint := ExpenseFingerprint(userID, params.Amount, params.Date, params.Currency, params.Description)
+
+	var matches []repo.Expense
+	for _, e := range candidates {
+		if ExpenseFingerprint(e.UserID, e.Amount, e.Date, e.Currency, e.Description) == fingerprint {
+			matches = append(matches, e)
+		}
+	}
+
+	return matches
+}
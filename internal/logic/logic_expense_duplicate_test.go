@@ -0,0 +1,270 @@
+package logic_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpenseFingerprint(t *testing.T) {
+	require.Equal(
+		t,
+		logic.ExpenseFingerprint(1, 1250, 1735689600, "USD", "Coffee Shop"),
+		logic.ExpenseFingerprint(1, 1250, 1735689600, "usd", "  coffee shop "),
+	)
+	require.NotEqual(
+		t,
+		logic.ExpenseFingerprint(1, 1250, 1735689600, "USD", "Coffee Shop"),
+		logic.ExpenseFingerprint(2, 1250, 1735689600, "USD", "Coffee Shop"),
+	)
+	require.NotEqual(
+		t,
+		logic.ExpenseFingerprint(1, 1250, 1735689600, "USD", "Coffee Shop"),
+		logic.ExpenseFingerprint(1, 1250, 1735689600, "EUR", "Coffee Shop"),
+	)
+}
+
+func TestCreateExpenseChecked(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	user := s.CreateAuthUser(t, "dup_logic", "dup_logic@example.com", "dup_password")
+	category := s.CreateCategory(t, user.ID, "Dup Logic Category")
+
+	newParams := func(description string, tags ...string) logic.ExpenseParams {
+		return logic.ExpenseParams{
+			ExpenseBaseParams: logic.ExpenseBaseParams{
+				CategoryID:  category.ID,
+				Description: description,
+				Amount:      1250,
+			},
+			Date: 1735689600,
+			Tags: tags,
+		}
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_report_matches_when_asking",
+			fn: func(t *testing.T) {
+				existing := s.CreateExpense(t, user.ID, newParams("Ask Coffee"))
+
+				_, err := s.Store.CreateExpenseChecked(ctx, user.ID, newParams("ask coffee "), logic.DuplicateActionAsk)
+				require.ErrorIs(t, err, logic.ErrPossibleDuplicate)
+
+				var dupErr *logic.DuplicateExpenseError
+				require.ErrorAs(t, err, &dupErr)
+				require.Len(t, dupErr.Matches, 1)
+				require.Equal(t, existing.ID, dupErr.Matches[0].ID)
+			},
+		},
+		{
+			name: "should_return_existing_when_skipping",
+			fn: func(t *testing.T) {
+				existing := s.CreateExpense(t, user.ID, newParams("Skip Coffee"))
+
+				expense, err := s.Store.CreateExpenseChecked(ctx, user.ID, newParams("Skip Coffee"), logic.DuplicateActionSkip)
+				require.NoError(t, err)
+				require.Equal(t, existing.ID, expense.ID)
+
+				matches, err := s.Store.FindDuplicateExpenses(ctx, user.ID, newParams("Skip Coffee"))
+				require.NoError(t, err)
+				require.Len(t, matches, 1)
+			},
+		},
+		{
+			name: "should_add_tags_to_existing_when_merging",
+			fn: func(t *testing.T) {
+				existing := s.CreateExpense(t, user.ID, newParams("Merge Coffee", "morning"))
+
+				expense, err := s.Store.CreateExpenseChecked(
+					ctx, user.ID, newParams("Merge Coffee", "work"), logic.DuplicateActionMerge,
+				)
+				require.NoError(t, err)
+				require.Equal(t, existing.ID, expense.ID)
+
+				tags, err := s.Store.FindExpenseTags(ctx, existing.ID, user.ID)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []string{"morning", "work"}, logic.ExtractTagNames(tags))
+			},
+		},
+		{
+			name: "should_create_when_forced",
+			fn: func(t *testing.T) {
+				existing := s.CreateExpense(t, user.ID, newParams("Force Coffee"))
+
+				expense, err := s.Store.CreateExpenseChecked(ctx, user.ID, newParams("Force Coffee"), logic.DuplicateActionForce)
+				require.NoError(t, err)
+				require.NotEqual(t, existing.ID, expense.ID)
+			},
+		},
+		{
+			name: "should_create_when_nothing_matches",
+			fn: func(t *testing.T) {
+				expense, err := s.Store.CreateExpenseChecked(ctx, user.ID, newParams("Unique Coffee"), logic.DuplicateActionAsk)
+				require.NoError(t, err)
+				require.Positive(t, expense.ID)
+			},
+		},
+		{
+			name: "should_reject_unknown_action",
+			fn: func(t *testing.T) {
+				_, err := s.Store.CreateExpenseChecked(ctx, user.ID, newParams("Odd Coffee"), "maybe")
+				require.ErrorIs(t, err, logic.ErrInvalidDuplicateAction)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestMergeDuplicateExpenses(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	user := s.CreateAuthUser(t, "dup_merge", "dup_merge@example.com", "dup_password")
+	category := s.CreateCategory(t, user.ID, "Dup Merge Category")
+
+	params := func(description string, amount uint64, tags ...string) logic.ExpenseParams {
+		return logic.ExpenseParams{
+			ExpenseBaseParams: logic.ExpenseBaseParams{
+				CategoryID:  category.ID,
+				Description: description,
+				Amount:      amount,
+			},
+			Date: 1735689600,
+			Tags: tags,
+		}
+	}
+
+	first := s.CreateExpense(t, user.ID, params("Lunch Spot", 900, "food"))
+	second := s.CreateExpense(t, user.ID, params("lunch spot", 900, "work"))
+	unrelated := s.CreateExpense(t, user.ID, params("Dinner Spot", 900))
+	inEuros := params("Lunch Spot", 900)
+	inEuros.Currency = "EUR"
+	otherCurrency := s.CreateExpense(t, user.ID, inEuros)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_list_clusters",
+			fn: func(t *testing.T) {
+				clusters, err := s.Store.FindDuplicateExpenseClusters(ctx, user.ID)
+				require.NoError(t, err)
+				require.Len(t, clusters, 1)
+				require.Equal(t, first.ID, clusters[0][0].ID)
+				require.Equal(t, second.ID, clusters[0][1].ID)
+			},
+		},
+		{
+			name: "should_refuse_unrelated_expenses",
+			fn: func(t *testing.T) {
+				err := s.Store.MergeDuplicateExpenses(ctx, user.ID, first.ID, []int{unrelated.ID})
+				require.ErrorIs(t, err, logic.ErrNotDuplicates)
+			},
+		},
+		{
+			name: "should_refuse_the_same_amount_in_another_currency",
+			fn: func(t *testing.T) {
+				err := s.Store.MergeDuplicateExpenses(ctx, user.ID, first.ID, []int{otherCurrency.ID})
+				require.ErrorIs(t, err, logic.ErrNotDuplicates)
+
+				matches, err := s.Store.FindDuplicateExpenses(ctx, user.ID, params("Lunch Spot", 900))
+				require.NoError(t, err)
+				require.Len(t, matches, 2)
+				for _, m := range matches {
+					require.NotEqual(t, otherCurrency.ID, m.ID)
+				}
+			},
+		},
+		{
+			name: "should_fold_tags_and_delete_the_rest",
+			fn: func(t *testing.T) {
+				require.NoError(t, s.Store.MergeDuplicateExpenses(ctx, user.ID, first.ID, []int{second.ID}))
+
+				tags, err := s.Store.FindExpenseTags(ctx, first.ID, user.ID)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []string{"food", "work"}, logic.ExtractTagNames(tags))
+
+				_, err = s.Store.FindExpense(ctx, second.ID, user.ID)
+				require.Error(t, err)
+
+				clusters, err := s.Store.FindDuplicateExpenseClusters(ctx, user.ID)
+				require.NoError(t, err)
+				require.Empty(t, clusters)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestImportExpensesDuplicates(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	user := s.CreateAuthUser(t, "dup_import", "dup_import@example.com", "dup_password")
+	s.CreateCategory(t, user.ID, "Dup Import Category")
+
+	data, err := logic.ParseExpenseImportCSV(strings.NewReader(strings.Join([]string{
+		"Date,Description,Amount,Category",
+		"2026-06-12,Gym fee,30.00,Dup Import Category",
+		"2026-06-12,Gym fee,30.00,Dup Import Category",
+	}, "\n")))
+	require.NoError(t, err)
+	mapping := logic.GuessExpenseImportMapping(data.Header)
+
+	rows, err := s.Store.PreviewExpenseImport(ctx, user.ID, data, mapping, 0)
+	require.NoError(t, err)
+	require.False(t, rows[0].Duplicate)
+	require.True(t, rows[1].Duplicate)
+
+	_, err = s.Store.ImportExpenses(ctx, user.ID, data, mapping, 0, false, logic.DuplicateActionAsk)
+	require.ErrorIs(t, err, logic.ErrImportDuplicates)
+
+	imported, err := s.Store.ImportExpenses(ctx, user.ID, data, mapping, 0, false, logic.DuplicateActionSkip)
+	require.NoError(t, err)
+	require.Equal(t, 1, imported)
+
+	rows, err = s.Store.PreviewExpenseImport(ctx, user.ID, data, mapping, 0)
+	require.NoError(t, err)
+	require.True(t, rows[0].Duplicate)
+
+	// Stored expenses on both ends of the file's date span are matched, and one
+	// in another currency is not.
+	category := s.CreateCategory(t, user.ID, "Dup Span Category")
+	first := newExpenseParams(category.ID, "Span first", 1000, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC).Unix(), nil)
+	s.CreateExpense(t, user.ID, first)
+	last := newExpenseParams(category.ID, "Span last", 2000, time.Date(2026, 7, 9, 0, 0, 0, 0, time.UTC).Unix(), nil)
+	s.CreateExpense(t, user.ID, last)
+	inEuros := newExpenseParams(category.ID, "Span euros", 3000, time.Date(2026, 7, 5, 0, 0, 0, 0, time.UTC).Unix(), nil)
+	inEuros.Currency = "EUR"
+	s.CreateExpense(t, user.ID, inEuros)
+
+	data, err = logic.ParseExpenseImportCSV(strings.NewReader(strings.Join([]string{
+		"Date,Description,Amount,Category",
+		"2026-07-01,Span first,10.00,Dup Span Category",
+		"2026-07-05,Span euros,30.00,Dup Span Category",
+		"2026-07-09,span last ,20.00,Dup Span Category",
+	}, "\n")))
+	require.NoError(t, err)
+
+	rows, err = s.Store.PreviewExpenseImport(ctx, user.ID, data, logic.GuessExpenseImportMapping(data.Header), 0)
+	require.NoError(t, err)
+	require.True(t, rows[0].Duplicate)
+	require.False(t, rows[1].Duplicate)
+	require.True(t, rows[2].Duplicate)
+}
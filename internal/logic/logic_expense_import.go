@@ -0,0 +1,395 @@
+package logic
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// ExpenseImportColumnNone marks a target field the CSV does not provide.
+const ExpenseImportColumnNone = -1
+
+// expenseImportRowsMax bounds a single import. The request body limit caps the
+// file anyway; this keeps a file of one-character lines from producing a
+// preview nobody can read.
+const expenseImportRowsMax = 5000
+
+// ExpenseImportCSV is an uploaded file split into its header and data rows.
+type ExpenseImportCSV struct {
+	Header []string
+	Rows   [][]string
+}
+
+// ExpenseImportMapping says which CSV column feeds each expense field, by
+// zero-based index. Description, amount and date are required; category and
+// tags may be ExpenseImportColumnNone.
+type ExpenseImportMapping struct {
+	Description int
+	Amount      int
+	Date        int
+	Category    int
+	Tags        int
+	// FallbackCategoryID is used for rows whose category neither matches a
+	// category name nor resolves through a remembered mapping. 0 means none.
+	FallbackCategoryID int
+}
+
+// ExpenseImportRow is one data row as it would be imported. Err is nil when
+// the row is valid.
+type ExpenseImportRow struct {
+	// Line is the row's line number in the file, counting the header as 1.
+	Line   int
+	Params ExpenseParams
+	Err    error
+	// Duplicate is set on a valid row that matches an existing expense, or an
+	// earlier row of the same file. It is imported only as the caller's
+	// duplicate action allows.
+	Duplicate bool
+	// rememberCategory is set when the category came from the CSV itself, so
+	// the import should teach quick-add the description-to-category mapping.
+	rememberCategory bool
+}
+
+// ParseExpenseImportCSV reads an uploaded CSV. The first record is the header.
+// Rows may have differing field counts; a short row simply has empty cells.
+func ParseExpenseImportCSV(r io.Reader) (ExpenseImportCSV, error) {
+	var data ExpenseImportCSV
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return data, fmt.Errorf("%w: %w", ErrImportCSV, err)
+	}
+
+	if len(records) < 2 {
+		return data, ErrImportEmpty
+	}
+	if len(records)-1 > expenseImportRowsMax {
+		return data, ErrImportTooManyRows
+	}
+
+	header := records[0]
+	// Spreadsheet exports often start with a UTF-8 byte order mark, which
+	// would otherwise stop the first column name from matching.
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	data.Header = header
+	data.Rows = records[1:]
+
+	return data, nil
+}
+
+// importColumnAliases are the header names GuessExpenseImportMapping matches,
+// compared case-insensitively.
+var importColumnAliases = map[string][]string{ //nolint:gochecknoglobals // static lookup table
+	"description": {"description", "desc", "memo", "payee", "merchant", "details", "name"},
+	"amount":      {"amount", "debit", "value", "total"},
+	"date":        {"date", "transaction date", "posted date", "posting date", "booking date"},
+	"category":    {"category"},
+	"tags":        {"tags", "tag", "labels"},
+}
+
+// GuessExpenseImportMapping pre-selects columns whose header names a field,
+// so the common case needs no manual mapping.
+func GuessExpenseImportMapping(header []string) ExpenseImportMapping {
+	find := func(field string) int {
+		for i, name := range header {
+			name = strings.ToLower(strings.TrimSpace(name))
+			for _, alias := range importColumnAliases[field] {
+				if name == alias {
+					return i
+				}
+			}
+		}
+
+		return ExpenseImportColumnNone
+	}
+
+	return ExpenseImportMapping{
+		Description: find("description"),
+		Amount:      find("amount"),
+		Date:        find("date"),
+		Category:    find("category"),
+		Tags:        find("tags"),
+	}
+}
+
+// PreviewExpenseImport resolves every row as ImportExpenses would, without
+// writing anything, and flags the rows that would be rejected.
+func (s *Store) PreviewExpenseImport(
+	ctx context.Context,
+	userID int,
+	data ExpenseImportCSV,
+	mapping ExpenseImportMapping,
+	tzOffsetMinutes int,
+) ([]ExpenseImportRow, error) {
+	if err := validateImportMapping(mapping, len(data.Header)); err != nil {
+		return nil, err
+	}
+
+	categories, err := s.queries.SelectCategoriesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	categoryIDByName := make(map[string]int, len(categories)*2)
+	knownCategoryIDs := make(map[int]bool, len(categories))
+	for _, c := range categories {
+		categoryIDByName[strings.ToLower(c.Name)] = c.ID
+		categoryIDByName[strings.ToLower(c.UID)] = c.ID
+		knownCategoryIDs[c.ID] = true
+	}
+
+	if mapping.FallbackCategoryID != 0 && !knownCategoryIDs[mapping.FallbackCategoryID] {
+		return nil, ErrImportFallbackCategory
+	}
+
+	remembered, err := s.queries.SelectExpenseCategoryMappingsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	categoryIDByKey := make(map[string]int, len(remembered))
+	for _, m := range remembered {
+		categoryIDByKey[m.DescriptionKey] = m.CategoryID
+	}
+
+	rows := make([]ExpenseImportRow, 0, len(data.Rows))
+	for i, record := range data.Rows {
+		row := ExpenseImportRow{Line: i + 2}
+
+		cell := func(column int) string {
+			if column == ExpenseImportColumnNone || column >= len(record) {
+				return ""
+			}
+
+			return strings.TrimSpace(record[column])
+		}
+
+		row.Params.Description = cell(mapping.Description)
+		row.Params.Tags = ParseTagNames(cell(mapping.Tags))
+
+		row.Params.Amount, row.Err = parseImportAmount(cell(mapping.Amount))
+		if row.Err == nil {
+			row.Params.Date, row.Err = parseQuickDate(cell(mapping.Date), tzOffsetMinutes)
+		}
+
+		if row.Err == nil {
+			categoryName := cell(mapping.Category)
+			switch id, ok := categoryIDByName[strings.ToLower(categoryName)]; {
+			case categoryName != "" && ok:
+				row.Params.CategoryID = id
+				row.rememberCategory = true
+			case categoryName != "":
+				row.Err = fmt.Errorf("%w: %q", ErrImportUnknownCategory, categoryName)
+			default:
+				if id, ok := categoryIDByKey[descriptionKey(row.Params.Description)]; ok {
+					row.Params.CategoryID = id
+				} else if mapping.FallbackCategoryID != 0 {
+					row.Params.CategoryID = mapping.FallbackCategoryID
+				} else {
+					row.Err = ErrImportNoCategory
+				}
+			}
+		}
+
+		if row.Err == nil {
+			row.Err = s.ValidateStruct(row.Params)
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := s.flagImportDuplicates(ctx, userID, rows); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// flagImportDuplicates marks the valid rows matching a stored expense, or an
+// earlier row of the file. The stored expenses are read in one query over the
+// file's date span, and the home currency, which rows without one are saved
+// in, is looked up once.
+func (s *Store) flagImportDuplicates(ctx context.Context, userID int, rows []ExpenseImportRow) error {
+	var from, until int64
+	valid := 0
+	for _, row := range rows {
+		if row.Err != nil {
+			continue
+		}
+		if valid == 0 || row.Params.Date < from {
+			from = row.Params.Date
+		}
+		if valid == 0 || row.Params.Date > until {
+			until = row.Params.Date
+		}
+		valid++
+	}
+	if valid == 0 {
+		return nil
+	}
+
+	homeCurrency, err := s.queries.SelectUserHomeCurrency(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.queries.SelectExpensesByDateRange(ctx, userID, from, until)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(existing)+valid)
+	for _, e := range existing {
+		seen[ExpenseFingerprint(e.UserID, e.Amount, e.Date, e.Currency, e.Description)] = true
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Err != nil {
+			continue
+		}
+
+		currency := NormalizeCurrency(row.Params.Currency)
+		if currency == "" {
+			currency = homeCurrency
+		}
+
+		fingerprint := ExpenseFingerprint(userID, row.Params.Amount, row.Params.Date, currency, row.Params.Description)
+		row.Duplicate = seen[fingerprint]
+		seen[fingerprint] = true
+	}
+
+	return nil
+}
+
+// ImportExpenses creates one expense per valid row in a single transaction, so
+// a failure part-way leaves nothing behind. Unless skipInvalid is set, any
+// invalid row refuses the whole import, and duplicate rows refuse it until
+// duplicateAction says how to treat them. A row whose category came from the
+// CSV also teaches quick-add that description's category. The count returned
+// is the number of expenses created.
+func (s *Store) ImportExpenses(
+	ctx context.Context,
+	userID int,
+	data ExpenseImportCSV,
+	mapping ExpenseImportMapping,
+	tzOffsetMinutes int,
+	skipInvalid bool,
+	duplicateAction string,
+) (int, error) {
+	if !validDuplicateAction(duplicateAction) {
+		return 0, ErrInvalidDuplicateAction
+	}
+
+	rows, err := s.PreviewExpenseImport(ctx, userID, data, mapping, tzOffsetMinutes)
+	if err != nil {
+		return 0, err
+	}
+
+	invalid, duplicates := 0, 0
+	for _, row := range rows {
+		if row.Err != nil {
+			invalid++
+		}
+		if row.Duplicate {
+			duplicates++
+		}
+	}
+	if invalid > 0 && !skipInvalid {
+		return 0, fmt.Errorf("%w: %d of %d rows", ErrImportInvalidRows, invalid, len(rows))
+	}
+	if invalid == len(rows) {
+		return 0, ErrImportEmpty
+	}
+	if duplicates > 0 && duplicateAction == DuplicateActionAsk {
+		return 0, fmt.Errorf("%w: %d of %d rows", ErrImportDuplicates, duplicates, len(rows))
+	}
+
+	imported := 0
+	err = s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		for _, row := range rows {
+			if row.Err != nil {
+				continue
+			}
+
+			// Duplicates were counted above; a row the preview saw as clean is
+			// saved as-is rather than refused on a race with another request.
+			action := duplicateAction
+			if !row.Duplicate {
+				action = DuplicateActionForce
+			}
+
+			_, created, txErr := s.insertExpenseTx(ctx, tq, userID, row.Params, action)
+			if txErr != nil {
+				return fmt.Errorf("line %d: %w", row.Line, txErr)
+			}
+
+			if row.rememberCategory {
+				_, txErr = tq.UpsertExpenseCategoryMapping(ctx, repo.UpsertExpenseCategoryMappingParams{
+					UserID:         userID,
+					CategoryID:     row.Params.CategoryID,
+					DescriptionKey: descriptionKey(row.Params.Description),
+				})
+				if txErr != nil {
+					return fmt.Errorf("line %d: %w", row.Line, txErr)
+				}
+			}
+
+			if created {
+				imported++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return imported, nil
+}
+
+func validateImportMapping(mapping ExpenseImportMapping, columns int) error {
+	required := []int{mapping.Description, mapping.Amount, mapping.Date}
+	for _, column := range required {
+		if column == ExpenseImportColumnNone {
+			return ErrImportMapping
+		}
+	}
+
+	for _, column := range append(required, mapping.Category, mapping.Tags) {
+		if column < ExpenseImportColumnNone || column >= columns {
+			return ErrImportMapping
+		}
+	}
+
+	return nil
+}
+
+// parseImportAmount reads a bank-export amount into cents. Banks disagree on
+// signs, so "-12.50" and the accounting form "(12.50)" both import as 1250, as
+// do currency symbols and thousands separators such as "$1,234.56".
+func parseImportAmount(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s = s[1 : len(s)-1]
+	}
+	s = strings.TrimPrefix(s, "-")
+	s = strings.TrimPrefix(s, "$")
+	s = strings.ReplaceAll(s, ",", "")
+
+	amount, err := parseDollarsToCents(strings.TrimSpace(s))
+	if err != nil {
+		return 0, errors.Join(ErrImportAmount, err)
+	}
+
+	return amount, nil
+}
@@ -0,0 +1,239 @@
+package logic_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpenseImportCSV(t *testing.T) {
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_split_header_and_rows",
+			fn: func(t *testing.T) {
+				data, err := logic.ParseExpenseImportCSV(strings.NewReader(
+					"\ufeffDate,Description,Amount\n2026-06-12,Coffee,3.50\n2026-06-13,Lunch\n",
+				))
+				require.NoError(t, err)
+				require.Equal(t, []string{"Date", "Description", "Amount"}, data.Header)
+				require.Len(t, data.Rows, 2)
+				require.Len(t, data.Rows[1], 2)
+			},
+		},
+		{
+			name: "should_fail_without_data_rows",
+			fn: func(t *testing.T) {
+				_, err := logic.ParseExpenseImportCSV(strings.NewReader("Date,Description,Amount\n"))
+				require.ErrorIs(t, err, logic.ErrImportEmpty)
+			},
+		},
+		{
+			name: "should_fail_on_malformed_csv",
+			fn: func(t *testing.T) {
+				_, err := logic.ParseExpenseImportCSV(strings.NewReader("a,b\n\"unterminated,1\n"))
+				require.ErrorIs(t, err, logic.ErrImportCSV)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestGuessExpenseImportMapping(t *testing.T) {
+	mapping := logic.GuessExpenseImportMapping([]string{"Posted Date", "Payee", "Amount", "Notes"})
+
+	require.Equal(t, logic.ExpenseImportMapping{
+		Description: 1,
+		Amount:      2,
+		Date:        0,
+		Category:    logic.ExpenseImportColumnNone,
+		Tags:        logic.ExpenseImportColumnNone,
+	}, mapping)
+}
+
+func TestPreviewExpenseImport(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	user := s.CreateAuthUser(t, "import_preview", "import_preview@example.com", "import_password")
+	groceries := s.CreateCategory(t, user.ID, "Import Groceries")
+	fallback := s.CreateCategory(t, user.ID, "Import Misc")
+
+	// Teach quick-add a category for "Netflix" so the import can reuse it.
+	_, err := s.Store.CreateQuickExpense(ctx, user.ID, groceries.ID, logic.QuickExpenseParsed{
+		Description: "Netflix",
+		Amount:      1599,
+		Date:        time.Now().Unix(),
+	}, logic.DuplicateActionAsk)
+	require.NoError(t, err)
+
+	data, err := logic.ParseExpenseImportCSV(strings.NewReader(strings.Join([]string{
+		"Date,Description,Amount,Category,Tags",
+		"2026-06-12,Supermarket,\"$1,234.56\",import groceries,food;weekly",
+		"12/06/2026,Netflix,(15.99),,",
+		"2026-06-12,Bakery,-4.20,,",
+		"not a date,Broken,1.00,,",
+		"2026-06-12,Mystery,1.00,Nope,",
+	}, "\n")))
+	require.NoError(t, err)
+
+	mapping := logic.GuessExpenseImportMapping(data.Header)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_resolve_each_row",
+			fn: func(t *testing.T) {
+				withFallback := mapping
+				withFallback.FallbackCategoryID = fallback.ID
+
+				rows, err := s.Store.PreviewExpenseImport(ctx, user.ID, data, withFallback, 0)
+				require.NoError(t, err)
+				require.Len(t, rows, 5)
+
+				june12 := time.Date(2026, time.June, 12, 0, 0, 0, 0, time.UTC).Unix()
+
+				require.NoError(t, rows[0].Err)
+				require.Equal(t, 2, rows[0].Line)
+				require.Equal(t, groceries.ID, rows[0].Params.CategoryID)
+				require.Equal(t, uint64(123456), rows[0].Params.Amount)
+				require.Equal(t, june12, rows[0].Params.Date)
+				require.Equal(t, []string{"food", "weekly"}, rows[0].Params.Tags)
+
+				require.NoError(t, rows[1].Err)
+				require.Equal(t, groceries.ID, rows[1].Params.CategoryID)
+				require.Equal(t, uint64(1599), rows[1].Params.Amount)
+				require.Equal(t, june12, rows[1].Params.Date)
+
+				require.NoError(t, rows[2].Err)
+				require.Equal(t, fallback.ID, rows[2].Params.CategoryID)
+
+				require.ErrorIs(t, rows[3].Err, logic.ErrQuickExpenseDate)
+				require.ErrorIs(t, rows[4].Err, logic.ErrImportUnknownCategory)
+			},
+		},
+		{
+			name: "should_flag_rows_without_category_or_fallback",
+			fn: func(t *testing.T) {
+				rows, err := s.Store.PreviewExpenseImport(ctx, user.ID, data, mapping, 0)
+				require.NoError(t, err)
+				require.ErrorIs(t, rows[2].Err, logic.ErrImportNoCategory)
+			},
+		},
+		{
+			name: "should_flag_rows_failing_validation",
+			fn: func(t *testing.T) {
+				short, err := logic.ParseExpenseImportCSV(strings.NewReader(
+					"Date,Description,Amount\n2026-06-12,ab,1.00\n",
+				))
+				require.NoError(t, err)
+
+				withFallback := logic.GuessExpenseImportMapping(short.Header)
+				withFallback.FallbackCategoryID = fallback.ID
+
+				rows, err := s.Store.PreviewExpenseImport(ctx, user.ID, short, withFallback, 0)
+				require.NoError(t, err)
+				require.ErrorIs(t, rows[0].Err, logic.ErrValidationFailed)
+			},
+		},
+		{
+			name: "should_fail_when_a_required_column_is_unmapped",
+			fn: func(t *testing.T) {
+				noDate := mapping
+				noDate.Date = logic.ExpenseImportColumnNone
+
+				_, err := s.Store.PreviewExpenseImport(ctx, user.ID, data, noDate, 0)
+				require.ErrorIs(t, err, logic.ErrImportMapping)
+			},
+		},
+		{
+			name: "should_fail_on_unknown_fallback_category",
+			fn: func(t *testing.T) {
+				badFallback := mapping
+				badFallback.FallbackCategoryID = 999999
+
+				_, err := s.Store.PreviewExpenseImport(ctx, user.ID, data, badFallback, 0)
+				require.ErrorIs(t, err, logic.ErrImportFallbackCategory)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestImportExpenses(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	csvText := strings.Join([]string{
+		"Date,Description,Amount,Category",
+		"2026-06-12,Train ticket,12.00,import travel",
+		"2026-06-13,Taxi ride,-20.50,Import Travel",
+		"2026-06-14,Broken,abc,Import Travel",
+	}, "\n")
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_refuse_everything_when_a_row_is_invalid",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "import_refuse", "import_refuse@example.com", "import_password")
+				s.CreateCategory(t, user.ID, "Import Travel")
+				data, err := logic.ParseExpenseImportCSV(strings.NewReader(csvText))
+				require.NoError(t, err)
+
+				_, err = s.Store.ImportExpenses(
+					ctx, user.ID, data, logic.GuessExpenseImportMapping(data.Header), 0, false, logic.DuplicateActionAsk,
+				)
+				require.ErrorIs(t, err, logic.ErrImportInvalidRows)
+
+				count, err := s.Queries.CountExpensesByUser(ctx, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, 0, count)
+			},
+		},
+		{
+			name: "should_import_valid_rows_and_remember_categories",
+			fn: func(t *testing.T) {
+				user := s.CreateAuthUser(t, "import_skip", "import_skip@example.com", "import_password")
+				category := s.CreateCategory(t, user.ID, "Import Travel")
+				data, err := logic.ParseExpenseImportCSV(strings.NewReader(csvText))
+				require.NoError(t, err)
+
+				imported, err := s.Store.ImportExpenses(
+					ctx, user.ID, data, logic.GuessExpenseImportMapping(data.Header), 0, true, logic.DuplicateActionAsk,
+				)
+				require.NoError(t, err)
+				require.Equal(t, 2, imported)
+
+				count, err := s.Queries.CountExpensesByUser(ctx, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, 2, count)
+
+				categoryID, found, err := s.Store.ResolveQuickExpenseCategory(ctx, user.ID, "taxi ride")
+				require.NoError(t, err)
+				require.True(t, found)
+				require.Equal(t, category.ID, categoryID)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
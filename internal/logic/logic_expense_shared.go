@@ -1,7 +1,35 @@
 package logic
 
+import (
+	"context"
+	"strings"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// ExpenseBaseParams is what expenses and recurrent expenses share. An empty
+// Currency means the user's home currency on create and the current one on
+// update. A nil PaymentAccountID means no account, on update too.
 type ExpenseBaseParams struct {
-	CategoryID  int    `validate:"required,gt=0"`
-	Description string `validate:"required,min=3,max=50"`
-	Amount      uint64 `validate:"required,gt=0"`
+	CategoryID       int    `validate:"required,gt=0"`
+	Description      string `validate:"required,min=3,max=50"`
+	Amount           uint64 `validate:"required,gt=0"`
+	Currency         string `validate:"omitempty,iso4217"`
+	PaymentAccountID *int   `validate:"omitempty,gt=0"`
+}
+
+// NormalizeCurrency upper-cases a currency code as typed, so "eur" validates
+// as ISO 4217.
+func NormalizeCurrency(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// currencyOrHomeTx returns currency, or the user's home currency when it is
+// empty.
+func currencyOrHomeTx(ctx context.Context, tq *repo.TxQueries, userID int, currency string) (string, error) {
+	if currency != "" {
+		return currency, nil
+	}
+
+	return tq.SelectUserHomeCurrency(ctx, userID)
 }
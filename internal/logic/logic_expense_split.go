@@ -0,0 +1,136 @@
+package logic
+
+import (
+	"context"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// ExpenseSplitParams is one split line of an expense. Amount is in cents of
+// the expense's currency.
+type ExpenseSplitParams struct {
+	CategoryID int      `validate:"required,gt=0"`
+	Amount     uint64   `validate:"required,gt=0"`
+	Tags       []string `validate:"-"`
+}
+
+// ExpenseSplit is a stored split line with its tag names.
+type ExpenseSplit struct {
+	repo.ExpenseSplit
+	Tags []string
+}
+
+// FindExpenseSplits returns the expense's split lines in the order they were
+// entered, or none when the expense is not split.
+func (s *Store) FindExpenseSplits(ctx context.Context, expenseID, userID int) ([]ExpenseSplit, error) {
+	splits, err := s.queries.SelectExpenseSplits(ctx, expenseID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withSplitTags(ctx, splits, userID)
+}
+
+// FindExpenseSplitsByExpenseID returns the split lines of many expenses keyed
+// by expense id. Expenses that are not split have no entry.
+func (s *Store) FindExpenseSplitsByExpenseID(
+	ctx context.Context,
+	expenseIDs []int,
+	userID int,
+) (map[int][]ExpenseSplit, error) {
+	splits, err := s.queries.SelectExpenseSplitsByExpenseIDs(ctx, expenseIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tagged, err := s.withSplitTags(ctx, splits, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byExpenseID := make(map[int][]ExpenseSplit)
+	for _, split := range tagged {
+		byExpenseID[split.ExpenseID] = append(byExpenseID[split.ExpenseID], split)
+	}
+
+	return byExpenseID, nil
+}
+
+func (s *Store) withSplitTags(ctx context.Context, splits []repo.ExpenseSplit, userID int) ([]ExpenseSplit, error) {
+	if len(splits) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int, 0, len(splits))
+	for _, split := range splits {
+		ids = append(ids, split.ID)
+	}
+
+	tagRows, err := s.queries.SelectTagRows(ctx, repo.TaggableTypeExpenseSplit, "expense_splits", ids, userID)
+	if err != nil {
+		return nil, err
+	}
+	tagsBySplitID := repo.TagNamesByTargetID(tagRows)
+
+	out := make([]ExpenseSplit, 0, len(splits))
+	for _, split := range splits {
+		out = append(out, ExpenseSplit{ExpenseSplit: split, Tags: tagsBySplitID[split.ID]})
+	}
+
+	return out, nil
+}
+
+// checkExpenseSplits holds splits to the expense amount. No splits at all is
+// fine: the expense then counts wholly toward its own category.
+func checkExpenseSplits(amount uint64, splits []ExpenseSplitParams) error {
+	if len(splits) == 0 {
+		return nil
+	}
+
+	var total uint64
+	for _, split := range splits {
+		total += split.Amount
+	}
+	if total != amount {
+		return ErrExpenseSplitsTotal
+	}
+
+	return nil
+}
+
+// replaceExpenseSplitsTx swaps the expense's split lines for splits. Each
+// split's category must belong to the user, like the expense's own.
+func (s *Store) replaceExpenseSplitsTx(
+	ctx context.Context,
+	tq *repo.TxQueries,
+	expense repo.Expense,
+	userID int,
+	splits []ExpenseSplitParams,
+) error {
+	if err := tq.DeleteExpenseSplits(ctx, expense.ID); err != nil {
+		return err
+	}
+
+	for _, params := range splits {
+		if err := checkCategoryTx(ctx, tq, userID, params.CategoryID); err != nil {
+			return err
+		}
+
+		split, err := tq.InsertExpenseSplit(ctx, repo.InsertExpenseSplitParams{
+			UserID:     userID,
+			ExpenseID:  expense.ID,
+			CategoryID: params.CategoryID,
+			Amount:     params.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = s.addTagsTx(ctx, tq, repo.TaggableTypeExpenseSplit, split.ID, userID, params.Tags)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
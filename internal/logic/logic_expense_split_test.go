@@ -0,0 +1,200 @@
+package logic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpenseSplits(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "expense_split_user_1",
+		Email:        "expense_split_user_1@example.com",
+		PasswordHash: []byte("expense_split_hash_1"),
+	})
+	other := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "expense_split_user_2",
+		Email:        "expense_split_user_2@example.com",
+		PasswordHash: []byte("expense_split_hash_2"),
+	})
+	food := s.CreateCategory(t, user.ID, "Pantry")
+	household := s.CreateCategory(t, user.ID, "Cleaning supplies")
+	receipts := s.CreateCategory(t, user.ID, "Receipts")
+	foreign := s.CreateCategory(t, other.ID, "Pantry")
+	date := time.Date(2026, time.May, 4, 0, 0, 0, 0, time.UTC).Unix()
+
+	splitReceipt := func(description string, splits ...logic.ExpenseSplitParams) logic.ExpenseParams {
+		params := newExpenseParams(receipts.ID, description, 0, date, nil)
+		for _, split := range splits {
+			params.Amount += split.Amount
+		}
+		params.Splits = splits
+
+		return params
+	}
+	userFilters := func(userID int) repo.Filters {
+		return repo.Filters{
+			FilterFields: []repo.FilterField{{Name: "user_id", Value: userID, Operator: "="}},
+		}
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_save_split_lines_with_their_tags",
+			fn: func(t *testing.T) {
+				expense := s.CreateExpense(t, user.ID, splitReceipt("Supermarket",
+					logic.ExpenseSplitParams{CategoryID: food.ID, Amount: 4200, Tags: []string{"weekly"}},
+					logic.ExpenseSplitParams{CategoryID: household.ID, Amount: 1300},
+				))
+
+				splits, err := s.Store.FindExpenseSplits(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+				require.Len(t, splits, 2)
+				require.Equal(t, food.ID, splits[0].CategoryID)
+				require.Equal(t, uint64(4200), splits[0].Amount)
+				require.Equal(t, []string{"weekly"}, splits[0].Tags)
+				require.Equal(t, household.ID, splits[1].CategoryID)
+				require.Empty(t, splits[1].Tags)
+			},
+		},
+		{
+			name: "should_reject_splits_that_do_not_add_up",
+			fn: func(t *testing.T) {
+				params := splitReceipt("Corner shop",
+					logic.ExpenseSplitParams{CategoryID: food.ID, Amount: 700},
+					logic.ExpenseSplitParams{CategoryID: household.ID, Amount: 300},
+				)
+				params.Amount = 1100
+
+				_, err := s.Store.CreateExpense(ctx, user.ID, params)
+				require.ErrorIs(t, err, logic.ErrExpenseSplitsTotal)
+
+				_, err = s.Store.CreateExpenseChecked(ctx, user.ID, params, logic.DuplicateActionForce)
+				require.ErrorIs(t, err, logic.ErrExpenseSplitsTotal)
+			},
+		},
+		{
+			name: "should_reject_another_users_category_on_a_split",
+			fn: func(t *testing.T) {
+				_, err := s.Store.CreateExpense(ctx, user.ID, splitReceipt("Market stall",
+					logic.ExpenseSplitParams{CategoryID: food.ID, Amount: 500},
+					logic.ExpenseSplitParams{CategoryID: foreign.ID, Amount: 500},
+				))
+				require.ErrorIs(t, err, logic.ErrUnknownCategory)
+			},
+		},
+		{
+			name: "should_replace_and_clear_splits_on_update",
+			fn: func(t *testing.T) {
+				expense := s.CreateExpense(t, user.ID, splitReceipt("Hardware store",
+					logic.ExpenseSplitParams{CategoryID: food.ID, Amount: 200, Tags: []string{"snacks"}},
+					logic.ExpenseSplitParams{CategoryID: household.ID, Amount: 800},
+				))
+
+				params := splitReceipt("Hardware store",
+					logic.ExpenseSplitParams{CategoryID: household.ID, Amount: 1000, Tags: []string{"tools"}},
+				)
+				_, err := s.Store.UpdateExpense(ctx, expense.ID, user.ID, params)
+				require.NoError(t, err)
+
+				splits, err := s.Store.FindExpenseSplits(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+				require.Len(t, splits, 1)
+				require.Equal(t, []string{"tools"}, splits[0].Tags)
+
+				params.Splits = nil
+				_, err = s.Store.UpdateExpense(ctx, expense.ID, user.ID, params)
+				require.NoError(t, err)
+
+				splits, err = s.Store.FindExpenseSplits(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+				require.Empty(t, splits)
+			},
+		},
+		{
+			name: "should_attribute_each_split_to_its_category_in_totals",
+			fn: func(t *testing.T) {
+				owner := s.CreateUser(t, repo.InsertUserParams{
+					Username:     "expense_split_user_3",
+					Email:        "expense_split_user_3@example.com",
+					PasswordHash: []byte("expense_split_hash_3"),
+				})
+				groceries := s.CreateCategory(t, owner.ID, "Fresh food")
+				cleaning := s.CreateCategory(t, owner.ID, "Detergents")
+				home := s.CreateCategory(t, owner.ID, "Home")
+				_, err := s.Store.SetCategoryParent(ctx, cleaning.ID, owner.ID, &home.ID)
+				require.NoError(t, err)
+
+				params := newExpenseParams(groceries.ID, "Big shop", 6000, date, nil)
+				params.Splits = []logic.ExpenseSplitParams{
+					{CategoryID: groceries.ID, Amount: 4500},
+					{CategoryID: cleaning.ID, Amount: 1500},
+				}
+				s.CreateExpense(t, owner.ID, params)
+				s.CreateExpense(t, owner.ID, newExpenseParams(groceries.ID, "Bread", 300, date, nil))
+
+				totals, err := s.Store.FindExpensesCategoryTotals(ctx, userFilters(owner.ID), "USD", false)
+				require.NoError(t, err)
+				byCategory := map[int]uint64{}
+				for _, row := range totals {
+					byCategory[row.CategoryID] = row.Total
+				}
+				require.Equal(t, uint64(4800), byCategory[groceries.ID])
+				require.Equal(t, uint64(1500), byCategory[cleaning.ID])
+
+				monthTotals, err := s.Store.FindExpensesCategoryMonthTotals(ctx, userFilters(owner.ID), "USD", true)
+				require.NoError(t, err)
+				byCategory = map[int]uint64{}
+				for _, row := range monthTotals {
+					require.Equal(t, "2026-05", row.Month)
+					byCategory[row.CategoryID] = row.Total
+				}
+				require.Equal(t, uint64(4800), byCategory[groceries.ID])
+				require.Equal(t, uint64(1500), byCategory[home.ID])
+			},
+		},
+		{
+			name: "should_export_splits_with_their_categories_and_tags",
+			fn: func(t *testing.T) {
+				owner := s.CreateUser(t, repo.InsertUserParams{
+					Username:     "expense_split_user_4",
+					Email:        "expense_split_user_4@example.com",
+					PasswordHash: []byte("expense_split_hash_4"),
+				})
+				groceries := s.CreateCategory(t, owner.ID, "Fresh food")
+				cleaning := s.CreateCategory(t, owner.ID, "Detergents")
+
+				params := newExpenseParams(groceries.ID, "Big shop", 2500, date, nil)
+				params.Splits = []logic.ExpenseSplitParams{
+					{CategoryID: groceries.ID, Amount: 2000, Tags: []string{"organic"}},
+					{CategoryID: cleaning.ID, Amount: 500},
+				}
+				s.CreateExpense(t, owner.ID, params)
+				s.CreateExpense(t, owner.ID, newExpenseParams(groceries.ID, "Milk", 150, date-86400, nil))
+
+				out, err := s.Store.ExportExpenses(ctx, owner.ID)
+				require.NoError(t, err)
+				require.Len(t, out, 2)
+				require.Len(t, out[0].Splits, 2)
+				require.Equal(t, "Fresh food", out[0].Splits[0].Category.Name)
+				require.Equal(t, []string{"organic"}, out[0].Splits[0].Tags)
+				require.Equal(t, "Detergents", out[0].Splits[1].Category.Name)
+				require.Equal(t, uint64(500), out[0].Splits[1].Amount)
+				require.Empty(t, out[1].Splits)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
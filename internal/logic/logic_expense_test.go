@@ -2,6 +2,7 @@ package logic_test
 
 import (
 	"database/sql"
+	"strings"
 	"testing"
 
 	"github.com/ad9311/ninete/internal/logic"
@@ -18,7 +19,7 @@ func TestCreateExpense(t *testing.T) {
 		Email:        "expense_user_1@example.com",
 		PasswordHash: []byte("expense_user_hash_1"),
 	})
-	category := s.CreateCategory(t, "expense category 1")
+	category := s.CreateCategory(t, user.ID, "expense category 1")
 
 	cases := []struct {
 		name string
@@ -77,7 +78,8 @@ func TestFindExpenses(t *testing.T) {
 		Email:        "expense_user_3@example.com",
 		PasswordHash: []byte("expense_user_hash_3"),
 	})
-	category := s.CreateCategory(t, "expense category 2")
+	category := s.CreateCategory(t, user.ID, "expense category 2")
+	otherCategory := s.CreateCategory(t, otherUser.ID, "expense category 2")
 
 	cases := []struct {
 		name string
@@ -96,7 +98,11 @@ func TestFindExpenses(t *testing.T) {
 					user.ID,
 					newExpenseParams(category.ID, "expense description 3", 200, 1735862400, nil),
 				)
-				s.CreateExpense(t, otherUser.ID, newExpenseParams(category.ID, "expense description 4", 300, 1735948800, nil))
+				s.CreateExpense(
+					t,
+					otherUser.ID,
+					newExpenseParams(otherCategory.ID, "expense description 4", 300, 1735948800, nil),
+				)
 
 				expenses, err := s.Store.FindExpenses(ctx, repo.QueryOptions{
 					Filters: repo.Filters{
@@ -144,7 +150,8 @@ func TestCountExpenses(t *testing.T) {
 		Email:        "expense_user_5@example.com",
 		PasswordHash: []byte("expense_user_hash_5"),
 	})
-	category := s.CreateCategory(t, "expense category 3")
+	category := s.CreateCategory(t, user.ID, "expense category 3")
+	otherCategory := s.CreateCategory(t, otherUser.ID, "expense category 3")
 
 	cases := []struct {
 		name string
@@ -155,7 +162,11 @@ func TestCountExpenses(t *testing.T) {
 			fn: func(t *testing.T) {
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "expense description 5", 100, 1736035200, nil))
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "expense description 6", 200, 1736121600, nil))
-				s.CreateExpense(t, otherUser.ID, newExpenseParams(category.ID, "expense description 7", 300, 1736208000, nil))
+				s.CreateExpense(
+					t,
+					otherUser.ID,
+					newExpenseParams(otherCategory.ID, "expense description 7", 300, 1736208000, nil),
+				)
 
 				count, err := s.Store.CountExpenses(ctx, repo.Filters{
 					FilterFields: []repo.FilterField{
@@ -197,7 +208,7 @@ func TestFindExpense(t *testing.T) {
 		Email:        "expense_user_7@example.com",
 		PasswordHash: []byte("expense_user_hash_7"),
 	})
-	category := s.CreateCategory(t, "expense category 4")
+	category := s.CreateCategory(t, user.ID, "expense category 4")
 
 	cases := []struct {
 		name string
@@ -248,7 +259,7 @@ func TestFindExpenseTagsAndRows(t *testing.T) {
 		Email:        "expense_user_9@example.com",
 		PasswordHash: []byte("expense_user_hash_9"),
 	})
-	category := s.CreateCategory(t, "expense category 5")
+	category := s.CreateCategory(t, user.ID, "expense category 5")
 
 	cases := []struct {
 		name string
@@ -322,8 +333,9 @@ func TestUpdateExpense(t *testing.T) {
 		Email:        "expense_user_11@example.com",
 		PasswordHash: []byte("expense_user_hash_11"),
 	})
-	categoryOne := s.CreateCategory(t, "expense category 6")
-	categoryTwo := s.CreateCategory(t, "expense category 7")
+	categoryOne := s.CreateCategory(t, user.ID, "expense category 6")
+	categoryTwo := s.CreateCategory(t, user.ID, "expense category 7")
+	otherCategory := s.CreateCategory(t, otherUser.ID, "expense category 7")
 
 	cases := []struct {
 		name string
@@ -366,7 +378,7 @@ func TestUpdateExpense(t *testing.T) {
 					ctx,
 					expense.ID,
 					otherUser.ID,
-					newExpenseParams(categoryTwo.ID, "expense description 14 updated", 1500, 1736985600, nil),
+					newExpenseParams(otherCategory.ID, "expense description 14 updated", 1500, 1736985600, nil),
 				)
 				require.ErrorIs(t, err, sql.ErrNoRows)
 			},
@@ -403,7 +415,7 @@ func TestDeleteExpense(t *testing.T) {
 		Email:        "expense_user_13@example.com",
 		PasswordHash: []byte("expense_user_hash_13"),
 	})
-	category := s.CreateCategory(t, "expense category 8")
+	category := s.CreateCategory(t, user.ID, "expense category 8")
 
 	cases := []struct {
 		name string
@@ -444,8 +456,6 @@ func TestDeleteExpense(t *testing.T) {
 func TestFindExpensesCategoryTotals(t *testing.T) {
 	s := spec.New(t)
 	ctx := t.Context()
-	categoryOne := s.CreateCategory(t, "expense category 9")
-	categoryTwo := s.CreateCategory(t, "expense category 10")
 
 	const dateJan10 int64 = 1736467200 // 2026-01-10
 	const dateJan20 int64 = 1737331200 // 2026-01-20
@@ -467,17 +477,20 @@ func TestFindExpensesCategoryTotals(t *testing.T) {
 					Email:        "expense_user_15@example.com",
 					PasswordHash: []byte("expense_user_hash_15"),
 				})
+				categoryOne := s.CreateCategory(t, user.ID, "expense category 9")
+				categoryTwo := s.CreateCategory(t, user.ID, "expense category 10")
+				otherCategory := s.CreateCategory(t, otherUser.ID, "expense category 9")
 
 				s.CreateExpense(t, user.ID, newExpenseParams(categoryOne.ID, "totals_exp_1", 100, dateJan10, nil))
 				s.CreateExpense(t, user.ID, newExpenseParams(categoryOne.ID, "totals_exp_2", 200, dateJan10, nil))
 				s.CreateExpense(t, user.ID, newExpenseParams(categoryTwo.ID, "totals_exp_3", 400, dateJan10, nil))
-				s.CreateExpense(t, otherUser.ID, newExpenseParams(categoryOne.ID, "totals_exp_4", 999, dateJan10, nil))
+				s.CreateExpense(t, otherUser.ID, newExpenseParams(otherCategory.ID, "totals_exp_4", 999, dateJan10, nil))
 
 				totals, err := s.Store.FindExpensesCategoryTotals(ctx, repo.Filters{
 					FilterFields: []repo.FilterField{
 						{Name: "user_id", Value: user.ID, Operator: "="},
 					},
-				})
+				}, "USD", false)
 				require.NoError(t, err)
 				require.Len(t, totals, 2)
 
@@ -497,6 +510,7 @@ func TestFindExpensesCategoryTotals(t *testing.T) {
 					Email:        "expense_user_16@example.com",
 					PasswordHash: []byte("expense_user_hash_16"),
 				})
+				categoryOne := s.CreateCategory(t, user.ID, "expense category 11")
 
 				s.CreateExpense(t, user.ID, newExpenseParams(categoryOne.ID, "totals_exp_5", 500, dateJan10, nil))
 				s.CreateExpense(t, user.ID, newExpenseParams(categoryOne.ID, "totals_exp_6", 600, dateJan20, nil))
@@ -508,13 +522,86 @@ func TestFindExpensesCategoryTotals(t *testing.T) {
 						{Name: "date", Value: dateJan20, Operator: "<"},
 					},
 					Connector: "AND",
-				})
+				}, "USD", false)
 				require.NoError(t, err)
 				require.Len(t, totals, 1)
 				require.Equal(t, categoryOne.ID, totals[0].CategoryID)
 				require.Equal(t, uint64(500), totals[0].Total)
 			},
 		},
+		{
+			name: "should_roll_children_up_into_their_parent",
+			fn: func(t *testing.T) {
+				user := s.CreateUser(t, repo.InsertUserParams{
+					Username:     "expense_user_rollup",
+					Email:        "expense_user_rollup@example.com",
+					PasswordHash: []byte("expense_user_hash_rollup"),
+				})
+				parent := s.CreateCategory(t, user.ID, "expense rollup parent")
+				child := s.CreateCategory(t, user.ID, "expense rollup child")
+				_, err := s.Store.SetCategoryParent(ctx, child.ID, user.ID, &parent.ID)
+				require.NoError(t, err)
+
+				s.CreateExpense(t, user.ID, newExpenseParams(parent.ID, "rollup_exp_1", 100, dateJan10, nil))
+				s.CreateExpense(t, user.ID, newExpenseParams(child.ID, "rollup_exp_2", 250, dateJan10, nil))
+
+				filters := repo.Filters{
+					FilterFields: []repo.FilterField{
+						{Name: "user_id", Value: user.ID, Operator: "="},
+					},
+				}
+
+				totals, err := s.Store.FindExpensesCategoryTotals(ctx, filters, "USD", true)
+				require.NoError(t, err)
+				require.Len(t, totals, 1)
+				require.Equal(t, parent.ID, totals[0].CategoryID)
+				require.Equal(t, uint64(350), totals[0].Total)
+
+				totals, err = s.Store.FindExpensesCategoryTotals(ctx, filters, "USD", false)
+				require.NoError(t, err)
+				require.Len(t, totals, 2)
+			},
+		},
+		{
+			name: "should_convert_foreign_amounts_with_the_rate_for_the_expense_date",
+			fn: func(t *testing.T) {
+				user := s.CreateUser(t, repo.InsertUserParams{
+					Username:     "expense_user_fx",
+					Email:        "expense_user_fx@example.com",
+					PasswordHash: []byte("expense_user_hash_fx"),
+				})
+				category := s.CreateCategory(t, user.ID, "expense fx category")
+
+				_, err := s.Store.ImportExchangeRates(ctx, strings.NewReader(
+					"date,base_currency,quote_currency,rate\n2025-01-01,EUR,USD,1.10\n2025-01-15,EUR,USD,1.20\n",
+				))
+				require.NoError(t, err)
+
+				early := newExpenseParams(category.ID, "fx_exp_1", 1000, dateJan10, nil)
+				early.Currency = "eur"
+				late := newExpenseParams(category.ID, "fx_exp_2", 1000, dateJan20, nil)
+				late.Currency = "EUR"
+				s.CreateExpense(t, user.ID, early)
+				s.CreateExpense(t, user.ID, late)
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "fx_exp_3", 500, dateJan10, nil))
+
+				filters := repo.Filters{
+					FilterFields: []repo.FilterField{
+						{Name: "user_id", Value: user.ID, Operator: "="},
+					},
+				}
+
+				totals, err := s.Store.FindExpensesCategoryTotals(ctx, filters, "USD", false)
+				require.NoError(t, err)
+				require.Len(t, totals, 1)
+				require.Equal(t, uint64(1100+1200+500), totals[0].Total)
+
+				totals, err = s.Store.FindExpensesCategoryTotals(ctx, filters, "EUR", false)
+				require.NoError(t, err)
+				require.Len(t, totals, 1)
+				require.Equal(t, uint64(1000+1000+455), totals[0].Total)
+			},
+		},
 		{
 			name: "should_return_empty_when_user_has_no_expenses",
 			fn: func(t *testing.T) {
@@ -528,7 +615,7 @@ func TestFindExpensesCategoryTotals(t *testing.T) {
 					FilterFields: []repo.FilterField{
 						{Name: "user_id", Value: user.ID, Operator: "="},
 					},
-				})
+				}, "USD", false)
 				require.NoError(t, err)
 				require.Empty(t, totals)
 			},
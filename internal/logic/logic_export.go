@@ -15,11 +15,24 @@ type ExportExpense struct {
 	ID          int             `json:"id"`
 	Description string          `json:"description"`
 	Amount      uint64          `json:"amount"`
+	Currency    string          `json:"currency"`
 	BilledAt    int64           `json:"billed_at"`
 	CreatedAt   int64           `json:"created_at"`
 	UpdatedAt   int64           `json:"updated_at"`
 	Category    *ExportCategory `json:"category"`
 	Tags        []string        `json:"tags"`
+	// PaymentAccountID is the account the expense was paid from, null when
+	// none was picked.
+	PaymentAccountID *int `json:"payment_account_id"`
+	// Splits is empty unless the expense is split across categories, in which
+	// case each line counts toward its own category.
+	Splits []ExportExpenseSplit `json:"splits"`
+}
+
+type ExportExpenseSplit struct {
+	Amount   uint64          `json:"amount"`
+	Category *ExportCategory `json:"category"`
+	Tags     []string        `json:"tags"`
 }
 
 func (s *Store) ExportExpenses(ctx context.Context, userID int) ([]ExportExpense, error) {
@@ -38,7 +51,7 @@ func (s *Store) ExportExpenses(ctx context.Context, userID int) ([]ExportExpense
 		return nil, err
 	}
 
-	categories, err := s.queries.SelectCategories(ctx)
+	categories, err := s.queries.SelectCategoriesByUser(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -59,29 +72,64 @@ func (s *Store) ExportExpenses(ctx context.Context, userID int) ([]ExportExpense
 
 	tagsByExpenseID := repo.TagNamesByTargetID(tagRows)
 
+	splitsByExpenseID, err := s.FindExpenseSplitsByExpenseID(ctx, expenseIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	out := make([]ExportExpense, 0, len(expenses))
 	for _, e := range expenses {
-		var cat *ExportCategory
-		if c, ok := categoryByID[e.CategoryID]; ok {
-			cat = &ExportCategory{Name: c.Name, UID: c.UID}
-		}
+		out = append(out, toExportExpense(e, categoryByID, tagsByExpenseID, splitsByExpenseID))
+	}
 
-		tags := tagsByExpenseID[e.ID]
-		if tags == nil {
-			tags = []string{}
+	return out, nil
+}
+
+func toExportExpense(
+	e repo.Expense,
+	categoryByID map[int]repo.Category,
+	tagsByExpenseID map[int][]string,
+	splitsByExpenseID map[int][]ExpenseSplit,
+) ExportExpense {
+	tags := tagsByExpenseID[e.ID]
+	if tags == nil {
+		tags = []string{}
+	}
+
+	splits := make([]ExportExpenseSplit, 0, len(splitsByExpenseID[e.ID]))
+	for _, split := range splitsByExpenseID[e.ID] {
+		splitTags := split.Tags
+		if splitTags == nil {
+			splitTags = []string{}
 		}
 
-		out = append(out, ExportExpense{
-			ID:          e.ID,
-			Description: e.Description,
-			Amount:      e.Amount,
-			BilledAt:    e.Date,
-			CreatedAt:   e.CreatedAt,
-			UpdatedAt:   e.UpdatedAt,
-			Category:    cat,
-			Tags:        tags,
+		splits = append(splits, ExportExpenseSplit{
+			Amount:   split.Amount,
+			Category: toExportCategory(categoryByID, split.CategoryID),
+			Tags:     splitTags,
 		})
 	}
 
-	return out, nil
+	return ExportExpense{
+		ID:               e.ID,
+		Description:      e.Description,
+		Amount:           e.Amount,
+		Currency:         e.Currency,
+		BilledAt:         e.Date,
+		CreatedAt:        e.CreatedAt,
+		UpdatedAt:        e.UpdatedAt,
+		Category:         toExportCategory(categoryByID, e.CategoryID),
+		Tags:             tags,
+		PaymentAccountID: e.PaymentAccountID,
+		Splits:           splits,
+	}
+}
+
+func toExportCategory(categoryByID map[int]repo.Category, categoryID int) *ExportCategory {
+	c, ok := categoryByID[categoryID]
+	if !ok {
+		return nil
+	}
+
+	return &ExportCategory{Name: c.Name, UID: c.UID}
 }
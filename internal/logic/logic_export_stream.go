@@ -0,0 +1,1357 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// Export formats. CSV has a header row; NDJSON is one JSON object per line
+// with the same keys the expenses JSON export uses.
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// Export areas, one per downloadable file.
+const (
+	ExportAreaExpenses             = "expenses"
+	ExportAreaRecurrentExpenses    = "recurrent_expenses"
+	ExportAreaIncomes              = "incomes"
+	ExportAreaRecurrentIncomes     = "recurrent_incomes"
+	ExportAreaMacroEntries         = "macro_entries"
+	ExportAreaMacroGoals           = "macro_goals"
+	ExportAreaFoods                = "foods"
+	ExportAreaMoodEntries          = "mood_entries"
+	ExportAreaTags                 = "tags"
+	ExportAreaBudgets              = "budgets"
+	ExportAreaSavingsGoals         = "savings_goals"
+	ExportAreaSavingsContributions = "savings_contributions"
+	ExportAreaPaymentAccounts      = "payment_accounts"
+	ExportAreaRecipes              = "recipes"
+	ExportAreaRecipeIngredients    = "recipe_ingredients"
+	ExportAreaMealTemplates        = "meal_templates"
+	ExportAreaMealTemplateItems    = "meal_template_items"
+)
+
+// exportBatchSize is how many rows are read, tagged and written at a time.
+// It matches the tag lookup's IN-list chunk, so a batch costs one tag query.
+const exportBatchSize = 500
+
+// exportRecord is a row of any area. NDJSON encodes the record itself; CSV
+// writes csvRow, whose fields follow the area's header.
+type exportRecord interface {
+	csvRow() []string
+}
+
+type exportArea struct {
+	header []string
+	each   func(s *Store, ctx context.Context, userID int, emit func(exportRecord) error) error
+}
+
+var exportAreas = map[string]exportArea{ //nolint:gochecknoglobals // static lookup table
+	ExportAreaExpenses: {
+		header: []string{
+			"id", "description", "amount", "billed_at", "created_at", "updated_at",
+			"category_name", "category_uid", "tags", "currency", "payment_account_id",
+		},
+		each: (*Store).eachExportExpense,
+	},
+	ExportAreaRecurrentExpenses: {
+		header: []string{
+			"id", "description", "amount", "period", "occurrence_limit", "occurrence_count",
+			"last_copy_created_at", "archived_at", "created_at", "updated_at",
+			"category_name", "category_uid", "tags", "currency",
+			"frequency", "anchor_day", "starts_at", "ends_at", "is_estimate",
+		},
+		each: (*Store).eachExportRecurrentExpense,
+	},
+	ExportAreaIncomes: {
+		header: []string{
+			"id", "source", "amount", "currency", "date", "recurrent_income_id", "created_at", "updated_at", "tags",
+		},
+		each: (*Store).eachExportIncome,
+	},
+	ExportAreaRecurrentIncomes: {
+		header: []string{
+			"id", "source", "amount", "currency", "period", "frequency", "anchor_day", "starts_at", "ends_at",
+			"occurrence_limit", "occurrence_count", "last_copy_created_at", "archived_at", "created_at", "updated_at",
+			"tags",
+		},
+		each: (*Store).eachExportRecurrentIncome,
+	},
+	ExportAreaMacroEntries: {
+		header: []string{
+			"id", "name", "meal_type", "kcal", "protein_g", "carbs_g", "fat_g",
+			"fiber_g", "sodium_g", "saturated_fat_g", "date", "created_at", "updated_at",
+		},
+		each: (*Store).eachExportMacroEntry,
+	},
+	ExportAreaMacroGoals: {
+		header: []string{
+			"kcal", "protein_g", "carbs_g", "fat_g", "fiber_g", "sodium_g", "saturated_fat_g",
+			"created_at", "updated_at",
+		},
+		each: (*Store).eachExportMacroGoal,
+	},
+	ExportAreaFoods: {
+		header: []string{
+			"id", "name", "kcal", "protein_g", "carbs_g", "fat_g",
+			"fiber_g", "sodium_g", "saturated_fat_g", "base_amount", "base_unit", "density_g_per_ml",
+			"barcode", "created_at", "updated_at",
+		},
+		each: (*Store).eachExportFood,
+	},
+	ExportAreaRecipes: {
+		header: []string{"id", "name", "servings", "created_at", "updated_at"},
+		each:   (*Store).eachExportRecipe,
+	},
+	ExportAreaRecipeIngredients: {
+		header: []string{"id", "recipe_id", "food_id", "food_name", "quantity_g", "created_at", "updated_at"},
+		each:   (*Store).eachExportRecipeIngredient,
+	},
+	ExportAreaMealTemplates: {
+		header: []string{"id", "name", "meal_type", "created_at", "updated_at"},
+		each:   (*Store).eachExportMealTemplate,
+	},
+	ExportAreaMealTemplateItems: {
+		header: []string{
+			"id", "meal_template_id", "food_id", "food_name", "quantity", "unit", "created_at", "updated_at",
+		},
+		each: (*Store).eachExportMealTemplateItem,
+	},
+	ExportAreaMoodEntries: {
+		header: []string{"id", "mood", "notes", "logged_at", "created_at", "updated_at", "tags"},
+		each:   (*Store).eachExportMoodEntry,
+	},
+	ExportAreaTags: {
+		header: []string{"id", "name", "created_at", "updated_at"},
+		each:   (*Store).eachExportTag,
+	},
+	ExportAreaBudgets: {
+		header: []string{
+			"category_name", "category_uid", "amount", "rollover", "effective_from", "created_at", "updated_at",
+		},
+		each: (*Store).eachExportBudget,
+	},
+	ExportAreaSavingsGoals: {
+		header: []string{"id", "name", "target_amount", "target_date", "created_at", "updated_at"},
+		each:   (*Store).eachExportSavingsGoal,
+	},
+	ExportAreaSavingsContributions: {
+		header: []string{"id", "savings_goal_id", "amount", "date", "note", "created_at", "updated_at"},
+		each:   (*Store).eachExportSavingsContribution,
+	},
+	ExportAreaPaymentAccounts: {
+		header: []string{
+			"id", "name", "kind", "currency", "opening_balance", "reconciled_through", "created_at", "updated_at",
+		},
+		each: (*Store).eachExportPaymentAccount,
+	},
+}
+
+// ExportAreas lists every area in the order the exports page shows them.
+func ExportAreas() []string {
+	return []string{
+		ExportAreaExpenses,
+		ExportAreaRecurrentExpenses,
+		ExportAreaIncomes,
+		ExportAreaRecurrentIncomes,
+		ExportAreaPaymentAccounts,
+		ExportAreaBudgets,
+		ExportAreaTags,
+		ExportAreaMacroEntries,
+		ExportAreaMacroGoals,
+		ExportAreaFoods,
+		ExportAreaRecipes,
+		ExportAreaRecipeIngredients,
+		ExportAreaMealTemplates,
+		ExportAreaMealTemplateItems,
+		ExportAreaMoodEntries,
+		ExportAreaSavingsGoals,
+		ExportAreaSavingsContributions,
+	}
+}
+
+// ValidateExport reports whether area and format name a downloadable export,
+// so a caller can reject a request before committing to a response.
+func ValidateExport(area, format string) error {
+	if _, ok := exportAreas[area]; !ok {
+		return ErrUnknownExportArea
+	}
+	if format != ExportFormatCSV && format != ExportFormatNDJSON {
+		return ErrUnknownExportFormat
+	}
+
+	return nil
+}
+
+// StreamExport writes every row of one area to w. Rows are read in batches of
+// exportBatchSize and written as each batch is tagged, so the full export is
+// never held in memory.
+func (s *Store) StreamExport(ctx context.Context, w io.Writer, userID int, area, format string) error {
+	if err := ValidateExport(area, format); err != nil {
+		return err
+	}
+	spec := exportAreas[area]
+
+	if format == ExportFormatNDJSON {
+		enc := json.NewEncoder(w)
+
+		return spec.each(s, ctx, userID, func(record exportRecord) error {
+			return enc.Encode(record)
+		})
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(spec.header); err != nil {
+		return err
+	}
+
+	err := spec.each(s, ctx, userID, func(record exportRecord) error {
+		return cw.Write(record.csvRow())
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// userBatchOptions selects the next batch of a user's rows after afterID.
+// Walking the primary key keeps every batch an index seek, however deep the
+// export is.
+func userBatchOptions(userID, afterID int) repo.QueryOptions {
+	return repo.QueryOptions{
+		Filters: repo.Filters{
+			FilterFields: []repo.FilterField{
+				{Name: "user_id", Value: userID, Operator: "="},
+				{Name: "id", Value: afterID, Operator: ">"},
+			},
+			Connector: "AND",
+		},
+		Sorting:    repo.Sorting{Field: "id", Order: "ASC"},
+		Pagination: repo.Pagination{Page: 1, PerPage: exportBatchSize},
+	}
+}
+
+// eachBatch pages through a user's rows with userBatchOptions until a short
+// batch comes back, handing each batch to fn.
+func eachBatch[T any](
+	userID int,
+	selectBatch func(repo.QueryOptions) ([]T, error),
+	idOf func(T) int,
+	fn func([]T) error,
+) error {
+	afterID := 0
+	for {
+		batch, err := selectBatch(userBatchOptions(userID, afterID))
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := fn(batch); err != nil {
+			return err
+		}
+
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+		afterID = idOf(batch[len(batch)-1])
+	}
+}
+
+func (s *Store) exportCategoryByID(ctx context.Context, userID int) (map[int]repo.Category, error) {
+	categories, err := s.queries.SelectCategoriesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryByID := make(map[int]repo.Category, len(categories))
+	for _, c := range categories {
+		categoryByID[c.ID] = c
+	}
+
+	return categoryByID, nil
+}
+
+func (s *Store) exportTagNames(
+	ctx context.Context,
+	taggableType, joinTable string,
+	ids []int,
+	userID int,
+) (map[int][]string, error) {
+	tagRows, err := s.queries.SelectTagRows(ctx, taggableType, joinTable, ids, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.TagNamesByTargetID(tagRows), nil
+}
+
+// ----------------------------------------------------------------------------- //
+// Areas
+// ----------------------------------------------------------------------------- //
+
+func (s *Store) eachExportExpense(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	categoryByID, err := s.exportCategoryByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return eachBatch(userID,
+		func(opts repo.QueryOptions) ([]repo.Expense, error) { return s.queries.SelectExpenses(ctx, opts) },
+		func(e repo.Expense) int { return e.ID },
+		func(batch []repo.Expense) error {
+			ids := make([]int, 0, len(batch))
+			for _, e := range batch {
+				ids = append(ids, e.ID)
+			}
+
+			tagsByID, err := s.exportTagNames(ctx, repo.TaggableTypeExpense, "expenses", ids, userID)
+			if err != nil {
+				return err
+			}
+
+			splitsByID, err := s.FindExpenseSplitsByExpenseID(ctx, ids, userID)
+			if err != nil {
+				return err
+			}
+
+			for _, e := range batch {
+				if err := emit(toExportExpense(e, categoryByID, tagsByID, splitsByID)); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
+
+type ExportRecurrentExpense struct {
+	ID                int             `json:"id"`
+	Description       string          `json:"description"`
+	Amount            uint64          `json:"amount"`
+	Currency          string          `json:"currency"`
+	Period            uint            `json:"period"`
+	Frequency         string          `json:"frequency"`
+	AnchorDay         uint            `json:"anchor_day"`
+	StartsAt          *int64          `json:"starts_at"`
+	EndsAt            *int64          `json:"ends_at"`
+	IsEstimate        bool            `json:"is_estimate"`
+	OccurrenceLimit   uint            `json:"occurrence_limit"`
+	OccurrenceCount   uint            `json:"occurrence_count"`
+	LastCopyCreatedAt *int64          `json:"last_copy_created_at"`
+	ArchivedAt        *int64          `json:"archived_at"`
+	CreatedAt         int64           `json:"created_at"`
+	UpdatedAt         int64           `json:"updated_at"`
+	Category          *ExportCategory `json:"category"`
+	Tags              []string        `json:"tags"`
+}
+
+func (s *Store) eachExportRecurrentExpense(
+	ctx context.Context,
+	userID int,
+	emit func(exportRecord) error,
+) error {
+	categoryByID, err := s.exportCategoryByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return eachBatch(userID,
+		func(opts repo.QueryOptions) ([]repo.RecurrentExpense, error) {
+			return s.queries.SelectRecurrentExpenses(ctx, opts)
+		},
+		func(e repo.RecurrentExpense) int { return e.ID },
+		func(batch []repo.RecurrentExpense) error {
+			ids := make([]int, 0, len(batch))
+			for _, e := range batch {
+				ids = append(ids, e.ID)
+			}
+
+			tagsByID, err := s.exportTagNames(
+				ctx, repo.TaggableTypeRecurrentExpense, "recurrent_expenses", ids, userID,
+			)
+			if err != nil {
+				return err
+			}
+
+			for _, e := range batch {
+				tags := tagsByID[e.ID]
+				if tags == nil {
+					tags = []string{}
+				}
+
+				err := emit(ExportRecurrentExpense{
+					ID:                e.ID,
+					Description:       e.Description,
+					Amount:            e.Amount,
+					Currency:          e.Currency,
+					Period:            e.Period,
+					Frequency:         e.Frequency,
+					AnchorDay:         e.AnchorDay,
+					StartsAt:          e.StartsAt,
+					EndsAt:            e.EndsAt,
+					IsEstimate:        e.IsEstimate,
+					OccurrenceLimit:   e.OccurrenceLimit,
+					OccurrenceCount:   e.OccurrenceCount,
+					LastCopyCreatedAt: e.LastCopyCreatedAt,
+					ArchivedAt:        e.ArchivedAt,
+					CreatedAt:         e.CreatedAt,
+					UpdatedAt:         e.UpdatedAt,
+					Category:          toExportCategory(categoryByID, e.CategoryID),
+					Tags:              tags,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
+
+type ExportIncome struct {
+	ID                int      `json:"id"`
+	Source            string   `json:"source"`
+	Amount            uint64   `json:"amount"`
+	Currency          string   `json:"currency"`
+	Date              int64    `json:"date"`
+	RecurrentIncomeID *int     `json:"recurrent_income_id"`
+	CreatedAt         int64    `json:"created_at"`
+	UpdatedAt         int64    `json:"updated_at"`
+	Tags              []string `json:"tags"`
+}
+
+func (s *Store) eachExportIncome(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	return eachBatch(userID,
+		func(opts repo.QueryOptions) ([]repo.Income, error) { return s.queries.SelectIncomes(ctx, opts) },
+		func(i repo.Income) int { return i.ID },
+		func(batch []repo.Income) error {
+			ids := make([]int, 0, len(batch))
+			for _, i := range batch {
+				ids = append(ids, i.ID)
+			}
+
+			tagsByID, err := s.exportTagNames(ctx, repo.TaggableTypeIncome, "incomes", ids, userID)
+			if err != nil {
+				return err
+			}
+
+			for _, i := range batch {
+				tags := tagsByID[i.ID]
+				if tags == nil {
+					tags = []string{}
+				}
+
+				err := emit(ExportIncome{
+					ID:                i.ID,
+					Source:            i.Source,
+					Amount:            i.Amount,
+					Currency:          i.Currency,
+					Date:              i.Date,
+					RecurrentIncomeID: i.RecurrentIncomeID,
+					CreatedAt:         i.CreatedAt,
+					UpdatedAt:         i.UpdatedAt,
+					Tags:              tags,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
+
+type ExportRecurrentIncome struct {
+	ID                int      `json:"id"`
+	Source            string   `json:"source"`
+	Amount            uint64   `json:"amount"`
+	Currency          string   `json:"currency"`
+	Period            uint     `json:"period"`
+	Frequency         string   `json:"frequency"`
+	AnchorDay         uint     `json:"anchor_day"`
+	StartsAt          *int64   `json:"starts_at"`
+	EndsAt            *int64   `json:"ends_at"`
+	OccurrenceLimit   uint     `json:"occurrence_limit"`
+	OccurrenceCount   uint     `json:"occurrence_count"`
+	LastCopyCreatedAt *int64   `json:"last_copy_created_at"`
+	ArchivedAt        *int64   `json:"archived_at"`
+	CreatedAt         int64    `json:"created_at"`
+	UpdatedAt         int64    `json:"updated_at"`
+	Tags              []string `json:"tags"`
+}
+
+func (s *Store) eachExportRecurrentIncome(
+	ctx context.Context,
+	userID int,
+	emit func(exportRecord) error,
+) error {
+	return eachBatch(userID,
+		func(opts repo.QueryOptions) ([]repo.RecurrentIncome, error) {
+			return s.queries.SelectRecurrentIncomes(ctx, opts)
+		},
+		func(i repo.RecurrentIncome) int { return i.ID },
+		func(batch []repo.RecurrentIncome) error {
+			ids := make([]int, 0, len(batch))
+			for _, i := range batch {
+				ids = append(ids, i.ID)
+			}
+
+			tagsByID, err := s.exportTagNames(
+				ctx, repo.TaggableTypeRecurrentIncome, "recurrent_incomes", ids, userID,
+			)
+			if err != nil {
+				return err
+			}
+
+			for _, i := range batch {
+				tags := tagsByID[i.ID]
+				if tags == nil {
+					tags = []string{}
+				}
+
+				err := emit(ExportRecurrentIncome{
+					ID:                i.ID,
+					Source:            i.Source,
+					Amount:            i.Amount,
+					Currency:          i.Currency,
+					Period:            i.Period,
+					Frequency:         i.Frequency,
+					AnchorDay:         i.AnchorDay,
+					StartsAt:          i.StartsAt,
+					EndsAt:            i.EndsAt,
+					OccurrenceLimit:   i.OccurrenceLimit,
+					OccurrenceCount:   i.OccurrenceCount,
+					LastCopyCreatedAt: i.LastCopyCreatedAt,
+					ArchivedAt:        i.ArchivedAt,
+					CreatedAt:         i.CreatedAt,
+					UpdatedAt:         i.UpdatedAt,
+					Tags:              tags,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
+
+type ExportMacroEntry struct {
+	ID            int     `json:"id"`
+	Name          string  `json:"name"`
+	MealType      string  `json:"meal_type"`
+	Kcal          float64 `json:"kcal"`
+	ProteinG      float64 `json:"protein_g"`
+	CarbsG        float64 `json:"carbs_g"`
+	FatG          float64 `json:"fat_g"`
+	FiberG        float64 `json:"fiber_g"`
+	SodiumG       float64 `json:"sodium_g"`
+	SaturatedFatG float64 `json:"saturated_fat_g"`
+	Date          int64   `json:"date"`
+	CreatedAt     int64   `json:"created_at"`
+	UpdatedAt     int64   `json:"updated_at"`
+}
+
+func (s *Store) eachExportMacroEntry(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	return eachBatch(userID,
+		func(opts repo.QueryOptions) ([]repo.MacroEntry, error) {
+			return s.queries.SelectMacroEntries(ctx, opts)
+		},
+		func(e repo.MacroEntry) int { return e.ID },
+		func(batch []repo.MacroEntry) error {
+			for _, e := range batch {
+				err := emit(ExportMacroEntry{
+					ID:            e.ID,
+					Name:          e.Name,
+					MealType:      e.MealType,
+					Kcal:          e.Kcal,
+					ProteinG:      e.ProteinG,
+					CarbsG:        e.CarbsG,
+					FatG:          e.FatG,
+					FiberG:        e.FiberG,
+					SodiumG:       e.SodiumG,
+					SaturatedFatG: e.SaturatedFatG,
+					Date:          e.Date,
+					CreatedAt:     e.CreatedAt,
+					UpdatedAt:     e.UpdatedAt,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
+
+type ExportMacroGoal struct {
+	Kcal          float64 `json:"kcal"`
+	ProteinG      float64 `json:"protein_g"`
+	CarbsG        float64 `json:"carbs_g"`
+	FatG          float64 `json:"fat_g"`
+	FiberG        float64 `json:"fiber_g"`
+	SodiumG       float64 `json:"sodium_g"`
+	SaturatedFatG float64 `json:"saturated_fat_g"`
+	CreatedAt     int64   `json:"created_at"`
+	UpdatedAt     int64   `json:"updated_at"`
+}
+
+// eachExportMacroGoal emits the user's single goal row, or nothing when no
+// goal has been saved yet.
+func (s *Store) eachExportMacroGoal(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	g, err := s.queries.SelectMacroGoal(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return emit(ExportMacroGoal{
+		Kcal:          g.Kcal,
+		ProteinG:      g.ProteinG,
+		CarbsG:        g.CarbsG,
+		FatG:          g.FatG,
+		FiberG:        g.FiberG,
+		SodiumG:       g.SodiumG,
+		SaturatedFatG: g.SaturatedFatG,
+		CreatedAt:     g.CreatedAt,
+		UpdatedAt:     g.UpdatedAt,
+	})
+}
+
+type ExportFood struct {
+	ID            int      `json:"id"`
+	Name          string   `json:"name"`
+	Kcal          float64  `json:"kcal"`
+	ProteinG      float64  `json:"protein_g"`
+	CarbsG        float64  `json:"carbs_g"`
+	FatG          float64  `json:"fat_g"`
+	FiberG        float64  `json:"fiber_g"`
+	SodiumG       float64  `json:"sodium_g"`
+	SaturatedFatG float64  `json:"saturated_fat_g"`
+	BaseAmount    float64  `json:"base_amount"`
+	BaseUnit      string   `json:"base_unit"`
+	DensityGPerML *float64 `json:"density_g_per_ml"`
+	Barcode       *string  `json:"barcode"`
+	CreatedAt     int64    `json:"created_at"`
+	UpdatedAt     int64    `json:"updated_at"`
+}
+
+func (s *Store) eachExportFood(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	return eachBatch(userID,
+		func(opts repo.QueryOptions) ([]repo.Food, error) { return s.queries.SelectFoods(ctx, opts) },
+		func(f repo.Food) int { return f.ID },
+		func(batch []repo.Food) error {
+			for _, f := range batch {
+				err := emit(ExportFood{
+					ID:            f.ID,
+					Name:          f.Name,
+					Kcal:          f.Kcal,
+					ProteinG:      f.ProteinG,
+					CarbsG:        f.CarbsG,
+					FatG:          f.FatG,
+					FiberG:        f.FiberG,
+					SodiumG:       f.SodiumG,
+					SaturatedFatG: f.SaturatedFatG,
+					BaseAmount:    f.BaseAmount,
+					BaseUnit:      f.BaseUnit,
+					DensityGPerML: f.DensityGPerML,
+					Barcode:       f.Barcode,
+					CreatedAt:     f.CreatedAt,
+					UpdatedAt:     f.UpdatedAt,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
+
+type ExportRecipe struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Servings  float64 `json:"servings"`
+	CreatedAt int64   `json:"created_at"`
+	UpdatedAt int64   `json:"updated_at"`
+}
+
+// eachExportRecipe reads every recipe at once; recipes are written by hand,
+// so a user keeps tens at most.
+func (s *Store) eachExportRecipe(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	recipes, err := s.queries.SelectRecipesByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range recipes {
+		err := emit(ExportRecipe{
+			ID:        r.ID,
+			Name:      r.Name,
+			Servings:  r.Servings,
+			CreatedAt: r.CreatedAt,
+			UpdatedAt: r.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportRecipeIngredient carries its food's name beside food_id, so the file
+// reads on its own without a lookup in the foods area.
+type ExportRecipeIngredient struct {
+	ID        int     `json:"id"`
+	RecipeID  int     `json:"recipe_id"`
+	FoodID    int     `json:"food_id"`
+	FoodName  string  `json:"food_name"`
+	QuantityG float64 `json:"quantity_g"`
+	CreatedAt int64   `json:"created_at"`
+	UpdatedAt int64   `json:"updated_at"`
+}
+
+func (s *Store) eachExportRecipeIngredient(
+	ctx context.Context,
+	userID int,
+	emit func(exportRecord) error,
+) error {
+	ingredients, err := s.queries.SelectRecipeIngredientsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range ingredients {
+		err := emit(ExportRecipeIngredient{
+			ID:        i.ID,
+			RecipeID:  i.RecipeID,
+			FoodID:    i.FoodID,
+			FoodName:  i.Food.Name,
+			QuantityG: i.QuantityG,
+			CreatedAt: i.CreatedAt,
+			UpdatedAt: i.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type ExportMealTemplate struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	MealType  string `json:"meal_type"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// eachExportMealTemplate reads every template at once, as recipes are read.
+func (s *Store) eachExportMealTemplate(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	templates, err := s.queries.SelectMealTemplatesByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range templates {
+		err := emit(ExportMealTemplate{
+			ID:        m.ID,
+			Name:      m.Name,
+			MealType:  m.MealType,
+			CreatedAt: m.CreatedAt,
+			UpdatedAt: m.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportMealTemplateItem names its food for the reason ExportRecipeIngredient
+// does.
+type ExportMealTemplateItem struct {
+	ID             int     `json:"id"`
+	MealTemplateID int     `json:"meal_template_id"`
+	FoodID         int     `json:"food_id"`
+	FoodName       string  `json:"food_name"`
+	Quantity       float64 `json:"quantity"`
+	Unit           string  `json:"unit"`
+	CreatedAt      int64   `json:"created_at"`
+	UpdatedAt      int64   `json:"updated_at"`
+}
+
+func (s *Store) eachExportMealTemplateItem(
+	ctx context.Context,
+	userID int,
+	emit func(exportRecord) error,
+) error {
+	items, err := s.queries.SelectMealTemplateItemsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range items {
+		err := emit(ExportMealTemplateItem{
+			ID:             i.ID,
+			MealTemplateID: i.MealTemplateID,
+			FoodID:         i.FoodID,
+			FoodName:       i.Food.Name,
+			Quantity:       i.Quantity,
+			Unit:           i.Unit,
+			CreatedAt:      i.CreatedAt,
+			UpdatedAt:      i.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type ExportMoodEntry struct {
+	ID        int      `json:"id"`
+	Mood      string   `json:"mood"`
+	Notes     string   `json:"notes"`
+	LoggedAt  int64    `json:"logged_at"`
+	CreatedAt int64    `json:"created_at"`
+	UpdatedAt int64    `json:"updated_at"`
+	Tags      []string `json:"tags"`
+}
+
+func (s *Store) eachExportMoodEntry(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	return eachBatch(userID,
+		func(opts repo.QueryOptions) ([]repo.MoodEntry, error) { return s.queries.SelectMoodEntries(ctx, opts) },
+		func(e repo.MoodEntry) int { return e.ID },
+		func(batch []repo.MoodEntry) error {
+			ids := make([]int, 0, len(batch))
+			for _, e := range batch {
+				ids = append(ids, e.ID)
+			}
+
+			tagsByID, err := s.exportTagNames(ctx, repo.TaggableTypeMoodEntry, "mood_entries", ids, userID)
+			if err != nil {
+				return err
+			}
+
+			for _, e := range batch {
+				tags := tagsByID[e.ID]
+				if tags == nil {
+					tags = []string{}
+				}
+
+				err := emit(ExportMoodEntry{
+					ID:        e.ID,
+					Mood:      e.Mood,
+					Notes:     e.Notes,
+					LoggedAt:  e.LoggedAt,
+					CreatedAt: e.CreatedAt,
+					UpdatedAt: e.UpdatedAt,
+					Tags:      tags,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
+
+type ExportTag struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+func (s *Store) eachExportTag(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	return eachBatch(userID,
+		func(opts repo.QueryOptions) ([]repo.Tag, error) { return s.queries.SelectTags(ctx, opts) },
+		func(t repo.Tag) int { return t.ID },
+		func(batch []repo.Tag) error {
+			for _, t := range batch {
+				err := emit(ExportTag{ID: t.ID, Name: t.Name, CreatedAt: t.CreatedAt, UpdatedAt: t.UpdatedAt})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
+
+// ExportBudget is one budget version. Category is null for the whole-month
+// budget.
+type ExportBudget struct {
+	Category      *ExportCategory `json:"category"`
+	Amount        uint64          `json:"amount"`
+	Rollover      bool            `json:"rollover"`
+	EffectiveFrom string          `json:"effective_from"`
+	CreatedAt     int64           `json:"created_at"`
+	UpdatedAt     int64           `json:"updated_at"`
+}
+
+// eachExportBudget emits every budget version in one read: budgets change by
+// hand, a few times a year at most, so the set stays small.
+func (s *Store) eachExportBudget(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	categoryByID, err := s.exportCategoryByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	budgets, err := s.queries.SelectExpenseBudgetVersionsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range budgets {
+		var category *ExportCategory
+		if b.CategoryID != nil {
+			category = toExportCategory(categoryByID, *b.CategoryID)
+		}
+
+		err := emit(ExportBudget{
+			Category:      category,
+			Amount:        b.Amount,
+			Rollover:      b.Rollover,
+			EffectiveFrom: b.EffectiveFrom,
+			CreatedAt:     b.CreatedAt,
+			UpdatedAt:     b.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type ExportSavingsGoal struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	TargetAmount uint64 `json:"target_amount"`
+	TargetDate   int64  `json:"target_date"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+// eachExportSavingsGoal reads every goal at once; a user keeps a handful.
+func (s *Store) eachExportSavingsGoal(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	goals, err := s.queries.SelectSavingsGoalsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range goals {
+		err := emit(ExportSavingsGoal{
+			ID:           g.ID,
+			Name:         g.Name,
+			TargetAmount: g.TargetAmount,
+			TargetDate:   g.TargetDate,
+			CreatedAt:    g.CreatedAt,
+			UpdatedAt:    g.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type ExportSavingsContribution struct {
+	ID            int    `json:"id"`
+	SavingsGoalID int    `json:"savings_goal_id"`
+	Amount        uint64 `json:"amount"`
+	Date          int64  `json:"date"`
+	Note          string `json:"note"`
+	CreatedAt     int64  `json:"created_at"`
+	UpdatedAt     int64  `json:"updated_at"`
+}
+
+func (s *Store) eachExportSavingsContribution(
+	ctx context.Context,
+	userID int,
+	emit func(exportRecord) error,
+) error {
+	contributions, err := s.queries.SelectSavingsContributionsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range contributions {
+		err := emit(ExportSavingsContribution{
+			ID:            c.ID,
+			SavingsGoalID: c.SavingsGoalID,
+			Amount:        c.Amount,
+			Date:          c.Date,
+			Note:          c.Note,
+			CreatedAt:     c.CreatedAt,
+			UpdatedAt:     c.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type ExportPaymentAccount struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	Kind              string `json:"kind"`
+	Currency          string `json:"currency"`
+	OpeningBalance    int64  `json:"opening_balance"`
+	ReconciledThrough *int64 `json:"reconciled_through"`
+	CreatedAt         int64  `json:"created_at"`
+	UpdatedAt         int64  `json:"updated_at"`
+}
+
+// eachExportPaymentAccount reads every account at once; a user keeps a
+// handful.
+func (s *Store) eachExportPaymentAccount(ctx context.Context, userID int, emit func(exportRecord) error) error {
+	accounts, err := s.queries.SelectPaymentAccountsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range accounts {
+		err := emit(ExportPaymentAccount{
+			ID:                a.ID,
+			Name:              a.Name,
+			Kind:              a.Kind,
+			Currency:          a.Currency,
+			OpeningBalance:    a.OpeningBalance,
+			ReconciledThrough: a.ReconciledThrough,
+			CreatedAt:         a.CreatedAt,
+			UpdatedAt:         a.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ----------------------------------------------------------------------------- //
+// CSV rows
+// ----------------------------------------------------------------------------- //
+
+func (e ExportExpense) csvRow() []string {
+	name, uid := e.Category.csvFields()
+
+	return []string{
+		strconv.Itoa(e.ID),
+		e.Description,
+		formatUint(e.Amount),
+		formatInt(e.BilledAt),
+		formatInt(e.CreatedAt),
+		formatInt(e.UpdatedAt),
+		name,
+		uid,
+		JoinTagNames(e.Tags),
+		e.Currency,
+		formatOptionalID(e.PaymentAccountID),
+	}
+}
+
+func (e ExportRecurrentExpense) csvRow() []string {
+	name, uid := e.Category.csvFields()
+
+	return []string{
+		strconv.Itoa(e.ID),
+		e.Description,
+		formatUint(e.Amount),
+		formatUint(uint64(e.Period)),
+		formatUint(uint64(e.OccurrenceLimit)),
+		formatUint(uint64(e.OccurrenceCount)),
+		formatOptionalInt(e.LastCopyCreatedAt),
+		formatOptionalInt(e.ArchivedAt),
+		formatInt(e.CreatedAt),
+		formatInt(e.UpdatedAt),
+		name,
+		uid,
+		JoinTagNames(e.Tags),
+		e.Currency,
+		e.Frequency,
+		formatUint(uint64(e.AnchorDay)),
+		formatOptionalInt(e.StartsAt),
+		formatOptionalInt(e.EndsAt),
+		strconv.FormatBool(e.IsEstimate),
+	}
+}
+
+func (i ExportIncome) csvRow() []string {
+	return []string{
+		strconv.Itoa(i.ID),
+		i.Source,
+		formatUint(i.Amount),
+		i.Currency,
+		formatInt(i.Date),
+		formatOptionalID(i.RecurrentIncomeID),
+		formatInt(i.CreatedAt),
+		formatInt(i.UpdatedAt),
+		JoinTagNames(i.Tags),
+	}
+}
+
+func (i ExportRecurrentIncome) csvRow() []string {
+	return []string{
+		strconv.Itoa(i.ID),
+		i.Source,
+		formatUint(i.Amount),
+		i.Currency,
+		formatUint(uint64(i.Period)),
+		i.Frequency,
+		formatUint(uint64(i.AnchorDay)),
+		formatOptionalInt(i.StartsAt),
+		formatOptionalInt(i.EndsAt),
+		formatUint(uint64(i.OccurrenceLimit)),
+		formatUint(uint64(i.OccurrenceCount)),
+		formatOptionalInt(i.LastCopyCreatedAt),
+		formatOptionalInt(i.ArchivedAt),
+		formatInt(i.CreatedAt),
+		formatInt(i.UpdatedAt),
+		JoinTagNames(i.Tags),
+	}
+}
+
+func (e ExportMacroEntry) csvRow() []string {
+	return []string{
+		strconv.Itoa(e.ID),
+		e.Name,
+		e.MealType,
+		formatFloat(e.Kcal),
+		formatFloat(e.ProteinG),
+		formatFloat(e.CarbsG),
+		formatFloat(e.FatG),
+		formatFloat(e.FiberG),
+		formatFloat(e.SodiumG),
+		formatFloat(e.SaturatedFatG),
+		formatInt(e.Date),
+		formatInt(e.CreatedAt),
+		formatInt(e.UpdatedAt),
+	}
+}
+
+func (g ExportMacroGoal) csvRow() []string {
+	return []string{
+		formatFloat(g.Kcal),
+		formatFloat(g.ProteinG),
+		formatFloat(g.CarbsG),
+		formatFloat(g.FatG),
+		formatFloat(g.FiberG),
+		formatFloat(g.SodiumG),
+		formatFloat(g.SaturatedFatG),
+		formatInt(g.CreatedAt),
+		formatInt(g.UpdatedAt),
+	}
+}
+
+func (f ExportFood) csvRow() []string {
+	return []string{
+		strconv.Itoa(f.ID),
+		f.Name,
+		formatFloat(f.Kcal),
+		formatFloat(f.ProteinG),
+		formatFloat(f.CarbsG),
+		formatFloat(f.FatG),
+		formatFloat(f.FiberG),
+		formatFloat(f.SodiumG),
+		formatFloat(f.SaturatedFatG),
+		formatFloat(f.BaseAmount),
+		f.BaseUnit,
+		formatOptionalFloat(f.DensityGPerML),
+		barcodeOrEmpty(f.Barcode),
+		formatInt(f.CreatedAt),
+		formatInt(f.UpdatedAt),
+	}
+}
+
+func (r ExportRecipe) csvRow() []string {
+	return []string{
+		strconv.Itoa(r.ID),
+		r.Name,
+		formatFloat(r.Servings),
+		formatInt(r.CreatedAt),
+		formatInt(r.UpdatedAt),
+	}
+}
+
+func (i ExportRecipeIngredient) csvRow() []string {
+	return []string{
+		strconv.Itoa(i.ID),
+		strconv.Itoa(i.RecipeID),
+		strconv.Itoa(i.FoodID),
+		i.FoodName,
+		formatFloat(i.QuantityG),
+		formatInt(i.CreatedAt),
+		formatInt(i.UpdatedAt),
+	}
+}
+
+func (m ExportMealTemplate) csvRow() []string {
+	return []string{
+		strconv.Itoa(m.ID),
+		m.Name,
+		m.MealType,
+		formatInt(m.CreatedAt),
+		formatInt(m.UpdatedAt),
+	}
+}
+
+func (i ExportMealTemplateItem) csvRow() []string {
+	return []string{
+		strconv.Itoa(i.ID),
+		strconv.Itoa(i.MealTemplateID),
+		strconv.Itoa(i.FoodID),
+		i.FoodName,
+		formatFloat(i.Quantity),
+		i.Unit,
+		formatInt(i.CreatedAt),
+		formatInt(i.UpdatedAt),
+	}
+}
+
+func (e ExportMoodEntry) csvRow() []string {
+	return []string{
+		strconv.Itoa(e.ID),
+		e.Mood,
+		e.Notes,
+		formatInt(e.LoggedAt),
+		formatInt(e.CreatedAt),
+		formatInt(e.UpdatedAt),
+		JoinTagNames(e.Tags),
+	}
+}
+
+func (t ExportTag) csvRow() []string {
+	return []string{strconv.Itoa(t.ID), t.Name, formatInt(t.CreatedAt), formatInt(t.UpdatedAt)}
+}
+
+func (b ExportBudget) csvRow() []string {
+	name, uid := b.Category.csvFields()
+
+	return []string{
+		name,
+		uid,
+		formatUint(b.Amount),
+		strconv.FormatBool(b.Rollover),
+		b.EffectiveFrom,
+		formatInt(b.CreatedAt),
+		formatInt(b.UpdatedAt),
+	}
+}
+
+func (g ExportSavingsGoal) csvRow() []string {
+	return []string{
+		strconv.Itoa(g.ID),
+		g.Name,
+		formatUint(g.TargetAmount),
+		formatInt(g.TargetDate),
+		formatInt(g.CreatedAt),
+		formatInt(g.UpdatedAt),
+	}
+}
+
+func (c ExportSavingsContribution) csvRow() []string {
+	return []string{
+		strconv.Itoa(c.ID),
+		strconv.Itoa(c.SavingsGoalID),
+		formatUint(c.Amount),
+		formatInt(c.Date),
+		c.Note,
+		formatInt(c.CreatedAt),
+		formatInt(c.UpdatedAt),
+	}
+}
+
+func (a ExportPaymentAccount) csvRow() []string {
+	return []string{
+		strconv.Itoa(a.ID),
+		a.Name,
+		a.Kind,
+		a.Currency,
+		formatInt(a.OpeningBalance),
+		formatOptionalInt(a.ReconciledThrough),
+		formatInt(a.CreatedAt),
+		formatInt(a.UpdatedAt),
+	}
+}
+
+// csvFields flattens an optional category into its name and uid columns.
+func (c *ExportCategory) csvFields() (string, string) {
+	if c == nil {
+		return "", ""
+	}
+
+	return c.Name, c.UID
+}
+
+func formatInt(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+func formatUint(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatOptionalInt(v *int64) string {
+	if v == nil {
+		return ""
+	}
+
+	return formatInt(*v)
+}
+
+func formatOptionalID(v *int) string {
+	if v == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*v)
+}
+
+func formatOptionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+
+	return formatFloat(*v)
+}
@@ -0,0 +1,298 @@
+package logic_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamExport(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "stream_export_user_1",
+		Email:        "stream_export_user_1@example.com",
+		PasswordHash: []byte("stream_export_hash_1"),
+	})
+	otherUser := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "stream_export_user_2",
+		Email:        "stream_export_user_2@example.com",
+		PasswordHash: []byte("stream_export_hash_2"),
+	})
+	category := s.CreateCategory(t, user.ID, "stream_export_category")
+	otherCategory := s.CreateCategory(t, otherUser.ID, "stream_export_category")
+
+	account, err := s.Store.CreatePaymentAccount(ctx, user.ID, logic.PaymentAccountParams{
+		Name: "stream_card", Kind: "credit_card", OpeningBalance: -2500,
+	})
+	require.NoError(t, err)
+
+	paidExpense := newExpenseParams(category.ID, "stream_mine", 1250, 1735689600, []string{"b", "a"})
+	paidExpense.PaymentAccountID = &account.ID
+	s.CreateExpense(t, user.ID, paidExpense)
+	s.CreateExpense(t, otherUser.ID, newExpenseParams(otherCategory.ID, "stream_theirs", 300, 1735689600, nil))
+	s.CreateMoodEntry(t, user.ID, newMoodEntryParams("Calm", "with, comma", 1735689600, []string{"walk"}))
+
+	_, err = s.Store.CreateIncome(ctx, user.ID, logic.IncomeParams{
+		Source: "stream_salary", Amount: 500000, Date: 1735689600, Tags: []string{"work", "main"},
+	})
+	require.NoError(t, err)
+	_, err = s.Store.CreateRecurrentIncome(ctx, user.ID, logic.RecurrentIncomeParams{
+		Source: "stream_rent", Amount: 80000, Period: 1, Tags: []string{"property"},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_write_csv_header_and_user_rows",
+			fn: func(t *testing.T) {
+				var buf bytes.Buffer
+				err := s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaExpenses, logic.ExportFormatCSV)
+				require.NoError(t, err)
+
+				records, err := csv.NewReader(&buf).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, records, 2)
+				require.Equal(t, "description", records[0][1])
+				require.Equal(t, "stream_mine", records[1][1])
+				require.Equal(t, "1250", records[1][2])
+				require.Equal(t, "stream_export_category", records[1][6])
+				require.Equal(t, "a; b", records[1][8])
+				require.Equal(t, "USD", records[1][9])
+				require.Equal(t, strconv.Itoa(account.ID), records[1][10])
+			},
+		},
+		{
+			name: "should_write_one_json_object_per_line",
+			fn: func(t *testing.T) {
+				var buf bytes.Buffer
+				err := s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaMoodEntries, logic.ExportFormatNDJSON)
+				require.NoError(t, err)
+
+				var lines []logic.ExportMoodEntry
+				scanner := bufio.NewScanner(&buf)
+				for scanner.Scan() {
+					var entry logic.ExportMoodEntry
+					require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+					lines = append(lines, entry)
+				}
+				require.Len(t, lines, 1)
+				require.Equal(t, "with, comma", lines[0].Notes)
+				require.Equal(t, []string{"walk"}, lines[0].Tags)
+			},
+		},
+		{
+			name: "should_export_incomes_with_their_tags",
+			fn: func(t *testing.T) {
+				var buf bytes.Buffer
+				err := s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaIncomes, logic.ExportFormatCSV)
+				require.NoError(t, err)
+
+				records, err := csv.NewReader(&buf).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, records, 2)
+				require.Equal(t, "stream_salary", records[1][1])
+				require.Equal(t, "500000", records[1][2])
+				require.Empty(t, records[1][5])
+				require.Equal(t, "main; work", records[1][8])
+
+				buf.Reset()
+				err = s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaRecurrentIncomes, logic.ExportFormatNDJSON)
+				require.NoError(t, err)
+
+				var income logic.ExportRecurrentIncome
+				require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &income))
+				require.Equal(t, "stream_rent", income.Source)
+				require.Equal(t, []string{"property"}, income.Tags)
+			},
+		},
+		{
+			name: "should_export_payment_accounts",
+			fn: func(t *testing.T) {
+				var buf bytes.Buffer
+				err := s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaPaymentAccounts, logic.ExportFormatCSV)
+				require.NoError(t, err)
+
+				records, err := csv.NewReader(&buf).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, records, 2)
+				require.Equal(t, strconv.Itoa(account.ID), records[1][0])
+				require.Equal(t, "stream_card", records[1][1])
+				require.Equal(t, "credit_card", records[1][2])
+				require.Equal(t, "-2500", records[1][4])
+				require.Empty(t, records[1][5])
+			},
+		},
+		{
+			name: "should_write_only_header_for_empty_area",
+			fn: func(t *testing.T) {
+				var buf bytes.Buffer
+				err := s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaFoods, logic.ExportFormatCSV)
+				require.NoError(t, err)
+
+				records, err := csv.NewReader(&buf).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, records, 1)
+			},
+		},
+		{
+			name: "should_reject_unknown_area_and_format",
+			fn: func(t *testing.T) {
+				var buf bytes.Buffer
+				err := s.Store.StreamExport(ctx, &buf, user.ID, "passwords", logic.ExportFormatCSV)
+				require.ErrorIs(t, err, logic.ErrUnknownExportArea)
+
+				err = s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaTags, "xml")
+				require.ErrorIs(t, err, logic.ErrUnknownExportFormat)
+				require.Zero(t, buf.Len())
+			},
+		},
+		{
+			name: "should_export_every_area",
+			fn: func(t *testing.T) {
+				for _, area := range logic.ExportAreas() {
+					var buf bytes.Buffer
+					err := s.Store.StreamExport(ctx, &buf, user.ID, area, logic.ExportFormatNDJSON)
+					require.NoError(t, err, area)
+				}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestStreamExportAcrossBatches(t *testing.T) {
+	const expenseCount = 1100
+
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "stream_batch_user",
+		Email:        "stream_batch_user@example.com",
+		PasswordHash: []byte("stream_batch_hash"),
+	})
+	category := s.CreateCategory(t, user.ID, "stream_batch_category")
+
+	for i := range expenseCount {
+		var tags []string
+		if i == expenseCount-1 {
+			tags = []string{"last_tag"}
+		}
+
+		description := fmt.Sprintf("batched_%04d", i)
+		s.CreateExpense(t, user.ID, newExpenseParams(category.ID, description, 100, 1735689600, tags))
+	}
+
+	var buf bytes.Buffer
+	err := s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaExpenses, logic.ExportFormatCSV)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, expenseCount+1)
+
+	seen := make(map[string]bool, expenseCount)
+	for _, record := range records[1:] {
+		require.False(t, seen[record[1]], "duplicate row %s", record[1])
+		seen[record[1]] = true
+	}
+
+	last := records[len(records)-1]
+	require.Equal(t, fmt.Sprintf("batched_%04d", expenseCount-1), last[1])
+	require.Equal(t, "last_tag", last[8])
+}
+
+func TestStreamExportRecipes(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "stream_recipe_user",
+		Email:        "stream_recipe_user@example.com",
+		PasswordHash: []byte("stream_recipe_hash"),
+	})
+	oats := s.CreateFood(t, user.ID, newFoodParams("stream_oats", 380, 13, 67, 7))
+
+	recipe, err := s.Store.CreateRecipe(ctx, user.ID, logic.RecipeParams{Name: "stream_porridge", Servings: 2})
+	require.NoError(t, err)
+	_, err = s.Store.SaveRecipeIngredient(ctx, recipe.ID, user.ID, logic.RecipeIngredientParams{
+		FoodID: oats.ID, QuantityG: 80,
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaRecipes, logic.ExportFormatCSV)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "stream_porridge", records[1][1])
+	require.Equal(t, "2", records[1][2])
+
+	buf.Reset()
+	err = s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaRecipeIngredients, logic.ExportFormatNDJSON)
+	require.NoError(t, err)
+
+	var ingredient logic.ExportRecipeIngredient
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &ingredient))
+	require.Equal(t, recipe.ID, ingredient.RecipeID)
+	require.Equal(t, oats.ID, ingredient.FoodID)
+	require.Equal(t, "stream_oats", ingredient.FoodName)
+	require.InDelta(t, 80, ingredient.QuantityG, 0.001)
+}
+
+func TestStreamExportMealTemplates(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "stream_template_user",
+		Email:        "stream_template_user@example.com",
+		PasswordHash: []byte("stream_template_hash"),
+	})
+	milk := s.CreateFood(t, user.ID, newFoodParams("stream_milk", 64, 3.4, 4.8, 3.6))
+
+	template, err := s.Store.CreateMealTemplate(ctx, user.ID, logic.MealTemplateParams{
+		Name: "stream_breakfast", MealType: "breakfast",
+	})
+	require.NoError(t, err)
+	_, err = s.Store.SaveMealTemplateItem(ctx, template.ID, user.ID, logic.MealTemplateItemParams{
+		FoodID: milk.ID, Quantity: 250, Unit: "g",
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaMealTemplates, logic.ExportFormatCSV)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "stream_breakfast", records[1][1])
+	require.Equal(t, "breakfast", records[1][2])
+
+	buf.Reset()
+	err = s.Store.StreamExport(ctx, &buf, user.ID, logic.ExportAreaMealTemplateItems, logic.ExportFormatNDJSON)
+	require.NoError(t, err)
+
+	var item logic.ExportMealTemplateItem
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &item))
+	require.Equal(t, template.ID, item.MealTemplateID)
+	require.Equal(t, "stream_milk", item.FoodName)
+	require.InDelta(t, 250, item.Quantity, 0.001)
+	require.Equal(t, "g", item.Unit)
+}
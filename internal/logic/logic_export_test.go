@@ -22,7 +22,8 @@ func TestExportExpenses(t *testing.T) {
 		Email:        "export_user_2@example.com",
 		PasswordHash: []byte("export_user_hash_2"),
 	})
-	category := s.CreateCategory(t, "export_category_1")
+	category := s.CreateCategory(t, user.ID, "export_category_1")
+	otherCategory := s.CreateCategory(t, otherUser.ID, "export_category_1")
 
 	cases := []struct {
 		name string
@@ -32,7 +33,7 @@ func TestExportExpenses(t *testing.T) {
 			name: "should_return_only_user_expenses",
 			fn: func(t *testing.T) {
 				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "mine_1", 100, 1735689600, nil))
-				s.CreateExpense(t, otherUser.ID, newExpenseParams(category.ID, "theirs_1", 200, 1735689600, nil))
+				s.CreateExpense(t, otherUser.ID, newExpenseParams(otherCategory.ID, "theirs_1", 200, 1735689600, nil))
 
 				out, err := s.Store.ExportExpenses(ctx, user.ID)
 				require.NoError(t, err)
@@ -127,7 +128,7 @@ func TestExportExpensesAcrossTagChunks(t *testing.T) {
 		Email:        "export_chunk_user@example.com",
 		PasswordHash: []byte("export_chunk_hash"),
 	})
-	category := s.CreateCategory(t, "export_chunk_category")
+	category := s.CreateCategory(t, user.ID, "export_chunk_category")
 
 	// Tag the first, a middle and the last expense so the assertions straddle
 	// every chunk boundary.
@@ -2,10 +2,24 @@ package logic
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
 
 	"github.com/ad9311/ninete/internal/repo"
 )
 
+// DefaultFoodBaseAmount and DefaultFoodBaseUnit are what a new food's
+// nutrients are given for unless it says otherwise.
+const (
+	DefaultFoodBaseAmount = 100.0
+	DefaultFoodBaseUnit   = UnitGram
+)
+
+// FoodParams gives a food's nutrients for BaseAmount of BaseUnit. Leaving both
+// unset means the default base, and a zero DensityGPerML means the density is
+// unknown. Barcode is optional and normalized before it is saved.
 type FoodParams struct {
 	Name          string  `validate:"required,min=1,max=100"`
 	Kcal          float64 `validate:"gte=0"`
@@ -15,6 +29,35 @@ type FoodParams struct {
 	FiberG        float64 `validate:"gte=0"`
 	SodiumG       float64 `validate:"gte=0"`
 	SaturatedFatG float64 `validate:"gte=0"`
+	BaseAmount    float64 `validate:"gt=0"`
+	BaseUnit      string  `validate:"required,oneof=g kg oz lb ml l"`
+	DensityGPerML float64 `validate:"gte=0"`
+	Barcode       string
+}
+
+type FoodServingParams struct {
+	Name   string  `validate:"required,min=1,max=50"`
+	Amount float64 `validate:"gt=0"`
+	Unit   string  `validate:"required,oneof=g kg oz lb ml l"`
+}
+
+// FoodPortionParams is Quantity of Unit, or Quantity servings when ServingID
+// names one of the food's servings.
+type FoodPortionParams struct {
+	Quantity  float64 `validate:"gt=0"`
+	Unit      string
+	ServingID int
+}
+
+// Nutrients holds the macros of some amount of a food or a recipe.
+type Nutrients struct {
+	Kcal          float64
+	ProteinG      float64
+	CarbsG        float64
+	FatG          float64
+	FiberG        float64
+	SodiumG       float64
+	SaturatedFatG float64
 }
 
 func (s *Store) FindFoods(ctx context.Context, opts repo.QueryOptions) ([]repo.Food, error) {
@@ -38,11 +81,18 @@ func (s *Store) FindFood(ctx context.Context, id, userID int) (repo.Food, error)
 func (s *Store) CreateFood(ctx context.Context, userID int, params FoodParams) (repo.Food, error) {
 	var food repo.Food
 
+	params = params.withDefaultBase()
+
 	if err := s.ValidateStruct(params); err != nil {
 		return food, err
 	}
 
-	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+	barcode, err := s.foodBarcode(ctx, userID, 0, params.Barcode)
+	if err != nil {
+		return food, err
+	}
+
+	err = s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
 		var txErr error
 
 		food, txErr = tq.InsertFood(ctx, repo.InsertFoodParams{
@@ -55,10 +105,17 @@ func (s *Store) CreateFood(ctx context.Context, userID int, params FoodParams) (
 			FiberG:        params.FiberG,
 			SodiumG:       params.SodiumG,
 			SaturatedFatG: params.SaturatedFatG,
+			BaseAmount:    params.BaseAmount,
+			BaseUnit:      params.BaseUnit,
+			DensityGPerML: densityOrNil(params.DensityGPerML),
+			Barcode:       barcode,
 		})
 
 		return txErr
 	})
+	if repo.IsUniqueViolation(err) {
+		return food, ErrFoodNameTaken
+	}
 	if err != nil {
 		return food, err
 	}
@@ -73,11 +130,18 @@ func (s *Store) UpdateFood(
 ) (repo.Food, error) {
 	var food repo.Food
 
+	params = params.withDefaultBase()
+
 	if err := s.ValidateStruct(params); err != nil {
 		return food, err
 	}
 
-	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+	barcode, err := s.foodBarcode(ctx, userID, id, params.Barcode)
+	if err != nil {
+		return food, err
+	}
+
+	err = s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
 		var txErr error
 
 		food, txErr = tq.UpdateFood(ctx, userID, repo.UpdateFoodParams{
@@ -90,10 +154,17 @@ func (s *Store) UpdateFood(
 			FiberG:        params.FiberG,
 			SodiumG:       params.SodiumG,
 			SaturatedFatG: params.SaturatedFatG,
+			BaseAmount:    params.BaseAmount,
+			BaseUnit:      params.BaseUnit,
+			DensityGPerML: densityOrNil(params.DensityGPerML),
+			Barcode:       barcode,
 		})
 
 		return txErr
 	})
+	if repo.IsUniqueViolation(err) {
+		return food, ErrFoodNameTaken
+	}
 	if err != nil {
 		return food, err
 	}
@@ -115,3 +186,197 @@ func (s *Store) DeleteAllFoods(ctx context.Context, userID int) error {
 		return tq.DeleteAllFoodsByUser(ctx, userID)
 	})
 }
+
+func (s *Store) FindFoodServings(ctx context.Context, foodID, userID int) ([]repo.FoodServing, error) {
+	return s.queries.SelectFoodServingsByFood(ctx, foodID, userID)
+}
+
+// AddFoodServing names an amount of the food. The amount must convert to the
+// food's base unit, so a volume serving of a food weighed in grams needs the
+// food's density first.
+func (s *Store) AddFoodServing(
+	ctx context.Context,
+	food repo.Food,
+	params FoodServingParams,
+) (repo.FoodServing, error) {
+	params.Name = strings.TrimSpace(params.Name)
+	if err := s.ValidateStruct(params); err != nil {
+		return repo.FoodServing{}, err
+	}
+
+	if _, err := ConvertAmount(params.Amount, params.Unit, food.BaseUnit, food.DensityGPerML); err != nil {
+		return repo.FoodServing{}, err
+	}
+
+	serving, err := s.queries.InsertFoodServing(ctx, repo.InsertFoodServingParams{
+		UserID: food.UserID,
+		FoodID: food.ID,
+		Name:   params.Name,
+		Amount: params.Amount,
+		Unit:   params.Unit,
+	})
+	if repo.IsUniqueViolation(err) {
+		return serving, ErrFoodServingNameTaken
+	}
+
+	return serving, err
+}
+
+// DeleteFoodServing returns sql.ErrNoRows when the food has no such serving.
+func (s *Store) DeleteFoodServing(ctx context.Context, id, foodID, userID int) error {
+	_, err := s.queries.DeleteFoodServing(ctx, id, foodID, userID)
+
+	return err
+}
+
+// MeasureFoodPortion returns the nutrients of a portion of the food, rounded
+// to two decimals.
+func (s *Store) MeasureFoodPortion(
+	ctx context.Context,
+	food repo.Food,
+	params FoodPortionParams,
+) (Nutrients, error) {
+	if err := s.ValidateStruct(params); err != nil {
+		return Nutrients{}, err
+	}
+
+	amount, unit := params.Quantity, params.Unit
+	if params.ServingID > 0 {
+		serving, err := s.queries.SelectFoodServing(ctx, params.ServingID, food.ID, food.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return Nutrients{}, ErrUnknownUnit
+		}
+		if err != nil {
+			return Nutrients{}, err
+		}
+		amount, unit = params.Quantity*serving.Amount, serving.Unit
+	}
+
+	nutrients, err := ScaleFood(food, amount, unit)
+	if err != nil {
+		return Nutrients{}, err
+	}
+
+	return nutrients.rounded(), nil
+}
+
+// LogFoodPortion records a portion of the food as a macro entry. The
+// nutrients in entry are replaced by the portion's, and a blank name takes
+// the food's.
+func (s *Store) LogFoodPortion(
+	ctx context.Context,
+	food repo.Food,
+	portion FoodPortionParams,
+	entry MacroEntryParams,
+) (repo.MacroEntry, error) {
+	nutrients, err := s.MeasureFoodPortion(ctx, food, portion)
+	if err != nil {
+		return repo.MacroEntry{}, err
+	}
+
+	if strings.TrimSpace(entry.Name) == "" {
+		entry.Name = food.Name
+	}
+	entry.Kcal = nutrients.Kcal
+	entry.ProteinG = nutrients.ProteinG
+	entry.CarbsG = nutrients.CarbsG
+	entry.FatG = nutrients.FatG
+	entry.FiberG = nutrients.FiberG
+	entry.SodiumG = nutrients.SodiumG
+	entry.SaturatedFatG = nutrients.SaturatedFatG
+
+	return s.CreateMacroEntry(ctx, food.UserID, entry)
+}
+
+// ScaleFood returns the unrounded nutrients of amount of unit of the food.
+func ScaleFood(food repo.Food, amount float64, unit string) (Nutrients, error) {
+	base, err := ConvertAmount(amount, unit, food.BaseUnit, food.DensityGPerML)
+	if err != nil {
+		return Nutrients{}, err
+	}
+
+	return Nutrients{
+		Kcal:          food.Kcal,
+		ProteinG:      food.ProteinG,
+		CarbsG:        food.CarbsG,
+		FatG:          food.FatG,
+		FiberG:        food.FiberG,
+		SodiumG:       food.SodiumG,
+		SaturatedFatG: food.SaturatedFatG,
+	}.scale(base / food.BaseAmount), nil
+}
+
+// foodBarcode normalizes a food's barcode and refuses one that another of the
+// user's foods, other than excludeID, already has. Trashed foods are left
+// out, and the column is not unique, so restoring one never fails over it.
+func (s *Store) foodBarcode(ctx context.Context, userID, excludeID int, code string) (*string, error) {
+	barcode, err := barcodeOrNil(code)
+	if err != nil || barcode == nil {
+		return nil, err
+	}
+
+	other, err := s.queries.SelectFoodByBarcode(ctx, userID, *barcode)
+	if err == nil && other.ID != excludeID {
+		return nil, ErrFoodBarcodeTaken
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return barcode, nil
+}
+
+func (p FoodParams) withDefaultBase() FoodParams {
+	if p.BaseAmount == 0 && p.BaseUnit == "" {
+		p.BaseAmount = DefaultFoodBaseAmount
+		p.BaseUnit = DefaultFoodBaseUnit
+	}
+
+	return p
+}
+
+func densityOrNil(density float64) *float64 {
+	if density <= 0 {
+		return nil
+	}
+
+	return &density
+}
+
+func (n Nutrients) add(o Nutrients) Nutrients {
+	return Nutrients{
+		Kcal:          n.Kcal + o.Kcal,
+		ProteinG:      n.ProteinG + o.ProteinG,
+		CarbsG:        n.CarbsG + o.CarbsG,
+		FatG:          n.FatG + o.FatG,
+		FiberG:        n.FiberG + o.FiberG,
+		SodiumG:       n.SodiumG + o.SodiumG,
+		SaturatedFatG: n.SaturatedFatG + o.SaturatedFatG,
+	}
+}
+
+func (n Nutrients) scale(factor float64) Nutrients {
+	return Nutrients{
+		Kcal:          n.Kcal * factor,
+		ProteinG:      n.ProteinG * factor,
+		CarbsG:        n.CarbsG * factor,
+		FatG:          n.FatG * factor,
+		FiberG:        n.FiberG * factor,
+		SodiumG:       n.SodiumG * factor,
+		SaturatedFatG: n.SaturatedFatG * factor,
+	}
+}
+
+func (n Nutrients) rounded() Nutrients {
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+
+	return Nutrients{
+		Kcal:          round(n.Kcal),
+		ProteinG:      round(n.ProteinG),
+		CarbsG:        round(n.CarbsG),
+		FatG:          round(n.FatG),
+		FiberG:        round(n.FiberG),
+		SodiumG:       round(n.SodiumG),
+		SaturatedFatG: round(n.SaturatedFatG),
+	}
+}
@@ -2,6 +2,7 @@ package logic_test
 
 import (
 	"database/sql"
+	"strings"
 	"testing"
 
 	"github.com/ad9311/ninete/internal/logic"
@@ -314,6 +315,344 @@ func TestCreateFoodWithMicros(t *testing.T) {
 	}
 }
 
+func TestConvertAmount(t *testing.T) {
+	density := 0.92
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_convert_between_masses",
+			fn: func(t *testing.T) {
+				oz, err := logic.ConvertAmount(100, logic.UnitGram, logic.UnitOunce, nil)
+				require.NoError(t, err)
+				require.InDelta(t, 3.5274, oz, 0.0001)
+
+				kg, err := logic.ConvertAmount(2, logic.UnitPound, logic.UnitKilogram, nil)
+				require.NoError(t, err)
+				require.InDelta(t, 0.90718, kg, 0.00001)
+			},
+		},
+		{
+			name: "should_convert_between_volumes",
+			fn: func(t *testing.T) {
+				ml, err := logic.ConvertAmount(1.5, logic.UnitLiter, logic.UnitMilliliter, nil)
+				require.NoError(t, err)
+				require.InDelta(t, 1500.0, ml, 0.0001)
+			},
+		},
+		{
+			name: "should_convert_volume_to_mass_through_density",
+			fn: func(t *testing.T) {
+				g, err := logic.ConvertAmount(100, logic.UnitMilliliter, logic.UnitGram, &density)
+				require.NoError(t, err)
+				require.InDelta(t, 92.0, g, 0.0001)
+
+				ml, err := logic.ConvertAmount(92, logic.UnitGram, logic.UnitMilliliter, &density)
+				require.NoError(t, err)
+				require.InDelta(t, 100.0, ml, 0.0001)
+			},
+		},
+		{
+			name: "should_need_density_between_mass_and_volume",
+			fn: func(t *testing.T) {
+				_, err := logic.ConvertAmount(1, logic.UnitLiter, logic.UnitGram, nil)
+				require.ErrorIs(t, err, logic.ErrUnitNeedsDensity)
+			},
+		},
+		{
+			name: "should_reject_unknown_unit",
+			fn: func(t *testing.T) {
+				_, err := logic.ConvertAmount(1, "cup", logic.UnitGram, nil)
+				require.ErrorIs(t, err, logic.ErrUnknownUnit)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestFoodServings(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "food_user_servings_1",
+		Email:        "food_user_servings_1@example.com",
+		PasswordHash: []byte("food_user_hash_servings_1"),
+	})
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_default_base_to_100_grams",
+			fn: func(t *testing.T) {
+				food := s.CreateFood(t, user.ID, newFoodParams("oats", 389, 17, 66, 7))
+				require.Equal(t, logic.DefaultFoodBaseAmount, food.BaseAmount)
+				require.Equal(t, logic.UnitGram, food.BaseUnit)
+				require.Nil(t, food.DensityGPerML)
+			},
+		},
+		{
+			name: "should_measure_a_portion_by_unit_and_by_serving",
+			fn: func(t *testing.T) {
+				params := newFoodParams("rice", 130, 2.7, 28, 0.3)
+				food := s.CreateFood(t, user.ID, params)
+
+				serving, err := s.Store.AddFoodServing(ctx, food, logic.FoodServingParams{
+					Name: " 1 cup ", Amount: 185, Unit: logic.UnitGram,
+				})
+				require.NoError(t, err)
+				require.Equal(t, "1 cup", serving.Name)
+
+				nutrients, err := s.Store.MeasureFoodPortion(ctx, food, logic.FoodPortionParams{
+					Quantity: 0.5, Unit: logic.UnitKilogram,
+				})
+				require.NoError(t, err)
+				require.InDelta(t, 650.0, nutrients.Kcal, 0.001)
+
+				nutrients, err = s.Store.MeasureFoodPortion(ctx, food, logic.FoodPortionParams{
+					Quantity: 2, ServingID: serving.ID,
+				})
+				require.NoError(t, err)
+				require.InDelta(t, 481.0, nutrients.Kcal, 0.001)
+				require.InDelta(t, 103.6, nutrients.CarbsG, 0.001)
+			},
+		},
+		{
+			name: "should_reject_duplicate_serving_names",
+			fn: func(t *testing.T) {
+				food := s.CreateFood(t, user.ID, newFoodParams("flour", 364, 10, 76, 1))
+
+				_, err := s.Store.AddFoodServing(ctx, food, logic.FoodServingParams{
+					Name: "Cup", Amount: 120, Unit: logic.UnitGram,
+				})
+				require.NoError(t, err)
+
+				_, err = s.Store.AddFoodServing(ctx, food, logic.FoodServingParams{
+					Name: "cup", Amount: 125, Unit: logic.UnitGram,
+				})
+				require.ErrorIs(t, err, logic.ErrFoodServingNameTaken)
+			},
+		},
+		{
+			name: "should_need_density_for_a_volume_serving_of_a_weighed_food",
+			fn: func(t *testing.T) {
+				food := s.CreateFood(t, user.ID, newFoodParams("honey", 304, 0.3, 82, 0))
+
+				_, err := s.Store.AddFoodServing(ctx, food, logic.FoodServingParams{
+					Name: "tbsp", Amount: 15, Unit: logic.UnitMilliliter,
+				})
+				require.ErrorIs(t, err, logic.ErrUnitNeedsDensity)
+
+				params := newFoodParams("honey", 304, 0.3, 82, 0)
+				params.BaseAmount = 100
+				params.BaseUnit = logic.UnitGram
+				params.DensityGPerML = 1.42
+				food, err = s.Store.UpdateFood(ctx, food.ID, user.ID, params)
+				require.NoError(t, err)
+
+				serving, err := s.Store.AddFoodServing(ctx, food, logic.FoodServingParams{
+					Name: "tbsp", Amount: 15, Unit: logic.UnitMilliliter,
+				})
+				require.NoError(t, err)
+
+				nutrients, err := s.Store.MeasureFoodPortion(ctx, food, logic.FoodPortionParams{
+					Quantity: 1, ServingID: serving.ID,
+				})
+				require.NoError(t, err)
+				require.InDelta(t, 64.75, nutrients.Kcal, 0.001)
+			},
+		},
+		{
+			name: "should_log_a_portion_of_a_food_given_per_volume",
+			fn: func(t *testing.T) {
+				params := newFoodParams("milk", 122, 8, 12, 5)
+				params.BaseAmount = 250
+				params.BaseUnit = logic.UnitMilliliter
+				food := s.CreateFood(t, user.ID, params)
+
+				entry, err := s.Store.LogFoodPortion(ctx, food, logic.FoodPortionParams{
+					Quantity: 0.5, Unit: logic.UnitLiter,
+				}, logic.MacroEntryParams{Date: 1775692800, MealType: "breakfast"})
+				require.NoError(t, err)
+				require.Equal(t, "milk", entry.Name)
+				require.InDelta(t, 244.0, entry.Kcal, 0.001)
+				require.InDelta(t, 16.0, entry.ProteinG, 0.001)
+
+				_, err = s.Store.LogFoodPortion(ctx, food, logic.FoodPortionParams{
+					Quantity: 100, Unit: logic.UnitGram,
+				}, logic.MacroEntryParams{Date: 1775692800, MealType: "breakfast"})
+				require.ErrorIs(t, err, logic.ErrUnitNeedsDensity)
+			},
+		},
+		{
+			name: "should_not_measure_another_foods_serving",
+			fn: func(t *testing.T) {
+				food := s.CreateFood(t, user.ID, newFoodParams("beans", 127, 9, 23, 0.5))
+				other := s.CreateFood(t, user.ID, newFoodParams("corn", 96, 3.4, 21, 1.5))
+				serving, err := s.Store.AddFoodServing(ctx, other, logic.FoodServingParams{
+					Name: "ear", Amount: 90, Unit: logic.UnitGram,
+				})
+				require.NoError(t, err)
+
+				_, err = s.Store.MeasureFoodPortion(ctx, food, logic.FoodPortionParams{
+					Quantity: 1, ServingID: serving.ID,
+				})
+				require.ErrorIs(t, err, logic.ErrUnknownUnit)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestNormalizeBarcode(t *testing.T) {
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_keep_ean_13_and_ean_8_codes",
+			fn: func(t *testing.T) {
+				code, err := logic.NormalizeBarcode("4006381333931")
+				require.NoError(t, err)
+				require.Equal(t, "4006381333931", code)
+
+				code, err = logic.NormalizeBarcode("9638-5074")
+				require.NoError(t, err)
+				require.Equal(t, "96385074", code)
+			},
+		},
+		{
+			name: "should_store_upc_a_and_gtin_14_as_ean_13",
+			fn: func(t *testing.T) {
+				upc, err := logic.NormalizeBarcode("0 36000 29145 2")
+				require.NoError(t, err)
+				require.Equal(t, "0036000291452", upc)
+
+				gtin, err := logic.NormalizeBarcode("00036000291452")
+				require.NoError(t, err)
+				require.Equal(t, upc, gtin)
+			},
+		},
+		{
+			name: "should_reject_bad_check_digits_lengths_and_letters",
+			fn: func(t *testing.T) {
+				for _, code := range []string{"4006381333932", "1234567", "400638133393X", ""} {
+					_, err := logic.NormalizeBarcode(code)
+					require.ErrorIs(t, err, logic.ErrInvalidBarcode, code)
+				}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestFoodBarcodes(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "food_user_barcodes_1",
+		Email:        "food_user_barcodes_1@example.com",
+		PasswordHash: []byte("food_user_hash_barcodes_1"),
+	})
+	other := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "food_user_barcodes_2",
+		Email:        "food_user_barcodes_2@example.com",
+		PasswordHash: []byte("food_user_hash_barcodes_2"),
+	})
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_save_a_normalized_barcode_once_per_user",
+			fn: func(t *testing.T) {
+				params := newFoodParams("barcode crackers", 480, 9, 62, 21)
+				params.Barcode = "036000291452"
+				food := s.CreateFood(t, user.ID, params)
+				require.NotNil(t, food.Barcode)
+				require.Equal(t, "0036000291452", *food.Barcode)
+
+				params.Name = "barcode crackers again"
+				params.Barcode = "0036000291452"
+				_, err := s.Store.CreateFood(ctx, user.ID, params)
+				require.ErrorIs(t, err, logic.ErrFoodBarcodeTaken)
+
+				_, err = s.Store.CreateFood(ctx, other.ID, params)
+				require.NoError(t, err)
+
+				params.Name = "barcode crackers"
+				updated, err := s.Store.UpdateFood(ctx, food.ID, user.ID, params)
+				require.NoError(t, err)
+				require.Equal(t, "0036000291452", *updated.Barcode)
+
+				params.Barcode = ""
+				updated, err = s.Store.UpdateFood(ctx, food.ID, user.ID, params)
+				require.NoError(t, err)
+				require.Nil(t, updated.Barcode)
+			},
+		},
+		{
+			name: "should_reject_an_invalid_barcode",
+			fn: func(t *testing.T) {
+				params := newFoodParams("barcode typo", 100, 1, 1, 1)
+				params.Barcode = "4006381333932"
+				_, err := s.Store.CreateFood(ctx, user.ID, params)
+				require.ErrorIs(t, err, logic.ErrInvalidBarcode)
+			},
+		},
+		{
+			name: "should_look_up_own_foods_before_reference_foods",
+			fn: func(t *testing.T) {
+				_, err := s.Store.ImportOpenFoodFacts(ctx, strings.NewReader(
+					`{"code":"5901234123457","product_name":"Lookup muesli","brands":"Mill",`+
+						`"nutriments":{"energy-kcal_100g":360}}`,
+				))
+				require.NoError(t, err)
+
+				match, err := s.Store.LookupBarcode(ctx, user.ID, "5901234123457")
+				require.NoError(t, err)
+				require.Nil(t, match.Food)
+				require.NotNil(t, match.ReferenceFood)
+				require.Equal(t, "Lookup muesli", match.ReferenceFood.Name)
+
+				copied, err := s.Store.CopyReferenceFood(ctx, user.ID, match.ReferenceFood.ID)
+				require.NoError(t, err)
+				require.Equal(t, "5901234123457", *copied.Barcode)
+
+				match, err = s.Store.LookupBarcode(ctx, user.ID, "5901234123457")
+				require.NoError(t, err)
+				require.NotNil(t, match.Food)
+				require.Equal(t, copied.ID, match.Food.ID)
+				require.Nil(t, match.ReferenceFood)
+
+				match, err = s.Store.LookupBarcode(ctx, user.ID, "2000000000015")
+				require.NoError(t, err)
+				require.Equal(t, logic.BarcodeMatch{Barcode: "2000000000015"}, match)
+
+				_, err = s.Store.LookupBarcode(ctx, user.ID, "12345")
+				require.ErrorIs(t, err, logic.ErrInvalidBarcode)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
 func newFoodParams(name string, kcal, proteinG, carbsG, fatG float64) logic.FoodParams {
 	return logic.FoodParams{
 		Name:     name,
@@ -0,0 +1,284 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// ForecastMonthLayout keys forecast months the same way budget months are
+// keyed, so the two can be compared side by side.
+const ForecastMonthLayout = "2006-01"
+
+// ForecastHorizons are the day counts the forecast offers. The first one is
+// the default and the one the dashboard shows.
+func ForecastHorizons() []int {
+	return []int{30, 90, 365}
+}
+
+// Forecast is every bill the active recurrent expenses of a user will create
+// from today until the horizon, with the totals the budgets are checked
+// against. Amounts in Months are in the home currency.
+type Forecast struct {
+	From   time.Time
+	Until  time.Time
+	Total  uint64
+	Bills  []ForecastBill
+	Months []ForecastMonth
+}
+
+// ForecastBill is one projected copy of a recurrent expense. Amount is in the
+// rule's own currency and HomeAmount in the home currency, converted at the
+// latest stored rate.
+type ForecastBill struct {
+	RecurrentExpenseID int
+	CategoryID         int
+	Description        string
+	Date               time.Time
+	Amount             uint64
+	Currency           string
+	HomeAmount         uint64
+}
+
+// ForecastMonth is one calendar month of bills. Budget and Over are the
+// whole-month budget's, and OverBudget counts it along with the categories.
+type ForecastMonth struct {
+	Month      string
+	Total      uint64
+	HasBudget  bool
+	Budget     uint64
+	Over       bool
+	Categories []ForecastCategory
+	OverBudget int
+}
+
+// ForecastCategory is one category's projected bills in a month. A parent
+// category takes in its subcategories' bills, as its budget does. Budget is
+// what the month may spend under the version in force then, with any rollover
+// carry, and never below zero.
+type ForecastCategory struct {
+	CategoryID int
+	Total      uint64
+	HasBudget  bool
+	Budget     uint64
+	Over       bool
+}
+
+// ForecastRecurrentExpenses projects the user's active recurrent expenses over
+// the next days days, starting today. Only recurrent bills are counted: a
+// month marked over budget is one whose bills alone exceed it.
+func (s *Store) ForecastRecurrentExpenses(
+	ctx context.Context,
+	userID int,
+	homeCurrency string,
+	now time.Time,
+	days int,
+) (Forecast, error) {
+	today := utcDay(now.Unix())
+	forecast := Forecast{From: today, Until: today.AddDate(0, 0, days)}
+
+	recurrentExpenses, err := s.queries.SelectRecurrentExpenses(ctx, repo.QueryOptions{
+		Filters: repo.Filters{
+			FilterFields: []repo.FilterField{
+				{Name: "user_id", Value: userID, Operator: "="},
+				repo.RecurrentExpenseArchivedFilter(false),
+			},
+			Connector: "AND",
+		},
+		Sorting: repo.Sorting{Field: "id", Order: "ASC"},
+	})
+	if err != nil {
+		return forecast, err
+	}
+
+	rates := make(map[string]float64)
+	for _, re := range recurrentExpenses {
+		for _, date := range occurrencesBefore(RecurrentExpenseRecurrence(re), today, forecast.Until) {
+			if date.Before(today) {
+				continue
+			}
+
+			homeAmount, err := s.homeAmount(ctx, rates, re.Amount, re.Currency, homeCurrency)
+			if err != nil {
+				return forecast, err
+			}
+
+			forecast.Bills = append(forecast.Bills, ForecastBill{
+				RecurrentExpenseID: re.ID,
+				CategoryID:         re.CategoryID,
+				Description:        re.Description,
+				Date:               date,
+				Amount:             re.Amount,
+				Currency:           re.Currency,
+				HomeAmount:         homeAmount,
+			})
+			forecast.Total += homeAmount
+		}
+	}
+
+	sort.SliceStable(forecast.Bills, func(i, j int) bool {
+		return forecast.Bills[i].Date.Before(forecast.Bills[j].Date)
+	})
+
+	categories, err := s.queries.SelectCategoriesByUser(ctx, userID)
+	if err != nil {
+		return forecast, err
+	}
+
+	// Each month is measured against the budget version in force then, and the
+	// bills count as spent so rollover carries what they would leave over.
+	projected := make([]repo.ExpenseCategoryMonthTotal, 0, len(forecast.Bills))
+	for _, bill := range forecast.Bills {
+		projected = append(projected, repo.ExpenseCategoryMonthTotal{
+			CategoryID: bill.CategoryID,
+			Month:      bill.Date.Format(BudgetMonthLayout),
+			Total:      bill.HomeAmount,
+		})
+	}
+
+	monthKeys := forecastMonthKeys(forecast)
+	budgets, err := s.measureExpenseBudgetsWith(ctx, userID, homeCurrency, monthKeys, projected)
+	if err != nil {
+		return forecast, err
+	}
+
+	forecast.Months = forecastMonths(forecast, monthKeys, categories, budgets)
+
+	return forecast, nil
+}
+
+// forecastMonthKeys lists every month the horizon touches, oldest first.
+func forecastMonthKeys(forecast Forecast) []string {
+	var keys []string
+	first := time.Date(forecast.From.Year(), forecast.From.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := forecast.Until.AddDate(0, 0, -1)
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		keys = append(keys, m.Format(ForecastMonthLayout))
+	}
+
+	return keys
+}
+
+// forecastMonths buckets the bills by calendar month, listing every month the
+// horizon touches even when nothing is due in it.
+func forecastMonths(
+	forecast Forecast,
+	monthKeys []string,
+	categories []repo.Category,
+	budgets []ExpenseBudgetMonth,
+) []ForecastMonth {
+	parentByID := make(map[int]int, len(categories))
+	for _, c := range categories {
+		if c.ParentID != nil {
+			parentByID[c.ID] = *c.ParentID
+		}
+	}
+
+	availableByMonth := make(map[string]map[int]int64)
+	for _, b := range budgets {
+		if availableByMonth[b.Month] == nil {
+			availableByMonth[b.Month] = make(map[int]int64)
+		}
+		availableByMonth[b.Month][budgetKey(b.CategoryID)] = b.Available()
+	}
+
+	months := make([]ForecastMonth, 0, len(monthKeys))
+	indexByMonth := make(map[string]int, len(monthKeys))
+	for _, key := range monthKeys {
+		indexByMonth[key] = len(months)
+		months = append(months, ForecastMonth{Month: key})
+	}
+
+	totals := make([]map[int]uint64, len(months))
+	for _, bill := range forecast.Bills {
+		i := indexByMonth[bill.Date.Format(ForecastMonthLayout)]
+		if totals[i] == nil {
+			totals[i] = make(map[int]uint64)
+		}
+
+		months[i].Total += bill.HomeAmount
+		totals[i][bill.CategoryID] += bill.HomeAmount
+		if parentID, ok := parentByID[bill.CategoryID]; ok {
+			totals[i][parentID] += bill.HomeAmount
+		}
+	}
+
+	for i := range months {
+		available := availableByMonth[months[i].Month]
+		if a, ok := available[0]; ok {
+			months[i].HasBudget = true
+			months[i].Budget, months[i].Over = forecastBudget(months[i].Total, a)
+			if months[i].Over {
+				months[i].OverBudget++
+			}
+		}
+
+		for categoryID, total := range totals[i] {
+			category := ForecastCategory{CategoryID: categoryID, Total: total}
+			if a, ok := available[categoryID]; ok {
+				category.HasBudget = true
+				category.Budget, category.Over = forecastBudget(total, a)
+			}
+			if category.Over {
+				months[i].OverBudget++
+			}
+
+			months[i].Categories = append(months[i].Categories, category)
+		}
+
+		sort.Slice(months[i].Categories, func(a, b int) bool {
+			return months[i].Categories[a].Total > months[i].Categories[b].Total
+		})
+	}
+
+	return months
+}
+
+// forecastBudget floors what a month may spend at zero, since an overspent
+// rollover month can leave the next one nothing, and reports whether total
+// goes past it.
+func forecastBudget(total uint64, available int64) (uint64, bool) {
+	if available <= 0 {
+		return 0, total > 0
+	}
+
+	budget := uint64(available) //nolint:gosec // positive, checked above
+
+	return budget, total > budget
+}
+
+// homeAmount converts amount into the home currency at the latest stored rate,
+// caching rates per currency for the run. With no rate for the pair the
+// amount is counted as it is, as expense totals do.
+func (s *Store) homeAmount(
+	ctx context.Context,
+	rates map[string]float64,
+	amount uint64,
+	currency, homeCurrency string,
+) (uint64, error) {
+	if currency == homeCurrency {
+		return amount, nil
+	}
+
+	rate, ok := rates[currency]
+	if !ok {
+		var err error
+
+		rate, err = s.queries.SelectLatestExchangeRate(ctx, currency, homeCurrency)
+		if errors.Is(err, sql.ErrNoRows) {
+			rate, err = 1, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		rates[currency] = rate
+	}
+
+	return uint64(math.Round(float64(amount) * rate)), nil
+}
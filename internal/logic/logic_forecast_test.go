@@ -0,0 +1,203 @@
+package logic_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForecastRecurrentExpenses(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	now := time.Date(2026, time.January, 10, 9, 30, 0, 0, time.UTC)
+
+	newUser := func(t *testing.T, n string) logic.User {
+		t.Helper()
+
+		return s.CreateUser(t, repo.InsertUserParams{
+			Username:     "forecast_user_" + n,
+			Email:        "forecast_user_" + n + "@example.com",
+			PasswordHash: []byte("forecast_user_hash_" + n),
+		})
+	}
+	findMonth := func(t *testing.T, forecast logic.Forecast, month string) logic.ForecastMonth {
+		t.Helper()
+
+		for _, m := range forecast.Months {
+			if m.Month == month {
+				return m
+			}
+		}
+		require.Failf(t, "month not in forecast", "%s", month)
+
+		return logic.ForecastMonth{}
+	}
+	findCategory := func(t *testing.T, month logic.ForecastMonth, categoryID int) logic.ForecastCategory {
+		t.Helper()
+
+		for _, c := range month.Categories {
+			if c.CategoryID == categoryID {
+				return c
+			}
+		}
+		require.Failf(t, "category not in month", "%d in %s", categoryID, month.Month)
+
+		return logic.ForecastCategory{}
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_project_monthly_bills_and_flag_budgets",
+			fn: func(t *testing.T) {
+				user := newUser(t, "1")
+				category := s.CreateCategory(t, user.ID, "forecast rent 1")
+				params := newRecurrentExpenseParams(category.ID, "forecast rent", 60000, 1)
+				params.AnchorDay = 15
+				s.CreateRecurrentExpense(t, user.ID, params)
+				require.NoError(t, s.Store.SaveExpenseBudgets(ctx, user.ID, map[int]uint64{category.ID: 50000}))
+
+				forecast, err := s.Store.ForecastRecurrentExpenses(ctx, user.ID, "USD", now, 90)
+				require.NoError(t, err)
+
+				require.Len(t, forecast.Bills, 3)
+				require.Equal(t, time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC), forecast.Bills[0].Date)
+				require.Equal(t, uint64(180000), forecast.Total)
+				require.Len(t, forecast.Months, 4)
+
+				january := findMonth(t, forecast, "2026-01")
+				require.Equal(t, 1, january.OverBudget)
+				rent := findCategory(t, january, category.ID)
+				require.True(t, rent.Over)
+				require.Equal(t, uint64(50000), rent.Budget)
+
+				april := findMonth(t, forecast, "2026-04")
+				require.Zero(t, april.Total)
+				require.Empty(t, april.Categories)
+			},
+		},
+		{
+			name: "should_roll_subcategory_bills_into_the_parent_budget",
+			fn: func(t *testing.T) {
+				user := newUser(t, "2")
+				parent := s.CreateCategory(t, user.ID, "forecast home 2")
+				child := s.CreateCategory(t, user.ID, "forecast power 2")
+				_, err := s.Store.SetCategoryParent(ctx, child.ID, user.ID, &parent.ID)
+				require.NoError(t, err)
+				require.NoError(t, s.Store.SaveExpenseBudgets(ctx, user.ID, map[int]uint64{parent.ID: 5000}))
+
+				params := newRecurrentExpenseParams(child.ID, "forecast power", 3000, 1)
+				params.Frequency = logic.RecurrenceWeekly
+				startsAt := time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC).Unix()
+				params.StartsAt = &startsAt
+				s.CreateRecurrentExpense(t, user.ID, params)
+
+				forecast, err := s.Store.ForecastRecurrentExpenses(ctx, user.ID, "USD", now, 30)
+				require.NoError(t, err)
+
+				january := findMonth(t, forecast, "2026-01")
+				home := findCategory(t, january, parent.ID)
+				require.Equal(t, uint64(9000), home.Total)
+				require.True(t, home.Over)
+
+				power := findCategory(t, january, child.ID)
+				require.Equal(t, uint64(9000), power.Total)
+				require.False(t, power.Over)
+			},
+		},
+		{
+			name: "should_measure_each_month_against_the_budget_in_force_then",
+			fn: func(t *testing.T) {
+				user := newUser(t, "5")
+				category := s.CreateCategory(t, user.ID, "forecast rent 5")
+				params := newRecurrentExpenseParams(category.ID, "forecast rent", 60000, 1)
+				params.AnchorDay = 15
+				s.CreateRecurrentExpense(t, user.ID, params)
+				require.NoError(t, s.Store.SaveExpenseBudgetsFrom(ctx, user.ID, "2026-01", []logic.ExpenseBudgetParams{
+					{CategoryID: category.ID, Amount: 50000},
+					{Amount: 100000, Rollover: true},
+				}))
+				require.NoError(t, s.Store.SaveExpenseBudgetsFrom(ctx, user.ID, "2026-02", []logic.ExpenseBudgetParams{
+					{CategoryID: category.ID, Amount: 70000},
+				}))
+
+				forecast, err := s.Store.ForecastRecurrentExpenses(ctx, user.ID, "USD", now, 60)
+				require.NoError(t, err)
+
+				january := findMonth(t, forecast, "2026-01")
+				require.True(t, findCategory(t, january, category.ID).Over)
+				require.True(t, january.HasBudget)
+				require.Equal(t, uint64(100000), january.Budget)
+				require.False(t, january.Over)
+				require.Equal(t, 1, january.OverBudget)
+
+				february := findMonth(t, forecast, "2026-02")
+				rent := findCategory(t, february, category.ID)
+				require.Equal(t, uint64(70000), rent.Budget)
+				require.False(t, rent.Over)
+				require.Equal(t, uint64(140000), february.Budget, "January's unbilled 40000 rolls over")
+				require.Zero(t, february.OverBudget)
+			},
+		},
+		{
+			name: "should_stop_at_the_occurrence_limit_and_skip_archived_rules",
+			fn: func(t *testing.T) {
+				user := newUser(t, "3")
+				category := s.CreateCategory(t, user.ID, "forecast gym 3")
+				archived := newRecurrentExpenseParams(category.ID, "forecast old gym", 2500, 1)
+				archived.OccurrenceLimit = 1
+				s.CreateRecurrentExpense(t, user.ID, archived)
+				_, err := s.Store.CopyDueRecurrentExpenses(ctx, time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC))
+				require.NoError(t, err)
+
+				// The overdue Dec 20 copy the next run makes uses up one of the two.
+				params := newRecurrentExpenseParams(category.ID, "forecast gym", 2500, 1)
+				params.OccurrenceLimit = 2
+				params.AnchorDay = 20
+				s.CreateRecurrentExpense(t, user.ID, params)
+
+				forecast, err := s.Store.ForecastRecurrentExpenses(ctx, user.ID, "USD", now, 365)
+				require.NoError(t, err)
+
+				require.Len(t, forecast.Bills, 1)
+				require.Equal(t, "forecast gym", forecast.Bills[0].Description)
+				require.Equal(t, time.Date(2026, time.January, 20, 0, 0, 0, 0, time.UTC), forecast.Bills[0].Date)
+			},
+		},
+		{
+			name: "should_convert_at_the_latest_rate",
+			fn: func(t *testing.T) {
+				user := newUser(t, "4")
+				_, err := s.Store.ImportExchangeRates(ctx, strings.NewReader(
+					"date,base_currency,quote_currency,rate\n2025-01-01,SEK,USD,0.08\n2025-12-01,SEK,USD,0.1\n",
+				))
+				require.NoError(t, err)
+
+				category := s.CreateCategory(t, user.ID, "forecast streaming 4")
+				params := newRecurrentExpenseParams(category.ID, "forecast streaming", 10000, 1)
+				params.Currency = "SEK"
+				params.AnchorDay = 28
+				s.CreateRecurrentExpense(t, user.ID, params)
+
+				forecast, err := s.Store.ForecastRecurrentExpenses(ctx, user.ID, "USD", now, 30)
+				require.NoError(t, err)
+
+				require.Len(t, forecast.Bills, 1)
+				require.Equal(t, uint64(10000), forecast.Bills[0].Amount)
+				require.Equal(t, uint64(1000), forecast.Bills[0].HomeAmount)
+				require.Equal(t, uint64(1000), forecast.Total)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -0,0 +1,143 @@
+package logic
+
+import (
+	"context"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+type IncomeParams struct {
+	Source   string   `validate:"required,min=2,max=50"`
+	Amount   uint64   `validate:"required,gt=0"`
+	Currency string   `validate:"omitempty,iso4217"`
+	Date     int64    `validate:"required,gt=0"`
+	Tags     []string `validate:"-"`
+}
+
+func (s *Store) FindIncomes(ctx context.Context, opts repo.QueryOptions) ([]repo.Income, error) {
+	incomes, err := s.queries.SelectIncomes(ctx, opts)
+	if err != nil {
+		return incomes, err
+	}
+
+	return incomes, nil
+}
+
+func (s *Store) CountIncomes(ctx context.Context, filters repo.Filters) (int, error) {
+	count, err := s.queries.CountIncomes(ctx, filters)
+	if err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+func (s *Store) FindIncome(ctx context.Context, id, userID int) (repo.Income, error) {
+	income, err := s.queries.SelectIncome(ctx, id, userID)
+	if err != nil {
+		return income, err
+	}
+
+	return income, nil
+}
+
+func (s *Store) FindIncomeTags(ctx context.Context, incomeID, userID int) ([]repo.Tag, error) {
+	tags, err := s.queries.SelectTagsForTaggable(ctx, repo.TaggableTypeIncome, "incomes", incomeID, userID)
+	if err != nil {
+		return tags, err
+	}
+
+	return tags, nil
+}
+
+// FindIncomeSources returns the sources the user has recorded, most used
+// first, for the form to suggest.
+func (s *Store) FindIncomeSources(ctx context.Context, userID int) ([]string, error) {
+	return s.queries.SelectIncomeSources(ctx, userID)
+}
+
+func (s *Store) CreateIncome(ctx context.Context, userID int, params IncomeParams) (repo.Income, error) {
+	var income repo.Income
+
+	params.Currency = NormalizeCurrency(params.Currency)
+	if err := s.ValidateStruct(params); err != nil {
+		return income, err
+	}
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		currency, txErr := currencyOrHomeTx(ctx, tq, userID, params.Currency)
+		if txErr != nil {
+			return txErr
+		}
+
+		income, txErr = tq.InsertIncome(ctx, repo.InsertIncomeParams{
+			UserID:   userID,
+			Source:   params.Source,
+			Amount:   params.Amount,
+			Currency: currency,
+			Date:     params.Date,
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		return s.replaceTagsTx(ctx, tq, repo.TaggableTypeIncome, income.ID, userID, params.Tags)
+	})
+	if err != nil {
+		return income, err
+	}
+
+	return income, nil
+}
+
+func (s *Store) UpdateIncome(ctx context.Context, id, userID int, params IncomeParams) (repo.Income, error) {
+	var income repo.Income
+
+	params.Currency = NormalizeCurrency(params.Currency)
+	if err := s.ValidateStruct(params); err != nil {
+		return income, err
+	}
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		var txErr error
+
+		income, txErr = tq.UpdateIncome(ctx, repo.UpdateIncomeParams{
+			ID:       id,
+			UserID:   userID,
+			Source:   params.Source,
+			Amount:   params.Amount,
+			Currency: params.Currency,
+			Date:     params.Date,
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		return s.replaceTagsTx(ctx, tq, repo.TaggableTypeIncome, income.ID, userID, params.Tags)
+	})
+	if err != nil {
+		return income, err
+	}
+
+	return income, nil
+}
+
+// DeleteIncome removes the income and its taggings together, for the same
+// reason DeleteRecurrentExpense does: a left-behind tagging would tag
+// whichever income reuses the id.
+func (s *Store) DeleteIncome(ctx context.Context, id, userID int) error {
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		deletedID, err := tq.DeleteIncome(ctx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		return tq.DeleteTaggingsByTarget(ctx, repo.TaggableTypeIncome, deletedID)
+	})
+}
+
+func (s *Store) DeleteAllIncomes(ctx context.Context, userID int) error {
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		return tq.DeleteAllIncomesByUser(ctx, userID)
+	})
+}
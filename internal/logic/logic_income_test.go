@@ -0,0 +1,170 @@
+package logic_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateIncome(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "income_user_1",
+		Email:        "income_user_1@example.com",
+		PasswordHash: []byte("income_user_hash_1"),
+	})
+	date := time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC).Unix()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_income_in_the_home_currency",
+			fn: func(t *testing.T) {
+				income, err := s.Store.CreateIncome(ctx, user.ID, logic.IncomeParams{
+					Source: "Acme payroll",
+					Amount: 250000,
+					Date:   date,
+				})
+				require.NoError(t, err)
+				require.Positive(t, income.ID)
+				require.Equal(t, user.ID, income.UserID)
+				require.Equal(t, "USD", income.Currency)
+				require.Nil(t, income.RecurrentIncomeID)
+			},
+		},
+		{
+			name: "should_tag_income",
+			fn: func(t *testing.T) {
+				income, err := s.Store.CreateIncome(ctx, user.ID, logic.IncomeParams{
+					Source:   "Freelance client",
+					Amount:   80000,
+					Currency: "eur",
+					Date:     date,
+					Tags:     []string{"side", "consulting"},
+				})
+				require.NoError(t, err)
+				require.Equal(t, "EUR", income.Currency)
+
+				tags, err := s.Store.FindIncomeTags(ctx, income.ID, user.ID)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []string{"side", "consulting"}, logic.ExtractTagNames(tags))
+			},
+		},
+		{
+			name: "should_fail_validation_for_invalid_params",
+			fn: func(t *testing.T) {
+				_, err := s.Store.CreateIncome(ctx, user.ID, logic.IncomeParams{Source: "x"})
+				require.ErrorIs(t, err, logic.ErrValidationFailed)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestUpdateAndDeleteIncome(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "income_user_2",
+		Email:        "income_user_2@example.com",
+		PasswordHash: []byte("income_user_hash_2"),
+	})
+	otherUser := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "income_user_3",
+		Email:        "income_user_3@example.com",
+		PasswordHash: []byte("income_user_hash_3"),
+	})
+	date := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+	newIncome := func(t *testing.T, source string) repo.Income {
+		t.Helper()
+
+		income, err := s.Store.CreateIncome(ctx, user.ID, logic.IncomeParams{
+			Source: source,
+			Amount: 1000,
+			Date:   date,
+			Tags:   []string{"salary"},
+		})
+		require.NoError(t, err)
+
+		return income
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_update_income_and_keep_currency_when_blank",
+			fn: func(t *testing.T) {
+				income := newIncome(t, "Update source")
+
+				updated, err := s.Store.UpdateIncome(ctx, income.ID, user.ID, logic.IncomeParams{
+					Source: "Updated source",
+					Amount: 2000,
+					Date:   date,
+				})
+				require.NoError(t, err)
+				require.Equal(t, "Updated source", updated.Source)
+				require.Equal(t, uint64(2000), updated.Amount)
+				require.Equal(t, income.Currency, updated.Currency)
+
+				tags, err := s.Store.FindIncomeTags(ctx, income.ID, user.ID)
+				require.NoError(t, err)
+				require.Empty(t, tags)
+			},
+		},
+		{
+			name: "should_not_update_another_users_income",
+			fn: func(t *testing.T) {
+				income := newIncome(t, "Guarded source")
+
+				_, err := s.Store.UpdateIncome(ctx, income.ID, otherUser.ID, logic.IncomeParams{
+					Source: "Stolen",
+					Amount: 1,
+					Date:   date,
+				})
+				require.ErrorIs(t, err, sql.ErrNoRows)
+			},
+		},
+		{
+			name: "should_delete_income_with_its_taggings",
+			fn: func(t *testing.T) {
+				income := newIncome(t, "Deleted source")
+
+				require.NoError(t, s.Store.DeleteIncome(ctx, income.ID, user.ID))
+
+				_, err := s.Store.FindIncome(ctx, income.ID, user.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+
+				tags, err := s.Store.FindIncomeTags(ctx, income.ID, user.ID)
+				require.NoError(t, err)
+				require.Empty(t, tags)
+			},
+		},
+		{
+			name: "should_suggest_sources_most_used_first",
+			fn: func(t *testing.T) {
+				sources, err := s.Store.FindIncomeSources(ctx, user.ID)
+				require.NoError(t, err)
+				require.NotEmpty(t, sources)
+				require.Contains(t, sources, "Guarded source")
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
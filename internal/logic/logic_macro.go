@@ -63,36 +63,12 @@ func (s *Store) FindMacroDailyTotals(
 }
 
 func (s *Store) CreateMacroEntry(ctx context.Context, userID int, params MacroEntryParams) (repo.MacroEntry, error) {
-	var entry repo.MacroEntry
-
-	if err := s.ValidateStruct(params); err != nil {
-		return entry, err
-	}
-
-	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
-		var txErr error
-
-		entry, txErr = tq.InsertMacroEntry(ctx, repo.InsertMacroEntryParams{
-			UserID:        userID,
-			Name:          params.Name,
-			Kcal:          params.Kcal,
-			ProteinG:      params.ProteinG,
-			CarbsG:        params.CarbsG,
-			FatG:          params.FatG,
-			Date:          params.Date,
-			MealType:      params.MealType,
-			FiberG:        params.FiberG,
-			SodiumG:       params.SodiumG,
-			SaturatedFatG: params.SaturatedFatG,
-		})
-
-		return txErr
-	})
+	entries, err := s.createMacroEntries(ctx, userID, []MacroEntryParams{params})
 	if err != nil {
-		return entry, err
+		return repo.MacroEntry{}, err
 	}
 
-	return entry, nil
+	return entries[0], nil
 }
 
 func (s *Store) UpdateMacroEntry(
@@ -132,6 +108,50 @@ func (s *Store) UpdateMacroEntry(
 	return entry, nil
 }
 
+// createMacroEntries logs entries in one transaction, so a meal is never left
+// half logged. Every entry is validated before any is written.
+func (s *Store) createMacroEntries(
+	ctx context.Context,
+	userID int,
+	params []MacroEntryParams,
+) ([]repo.MacroEntry, error) {
+	for _, p := range params {
+		if err := s.ValidateStruct(p); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make([]repo.MacroEntry, 0, len(params))
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		for _, p := range params {
+			entry, err := tq.InsertMacroEntry(ctx, repo.InsertMacroEntryParams{
+				UserID:        userID,
+				Name:          p.Name,
+				Kcal:          p.Kcal,
+				ProteinG:      p.ProteinG,
+				CarbsG:        p.CarbsG,
+				FatG:          p.FatG,
+				Date:          p.Date,
+				MealType:      p.MealType,
+				FiberG:        p.FiberG,
+				SodiumG:       p.SodiumG,
+				SaturatedFatG: p.SaturatedFatG,
+			})
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
 func (s *Store) DeleteMacroEntry(ctx context.Context, id, userID int) (int, error) {
 	i, err := s.queries.DeleteMacroEntry(ctx, id, userID)
 	if err != nil {
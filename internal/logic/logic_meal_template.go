@@ -0,0 +1,315 @@
+package logic
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+type MealTemplateParams struct {
+	Name     string `validate:"required,min=1,max=100"`
+	MealType string `validate:"required,oneof=breakfast lunch dinner snack other"`
+}
+
+type MealTemplateItemParams struct {
+	FoodID   int     `validate:"required,gt=0"`
+	Quantity float64 `validate:"gt=0"`
+	Unit     string  `validate:"required,oneof=g kg oz lb ml l"`
+}
+
+// MealTemplateApplyParams logs a template on Date. A blank MealType keeps the
+// template's own.
+type MealTemplateApplyParams struct {
+	Date     int64  `validate:"required,gt=0"`
+	MealType string `validate:"omitempty,oneof=breakfast lunch dinner snack other"`
+}
+
+// MealCopyParams copies the MealType entries of the UTC day holding From to
+// the day holding To. Each copy keeps its time of day.
+type MealCopyParams struct {
+	From     int64  `validate:"required,gt=0"`
+	To       int64  `validate:"required,gt=0"`
+	MealType string `validate:"required,oneof=breakfast lunch dinner snack other"`
+}
+
+// MealTemplateItemLine is an item with what its quantity contributes.
+// NeedsDensity marks an item whose unit does not convert to the food's base
+// unit; it adds nothing, and the template cannot be applied, until the food
+// has a density.
+type MealTemplateItemLine struct {
+	repo.MealTemplateItemFood
+	Macros       Nutrients
+	NeedsDensity bool
+}
+
+// MealTemplateDetail is a template measured from its foods as they stand now.
+type MealTemplateDetail struct {
+	Template repo.MealTemplate
+	Items    []MealTemplateItemLine
+	Total    Nutrients
+}
+
+// FindMealTemplates returns every template measured from its foods, by name.
+func (s *Store) FindMealTemplates(ctx context.Context, userID int) ([]MealTemplateDetail, error) {
+	templates, err := s.queries.SelectMealTemplatesByUser(ctx, userID)
+	if err != nil || len(templates) == 0 {
+		return nil, err
+	}
+
+	items, err := s.queries.SelectMealTemplateItemsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byTemplateID := make(map[int][]repo.MealTemplateItemFood, len(templates))
+	for _, i := range items {
+		byTemplateID[i.MealTemplateID] = append(byTemplateID[i.MealTemplateID], i)
+	}
+
+	details := make([]MealTemplateDetail, 0, len(templates))
+	for _, t := range templates {
+		details = append(details, measureMealTemplate(t, byTemplateID[t.ID]))
+	}
+
+	return details, nil
+}
+
+func (s *Store) FindMealTemplate(ctx context.Context, id, userID int) (repo.MealTemplate, error) {
+	return s.queries.SelectMealTemplate(ctx, id, userID)
+}
+
+// FindMealTemplateDetail measures one template, its items by food name.
+func (s *Store) FindMealTemplateDetail(ctx context.Context, template repo.MealTemplate) (MealTemplateDetail, error) {
+	items, err := s.queries.SelectMealTemplateItemsByTemplate(ctx, template.ID, template.UserID)
+	if err != nil {
+		return MealTemplateDetail{}, err
+	}
+
+	return measureMealTemplate(template, items), nil
+}
+
+func (s *Store) CreateMealTemplate(
+	ctx context.Context,
+	userID int,
+	params MealTemplateParams,
+) (repo.MealTemplate, error) {
+	if err := s.validateMealTemplateParams(&params); err != nil {
+		return repo.MealTemplate{}, err
+	}
+
+	template, err := s.queries.InsertMealTemplate(ctx, repo.InsertMealTemplateParams{
+		UserID:   userID,
+		Name:     params.Name,
+		MealType: params.MealType,
+	})
+	if repo.IsUniqueViolation(err) {
+		return template, ErrMealTemplateNameTaken
+	}
+
+	return template, err
+}
+
+func (s *Store) UpdateMealTemplate(
+	ctx context.Context,
+	id, userID int,
+	params MealTemplateParams,
+) (repo.MealTemplate, error) {
+	if err := s.validateMealTemplateParams(&params); err != nil {
+		return repo.MealTemplate{}, err
+	}
+
+	template, err := s.queries.UpdateMealTemplate(ctx, repo.UpdateMealTemplateParams{
+		ID:       id,
+		UserID:   userID,
+		Name:     params.Name,
+		MealType: params.MealType,
+	})
+	if repo.IsUniqueViolation(err) {
+		return template, ErrMealTemplateNameTaken
+	}
+
+	return template, err
+}
+
+// DeleteMealTemplate removes the template and its items. Entries already
+// logged from it are left alone.
+func (s *Store) DeleteMealTemplate(ctx context.Context, id, userID int) error {
+	_, err := s.queries.DeleteMealTemplate(ctx, id, userID)
+
+	return err
+}
+
+func (s *Store) DeleteAllMealTemplates(ctx context.Context, userID int) error {
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		return tq.DeleteAllMealTemplatesByUser(ctx, userID)
+	})
+}
+
+// SaveMealTemplateItem adds a food to a template, or sets its quantity when
+// the template already has it. It returns sql.ErrNoRows when the template or
+// the food is not the user's, or the food is in the trash, and
+// ErrUnitNeedsDensity when the unit does not convert to the food's.
+func (s *Store) SaveMealTemplateItem(
+	ctx context.Context,
+	templateID, userID int,
+	params MealTemplateItemParams,
+) (repo.MealTemplateItem, error) {
+	if err := s.ValidateStruct(params); err != nil {
+		return repo.MealTemplateItem{}, err
+	}
+
+	food, err := s.queries.SelectFood(ctx, params.FoodID, userID)
+	if err != nil {
+		return repo.MealTemplateItem{}, err
+	}
+	if _, err = ScaleFood(food, params.Quantity, params.Unit); err != nil {
+		return repo.MealTemplateItem{}, err
+	}
+
+	return s.queries.UpsertMealTemplateItem(ctx, repo.UpsertMealTemplateItemParams{
+		UserID:         userID,
+		MealTemplateID: templateID,
+		FoodID:         params.FoodID,
+		Quantity:       params.Quantity,
+		Unit:           params.Unit,
+	})
+}
+
+// RemoveMealTemplateItem takes one item out of a template. It returns
+// sql.ErrNoRows when the template has no such item.
+func (s *Store) RemoveMealTemplateItem(ctx context.Context, id, templateID, userID int) error {
+	_, err := s.queries.DeleteMealTemplateItem(ctx, id, templateID, userID)
+
+	return err
+}
+
+// ApplyMealTemplate logs every item of the template as its own macro entry,
+// named after its food, in one transaction: either the whole meal is logged
+// or none of it is. Entries are copies and do not follow later edits.
+func (s *Store) ApplyMealTemplate(
+	ctx context.Context,
+	template repo.MealTemplate,
+	params MealTemplateApplyParams,
+) ([]repo.MacroEntry, error) {
+	if err := s.ValidateStruct(params); err != nil {
+		return nil, err
+	}
+
+	mealType := params.MealType
+	if mealType == "" {
+		mealType = template.MealType
+	}
+
+	items, err := s.queries.SelectMealTemplateItemsByTemplate(ctx, template.ID, template.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrMealTemplateEmpty
+	}
+
+	entries := make([]MacroEntryParams, 0, len(items))
+	for _, i := range items {
+		m, err := ScaleFood(i.Food, i.Quantity, i.Unit)
+		if err != nil {
+			return nil, err
+		}
+		m = m.rounded()
+
+		entries = append(entries, MacroEntryParams{
+			Name:          i.Food.Name,
+			Kcal:          m.Kcal,
+			ProteinG:      m.ProteinG,
+			CarbsG:        m.CarbsG,
+			FatG:          m.FatG,
+			Date:          params.Date,
+			MealType:      mealType,
+			FiberG:        m.FiberG,
+			SodiumG:       m.SodiumG,
+			SaturatedFatG: m.SaturatedFatG,
+		})
+	}
+
+	return s.createMacroEntries(ctx, template.UserID, entries)
+}
+
+// CopyMeal logs again what was eaten at one meal on another day, as with
+// copying yesterday's lunch to today. It returns ErrMealEmpty when nothing
+// was logged for that meal.
+func (s *Store) CopyMeal(ctx context.Context, userID int, params MealCopyParams) ([]repo.MacroEntry, error) {
+	if err := s.ValidateStruct(params); err != nil {
+		return nil, err
+	}
+
+	fromDay := utcDayStart(params.From)
+	source, err := s.queries.SelectMacroEntries(ctx, repo.QueryOptions{
+		Filters: repo.Filters{
+			FilterFields: []repo.FilterField{
+				{Name: "user_id", Value: userID, Operator: "="},
+				{Name: "date", Value: fromDay, Operator: ">="},
+				{Name: "date", Value: fromDay + secondsPerDay, Operator: "<"},
+				{Name: "meal_type", Value: params.MealType, Operator: "="},
+			},
+			Connector: "AND",
+		},
+		Sorting: repo.Sorting{Field: "date", Order: "ASC"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(source) == 0 {
+		return nil, ErrMealEmpty
+	}
+
+	shift := utcDayStart(params.To) - fromDay
+	entries := make([]MacroEntryParams, 0, len(source))
+	for _, e := range source {
+		entries = append(entries, MacroEntryParams{
+			Name:          e.Name,
+			Kcal:          e.Kcal,
+			ProteinG:      e.ProteinG,
+			CarbsG:        e.CarbsG,
+			FatG:          e.FatG,
+			Date:          e.Date + shift,
+			MealType:      e.MealType,
+			FiberG:        e.FiberG,
+			SodiumG:       e.SodiumG,
+			SaturatedFatG: e.SaturatedFatG,
+		})
+	}
+
+	return s.createMacroEntries(ctx, userID, entries)
+}
+
+func (s *Store) validateMealTemplateParams(params *MealTemplateParams) error {
+	params.Name = strings.TrimSpace(params.Name)
+
+	return s.ValidateStruct(*params)
+}
+
+// measureMealTemplate sums the items unrounded and rounds each figure once.
+func measureMealTemplate(template repo.MealTemplate, items []repo.MealTemplateItemFood) MealTemplateDetail {
+	detail := MealTemplateDetail{Template: template, Items: make([]MealTemplateItemLine, 0, len(items))}
+
+	var total Nutrients
+	for _, i := range items {
+		m, err := ScaleFood(i.Food, i.Quantity, i.Unit)
+		detail.Items = append(detail.Items, MealTemplateItemLine{
+			MealTemplateItemFood: i,
+			Macros:               m.rounded(),
+			NeedsDensity:         err != nil,
+		})
+		total = total.add(m)
+	}
+
+	detail.Total = total.rounded()
+
+	return detail
+}
+
+// utcDayStart is the start of the UTC day holding ts, the day the macros
+// pages group entries by.
+func utcDayStart(ts int64) int64 {
+	return ts - ts%secondsPerDay
+}
@@ -0,0 +1,272 @@
+package logic_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMealTemplates(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "meal_template_user_1",
+		Email:        "meal_template_user_1@example.com",
+		PasswordHash: []byte("meal_template_hash_1"),
+	})
+	other := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "meal_template_user_2",
+		Email:        "meal_template_user_2@example.com",
+		PasswordHash: []byte("meal_template_hash_2"),
+	})
+
+	oats, err := s.Store.CreateFood(ctx, user.ID, newFoodParams("Oats", 380, 13, 67, 7))
+	require.NoError(t, err)
+	milkParams := newFoodParams("Milk", 60, 3.2, 4.8, 3.3)
+	milkParams.BaseAmount = 100
+	milkParams.BaseUnit = logic.UnitMilliliter
+	milkParams.DensityGPerML = 1.03
+	milk, err := s.Store.CreateFood(ctx, user.ID, milkParams)
+	require.NoError(t, err)
+	foreignFood, err := s.Store.CreateFood(ctx, other.ID, newFoodParams("Foreign", 100, 1, 1, 1))
+	require.NoError(t, err)
+
+	breakfast, err := s.Store.CreateMealTemplate(ctx, user.ID, logic.MealTemplateParams{
+		Name:     " Usual breakfast ",
+		MealType: "breakfast",
+	})
+	require.NoError(t, err)
+
+	addItem := func(t *testing.T, templateID, foodID int, quantity float64, unit string) {
+		t.Helper()
+
+		_, err := s.Store.SaveMealTemplateItem(ctx, templateID, user.ID, logic.MealTemplateItemParams{
+			FoodID:   foodID,
+			Quantity: quantity,
+			Unit:     unit,
+		})
+		require.NoError(t, err)
+	}
+	entriesOn := func(t *testing.T, day time.Time) []repo.MacroEntry {
+		t.Helper()
+
+		entries, err := s.Store.FindMacroEntries(ctx, repo.QueryOptions{
+			Filters: repo.Filters{
+				FilterFields: []repo.FilterField{
+					{Name: "user_id", Value: user.ID, Operator: "="},
+					{Name: "date", Value: day.Unix(), Operator: ">="},
+					{Name: "date", Value: day.AddDate(0, 0, 1).Unix(), Operator: "<"},
+				},
+				Connector: "AND",
+			},
+			Sorting: repo.Sorting{Field: "name", Order: "ASC"},
+		})
+		require.NoError(t, err)
+
+		return entries
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_a_template_with_a_trimmed_name",
+			fn: func(t *testing.T) {
+				require.Equal(t, "Usual breakfast", breakfast.Name)
+				require.Equal(t, "breakfast", breakfast.MealType)
+			},
+		},
+		{
+			name: "should_reject_a_name_already_taken",
+			fn: func(t *testing.T) {
+				_, err := s.Store.CreateMealTemplate(ctx, user.ID, logic.MealTemplateParams{
+					Name:     "usual BREAKFAST",
+					MealType: "lunch",
+				})
+				require.ErrorIs(t, err, logic.ErrMealTemplateNameTaken)
+			},
+		},
+		{
+			name: "should_measure_items_in_any_unit_their_food_converts_to",
+			fn: func(t *testing.T) {
+				addItem(t, breakfast.ID, oats.ID, 50, logic.UnitGram)
+				addItem(t, breakfast.ID, milk.ID, 200, logic.UnitMilliliter)
+
+				detail, err := s.Store.FindMealTemplateDetail(ctx, breakfast)
+				require.NoError(t, err)
+				require.Len(t, detail.Items, 2)
+				require.Equal(t, "Milk", detail.Items[0].Food.Name)
+				require.InDelta(t, 120.0, detail.Items[0].Macros.Kcal, 0.001)
+				require.InDelta(t, 190.0, detail.Items[1].Macros.Kcal, 0.001)
+				require.InDelta(t, 310.0, detail.Total.Kcal, 0.001)
+			},
+		},
+		{
+			name: "should_not_add_a_unit_that_needs_a_missing_density",
+			fn: func(t *testing.T) {
+				_, err := s.Store.SaveMealTemplateItem(ctx, breakfast.ID, user.ID, logic.MealTemplateItemParams{
+					FoodID:   oats.ID,
+					Quantity: 100,
+					Unit:     logic.UnitMilliliter,
+				})
+				require.ErrorIs(t, err, logic.ErrUnitNeedsDensity)
+			},
+		},
+		{
+			name: "should_not_add_another_users_food",
+			fn: func(t *testing.T) {
+				_, err := s.Store.SaveMealTemplateItem(ctx, breakfast.ID, user.ID, logic.MealTemplateItemParams{
+					FoodID:   foreignFood.ID,
+					Quantity: 100,
+					Unit:     logic.UnitGram,
+				})
+				require.ErrorIs(t, err, sql.ErrNoRows)
+			},
+		},
+		{
+			name: "should_log_every_item_under_the_default_meal",
+			fn: func(t *testing.T) {
+				day := time.Date(2026, time.May, 4, 0, 0, 0, 0, time.UTC)
+				logged, err := s.Store.ApplyMealTemplate(ctx, breakfast, logic.MealTemplateApplyParams{
+					Date: day.Add(8 * time.Hour).Unix(),
+				})
+				require.NoError(t, err)
+				require.Len(t, logged, 2)
+
+				entries := entriesOn(t, day)
+				require.Len(t, entries, 2)
+				require.Equal(t, "Milk", entries[0].Name)
+				require.Equal(t, "breakfast", entries[0].MealType)
+				require.InDelta(t, 120.0, entries[0].Kcal, 0.001)
+				require.Equal(t, "Oats", entries[1].Name)
+				require.InDelta(t, 33.5, entries[1].CarbsG, 0.001)
+			},
+		},
+		{
+			name: "should_log_under_the_meal_picked_instead",
+			fn: func(t *testing.T) {
+				day := time.Date(2026, time.May, 5, 0, 0, 0, 0, time.UTC)
+				_, err := s.Store.ApplyMealTemplate(ctx, breakfast, logic.MealTemplateApplyParams{
+					Date:     day.Add(15 * time.Hour).Unix(),
+					MealType: "snack",
+				})
+				require.NoError(t, err)
+
+				entries := entriesOn(t, day)
+				require.Len(t, entries, 2)
+				for _, e := range entries {
+					require.Equal(t, "snack", e.MealType)
+				}
+			},
+		},
+		{
+			name: "should_not_log_a_template_without_items",
+			fn: func(t *testing.T) {
+				empty, err := s.Store.CreateMealTemplate(ctx, user.ID, logic.MealTemplateParams{
+					Name:     "Empty",
+					MealType: "lunch",
+				})
+				require.NoError(t, err)
+
+				_, err = s.Store.ApplyMealTemplate(ctx, empty, logic.MealTemplateApplyParams{Date: time.Now().Unix()})
+				require.ErrorIs(t, err, logic.ErrMealTemplateEmpty)
+			},
+		},
+		{
+			name: "should_log_nothing_when_one_item_cannot_be_measured",
+			fn: func(t *testing.T) {
+				juiceParams := newFoodParams("Juice", 45, 0.7, 10, 0.2)
+				juiceParams.DensityGPerML = 1.04
+				juice, err := s.Store.CreateFood(ctx, user.ID, juiceParams)
+				require.NoError(t, err)
+				lunch, err := s.Store.CreateMealTemplate(ctx, user.ID, logic.MealTemplateParams{
+					Name:     "Lunch box",
+					MealType: "lunch",
+				})
+				require.NoError(t, err)
+				addItem(t, lunch.ID, oats.ID, 80, logic.UnitGram)
+				addItem(t, lunch.ID, juice.ID, 250, logic.UnitMilliliter)
+
+				juiceParams.DensityGPerML = 0
+				_, err = s.Store.UpdateFood(ctx, juice.ID, user.ID, juiceParams)
+				require.NoError(t, err)
+
+				day := time.Date(2026, time.May, 6, 0, 0, 0, 0, time.UTC)
+				_, err = s.Store.ApplyMealTemplate(ctx, lunch, logic.MealTemplateApplyParams{
+					Date: day.Add(12 * time.Hour).Unix(),
+				})
+				require.ErrorIs(t, err, logic.ErrUnitNeedsDensity)
+				require.Empty(t, entriesOn(t, day))
+			},
+		},
+		{
+			name: "should_copy_a_meal_to_another_day_keeping_its_times",
+			fn: func(t *testing.T) {
+				from := time.Date(2026, time.May, 4, 0, 0, 0, 0, time.UTC)
+				to := time.Date(2026, time.May, 7, 0, 0, 0, 0, time.UTC)
+				_, err := s.Store.CreateMacroEntry(ctx, user.ID, logic.MacroEntryParams{
+					Name:     "Coffee",
+					Kcal:     5,
+					Date:     from.Add(10 * time.Hour).Unix(),
+					MealType: "snack",
+				})
+				require.NoError(t, err)
+
+				copied, err := s.Store.CopyMeal(ctx, user.ID, logic.MealCopyParams{
+					From:     from.Unix(),
+					To:       to.Add(20 * time.Hour).Unix(),
+					MealType: "breakfast",
+				})
+				require.NoError(t, err)
+				require.Len(t, copied, 2)
+
+				entries := entriesOn(t, to)
+				require.Len(t, entries, 2)
+				for _, e := range entries {
+					require.Equal(t, "breakfast", e.MealType)
+					require.Equal(t, to.Add(8*time.Hour).Unix(), e.Date)
+				}
+			},
+		},
+		{
+			name: "should_not_copy_a_meal_nothing_was_logged_for",
+			fn: func(t *testing.T) {
+				from := time.Date(2026, time.May, 4, 0, 0, 0, 0, time.UTC)
+
+				_, err := s.Store.CopyMeal(ctx, user.ID, logic.MealCopyParams{
+					From:     from.Unix(),
+					To:       from.AddDate(0, 0, 1).Unix(),
+					MealType: "dinner",
+				})
+				require.ErrorIs(t, err, logic.ErrMealEmpty)
+			},
+		},
+		{
+			name: "should_remove_an_item_and_then_delete_the_template",
+			fn: func(t *testing.T) {
+				detail, err := s.Store.FindMealTemplateDetail(ctx, breakfast)
+				require.NoError(t, err)
+				require.NoError(t, s.Store.RemoveMealTemplateItem(ctx, detail.Items[0].ID, breakfast.ID, user.ID))
+
+				detail, err = s.Store.FindMealTemplateDetail(ctx, breakfast)
+				require.NoError(t, err)
+				require.Len(t, detail.Items, 1)
+
+				require.NoError(t, s.Store.DeleteMealTemplate(ctx, breakfast.ID, user.ID))
+				_, err = s.Store.FindMealTemplate(ctx, breakfast.ID, user.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+				require.Len(t, entriesOn(t, time.Date(2026, time.May, 4, 0, 0, 0, 0, time.UTC)), 3)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
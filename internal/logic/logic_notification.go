@@ -0,0 +1,187 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// budgetAlertThresholds are the shares of a month's budget, in percent, whose
+// crossing raises a notification. Highest first: an expense that jumps past
+// both raises only the higher one.
+var budgetAlertThresholds = []int64{100, 80} //nolint:gochecknoglobals // static lookup table
+
+func (s *Store) FindUnreadNotifications(ctx context.Context, userID int) ([]repo.Notification, error) {
+	return s.queries.SelectUnreadNotificationsByUser(ctx, userID)
+}
+
+// DismissNotification marks one notification read. It returns sql.ErrNoRows
+// when the user has no such unread notification.
+func (s *Store) DismissNotification(ctx context.Context, id, userID int) error {
+	return s.queries.MarkNotificationRead(ctx, id, userID)
+}
+
+func (s *Store) DismissAllNotifications(ctx context.Context, userID int) error {
+	return s.queries.MarkAllNotificationsRead(ctx, userID)
+}
+
+// raiseBudgetAlertsTx checks the budgets a new expense counts toward — those
+// of categoryIDs, their parents and the whole-month budget — for the month the
+// expense is dated in, and raises a notification for each that has reached a
+// threshold. Each budget, month and threshold raises at most once.
+func (s *Store) raiseBudgetAlertsTx(
+	ctx context.Context,
+	tq *repo.TxQueries,
+	userID int,
+	date int64,
+	categoryIDs []int,
+) error {
+	versions, err := tq.SelectExpenseBudgetVersionsByUser(ctx, userID)
+	if err != nil || len(versions) == 0 {
+		return err
+	}
+
+	names := map[int]string{0: "Total spending"}
+	isParent := make(map[int]bool)
+	for _, categoryID := range categoryIDs {
+		if err := budgetAlertCategoryTx(ctx, tq, userID, categoryID, names, isParent); err != nil {
+			return err
+		}
+	}
+
+	touched := make([]repo.ExpenseBudget, 0, len(versions))
+	for _, v := range versions {
+		if _, ok := names[budgetKey(v.CategoryID)]; ok {
+			touched = append(touched, v)
+		}
+	}
+	if len(touched) == 0 {
+		return nil
+	}
+
+	month := time.Unix(date, 0).UTC().Format(BudgetMonthLayout)
+	months := []string{month}
+
+	filters, err := budgetTotalsFilters(userID, touched, months)
+	if err != nil {
+		return err
+	}
+
+	homeCurrency, err := tq.SelectUserHomeCurrency(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	leafTotals, err := tq.SelectExpensesCategoryMonthTotals(ctx, filters, homeCurrency, false)
+	if err != nil {
+		return err
+	}
+
+	rolledUpTotals, err := tq.SelectExpensesCategoryMonthTotals(ctx, filters, homeCurrency, true)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range measureExpenseBudgets(touched, isParent, leafTotals, rolledUpTotals, months) {
+		threshold, ok := budgetThresholdReached(m)
+		if !ok {
+			continue
+		}
+
+		key := budgetKey(m.CategoryID)
+		_, err := tq.InsertNotification(ctx, repo.InsertNotificationParams{
+			UserID:    userID,
+			Kind:      repo.NotificationKindBudget,
+			Message:   budgetAlertMessage(names[key], m),
+			Link:      "/expenses/budgets",
+			DedupeKey: fmt.Sprintf("budget:%d:%s:%d", key, m.Month, threshold),
+		})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expenseAlertCategoryIDs returns the categories an expense's amount is counted
+// under: its splits' when it has them, its own otherwise.
+func expenseAlertCategoryIDs(params ExpenseParams) []int {
+	if len(params.Splits) == 0 {
+		return []int{params.CategoryID}
+	}
+
+	ids := make([]int, 0, len(params.Splits))
+	for _, split := range params.Splits {
+		ids = append(ids, split.CategoryID)
+	}
+
+	return ids
+}
+
+// budgetAlertCategoryTx records the names of the budgets spending in
+// categoryID counts toward: its own and its parent's. A category with children
+// is measured with them, as its parent's would be.
+func budgetAlertCategoryTx(
+	ctx context.Context,
+	tq *repo.TxQueries,
+	userID, categoryID int,
+	names map[int]string,
+	isParent map[int]bool,
+) error {
+	category, err := tq.SelectCategory(ctx, categoryID, userID)
+	if err != nil {
+		return err
+	}
+	names[category.ID] = category.Name
+
+	children, err := tq.CountCategoryChildren(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		isParent[category.ID] = true
+	}
+
+	if category.ParentID == nil {
+		return nil
+	}
+
+	parent, err := tq.SelectCategory(ctx, *category.ParentID, userID)
+	if err != nil {
+		return err
+	}
+	names[parent.ID] = parent.Name
+	isParent[parent.ID] = true
+
+	return nil
+}
+
+// budgetThresholdReached returns the highest threshold the month's spending
+// has reached. A month whose carry left nothing available is past every
+// threshold as soon as anything is spent.
+func budgetThresholdReached(m ExpenseBudgetMonth) (int64, bool) {
+	spent := int64(m.Spent) //nolint:gosec // cent amount, far below int64 max
+	for _, threshold := range budgetAlertThresholds {
+		if spent > 0 && spent*100 >= threshold*m.Available() {
+			return threshold, true
+		}
+	}
+
+	return 0, false
+}
+
+func budgetAlertMessage(name string, m ExpenseBudgetMonth) string {
+	available := m.Available()
+	if available <= 0 {
+		return fmt.Sprintf("%s is over the %s budget.", name, m.Month)
+	}
+
+	pct := int64(m.Spent) * 100 / available //nolint:gosec // cent amount, far below int64 max
+
+	return fmt.Sprintf("%s has reached %d%% of the %s budget.", name, pct, m.Month)
+}
@@ -0,0 +1,133 @@
+package logic_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetNotifications(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	user := s.CreateAuthUser(t, "budget_alert_user", "budget_alert_user@example.com", "budget_alert_password_1")
+	other := s.CreateAuthUser(t, "budget_alert_other", "budget_alert_other@example.com", "budget_alert_password_2")
+	category := s.CreateCategory(t, user.ID, "budget alert category")
+	unbudgeted := s.CreateCategory(t, other.ID, "budget alert unbudgeted")
+
+	s.SaveExpenseBudgets(t, user.ID, map[int]uint64{category.ID: 10000})
+
+	unread := func(t *testing.T, userID int) []string {
+		t.Helper()
+
+		notifications, err := s.Store.FindUnreadNotifications(ctx, userID)
+		require.NoError(t, err)
+
+		messages := make([]string, 0, len(notifications))
+		for _, n := range notifications {
+			messages = append(messages, n.Message)
+		}
+
+		return messages
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_stay_quiet_below_the_first_threshold",
+			fn: func(t *testing.T) {
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "alert one", 5000, budgetMonthDate(0), nil))
+
+				require.Empty(t, unread(t, user.ID))
+			},
+		},
+		{
+			name: "should_notify_once_at_eighty_percent",
+			fn: func(t *testing.T) {
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "alert two", 3000, budgetMonthDate(0), nil))
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "alert three", 500, budgetMonthDate(0), nil))
+
+				messages := unread(t, user.ID)
+				require.Len(t, messages, 1)
+				require.Contains(t, messages[0], "budget alert category has reached 80%")
+			},
+		},
+		{
+			name: "should_notify_at_one_hundred_percent_from_a_quick_expense",
+			fn: func(t *testing.T) {
+				parsed := logic.QuickExpenseParsed{Description: "alert quick", Amount: 2000, Date: budgetMonthDate(0)}
+				_, err := s.Store.CreateQuickExpense(ctx, user.ID, category.ID, parsed, logic.DuplicateActionForce)
+				require.NoError(t, err)
+
+				messages := unread(t, user.ID)
+				require.Len(t, messages, 2)
+				require.Contains(t, messages[0], "budget alert category has reached 105%")
+			},
+		},
+		{
+			name: "should_leave_users_without_budgets_alone",
+			fn: func(t *testing.T) {
+				s.CreateExpense(t, other.ID, newExpenseParams(unbudgeted.ID, "alert other", 900000, budgetMonthDate(0), nil))
+
+				require.Empty(t, unread(t, other.ID))
+			},
+		},
+		{
+			name: "should_dismiss_one_then_all",
+			fn: func(t *testing.T) {
+				notifications, err := s.Store.FindUnreadNotifications(ctx, user.ID)
+				require.NoError(t, err)
+				require.Len(t, notifications, 2)
+
+				err = s.Store.DismissNotification(ctx, notifications[0].ID, other.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+
+				require.NoError(t, s.Store.DismissNotification(ctx, notifications[0].ID, user.ID))
+				require.Len(t, unread(t, user.ID), 1)
+
+				err = s.Store.DismissNotification(ctx, notifications[0].ID, user.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+
+				require.NoError(t, s.Store.DismissAllNotifications(ctx, user.ID))
+				require.Empty(t, unread(t, user.ID))
+			},
+		},
+		{
+			name: "should_not_raise_a_dismissed_threshold_again",
+			fn: func(t *testing.T) {
+				s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "alert four", 1000, budgetMonthDate(0), nil))
+
+				require.Empty(t, unread(t, user.ID))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestRecurrentCopyBudgetNotifications(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	user := s.CreateAuthUser(t, "budget_alert_copy", "budget_alert_copy@example.com", "budget_alert_password_3")
+	category := s.CreateCategory(t, user.ID, "budget alert rent")
+	s.SaveExpenseBudgets(t, user.ID, map[int]uint64{category.ID: 10000})
+	s.CreateRecurrentExpense(t, user.ID, newRecurrentExpenseParams(category.ID, "alert rent", 9000, 1))
+
+	copied, err := s.Store.CopyDueRecurrentExpenses(ctx, time.Now().AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.Positive(t, copied)
+
+	notifications, err := s.Store.FindUnreadNotifications(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, notifications)
+	require.Contains(t, notifications[0].Message, "budget alert rent has reached")
+}
@@ -37,7 +37,7 @@ func TestPaginationIsStableAcrossPages(t *testing.T) {
 		Email:        "pagination_user@example.com",
 		PasswordHash: []byte("pagination_hash"),
 	})
-	category := s.CreateCategory(t, "pagination_category")
+	category := s.CreateCategory(t, user.ID, "pagination_category")
 
 	for i := range expenseCount {
 		description := fmt.Sprintf("paged_%04d", i)
@@ -0,0 +1,231 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+// PaymentAccountParams holds what the account form edits. OpeningBalance is in
+// cents and may be negative. An empty Currency means the home currency on
+// create and the current one on update.
+type PaymentAccountParams struct {
+	Name           string `validate:"required,min=2,max=50"`
+	Kind           string `validate:"required,oneof=checking credit_card cash"`
+	Currency       string `validate:"omitempty,iso4217"`
+	OpeningBalance int64  `validate:"-"`
+}
+
+// PaymentAccountKinds returns the kinds an account can have, in the order the
+// form offers them.
+func PaymentAccountKinds() []string {
+	return []string{"checking", "credit_card", "cash"}
+}
+
+// PaymentAccountLedger is an account's expenses oldest first, each with the
+// balance left after it. Balances are in the account's currency and start from
+// its opening balance.
+type PaymentAccountLedger struct {
+	Account repo.PaymentAccount
+	Entries []PaymentAccountLedgerEntry
+	Balance int64
+	// ReconciledBalance is the balance at the end of the reconciled day, the
+	// figure to compare with the statement. It is the opening balance until
+	// the first reconcile.
+	ReconciledBalance int64
+}
+
+type PaymentAccountLedgerEntry struct {
+	repo.PaymentAccountLedgerExpense
+	Balance    int64
+	Reconciled bool
+}
+
+func (s *Store) FindPaymentAccounts(ctx context.Context, userID int) ([]repo.PaymentAccount, error) {
+	accounts, err := s.queries.SelectPaymentAccountsByUser(ctx, userID)
+	if err != nil {
+		return accounts, err
+	}
+
+	return accounts, nil
+}
+
+func (s *Store) FindPaymentAccount(ctx context.Context, id, userID int) (repo.PaymentAccount, error) {
+	account, err := s.queries.SelectPaymentAccount(ctx, id, userID)
+	if err != nil {
+		return account, err
+	}
+
+	return account, nil
+}
+
+func (s *Store) CreatePaymentAccount(
+	ctx context.Context,
+	userID int,
+	params PaymentAccountParams,
+) (repo.PaymentAccount, error) {
+	var account repo.PaymentAccount
+
+	if err := s.validatePaymentAccountParams(&params); err != nil {
+		return account, err
+	}
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		currency, txErr := currencyOrHomeTx(ctx, tq, userID, params.Currency)
+		if txErr != nil {
+			return txErr
+		}
+
+		account, txErr = tq.InsertPaymentAccount(ctx, repo.InsertPaymentAccountParams{
+			UserID:         userID,
+			Name:           params.Name,
+			Kind:           params.Kind,
+			Currency:       currency,
+			OpeningBalance: params.OpeningBalance,
+		})
+
+		return txErr
+	})
+	if err != nil {
+		if repo.IsUniqueViolation(err) {
+			return account, ErrPaymentAccountNameTaken
+		}
+
+		return account, err
+	}
+
+	return account, nil
+}
+
+func (s *Store) UpdatePaymentAccount(
+	ctx context.Context,
+	id, userID int,
+	params PaymentAccountParams,
+) (repo.PaymentAccount, error) {
+	var account repo.PaymentAccount
+
+	if err := s.validatePaymentAccountParams(&params); err != nil {
+		return account, err
+	}
+
+	account, err := s.queries.UpdatePaymentAccount(ctx, repo.UpdatePaymentAccountParams{
+		ID:             id,
+		UserID:         userID,
+		Name:           params.Name,
+		Kind:           params.Kind,
+		Currency:       params.Currency,
+		OpeningBalance: params.OpeningBalance,
+	})
+	if err != nil {
+		if repo.IsUniqueViolation(err) {
+			return account, ErrPaymentAccountNameTaken
+		}
+
+		return account, err
+	}
+
+	return account, nil
+}
+
+// ReconcilePaymentAccount records that the ledger matches a statement through
+// the day starting at through. Moving it back is allowed, to undo a reconcile
+// made too far ahead.
+func (s *Store) ReconcilePaymentAccount(
+	ctx context.Context,
+	id, userID int,
+	through int64,
+) (repo.PaymentAccount, error) {
+	if through <= 0 {
+		return repo.PaymentAccount{}, ErrReconcileDate
+	}
+
+	return s.queries.UpdatePaymentAccountReconciledThrough(ctx, id, userID, through)
+}
+
+// DeletePaymentAccount removes the account. Its expenses and recurrent
+// expenses stay, with no account.
+func (s *Store) DeletePaymentAccount(ctx context.Context, id, userID int) error {
+	_, err := s.queries.DeletePaymentAccount(ctx, id, userID)
+
+	return err
+}
+
+func (s *Store) DeleteAllPaymentAccounts(ctx context.Context, userID int) error {
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		return tq.DeleteAllPaymentAccountsByUser(ctx, userID)
+	})
+}
+
+// FindPaymentAccountLedger builds the running balance of an account. Trashed
+// expenses are left out; restoring one puts it back in its place.
+func (s *Store) FindPaymentAccountLedger(ctx context.Context, id, userID int) (PaymentAccountLedger, error) {
+	var ledger PaymentAccountLedger
+
+	account, err := s.queries.SelectPaymentAccount(ctx, id, userID)
+	if err != nil {
+		return ledger, err
+	}
+
+	expenses, err := s.queries.SelectPaymentAccountLedger(ctx, account)
+	if err != nil {
+		return ledger, err
+	}
+
+	ledger.Account = account
+	ledger.Balance = account.OpeningBalance
+	ledger.ReconciledBalance = account.OpeningBalance
+	ledger.Entries = make([]PaymentAccountLedgerEntry, 0, len(expenses))
+
+	for _, e := range expenses {
+		ledger.Balance -= int64(e.AccountAmount) //nolint:gosec // cent amount, far below int64 max
+
+		entry := PaymentAccountLedgerEntry{
+			PaymentAccountLedgerExpense: e,
+			Balance:                     ledger.Balance,
+			Reconciled:                  isReconciled(account, e.Date),
+		}
+		if entry.Reconciled {
+			ledger.ReconciledBalance = ledger.Balance
+		}
+
+		ledger.Entries = append(ledger.Entries, entry)
+	}
+
+	return ledger, nil
+}
+
+// isReconciled reports whether an expense dated date falls on or before the
+// account's reconciled day.
+func isReconciled(account repo.PaymentAccount, date int64) bool {
+	return account.ReconciledThrough != nil && date < *account.ReconciledThrough+secondsPerDay
+}
+
+// checkPaymentAccountTx rejects an account id the user does not own, for the
+// reason checkCategoryTx does. A nil id means no account and always passes.
+func checkPaymentAccountTx(ctx context.Context, tq *repo.TxQueries, userID int, accountID *int) error {
+	if accountID == nil {
+		return nil
+	}
+
+	if _, err := tq.SelectPaymentAccount(ctx, *accountID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUnknownPaymentAccount
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) validatePaymentAccountParams(params *PaymentAccountParams) error {
+	params.Name = strings.TrimSpace(params.Name)
+	params.Currency = NormalizeCurrency(params.Currency)
+
+	return s.ValidateStruct(*params)
+}
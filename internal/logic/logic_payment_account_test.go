@@ -0,0 +1,243 @@
+package logic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePaymentAccount(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "payment_account_user_1",
+		Email:        "payment_account_user_1@example.com",
+		PasswordHash: []byte("payment_account_hash_1"),
+	})
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_account_in_the_home_currency",
+			fn: func(t *testing.T) {
+				account, err := s.Store.CreatePaymentAccount(ctx, user.ID, logic.PaymentAccountParams{
+					Name:           "  Everyday checking ",
+					Kind:           "checking",
+					OpeningBalance: 150000,
+				})
+				require.NoError(t, err)
+				require.Positive(t, account.ID)
+				require.Equal(t, "Everyday checking", account.Name)
+				require.Equal(t, "USD", account.Currency)
+				require.Equal(t, int64(150000), account.OpeningBalance)
+				require.Nil(t, account.ReconciledThrough)
+			},
+		},
+		{
+			name: "should_allow_a_negative_opening_balance",
+			fn: func(t *testing.T) {
+				account, err := s.Store.CreatePaymentAccount(ctx, user.ID, logic.PaymentAccountParams{
+					Name:           "Visa",
+					Kind:           "credit_card",
+					Currency:       "eur",
+					OpeningBalance: -32050,
+				})
+				require.NoError(t, err)
+				require.Equal(t, "EUR", account.Currency)
+				require.Equal(t, int64(-32050), account.OpeningBalance)
+			},
+		},
+		{
+			name: "should_reject_a_name_already_taken",
+			fn: func(t *testing.T) {
+				_, err := s.Store.CreatePaymentAccount(ctx, user.ID, logic.PaymentAccountParams{
+					Name: "visa",
+					Kind: "credit_card",
+				})
+				require.ErrorIs(t, err, logic.ErrPaymentAccountNameTaken)
+			},
+		},
+		{
+			name: "should_fail_validation_for_an_unknown_kind",
+			fn: func(t *testing.T) {
+				_, err := s.Store.CreatePaymentAccount(ctx, user.ID, logic.PaymentAccountParams{
+					Name: "Savings",
+					Kind: "savings",
+				})
+				require.ErrorIs(t, err, logic.ErrValidationFailed)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestFindPaymentAccountLedger(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "payment_account_user_2",
+		Email:        "payment_account_user_2@example.com",
+		PasswordHash: []byte("payment_account_hash_2"),
+	})
+	category := s.CreateCategory(t, user.ID, "Market runs")
+	account, err := s.Store.CreatePaymentAccount(ctx, user.ID, logic.PaymentAccountParams{
+		Name:           "Checking",
+		Kind:           "checking",
+		OpeningBalance: 10000,
+	})
+	require.NoError(t, err)
+
+	march := func(day int) int64 {
+		return time.Date(2026, time.March, day, 0, 0, 0, 0, time.UTC).Unix()
+	}
+	paidFrom := func(description string, amount uint64, date int64) logic.ExpenseParams {
+		params := newExpenseParams(category.ID, description, amount, date, nil)
+		params.PaymentAccountID = &account.ID
+
+		return params
+	}
+
+	s.CreateExpense(t, user.ID, paidFrom("Market", 2500, march(10)))
+	s.CreateExpense(t, user.ID, paidFrom("Bakery", 500, march(2)))
+	s.CreateExpense(t, user.ID, paidFrom("Butcher", 4000, march(10)))
+	s.CreateExpense(t, user.ID, newExpenseParams(category.ID, "Cash lunch", 900, march(3), nil))
+	trashed := s.CreateExpense(t, user.ID, paidFrom("Returned", 700, march(4)))
+	_, err = s.Store.DeleteExpense(ctx, trashed.ID, user.ID)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_run_the_balance_oldest_first",
+			fn: func(t *testing.T) {
+				ledger, err := s.Store.FindPaymentAccountLedger(ctx, account.ID, user.ID)
+				require.NoError(t, err)
+				require.Len(t, ledger.Entries, 3)
+				require.Equal(t, "Bakery", ledger.Entries[0].Description)
+				require.Equal(t, int64(9500), ledger.Entries[0].Balance)
+				require.Equal(t, "Market", ledger.Entries[1].Description)
+				require.Equal(t, int64(7000), ledger.Entries[1].Balance)
+				require.Equal(t, "Butcher", ledger.Entries[2].Description)
+				require.Equal(t, int64(3000), ledger.Balance)
+				require.Equal(t, int64(10000), ledger.ReconciledBalance)
+			},
+		},
+		{
+			name: "should_mark_entries_through_the_reconciled_day",
+			fn: func(t *testing.T) {
+				_, err := s.Store.ReconcilePaymentAccount(ctx, account.ID, user.ID, march(2))
+				require.NoError(t, err)
+
+				ledger, err := s.Store.FindPaymentAccountLedger(ctx, account.ID, user.ID)
+				require.NoError(t, err)
+				require.True(t, ledger.Entries[0].Reconciled)
+				require.False(t, ledger.Entries[1].Reconciled)
+				require.Equal(t, int64(9500), ledger.ReconciledBalance)
+
+				_, err = s.Store.ReconcilePaymentAccount(ctx, account.ID, user.ID, march(10))
+				require.NoError(t, err)
+
+				ledger, err = s.Store.FindPaymentAccountLedger(ctx, account.ID, user.ID)
+				require.NoError(t, err)
+				require.True(t, ledger.Entries[2].Reconciled)
+				require.Equal(t, ledger.Balance, ledger.ReconciledBalance)
+			},
+		},
+		{
+			name: "should_require_a_reconcile_date",
+			fn: func(t *testing.T) {
+				_, err := s.Store.ReconcilePaymentAccount(ctx, account.ID, user.ID, 0)
+				require.ErrorIs(t, err, logic.ErrReconcileDate)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestPaymentAccountOnExpenses(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "payment_account_user_3",
+		Email:        "payment_account_user_3@example.com",
+		PasswordHash: []byte("payment_account_hash_3"),
+	})
+	other := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "payment_account_user_4",
+		Email:        "payment_account_user_4@example.com",
+		PasswordHash: []byte("payment_account_hash_4"),
+	})
+	category := s.CreateCategory(t, user.ID, "Home bills")
+	date := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_reject_another_users_account",
+			fn: func(t *testing.T) {
+				foreign, err := s.Store.CreatePaymentAccount(ctx, other.ID, logic.PaymentAccountParams{
+					Name: "Other checking",
+					Kind: "checking",
+				})
+				require.NoError(t, err)
+
+				params := newExpenseParams(category.ID, "Power bill", 6000, date, nil)
+				params.PaymentAccountID = &foreign.ID
+				_, err = s.Store.CreateExpense(ctx, user.ID, params)
+				require.ErrorIs(t, err, logic.ErrUnknownPaymentAccount)
+
+				_, err = s.Store.CreateRecurrentExpense(ctx, user.ID, logic.RecurrentExpenseParams{
+					ExpenseBaseParams: logic.ExpenseBaseParams{
+						CategoryID:       category.ID,
+						Description:      "Water bill",
+						Amount:           3000,
+						PaymentAccountID: &foreign.ID,
+					},
+					Period: 1,
+				})
+				require.ErrorIs(t, err, logic.ErrUnknownPaymentAccount)
+			},
+		},
+		{
+			name: "should_unlink_expenses_when_the_account_is_deleted",
+			fn: func(t *testing.T) {
+				account, err := s.Store.CreatePaymentAccount(ctx, user.ID, logic.PaymentAccountParams{
+					Name: "Wallet",
+					Kind: "cash",
+				})
+				require.NoError(t, err)
+
+				params := newExpenseParams(category.ID, "Gas bill", 4500, date, nil)
+				params.PaymentAccountID = &account.ID
+				expense := s.CreateExpense(t, user.ID, params)
+				require.Equal(t, &account.ID, expense.PaymentAccountID)
+
+				require.NoError(t, s.Store.DeletePaymentAccount(ctx, account.ID, user.ID))
+
+				expense, err = s.Store.FindExpense(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+				require.Nil(t, expense.PaymentAccountID)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
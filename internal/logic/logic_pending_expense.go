@@ -0,0 +1,108 @@
+package logic
+
+import (
+	"context"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// PendingExpense is an inbox item with the estimate recurrent expense it came
+// from, whose amount is the estimate to confirm or correct.
+type PendingExpense struct {
+	repo.PendingExpense
+	RecurrentExpense repo.RecurrentExpense
+}
+
+type ConfirmPendingExpenseParams struct {
+	// Amount is what the bill actually came to.
+	Amount uint64 `validate:"required,gt=0"`
+}
+
+// FindPendingExpenses returns the user's inbox, oldest due date first.
+func (s *Store) FindPendingExpenses(ctx context.Context, userID int) ([]PendingExpense, error) {
+	pending, err := s.queries.SelectPendingExpensesByUser(ctx, userID)
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+
+	recurrentExpenses, err := s.queries.SelectRecurrentExpenses(ctx, repo.QueryOptions{
+		Filters: repo.Filters{
+			FilterFields: []repo.FilterField{
+				{Name: "user_id", Value: userID, Operator: "="},
+			},
+		},
+		Sorting: repo.Sorting{Field: "id", Order: "ASC"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recurrentExpenseByID := make(map[int]repo.RecurrentExpense, len(recurrentExpenses))
+	for _, re := range recurrentExpenses {
+		recurrentExpenseByID[re.ID] = re
+	}
+
+	items := make([]PendingExpense, 0, len(pending))
+	for _, p := range pending {
+		items = append(items, PendingExpense{
+			PendingExpense:   p,
+			RecurrentExpense: recurrentExpenseByID[p.RecurrentExpenseID],
+		})
+	}
+
+	return items, nil
+}
+
+// ConfirmPendingExpense posts a pending item as an expense for the actual
+// amount, dated on its due date and carrying the recurrent expense's tags.
+func (s *Store) ConfirmPendingExpense(
+	ctx context.Context,
+	id, userID int,
+	params ConfirmPendingExpenseParams,
+) (repo.Expense, error) {
+	var expense repo.Expense
+
+	if err := s.ValidateStruct(params); err != nil {
+		return expense, err
+	}
+
+	pending, err := s.queries.SelectPendingExpense(ctx, id, userID)
+	if err != nil {
+		return expense, err
+	}
+
+	recurrentExpense, err := s.queries.SelectRecurrentExpense(ctx, pending.RecurrentExpenseID, userID)
+	if err != nil {
+		return expense, err
+	}
+
+	err = s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		if _, txErr := tq.DeletePendingExpense(ctx, id, userID); txErr != nil {
+			return txErr
+		}
+
+		var txErr error
+
+		expense, txErr = insertRecurrentExpenseCopyTx(ctx, tq, recurrentExpense, params.Amount, pending.Date)
+		if txErr != nil {
+			return txErr
+		}
+
+		return s.raiseBudgetAlertsTx(ctx, tq, userID, expense.Date, []int{expense.CategoryID})
+	})
+	if err != nil {
+		return repo.Expense{}, err
+	}
+
+	return expense, nil
+}
+
+// SkipPendingExpense drops a pending item without posting it. Its occurrence
+// was counted when it was created, so it still uses up the limit.
+func (s *Store) SkipPendingExpense(ctx context.Context, id, userID int) error {
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		_, err := tq.DeletePendingExpense(ctx, id, userID)
+
+		return err
+	})
+}
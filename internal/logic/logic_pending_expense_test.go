@@ -0,0 +1,182 @@
+package logic_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingExpenses(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "pending_user_1",
+		Email:        "pending_user_1@example.com",
+		PasswordHash: []byte("pending_user_hash_1"),
+	})
+	other := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "pending_user_2",
+		Email:        "pending_user_2@example.com",
+		PasswordHash: []byte("pending_user_hash_2"),
+	})
+	category := s.CreateCategory(t, user.ID, "pending category 1")
+	march := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	newEstimate := func(t *testing.T, description string, limit uint) repo.RecurrentExpense {
+		t.Helper()
+
+		params := newRecurrentExpenseParams(category.ID, description, 8000, 1)
+		params.IsEstimate = true
+		params.OccurrenceLimit = limit
+		params.Tags = []string{"utilities"}
+
+		return s.CreateRecurrentExpense(t, user.ID, params)
+	}
+	findPending := func(t *testing.T, re repo.RecurrentExpense) []logic.PendingExpense {
+		t.Helper()
+
+		pending, err := s.Store.FindPendingExpenses(ctx, user.ID)
+		require.NoError(t, err)
+
+		var res []logic.PendingExpense
+		for _, p := range pending {
+			if p.RecurrentExpenseID == re.ID {
+				res = append(res, p)
+			}
+		}
+
+		return res
+	}
+	findGenerated := func(t *testing.T, re repo.RecurrentExpense) []repo.Expense {
+		t.Helper()
+
+		expenses, err := s.Store.FindExpenses(ctx, repo.QueryOptions{
+			Filters: repo.Filters{
+				FilterFields: []repo.FilterField{
+					{Name: "recurrent_expense_id", Value: re.ID, Operator: "="},
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		return expenses
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_post_an_estimate_as_a_pending_expense",
+			fn: func(t *testing.T) {
+				re := newEstimate(t, "pending power 1", 0)
+
+				_, err := s.Store.CopyDueRecurrentExpenses(ctx, march)
+				require.NoError(t, err)
+
+				pending := findPending(t, re)
+				require.Len(t, pending, 1)
+				require.Equal(t, march.Unix(), pending[0].Date)
+				require.Equal(t, uint64(8000), pending[0].RecurrentExpense.Amount)
+				require.Empty(t, findGenerated(t, re))
+
+				updated, err := s.Store.FindRecurrentExpense(ctx, re.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, uint(1), updated.OccurrenceCount)
+			},
+		},
+		{
+			name: "should_confirm_with_the_actual_amount_and_tags",
+			fn: func(t *testing.T) {
+				re := newEstimate(t, "pending water 1", 0)
+
+				_, err := s.Store.CopyDueRecurrentExpenses(ctx, march)
+				require.NoError(t, err)
+				pending := findPending(t, re)
+				require.Len(t, pending, 1)
+
+				expense, err := s.Store.ConfirmPendingExpense(
+					ctx, pending[0].ID, user.ID, logic.ConfirmPendingExpenseParams{Amount: 9150},
+				)
+				require.NoError(t, err)
+				require.Equal(t, uint64(9150), expense.Amount)
+				require.Equal(t, march.Unix(), expense.Date)
+				require.Equal(t, &re.ID, expense.RecurrentExpenseID)
+
+				tags, err := s.Store.FindExpenseTags(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, []string{"utilities"}, logic.ExtractTagNames(tags))
+				require.Empty(t, findPending(t, re))
+
+				_, err = s.Store.ConfirmPendingExpense(
+					ctx, pending[0].ID, user.ID, logic.ConfirmPendingExpenseParams{Amount: 9150},
+				)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+				require.Len(t, findGenerated(t, re), 1)
+			},
+		},
+		{
+			name: "should_reject_a_zero_amount",
+			fn: func(t *testing.T) {
+				re := newEstimate(t, "pending gas 1", 0)
+
+				_, err := s.Store.CopyDueRecurrentExpenses(ctx, march)
+				require.NoError(t, err)
+				pending := findPending(t, re)
+				require.Len(t, pending, 1)
+
+				_, err = s.Store.ConfirmPendingExpense(ctx, pending[0].ID, user.ID, logic.ConfirmPendingExpenseParams{})
+				require.ErrorIs(t, err, logic.ErrValidationFailed)
+				require.Len(t, findPending(t, re), 1)
+			},
+		},
+		{
+			name: "should_count_a_skipped_item_toward_the_limit",
+			fn: func(t *testing.T) {
+				re := newEstimate(t, "pending internet 1", 1)
+
+				_, err := s.Store.CopyDueRecurrentExpenses(ctx, march)
+				require.NoError(t, err)
+				pending := findPending(t, re)
+				require.Len(t, pending, 1)
+
+				require.NoError(t, s.Store.SkipPendingExpense(ctx, pending[0].ID, user.ID))
+				require.Empty(t, findPending(t, re))
+				require.Empty(t, findGenerated(t, re))
+
+				updated, err := s.Store.FindRecurrentExpense(ctx, re.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, uint(1), updated.OccurrenceCount)
+				require.NotNil(t, updated.ArchivedAt)
+			},
+		},
+		{
+			name: "should_not_touch_another_users_item",
+			fn: func(t *testing.T) {
+				re := newEstimate(t, "pending heating 1", 0)
+
+				_, err := s.Store.CopyDueRecurrentExpenses(ctx, march)
+				require.NoError(t, err)
+				pending := findPending(t, re)
+				require.Len(t, pending, 1)
+
+				err = s.Store.SkipPendingExpense(ctx, pending[0].ID, other.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+				_, err = s.Store.ConfirmPendingExpense(
+					ctx, pending[0].ID, other.ID, logic.ConfirmPendingExpenseParams{Amount: 100},
+				)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+				require.Len(t, findPending(t, re), 1)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
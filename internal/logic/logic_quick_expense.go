@@ -188,10 +188,13 @@ func (s *Store) ResolveQuickExpenseCategory(
 
 // CreateQuickExpense creates an expense from parsed quick-add fields and, in the
 // same transaction, remembers the description-to-category mapping for reuse.
+// A repeat of an existing expense is handled per action, as in
+// CreateExpenseChecked; the mapping is remembered either way.
 func (s *Store) CreateQuickExpense(
 	ctx context.Context,
 	userID, categoryID int,
 	parsed QuickExpenseParsed,
+	action string,
 ) (repo.Expense, error) {
 	var expense repo.Expense
 
@@ -204,20 +207,17 @@ func (s *Store) CreateQuickExpense(
 		Date: parsed.Date,
 		Tags: parsed.Tags,
 	}
-	if err := s.ValidateStruct(params); err != nil {
+	if err := s.validateExpenseParams(&params); err != nil {
 		return expense, err
 	}
+	if !validDuplicateAction(action) {
+		return expense, ErrInvalidDuplicateAction
+	}
 
 	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
 		var txErr error
 
-		expense, txErr = tq.InsertExpense(ctx, repo.InsertExpenseParams{
-			UserID:      userID,
-			CategoryID:  params.CategoryID,
-			Description: params.Description,
-			Amount:      params.Amount,
-			Date:        params.Date,
-		})
+		expense, _, txErr = s.insertExpenseTx(ctx, tq, userID, params, action)
 		if txErr != nil {
 			return txErr
 		}
@@ -227,11 +227,8 @@ func (s *Store) CreateQuickExpense(
 			CategoryID:     categoryID,
 			DescriptionKey: descriptionKey(parsed.Description),
 		})
-		if txErr != nil {
-			return txErr
-		}
 
-		return s.replaceTagsTx(ctx, tq, repo.TaggableTypeExpense, expense.ID, userID, params.Tags)
+		return txErr
 	})
 	if err != nil {
 		return expense, err
@@ -225,8 +225,8 @@ func TestQuickExpenseCategoryMapping(t *testing.T) {
 		Email:        "quick_user_1@example.com",
 		PasswordHash: []byte("quick_user_hash_1"),
 	})
-	category := s.CreateCategory(t, "quick category 1")
-	other := s.CreateCategory(t, "quick category 2")
+	category := s.CreateCategory(t, user.ID, "quick category 1")
+	other := s.CreateCategory(t, user.ID, "quick category 2")
 
 	cases := []struct {
 		name string
@@ -248,7 +248,7 @@ func TestQuickExpenseCategoryMapping(t *testing.T) {
 					Amount:      1599,
 					Date:        1735689600,
 				}
-				expense, err := s.Store.CreateQuickExpense(ctx, user.ID, category.ID, parsed)
+				expense, err := s.Store.CreateQuickExpense(ctx, user.ID, category.ID, parsed, logic.DuplicateActionAsk)
 				require.NoError(t, err)
 				require.Positive(t, expense.ID)
 				require.Equal(t, category.ID, expense.CategoryID)
@@ -267,10 +267,10 @@ func TestQuickExpenseCategoryMapping(t *testing.T) {
 					Amount:      999,
 					Date:        1735689600,
 				}
-				_, err := s.Store.CreateQuickExpense(ctx, user.ID, category.ID, parsed)
+				_, err := s.Store.CreateQuickExpense(ctx, user.ID, category.ID, parsed, logic.DuplicateActionAsk)
 				require.NoError(t, err)
 
-				_, err = s.Store.CreateQuickExpense(ctx, user.ID, other.ID, parsed)
+				_, err = s.Store.CreateQuickExpense(ctx, user.ID, other.ID, parsed, logic.DuplicateActionForce)
 				require.NoError(t, err)
 
 				id, found, err := s.Store.ResolveQuickExpenseCategory(ctx, user.ID, "Spotify")
@@ -288,7 +288,7 @@ func TestQuickExpenseCategoryMapping(t *testing.T) {
 					Date:        1735689600,
 					Tags:        []string{"streaming", "monthly"},
 				}
-				expense, err := s.Store.CreateQuickExpense(ctx, user.ID, category.ID, parsed)
+				expense, err := s.Store.CreateQuickExpense(ctx, user.ID, category.ID, parsed, logic.DuplicateActionAsk)
 				require.NoError(t, err)
 
 				tags, err := s.Store.FindExpenseTags(ctx, expense.ID, user.ID)
@@ -300,7 +300,7 @@ func TestQuickExpenseCategoryMapping(t *testing.T) {
 			name: "should_fail_validation_for_short_description",
 			fn: func(t *testing.T) {
 				parsed := logic.QuickExpenseParsed{Description: "no", Amount: 100, Date: 1735689600}
-				_, err := s.Store.CreateQuickExpense(ctx, user.ID, category.ID, parsed)
+				_, err := s.Store.CreateQuickExpense(ctx, user.ID, category.ID, parsed, logic.DuplicateActionAsk)
 				require.ErrorIs(t, err, logic.ErrValidationFailed)
 			},
 		},
@@ -0,0 +1,249 @@
+package logic
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+type RecipeParams struct {
+	Name     string  `validate:"required,min=1,max=100"`
+	Servings float64 `validate:"gt=0"`
+}
+
+type RecipeIngredientParams struct {
+	FoodID    int     `validate:"required,gt=0"`
+	QuantityG float64 `validate:"gt=0"`
+}
+
+// RecipeLogParams logs Servings portions of a recipe as one macro entry.
+type RecipeLogParams struct {
+	Servings float64 `validate:"gt=0"`
+	Date     int64   `validate:"required,gt=0"`
+	MealType string  `validate:"required,oneof=breakfast lunch dinner snack other"`
+}
+
+// RecipeIngredientLine is an ingredient with what its quantity contributes.
+// NeedsDensity marks a food measured by volume whose grams cannot be
+// converted; it adds nothing until the food has a density.
+type RecipeIngredientLine struct {
+	repo.RecipeIngredientFood
+	Macros       Nutrients
+	NeedsDensity bool
+}
+
+// RecipeDetail is a recipe measured from its foods as they stand now. Every
+// figure is rounded to two decimals for display.
+type RecipeDetail struct {
+	Recipe      repo.Recipe
+	Ingredients []RecipeIngredientLine
+	Total       Nutrients
+	PerServing  Nutrients
+}
+
+// FindRecipes returns every recipe measured from its foods, by name.
+func (s *Store) FindRecipes(ctx context.Context, userID int) ([]RecipeDetail, error) {
+	recipes, err := s.queries.SelectRecipesByUser(ctx, userID)
+	if err != nil || len(recipes) == 0 {
+		return nil, err
+	}
+
+	ingredients, err := s.queries.SelectRecipeIngredientsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byRecipeID := make(map[int][]repo.RecipeIngredientFood, len(recipes))
+	for _, i := range ingredients {
+		byRecipeID[i.RecipeID] = append(byRecipeID[i.RecipeID], i)
+	}
+
+	details := make([]RecipeDetail, 0, len(recipes))
+	for _, r := range recipes {
+		details = append(details, measureRecipe(r, byRecipeID[r.ID]))
+	}
+
+	return details, nil
+}
+
+func (s *Store) FindRecipe(ctx context.Context, id, userID int) (repo.Recipe, error) {
+	return s.queries.SelectRecipe(ctx, id, userID)
+}
+
+// FindRecipeDetail measures one recipe, its ingredients by food name.
+func (s *Store) FindRecipeDetail(ctx context.Context, recipe repo.Recipe) (RecipeDetail, error) {
+	ingredients, err := s.queries.SelectRecipeIngredientsByRecipe(ctx, recipe.ID, recipe.UserID)
+	if err != nil {
+		return RecipeDetail{}, err
+	}
+
+	return measureRecipe(recipe, ingredients), nil
+}
+
+func (s *Store) CreateRecipe(ctx context.Context, userID int, params RecipeParams) (repo.Recipe, error) {
+	if err := s.validateRecipeParams(&params); err != nil {
+		return repo.Recipe{}, err
+	}
+
+	recipe, err := s.queries.InsertRecipe(ctx, repo.InsertRecipeParams{
+		UserID:   userID,
+		Name:     params.Name,
+		Servings: params.Servings,
+	})
+	if repo.IsUniqueViolation(err) {
+		return recipe, ErrRecipeNameTaken
+	}
+
+	return recipe, err
+}
+
+func (s *Store) UpdateRecipe(
+	ctx context.Context,
+	id, userID int,
+	params RecipeParams,
+) (repo.Recipe, error) {
+	if err := s.validateRecipeParams(&params); err != nil {
+		return repo.Recipe{}, err
+	}
+
+	recipe, err := s.queries.UpdateRecipe(ctx, repo.UpdateRecipeParams{
+		ID:       id,
+		UserID:   userID,
+		Name:     params.Name,
+		Servings: params.Servings,
+	})
+	if repo.IsUniqueViolation(err) {
+		return recipe, ErrRecipeNameTaken
+	}
+
+	return recipe, err
+}
+
+// DeleteRecipe removes the recipe and its ingredients. Entries already logged
+// from it are left alone.
+func (s *Store) DeleteRecipe(ctx context.Context, id, userID int) error {
+	_, err := s.queries.DeleteRecipe(ctx, id, userID)
+
+	return err
+}
+
+func (s *Store) DeleteAllRecipes(ctx context.Context, userID int) error {
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		return tq.DeleteAllRecipesByUser(ctx, userID)
+	})
+}
+
+// SaveRecipeIngredient adds a food to a recipe, or sets its quantity when the
+// recipe already has it. It returns sql.ErrNoRows when the recipe or the food
+// is not the user's, or the food is in the trash, and ErrUnitNeedsDensity when
+// the food is measured by volume without a density.
+func (s *Store) SaveRecipeIngredient(
+	ctx context.Context,
+	recipeID, userID int,
+	params RecipeIngredientParams,
+) (repo.RecipeIngredient, error) {
+	if err := s.ValidateStruct(params); err != nil {
+		return repo.RecipeIngredient{}, err
+	}
+
+	food, err := s.queries.SelectFood(ctx, params.FoodID, userID)
+	if err != nil {
+		return repo.RecipeIngredient{}, err
+	}
+	if _, err = ScaleFood(food, params.QuantityG, UnitGram); err != nil {
+		return repo.RecipeIngredient{}, err
+	}
+
+	return s.queries.UpsertRecipeIngredient(ctx, repo.UpsertRecipeIngredientParams{
+		UserID:    userID,
+		RecipeID:  recipeID,
+		FoodID:    params.FoodID,
+		QuantityG: params.QuantityG,
+	})
+}
+
+// RemoveRecipeIngredient takes one ingredient out of a recipe. It returns
+// sql.ErrNoRows when the recipe has no such ingredient.
+func (s *Store) RemoveRecipeIngredient(ctx context.Context, id, recipeID, userID int) error {
+	_, err := s.queries.DeleteRecipeIngredient(ctx, id, recipeID, userID)
+
+	return err
+}
+
+// LogRecipe records params.Servings portions of the recipe as a macro entry
+// named after it. The entry is a copy: later edits to the recipe or its foods
+// do not change what was logged.
+func (s *Store) LogRecipe(
+	ctx context.Context,
+	recipe repo.Recipe,
+	params RecipeLogParams,
+) (repo.MacroEntry, error) {
+	if err := s.ValidateStruct(params); err != nil {
+		return repo.MacroEntry{}, err
+	}
+
+	ingredients, err := s.queries.SelectRecipeIngredientsByRecipe(ctx, recipe.ID, recipe.UserID)
+	if err != nil {
+		return repo.MacroEntry{}, err
+	}
+	if len(ingredients) == 0 {
+		return repo.MacroEntry{}, ErrRecipeEmpty
+	}
+
+	var total Nutrients
+	for _, i := range ingredients {
+		m, err := ingredientNutrients(i)
+		if err != nil {
+			return repo.MacroEntry{}, err
+		}
+		total = total.add(m)
+	}
+	portion := total.scale(params.Servings / recipe.Servings).rounded()
+
+	return s.CreateMacroEntry(ctx, recipe.UserID, MacroEntryParams{
+		Name:          recipe.Name,
+		Kcal:          portion.Kcal,
+		ProteinG:      portion.ProteinG,
+		CarbsG:        portion.CarbsG,
+		FatG:          portion.FatG,
+		Date:          params.Date,
+		MealType:      params.MealType,
+		FiberG:        portion.FiberG,
+		SodiumG:       portion.SodiumG,
+		SaturatedFatG: portion.SaturatedFatG,
+	})
+}
+
+func (s *Store) validateRecipeParams(params *RecipeParams) error {
+	params.Name = strings.TrimSpace(params.Name)
+
+	return s.ValidateStruct(*params)
+}
+
+// measureRecipe sums the ingredients unrounded and rounds each figure only
+// once, so the per-serving values do not drift from the total.
+func measureRecipe(recipe repo.Recipe, ingredients []repo.RecipeIngredientFood) RecipeDetail {
+	detail := RecipeDetail{Recipe: recipe, Ingredients: make([]RecipeIngredientLine, 0, len(ingredients))}
+
+	var total Nutrients
+	for _, i := range ingredients {
+		m, err := ingredientNutrients(i)
+		detail.Ingredients = append(detail.Ingredients, RecipeIngredientLine{
+			RecipeIngredientFood: i,
+			Macros:               m.rounded(),
+			NeedsDensity:         err != nil,
+		})
+		total = total.add(m)
+	}
+
+	detail.Total = total.rounded()
+	detail.PerServing = total.scale(1 / recipe.Servings).rounded()
+
+	return detail
+}
+
+// ingredientNutrients scales the food to the grams the recipe uses.
+func ingredientNutrients(i repo.RecipeIngredientFood) (Nutrients, error) {
+	return ScaleFood(i.Food, i.QuantityG, UnitGram)
+}
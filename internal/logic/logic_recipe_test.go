@@ -0,0 +1,214 @@
+package logic_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecipes(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "recipe_user_1",
+		Email:        "recipe_user_1@example.com",
+		PasswordHash: []byte("recipe_hash_1"),
+	})
+	other := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "recipe_user_2",
+		Email:        "recipe_user_2@example.com",
+		PasswordHash: []byte("recipe_hash_2"),
+	})
+
+	oatsParams := logic.FoodParams{
+		Name:          "Oats",
+		Kcal:          380,
+		ProteinG:      13,
+		CarbsG:        67,
+		FatG:          7,
+		FiberG:        10,
+		SodiumG:       0.01,
+		SaturatedFatG: 1.2,
+	}
+	oats, err := s.Store.CreateFood(ctx, user.ID, oatsParams)
+	require.NoError(t, err)
+	milkParams := logic.FoodParams{
+		Name:          "Milk",
+		Kcal:          60,
+		ProteinG:      3.2,
+		CarbsG:        4.8,
+		FatG:          3.3,
+		SodiumG:       0.05,
+		SaturatedFatG: 1.9,
+	}
+	milk, err := s.Store.CreateFood(ctx, user.ID, milkParams)
+	require.NoError(t, err)
+	foreignFood, err := s.Store.CreateFood(ctx, other.ID, logic.FoodParams{Name: "Foreign", Kcal: 100})
+	require.NoError(t, err)
+
+	porridge, err := s.Store.CreateRecipe(ctx, user.ID, logic.RecipeParams{Name: " Porridge ", Servings: 2})
+	require.NoError(t, err)
+
+	addIngredient := func(t *testing.T, recipeID, foodID int, grams float64) {
+		t.Helper()
+
+		_, err := s.Store.SaveRecipeIngredient(ctx, recipeID, user.ID, logic.RecipeIngredientParams{
+			FoodID:    foodID,
+			QuantityG: grams,
+		})
+		require.NoError(t, err)
+	}
+	detailOf := func(t *testing.T, recipe repo.Recipe) logic.RecipeDetail {
+		t.Helper()
+
+		detail, err := s.Store.FindRecipeDetail(ctx, recipe)
+		require.NoError(t, err)
+
+		return detail
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_a_recipe_with_a_trimmed_name",
+			fn: func(t *testing.T) {
+				require.Positive(t, porridge.ID)
+				require.Equal(t, "Porridge", porridge.Name)
+				require.InDelta(t, 2.0, porridge.Servings, 0.001)
+			},
+		},
+		{
+			name: "should_reject_a_name_already_taken",
+			fn: func(t *testing.T) {
+				_, err := s.Store.CreateRecipe(ctx, user.ID, logic.RecipeParams{Name: "PORRIDGE", Servings: 1})
+				require.ErrorIs(t, err, logic.ErrRecipeNameTaken)
+			},
+		},
+		{
+			name: "should_fail_validation_without_servings",
+			fn: func(t *testing.T) {
+				_, err := s.Store.CreateRecipe(ctx, user.ID, logic.RecipeParams{Name: "Soup"})
+				require.ErrorIs(t, err, logic.ErrValidationFailed)
+			},
+		},
+		{
+			name: "should_compute_macros_per_serving_from_the_ingredients",
+			fn: func(t *testing.T) {
+				addIngredient(t, porridge.ID, oats.ID, 80)
+				addIngredient(t, porridge.ID, milk.ID, 250)
+
+				detail := detailOf(t, porridge)
+				require.Len(t, detail.Ingredients, 2)
+				require.Equal(t, "Milk", detail.Ingredients[0].Food.Name)
+				require.InDelta(t, 150.0, detail.Ingredients[0].Macros.Kcal, 0.001)
+
+				require.InDelta(t, 454.0, detail.Total.Kcal, 0.001)
+				require.InDelta(t, 227.0, detail.PerServing.Kcal, 0.001)
+				require.InDelta(t, 9.2, detail.PerServing.ProteinG, 0.001)
+				require.InDelta(t, 32.8, detail.PerServing.CarbsG, 0.001)
+				require.InDelta(t, 6.93, detail.PerServing.FatG, 0.011)
+				require.InDelta(t, 4.0, detail.PerServing.FiberG, 0.001)
+				require.InDelta(t, 0.07, detail.PerServing.SodiumG, 0.011)
+				require.InDelta(t, 2.86, detail.PerServing.SaturatedFatG, 0.011)
+			},
+		},
+		{
+			name: "should_recompute_when_an_ingredient_food_changes",
+			fn: func(t *testing.T) {
+				params := milkParams
+				params.Kcal = 40
+				_, err := s.Store.UpdateFood(ctx, milk.ID, user.ID, params)
+				require.NoError(t, err)
+
+				detail := detailOf(t, porridge)
+				require.InDelta(t, 404.0, detail.Total.Kcal, 0.001)
+				require.InDelta(t, 202.0, detail.PerServing.Kcal, 0.001)
+			},
+		},
+		{
+			name: "should_replace_the_quantity_when_a_food_is_added_again",
+			fn: func(t *testing.T) {
+				addIngredient(t, porridge.ID, oats.ID, 100)
+				addIngredient(t, porridge.ID, oats.ID, 80)
+
+				detail := detailOf(t, porridge)
+				require.Len(t, detail.Ingredients, 2)
+				require.InDelta(t, 80.0, detail.Ingredients[1].QuantityG, 0.001)
+			},
+		},
+		{
+			name: "should_not_add_another_users_food",
+			fn: func(t *testing.T) {
+				_, err := s.Store.SaveRecipeIngredient(ctx, porridge.ID, user.ID, logic.RecipeIngredientParams{
+					FoodID:    foreignFood.ID,
+					QuantityG: 50,
+				})
+				require.ErrorIs(t, err, sql.ErrNoRows)
+
+				_, err = s.Store.SaveRecipeIngredient(ctx, porridge.ID, other.ID, logic.RecipeIngredientParams{
+					FoodID:    foreignFood.ID,
+					QuantityG: 50,
+				})
+				require.ErrorIs(t, err, sql.ErrNoRows)
+			},
+		},
+		{
+			name: "should_log_a_portion_as_a_macro_entry",
+			fn: func(t *testing.T) {
+				date := time.Date(2026, time.March, 3, 0, 0, 0, 0, time.UTC).Unix()
+				entry, err := s.Store.LogRecipe(ctx, porridge, logic.RecipeLogParams{
+					Servings: 1.5,
+					Date:     date,
+					MealType: "breakfast",
+				})
+				require.NoError(t, err)
+				require.Equal(t, "Porridge", entry.Name)
+				require.Equal(t, date, entry.Date)
+				require.Equal(t, "breakfast", entry.MealType)
+				require.InDelta(t, 303.0, entry.Kcal, 0.001)
+				require.InDelta(t, 13.8, entry.ProteinG, 0.001)
+				require.InDelta(t, 6.0, entry.FiberG, 0.001)
+			},
+		},
+		{
+			name: "should_not_log_a_recipe_without_ingredients",
+			fn: func(t *testing.T) {
+				empty, err := s.Store.CreateRecipe(ctx, user.ID, logic.RecipeParams{Name: "Empty", Servings: 1})
+				require.NoError(t, err)
+
+				_, err = s.Store.LogRecipe(ctx, empty, logic.RecipeLogParams{
+					Servings: 1,
+					Date:     time.Now().Unix(),
+					MealType: "lunch",
+				})
+				require.ErrorIs(t, err, logic.ErrRecipeEmpty)
+			},
+		},
+		{
+			name: "should_remove_an_ingredient_and_then_delete_the_recipe",
+			fn: func(t *testing.T) {
+				detail := detailOf(t, porridge)
+				require.NoError(t, s.Store.RemoveRecipeIngredient(ctx, detail.Ingredients[0].ID, porridge.ID, user.ID))
+				require.Len(t, detailOf(t, porridge).Ingredients, 1)
+
+				err := s.Store.RemoveRecipeIngredient(ctx, detail.Ingredients[0].ID, porridge.ID, user.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+
+				require.NoError(t, s.Store.DeleteRecipe(ctx, porridge.ID, user.ID))
+				_, err = s.Store.FindRecipe(ctx, porridge.ID, user.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -0,0 +1,273 @@
+package logic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+const (
+	RecurrenceDaily   = "daily"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+	RecurrenceYearly  = "yearly"
+)
+
+// RecurrenceFrequencies lists the frequencies in the order the form offers them.
+func RecurrenceFrequencies() []string {
+	return []string{RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly, RecurrenceYearly}
+}
+
+// Recurrence is a recurring rule's schedule and how far it has got. Recurrent
+// expenses and recurrent incomes both convert to it, so they share the
+// occurrence math below.
+type Recurrence struct {
+	Frequency         string
+	Period            uint
+	AnchorDay         uint
+	StartsAt          *int64
+	EndsAt            *int64
+	OccurrenceLimit   uint
+	OccurrenceCount   uint
+	LastCopyCreatedAt *int64
+}
+
+func RecurrentExpenseRecurrence(re repo.RecurrentExpense) Recurrence {
+	return Recurrence{
+		Frequency:         re.Frequency,
+		Period:            re.Period,
+		AnchorDay:         re.AnchorDay,
+		StartsAt:          re.StartsAt,
+		EndsAt:            re.EndsAt,
+		OccurrenceLimit:   re.OccurrenceLimit,
+		OccurrenceCount:   re.OccurrenceCount,
+		LastCopyCreatedAt: re.LastCopyCreatedAt,
+	}
+}
+
+func RecurrentIncomeRecurrence(ri repo.RecurrentIncome) Recurrence {
+	return Recurrence{
+		Frequency:         ri.Frequency,
+		Period:            ri.Period,
+		AnchorDay:         ri.AnchorDay,
+		StartsAt:          ri.StartsAt,
+		EndsAt:            ri.EndsAt,
+		OccurrenceLimit:   ri.OccurrenceLimit,
+		OccurrenceCount:   ri.OccurrenceCount,
+		LastCopyCreatedAt: ri.LastCopyCreatedAt,
+	}
+}
+
+// withDefaults fills in what a form may leave out: a missing frequency is
+// monthly, dates snap to UTC midnight and a missing anchor day follows the
+// start date.
+func (rec Recurrence) withDefaults() Recurrence {
+	if rec.Frequency == "" {
+		rec.Frequency = RecurrenceMonthly
+	}
+
+	if rec.StartsAt != nil {
+		startsAt := utcDay(*rec.StartsAt).Unix()
+		rec.StartsAt = &startsAt
+	}
+	if rec.EndsAt != nil {
+		endsAt := utcDay(*rec.EndsAt).Unix()
+		rec.EndsAt = &endsAt
+	}
+
+	if rec.AnchorDay == 0 {
+		rec.AnchorDay = 1
+		if rec.StartsAt != nil {
+			rec.AnchorDay = uint(utcDay(*rec.StartsAt).Day())
+		}
+	}
+
+	return rec
+}
+
+func (rec Recurrence) endsBeforeStart() bool {
+	return rec.StartsAt != nil && rec.EndsAt != nil && *rec.EndsAt < *rec.StartsAt
+}
+
+// recurrenceRule is the schedule part of a Recurrence as dates. Dates are UTC
+// midnights, like expense dates.
+type recurrenceRule struct {
+	frequency string
+	interval  int
+	anchorDay int
+	startsAt  *time.Time
+	endsAt    *time.Time
+}
+
+func newRecurrenceRule(rec Recurrence) recurrenceRule {
+	rule := recurrenceRule{
+		frequency: rec.Frequency,
+		interval:  max(int(rec.Period), 1),
+		anchorDay: int(rec.AnchorDay),
+	}
+
+	if rec.StartsAt != nil {
+		startsAt := utcDay(*rec.StartsAt)
+		rule.startsAt = &startsAt
+	}
+	if rec.EndsAt != nil {
+		endsAt := utcDay(*rec.EndsAt)
+		rule.endsAt = &endsAt
+	}
+
+	return rule
+}
+
+// DueOccurrences returns every date a run at now should copy the rule on, oldest
+// first: each occurrence after the last copy up to and including today, so a
+// run that resumes after a gap catches up one copy per missed period. A rule
+// that has never been copied starts at its start date; without one, its first
+// occurrence is the latest on or before now. The list stops at the end date
+// and at whatever is left of a non-zero occurrence limit.
+func DueOccurrences(rec Recurrence, now time.Time) []time.Time {
+	today := utcDay(now.Unix())
+
+	return occurrencesBefore(rec, today, today.AddDate(0, 0, 1))
+}
+
+// occurrencesBefore lists the occurrences DueOccurrences would return if runs
+// kept happening until the day before until. today only decides where a rule
+// with neither a last copy nor a start date begins.
+func occurrencesBefore(rec Recurrence, today, until time.Time) []time.Time {
+	rule := newRecurrenceRule(rec)
+
+	var next time.Time
+	switch {
+	case rec.LastCopyCreatedAt != nil:
+		next = rule.step(utcDay(*rec.LastCopyCreatedAt))
+	case rule.startsAt != nil:
+		next = rule.firstOnOrAfter(*rule.startsAt)
+	default:
+		next = rule.latestOnOrBefore(today)
+	}
+
+	// A start date moved past the last copy restarts the schedule there.
+	if rule.startsAt != nil && next.Before(*rule.startsAt) {
+		next = rule.firstOnOrAfter(*rule.startsAt)
+	}
+
+	remaining := -1
+	if rec.OccurrenceLimit > 0 {
+		remaining = max(int(rec.OccurrenceLimit)-int(rec.OccurrenceCount), 0)
+	}
+
+	var dates []time.Time
+	for next.Before(until) && !rule.ended(next) && remaining != 0 {
+		dates = append(dates, next)
+		next = rule.step(next)
+		remaining--
+	}
+
+	return dates
+}
+
+// IsLastOccurrence reports whether the rule has no occurrence after date, so
+// the row can be archived along with the copy made for date.
+func IsLastOccurrence(rec Recurrence, date time.Time) bool {
+	rule := newRecurrenceRule(rec)
+
+	return rule.ended(rule.step(date))
+}
+
+// DescribeRecurrence renders the rule as the list and detail pages show it,
+// e.g. "Every 2 weeks" or "Monthly on day 15".
+func DescribeRecurrence(rec Recurrence) string {
+	names := map[string][2]string{
+		RecurrenceDaily:   {"Daily", "days"},
+		RecurrenceWeekly:  {"Weekly", "weeks"},
+		RecurrenceMonthly: {"Monthly", "months"},
+		RecurrenceYearly:  {"Yearly", "years"},
+	}
+	name := names[rec.Frequency]
+
+	description := name[0]
+	if rec.Period > 1 {
+		description = fmt.Sprintf("Every %d %s", rec.Period, name[1])
+	}
+
+	switch {
+	case rec.Frequency == RecurrenceYearly && rec.StartsAt != nil:
+		month := time.Unix(*rec.StartsAt, 0).UTC().Month()
+		description += fmt.Sprintf(" on %s %d", month, rec.AnchorDay)
+	case rec.Frequency == RecurrenceMonthly || rec.Frequency == RecurrenceYearly:
+		description += fmt.Sprintf(" on day %d", rec.AnchorDay)
+	}
+
+	return description
+}
+
+// step moves one interval forward. Monthly and yearly rules land on the
+// anchor day clamped to the target month, so a rule anchored on the 31st
+// copies on Feb 28 and is back on the 31st in March.
+func (r recurrenceRule) step(from time.Time) time.Time {
+	switch r.frequency {
+	case RecurrenceDaily:
+		return from.AddDate(0, 0, r.interval)
+	case RecurrenceWeekly:
+		return from.AddDate(0, 0, 7*r.interval)
+	case RecurrenceYearly:
+		return anchoredDay(from.Year()+r.interval, from.Month(), r.anchorDay)
+	default:
+		return anchoredDay(from.Year(), from.Month()+time.Month(r.interval), r.anchorDay)
+	}
+}
+
+func (r recurrenceRule) firstOnOrAfter(date time.Time) time.Time {
+	switch r.frequency {
+	case RecurrenceDaily, RecurrenceWeekly:
+		return date
+	case RecurrenceYearly:
+		candidate := anchoredDay(date.Year(), date.Month(), r.anchorDay)
+		if candidate.Before(date) {
+			return anchoredDay(date.Year()+1, date.Month(), r.anchorDay)
+		}
+
+		return candidate
+	default:
+		candidate := anchoredDay(date.Year(), date.Month(), r.anchorDay)
+		if candidate.Before(date) {
+			return anchoredDay(date.Year(), date.Month()+1, r.anchorDay)
+		}
+
+		return candidate
+	}
+}
+
+func (r recurrenceRule) latestOnOrBefore(date time.Time) time.Time {
+	switch r.frequency {
+	case RecurrenceDaily, RecurrenceWeekly:
+		return date
+	default:
+		candidate := anchoredDay(date.Year(), date.Month(), r.anchorDay)
+		if candidate.After(date) {
+			return anchoredDay(date.Year(), date.Month()-1, r.anchorDay)
+		}
+
+		return candidate
+	}
+}
+
+func (r recurrenceRule) ended(date time.Time) bool {
+	return r.endsAt != nil && date.After(*r.endsAt)
+}
+
+// anchoredDay is day in the given month, or the month's last day when the
+// month is shorter. month may overflow; time.Date normalizes it.
+func anchoredDay(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+
+	return first.AddDate(0, 0, min(max(day, 1), lastDay)-1)
+}
+
+func utcDay(unix int64) time.Time {
+	t := time.Unix(unix, 0).UTC()
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
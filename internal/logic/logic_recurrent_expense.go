@@ -2,6 +2,9 @@ package logic
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/ad9311/ninete/internal/repo"
@@ -9,11 +12,22 @@ import (
 
 type RecurrentExpenseParams struct {
 	ExpenseBaseParams
-	Period uint `validate:"required,gt=0"`
+	// Period is the rule's interval: a copy every Period units of Frequency.
+	Period    uint   `validate:"required,gt=0"`
+	Frequency string `validate:"omitempty,oneof=daily weekly monthly yearly"`
+	// AnchorDay is the day of the month monthly and yearly rules copy on,
+	// clamped to shorter months. Zero means the start date's day, or the 1st.
+	AnchorDay uint `validate:"max=31"`
+	// StartsAt and EndsAt bound the occurrences. Either may be nil.
+	StartsAt *int64 `validate:"-"`
+	EndsAt   *int64 `validate:"-"`
 	// OccurrenceLimit caps how many expenses this recurrent expense generates
 	// before it archives itself. Zero means unlimited.
-	OccurrenceLimit uint     `validate:"-"`
-	Tags            []string `validate:"-"`
+	OccurrenceLimit uint `validate:"-"`
+	// IsEstimate makes Amount a guess: due occurrences wait in the pending
+	// inbox for the actual amount instead of posting as expenses.
+	IsEstimate bool     `validate:"-"`
+	Tags       []string `validate:"-"`
 }
 
 func (s *Store) FindRecurrentExpenses(
@@ -68,12 +82,23 @@ func (s *Store) CreateRecurrentExpense(
 ) (repo.RecurrentExpense, error) {
 	var recurrentExpense repo.RecurrentExpense
 
-	if err := s.ValidateStruct(params); err != nil {
+	params.Currency = NormalizeCurrency(params.Currency)
+	if err := s.validateRecurrentExpenseParams(&params); err != nil {
 		return recurrentExpense, err
 	}
 
 	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
-		var txErr error
+		if err := checkCategoryTx(ctx, tq, userID, params.CategoryID); err != nil {
+			return err
+		}
+		if err := checkPaymentAccountTx(ctx, tq, userID, params.PaymentAccountID); err != nil {
+			return err
+		}
+
+		currency, txErr := currencyOrHomeTx(ctx, tq, userID, params.Currency)
+		if txErr != nil {
+			return txErr
+		}
 
 		recurrentExpense, txErr = tq.InsertRecurrentExpense(ctx, repo.InsertRecurrentExpenseParams{
 			UserID:          userID,
@@ -82,6 +107,14 @@ func (s *Store) CreateRecurrentExpense(
 			Amount:          params.Amount,
 			Period:          params.Period,
 			OccurrenceLimit: params.OccurrenceLimit,
+			Currency:        currency,
+			Frequency:       params.Frequency,
+			AnchorDay:       params.AnchorDay,
+			StartsAt:        repo.NullInt64FromPtr(params.StartsAt),
+			EndsAt:          repo.NullInt64FromPtr(params.EndsAt),
+			IsEstimate:      params.IsEstimate,
+
+			PaymentAccountID: params.PaymentAccountID,
 		})
 		if txErr != nil {
 			return txErr
@@ -110,11 +143,19 @@ func (s *Store) UpdateRecurrentExpense(
 ) (repo.RecurrentExpense, error) {
 	var recurrentExpense repo.RecurrentExpense
 
-	if err := s.ValidateStruct(params); err != nil {
+	params.Currency = NormalizeCurrency(params.Currency)
+	if err := s.validateRecurrentExpenseParams(&params); err != nil {
 		return recurrentExpense, err
 	}
 
 	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		if err := checkCategoryTx(ctx, tq, userID, params.CategoryID); err != nil {
+			return err
+		}
+		if err := checkPaymentAccountTx(ctx, tq, userID, params.PaymentAccountID); err != nil {
+			return err
+		}
+
 		var txErr error
 
 		recurrentExpense, txErr = tq.UpdateRecurrentExpense(ctx, repo.UpdateRecurrentExpenseParams{
@@ -125,6 +166,14 @@ func (s *Store) UpdateRecurrentExpense(
 			Amount:          params.Amount,
 			Period:          params.Period,
 			OccurrenceLimit: params.OccurrenceLimit,
+			Currency:        params.Currency,
+			Frequency:       params.Frequency,
+			AnchorDay:       params.AnchorDay,
+			StartsAt:        repo.NullInt64FromPtr(params.StartsAt),
+			EndsAt:          repo.NullInt64FromPtr(params.EndsAt),
+			IsEstimate:      params.IsEstimate,
+
+			PaymentAccountID: params.PaymentAccountID,
 		})
 		if txErr != nil {
 			return txErr
@@ -175,61 +224,170 @@ func (s *Store) DeleteAllRecurrentExpenses(ctx context.Context, userID int) erro
 	})
 }
 
-func (s *Store) CopyDueRecurrentExpenses(ctx context.Context, now time.Time) (int, error) {
-	nowUnix := now.Unix()
-	expenseDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Unix()
+// DueRecurrentExpense is an active rule with the dates a run would copy it
+// on, oldest first.
+type DueRecurrentExpense struct {
+	repo.RecurrentExpense
+	Dates []time.Time
+}
 
-	recurrentExpenses, err := s.queries.SelectAllDueRecurrentExpenses(ctx, nowUnix)
+// FindDueRecurrentExpenses works out what CopyDueRecurrentExpenses would
+// create at now without writing anything; the task's --dry-run prints it.
+func (s *Store) FindDueRecurrentExpenses(ctx context.Context, now time.Time) ([]DueRecurrentExpense, error) {
+	recurrentExpenses, err := s.queries.SelectActiveRecurrentExpenses(ctx, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	var due []DueRecurrentExpense
+	for _, re := range recurrentExpenses {
+		dates := DueOccurrences(RecurrentExpenseRecurrence(re), now)
+		if len(dates) == 0 {
+			continue
+		}
+
+		due = append(due, DueRecurrentExpense{RecurrentExpense: re, Dates: dates})
+	}
+
+	return due, nil
+}
+
+// CopyDueRecurrentExpenses creates one expense per due occurrence, dated on
+// that occurrence and linked to its recurrent expense, and returns how many it
+// created. Estimate rules get a pending expense instead, counted the same.
+// A rule that missed runs gets a copy for every period it missed.
+// Each rule's copies land in one transaction that only commits if no other run
+// recorded a copy on or after the first date in the meantime, so re-running
+// the task never duplicates.
+//
+// The run is recorded in task_runs with one task_run_failures row per rule
+// that failed. A failing rule does not stop the others, but the run then
+// returns ErrRecurrentCopiesFailed alongside the count.
+func (s *Store) CopyDueRecurrentExpenses(ctx context.Context, now time.Time) (int, error) {
+	run, err := s.queries.InsertTaskRun(ctx, TaskCopyDueRecurrentExpenses, time.Now().Unix())
 	if err != nil {
 		return 0, err
 	}
 
-	if len(recurrentExpenses) == 0 {
-		return 0, nil
+	due, err := s.FindDueRecurrentExpenses(ctx, now)
+	if err != nil {
+		s.finishTaskRun(ctx, run.ID, 0, []taskRunFailure{{err: err}})
+
+		return 0, err
 	}
 
 	copied := 0
-	for _, re := range recurrentExpenses {
-		if err := s.copyRecurrentExpense(ctx, re, expenseDate); err != nil {
-			s.app.Logger.Errorf("failed to copy recurrent expense [id=%d]: %v", re.ID, err)
+	var failures []taskRunFailure
+	for _, d := range due {
+		err := s.copyRecurrentExpense(ctx, d.RecurrentExpense, d.Dates)
+		if errors.Is(err, sql.ErrNoRows) {
+			s.app.Logger.Logf("skipped recurrent expense [id=%d]: already copied by another run", d.ID)
 
 			continue
 		}
+		if err != nil {
+			s.app.Logger.Errorf("failed to copy recurrent expense [id=%d]: %v", d.ID, err)
+			failures = append(failures, taskRunFailure{subjectID: d.ID, err: err})
+
+			continue
+		}
+
+		copied += len(d.Dates)
+	}
+
+	s.finishTaskRun(ctx, run.ID, copied, failures)
 
-		copied++
+	if len(failures) > 0 {
+		return copied, fmt.Errorf("%w: %d of %d failed", ErrRecurrentCopiesFailed, len(failures), len(due))
 	}
 
 	return copied, nil
 }
 
-func (s *Store) copyRecurrentExpense(ctx context.Context, re repo.RecurrentExpense, expenseDate int64) error {
-	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
-		expense, err := tq.InsertExpense(ctx, repo.InsertExpenseParams{
-			UserID:      re.UserID,
-			CategoryID:  re.CategoryID,
-			Description: re.Description,
-			Amount:      re.Amount,
-			Date:        expenseDate,
-		})
-		if err != nil {
-			return err
+// copyRecurrentExpense posts each date as an expense, or as a pending expense
+// for an estimate. Either way the occurrence is recorded now, so a pending one
+// the user later skips still counts toward the limit.
+func (s *Store) copyRecurrentExpense(ctx context.Context, re repo.RecurrentExpense, dates []time.Time) error {
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		for i, date := range dates {
+			var err error
+			if re.IsEstimate {
+				_, err = tq.InsertPendingExpense(ctx, re.UserID, re.ID, date.Unix())
+			} else {
+				_, err = insertRecurrentExpenseCopyTx(ctx, tq, re, re.Amount, date.Unix())
+			}
+			if err != nil {
+				return err
+			}
+
+			finished := i == len(dates)-1 && IsLastOccurrence(RecurrentExpenseRecurrence(re), date)
+			_, err = tq.RecordRecurrentExpenseOccurrence(ctx, re.ID, re.UserID, date.Unix(), finished)
+			if err != nil {
+				return err
+			}
 		}
 
-		err = tq.CopyTaggings(
-			ctx,
-			repo.TaggableTypeRecurrentExpense,
-			re.ID,
-			repo.TaggableTypeExpense,
-			expense.ID,
-		)
-		if err != nil {
-			return err
+		return nil
+	})
+	if err != nil || re.IsEstimate {
+		return err
+	}
+
+	s.raiseRecurrentCopyAlerts(ctx, re, dates)
+
+	return nil
+}
+
+// raiseRecurrentCopyAlerts raises the budget alerts for copies already
+// committed. It only logs a failure: an alert must not undo the copies, and
+// its sql.ErrNoRows must not pass for the concurrency guard's.
+func (s *Store) raiseRecurrentCopyAlerts(ctx context.Context, re repo.RecurrentExpense, dates []time.Time) {
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		for _, date := range dates {
+			if err := s.raiseBudgetAlertsTx(ctx, tq, re.UserID, date.Unix(), []int{re.CategoryID}); err != nil {
+				return err
+			}
 		}
 
-		_, err = tq.RecordRecurrentExpenseOccurrence(ctx, re.ID, re.UserID, expenseDate)
+		return nil
+	})
+	if err != nil {
+		s.app.Logger.Errorf("failed to raise budget alerts for recurrent expense [id=%d]: %v", re.ID, err)
+	}
+}
 
-		return err
+// insertRecurrentExpenseCopyTx creates the expense a recurrent expense
+// generates on date, linked back to it and carrying its tags.
+func insertRecurrentExpenseCopyTx(
+	ctx context.Context,
+	tq *repo.TxQueries,
+	re repo.RecurrentExpense,
+	amount uint64,
+	date int64,
+) (repo.Expense, error) {
+	expense, err := tq.InsertExpense(ctx, repo.InsertExpenseParams{
+		UserID:             re.UserID,
+		CategoryID:         re.CategoryID,
+		Description:        re.Description,
+		Amount:             amount,
+		Date:               date,
+		Currency:           re.Currency,
+		RecurrentExpenseID: &re.ID,
+		PaymentAccountID:   re.PaymentAccountID,
 	})
+	if err != nil {
+		return expense, err
+	}
+
+	err = tq.CopyTaggings(
+		ctx,
+		repo.TaggableTypeRecurrentExpense,
+		re.ID,
+		repo.TaggableTypeExpense,
+		expense.ID,
+	)
+
+	return expense, err
 }
 
 // UnarchiveRecurrentExpense clears the archived flag and resets the occurrence
@@ -250,3 +408,29 @@ func (s *Store) UnarchiveRecurrentExpense(ctx context.Context, id, userID int) (
 
 	return recurrentExpense, nil
 }
+
+// validateRecurrentExpenseParams fills in the rule defaults before
+// validating; see Recurrence.withDefaults.
+func (s *Store) validateRecurrentExpenseParams(params *RecurrentExpenseParams) error {
+	rec := Recurrence{
+		Frequency: params.Frequency,
+		AnchorDay: params.AnchorDay,
+		StartsAt:  params.StartsAt,
+		EndsAt:    params.EndsAt,
+	}.withDefaults()
+
+	params.Frequency = rec.Frequency
+	params.AnchorDay = rec.AnchorDay
+	params.StartsAt = rec.StartsAt
+	params.EndsAt = rec.EndsAt
+
+	if err := s.ValidateStruct(*params); err != nil {
+		return err
+	}
+
+	if rec.endsBeforeStart() {
+		return ErrRecurrenceEndsBeforeStart
+	}
+
+	return nil
+}
@@ -19,7 +19,7 @@ func TestCreateRecurrentExpense(t *testing.T) {
 		Email:        "recurrent_user_1@example.com",
 		PasswordHash: []byte("recurrent_user_hash_1"),
 	})
-	category := s.CreateCategory(t, "recurrent category 1")
+	category := s.CreateCategory(t, user.ID, "recurrent category 1")
 
 	cases := []struct {
 		name string
@@ -69,7 +69,7 @@ func TestFindRecurrentExpense(t *testing.T) {
 		Email:        "recurrent_user_3@example.com",
 		PasswordHash: []byte("recurrent_user_hash_3"),
 	})
-	category := s.CreateCategory(t, "recurrent category 2")
+	category := s.CreateCategory(t, user.ID, "recurrent category 2")
 
 	cases := []struct {
 		name string
@@ -122,7 +122,8 @@ func TestFindRecurrentExpenses(t *testing.T) {
 		Email:        "recurrent_user_5@example.com",
 		PasswordHash: []byte("recurrent_user_hash_5"),
 	})
-	category := s.CreateCategory(t, "recurrent category 3")
+	category := s.CreateCategory(t, user.ID, "recurrent category 3")
+	otherCategory := s.CreateCategory(t, otherUser.ID, "recurrent category 3")
 
 	cases := []struct {
 		name string
@@ -144,7 +145,7 @@ func TestFindRecurrentExpenses(t *testing.T) {
 				s.CreateRecurrentExpense(
 					t,
 					otherUser.ID,
-					newRecurrentExpenseParams(category.ID, "recurrent description 6", 2500, 1),
+					newRecurrentExpenseParams(otherCategory.ID, "recurrent description 6", 2500, 1),
 				)
 
 				recurrentExpenses, err := s.Store.FindRecurrentExpenses(ctx, repo.QueryOptions{
@@ -190,8 +191,9 @@ func TestUpdateRecurrentExpense(t *testing.T) {
 		Email:        "recurrent_user_7@example.com",
 		PasswordHash: []byte("recurrent_user_hash_7"),
 	})
-	categoryOne := s.CreateCategory(t, "recurrent category 4")
-	categoryTwo := s.CreateCategory(t, "recurrent category 5")
+	categoryOne := s.CreateCategory(t, user.ID, "recurrent category 4")
+	categoryTwo := s.CreateCategory(t, user.ID, "recurrent category 5")
+	otherCategory := s.CreateCategory(t, otherUser.ID, "recurrent category 5")
 
 	cases := []struct {
 		name string
@@ -230,7 +232,7 @@ func TestUpdateRecurrentExpense(t *testing.T) {
 					ctx,
 					recurrentExpense.ID,
 					otherUser.ID,
-					newRecurrentExpenseParams(categoryTwo.ID, "recurrent description 8 updated", 2900, 2),
+					newRecurrentExpenseParams(otherCategory.ID, "recurrent description 8 updated", 2900, 2),
 				)
 				require.ErrorIs(t, err, sql.ErrNoRows)
 			},
@@ -273,7 +275,7 @@ func TestDeleteRecurrentExpense(t *testing.T) {
 		Email:        "recurrent_user_9@example.com",
 		PasswordHash: []byte("recurrent_user_hash_9"),
 	})
-	category := s.CreateCategory(t, "recurrent category 6")
+	category := s.CreateCategory(t, user.ID, "recurrent category 6")
 
 	cases := []struct {
 		name string
@@ -321,7 +323,7 @@ func TestCopyDueRecurrentExpenses(t *testing.T) {
 		Email:        "recurrent_user_copy_1@example.com",
 		PasswordHash: []byte("recurrent_user_copy_hash_1"),
 	})
-	category := s.CreateCategory(t, "recurrent category copy 1")
+	category := s.CreateCategory(t, user.ID, "recurrent category copy 1")
 	now := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
 	expenseDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Unix()
 
@@ -354,6 +356,7 @@ func TestCopyDueRecurrentExpenses(t *testing.T) {
 				require.NoError(t, err)
 				require.Len(t, expenses, 1)
 				require.Equal(t, expenseDate, expenses[0].Date)
+				require.Equal(t, &re.ID, expenses[0].RecurrentExpenseID)
 
 				updated, err := s.Store.FindRecurrentExpense(ctx, re.ID, user.ID)
 				require.NoError(t, err)
@@ -361,6 +364,27 @@ func TestCopyDueRecurrentExpenses(t *testing.T) {
 				require.Equal(t, expenseDate, *updated.LastCopyCreatedAt)
 			},
 		},
+		{
+			name: "should_record_the_run",
+			fn: func(t *testing.T) {
+				s.CreateRecurrentExpense(
+					t,
+					user.ID,
+					newRecurrentExpenseParams(category.ID, "copy audited 1", 4000, 1),
+				)
+
+				copied, err := s.Store.CopyDueRecurrentExpenses(ctx, now)
+				require.NoError(t, err)
+
+				run, failures, err := s.Store.FindLatestTaskRun(ctx, logic.TaskCopyDueRecurrentExpenses)
+				require.NoError(t, err)
+				require.NotNil(t, run.FinishedAt)
+				require.GreaterOrEqual(t, *run.FinishedAt, run.StartedAt)
+				require.Equal(t, copied, run.SucceededCount)
+				require.Zero(t, run.FailedCount)
+				require.Empty(t, failures)
+			},
+		},
 		{
 			name: "should_copy_when_period_has_elapsed",
 			fn: func(t *testing.T) {
@@ -443,7 +467,7 @@ func TestRecurrentExpenseTags(t *testing.T) {
 		Email:        "recurrent_tag_user_1@example.com",
 		PasswordHash: []byte("recurrent_tag_user_hash_1"),
 	})
-	category := s.CreateCategory(t, "recurrent tag category 1")
+	category := s.CreateCategory(t, user.ID, "recurrent tag category 1")
 	now := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
 
 	cases := []struct {
@@ -543,7 +567,7 @@ func TestRecurrentExpenseOccurrenceLimit(t *testing.T) {
 		Email:        "recurrent_limit_user_1@example.com",
 		PasswordHash: []byte("recurrent_limit_user_hash_1"),
 	})
-	category := s.CreateCategory(t, "recurrent category limit 1")
+	category := s.CreateCategory(t, user.ID, "recurrent category limit 1")
 	march := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
 	april := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
 	may := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
@@ -767,3 +791,297 @@ func TestRecurrentExpenseOccurrenceLimit(t *testing.T) {
 		t.Run(tc.name, tc.fn)
 	}
 }
+
+func TestRecurrenceRules(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "recurrence_rule_user_1",
+		Email:        "recurrence_rule_user_1@example.com",
+		PasswordHash: []byte("recurrence_rule_user_hash_1"),
+	})
+	category := s.CreateCategory(t, user.ID, "recurrence rule category 1")
+
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	unix := func(t time.Time) *int64 {
+		value := t.Unix()
+
+		return &value
+	}
+	copyOn := func(t *testing.T, now time.Time) {
+		t.Helper()
+
+		_, err := s.Store.CopyDueRecurrentExpenses(ctx, now)
+		require.NoError(t, err)
+	}
+	expenseDates := func(t *testing.T, description string) []int64 {
+		t.Helper()
+
+		expenses, err := s.Store.FindExpenses(ctx, repo.QueryOptions{
+			Filters: repo.Filters{
+				FilterFields: []repo.FilterField{
+					{Name: "user_id", Value: user.ID, Operator: "="},
+					{Name: "description", Value: description, Operator: "="},
+				},
+				Connector: "AND",
+			},
+			Sorting: repo.Sorting{Field: "date", Order: "ASC"},
+		})
+		require.NoError(t, err)
+
+		dates := make([]int64, 0, len(expenses))
+		for _, e := range expenses {
+			dates = append(dates, e.Date)
+		}
+
+		return dates
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_clamp_a_monthly_anchor_to_short_months",
+			fn: func(t *testing.T) {
+				params := newRecurrentExpenseParams(category.ID, "rule monthly 31", 1000, 1)
+				params.StartsAt = unix(day(2026, time.January, 31))
+				re := s.CreateRecurrentExpense(t, user.ID, params)
+				require.Equal(t, uint(31), re.AnchorDay)
+
+				copyOn(t, day(2026, time.January, 31))
+				copyOn(t, day(2026, time.February, 28))
+				copyOn(t, day(2026, time.March, 31))
+
+				require.Equal(t, []int64{
+					day(2026, time.January, 31).Unix(),
+					day(2026, time.February, 28).Unix(),
+					day(2026, time.March, 31).Unix(),
+				}, expenseDates(t, "rule monthly 31"))
+			},
+		},
+		{
+			name: "should_copy_weekly_rules_on_their_due_date_from_the_start",
+			fn: func(t *testing.T) {
+				params := newRecurrentExpenseParams(category.ID, "rule weekly gym", 1500, 1)
+				params.Frequency = logic.RecurrenceWeekly
+				params.StartsAt = unix(day(2026, time.March, 2))
+				s.CreateRecurrentExpense(t, user.ID, params)
+
+				copyOn(t, day(2026, time.March, 1))
+				require.Empty(t, expenseDates(t, "rule weekly gym"))
+
+				copyOn(t, day(2026, time.March, 4))
+				copyOn(t, day(2026, time.March, 10))
+
+				require.Equal(t, []int64{
+					day(2026, time.March, 2).Unix(),
+					day(2026, time.March, 9).Unix(),
+				}, expenseDates(t, "rule weekly gym"))
+			},
+		},
+		{
+			name: "should_archive_after_the_last_occurrence_before_the_end_date",
+			fn: func(t *testing.T) {
+				params := newRecurrentExpenseParams(category.ID, "rule ends", 2000, 1)
+				params.StartsAt = unix(day(2026, time.January, 15))
+				params.EndsAt = unix(day(2026, time.February, 20))
+				re := s.CreateRecurrentExpense(t, user.ID, params)
+
+				copyOn(t, day(2026, time.January, 16))
+				updated, err := s.Store.FindRecurrentExpense(ctx, re.ID, user.ID)
+				require.NoError(t, err)
+				require.Nil(t, updated.ArchivedAt)
+
+				copyOn(t, day(2026, time.February, 15))
+				updated, err = s.Store.FindRecurrentExpense(ctx, re.ID, user.ID)
+				require.NoError(t, err)
+				require.NotNil(t, updated.ArchivedAt)
+				require.Len(t, expenseDates(t, "rule ends"), 2)
+			},
+		},
+		{
+			name: "should_keep_a_yearly_rule_on_its_month",
+			fn: func(t *testing.T) {
+				params := newRecurrentExpenseParams(category.ID, "rule yearly leap", 9900, 1)
+				params.Frequency = logic.RecurrenceYearly
+				params.StartsAt = unix(day(2024, time.February, 29))
+				s.CreateRecurrentExpense(t, user.ID, params)
+
+				copyOn(t, day(2024, time.March, 1))
+				copyOn(t, day(2025, time.March, 1))
+
+				require.Equal(t, []int64{
+					day(2024, time.February, 29).Unix(),
+					day(2025, time.February, 28).Unix(),
+				}, expenseDates(t, "rule yearly leap"))
+			},
+		},
+		{
+			name: "should_reject_an_end_date_before_the_start_date",
+			fn: func(t *testing.T) {
+				params := newRecurrentExpenseParams(category.ID, "rule backwards", 2000, 1)
+				params.StartsAt = unix(day(2026, time.June, 1))
+				params.EndsAt = unix(day(2026, time.March, 1))
+
+				_, err := s.Store.CreateRecurrentExpense(ctx, user.ID, params)
+				require.ErrorIs(t, err, logic.ErrRecurrenceEndsBeforeStart)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestDescribeRecurrence(t *testing.T) {
+	startsAt := time.Date(2026, time.April, 10, 0, 0, 0, 0, time.UTC).Unix()
+
+	cases := []struct {
+		name string
+		re   repo.RecurrentExpense
+		want string
+	}{
+		{"should_describe_a_plain_monthly_rule", repo.RecurrentExpense{
+			Frequency: logic.RecurrenceMonthly, Period: 1, AnchorDay: 15,
+		}, "Monthly on day 15"},
+		{"should_describe_an_interval", repo.RecurrentExpense{
+			Frequency: logic.RecurrenceWeekly, Period: 2, AnchorDay: 1,
+		}, "Every 2 weeks"},
+		{"should_describe_a_yearly_rule_with_its_month", repo.RecurrentExpense{
+			Frequency: logic.RecurrenceYearly, Period: 1, AnchorDay: 10, StartsAt: &startsAt,
+		}, "Yearly on April 10"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, logic.DescribeRecurrence(logic.RecurrentExpenseRecurrence(tc.re)))
+		})
+	}
+}
+
+func TestCopyDueRecurrentExpensesCatchUp(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "recurrent_catch_up_user_1",
+		Email:        "recurrent_catch_up_user_1@example.com",
+		PasswordHash: []byte("recurrent_catch_up_user_hash_1"),
+	})
+	category := s.CreateCategory(t, user.ID, "recurrent category catch up 1")
+	january := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	expenseDates := func(t *testing.T, description string) []int64 {
+		t.Helper()
+
+		expenses, err := s.Store.FindExpenses(ctx, repo.QueryOptions{
+			Filters: repo.Filters{
+				FilterFields: []repo.FilterField{
+					{Name: "user_id", Value: user.ID, Operator: "="},
+					{Name: "description", Value: description, Operator: "="},
+				},
+				Connector: "AND",
+			},
+			Sorting: repo.Sorting{Field: "date", Order: "ASC"},
+		})
+		require.NoError(t, err)
+
+		dates := make([]int64, 0, len(expenses))
+		for _, e := range expenses {
+			dates = append(dates, e.Date)
+		}
+
+		return dates
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_one_copy_per_missed_month",
+			fn: func(t *testing.T) {
+				re := s.CreateRecurrentExpense(
+					t,
+					user.ID,
+					newRecurrentExpenseParams(category.ID, "catch up monthly 1", 4000, 1),
+				)
+				s.SetRecurrentExpenseLastCopy(t, re, january.Unix())
+
+				due, err := s.Store.FindDueRecurrentExpenses(ctx, april)
+				require.NoError(t, err)
+				require.Empty(t, expenseDates(t, "catch up monthly 1"))
+
+				var planned []time.Time
+				for _, d := range due {
+					if d.ID == re.ID {
+						planned = d.Dates
+					}
+				}
+				require.Len(t, planned, 3)
+
+				_, err = s.Store.CopyDueRecurrentExpenses(ctx, april)
+				require.NoError(t, err)
+
+				require.Equal(t, []int64{
+					time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC).Unix(),
+					time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC).Unix(),
+					april.Unix(),
+				}, expenseDates(t, "catch up monthly 1"))
+
+				updated, err := s.Store.FindRecurrentExpense(ctx, re.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, april.Unix(), *updated.LastCopyCreatedAt)
+				require.Equal(t, uint(3), updated.OccurrenceCount)
+			},
+		},
+		{
+			name: "should_not_duplicate_when_run_again",
+			fn: func(t *testing.T) {
+				re := s.CreateRecurrentExpense(
+					t,
+					user.ID,
+					newRecurrentExpenseParams(category.ID, "catch up rerun 1", 4000, 1),
+				)
+				s.SetRecurrentExpenseLastCopy(t, re, january.Unix())
+
+				_, err := s.Store.CopyDueRecurrentExpenses(ctx, april)
+				require.NoError(t, err)
+				_, err = s.Store.CopyDueRecurrentExpenses(ctx, april)
+				require.NoError(t, err)
+
+				require.Len(t, expenseDates(t, "catch up rerun 1"), 3)
+			},
+		},
+		{
+			name: "should_stop_at_the_occurrence_limit",
+			fn: func(t *testing.T) {
+				params := newRecurrentExpenseParams(category.ID, "catch up limited 1", 4000, 1)
+				params.OccurrenceLimit = 2
+				re := s.CreateRecurrentExpense(t, user.ID, params)
+				s.SetRecurrentExpenseLastCopy(t, re, january.Unix())
+
+				_, err := s.Store.CopyDueRecurrentExpenses(ctx, april)
+				require.NoError(t, err)
+
+				require.Equal(t, []int64{
+					time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC).Unix(),
+					time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC).Unix(),
+				}, expenseDates(t, "catch up limited 1"))
+
+				updated, err := s.Store.FindRecurrentExpense(ctx, re.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, uint(2), updated.OccurrenceCount)
+				require.NotNil(t, updated.ArchivedAt)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -0,0 +1,335 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+type RecurrentIncomeParams struct {
+	Source   string `validate:"required,min=2,max=50"`
+	Amount   uint64 `validate:"required,gt=0"`
+	Currency string `validate:"omitempty,iso4217"`
+	// The rule fields mean what they do on RecurrentExpenseParams.
+	Period          uint     `validate:"required,gt=0"`
+	Frequency       string   `validate:"omitempty,oneof=daily weekly monthly yearly"`
+	AnchorDay       uint     `validate:"max=31"`
+	StartsAt        *int64   `validate:"-"`
+	EndsAt          *int64   `validate:"-"`
+	OccurrenceLimit uint     `validate:"-"`
+	Tags            []string `validate:"-"`
+}
+
+func (s *Store) FindRecurrentIncomes(ctx context.Context, opts repo.QueryOptions) ([]repo.RecurrentIncome, error) {
+	recurrentIncomes, err := s.queries.SelectRecurrentIncomes(ctx, opts)
+	if err != nil {
+		return recurrentIncomes, err
+	}
+
+	return recurrentIncomes, nil
+}
+
+func (s *Store) CountRecurrentIncomes(ctx context.Context, filters repo.Filters) (int, error) {
+	count, err := s.queries.CountRecurrentIncomes(ctx, filters)
+	if err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+func (s *Store) FindRecurrentIncome(ctx context.Context, id, userID int) (repo.RecurrentIncome, error) {
+	recurrentIncome, err := s.queries.SelectRecurrentIncome(ctx, id, userID)
+	if err != nil {
+		return recurrentIncome, err
+	}
+
+	return recurrentIncome, nil
+}
+
+func (s *Store) FindRecurrentIncomeTags(ctx context.Context, recurrentIncomeID, userID int) ([]repo.Tag, error) {
+	tags, err := s.queries.SelectTagsForTaggable(
+		ctx,
+		repo.TaggableTypeRecurrentIncome,
+		"recurrent_incomes",
+		recurrentIncomeID,
+		userID,
+	)
+	if err != nil {
+		return tags, err
+	}
+
+	return tags, nil
+}
+
+func (s *Store) CreateRecurrentIncome(
+	ctx context.Context,
+	userID int,
+	params RecurrentIncomeParams,
+) (repo.RecurrentIncome, error) {
+	var recurrentIncome repo.RecurrentIncome
+
+	params.Currency = NormalizeCurrency(params.Currency)
+	if err := s.validateRecurrentIncomeParams(&params); err != nil {
+		return recurrentIncome, err
+	}
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		currency, txErr := currencyOrHomeTx(ctx, tq, userID, params.Currency)
+		if txErr != nil {
+			return txErr
+		}
+
+		recurrentIncome, txErr = tq.InsertRecurrentIncome(ctx, repo.InsertRecurrentIncomeParams{
+			UserID:          userID,
+			Source:          params.Source,
+			Amount:          params.Amount,
+			Currency:        currency,
+			Period:          params.Period,
+			Frequency:       params.Frequency,
+			AnchorDay:       params.AnchorDay,
+			StartsAt:        params.StartsAt,
+			EndsAt:          params.EndsAt,
+			OccurrenceLimit: params.OccurrenceLimit,
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		return s.replaceTagsTx(
+			ctx,
+			tq,
+			repo.TaggableTypeRecurrentIncome,
+			recurrentIncome.ID,
+			userID,
+			params.Tags,
+		)
+	})
+	if err != nil {
+		return recurrentIncome, err
+	}
+
+	return recurrentIncome, nil
+}
+
+func (s *Store) UpdateRecurrentIncome(
+	ctx context.Context,
+	id, userID int,
+	params RecurrentIncomeParams,
+) (repo.RecurrentIncome, error) {
+	var recurrentIncome repo.RecurrentIncome
+
+	params.Currency = NormalizeCurrency(params.Currency)
+	if err := s.validateRecurrentIncomeParams(&params); err != nil {
+		return recurrentIncome, err
+	}
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		var txErr error
+
+		recurrentIncome, txErr = tq.UpdateRecurrentIncome(ctx, repo.UpdateRecurrentIncomeParams{
+			ID:              id,
+			UserID:          userID,
+			Source:          params.Source,
+			Amount:          params.Amount,
+			Currency:        params.Currency,
+			Period:          params.Period,
+			Frequency:       params.Frequency,
+			AnchorDay:       params.AnchorDay,
+			StartsAt:        params.StartsAt,
+			EndsAt:          params.EndsAt,
+			OccurrenceLimit: params.OccurrenceLimit,
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		return s.replaceTagsTx(
+			ctx,
+			tq,
+			repo.TaggableTypeRecurrentIncome,
+			recurrentIncome.ID,
+			userID,
+			params.Tags,
+		)
+	})
+	if err != nil {
+		return recurrentIncome, err
+	}
+
+	return recurrentIncome, nil
+}
+
+// DeleteRecurrentIncome removes the rule and its taggings. The incomes it
+// generated stay, unlinked.
+func (s *Store) DeleteRecurrentIncome(ctx context.Context, id, userID int) error {
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		deletedID, err := tq.DeleteRecurrentIncome(ctx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		return tq.DeleteTaggingsByTarget(ctx, repo.TaggableTypeRecurrentIncome, deletedID)
+	})
+}
+
+func (s *Store) DeleteAllRecurrentIncomes(ctx context.Context, userID int) error {
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		return tq.DeleteAllRecurrentIncomesByUser(ctx, userID)
+	})
+}
+
+// UnarchiveRecurrentIncome puts an archived rule back in rotation with its
+// occurrence counter reset.
+func (s *Store) UnarchiveRecurrentIncome(ctx context.Context, id, userID int) (repo.RecurrentIncome, error) {
+	var recurrentIncome repo.RecurrentIncome
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		var txErr error
+
+		recurrentIncome, txErr = tq.UnarchiveRecurrentIncome(ctx, id, userID)
+
+		return txErr
+	})
+	if err != nil {
+		return recurrentIncome, err
+	}
+
+	return recurrentIncome, nil
+}
+
+// DueRecurrentIncome is an active rule with the dates a run would copy it on,
+// oldest first.
+type DueRecurrentIncome struct {
+	repo.RecurrentIncome
+	Dates []time.Time
+}
+
+// FindDueRecurrentIncomes works out what CopyDueRecurrentIncomes would create
+// at now without writing anything.
+func (s *Store) FindDueRecurrentIncomes(ctx context.Context, now time.Time) ([]DueRecurrentIncome, error) {
+	recurrentIncomes, err := s.queries.SelectActiveRecurrentIncomes(ctx, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	var due []DueRecurrentIncome
+	for _, ri := range recurrentIncomes {
+		dates := DueOccurrences(RecurrentIncomeRecurrence(ri), now)
+		if len(dates) == 0 {
+			continue
+		}
+
+		due = append(due, DueRecurrentIncome{RecurrentIncome: ri, Dates: dates})
+	}
+
+	return due, nil
+}
+
+// CopyDueRecurrentIncomes creates one income per due occurrence, dated on that
+// occurrence and linked to its recurrent income, and returns how many it
+// created. It runs the way CopyDueRecurrentExpenses does: missed periods are
+// caught up, a rule another run already copied is skipped, and the run is
+// recorded in task_runs, returning ErrRecurrentIncomeCopiesFailed when any
+// rule failed.
+func (s *Store) CopyDueRecurrentIncomes(ctx context.Context, now time.Time) (int, error) {
+	run, err := s.queries.InsertTaskRun(ctx, TaskCopyDueRecurrentIncomes, time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+
+	due, err := s.FindDueRecurrentIncomes(ctx, now)
+	if err != nil {
+		s.finishTaskRun(ctx, run.ID, 0, []taskRunFailure{{err: err}})
+
+		return 0, err
+	}
+
+	copied := 0
+	var failures []taskRunFailure
+	for _, d := range due {
+		err := s.copyRecurrentIncome(ctx, d.RecurrentIncome, d.Dates)
+		if errors.Is(err, sql.ErrNoRows) {
+			s.app.Logger.Logf("skipped recurrent income [id=%d]: already copied by another run", d.ID)
+
+			continue
+		}
+		if err != nil {
+			s.app.Logger.Errorf("failed to copy recurrent income [id=%d]: %v", d.ID, err)
+			failures = append(failures, taskRunFailure{subjectID: d.ID, err: err})
+
+			continue
+		}
+
+		copied += len(d.Dates)
+	}
+
+	s.finishTaskRun(ctx, run.ID, copied, failures)
+
+	if len(failures) > 0 {
+		return copied, fmt.Errorf("%w: %d of %d failed", ErrRecurrentIncomeCopiesFailed, len(failures), len(due))
+	}
+
+	return copied, nil
+}
+
+func (s *Store) copyRecurrentIncome(ctx context.Context, ri repo.RecurrentIncome, dates []time.Time) error {
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		for i, date := range dates {
+			income, err := tq.InsertIncome(ctx, repo.InsertIncomeParams{
+				UserID:            ri.UserID,
+				Source:            ri.Source,
+				Amount:            ri.Amount,
+				Currency:          ri.Currency,
+				Date:              date.Unix(),
+				RecurrentIncomeID: &ri.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = tq.CopyTaggings(ctx, repo.TaggableTypeRecurrentIncome, ri.ID, repo.TaggableTypeIncome, income.ID)
+			if err != nil {
+				return err
+			}
+
+			finished := i == len(dates)-1 && IsLastOccurrence(RecurrentIncomeRecurrence(ri), date)
+			_, err = tq.RecordRecurrentIncomeOccurrence(ctx, ri.ID, ri.UserID, date.Unix(), finished)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// validateRecurrentIncomeParams fills in the rule defaults before validating;
+// see Recurrence.withDefaults.
+func (s *Store) validateRecurrentIncomeParams(params *RecurrentIncomeParams) error {
+	rec := Recurrence{
+		Frequency: params.Frequency,
+		AnchorDay: params.AnchorDay,
+		StartsAt:  params.StartsAt,
+		EndsAt:    params.EndsAt,
+	}.withDefaults()
+
+	params.Frequency = rec.Frequency
+	params.AnchorDay = rec.AnchorDay
+	params.StartsAt = rec.StartsAt
+	params.EndsAt = rec.EndsAt
+
+	if err := s.ValidateStruct(*params); err != nil {
+		return err
+	}
+
+	if rec.endsBeforeStart() {
+		return ErrRecurrenceEndsBeforeStart
+	}
+
+	return nil
+}
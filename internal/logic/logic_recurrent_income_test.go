@@ -0,0 +1,210 @@
+package logic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateRecurrentIncome(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "recurrent_income_user_1",
+		Email:        "recurrent_income_user_1@example.com",
+		PasswordHash: []byte("recurrent_income_user_hash_1"),
+	})
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_recurrent_income_with_rule_defaults",
+			fn: func(t *testing.T) {
+				ri, err := s.Store.CreateRecurrentIncome(ctx, user.ID, logic.RecurrentIncomeParams{
+					Source: "Salary",
+					Amount: 300000,
+					Period: 1,
+				})
+				require.NoError(t, err)
+				require.Positive(t, ri.ID)
+				require.Equal(t, logic.RecurrenceMonthly, ri.Frequency)
+				require.Equal(t, uint(1), ri.AnchorDay)
+				require.Equal(t, "USD", ri.Currency)
+			},
+		},
+		{
+			name: "should_reject_an_end_before_the_start",
+			fn: func(t *testing.T) {
+				startsAt := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC).Unix()
+				endsAt := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+				_, err := s.Store.CreateRecurrentIncome(ctx, user.ID, logic.RecurrentIncomeParams{
+					Source:   "Backwards",
+					Amount:   100,
+					Period:   1,
+					StartsAt: &startsAt,
+					EndsAt:   &endsAt,
+				})
+				require.ErrorIs(t, err, logic.ErrRecurrenceEndsBeforeStart)
+			},
+		},
+		{
+			name: "should_fail_validation_for_invalid_params",
+			fn: func(t *testing.T) {
+				_, err := s.Store.CreateRecurrentIncome(ctx, user.ID, logic.RecurrentIncomeParams{Source: "x"})
+				require.ErrorIs(t, err, logic.ErrValidationFailed)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+func TestCopyDueRecurrentIncomes(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "recurrent_income_user_2",
+		Email:        "recurrent_income_user_2@example.com",
+		PasswordHash: []byte("recurrent_income_user_hash_2"),
+	})
+	january := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2027, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_catch_up_linked_and_tagged_incomes",
+			fn: func(t *testing.T) {
+				startsAt := january.Unix()
+				ri, err := s.Store.CreateRecurrentIncome(ctx, user.ID, logic.RecurrentIncomeParams{
+					Source:   "Catch up salary",
+					Amount:   150000,
+					Period:   1,
+					StartsAt: &startsAt,
+					Tags:     []string{"payroll"},
+				})
+				require.NoError(t, err)
+
+				copied, err := s.Store.CopyDueRecurrentIncomes(ctx, march)
+				require.NoError(t, err)
+				require.GreaterOrEqual(t, copied, 3)
+
+				incomes, err := s.Store.FindIncomes(ctx, repo.QueryOptions{
+					Filters: repo.Filters{
+						FilterFields: []repo.FilterField{
+							{Name: "user_id", Value: user.ID, Operator: "="},
+							{Name: "recurrent_income_id", Value: ri.ID, Operator: "="},
+						},
+						Connector: "AND",
+					},
+					Sorting: repo.Sorting{Field: "date", Order: "ASC"},
+				})
+				require.NoError(t, err)
+				require.Len(t, incomes, 3)
+				require.Equal(t, january.Unix(), incomes[0].Date)
+				require.Equal(t, march.Unix(), incomes[2].Date)
+
+				tags, err := s.Store.FindIncomeTags(ctx, incomes[0].ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, []string{"payroll"}, logic.ExtractTagNames(tags))
+
+				updated, err := s.Store.FindRecurrentIncome(ctx, ri.ID, user.ID)
+				require.NoError(t, err)
+				require.NotNil(t, updated.LastCopyCreatedAt)
+				require.Equal(t, march.Unix(), *updated.LastCopyCreatedAt)
+				require.Equal(t, uint(3), updated.OccurrenceCount)
+
+				copied, err = s.Store.CopyDueRecurrentIncomes(ctx, march)
+				require.NoError(t, err)
+				require.Zero(t, copied)
+			},
+		},
+		{
+			name: "should_archive_when_the_limit_is_reached",
+			fn: func(t *testing.T) {
+				startsAt := january.Unix()
+				ri, err := s.Store.CreateRecurrentIncome(ctx, user.ID, logic.RecurrentIncomeParams{
+					Source:          "Limited grant",
+					Amount:          50000,
+					Period:          1,
+					StartsAt:        &startsAt,
+					OccurrenceLimit: 2,
+				})
+				require.NoError(t, err)
+
+				_, err = s.Store.CopyDueRecurrentIncomes(ctx, march)
+				require.NoError(t, err)
+
+				updated, err := s.Store.FindRecurrentIncome(ctx, ri.ID, user.ID)
+				require.NoError(t, err)
+				require.Equal(t, uint(2), updated.OccurrenceCount)
+				require.NotNil(t, updated.ArchivedAt)
+
+				unarchived, err := s.Store.UnarchiveRecurrentIncome(ctx, ri.ID, user.ID)
+				require.NoError(t, err)
+				require.Nil(t, unarchived.ArchivedAt)
+				require.Zero(t, unarchived.OccurrenceCount)
+			},
+		},
+		{
+			name: "should_record_the_run",
+			fn: func(t *testing.T) {
+				copied, err := s.Store.CopyDueRecurrentIncomes(ctx, march)
+				require.NoError(t, err)
+
+				run, failures, err := s.Store.FindLatestTaskRun(ctx, logic.TaskCopyDueRecurrentIncomes)
+				require.NoError(t, err)
+				require.NotNil(t, run.FinishedAt)
+				require.Equal(t, copied, run.SucceededCount)
+				require.Zero(t, run.FailedCount)
+				require.Empty(t, failures)
+			},
+		},
+		{
+			name: "should_keep_generated_incomes_when_the_rule_is_deleted",
+			fn: func(t *testing.T) {
+				startsAt := march.Unix()
+				ri, err := s.Store.CreateRecurrentIncome(ctx, user.ID, logic.RecurrentIncomeParams{
+					Source:   "Short contract",
+					Amount:   70000,
+					Period:   1,
+					StartsAt: &startsAt,
+				})
+				require.NoError(t, err)
+
+				_, err = s.Store.CopyDueRecurrentIncomes(ctx, march)
+				require.NoError(t, err)
+
+				require.NoError(t, s.Store.DeleteRecurrentIncome(ctx, ri.ID, user.ID))
+
+				incomes, err := s.Store.FindIncomes(ctx, repo.QueryOptions{
+					Filters: repo.Filters{
+						FilterFields: []repo.FilterField{
+							{Name: "user_id", Value: user.ID, Operator: "="},
+							{Name: "source", Value: "Short contract", Operator: "="},
+						},
+						Connector: "AND",
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, incomes, 1)
+				require.Nil(t, incomes[0].RecurrentIncomeID)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
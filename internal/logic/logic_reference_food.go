@@ -0,0 +1,604 @@
+package logic
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// Sources reference foods are imported from: USDA FoodData Central and Open
+// Food Facts.
+const (
+	ReferenceSourceUSDA = "usda"
+	ReferenceSourceOFF  = "off"
+)
+
+const (
+	// referenceFoodBatchSize is how many rows each import transaction writes.
+	// Dumps run to millions of rows, so they are not written in one.
+	referenceFoodBatchSize = 500
+	// usdaStagingBatchSize is how many CSV rows each transaction staging a
+	// USDA file writes. Staged rows are small, so it runs larger.
+	usdaStagingBatchSize     = 5000
+	referenceFoodSearchLimit = 20
+	// referenceFoodNameMax matches the FoodParams name limit, so any row can
+	// be copied into a library.
+	referenceFoodNameMax = 100
+	foodSearchLimit      = 20
+)
+
+// FoodData Central nutrient ids, from its nutrient.csv. Energy is given under
+// 1008 by most datasets; Foundation Foods use the Atwater ids instead.
+const (
+	usdaNutrientProtein      = 1003
+	usdaNutrientFat          = 1004
+	usdaNutrientCarbs        = 1005
+	usdaNutrientEnergy       = 1008
+	usdaNutrientFiber        = 1079
+	usdaNutrientSodiumMG     = 1093
+	usdaNutrientSaturatedFat = 1258
+	usdaNutrientAtwaterKcal  = 2047
+	usdaNutrientAtwaterSpec  = 2048
+)
+
+// BarcodeMatch is what a barcode lookup found: one of the user's foods, or
+// failing that a reference food, or neither. Barcode is the normalized code.
+type BarcodeMatch struct {
+	Barcode       string
+	Food          *repo.Food
+	ReferenceFood *repo.ReferenceFood
+}
+
+// USDADump holds the files of a FoodData Central CSV download the import
+// reads. BrandedFoods, its branded_food.csv, is optional; it adds brands and
+// barcodes to the branded foods.
+type USDADump struct {
+	Foods        io.Reader
+	Nutrients    io.Reader
+	BrandedFoods io.Reader
+}
+
+// ReferenceFoodImport counts the rows an import stored and the rows it left
+// out for lacking a name or an energy value.
+type ReferenceFoodImport struct {
+	Imported int
+	Skipped  int
+}
+
+// FoodSearch is what a search from the macro entry form finds: the user's
+// own foods, then reference foods that can be copied into the library.
+type FoodSearch struct {
+	Foods          []repo.Food
+	ReferenceFoods []repo.ReferenceFood
+}
+
+// ImportUSDAFoods loads a FoodData Central CSV download into the reference
+// catalogue. Its files are matched on fdc_id, and every amount is per 100 g.
+// The files are streamed into staging tables and joined there, so memory does
+// not grow with the download. The joined foods are then stored in batches, so
+// an error leaves the batches before it in place and a re-run picks up from
+// there.
+func (s *Store) ImportUSDAFoods(ctx context.Context, dump USDADump) (ReferenceFoodImport, error) {
+	if err := s.clearUSDAImport(ctx); err != nil {
+		return ReferenceFoodImport{}, err
+	}
+	defer func() {
+		if err := s.clearUSDAImport(context.WithoutCancel(ctx)); err != nil {
+			s.app.Logger.Errorf("failed to clear the USDA import staging tables: %v", err)
+		}
+	}()
+
+	if err := s.stageUSDAFile(ctx, dump.Foods, stageUSDAFood, "fdc_id", "description"); err != nil {
+		return ReferenceFoodImport{}, err
+	}
+
+	err := s.stageUSDAFile(ctx, dump.Nutrients, stageUSDANutrient, "fdc_id", "nutrient_id", "amount")
+	if err != nil {
+		return ReferenceFoodImport{}, err
+	}
+
+	if dump.BrandedFoods != nil {
+		err := s.stageUSDAFile(ctx, dump.BrandedFoods, stageUSDABranding, "fdc_id", "brand_owner", "gtin_upc")
+		if err != nil {
+			return ReferenceFoodImport{}, err
+		}
+	}
+
+	batch := s.newReferenceFoodBatch()
+	for afterID := 0; ; {
+		foods, err := s.queries.SelectUSDAImportFoods(ctx, afterID, referenceFoodBatchSize)
+		if err != nil {
+			return batch.result, err
+		}
+		if len(foods) == 0 {
+			break
+		}
+
+		for _, f := range foods {
+			amounts := usdaAmounts(f.Nutrients)
+			kcal, ok := amounts.kcal()
+			if !ok {
+				batch.result.Skipped++
+
+				continue
+			}
+
+			err := batch.add(ctx, repo.UpsertReferenceFoodParams{
+				Source:        ReferenceSourceUSDA,
+				SourceID:      f.FDCID,
+				Name:          f.Name,
+				Brand:         f.Brand,
+				Kcal:          kcal,
+				ProteinG:      amounts[usdaNutrientProtein],
+				CarbsG:        amounts[usdaNutrientCarbs],
+				FatG:          amounts[usdaNutrientFat],
+				FiberG:        amounts[usdaNutrientFiber],
+				SodiumG:       amounts[usdaNutrientSodiumMG] / 1000,
+				SaturatedFatG: amounts[usdaNutrientSaturatedFat],
+				Barcode:       f.Barcode,
+			})
+			if err != nil {
+				return batch.result, err
+			}
+		}
+
+		afterID = foods[len(foods)-1].ID
+	}
+
+	return batch.finish(ctx)
+}
+
+// ImportOpenFoodFacts loads an Open Food Facts JSONL dump, one product per
+// line, into the reference catalogue. Nutrients come from the product's
+// per-100 g values; products without a name or an energy value are skipped,
+// as are lines that are not a product.
+func (s *Store) ImportOpenFoodFacts(ctx context.Context, r io.Reader) (ReferenceFoodImport, error) {
+	reader := bufio.NewReaderSize(r, 1<<20)
+	batch := s.newReferenceFoodBatch()
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return batch.result, fmt.Errorf("%w: %w", ErrReferenceFoodsFile, readErr)
+		}
+
+		if len(strings.TrimSpace(string(line))) > 0 {
+			params, ok := parseOpenFoodFactsProduct(line)
+			if !ok {
+				batch.result.Skipped++
+			} else if err := batch.add(ctx, params); err != nil {
+				return batch.result, err
+			}
+		}
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	return batch.finish(ctx)
+}
+
+// SearchFoods looks a term up in the user's foods and in the reference
+// catalogue. A blank term finds nothing.
+func (s *Store) SearchFoods(ctx context.Context, userID int, term string) (FoodSearch, error) {
+	var search FoodSearch
+
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return search, nil
+	}
+
+	foods, err := s.queries.SelectFoods(ctx, repo.QueryOptions{
+		Filters: repo.Filters{
+			FilterFields: []repo.FilterField{
+				{Name: "user_id", Value: userID, Operator: "="},
+				repo.FoodNameFilter(term),
+			},
+			Connector: "AND",
+		},
+		Sorting:    repo.Sorting{Field: "name", Order: "ASC"},
+		Pagination: repo.Pagination{PerPage: foodSearchLimit, Page: 1},
+	})
+	if err != nil {
+		return search, err
+	}
+
+	references, err := s.queries.SearchReferenceFoods(ctx, term, referenceFoodSearchLimit)
+	if err != nil {
+		return search, err
+	}
+
+	search.Foods = foods
+	search.ReferenceFoods = references
+
+	return search, nil
+}
+
+// LookupBarcode finds the food a scanned or typed barcode belongs to. The
+// user's own foods come first, since they may have corrected a product's
+// values; finding nothing is not an error.
+func (s *Store) LookupBarcode(ctx context.Context, userID int, code string) (BarcodeMatch, error) {
+	barcode, err := NormalizeBarcode(code)
+	if err != nil {
+		return BarcodeMatch{}, err
+	}
+
+	match := BarcodeMatch{Barcode: barcode}
+
+	food, err := s.queries.SelectFoodByBarcode(ctx, userID, barcode)
+	if err == nil {
+		match.Food = &food
+
+		return match, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return match, err
+	}
+
+	ref, err := s.queries.SelectReferenceFoodByBarcode(ctx, barcode)
+	if err == nil {
+		match.ReferenceFood = &ref
+
+		return match, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return match, err
+	}
+
+	return match, nil
+}
+
+// CopyReferenceFood adds a reference food to the user's library as an
+// ordinary food per 100 g, named after its brand too when that fits. The
+// barcode comes along, so scanning the product again finds the copy.
+func (s *Store) CopyReferenceFood(ctx context.Context, userID, id int) (repo.Food, error) {
+	ref, err := s.queries.SelectReferenceFood(ctx, id)
+	if err != nil {
+		return repo.Food{}, err
+	}
+
+	name := ref.Name
+	if branded := fmt.Sprintf("%s (%s)", ref.Name, ref.Brand); ref.Brand != "" &&
+		utf8.RuneCountInString(branded) <= referenceFoodNameMax {
+		name = branded
+	}
+
+	return s.CreateFood(ctx, userID, FoodParams{
+		Name:          name,
+		Kcal:          ref.Kcal,
+		ProteinG:      ref.ProteinG,
+		CarbsG:        ref.CarbsG,
+		FatG:          ref.FatG,
+		FiberG:        ref.FiberG,
+		SodiumG:       ref.SodiumG,
+		SaturatedFatG: ref.SaturatedFatG,
+		BaseAmount:    DefaultFoodBaseAmount,
+		BaseUnit:      UnitGram,
+		Barcode:       barcodeOrEmpty(ref.Barcode),
+	})
+}
+
+type referenceFoodBatch struct {
+	store  *Store
+	rows   []repo.UpsertReferenceFoodParams
+	result ReferenceFoodImport
+}
+
+func (s *Store) newReferenceFoodBatch() *referenceFoodBatch {
+	return &referenceFoodBatch{
+		store: s,
+		rows:  make([]repo.UpsertReferenceFoodParams, 0, referenceFoodBatchSize),
+	}
+}
+
+// add queues a row, skipping it when its name or values would not make a
+// usable food, and writes the queue once it is full.
+func (b *referenceFoodBatch) add(ctx context.Context, params repo.UpsertReferenceFoodParams) error {
+	params.Name = truncateRunes(strings.TrimSpace(params.Name), referenceFoodNameMax)
+	params.Brand = strings.TrimSpace(params.Brand)
+	if params.Name == "" || !validReferenceValues(params) {
+		b.result.Skipped++
+
+		return nil
+	}
+
+	b.rows = append(b.rows, params)
+	if len(b.rows) < referenceFoodBatchSize {
+		return nil
+	}
+
+	return b.flush(ctx)
+}
+
+func (b *referenceFoodBatch) flush(ctx context.Context) error {
+	if len(b.rows) == 0 {
+		return nil
+	}
+
+	err := b.store.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		for _, row := range b.rows {
+			if err := tq.UpsertReferenceFood(ctx, row); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b.result.Imported += len(b.rows)
+	b.rows = b.rows[:0]
+
+	return nil
+}
+
+func (b *referenceFoodBatch) finish(ctx context.Context) (ReferenceFoodImport, error) {
+	err := b.flush(ctx)
+
+	return b.result, err
+}
+
+func validReferenceValues(params repo.UpsertReferenceFoodParams) bool {
+	for _, v := range []float64{
+		params.Kcal,
+		params.ProteinG,
+		params.CarbsG,
+		params.FatG,
+		params.FiberG,
+		params.SodiumG,
+		params.SaturatedFatG,
+	} {
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// usdaAmounts holds a food's nutrient amounts by nutrient id.
+type usdaAmounts map[int]float64
+
+func (a usdaAmounts) kcal() (float64, bool) {
+	for _, id := range []int{usdaNutrientEnergy, usdaNutrientAtwaterKcal, usdaNutrientAtwaterSpec} {
+		if v, ok := a[id]; ok {
+			return v, true
+		}
+	}
+
+	return 0, false
+}
+
+// usdaStageFunc writes one record of a USDA file, its columns located by
+// name, to the staging tables.
+type usdaStageFunc func(ctx context.Context, tq *repo.TxQueries, record []string, columns map[string]int) error
+
+// stageUSDAFile copies a file of the download into the staging tables in
+// transactions of usdaStagingBatchSize rows, holding no more than one row in
+// memory however large the file is.
+func (s *Store) stageUSDAFile(ctx context.Context, r io.Reader, stage usdaStageFunc, names ...string) error {
+	reader, columns, err := newUSDAReader(r, names...)
+	if err != nil {
+		return err
+	}
+
+	for done := false; !done; {
+		err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+			for range usdaStagingBatchSize {
+				record, err := reader.Read()
+				if errors.Is(err, io.EOF) {
+					done = true
+
+					return nil
+				}
+				if err != nil {
+					return fmt.Errorf("%w: %w", ErrReferenceFoodsFile, err)
+				}
+
+				if err := stage(ctx, tq, record, columns); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) clearUSDAImport(ctx context.Context) error {
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		return tq.ClearUSDAImport(ctx)
+	})
+}
+
+func stageUSDAFood(ctx context.Context, tq *repo.TxQueries, record []string, columns map[string]int) error {
+	id := strings.TrimSpace(record[columns["fdc_id"]])
+
+	return tq.StageUSDAImportFood(ctx, id, record[columns["description"]])
+}
+
+// stageUSDANutrient stages the nutrients the food library tracks. Rows for
+// other nutrients, or with an amount that does not parse, are passed over.
+func stageUSDANutrient(ctx context.Context, tq *repo.TxQueries, record []string, columns map[string]int) error {
+	nutrientID, err := strconv.Atoi(strings.TrimSpace(record[columns["nutrient_id"]]))
+	if err != nil || !usdaTracked(nutrientID) {
+		return nil
+	}
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(record[columns["amount"]]), 64)
+	if err != nil {
+		return nil
+	}
+
+	return tq.StageUSDAImportNutrient(ctx, strings.TrimSpace(record[columns["fdc_id"]]), nutrientID, amount)
+}
+
+// stageUSDABranding stages a branded food's brand owner and barcode. A GTIN
+// that does not check out is dropped, keeping the rest of the row.
+func stageUSDABranding(ctx context.Context, tq *repo.TxQueries, record []string, columns map[string]int) error {
+	var barcode *string
+	if normalized, err := NormalizeBarcode(record[columns["gtin_upc"]]); err == nil {
+		barcode = &normalized
+	}
+
+	id := strings.TrimSpace(record[columns["fdc_id"]])
+
+	return tq.StageUSDAImportBranding(ctx, id, record[columns["brand_owner"]], barcode)
+}
+
+func usdaTracked(nutrientID int) bool {
+	switch nutrientID {
+	case usdaNutrientProtein, usdaNutrientFat, usdaNutrientCarbs, usdaNutrientEnergy, usdaNutrientFiber,
+		usdaNutrientSodiumMG, usdaNutrientSaturatedFat, usdaNutrientAtwaterKcal, usdaNutrientAtwaterSpec:
+		return true
+	}
+
+	return false
+}
+
+// newUSDAReader reads the header and returns the position of each named
+// column. Columns are matched by name, so their order and any extra ones do
+// not matter.
+func newUSDAReader(r io.Reader, names ...string) (*csv.Reader, map[string]int, error) {
+	// A byte order mark is stripped before parsing: ahead of a quoted header it
+	// would otherwise read as a bare quote.
+	buffered := bufio.NewReader(r)
+	if bom, err := buffered.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+		if _, err := buffered.Discard(len(utf8BOM)); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrReferenceFoodsFile, err)
+		}
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrReferenceFoodsHeader, names[0])
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrReferenceFoodsFile, err)
+	}
+
+	columns := map[string]int{}
+	for _, name := range names {
+		i := slices.IndexFunc(header, func(h string) bool {
+			return strings.EqualFold(strings.TrimSpace(h), name)
+		})
+		if i < 0 {
+			return nil, nil, fmt.Errorf("%w: %s", ErrReferenceFoodsHeader, name)
+		}
+		columns[name] = i
+	}
+
+	return reader, columns, nil
+}
+
+// truncateRunes cuts s to at most n characters without splitting one.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return strings.TrimSpace(string([]rune(s)[:n]))
+}
+
+// offProduct is the part of an Open Food Facts product the import reads.
+type offProduct struct {
+	Code          string         `json:"code"`
+	ProductName   string         `json:"product_name"`
+	ProductNameEN string         `json:"product_name_en"`
+	Brands        string         `json:"brands"`
+	Nutriments    map[string]any `json:"nutriments"`
+}
+
+const utf8BOM = "\ufeff"
+
+// kjPerKcal converts the kJ energy some products only give into kcal.
+const kjPerKcal = 4.184
+
+func parseOpenFoodFactsProduct(line []byte) (repo.UpsertReferenceFoodParams, bool) {
+	var product offProduct
+	if err := json.Unmarshal(line, &product); err != nil {
+		return repo.UpsertReferenceFoodParams{}, false
+	}
+
+	code := strings.TrimSpace(product.Code)
+	name := product.ProductName
+	if strings.TrimSpace(name) == "" {
+		name = product.ProductNameEN
+	}
+	if code == "" {
+		return repo.UpsertReferenceFoodParams{}, false
+	}
+
+	kcal, ok := offNutriment(product.Nutriments, "energy-kcal_100g")
+	if !ok {
+		kj, hasKJ := offNutriment(product.Nutriments, "energy-kj_100g")
+		if !hasKJ {
+			return repo.UpsertReferenceFoodParams{}, false
+		}
+		kcal = kj / kjPerKcal
+	}
+
+	brand, _, _ := strings.Cut(product.Brands, ",")
+	var barcode *string
+	if normalized, err := NormalizeBarcode(code); err == nil {
+		barcode = &normalized
+	}
+	nutriment := func(key string) float64 {
+		v, _ := offNutriment(product.Nutriments, key)
+
+		return v
+	}
+
+	return repo.UpsertReferenceFoodParams{
+		Source:        ReferenceSourceOFF,
+		SourceID:      code,
+		Name:          name,
+		Brand:         brand,
+		Kcal:          kcal,
+		ProteinG:      nutriment("proteins_100g"),
+		CarbsG:        nutriment("carbohydrates_100g"),
+		FatG:          nutriment("fat_100g"),
+		FiberG:        nutriment("fiber_100g"),
+		SodiumG:       nutriment("sodium_100g"),
+		SaturatedFatG: nutriment("saturated-fat_100g"),
+		Barcode:       barcode,
+	}, true
+}
+
+// offNutriment reads a nutriment, which dumps give as a number or, in older
+// products, as a string.
+func offNutriment(nutriments map[string]any, key string) (float64, bool) {
+	switch v := nutriments[key].(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+
+		return f, err == nil
+	}
+
+	return 0, false
+}
@@ -0,0 +1,186 @@
+package logic_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportReferenceFoods(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "reference_food_user_1",
+		Email:        "reference_food_user_1@example.com",
+		PasswordHash: []byte("reference_food_hash_1"),
+	})
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_import_usda_foods_and_update_them_on_a_second_run",
+			fn: func(t *testing.T) {
+				foods := "\ufeff\"fdc_id\",\"data_type\",\"description\"\n" +
+					"\"1001\",\"sr_legacy_food\",\"Usdatest lentils, boiled\"\n" +
+					"\"1002\",\"foundation_food\",\"Usdatest kale, raw\"\n" +
+					"\"1003\",\"sr_legacy_food\",\"Usdatest water, no energy\"\n"
+				nutrients := "\"id\",\"fdc_id\",\"nutrient_id\",\"amount\"\n" +
+					"\"1\",\"1001\",\"1008\",\"116\"\n" +
+					"\"2\",\"1001\",\"1003\",\"9.02\"\n" +
+					"\"3\",\"1001\",\"1093\",\"2\"\n" +
+					"\"4\",\"1002\",\"2047\",\"43\"\n" +
+					"\"5\",\"1003\",\"1003\",\"0\"\n" +
+					"\"6\",\"9999\",\"1008\",\"500\"\n"
+
+				result, err := s.Store.ImportUSDAFoods(ctx, logic.USDADump{
+					Foods:     strings.NewReader(foods),
+					Nutrients: strings.NewReader(nutrients),
+				})
+				require.NoError(t, err)
+				require.Equal(t, logic.ReferenceFoodImport{Imported: 2, Skipped: 1}, result)
+
+				staged, err := s.Queries.SelectUSDAImportFoods(ctx, 0, 10)
+				require.NoError(t, err)
+				require.Empty(t, staged, "the staging tables are emptied after an import")
+
+				nutrients = strings.Replace(nutrients, "\"116\"", "\"120\"", 1)
+				_, err = s.Store.ImportUSDAFoods(ctx, logic.USDADump{
+					Foods:     strings.NewReader(foods),
+					Nutrients: strings.NewReader(nutrients),
+				})
+				require.NoError(t, err)
+
+				search, err := s.Store.SearchFoods(ctx, user.ID, "usdatest")
+				require.NoError(t, err)
+				require.Len(t, search.ReferenceFoods, 2)
+				kale, lentils := search.ReferenceFoods[0], search.ReferenceFoods[1]
+				require.Equal(t, "Usdatest kale, raw", kale.Name)
+				require.InDelta(t, 43.0, kale.Kcal, 0.001)
+				require.Equal(t, logic.ReferenceSourceUSDA, lentils.Source)
+				require.Equal(t, "1001", lentils.SourceID)
+				require.InDelta(t, 120.0, lentils.Kcal, 0.001)
+				require.InDelta(t, 9.02, lentils.ProteinG, 0.001)
+				require.InDelta(t, 0.002, lentils.SodiumG, 0.00001)
+			},
+		},
+		{
+			name: "should_take_brands_and_barcodes_from_usda_branded_foods",
+			fn: func(t *testing.T) {
+				result, err := s.Store.ImportUSDAFoods(ctx, logic.USDADump{
+					Foods: strings.NewReader("fdc_id,description\n" +
+						"2001,Brandtest cola\n2002,Brandtest crisps\n"),
+					Nutrients: strings.NewReader("fdc_id,nutrient_id,amount\n" +
+						"2001,1008,42\n2002,1008,536\n"),
+					BrandedFoods: strings.NewReader("fdc_id,brand_owner,gtin_upc\n" +
+						"2001,Fizz Inc.,049000028911\n2002,Crunch Ltd.,12345\n"),
+				})
+				require.NoError(t, err)
+				require.Equal(t, 2, result.Imported)
+
+				search, err := s.Store.SearchFoods(ctx, user.ID, "brandtest")
+				require.NoError(t, err)
+				require.Len(t, search.ReferenceFoods, 2)
+				cola, crisps := search.ReferenceFoods[0], search.ReferenceFoods[1]
+				require.Equal(t, "Fizz Inc.", cola.Brand)
+				require.NotNil(t, cola.Barcode)
+				require.Equal(t, "0049000028911", *cola.Barcode)
+				require.Equal(t, "Crunch Ltd.", crisps.Brand)
+				require.Nil(t, crisps.Barcode)
+			},
+		},
+		{
+			name: "should_reject_a_usda_file_missing_a_column",
+			fn: func(t *testing.T) {
+				_, err := s.Store.ImportUSDAFoods(ctx, logic.USDADump{
+					Foods:     strings.NewReader("fdc_id,name\n1,x\n"),
+					Nutrients: strings.NewReader("fdc_id,nutrient_id,amount\n"),
+				})
+				require.ErrorIs(t, err, logic.ErrReferenceFoodsHeader)
+			},
+		},
+		{
+			name: "should_import_open_food_facts_products",
+			fn: func(t *testing.T) {
+				dump := `{"code":"300001","product_name":"Offtest granola","brands":"Acme, Acme Foods",` +
+					`"nutriments":{"energy-kcal_100g":450,"proteins_100g":"10.5","fat_100g":18,"sodium_100g":0.2}}
+{"code":"300002","product_name":"","product_name_en":"Offtest oat milk",` +
+					`"nutriments":{"energy-kj_100g":209.2,"carbohydrates_100g":6.6}}
+{"code":"300003","product_name":"Offtest no energy","nutriments":{"proteins_100g":3}}
+{"code":"300004","nutriments":{"energy-kcal_100g":100}}
+{"code":"300005","product_name":"Offtest negative","nutriments":{"energy-kcal_100g":-5}}
+not json
+
+{"code":"300006","product_name":"Offtest last line","nutriments":{"energy-kcal_100g":1}}`
+
+				result, err := s.Store.ImportOpenFoodFacts(ctx, strings.NewReader(dump))
+				require.NoError(t, err)
+				require.Equal(t, logic.ReferenceFoodImport{Imported: 3, Skipped: 4}, result)
+
+				search, err := s.Store.SearchFoods(ctx, user.ID, "offtest")
+				require.NoError(t, err)
+				require.Len(t, search.ReferenceFoods, 3)
+				granola, milk := search.ReferenceFoods[0], search.ReferenceFoods[2]
+				require.Equal(t, "Acme", granola.Brand)
+				require.InDelta(t, 10.5, granola.ProteinG, 0.001)
+				require.Equal(t, "Offtest oat milk", milk.Name)
+				require.InDelta(t, 50.0, milk.Kcal, 0.001)
+
+				search, err = s.Store.SearchFoods(ctx, user.ID, "ACME")
+				require.NoError(t, err)
+				require.Len(t, search.ReferenceFoods, 1)
+
+				search, err = s.Store.SearchFoods(ctx, user.ID, "granola")
+				require.NoError(t, err)
+				require.Empty(t, search.ReferenceFoods, "names match by their start only")
+			},
+		},
+		{
+			name: "should_search_own_foods_and_copy_a_reference_food",
+			fn: func(t *testing.T) {
+				s.CreateFood(t, user.ID, logic.FoodParams{Name: "Copytest porridge", Kcal: 70})
+				_, err := s.Store.ImportOpenFoodFacts(ctx, strings.NewReader(
+					`{"code":"400001","product_name":"Copytest oats","brands":"Mill",`+
+						`"nutriments":{"energy-kcal_100g":380,"carbohydrates_100g":60}}`,
+				))
+				require.NoError(t, err)
+
+				search, err := s.Store.SearchFoods(ctx, user.ID, "copytest")
+				require.NoError(t, err)
+				require.Len(t, search.Foods, 1)
+				require.Equal(t, "Copytest porridge", search.Foods[0].Name)
+				require.Len(t, search.ReferenceFoods, 1)
+
+				food, err := s.Store.CopyReferenceFood(ctx, user.ID, search.ReferenceFoods[0].ID)
+				require.NoError(t, err)
+				require.Equal(t, user.ID, food.UserID)
+				require.Equal(t, "Copytest oats (Mill)", food.Name)
+				require.InDelta(t, 380.0, food.Kcal, 0.001)
+				require.InDelta(t, 60.0, food.CarbsG, 0.001)
+				require.InDelta(t, 100.0, food.BaseAmount, 0.001)
+				require.Equal(t, logic.UnitGram, food.BaseUnit)
+
+				_, err = s.Store.CopyReferenceFood(ctx, user.ID, search.ReferenceFoods[0].ID)
+				require.ErrorIs(t, err, logic.ErrFoodNameTaken)
+			},
+		},
+		{
+			name: "should_find_nothing_for_a_blank_term",
+			fn: func(t *testing.T) {
+				search, err := s.Store.SearchFoods(ctx, user.ID, "  ")
+				require.NoError(t, err)
+				require.Empty(t, search.Foods)
+				require.Empty(t, search.ReferenceFoods)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
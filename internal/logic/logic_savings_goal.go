@@ -0,0 +1,263 @@
+package logic
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// SavingsGoalParams holds what the goal form edits. TargetAmount is in cents
+// of the home currency and TargetDate the start of the target day.
+type SavingsGoalParams struct {
+	Name         string `validate:"required,min=2,max=50"`
+	TargetAmount uint64 `validate:"gt=0"`
+	TargetDate   int64  `validate:"gt=0"`
+}
+
+type SavingsContributionParams struct {
+	Amount uint64 `validate:"gt=0"`
+	Date   int64  `validate:"gt=0"`
+	Note   string `validate:"max=100"`
+}
+
+// SavingsGoalProgress is a goal measured on a given day. RequiredMonthly is
+// what each month left must add to reach the target on time; with no month
+// left, it is the whole remainder. ProjectedAt extrapolates the pace of the
+// contributions so far, and is nil until there is one; once the goal is
+// reached it is the day of the contribution that reached it.
+type SavingsGoalProgress struct {
+	Goal            repo.SavingsGoal
+	Contributions   []repo.SavingsContribution
+	Saved           uint64
+	Remaining       uint64
+	MonthsLeft      int
+	RequiredMonthly uint64
+	ProjectedAt     *int64
+	Complete        bool
+	Overdue         bool
+}
+
+const (
+	// savingsMinPace is the shortest span a pace is measured over. A first
+	// contribution made today would otherwise project the goal as good as
+	// done.
+	savingsMinPace = 30 * secondsPerDay
+	// savingsMaxProjection is how far ahead a projection is still worth
+	// showing. Past it the pace is a trickle and ProjectedAt stays nil.
+	savingsMaxProjection = 100 * 365 * secondsPerDay
+)
+
+// FindSavingsGoals returns every goal measured as of now, nearest deadline
+// first.
+func (s *Store) FindSavingsGoals(ctx context.Context, userID int, now time.Time) ([]SavingsGoalProgress, error) {
+	goals, err := s.queries.SelectSavingsGoalsByUser(ctx, userID)
+	if err != nil || len(goals) == 0 {
+		return nil, err
+	}
+
+	contributions, err := s.queries.SelectSavingsContributionsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byGoalID := make(map[int][]repo.SavingsContribution, len(goals))
+	for _, c := range contributions {
+		byGoalID[c.SavingsGoalID] = append(byGoalID[c.SavingsGoalID], c)
+	}
+
+	progress := make([]SavingsGoalProgress, 0, len(goals))
+	for _, g := range goals {
+		progress = append(progress, measureSavingsGoal(g, byGoalID[g.ID], now))
+	}
+
+	return progress, nil
+}
+
+func (s *Store) FindSavingsGoal(ctx context.Context, id, userID int) (repo.SavingsGoal, error) {
+	return s.queries.SelectSavingsGoal(ctx, id, userID)
+}
+
+// FindSavingsGoalProgress measures one goal as of now, its contributions
+// oldest first.
+func (s *Store) FindSavingsGoalProgress(
+	ctx context.Context,
+	goal repo.SavingsGoal,
+	now time.Time,
+) (SavingsGoalProgress, error) {
+	contributions, err := s.queries.SelectSavingsContributionsByGoal(ctx, goal.ID, goal.UserID)
+	if err != nil {
+		return SavingsGoalProgress{}, err
+	}
+
+	return measureSavingsGoal(goal, contributions, now), nil
+}
+
+func (s *Store) CreateSavingsGoal(
+	ctx context.Context,
+	userID int,
+	params SavingsGoalParams,
+) (repo.SavingsGoal, error) {
+	if err := s.validateSavingsGoalParams(&params); err != nil {
+		return repo.SavingsGoal{}, err
+	}
+
+	goal, err := s.queries.InsertSavingsGoal(ctx, repo.InsertSavingsGoalParams{
+		UserID:       userID,
+		Name:         params.Name,
+		TargetAmount: params.TargetAmount,
+		TargetDate:   params.TargetDate,
+	})
+	if repo.IsUniqueViolation(err) {
+		return goal, ErrSavingsGoalNameTaken
+	}
+
+	return goal, err
+}
+
+func (s *Store) UpdateSavingsGoal(
+	ctx context.Context,
+	id, userID int,
+	params SavingsGoalParams,
+) (repo.SavingsGoal, error) {
+	if err := s.validateSavingsGoalParams(&params); err != nil {
+		return repo.SavingsGoal{}, err
+	}
+
+	goal, err := s.queries.UpdateSavingsGoal(ctx, repo.UpdateSavingsGoalParams{
+		ID:           id,
+		UserID:       userID,
+		Name:         params.Name,
+		TargetAmount: params.TargetAmount,
+		TargetDate:   params.TargetDate,
+	})
+	if repo.IsUniqueViolation(err) {
+		return goal, ErrSavingsGoalNameTaken
+	}
+
+	return goal, err
+}
+
+// DeleteSavingsGoal removes the goal and its contributions.
+func (s *Store) DeleteSavingsGoal(ctx context.Context, id, userID int) error {
+	_, err := s.queries.DeleteSavingsGoal(ctx, id, userID)
+
+	return err
+}
+
+func (s *Store) DeleteAllSavingsGoals(ctx context.Context, userID int) error {
+	return s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		return tq.DeleteAllSavingsGoalsByUser(ctx, userID)
+	})
+}
+
+// AddSavingsContribution records money set aside toward a goal. It returns
+// sql.ErrNoRows when the user has no such goal.
+func (s *Store) AddSavingsContribution(
+	ctx context.Context,
+	goalID, userID int,
+	params SavingsContributionParams,
+) (repo.SavingsContribution, error) {
+	var contribution repo.SavingsContribution
+
+	params.Note = strings.TrimSpace(params.Note)
+	if err := s.ValidateStruct(params); err != nil {
+		return contribution, err
+	}
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		if _, err := tq.SelectSavingsGoal(ctx, goalID, userID); err != nil {
+			return err
+		}
+
+		var err error
+		contribution, err = tq.InsertSavingsContribution(ctx, repo.InsertSavingsContributionParams{
+			UserID:        userID,
+			SavingsGoalID: goalID,
+			Amount:        params.Amount,
+			Date:          params.Date,
+			Note:          params.Note,
+		})
+
+		return err
+	})
+
+	return contribution, err
+}
+
+// DeleteSavingsContribution removes one contribution from a goal. It returns
+// sql.ErrNoRows when the goal has no such contribution.
+func (s *Store) DeleteSavingsContribution(ctx context.Context, id, goalID, userID int) error {
+	_, err := s.queries.DeleteSavingsContribution(ctx, id, goalID, userID)
+
+	return err
+}
+
+// measureSavingsGoal works out a goal's progress from its contributions,
+// which must be oldest first.
+func measureSavingsGoal(
+	goal repo.SavingsGoal,
+	contributions []repo.SavingsContribution,
+	now time.Time,
+) SavingsGoalProgress {
+	p := SavingsGoalProgress{Goal: goal, Contributions: contributions}
+
+	for _, c := range contributions {
+		p.Saved += c.Amount
+		if !p.Complete && p.Saved >= goal.TargetAmount {
+			p.Complete = true
+			reachedAt := c.Date
+			p.ProjectedAt = &reachedAt
+		}
+	}
+	if p.Complete {
+		return p
+	}
+
+	p.Remaining = goal.TargetAmount - p.Saved
+	p.MonthsLeft = savingsMonthsLeft(now, time.Unix(goal.TargetDate, 0).UTC())
+	p.Overdue = p.MonthsLeft == 0
+
+	p.RequiredMonthly = p.Remaining
+	if p.MonthsLeft > 0 {
+		p.RequiredMonthly = (p.Remaining + uint64(p.MonthsLeft) - 1) / uint64(p.MonthsLeft)
+	}
+
+	if len(contributions) > 0 {
+		// Saved over elapsed is the pace per second; the remainder at that pace
+		// lands Remaining/pace seconds from now. Floats keep the product of a
+		// large remainder and a long span from overflowing.
+		elapsed := max(now.Unix()-contributions[0].Date, savingsMinPace)
+		seconds := float64(p.Remaining) * float64(elapsed) / float64(p.Saved)
+		if seconds <= savingsMaxProjection {
+			projected := time.Unix(now.Unix()+int64(seconds), 0).UTC()
+			day := time.Date(projected.Year(), projected.Month(), projected.Day(), 0, 0, 0, 0, time.UTC).Unix()
+			p.ProjectedAt = &day
+		}
+	}
+
+	return p
+}
+
+// savingsMonthsLeft counts the whole months from now to target, rounding a
+// part month up so a goal due in ten days still has one month to go. A target
+// already past has none.
+func savingsMonthsLeft(now, target time.Time) int {
+	if !target.After(now) {
+		return 0
+	}
+
+	months := (target.Year()-now.Year())*12 + int(target.Month()) - int(now.Month())
+	if target.Day() > now.Day() {
+		months++
+	}
+
+	return max(months, 1)
+}
+
+func (s *Store) validateSavingsGoalParams(params *SavingsGoalParams) error {
+	params.Name = strings.TrimSpace(params.Name)
+
+	return s.ValidateStruct(*params)
+}
@@ -0,0 +1,212 @@
+package logic_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavingsGoals(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+	user := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "savings_goal_user_1",
+		Email:        "savings_goal_user_1@example.com",
+		PasswordHash: []byte("savings_goal_hash_1"),
+	})
+	other := s.CreateUser(t, repo.InsertUserParams{
+		Username:     "savings_goal_user_2",
+		Email:        "savings_goal_user_2@example.com",
+		PasswordHash: []byte("savings_goal_hash_2"),
+	})
+
+	now := time.Date(2026, time.January, 15, 9, 30, 0, 0, time.UTC)
+	day := func(year int, month time.Month, d int) int64 {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC).Unix()
+	}
+
+	createGoal := func(t *testing.T, name string, target uint64, targetDate int64) repo.SavingsGoal {
+		t.Helper()
+
+		goal, err := s.Store.CreateSavingsGoal(ctx, user.ID, logic.SavingsGoalParams{
+			Name:         name,
+			TargetAmount: target,
+			TargetDate:   targetDate,
+		})
+		require.NoError(t, err)
+
+		return goal
+	}
+	contribute := func(t *testing.T, goalID int, amount uint64, date int64) {
+		t.Helper()
+
+		_, err := s.Store.AddSavingsContribution(ctx, goalID, user.ID, logic.SavingsContributionParams{
+			Amount: amount,
+			Date:   date,
+		})
+		require.NoError(t, err)
+	}
+	progressOf := func(t *testing.T, goal repo.SavingsGoal) logic.SavingsGoalProgress {
+		t.Helper()
+
+		progress, err := s.Store.FindSavingsGoalProgress(ctx, goal, now)
+		require.NoError(t, err)
+
+		return progress
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_create_a_goal_with_a_trimmed_name",
+			fn: func(t *testing.T) {
+				goal := createGoal(t, "  Emergency fund ", 500000, day(2026, time.December, 31))
+				require.Positive(t, goal.ID)
+				require.Equal(t, "Emergency fund", goal.Name)
+				require.Equal(t, uint64(500000), goal.TargetAmount)
+			},
+		},
+		{
+			name: "should_reject_a_name_already_taken",
+			fn: func(t *testing.T) {
+				_, err := s.Store.CreateSavingsGoal(ctx, user.ID, logic.SavingsGoalParams{
+					Name:         "emergency FUND",
+					TargetAmount: 1000,
+					TargetDate:   day(2027, time.January, 1),
+				})
+				require.ErrorIs(t, err, logic.ErrSavingsGoalNameTaken)
+			},
+		},
+		{
+			name: "should_fail_validation_without_a_target_amount",
+			fn: func(t *testing.T) {
+				_, err := s.Store.CreateSavingsGoal(ctx, user.ID, logic.SavingsGoalParams{
+					Name:       "Car",
+					TargetDate: day(2027, time.January, 1),
+				})
+				require.ErrorIs(t, err, logic.ErrValidationFailed)
+			},
+		},
+		{
+			name: "should_measure_required_monthly_and_projection_from_the_pace",
+			fn: func(t *testing.T) {
+				goal := createGoal(t, "Holiday", 120000, day(2026, time.July, 15))
+				contribute(t, goal.ID, 20000, day(2025, time.December, 16))
+
+				progress := progressOf(t, goal)
+				require.Equal(t, uint64(20000), progress.Saved)
+				require.Equal(t, uint64(100000), progress.Remaining)
+				require.Equal(t, 6, progress.MonthsLeft)
+				require.Equal(t, uint64(16667), progress.RequiredMonthly)
+				require.False(t, progress.Complete)
+				require.False(t, progress.Overdue)
+				// A sixth saved in 30 days and 9.5 hours leaves five times that to
+				// go, which lands on June 16.
+				require.NotNil(t, progress.ProjectedAt)
+				require.Equal(t, day(2026, time.June, 16), *progress.ProjectedAt)
+			},
+		},
+		{
+			name: "should_leave_the_projection_empty_without_contributions",
+			fn: func(t *testing.T) {
+				goal := createGoal(t, "Laptop", 150000, day(2026, time.January, 25))
+
+				progress := progressOf(t, goal)
+				require.Equal(t, 1, progress.MonthsLeft)
+				require.Equal(t, uint64(150000), progress.RequiredMonthly)
+				require.Nil(t, progress.ProjectedAt)
+			},
+		},
+		{
+			name: "should_flag_a_goal_past_its_target_date_as_overdue",
+			fn: func(t *testing.T) {
+				goal := createGoal(t, "Bike", 80000, day(2025, time.November, 1))
+				contribute(t, goal.ID, 30000, day(2025, time.October, 1))
+
+				progress := progressOf(t, goal)
+				require.True(t, progress.Overdue)
+				require.Zero(t, progress.MonthsLeft)
+				require.Equal(t, uint64(50000), progress.RequiredMonthly)
+			},
+		},
+		{
+			name: "should_complete_on_the_contribution_that_reaches_the_target",
+			fn: func(t *testing.T) {
+				goal := createGoal(t, "Camera", 60000, day(2026, time.March, 1))
+				contribute(t, goal.ID, 40000, day(2025, time.November, 3))
+				contribute(t, goal.ID, 25000, day(2025, time.December, 5))
+				contribute(t, goal.ID, 5000, day(2026, time.January, 2))
+
+				progress := progressOf(t, goal)
+				require.True(t, progress.Complete)
+				require.Equal(t, uint64(70000), progress.Saved)
+				require.Zero(t, progress.Remaining)
+				require.Zero(t, progress.RequiredMonthly)
+				require.Equal(t, day(2025, time.December, 5), *progress.ProjectedAt)
+			},
+		},
+		{
+			name: "should_not_add_a_contribution_to_another_users_goal",
+			fn: func(t *testing.T) {
+				goal := createGoal(t, "Private", 10000, day(2026, time.June, 1))
+
+				_, err := s.Store.AddSavingsContribution(ctx, goal.ID, other.ID, logic.SavingsContributionParams{
+					Amount: 1000,
+					Date:   day(2026, time.January, 1),
+				})
+				require.ErrorIs(t, err, sql.ErrNoRows)
+			},
+		},
+		{
+			name: "should_list_goals_nearest_deadline_first",
+			fn: func(t *testing.T) {
+				goals, err := s.Store.FindSavingsGoals(ctx, user.ID, now)
+				require.NoError(t, err)
+				require.NotEmpty(t, goals)
+				for i := 1; i < len(goals); i++ {
+					require.LessOrEqual(t, goals[i-1].Goal.TargetDate, goals[i].Goal.TargetDate)
+				}
+
+				others, err := s.Store.FindSavingsGoals(ctx, other.ID, now)
+				require.NoError(t, err)
+				require.Empty(t, others)
+			},
+		},
+		{
+			name: "should_delete_a_contribution_and_then_the_goal_with_the_rest",
+			fn: func(t *testing.T) {
+				goal := createGoal(t, "Sofa", 90000, day(2026, time.August, 1))
+				contribute(t, goal.ID, 10000, day(2026, time.January, 1))
+				contribution, err := s.Store.AddSavingsContribution(ctx, goal.ID, user.ID, logic.SavingsContributionParams{
+					Amount: 5000,
+					Date:   day(2026, time.January, 10),
+					Note:   " bonus ",
+				})
+				require.NoError(t, err)
+				require.Equal(t, "bonus", contribution.Note)
+
+				require.NoError(t, s.Store.DeleteSavingsContribution(ctx, contribution.ID, goal.ID, user.ID))
+				require.Equal(t, uint64(10000), progressOf(t, goal).Saved)
+
+				err = s.Store.DeleteSavingsContribution(ctx, contribution.ID, goal.ID, user.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+
+				require.NoError(t, s.Store.DeleteSavingsGoal(ctx, goal.ID, user.ID))
+				_, err = s.Store.FindSavingsGoal(ctx, goal.ID, user.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+				require.Empty(t, progressOf(t, goal).Contributions)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
@@ -38,6 +38,18 @@ func TestValidateStruct(t *testing.T) {
 				require.ErrorContains(t, err, "[Name:required]")
 			},
 		},
+		{
+			name: "should_expose_failed_fields_as_validation_error",
+			fn: func(t *testing.T) {
+				err := s.Store.ValidateStruct(validateStructParams{
+					Name: "",
+				})
+
+				var valErr *logic.ValidationError
+				require.ErrorAs(t, err, &valErr)
+				require.Equal(t, []logic.FieldError{{Field: "Name", Tag: "required"}}, valErr.Fields)
+			},
+		},
 		{
 			name: "should_return_validation_assertion_for_non_struct_input",
 			fn: func(t *testing.T) {
@@ -121,6 +121,18 @@ func (s *Store) replaceTagsTx(
 		return err
 	}
 
+	return s.addTagsTx(ctx, tq, taggableType, targetID, userID, tagNames)
+}
+
+// addTagsTx tags the target with tagNames on top of any tags it already has.
+func (s *Store) addTagsTx(
+	ctx context.Context,
+	tq *repo.TxQueries,
+	taggableType string,
+	targetID int,
+	userID int,
+	tagNames []string,
+) error {
 	if len(tagNames) == 0 {
 		return nil
 	}
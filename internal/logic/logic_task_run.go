@@ -0,0 +1,52 @@
+package logic
+
+import (
+	"context"
+	"time"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// TaskCopyDueRecurrentExpenses and TaskCopyDueRecurrentIncomes name the copy
+// tasks' rows in task_runs.
+const (
+	TaskCopyDueRecurrentExpenses = "copy_due_recurrent_expenses"
+	TaskCopyDueRecurrentIncomes  = "copy_due_recurrent_incomes"
+)
+
+// taskRunFailure is one row a run could not process. subjectID is zero when
+// the run failed as a whole, before it got to any row.
+type taskRunFailure struct {
+	subjectID int
+	err       error
+}
+
+// FindLatestTaskRun returns the most recent run of the named task with the
+// rows it failed on.
+func (s *Store) FindLatestTaskRun(ctx context.Context, name string) (repo.TaskRun, []repo.TaskRunFailure, error) {
+	run, err := s.queries.SelectLatestTaskRun(ctx, name)
+	if err != nil {
+		return run, nil, err
+	}
+
+	failures, err := s.queries.SelectTaskRunFailures(ctx, run.ID)
+	if err != nil {
+		return run, nil, err
+	}
+
+	return run, failures, nil
+}
+
+// finishTaskRun closes the run's audit row. Writing the audit must not change
+// what the task reports, so its own errors are logged rather than returned.
+func (s *Store) finishTaskRun(ctx context.Context, runID, succeeded int, failures []taskRunFailure) {
+	for _, f := range failures {
+		if _, err := s.queries.InsertTaskRunFailure(ctx, runID, f.subjectID, f.err.Error()); err != nil {
+			s.app.Logger.Errorf("failed to record task run failure [run=%d]: %v", runID, err)
+		}
+	}
+
+	if _, err := s.queries.FinishTaskRun(ctx, runID, time.Now().Unix(), succeeded, len(failures)); err != nil {
+		s.app.Logger.Errorf("failed to finish task run [id=%d]: %v", runID, err)
+	}
+}
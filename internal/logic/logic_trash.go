@@ -0,0 +1,108 @@
+package logic
+
+import (
+	"context"
+	"time"
+
+	"github.com/ad9311/ninete/internal/repo"
+)
+
+// DefaultTrashRetentionDays is how long the purge_trash task keeps a trashed
+// row when no other age is given.
+const DefaultTrashRetentionDays = 30
+
+// TrashKindLabels names each trash kind for pages and flash messages.
+var TrashKindLabels = map[string]string{ //nolint:gochecknoglobals // static lookup table
+	repo.TrashKindExpense:    "expense",
+	repo.TrashKindMacroEntry: "macro entry",
+	repo.TrashKindFood:       "food",
+	repo.TrashKindMoodEntry:  "mood entry",
+}
+
+func (s *Store) FindTrash(ctx context.Context, userID int) ([]repo.TrashedRow, error) {
+	trashed, err := s.queries.SelectTrashByUser(ctx, userID)
+	if err != nil {
+		return trashed, err
+	}
+
+	return trashed, nil
+}
+
+// RestoreFromTrash puts a trashed row back. A food whose name was reused while
+// it sat in the trash cannot come back until the newer one is renamed.
+func (s *Store) RestoreFromTrash(ctx context.Context, kind string, id, userID int) (int, error) {
+	if !repo.ValidTrashKind(kind) {
+		return 0, ErrUnknownTrashKind
+	}
+
+	i, err := s.queries.RestoreTrashed(ctx, kind, id, userID)
+	if err != nil {
+		if repo.IsUniqueViolation(err) {
+			return 0, ErrTrashFoodNameTaken
+		}
+
+		return 0, err
+	}
+
+	return i, nil
+}
+
+func (s *Store) PurgeFromTrash(ctx context.Context, kind string, id, userID int) (int, error) {
+	if !repo.ValidTrashKind(kind) {
+		return 0, ErrUnknownTrashKind
+	}
+
+	var i int
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		var txErr error
+		i, txErr = tq.PurgeTrashed(ctx, kind, id, userID)
+
+		return txErr
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return i, nil
+}
+
+func (s *Store) EmptyTrash(ctx context.Context, userID int) (int, error) {
+	var purged int
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		var txErr error
+		purged, txErr = tq.PurgeTrashByUser(ctx, userID)
+
+		return txErr
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// PurgeTrash deletes, across every account, the rows that went to the trash
+// more than days days before now.
+func (s *Store) PurgeTrash(ctx context.Context, now time.Time, days int) (int, error) {
+	if days < 0 {
+		return 0, ErrTrashRetentionDays
+	}
+
+	cutoff := now.AddDate(0, 0, -days).Unix()
+
+	var purged int
+
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		var txErr error
+		purged, txErr = tq.PurgeTrashBefore(ctx, cutoff)
+
+		return txErr
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
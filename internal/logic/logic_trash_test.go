@@ -0,0 +1,232 @@
+package logic_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ad9311/ninete/internal/logic"
+	"github.com/ad9311/ninete/internal/repo"
+	"github.com/ad9311/ninete/internal/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash(t *testing.T) {
+	s := spec.New(t)
+	ctx := t.Context()
+
+	newUser := func(t *testing.T, name string) logic.User {
+		t.Helper()
+
+		return s.CreateUser(t, repo.InsertUserParams{
+			Username:     name,
+			Email:        name + "@example.com",
+			PasswordHash: []byte(name + "_hash"),
+		})
+	}
+
+	userExpenses := func(t *testing.T, userID int) []repo.Expense {
+		t.Helper()
+
+		expenses, err := s.Store.FindExpenses(ctx, repo.QueryOptions{
+			Filters: repo.Filters{
+				FilterFields: []repo.FilterField{{Name: "user_id", Value: userID, Operator: "="}},
+			},
+		})
+		require.NoError(t, err)
+
+		return expenses
+	}
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{
+			name: "should_hide_deleted_rows_and_list_them_in_the_trash",
+			fn: func(t *testing.T) {
+				user := newUser(t, "trash_hide")
+				category := s.CreateCategory(t, user.ID, "trash_category")
+				expense := s.CreateExpense(t, user.ID,
+					newExpenseParams(category.ID, "trash lunch", 1250, 1735689600, []string{"food"}))
+				food := s.CreateFood(t, user.ID, newFoodParams("trash oats", 380, 13, 60, 7))
+
+				_, err := s.Store.DeleteExpense(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+				_, err = s.Store.DeleteFood(ctx, food.ID, user.ID)
+				require.NoError(t, err)
+
+				require.Empty(t, userExpenses(t, user.ID))
+				_, err = s.Store.FindExpense(ctx, expense.ID, user.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+
+				counts, err := s.Store.FindAccountDataCounts(ctx, user.ID)
+				require.NoError(t, err)
+				require.Zero(t, counts.Expenses)
+				require.Zero(t, counts.Foods)
+
+				trashed, err := s.Store.FindTrash(ctx, user.ID)
+				require.NoError(t, err)
+				require.Len(t, trashed, 2)
+
+				kinds := []string{trashed[0].Kind, trashed[1].Kind}
+				require.ElementsMatch(t, []string{repo.TrashKindExpense, repo.TrashKindFood}, kinds)
+			},
+		},
+		{
+			name: "should_restore_a_row_with_its_tags",
+			fn: func(t *testing.T) {
+				user := newUser(t, "trash_restore")
+				category := s.CreateCategory(t, user.ID, "trash_category")
+				expense := s.CreateExpense(t, user.ID,
+					newExpenseParams(category.ID, "trash dinner", 2400, 1735689600, []string{"food", "work"}))
+
+				_, err := s.Store.DeleteExpense(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+
+				_, err = s.Store.RestoreFromTrash(ctx, repo.TrashKindExpense, expense.ID, user.ID)
+				require.NoError(t, err)
+
+				require.Len(t, userExpenses(t, user.ID), 1)
+
+				tags, err := s.Store.FindExpenseTags(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []string{"food", "work"}, logic.ExtractTagNames(tags))
+
+				_, err = s.Store.RestoreFromTrash(ctx, repo.TrashKindExpense, expense.ID, user.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+			},
+		},
+		{
+			name: "should_refuse_to_restore_a_food_whose_name_was_reused",
+			fn: func(t *testing.T) {
+				user := newUser(t, "trash_food_name")
+				food := s.CreateFood(t, user.ID, newFoodParams("trash rice", 130, 3, 28, 0))
+
+				_, err := s.Store.DeleteFood(ctx, food.ID, user.ID)
+				require.NoError(t, err)
+				s.CreateFood(t, user.ID, newFoodParams("Trash Rice", 130, 3, 28, 0))
+
+				_, err = s.Store.RestoreFromTrash(ctx, repo.TrashKindFood, food.ID, user.ID)
+				require.ErrorIs(t, err, logic.ErrTrashFoodNameTaken)
+			},
+		},
+		{
+			name: "should_not_touch_another_users_trash",
+			fn: func(t *testing.T) {
+				owner := newUser(t, "trash_owner")
+				intruder := newUser(t, "trash_intruder")
+				entry := s.CreateMoodEntry(t, owner.ID,
+					newMoodEntryParams("Calm", "trash note", 1735689600, nil))
+				require.NoError(t, s.Store.DeleteMoodEntry(ctx, entry.ID, owner.ID))
+
+				_, err := s.Store.RestoreFromTrash(ctx, repo.TrashKindMoodEntry, entry.ID, intruder.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+				_, err = s.Store.PurgeFromTrash(ctx, repo.TrashKindMoodEntry, entry.ID, intruder.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+
+				trashed, err := s.Store.FindTrash(ctx, owner.ID)
+				require.NoError(t, err)
+				require.Len(t, trashed, 1)
+			},
+		},
+		{
+			name: "should_purge_a_row_and_its_taggings",
+			fn: func(t *testing.T) {
+				user := newUser(t, "trash_purge")
+				entry := s.CreateMoodEntry(t, user.ID,
+					newMoodEntryParams("Happy", "trash purge note", 1735689600, []string{"work"}))
+				require.NoError(t, s.Store.DeleteMoodEntry(ctx, entry.ID, user.ID))
+
+				_, err := s.Store.PurgeFromTrash(ctx, repo.TrashKindMoodEntry, entry.ID, user.ID)
+				require.NoError(t, err)
+
+				taggings, err := s.Queries.CountTaggingsByTarget(ctx, repo.TaggableTypeMoodEntry, entry.ID)
+				require.NoError(t, err)
+				require.Zero(t, taggings)
+
+				trashed, err := s.Store.FindTrash(ctx, user.ID)
+				require.NoError(t, err)
+				require.Empty(t, trashed)
+			},
+		},
+		{
+			name: "should_purge_the_taggings_of_an_expenses_splits",
+			fn: func(t *testing.T) {
+				user := newUser(t, "trash_split")
+				category := s.CreateCategory(t, user.ID, "trash_split_category")
+				params := newExpenseParams(category.ID, "trash split shop", 1000, 1735689600, nil)
+				params.Splits = []logic.ExpenseSplitParams{{CategoryID: category.ID, Amount: 1000, Tags: []string{"bulk"}}}
+				expense := s.CreateExpense(t, user.ID, params)
+				splits, err := s.Store.FindExpenseSplits(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+				require.Len(t, splits, 1)
+				_, err = s.Store.DeleteExpense(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+
+				_, err = s.Store.PurgeFromTrash(ctx, repo.TrashKindExpense, expense.ID, user.ID)
+				require.NoError(t, err)
+
+				taggings, err := s.Queries.CountTaggingsByTarget(ctx, repo.TaggableTypeExpenseSplit, splits[0].ID)
+				require.NoError(t, err)
+				require.Zero(t, taggings)
+			},
+		},
+		{
+			name: "should_never_purge_a_live_row",
+			fn: func(t *testing.T) {
+				user := newUser(t, "trash_live")
+				entry := s.CreateMacroEntry(t, user.ID,
+					newMacroEntryParams("trash breakfast", 400, 20, 50, 10, 1735689600))
+
+				_, err := s.Store.PurgeFromTrash(ctx, repo.TrashKindMacroEntry, entry.ID, user.ID)
+				require.ErrorIs(t, err, sql.ErrNoRows)
+
+				_, err = s.Store.FindMacroEntry(ctx, entry.ID, user.ID)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "should_purge_only_rows_older_than_the_retention",
+			fn: func(t *testing.T) {
+				user := newUser(t, "trash_retention")
+				category := s.CreateCategory(t, user.ID, "trash_category")
+				expense := s.CreateExpense(t, user.ID,
+					newExpenseParams(category.ID, "trash old lunch", 900, 1735689600, []string{"food"}))
+				_, err := s.Store.DeleteExpense(ctx, expense.ID, user.ID)
+				require.NoError(t, err)
+
+				purged, err := s.Store.PurgeTrash(ctx, time.Now(), logic.DefaultTrashRetentionDays)
+				require.NoError(t, err)
+				require.Zero(t, purged)
+
+				later := time.Now().AddDate(0, 0, logic.DefaultTrashRetentionDays+1)
+				purged, err = s.Store.PurgeTrash(ctx, later, logic.DefaultTrashRetentionDays)
+				require.NoError(t, err)
+				require.Positive(t, purged)
+
+				trashed, err := s.Store.FindTrash(ctx, user.ID)
+				require.NoError(t, err)
+				require.Empty(t, trashed)
+
+				taggings, err := s.Queries.CountTaggingsByTarget(ctx, repo.TaggableTypeExpense, expense.ID)
+				require.NoError(t, err)
+				require.Zero(t, taggings)
+			},
+		},
+		{
+			name: "should_reject_an_unknown_kind",
+			fn: func(t *testing.T) {
+				_, err := s.Store.RestoreFromTrash(ctx, "users", 1, 1)
+				require.ErrorIs(t, err, logic.ErrUnknownTrashKind)
+
+				_, err = s.Store.PurgeTrash(ctx, time.Now(), -1)
+				require.ErrorIs(t, err, logic.ErrTrashRetentionDays)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
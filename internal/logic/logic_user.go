@@ -7,11 +7,12 @@ import (
 )
 
 type User struct {
-	ID        int
-	Username  string
-	Email     string
-	CreatedAt int64
-	UpdatedAt int64
+	ID           int
+	Username     string
+	Email        string
+	CreatedAt    int64
+	UpdatedAt    int64
+	HomeCurrency string
 }
 
 func (s *Store) FindUser(ctx context.Context, id int) (User, error) {
@@ -39,11 +40,22 @@ func (s *Store) FindUserForAuth(ctx context.Context, email string) (repo.User, e
 	return user, nil
 }
 
+// CreateUser inserts the user together with their copy of the default
+// categories, so a new account can file an expense straight away.
 func (s *Store) CreateUser(ctx context.Context, params repo.InsertUserParams) (User, error) {
 	var user repo.User
 	var safeUser User
 
-	user, err := s.queries.InsertUser(ctx, params)
+	err := s.queries.WithTx(ctx, func(tq *repo.TxQueries) error {
+		var txErr error
+
+		user, txErr = tq.InsertUser(ctx, params)
+		if txErr != nil {
+			return txErr
+		}
+
+		return createDefaultCategoriesTx(ctx, tq, user.ID)
+	})
 	if err != nil {
 		return safeUser, err
 	}
@@ -59,4 +71,5 @@ func (u *User) fromRepoUser(user repo.User) {
 	u.Email = user.Email
 	u.CreatedAt = user.CreatedAt
 	u.UpdatedAt = user.UpdatedAt
+	u.HomeCurrency = user.HomeCurrency
 }
@@ -0,0 +1,74 @@
+package logic
+
+import "slices"
+
+// Units a food's base amount and servings can be given in. Grams and
+// milliliters are the reference units for mass and volume.
+const (
+	UnitGram       = "g"
+	UnitKilogram   = "kg"
+	UnitOunce      = "oz"
+	UnitPound      = "lb"
+	UnitMilliliter = "ml"
+	UnitLiter      = "l"
+)
+
+type unitKind int
+
+const (
+	unitMass unitKind = iota
+	unitVolume
+)
+
+type unitDef struct {
+	kind unitKind
+	// factor converts one of the unit into grams or milliliters.
+	factor float64
+}
+
+//nolint:gochecknoglobals
+var unitDefs = map[string]unitDef{
+	UnitGram:       {kind: unitMass, factor: 1},
+	UnitKilogram:   {kind: unitMass, factor: 1000},
+	UnitOunce:      {kind: unitMass, factor: 28.349523125},
+	UnitPound:      {kind: unitMass, factor: 453.59237},
+	UnitMilliliter: {kind: unitVolume, factor: 1},
+	UnitLiter:      {kind: unitVolume, factor: 1000},
+}
+
+// Units lists every unit, masses first, in the order forms offer them.
+func Units() []string {
+	return []string{UnitGram, UnitKilogram, UnitOunce, UnitPound, UnitMilliliter, UnitLiter}
+}
+
+func IsUnit(unit string) bool {
+	return slices.Contains(Units(), unit)
+}
+
+// ConvertAmount converts amount from one unit to another. Between a mass and a
+// volume it goes through densityGPerML, and returns ErrUnitNeedsDensity when
+// that is nil.
+func ConvertAmount(amount float64, from, to string, densityGPerML *float64) (float64, error) {
+	fromDef, ok := unitDefs[from]
+	if !ok {
+		return 0, ErrUnknownUnit
+	}
+	toDef, ok := unitDefs[to]
+	if !ok {
+		return 0, ErrUnknownUnit
+	}
+
+	base := amount * fromDef.factor
+	if fromDef.kind != toDef.kind {
+		if densityGPerML == nil || *densityGPerML <= 0 {
+			return 0, ErrUnitNeedsDensity
+		}
+		if fromDef.kind == unitMass {
+			base /= *densityGPerML
+		} else {
+			base *= *densityGPerML
+		}
+	}
+
+	return base / toDef.factor, nil
+}
@@ -0,0 +1,210 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+)
+
+type apiToken struct {
+	ID               int
+	UserID           int
+	Name             string
+	TokenHash        []byte
+	TokenFingerprint string
+	ExpensesScope    string
+	MacrosScope      string
+	MoodsScope       string
+	LastUsedAt       sql.NullInt64
+	CreatedAt        int64
+	UpdatedAt        int64
+}
+
+type APIToken struct {
+	ID               int
+	UserID           int
+	Name             string
+	TokenHash        []byte
+	TokenFingerprint string
+	ExpensesScope    string
+	MacrosScope      string
+	MoodsScope       string
+	LastUsedAt       *int64
+	CreatedAt        int64
+	UpdatedAt        int64
+}
+
+func (t apiToken) toAPIToken() APIToken {
+	var lastUsedAt *int64
+	if t.LastUsedAt.Valid {
+		value := t.LastUsedAt.Int64
+		lastUsedAt = &value
+	}
+
+	return APIToken{
+		ID:               t.ID,
+		UserID:           t.UserID,
+		Name:             t.Name,
+		TokenHash:        t.TokenHash,
+		TokenFingerprint: t.TokenFingerprint,
+		ExpensesScope:    t.ExpensesScope,
+		MacrosScope:      t.MacrosScope,
+		MoodsScope:       t.MoodsScope,
+		LastUsedAt:       lastUsedAt,
+		CreatedAt:        t.CreatedAt,
+		UpdatedAt:        t.UpdatedAt,
+	}
+}
+
+type InsertAPITokenParams struct {
+	UserID           int
+	Name             string
+	TokenHash        []byte
+	TokenFingerprint string
+	ExpensesScope    string
+	MacrosScope      string
+	MoodsScope       string
+}
+
+// apiTokenColumns pins the projection order the Scan calls in this file depend on.
+// SELECT * would resolve to whatever order the table happens to have, so an
+// ALTER TABLE could shift values into the wrong struct fields with no error.
+const apiTokenColumns = `"id", "user_id", "name", "token_hash", "token_fingerprint",
+"expenses_scope", "macros_scope", "moods_scope", "last_used_at", "created_at", "updated_at"`
+
+const insertAPIToken = `
+INSERT INTO "api_tokens"
+  ("user_id", "name", "token_hash", "token_fingerprint", "expenses_scope", "macros_scope", "moods_scope")
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING ` + apiTokenColumns
+
+func (q *Queries) InsertAPIToken(ctx context.Context, params InsertAPITokenParams) (APIToken, error) {
+	var t apiToken
+
+	err := q.wrapQuery(insertAPIToken, func() error {
+		row := q.db.QueryRowContext(
+			ctx,
+			insertAPIToken,
+			params.UserID,
+			params.Name,
+			params.TokenHash,
+			params.TokenFingerprint,
+			params.ExpensesScope,
+			params.MacrosScope,
+			params.MoodsScope,
+		)
+
+		return row.Scan(
+			&t.ID,
+			&t.UserID,
+			&t.Name,
+			&t.TokenHash,
+			&t.TokenFingerprint,
+			&t.ExpensesScope,
+			&t.MacrosScope,
+			&t.MoodsScope,
+			&t.LastUsedAt,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		)
+	})
+
+	return t.toAPIToken(), err
+}
+
+const selectAPITokensByUser = `SELECT ` + apiTokenColumns + `
+FROM "api_tokens" WHERE "user_id" = ? ORDER BY "created_at" DESC, "id" DESC`
+
+func (q *Queries) SelectAPITokensByUser(ctx context.Context, userID int) ([]APIToken, error) {
+	var tokens []APIToken
+
+	err := q.wrapQuery(selectAPITokensByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectAPITokensByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var t apiToken
+
+			if err := rows.Scan(
+				&t.ID,
+				&t.UserID,
+				&t.Name,
+				&t.TokenHash,
+				&t.TokenFingerprint,
+				&t.ExpensesScope,
+				&t.MacrosScope,
+				&t.MoodsScope,
+				&t.LastUsedAt,
+				&t.CreatedAt,
+				&t.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			tokens = append(tokens, t.toAPIToken())
+		}
+
+		return rows.Err()
+	})
+
+	return tokens, err
+}
+
+const selectAPITokenByFingerprint = `
+SELECT ` + apiTokenColumns + ` FROM "api_tokens"
+WHERE "token_fingerprint" = ?
+LIMIT 1`
+
+func (q *Queries) SelectAPITokenByFingerprint(ctx context.Context, fingerprint string) (APIToken, error) {
+	var t apiToken
+
+	err := q.wrapQuery(selectAPITokenByFingerprint, func() error {
+		row := q.db.QueryRowContext(ctx, selectAPITokenByFingerprint, fingerprint)
+
+		return row.Scan(
+			&t.ID,
+			&t.UserID,
+			&t.Name,
+			&t.TokenHash,
+			&t.TokenFingerprint,
+			&t.ExpensesScope,
+			&t.MacrosScope,
+			&t.MoodsScope,
+			&t.LastUsedAt,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		)
+	})
+
+	return t.toAPIToken(), err
+}
+
+const updateAPITokenLastUsedAt = `UPDATE "api_tokens" SET "last_used_at" = ? WHERE "id" = ?`
+
+func (q *Queries) UpdateAPITokenLastUsedAt(ctx context.Context, id int, usedAt int64) error {
+	return q.wrapQuery(updateAPITokenLastUsedAt, func() error {
+		_, err := q.db.ExecContext(ctx, updateAPITokenLastUsedAt, usedAt, id)
+
+		return err
+	})
+}
+
+const deleteAPIToken = `DELETE FROM "api_tokens" WHERE "id" = ? AND "user_id" = ? RETURNING "id"`
+
+func (q *Queries) DeleteAPIToken(ctx context.Context, id, userID int) (int, error) {
+	var i int
+
+	err := q.wrapQuery(deleteAPIToken, func() error {
+		row := q.db.QueryRowContext(ctx, deleteAPIToken, id, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
@@ -0,0 +1,422 @@
+package repo
+
+import (
+	"context"
+)
+
+// The Restore* inserts below write a row back exactly as a backup recorded it,
+// timestamps included, and return the id it was given. The caller maps the
+// backed-up id to the new one; nothing here trusts ids from the archive.
+
+const selectMigrationVersion = `SELECT COALESCE(MAX("version_id"), 0)
+FROM "goose_db_version" WHERE "is_applied" = 1`
+
+// SelectMigrationVersion returns the newest applied goose migration.
+func (q *Queries) SelectMigrationVersion(ctx context.Context) (int64, error) {
+	var v int64
+
+	err := q.wrapQuery(selectMigrationVersion, func() error {
+		row := q.db.QueryRowContext(ctx, selectMigrationVersion)
+
+		return row.Scan(&v)
+	})
+
+	return v, err
+}
+
+const taggingColumnsAliased = `tg."id", tg."tag_id", tg."taggable_id", tg."taggable_type",
+tg."created_at", tg."updated_at"`
+
+const selectTaggingsByUser = `SELECT ` + taggingColumnsAliased + `
+FROM "taggings" tg
+JOIN "tags" t ON t."id" = tg."tag_id"
+WHERE t."user_id" = ?
+  AND NOT (tg."taggable_type" = 'expense' AND tg."taggable_id" IN
+    (SELECT "id" FROM "expenses" WHERE "deleted_at" IS NOT NULL))
+  AND NOT (tg."taggable_type" = 'expense_split' AND tg."taggable_id" IN
+    (SELECT "s"."id" FROM "expense_splits" AS "s"
+     JOIN "expenses" AS "e" ON "e"."id" = "s"."expense_id" WHERE "e"."deleted_at" IS NOT NULL))
+  AND NOT (tg."taggable_type" = 'mood_entry' AND tg."taggable_id" IN
+    (SELECT "id" FROM "mood_entries" WHERE "deleted_at" IS NOT NULL))
+ORDER BY tg."id"`
+
+// SelectTaggingsByUser returns every tagging on the user's tags. Taggings have
+// no user column of their own; ownership comes through the tag. Taggings of
+// trashed rows are left out, since a backup does not carry the trash.
+func (q *Queries) SelectTaggingsByUser(ctx context.Context, userID int) ([]Tagging, error) {
+	var taggings []Tagging
+
+	err := q.wrapQuery(selectTaggingsByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectTaggingsByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var t Tagging
+
+			if err := rows.Scan(
+				&t.ID,
+				&t.TagID,
+				&t.TaggableID,
+				&t.TaggableType,
+				&t.CreatedAt,
+				&t.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			taggings = append(taggings, t)
+		}
+
+		return rows.Err()
+	})
+
+	return taggings, err
+}
+
+const restoreTag = `
+INSERT INTO "tags" ("user_id", "name", "created_at", "updated_at")
+VALUES (?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreTag(ctx context.Context, t Tag) (int, error) {
+	return q.restoreRow(ctx, restoreTag, t.UserID, t.Name, t.CreatedAt, t.UpdatedAt)
+}
+
+const restoreTagging = `
+INSERT INTO "taggings" ("tag_id", "taggable_id", "taggable_type", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreTagging(ctx context.Context, t Tagging) (int, error) {
+	return q.restoreRow(ctx, restoreTagging, t.TagID, t.TaggableID, t.TaggableType, t.CreatedAt, t.UpdatedAt)
+}
+
+const restoreExpense = `
+INSERT INTO "expenses"
+  ("user_id", "category_id", "description", "amount", "date", "created_at", "updated_at", "currency",
+   "recurrent_expense_id", "payment_account_id")
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreExpense(ctx context.Context, e Expense) (int, error) {
+	return q.restoreRow(ctx, restoreExpense,
+		e.UserID, e.CategoryID, e.Description, e.Amount, e.Date, e.CreatedAt, e.UpdatedAt, e.Currency,
+		e.RecurrentExpenseID, e.PaymentAccountID,
+	)
+}
+
+const restoreExpenseSplit = `
+INSERT INTO "expense_splits" ("user_id", "expense_id", "category_id", "amount", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreExpenseSplit(ctx context.Context, s ExpenseSplit) (int, error) {
+	return q.restoreRow(ctx, restoreExpenseSplit, s.UserID, s.ExpenseID, s.CategoryID, s.Amount, s.CreatedAt, s.UpdatedAt)
+}
+
+const restoreRecurrentExpense = `
+INSERT INTO "recurrent_expenses"
+  ("user_id", "category_id", "description", "amount", "period", "last_copy_created_at",
+   "occurrence_limit", "occurrence_count", "archived_at", "created_at", "updated_at", "currency",
+   "frequency", "anchor_day", "starts_at", "ends_at", "is_estimate", "payment_account_id")
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreRecurrentExpense(ctx context.Context, e RecurrentExpense) (int, error) {
+	return q.restoreRow(ctx, restoreRecurrentExpense,
+		e.UserID,
+		e.CategoryID,
+		e.Description,
+		e.Amount,
+		e.Period,
+		e.LastCopyCreatedAt,
+		e.OccurrenceLimit,
+		e.OccurrenceCount,
+		e.ArchivedAt,
+		e.CreatedAt,
+		e.UpdatedAt,
+		e.Currency,
+		e.Frequency,
+		e.AnchorDay,
+		e.StartsAt,
+		e.EndsAt,
+		e.IsEstimate,
+		e.PaymentAccountID,
+	)
+}
+
+const restorePendingExpense = `
+INSERT INTO "pending_expenses" ("user_id", "recurrent_expense_id", "date", "created_at")
+VALUES (?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestorePendingExpense(ctx context.Context, p PendingExpense) (int, error) {
+	return q.restoreRow(ctx, restorePendingExpense, p.UserID, p.RecurrentExpenseID, p.Date, p.CreatedAt)
+}
+
+const restoreExpenseBudget = `
+INSERT INTO "expense_budgets"
+  ("user_id", "category_id", "amount", "rollover", "effective_from", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreExpenseBudget(ctx context.Context, b ExpenseBudget) (int, error) {
+	return q.restoreRow(
+		ctx,
+		restoreExpenseBudget,
+		b.UserID,
+		b.CategoryID,
+		b.Amount,
+		b.Rollover,
+		b.EffectiveFrom,
+		b.CreatedAt,
+		b.UpdatedAt,
+	)
+}
+
+// restoreExpenseCategoryMapping upserts: quick-add may already have learned a
+// mapping for the same description, and the backed-up choice wins.
+const restoreExpenseCategoryMapping = `
+INSERT INTO "expense_category_mappings"
+  ("user_id", "category_id", "description_key", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT ("user_id", "description_key")
+DO UPDATE SET "category_id" = excluded."category_id", "updated_at" = excluded."updated_at"
+RETURNING "id"`
+
+func (q *TxQueries) RestoreExpenseCategoryMapping(ctx context.Context, m ExpenseCategoryMapping) (int, error) {
+	return q.restoreRow(ctx, restoreExpenseCategoryMapping,
+		m.UserID, m.CategoryID, m.DescriptionKey, m.CreatedAt, m.UpdatedAt,
+	)
+}
+
+const restoreMacroEntry = `
+INSERT INTO "macro_entries"
+  ("user_id", "name", "meal_type", "kcal", "protein_g", "carbs_g", "fat_g",
+   "fiber_g", "sodium_g", "saturated_fat_g", "date", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreMacroEntry(ctx context.Context, e MacroEntry) (int, error) {
+	return q.restoreRow(ctx, restoreMacroEntry,
+		e.UserID,
+		e.Name,
+		e.MealType,
+		e.Kcal,
+		e.ProteinG,
+		e.CarbsG,
+		e.FatG,
+		e.FiberG,
+		e.SodiumG,
+		e.SaturatedFatG,
+		e.Date,
+		e.CreatedAt,
+		e.UpdatedAt,
+	)
+}
+
+const restoreMacroGoal = `
+INSERT INTO "macro_goals"
+  ("user_id", "kcal", "protein_g", "carbs_g", "fat_g", "fiber_g", "sodium_g", "saturated_fat_g",
+   "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreMacroGoal(ctx context.Context, g MacroGoal) (int, error) {
+	return q.restoreRow(ctx, restoreMacroGoal,
+		g.UserID,
+		g.Kcal,
+		g.ProteinG,
+		g.CarbsG,
+		g.FatG,
+		g.FiberG,
+		g.SodiumG,
+		g.SaturatedFatG,
+		g.CreatedAt,
+		g.UpdatedAt,
+	)
+}
+
+const restoreFood = `
+INSERT INTO "foods"
+  ("user_id", "name", "kcal", "protein_g", "carbs_g", "fat_g",
+   "fiber_g", "sodium_g", "saturated_fat_g", "created_at", "updated_at",
+   "base_amount", "base_unit", "density_g_per_ml", "barcode")
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreFood(ctx context.Context, f Food) (int, error) {
+	return q.restoreRow(ctx, restoreFood,
+		f.UserID,
+		f.Name,
+		f.Kcal,
+		f.ProteinG,
+		f.CarbsG,
+		f.FatG,
+		f.FiberG,
+		f.SodiumG,
+		f.SaturatedFatG,
+		f.CreatedAt,
+		f.UpdatedAt,
+		f.BaseAmount,
+		f.BaseUnit,
+		f.DensityGPerML,
+		f.Barcode,
+	)
+}
+
+const restoreRecurrentIncome = `
+INSERT INTO "recurrent_incomes"
+  ("user_id", "source", "amount", "currency", "period", "frequency", "anchor_day", "starts_at", "ends_at",
+   "occurrence_limit", "occurrence_count", "last_copy_created_at", "archived_at", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreRecurrentIncome(ctx context.Context, ri RecurrentIncome) (int, error) {
+	return q.restoreRow(ctx, restoreRecurrentIncome,
+		ri.UserID,
+		ri.Source,
+		ri.Amount,
+		ri.Currency,
+		ri.Period,
+		ri.Frequency,
+		ri.AnchorDay,
+		ri.StartsAt,
+		ri.EndsAt,
+		ri.OccurrenceLimit,
+		ri.OccurrenceCount,
+		ri.LastCopyCreatedAt,
+		ri.ArchivedAt,
+		ri.CreatedAt,
+		ri.UpdatedAt,
+	)
+}
+
+const restorePaymentAccount = `
+INSERT INTO "payment_accounts"
+  ("user_id", "name", "kind", "currency", "opening_balance", "reconciled_through", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestorePaymentAccount(ctx context.Context, a PaymentAccount) (int, error) {
+	return q.restoreRow(ctx, restorePaymentAccount,
+		a.UserID, a.Name, a.Kind, a.Currency, a.OpeningBalance, a.ReconciledThrough, a.CreatedAt, a.UpdatedAt,
+	)
+}
+
+const restoreSavingsGoal = `
+INSERT INTO "savings_goals" ("user_id", "name", "target_amount", "target_date", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreSavingsGoal(ctx context.Context, g SavingsGoal) (int, error) {
+	return q.restoreRow(ctx, restoreSavingsGoal,
+		g.UserID, g.Name, g.TargetAmount, g.TargetDate, g.CreatedAt, g.UpdatedAt,
+	)
+}
+
+const restoreSavingsContribution = `
+INSERT INTO "savings_contributions"
+  ("user_id", "savings_goal_id", "amount", "date", "note", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreSavingsContribution(ctx context.Context, c SavingsContribution) (int, error) {
+	return q.restoreRow(ctx, restoreSavingsContribution,
+		c.UserID, c.SavingsGoalID, c.Amount, c.Date, c.Note, c.CreatedAt, c.UpdatedAt,
+	)
+}
+
+const restoreRecipe = `
+INSERT INTO "recipes" ("user_id", "name", "servings", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreRecipe(ctx context.Context, r Recipe) (int, error) {
+	return q.restoreRow(ctx, restoreRecipe, r.UserID, r.Name, r.Servings, r.CreatedAt, r.UpdatedAt)
+}
+
+const restoreRecipeIngredient = `
+INSERT INTO "recipe_ingredients"
+  ("user_id", "recipe_id", "food_id", "quantity_g", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreRecipeIngredient(ctx context.Context, i RecipeIngredient) (int, error) {
+	return q.restoreRow(ctx, restoreRecipeIngredient,
+		i.UserID, i.RecipeID, i.FoodID, i.QuantityG, i.CreatedAt, i.UpdatedAt,
+	)
+}
+
+const restoreFoodServing = `
+INSERT INTO "food_servings"
+  ("user_id", "food_id", "name", "amount", "unit", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreFoodServing(ctx context.Context, fs FoodServing) (int, error) {
+	return q.restoreRow(ctx, restoreFoodServing,
+		fs.UserID, fs.FoodID, fs.Name, fs.Amount, fs.Unit, fs.CreatedAt, fs.UpdatedAt,
+	)
+}
+
+const restoreMealTemplate = `
+INSERT INTO "meal_templates" ("user_id", "name", "meal_type", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreMealTemplate(ctx context.Context, m MealTemplate) (int, error) {
+	return q.restoreRow(ctx, restoreMealTemplate, m.UserID, m.Name, m.MealType, m.CreatedAt, m.UpdatedAt)
+}
+
+const restoreMealTemplateItem = `
+INSERT INTO "meal_template_items"
+  ("user_id", "meal_template_id", "food_id", "quantity", "unit", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreMealTemplateItem(ctx context.Context, i MealTemplateItem) (int, error) {
+	return q.restoreRow(ctx, restoreMealTemplateItem,
+		i.UserID, i.MealTemplateID, i.FoodID, i.Quantity, i.Unit, i.CreatedAt, i.UpdatedAt,
+	)
+}
+
+const restoreIncome = `
+INSERT INTO "incomes"
+  ("user_id", "source", "amount", "currency", "date", "recurrent_income_id", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreIncome(ctx context.Context, i Income) (int, error) {
+	return q.restoreRow(ctx, restoreIncome,
+		i.UserID, i.Source, i.Amount, i.Currency, i.Date, i.RecurrentIncomeID, i.CreatedAt, i.UpdatedAt,
+	)
+}
+
+const restoreMoodEntry = `
+INSERT INTO "mood_entries" ("user_id", "mood", "notes", "logged_at", "created_at", "updated_at")
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING "id"`
+
+func (q *TxQueries) RestoreMoodEntry(ctx context.Context, e MoodEntry) (int, error) {
+	return q.restoreRow(ctx, restoreMoodEntry, e.UserID, e.Mood, e.Notes, e.LoggedAt, e.CreatedAt, e.UpdatedAt)
+}
+
+func (q *TxQueries) restoreRow(ctx context.Context, query string, args ...any) (int, error) {
+	var id int
+
+	err := q.wrapQuery(query, func() error {
+		row := q.tx.QueryRowContext(ctx, query, args...)
+
+		return row.Scan(&id)
+	})
+
+	return id, err
+}
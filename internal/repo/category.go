@@ -3,49 +3,102 @@ package repo
 import "context"
 
 type Category struct {
-	ID        int
-	Name      string
-	UID       string
-	CreatedAt int64
-	UpdatedAt int64
+	ID         int
+	UserID     int
+	Name       string
+	UID        string
+	ArchivedAt *int64
+	CreatedAt  int64
+	UpdatedAt  int64
+	ParentID   *int
+}
+
+type InsertCategoryParams struct {
+	UserID     int
+	Name       string
+	UID        string
+	ArchivedAt *int64
+}
+
+type UpdateCategoryParams struct {
+	ID     int
+	UserID int
+	Name   string
+	UID    string
+}
+
+// CategoryUsage counts the rows that point at a category. Trashed expenses are
+// left out, as they are from every other count.
+type CategoryUsage struct {
+	CategoryID        int
+	Expenses          int
+	RecurrentExpenses int
 }
 
 // categoryColumns pins the projection order the Scan calls in this file depend on.
 // SELECT * would resolve to whatever order the table happens to have, so an
 // ALTER TABLE could shift values into the wrong struct fields with no error.
-const categoryColumns = `"id", "name", "uid", "created_at", "updated_at"`
+const categoryColumns = `"id", "user_id", "name", "uid", "archived_at", "created_at", "updated_at", "parent_id"`
 
 const insertCategory = `
-INSERT INTO "categories" ("name", "uid")
-VALUES (?, ?)
+INSERT INTO "categories" ("user_id", "name", "uid", "archived_at")
+VALUES (?, ?, ?, ?)
 RETURNING ` + categoryColumns
 
-func (q *Queries) InsertCategory(ctx context.Context, name, uid string) (Category, error) {
+func (q *Queries) InsertCategory(ctx context.Context, params InsertCategoryParams) (Category, error) {
+	var c Category
+
+	err := q.wrapQuery(insertCategory, func() error {
+		row := q.db.QueryRowContext(ctx, insertCategory,
+			params.UserID, params.Name, params.UID, params.ArchivedAt)
+
+		return row.Scan(
+			&c.ID,
+			&c.UserID,
+			&c.Name,
+			&c.UID,
+			&c.ArchivedAt,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+			&c.ParentID,
+		)
+	})
+
+	return c, err
+}
+
+func (q *TxQueries) InsertCategory(ctx context.Context, params InsertCategoryParams) (Category, error) {
 	var c Category
 
 	err := q.wrapQuery(insertCategory, func() error {
-		row := q.db.QueryRowContext(ctx, insertCategory, name, uid)
+		row := q.tx.QueryRowContext(ctx, insertCategory,
+			params.UserID, params.Name, params.UID, params.ArchivedAt)
 
 		return row.Scan(
 			&c.ID,
+			&c.UserID,
 			&c.Name,
 			&c.UID,
+			&c.ArchivedAt,
 			&c.CreatedAt,
 			&c.UpdatedAt,
+			&c.ParentID,
 		)
 	})
 
 	return c, err
 }
 
-const selectCategories = `
-SELECT ` + categoryColumns + ` FROM "categories" ORDER BY "name"`
+const selectCategoriesByUser = `
+SELECT ` + categoryColumns + ` FROM "categories" WHERE "user_id" = ? ORDER BY "name"`
 
-func (q *Queries) SelectCategories(ctx context.Context) ([]Category, error) {
+// SelectCategoriesByUser returns every category of the user, archived ones
+// included, so rows filed under an archived category still resolve a name.
+func (q *Queries) SelectCategoriesByUser(ctx context.Context, userID int) ([]Category, error) {
 	var cs []Category
 
-	err := q.wrapQuery(selectCategories, func() error {
-		rows, err := q.db.QueryContext(ctx, selectCategories)
+	err := q.wrapQuery(selectCategoriesByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectCategoriesByUser, userID)
 		if err != nil {
 			return err
 		}
@@ -60,10 +113,13 @@ func (q *Queries) SelectCategories(ctx context.Context) ([]Category, error) {
 
 			if err := rows.Scan(
 				&c.ID,
+				&c.UserID,
 				&c.Name,
 				&c.UID,
+				&c.ArchivedAt,
 				&c.CreatedAt,
 				&c.UpdatedAt,
+				&c.ParentID,
 			); err != nil {
 				return err
 			}
@@ -76,3 +132,288 @@ func (q *Queries) SelectCategories(ctx context.Context) ([]Category, error) {
 
 	return cs, err
 }
+
+const selectCategory = `
+SELECT ` + categoryColumns + ` FROM "categories" WHERE "id" = ? AND "user_id" = ? LIMIT 1`
+
+func (q *Queries) SelectCategory(ctx context.Context, id, userID int) (Category, error) {
+	var c Category
+
+	err := q.wrapQuery(selectCategory, func() error {
+		row := q.db.QueryRowContext(ctx, selectCategory, id, userID)
+
+		return row.Scan(
+			&c.ID,
+			&c.UserID,
+			&c.Name,
+			&c.UID,
+			&c.ArchivedAt,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+			&c.ParentID,
+		)
+	})
+
+	return c, err
+}
+
+func (q *TxQueries) SelectCategory(ctx context.Context, id, userID int) (Category, error) {
+	var c Category
+
+	err := q.wrapQuery(selectCategory, func() error {
+		row := q.tx.QueryRowContext(ctx, selectCategory, id, userID)
+
+		return row.Scan(
+			&c.ID,
+			&c.UserID,
+			&c.Name,
+			&c.UID,
+			&c.ArchivedAt,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+			&c.ParentID,
+		)
+	})
+
+	return c, err
+}
+
+const updateCategory = `
+UPDATE "categories"
+SET "name" = ?, "uid" = ?, "updated_at" = ?
+WHERE "id" = ? AND "user_id" = ?
+RETURNING ` + categoryColumns
+
+func (q *Queries) UpdateCategory(ctx context.Context, params UpdateCategoryParams) (Category, error) {
+	var c Category
+
+	err := q.wrapQuery(updateCategory, func() error {
+		row := q.db.QueryRowContext(
+			ctx,
+			updateCategory,
+			params.Name,
+			params.UID,
+			newUpdatedAt(),
+			params.ID,
+			params.UserID,
+		)
+
+		return row.Scan(
+			&c.ID,
+			&c.UserID,
+			&c.Name,
+			&c.UID,
+			&c.ArchivedAt,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+			&c.ParentID,
+		)
+	})
+
+	return c, err
+}
+
+const updateCategoryArchivedAt = `
+UPDATE "categories"
+SET "archived_at" = ?, "updated_at" = ?
+WHERE "id" = ? AND "user_id" = ?
+RETURNING ` + categoryColumns
+
+// UpdateCategoryArchivedAt archives a category at archivedAt, or brings it
+// back when archivedAt is nil.
+func (q *Queries) UpdateCategoryArchivedAt(
+	ctx context.Context,
+	id, userID int,
+	archivedAt *int64,
+) (Category, error) {
+	var c Category
+
+	err := q.wrapQuery(updateCategoryArchivedAt, func() error {
+		row := q.db.QueryRowContext(ctx, updateCategoryArchivedAt, archivedAt, newUpdatedAt(), id, userID)
+
+		return row.Scan(
+			&c.ID,
+			&c.UserID,
+			&c.Name,
+			&c.UID,
+			&c.ArchivedAt,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+			&c.ParentID,
+		)
+	})
+
+	return c, err
+}
+
+const updateCategoryParentID = `
+UPDATE "categories"
+SET "parent_id" = ?, "updated_at" = ?
+WHERE "id" = ? AND "user_id" = ?
+RETURNING ` + categoryColumns
+
+// UpdateCategoryParentID files a category under parentID, or makes it a
+// top-level category again when parentID is nil. The caller keeps the
+// hierarchy one level deep.
+func (q *TxQueries) UpdateCategoryParentID(
+	ctx context.Context,
+	id, userID int,
+	parentID *int,
+) (Category, error) {
+	var c Category
+
+	err := q.wrapQuery(updateCategoryParentID, func() error {
+		row := q.tx.QueryRowContext(ctx, updateCategoryParentID, parentID, newUpdatedAt(), id, userID)
+
+		return row.Scan(
+			&c.ID,
+			&c.UserID,
+			&c.Name,
+			&c.UID,
+			&c.ArchivedAt,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+			&c.ParentID,
+		)
+	})
+
+	return c, err
+}
+
+const countCategoryChildren = `SELECT COUNT(*) FROM "categories" WHERE "parent_id" = ?`
+
+func (q *TxQueries) CountCategoryChildren(ctx context.Context, id int) (int, error) {
+	var count int
+
+	err := q.wrapQuery(countCategoryChildren, func() error {
+		return q.tx.QueryRowContext(ctx, countCategoryChildren, id).Scan(&count)
+	})
+
+	return count, err
+}
+
+const selectCategoryUsageByUser = `
+SELECT "c"."id",
+  (SELECT COUNT(*) FROM "expenses" AS "e"
+   WHERE "e"."category_id" = "c"."id" AND "e"."deleted_at" IS NULL),
+  (SELECT COUNT(*) FROM "recurrent_expenses" AS "r"
+   WHERE "r"."category_id" = "c"."id")
+FROM "categories" AS "c"
+WHERE "c"."user_id" = ?`
+
+func (q *Queries) SelectCategoryUsageByUser(ctx context.Context, userID int) ([]CategoryUsage, error) {
+	var usage []CategoryUsage
+
+	err := q.wrapQuery(selectCategoryUsageByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectCategoryUsageByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var u CategoryUsage
+
+			if err := rows.Scan(&u.CategoryID, &u.Expenses, &u.RecurrentExpenses); err != nil {
+				return err
+			}
+
+			usage = append(usage, u)
+		}
+
+		return rows.Err()
+	})
+
+	return usage, err
+}
+
+// The merge statements match on "category_id" alone, not on the owner. Every
+// row still pointing at the source when it is deleted would go with it through
+// ON DELETE CASCADE, so none may be left behind.
+const (
+	mergeCategoryExpenses = `
+UPDATE "expenses" SET "category_id" = ?, "updated_at" = ? WHERE "category_id" = ?`
+
+	mergeCategoryExpenseSplits = `
+UPDATE "expense_splits" SET "category_id" = ?, "updated_at" = ? WHERE "category_id" = ?`
+
+	mergeCategoryRecurrentExpenses = `
+UPDATE "recurrent_expenses" SET "category_id" = ?, "updated_at" = ? WHERE "category_id" = ?`
+
+	// Two budgets become one holding their sum, since the spending they cap is
+	// now counted together. Both are versioned, so the target gets a version
+	// at every month either of them changed, holding what both had in force
+	// then; a month before a budget's first version counts that version, as
+	// it does everywhere else budgets are read.
+	mergeCategoryBudgets = `
+WITH "in_effect" AS (
+  SELECT "m"."effective_from", "c"."id" AS "category_id", (
+    SELECT COALESCE(MAX(CASE WHEN "v"."effective_from" <= "m"."effective_from" THEN "v"."effective_from" END),
+      MIN("v"."effective_from"))
+    FROM "expense_budgets" AS "v" WHERE "v"."category_id" = "c"."id"
+  ) AS "version_from"
+  FROM (SELECT DISTINCT "effective_from" FROM "expense_budgets" WHERE "category_id" IN (?, ?)) AS "m"
+  CROSS JOIN (SELECT ? AS "id" UNION ALL SELECT ?) AS "c"
+)
+INSERT INTO "expense_budgets" ("user_id", "category_id", "amount", "rollover", "effective_from")
+SELECT ?, ?, COALESCE(SUM("b"."amount"), 0), COALESCE(MAX("b"."rollover"), 0), "i"."effective_from"
+FROM "in_effect" AS "i"
+LEFT JOIN "expense_budgets" AS "b"
+  ON "b"."category_id" = "i"."category_id" AND "b"."effective_from" = "i"."version_from"
+GROUP BY "i"."effective_from"
+ON CONFLICT ("user_id", COALESCE("category_id", 0), "effective_from") DO UPDATE SET
+  "amount"     = excluded."amount",
+  "rollover"   = excluded."rollover",
+  "updated_at" = strftime('%s','now')`
+
+	deleteCategoryBudgets = `DELETE FROM "expense_budgets" WHERE "category_id" = ?`
+
+	// The source's children move under the target, except the target itself
+	// when it was one of them: it becomes top-level instead.
+	mergeCategoryChildren = `
+UPDATE "categories"
+SET "parent_id" = CASE WHEN "id" = ? THEN NULL ELSE ? END, "updated_at" = ?
+WHERE "parent_id" = ?`
+
+	mergeCategoryMappings = `
+UPDATE "expense_category_mappings" SET "category_id" = ?, "updated_at" = ? WHERE "category_id" = ?`
+
+	deleteCategory = `DELETE FROM "categories" WHERE "id" = ? AND "user_id" = ?`
+)
+
+// MergeCategory moves everything filed under sourceID to targetID and deletes
+// the source. Both must belong to userID; the caller checks that.
+func (q *TxQueries) MergeCategory(ctx context.Context, userID, sourceID, targetID int) error {
+	updatedAt := newUpdatedAt()
+
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{mergeCategoryExpenses, []any{targetID, updatedAt, sourceID}},
+		{mergeCategoryExpenseSplits, []any{targetID, updatedAt, sourceID}},
+		{mergeCategoryRecurrentExpenses, []any{targetID, updatedAt, sourceID}},
+		{mergeCategoryBudgets, []any{sourceID, targetID, sourceID, targetID, userID, targetID}},
+		{deleteCategoryBudgets, []any{sourceID}},
+		{mergeCategoryMappings, []any{targetID, updatedAt, sourceID}},
+		{mergeCategoryChildren, []any{targetID, targetID, updatedAt, sourceID}},
+		{deleteCategory, []any{sourceID, userID}},
+	}
+
+	for _, s := range statements {
+		err := q.wrapQuery(s.query, func() error {
+			_, err := q.tx.ExecContext(ctx, s.query, s.args...)
+
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
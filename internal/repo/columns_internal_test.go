@@ -77,14 +77,31 @@ func TestColumnConstantsMatchSchema(t *testing.T) {
 		{"categories", categoryColumns},
 		{"expense_budgets", expenseBudgetColumns},
 		{"expense_category_mappings", expenseCategoryMappingColumns},
+		{"expense_splits", expenseSplitColumns},
+		{"exchange_rates", exchangeRateColumns},
 		{"expenses", expenseColumns},
+		{"food_servings", foodServingColumns},
 		{"foods", foodColumns},
+		{"incomes", incomeColumns},
 		{"invitation_codes", invitationCodeColumns},
 		{"macro_entries", macroEntryColumns},
 		{"macro_goals", macroGoalColumns},
+		{"meal_template_items", mealTemplateItemColumns},
+		{"meal_templates", mealTemplateColumns},
 		{"mood_entries", moodEntryColumns},
+		{"notifications", notificationColumns},
+		{"payment_accounts", paymentAccountColumns},
+		{"pending_expenses", pendingExpenseColumns},
+		{"recipe_ingredients", recipeIngredientColumns},
+		{"recipes", recipeColumns},
 		{"recurrent_expenses", recurrentExpenseColumns},
+		{"recurrent_incomes", recurrentIncomeColumns},
+		{"reference_foods", referenceFoodColumns},
+		{"savings_contributions", savingsContributionColumns},
+		{"savings_goals", savingsGoalColumns},
 		{"tags", tagColumns},
+		{"task_run_failures", taskRunFailureColumns},
+		{"task_runs", taskRunColumns},
 		{"users", userColumns},
 	}
 
@@ -12,6 +12,7 @@ var (
 	ErrInvalidField      = errors.New("invalid field")
 	ErrInvalidSortOrder  = errors.New("invalid sort order")
 	ErrInvalidPagination = errors.New("invalid pagination values")
+	ErrInvalidTrashKind  = errors.New("invalid trash kind")
 )
 
 // IsUniqueViolation reports whether err is SQLite's UNIQUE constraint failure.
@@ -22,3 +23,11 @@ func IsUniqueViolation(err error) bool {
 
 	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
 }
+
+// IsForeignKeyViolation reports whether err is SQLite's FOREIGN KEY constraint
+// failure, which is how a write naming a row that does not exist surfaces.
+func IsForeignKeyViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+}
@@ -0,0 +1,100 @@
+package repo
+
+import "context"
+
+type ExchangeRate struct {
+	ID            int
+	BaseCurrency  string
+	QuoteCurrency string
+	Date          int64
+	Rate          float64
+	CreatedAt     int64
+	UpdatedAt     int64
+}
+
+type UpsertExchangeRateParams struct {
+	BaseCurrency  string
+	QuoteCurrency string
+	Date          int64
+	Rate          float64
+}
+
+// exchangeRateColumns pins the projection order the Scan calls in this file depend on.
+// SELECT * would resolve to whatever order the table happens to have, so an
+// ALTER TABLE could shift values into the wrong struct fields with no error.
+const exchangeRateColumns = `"id", "base_currency", "quote_currency", "date", "rate", "created_at", "updated_at"`
+
+// upsertExchangeRate replaces the rate of a pair on a day, so re-running an
+// import with corrected figures updates them instead of failing.
+const upsertExchangeRate = `
+INSERT INTO "exchange_rates" ("base_currency", "quote_currency", "date", "rate")
+VALUES (?, ?, ?, ?)
+ON CONFLICT ("base_currency", "quote_currency", "date") DO UPDATE SET
+  "rate"       = excluded."rate",
+  "updated_at" = strftime('%s','now')
+RETURNING ` + exchangeRateColumns
+
+func (q *TxQueries) UpsertExchangeRate(ctx context.Context, params UpsertExchangeRateParams) (ExchangeRate, error) {
+	var r ExchangeRate
+
+	err := q.wrapQuery(upsertExchangeRate, func() error {
+		row := q.tx.QueryRowContext(ctx, upsertExchangeRate,
+			params.BaseCurrency, params.QuoteCurrency, params.Date, params.Rate)
+
+		return row.Scan(
+			&r.ID,
+			&r.BaseCurrency,
+			&r.QuoteCurrency,
+			&r.Date,
+			&r.Rate,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+		)
+	})
+
+	return r, err
+}
+
+// expenseHomeAmount is an expense's amount in the home currency bound to its
+// two placeholders. The rate is the pair's, read in either direction, from the
+// latest day on or before the expense; an expense older than every stored rate
+// takes the earliest one after it. With no rate for the pair at all the amount
+// is counted as it is, so a missing import shows up as a wrong total rather
+// than a silently smaller one.
+const expenseHomeAmount = `CASE WHEN "currency" = ?1 THEN "amount" ELSE CAST(ROUND("amount" * COALESCE((
+  SELECT "x"."rate" FROM (
+    SELECT "r"."date" > "expenses"."date" AS "later", abs("r"."date" - "expenses"."date") AS "gap", "r"."rate"
+    FROM "exchange_rates" AS "r"
+    WHERE "r"."base_currency" = "expenses"."currency" AND "r"."quote_currency" = ?1
+    UNION ALL
+    SELECT "r"."date" > "expenses"."date", abs("r"."date" - "expenses"."date"), 1.0 / "r"."rate"
+    FROM "exchange_rates" AS "r"
+    WHERE "r"."base_currency" = ?1 AND "r"."quote_currency" = "expenses"."currency"
+  ) AS "x"
+  ORDER BY "x"."later", "x"."gap"
+  LIMIT 1
+), 1.0)) AS INTEGER) END`
+
+// selectLatestExchangeRate is the newest stored rate that turns ?1 into ?2,
+// read in either direction like expenseHomeAmount. Forecasts convert amounts
+// dated ahead of every stored rate, so the latest one is all they can use.
+const selectLatestExchangeRate = `
+SELECT "x"."rate" FROM (
+  SELECT "date", "rate" FROM "exchange_rates" WHERE "base_currency" = ?1 AND "quote_currency" = ?2
+  UNION ALL
+  SELECT "date", 1.0 / "rate" FROM "exchange_rates" WHERE "base_currency" = ?2 AND "quote_currency" = ?1
+) AS "x"
+ORDER BY "x"."date" DESC
+LIMIT 1`
+
+func (q *Queries) SelectLatestExchangeRate(ctx context.Context, from, to string) (float64, error) {
+	var rate float64
+
+	err := q.wrapQuery(selectLatestExchangeRate, func() error {
+		row := q.db.QueryRowContext(ctx, selectLatestExchangeRate, from, to)
+
+		return row.Scan(&rate)
+	})
+
+	return rate, err
+}
@@ -15,28 +15,41 @@ type Expense struct {
 	Date        int64
 	CreatedAt   int64
 	UpdatedAt   int64
+	DeletedAt   *int64
+	Currency    string
+	// RecurrentExpenseID is the recurrent expense that generated the expense,
+	// nil for one entered by hand or whose generator was deleted.
+	RecurrentExpenseID *int
+	// PaymentAccountID is the account the expense was paid from, if any.
+	PaymentAccountID *int
 }
 
 type InsertExpenseParams struct {
-	UserID      int
-	CategoryID  int
-	Description string
-	Amount      uint64
-	Date        int64
+	UserID             int
+	CategoryID         int
+	Description        string
+	Amount             uint64
+	Date               int64
+	Currency           string
+	RecurrentExpenseID *int
+	PaymentAccountID   *int
 }
 
 type UpdateExpenseParams struct {
-	ID          int
-	CategoryID  int
-	Description string
-	Amount      uint64
-	Date        int64
+	ID               int
+	CategoryID       int
+	Description      string
+	Amount           uint64
+	Date             int64
+	Currency         string
+	PaymentAccountID *int
 }
 
 // expenseColumns pins the projection order the Scan calls in this file depend on.
 // SELECT * would resolve to whatever order the table happens to have, so an
 // ALTER TABLE could shift values into the wrong struct fields with no error.
-const expenseColumns = `"id", "user_id", "category_id", "description", "amount", "date", "created_at", "updated_at"`
+const expenseColumns = `"id", "user_id", "category_id", "description", "amount", "date", "created_at",
+"updated_at", "deleted_at", "currency", "recurrent_expense_id", "payment_account_id"`
 
 const selectExpenses = `SELECT ` + expenseColumns + ` FROM "expenses"`
 
@@ -94,7 +107,7 @@ func (q *Queries) SelectExpenses(ctx context.Context, opts QueryOptions) ([]Expe
 		return es, err
 	}
 
-	subQuery, err := opts.Build()
+	subQuery, err := opts.BuildWithin(notDeleted)
 	if err != nil {
 		return es, err
 	}
@@ -125,6 +138,10 @@ func (q *Queries) SelectExpenses(ctx context.Context, opts QueryOptions) ([]Expe
 				&e.Date,
 				&e.CreatedAt,
 				&e.UpdatedAt,
+				&e.DeletedAt,
+				&e.Currency,
+				&e.RecurrentExpenseID,
+				&e.PaymentAccountID,
 			); err != nil {
 				return err
 			}
@@ -143,7 +160,7 @@ const countExpenses = `SELECT COUNT(*) FROM "expenses"`
 func (q *Queries) CountExpenses(ctx context.Context, filters Filters) (int, error) {
 	var c int
 
-	subQuery, err := filters.Build()
+	subQuery, err := filters.BuildWithin(notDeleted)
 	if err != nil {
 		return 0, err
 	}
@@ -161,7 +178,7 @@ func (q *Queries) CountExpenses(ctx context.Context, filters Filters) (int, erro
 }
 
 const selectExpense = `SELECT ` + expenseColumns + `
-FROM "expenses" WHERE "id" = ? AND "user_id" = ? LIMIT 1`
+FROM "expenses" WHERE "id" = ? AND "user_id" = ? AND "deleted_at" IS NULL LIMIT 1`
 
 func (q *Queries) SelectExpense(ctx context.Context, id, userID int) (Expense, error) {
 	var e Expense
@@ -178,6 +195,10 @@ func (q *Queries) SelectExpense(ctx context.Context, id, userID int) (Expense, e
 			&e.Date,
 			&e.CreatedAt,
 			&e.UpdatedAt,
+			&e.DeletedAt,
+			&e.Currency,
+			&e.RecurrentExpenseID,
+			&e.PaymentAccountID,
 		)
 	})
 
@@ -185,8 +206,11 @@ func (q *Queries) SelectExpense(ctx context.Context, id, userID int) (Expense, e
 }
 
 const insertExpense = `
-INSERT INTO "expenses" ("user_id", "category_id", "description", "amount", "date")
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO "expenses" (
+  "user_id", "category_id", "description", "amount", "date", "currency", "recurrent_expense_id",
+  "payment_account_id"
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING ` + expenseColumns
 
 func (q *Queries) InsertExpense(ctx context.Context, params InsertExpenseParams) (Expense, error) {
@@ -201,6 +225,9 @@ func (q *Queries) InsertExpense(ctx context.Context, params InsertExpenseParams)
 			params.Description,
 			params.Amount,
 			params.Date,
+			params.Currency,
+			params.RecurrentExpenseID,
+			params.PaymentAccountID,
 		)
 
 		return row.Scan(
@@ -212,6 +239,10 @@ func (q *Queries) InsertExpense(ctx context.Context, params InsertExpenseParams)
 			&e.Date,
 			&e.CreatedAt,
 			&e.UpdatedAt,
+			&e.DeletedAt,
+			&e.Currency,
+			&e.RecurrentExpenseID,
+			&e.PaymentAccountID,
 		)
 	})
 
@@ -230,6 +261,9 @@ func (q *TxQueries) InsertExpense(ctx context.Context, params InsertExpenseParam
 			params.Description,
 			params.Amount,
 			params.Date,
+			params.Currency,
+			params.RecurrentExpenseID,
+			params.PaymentAccountID,
 		)
 
 		return row.Scan(
@@ -241,21 +275,30 @@ func (q *TxQueries) InsertExpense(ctx context.Context, params InsertExpenseParam
 			&e.Date,
 			&e.CreatedAt,
 			&e.UpdatedAt,
+			&e.DeletedAt,
+			&e.Currency,
+			&e.RecurrentExpenseID,
+			&e.PaymentAccountID,
 		)
 	})
 
 	return e, err
 }
 
+// updateExpense keeps the current currency when params.Currency is empty. The
+// payment account is always replaced, so clearing it in the form sticks.
 const updateExpense = `
 UPDATE "expenses"
-SET "category_id" = ?,
-    "description" = ?,
-    "amount"      = ?,
-    "date"        = ?,
-    "updated_at"  = ?
+SET "category_id"        = ?,
+    "description"        = ?,
+    "amount"             = ?,
+    "date"               = ?,
+    "currency"           = COALESCE(NULLIF(?, ''), "currency"),
+    "payment_account_id" = ?,
+    "updated_at"         = ?
 WHERE "id" = ?
   AND "user_id" = ?
+  AND "deleted_at" IS NULL
 RETURNING ` + expenseColumns + `;
 `
 
@@ -274,6 +317,8 @@ func (q *Queries) UpdateExpense(
 			params.Description,
 			params.Amount,
 			params.Date,
+			params.Currency,
+			params.PaymentAccountID,
 			newUpdatedAt(),
 			params.ID,
 			userID,
@@ -288,6 +333,10 @@ func (q *Queries) UpdateExpense(
 			&e.Date,
 			&e.CreatedAt,
 			&e.UpdatedAt,
+			&e.DeletedAt,
+			&e.Currency,
+			&e.RecurrentExpenseID,
+			&e.PaymentAccountID,
 		)
 	})
 
@@ -309,6 +358,8 @@ func (q *TxQueries) UpdateExpense(
 			params.Description,
 			params.Amount,
 			params.Date,
+			params.Currency,
+			params.PaymentAccountID,
 			newUpdatedAt(),
 			params.ID,
 			userID,
@@ -323,13 +374,22 @@ func (q *TxQueries) UpdateExpense(
 			&e.Date,
 			&e.CreatedAt,
 			&e.UpdatedAt,
+			&e.DeletedAt,
+			&e.Currency,
+			&e.RecurrentExpenseID,
+			&e.PaymentAccountID,
 		)
 	})
 
 	return e, err
 }
 
-const deleteExpense = `DELETE FROM "expenses" WHERE "id" = ? AND "user_id" = ? RETURNING "id"`
+// deleteExpense moves the expense to the trash. Its taggings stay, so a restore
+// brings the tags back; PurgeTrashed removes both for good.
+const deleteExpense = `
+UPDATE "expenses" SET "deleted_at" = strftime('%s','now')
+WHERE "id" = ? AND "user_id" = ? AND "deleted_at" IS NULL
+RETURNING "id"`
 
 func (q *Queries) DeleteExpense(ctx context.Context, id, userID int) (int, error) {
 	var i int
@@ -343,7 +403,7 @@ func (q *Queries) DeleteExpense(ctx context.Context, id, userID int) (int, error
 	return i, err
 }
 
-const countExpensesByUser = `SELECT COUNT(*) FROM "expenses" WHERE "user_id" = ?`
+const countExpensesByUser = `SELECT COUNT(*) FROM "expenses" WHERE "user_id" = ? AND "deleted_at" IS NULL`
 
 func (q *Queries) CountExpensesByUser(ctx context.Context, userID int) (int, error) {
 	var c int
@@ -357,46 +417,60 @@ func (q *Queries) CountExpensesByUser(ctx context.Context, userID int) (int, err
 	return c, err
 }
 
-const deleteExpenseTaggingsByUser = `
-DELETE FROM "taggings"
-WHERE "taggable_type" = 'expense'
-  AND "taggable_id" IN (SELECT "id" FROM "expenses" WHERE "user_id" = ?)`
-
-const deleteAllExpensesByUser = `DELETE FROM "expenses" WHERE "user_id" = ?`
+const deleteAllExpensesByUser = `
+UPDATE "expenses" SET "deleted_at" = strftime('%s','now')
+WHERE "user_id" = ? AND "deleted_at" IS NULL`
 
 func (q *TxQueries) DeleteAllExpensesByUser(ctx context.Context, userID int) error {
 	return q.wrapQuery(deleteAllExpensesByUser, func() error {
-		if _, err := q.tx.ExecContext(ctx, deleteExpenseTaggingsByUser, userID); err != nil {
-			return err
-		}
-
 		_, err := q.tx.ExecContext(ctx, deleteAllExpensesByUser, userID)
 
 		return err
 	})
 }
 
+// expenseCategoryGroup is what the category totals group by: the expense's own
+// category, or with rollUp its parent when it has one, so a parent's total
+// takes in every child's. A subquery rather than a JOIN keeps the unqualified
+// columns the filters produce unambiguous.
+func expenseCategoryGroup(rollUp bool) string {
+	if !rollUp {
+		return `"category_id"`
+	}
+
+	return `COALESCE((SELECT "c"."parent_id" FROM "categories" AS "c" WHERE "c"."id" = "expenses"."category_id"),
+  "category_id")`
+}
+
+// The category totals sum amounts converted to the home currency; see
+// expenseHomeAmount. They read expensesWithSplits, so a split expense counts
+// toward each split's category rather than its own.
 const selectExpensesCategoryTotals = `
-SELECT "category_id", SUM("amount") AS "total"
-FROM "expenses"
+SELECT %s AS "group_category_id", SUM(` + expenseHomeAmount + `) AS "total"
+FROM ` + expensesWithSplits + `
 %s
-GROUP BY "category_id"`
+GROUP BY "group_category_id"`
 
 type ExpenseCategoryTotal struct {
 	CategoryID int
 	Total      uint64
 }
 
-func (q *Queries) SelectExpensesCategoryTotals(ctx context.Context, filters Filters) ([]ExpenseCategoryTotal, error) {
+func (q *Queries) SelectExpensesCategoryTotals(
+	ctx context.Context,
+	filters Filters,
+	homeCurrency string,
+	rollUp bool,
+) ([]ExpenseCategoryTotal, error) {
 	var totals []ExpenseCategoryTotal
 
-	filterSubQuery, err := filters.Build()
+	filterSubQuery, err := filters.BuildWithin(notDeleted)
 	if err != nil {
 		return totals, err
 	}
 
-	query := fmt.Sprintf(selectExpensesCategoryTotals, filterSubQuery)
-	values := filters.Values()
+	query := fmt.Sprintf(selectExpensesCategoryTotals, expenseCategoryGroup(rollUp), filterSubQuery)
+	values := append([]any{homeCurrency}, filters.Values()...)
 
 	err = q.wrapQuery(query, func() error {
 		rows, err := q.db.QueryContext(ctx, query, values...)
@@ -430,10 +504,11 @@ func (q *Queries) SelectExpensesCategoryTotals(ctx context.Context, filters Filt
 // boundaries computeDateRange builds with time.UTC — do not switch one side to
 // the client zone.
 const selectExpensesCategoryMonthTotals = `
-SELECT "category_id", strftime('%%Y-%%m', "date", 'unixepoch') AS "month", SUM("amount") AS "total"
-FROM "expenses"
+SELECT %s AS "group_category_id", strftime('%%Y-%%m', "date", 'unixepoch') AS "month",
+  SUM(` + expenseHomeAmount + `) AS "total"
+FROM ` + expensesWithSplits + `
 %s
-GROUP BY "category_id", "month"`
+GROUP BY "group_category_id", "month"`
 
 type ExpenseCategoryMonthTotal struct {
 	CategoryID int
@@ -444,16 +519,18 @@ type ExpenseCategoryMonthTotal struct {
 func (q *Queries) SelectExpensesCategoryMonthTotals(
 	ctx context.Context,
 	filters Filters,
+	homeCurrency string,
+	rollUp bool,
 ) ([]ExpenseCategoryMonthTotal, error) {
 	var totals []ExpenseCategoryMonthTotal
 
-	filterSubQuery, err := filters.Build()
+	filterSubQuery, err := filters.BuildWithin(notDeleted)
 	if err != nil {
 		return totals, err
 	}
 
-	query := fmt.Sprintf(selectExpensesCategoryMonthTotals, filterSubQuery)
-	values := filters.Values()
+	query := fmt.Sprintf(selectExpensesCategoryMonthTotals, expenseCategoryGroup(rollUp), filterSubQuery)
+	values := append([]any{homeCurrency}, filters.Values()...)
 
 	err = q.wrapQuery(query, func() error {
 		rows, err := q.db.QueryContext(ctx, query, values...)
@@ -482,6 +559,49 @@ func (q *Queries) SelectExpensesCategoryMonthTotals(
 	return totals, err
 }
 
+func (q *TxQueries) SelectExpensesCategoryMonthTotals(
+	ctx context.Context,
+	filters Filters,
+	homeCurrency string,
+	rollUp bool,
+) ([]ExpenseCategoryMonthTotal, error) {
+	var totals []ExpenseCategoryMonthTotal
+
+	filterSubQuery, err := filters.BuildWithin(notDeleted)
+	if err != nil {
+		return totals, err
+	}
+
+	query := fmt.Sprintf(selectExpensesCategoryMonthTotals, expenseCategoryGroup(rollUp), filterSubQuery)
+	values := append([]any{homeCurrency}, filters.Values()...)
+
+	err = q.wrapQuery(query, func() error {
+		rows, err := q.tx.QueryContext(ctx, query, values...)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var t ExpenseCategoryMonthTotal
+
+			if err := rows.Scan(&t.CategoryID, &t.Month, &t.Total); err != nil {
+				return err
+			}
+
+			totals = append(totals, t)
+		}
+
+		return rows.Err()
+	})
+
+	return totals, err
+}
+
 func validExpenseFields() []string {
 	return []string{
 		"id",
@@ -492,5 +612,7 @@ func validExpenseFields() []string {
 		"date",
 		"created_at",
 		"updated_at",
+		"recurrent_expense_id",
+		"payment_account_id",
 	}
 }
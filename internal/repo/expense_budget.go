@@ -2,38 +2,65 @@ package repo
 
 import (
 	"context"
+	"database/sql"
 )
 
+// ExpenseBudget is one version of a monthly budget, in force from
+// EffectiveFrom ('YYYY-MM') until the next version of the same budget. A nil
+// CategoryID is the whole-month budget across every category. An Amount of
+// zero ends the budget from EffectiveFrom on.
 type ExpenseBudget struct {
-	ID         int
-	UserID     int
-	CategoryID int
-	Amount     uint64
-	CreatedAt  int64
-	UpdatedAt  int64
+	ID            int
+	UserID        int
+	CategoryID    *int
+	Amount        uint64
+	Rollover      bool
+	EffectiveFrom string
+	CreatedAt     int64
+	UpdatedAt     int64
 }
 
 type UpsertExpenseBudgetParams struct {
-	UserID     int
-	CategoryID int
-	Amount     uint64
+	UserID        int
+	CategoryID    *int
+	Amount        uint64
+	Rollover      bool
+	EffectiveFrom string
 }
 
 // expenseBudgetColumns pins the projection order the Scan calls in this file
 // depend on. SELECT * would resolve to whatever order the table happens to
 // have, so an ALTER TABLE could shift values into the wrong struct fields with
 // no error.
-const expenseBudgetColumns = `"id", "user_id", "category_id", "amount",
-"created_at", "updated_at"`
-
-const selectExpenseBudgetsByUser = `SELECT ` + expenseBudgetColumns + `
-FROM "expense_budgets" WHERE "user_id" = ?`
-
-func (q *Queries) SelectExpenseBudgetsByUser(ctx context.Context, userID int) ([]ExpenseBudget, error) {
+const expenseBudgetColumns = `"id", "user_id", "category_id", "amount", "rollover",
+"effective_from", "created_at", "updated_at"`
+
+// expenseBudgetInEffect picks, for the budget of the outer row "b", the
+// version in force in the month bound to the two placeholders: the latest one
+// starting on or before it, or failing that the earliest, since a budget's
+// first version also measures the months before it was set.
+const expenseBudgetInEffect = `(
+  SELECT "v"."id" FROM "expense_budgets" AS "v"
+  WHERE "v"."user_id" = "b"."user_id" AND "v"."category_id" IS "b"."category_id"
+  ORDER BY "v"."effective_from" <= ? DESC,
+    CASE WHEN "v"."effective_from" <= ? THEN "v"."effective_from" END DESC,
+    "v"."effective_from"
+  LIMIT 1
+)`
+
+const selectExpenseBudgetsByUser = `SELECT "b"."id", "b"."user_id", "b"."category_id", "b"."amount",
+  "b"."rollover", "b"."effective_from", "b"."created_at", "b"."updated_at"
+FROM "expense_budgets" AS "b"
+WHERE "b"."user_id" = ? AND "b"."amount" > 0 AND "b"."id" = ` + expenseBudgetInEffect
+
+// SelectExpenseBudgetsByUser returns the budgets in force in month, one per
+// category plus the whole-month budget when there is one. Budgets a version of
+// zero ended are left out.
+func (q *Queries) SelectExpenseBudgetsByUser(ctx context.Context, userID int, month string) ([]ExpenseBudget, error) {
 	var budgets []ExpenseBudget
 
 	err := q.wrapQuery(selectExpenseBudgetsByUser, func() error {
-		rows, err := q.db.QueryContext(ctx, selectExpenseBudgetsByUser, userID)
+		rows, err := q.db.QueryContext(ctx, selectExpenseBudgetsByUser, userID, month, month)
 		if err != nil {
 			return err
 		}
@@ -43,36 +70,74 @@ func (q *Queries) SelectExpenseBudgetsByUser(ctx context.Context, userID int) ([
 			}
 		}()
 
-		for rows.Next() {
-			var b ExpenseBudget
-
-			if err := rows.Scan(
-				&b.ID,
-				&b.UserID,
-				&b.CategoryID,
-				&b.Amount,
-				&b.CreatedAt,
-				&b.UpdatedAt,
-			); err != nil {
-				return err
+		budgets, err = scanExpenseBudgets(rows)
+
+		return err
+	})
+
+	return budgets, err
+}
+
+const selectExpenseBudgetVersionsByUser = `SELECT ` + expenseBudgetColumns + `
+FROM "expense_budgets" WHERE "user_id" = ?
+ORDER BY COALESCE("category_id", 0), "effective_from"`
+
+// SelectExpenseBudgetVersionsByUser returns every version of every budget,
+// grouped by budget and oldest first within each.
+func (q *Queries) SelectExpenseBudgetVersionsByUser(ctx context.Context, userID int) ([]ExpenseBudget, error) {
+	var budgets []ExpenseBudget
+
+	err := q.wrapQuery(selectExpenseBudgetVersionsByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectExpenseBudgetVersionsByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
 			}
+		}()
+
+		budgets, err = scanExpenseBudgets(rows)
+
+		return err
+	})
+
+	return budgets, err
+}
 
-			budgets = append(budgets, b)
+func (q *TxQueries) SelectExpenseBudgetVersionsByUser(ctx context.Context, userID int) ([]ExpenseBudget, error) {
+	var budgets []ExpenseBudget
+
+	err := q.wrapQuery(selectExpenseBudgetVersionsByUser, func() error {
+		rows, err := q.tx.QueryContext(ctx, selectExpenseBudgetVersionsByUser, userID)
+		if err != nil {
+			return err
 		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		budgets, err = scanExpenseBudgets(rows)
 
-		return rows.Err()
+		return err
 	})
 
 	return budgets, err
 }
 
-const countExpenseBudgetsByUser = `SELECT COUNT(*) FROM "expense_budgets" WHERE "user_id" = ?`
+const countExpenseBudgetsByUser = `
+SELECT COUNT(*) FROM "expense_budgets" AS "b"
+WHERE "b"."user_id" = ? AND "b"."amount" > 0 AND "b"."id" = ` + expenseBudgetInEffect
 
-func (q *Queries) CountExpenseBudgetsByUser(ctx context.Context, userID int) (int, error) {
+// CountExpenseBudgetsByUser counts the budgets in force in month.
+func (q *Queries) CountExpenseBudgetsByUser(ctx context.Context, userID int, month string) (int, error) {
 	var c int
 
 	err := q.wrapQuery(countExpenseBudgetsByUser, func() error {
-		row := q.db.QueryRowContext(ctx, countExpenseBudgetsByUser, userID)
+		row := q.db.QueryRowContext(ctx, countExpenseBudgetsByUser, userID, month, month)
 
 		return row.Scan(&c)
 	})
@@ -81,13 +146,16 @@ func (q *Queries) CountExpenseBudgetsByUser(ctx context.Context, userID int) (in
 }
 
 const upsertExpenseBudget = `
-INSERT INTO "expense_budgets" ("user_id","category_id","amount")
-VALUES (?,?,?)
-ON CONFLICT ("user_id","category_id") DO UPDATE SET
+INSERT INTO "expense_budgets" ("user_id","category_id","amount","rollover","effective_from")
+VALUES (?,?,?,?,?)
+ON CONFLICT ("user_id", COALESCE("category_id", 0), "effective_from") DO UPDATE SET
   "amount"     = excluded."amount",
+  "rollover"   = excluded."rollover",
   "updated_at" = strftime('%s','now')
 RETURNING ` + expenseBudgetColumns
 
+// UpsertExpenseBudget writes the version of a budget starting in
+// params.EffectiveFrom, replacing one already saved for that month.
 func (q *TxQueries) UpsertExpenseBudget(
 	ctx context.Context,
 	params UpsertExpenseBudgetParams,
@@ -101,6 +169,8 @@ func (q *TxQueries) UpsertExpenseBudget(
 			params.UserID,
 			params.CategoryID,
 			params.Amount,
+			params.Rollover,
+			params.EffectiveFrom,
 		)
 
 		return row.Scan(
@@ -108,6 +178,8 @@ func (q *TxQueries) UpsertExpenseBudget(
 			&b.UserID,
 			&b.CategoryID,
 			&b.Amount,
+			&b.Rollover,
+			&b.EffectiveFrom,
 			&b.CreatedAt,
 			&b.UpdatedAt,
 		)
@@ -117,11 +189,13 @@ func (q *TxQueries) UpsertExpenseBudget(
 }
 
 const deleteExpenseBudget = `
-DELETE FROM "expense_budgets" WHERE "user_id" = ? AND "category_id" = ?`
+DELETE FROM "expense_budgets"
+WHERE "user_id" = ? AND "category_id" IS ? AND "effective_from" = ?`
 
-func (q *TxQueries) DeleteExpenseBudget(ctx context.Context, userID, categoryID int) error {
+// DeleteExpenseBudget drops the version of a budget starting in month.
+func (q *TxQueries) DeleteExpenseBudget(ctx context.Context, userID int, categoryID *int, month string) error {
 	return q.wrapQuery(deleteExpenseBudget, func() error {
-		_, err := q.tx.ExecContext(ctx, deleteExpenseBudget, userID, categoryID)
+		_, err := q.tx.ExecContext(ctx, deleteExpenseBudget, userID, categoryID, month)
 
 		return err
 	})
@@ -136,3 +210,28 @@ func (q *TxQueries) DeleteAllExpenseBudgetsByUser(ctx context.Context, userID in
 		return err
 	})
 }
+
+func scanExpenseBudgets(rows *sql.Rows) ([]ExpenseBudget, error) {
+	var budgets []ExpenseBudget
+
+	for rows.Next() {
+		var b ExpenseBudget
+
+		if err := rows.Scan(
+			&b.ID,
+			&b.UserID,
+			&b.CategoryID,
+			&b.Amount,
+			&b.Rollover,
+			&b.EffectiveFrom,
+			&b.CreatedAt,
+			&b.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		budgets = append(budgets, b)
+	}
+
+	return budgets, rows.Err()
+}
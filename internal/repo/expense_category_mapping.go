@@ -96,3 +96,49 @@ func (q *TxQueries) UpsertExpenseCategoryMapping(
 
 	return m, err
 }
+
+const selectExpenseCategoryMappingsByUser = `
+SELECT ` + expenseCategoryMappingColumns + ` FROM "expense_category_mappings"
+WHERE "user_id" = ?`
+
+// SelectExpenseCategoryMappingsByUser returns every remembered mapping for a
+// user, for callers resolving many descriptions at once.
+func (q *Queries) SelectExpenseCategoryMappingsByUser(
+	ctx context.Context,
+	userID int,
+) ([]ExpenseCategoryMapping, error) {
+	var mappings []ExpenseCategoryMapping
+
+	err := q.wrapQuery(selectExpenseCategoryMappingsByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectExpenseCategoryMappingsByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var m ExpenseCategoryMapping
+
+			if err := rows.Scan(
+				&m.ID,
+				&m.UserID,
+				&m.CategoryID,
+				&m.DescriptionKey,
+				&m.CreatedAt,
+				&m.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			mappings = append(mappings, m)
+		}
+
+		return rows.Err()
+	})
+
+	return mappings, err
+}
@@ -0,0 +1,182 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+)
+
+const selectExpensesByAmountDate = `SELECT ` + expenseColumns + `
+FROM "expenses"
+WHERE "user_id" = ? AND "amount" = ? AND "date" = ? AND "deleted_at" IS NULL
+ORDER BY "id"`
+
+// SelectExpensesByAmountDate returns the user's expenses with exactly this
+// amount and date. Descriptions are compared by the caller, which owns the
+// normalization rules.
+func (q *Queries) SelectExpensesByAmountDate(
+	ctx context.Context,
+	userID int,
+	amount uint64,
+	date int64,
+) ([]Expense, error) {
+	var expenses []Expense
+
+	err := q.wrapQuery(selectExpensesByAmountDate, func() error {
+		rows, err := q.db.QueryContext(ctx, selectExpensesByAmountDate, userID, amount, date)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		expenses, err = scanExpenseRows(rows)
+
+		return err
+	})
+
+	return expenses, err
+}
+
+// SelectExpensesByAmountDate is the transactional variant, so a bulk import
+// also sees the rows it inserted earlier in the same transaction.
+func (q *TxQueries) SelectExpensesByAmountDate(
+	ctx context.Context,
+	userID int,
+	amount uint64,
+	date int64,
+) ([]Expense, error) {
+	var expenses []Expense
+
+	err := q.wrapQuery(selectExpensesByAmountDate, func() error {
+		rows, err := q.tx.QueryContext(ctx, selectExpensesByAmountDate, userID, amount, date)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		expenses, err = scanExpenseRows(rows)
+
+		return err
+	})
+
+	return expenses, err
+}
+
+const selectExpensesByDateRange = `SELECT ` + expenseColumns + `
+FROM "expenses"
+WHERE "user_id" = ? AND "date" >= ? AND "date" <= ? AND "deleted_at" IS NULL
+ORDER BY "id"`
+
+// SelectExpensesByDateRange returns the user's expenses dated from from to
+// until, both included, so a batch of rows can be checked for duplicates
+// against one read instead of one per row.
+func (q *Queries) SelectExpensesByDateRange(
+	ctx context.Context,
+	userID int,
+	from, until int64,
+) ([]Expense, error) {
+	var expenses []Expense
+
+	err := q.wrapQuery(selectExpensesByDateRange, func() error {
+		rows, err := q.db.QueryContext(ctx, selectExpensesByDateRange, userID, from, until)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		expenses, err = scanExpenseRows(rows)
+
+		return err
+	})
+
+	return expenses, err
+}
+
+// selectDuplicateExpenseCandidates narrows the review page to rows sharing an
+// amount and date with at least one other row; "idx_expenses_user_date" keeps
+// both halves to the user's own rows.
+const selectDuplicateExpenseCandidates = `SELECT ` + expenseColumns + `
+FROM "expenses"
+WHERE "user_id" = ?
+  AND "deleted_at" IS NULL
+  AND ("amount", "date") IN (
+    SELECT "amount", "date" FROM "expenses"
+    WHERE "user_id" = ? AND "deleted_at" IS NULL
+    GROUP BY "amount", "date"
+    HAVING COUNT(*) > 1
+  )
+ORDER BY "date" DESC, "amount" DESC, "id"`
+
+func (q *Queries) SelectDuplicateExpenseCandidates(ctx context.Context, userID int) ([]Expense, error) {
+	var expenses []Expense
+
+	err := q.wrapQuery(selectDuplicateExpenseCandidates, func() error {
+		rows, err := q.db.QueryContext(ctx, selectDuplicateExpenseCandidates, userID, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		expenses, err = scanExpenseRows(rows)
+
+		return err
+	})
+
+	return expenses, err
+}
+
+func (q *TxQueries) DeleteExpense(ctx context.Context, id, userID int) (int, error) {
+	var i int
+
+	err := q.wrapQuery(deleteExpense, func() error {
+		row := q.tx.QueryRowContext(ctx, deleteExpense, id, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
+
+func scanExpenseRows(rows *sql.Rows) ([]Expense, error) {
+	var expenses []Expense
+
+	for rows.Next() {
+		var e Expense
+
+		if err := rows.Scan(
+			&e.ID,
+			&e.UserID,
+			&e.CategoryID,
+			&e.Description,
+			&e.Amount,
+			&e.Date,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+			&e.DeletedAt,
+			&e.Currency,
+			&e.RecurrentExpenseID,
+			&e.PaymentAccountID,
+		); err != nil {
+			return nil, err
+		}
+
+		expenses = append(expenses, e)
+	}
+
+	return expenses, rows.Err()
+}
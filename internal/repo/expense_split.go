@@ -0,0 +1,235 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ExpenseSplit files part of an expense under its own category. Amount is in
+// cents of the expense's currency; the splits of an expense add up to its
+// amount.
+type ExpenseSplit struct {
+	ID         int
+	UserID     int
+	ExpenseID  int
+	CategoryID int
+	Amount     uint64
+	CreatedAt  int64
+	UpdatedAt  int64
+}
+
+type InsertExpenseSplitParams struct {
+	UserID     int
+	ExpenseID  int
+	CategoryID int
+	Amount     uint64
+}
+
+// expenseSplitColumns pins the projection order the Scan calls in this file
+// depend on.
+const expenseSplitColumns = `"id", "user_id", "expense_id", "category_id", "amount", "created_at", "updated_at"`
+
+// expensesWithSplits stands in for the expenses table where amounts are
+// attributed to categories: one row per split line carrying the split's
+// category and amount, or the expense itself when it has none. It keeps the
+// name and every column the expense filters use, so a filter or expression
+// written against "expenses" reads the same against it.
+const expensesWithSplits = `(
+  SELECT "e"."id", "e"."user_id", COALESCE("s"."category_id", "e"."category_id") AS "category_id",
+    "e"."description", COALESCE("s"."amount", "e"."amount") AS "amount", "e"."date", "e"."created_at",
+    "e"."updated_at", "e"."deleted_at", "e"."currency", "e"."recurrent_expense_id", "e"."payment_account_id"
+  FROM "expenses" AS "e"
+  LEFT JOIN "expense_splits" AS "s" ON "s"."expense_id" = "e"."id"
+) AS "expenses"`
+
+const insertExpenseSplit = `
+INSERT INTO "expense_splits" ("user_id", "expense_id", "category_id", "amount")
+VALUES (?, ?, ?, ?)
+RETURNING ` + expenseSplitColumns
+
+func (q *TxQueries) InsertExpenseSplit(ctx context.Context, params InsertExpenseSplitParams) (ExpenseSplit, error) {
+	var s ExpenseSplit
+
+	err := q.wrapQuery(insertExpenseSplit, func() error {
+		row := q.tx.QueryRowContext(
+			ctx,
+			insertExpenseSplit,
+			params.UserID,
+			params.ExpenseID,
+			params.CategoryID,
+			params.Amount,
+		)
+
+		return row.Scan(
+			&s.ID,
+			&s.UserID,
+			&s.ExpenseID,
+			&s.CategoryID,
+			&s.Amount,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		)
+	})
+
+	return s, err
+}
+
+const selectExpenseSplits = `
+SELECT ` + expenseSplitColumns + ` FROM "expense_splits"
+WHERE "expense_id" = ? AND "user_id" = ?
+ORDER BY "id"`
+
+func (q *Queries) SelectExpenseSplits(ctx context.Context, expenseID, userID int) ([]ExpenseSplit, error) {
+	var ss []ExpenseSplit
+
+	err := q.wrapQuery(selectExpenseSplits, func() error {
+		rows, err := q.db.QueryContext(ctx, selectExpenseSplits, expenseID, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		ss, err = scanExpenseSplits(rows)
+
+		return err
+	})
+
+	return ss, err
+}
+
+const selectExpenseSplitsByExpenseIDsBase = `
+SELECT ` + expenseSplitColumns + ` FROM "expense_splits"
+WHERE "user_id" = ? AND "expense_id" IN (%s)
+ORDER BY "expense_id", "id"`
+
+// SelectExpenseSplitsByExpenseIDs reads the splits of many expenses at once,
+// in the same bounded batches as SelectTagRows.
+func (q *Queries) SelectExpenseSplitsByExpenseIDs(
+	ctx context.Context,
+	expenseIDs []int,
+	userID int,
+) ([]ExpenseSplit, error) {
+	var ss []ExpenseSplit
+
+	for chunk := range slices.Chunk(expenseIDs, tagRowChunkSize) {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ",")
+		query := fmt.Sprintf(selectExpenseSplitsByExpenseIDsBase, placeholders)
+
+		values := make([]any, 0, len(chunk)+1)
+		values = append(values, userID)
+		for _, id := range chunk {
+			values = append(values, id)
+		}
+
+		err := q.wrapQuery(query, func() error {
+			rows, err := q.db.QueryContext(ctx, query, values...)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if closeErr := rows.Close(); closeErr != nil {
+					q.app.Logger.Error(closeErr)
+				}
+			}()
+
+			chunkSplits, err := scanExpenseSplits(rows)
+			ss = append(ss, chunkSplits...)
+
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ss, nil
+}
+
+// selectExpenseSplitsByUser leaves out the splits of trashed expenses, since a
+// backup does not carry the trash.
+const selectExpenseSplitsByUser = `
+SELECT ` + expenseSplitColumns + ` FROM "expense_splits"
+WHERE "user_id" = ?
+  AND "expense_id" NOT IN (SELECT "id" FROM "expenses" WHERE "deleted_at" IS NOT NULL)
+ORDER BY "id"`
+
+func (q *Queries) SelectExpenseSplitsByUser(ctx context.Context, userID int) ([]ExpenseSplit, error) {
+	var ss []ExpenseSplit
+
+	err := q.wrapQuery(selectExpenseSplitsByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectExpenseSplitsByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		ss, err = scanExpenseSplits(rows)
+
+		return err
+	})
+
+	return ss, err
+}
+
+// Split taggings have no foreign key to cascade through, so they are deleted
+// before the splits they point at.
+const (
+	deleteExpenseSplitTaggings = `
+DELETE FROM "taggings"
+WHERE "taggable_type" = '` + TaggableTypeExpenseSplit + `'
+  AND "taggable_id" IN (SELECT "id" FROM "expense_splits" WHERE "expense_id" = ?)`
+
+	deleteExpenseSplits = `DELETE FROM "expense_splits" WHERE "expense_id" = ?`
+)
+
+// DeleteExpenseSplits removes every split of the expense along with their
+// taggings.
+func (q *TxQueries) DeleteExpenseSplits(ctx context.Context, expenseID int) error {
+	for _, query := range []string{deleteExpenseSplitTaggings, deleteExpenseSplits} {
+		err := q.wrapQuery(query, func() error {
+			_, err := q.tx.ExecContext(ctx, query, expenseID)
+
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanExpenseSplits(rows *sql.Rows) ([]ExpenseSplit, error) {
+	var ss []ExpenseSplit
+
+	for rows.Next() {
+		var s ExpenseSplit
+
+		if err := rows.Scan(
+			&s.ID,
+			&s.UserID,
+			&s.ExpenseID,
+			&s.CategoryID,
+			&s.Amount,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		ss = append(ss, s)
+	}
+
+	return ss, rows.Err()
+}
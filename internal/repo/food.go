@@ -17,6 +17,11 @@ type Food struct {
 	FiberG        float64
 	SodiumG       float64
 	SaturatedFatG float64
+	DeletedAt     *int64
+	BaseAmount    float64
+	BaseUnit      string
+	DensityGPerML *float64
+	Barcode       *string
 }
 
 type InsertFoodParams struct {
@@ -29,6 +34,10 @@ type InsertFoodParams struct {
 	FiberG        float64
 	SodiumG       float64
 	SaturatedFatG float64
+	BaseAmount    float64
+	BaseUnit      string
+	DensityGPerML *float64
+	Barcode       *string
 }
 
 type UpdateFoodParams struct {
@@ -41,16 +50,33 @@ type UpdateFoodParams struct {
 	FiberG        float64
 	SodiumG       float64
 	SaturatedFatG float64
+	BaseAmount    float64
+	BaseUnit      string
+	DensityGPerML *float64
+	Barcode       *string
 }
 
 // foodColumns pins the projection order the Scan calls in this file depend on.
 // SELECT * would resolve to whatever order the table happens to have, so an
 // ALTER TABLE could shift values into the wrong struct fields with no error.
 const foodColumns = `"id", "user_id", "name", "kcal", "protein_g", "carbs_g", "fat_g",
-"created_at", "updated_at", "fiber_g", "sodium_g", "saturated_fat_g"`
+"created_at", "updated_at", "fiber_g", "sodium_g", "saturated_fat_g", "deleted_at",
+"base_amount", "base_unit", "density_g_per_ml", "barcode"`
 
 const selectFoods = `SELECT ` + foodColumns + ` FROM "foods"`
 
+// foodNameExpr matches a name substring, case-insensitively for ASCII.
+const foodNameExpr = `"name" LIKE ? ESCAPE '\'`
+
+// FoodNameFilter builds a "name contains" predicate. LIKE wildcards in term
+// are escaped so they match literally.
+func FoodNameFilter(term string) FilterField {
+	return FilterField{
+		Expr: foodNameExpr,
+		Args: []any{"%" + escapeLikePattern(term) + "%"},
+	}
+}
+
 func (q *Queries) SelectFoods(ctx context.Context, opts QueryOptions) ([]Food, error) {
 	var fs []Food
 
@@ -58,7 +84,7 @@ func (q *Queries) SelectFoods(ctx context.Context, opts QueryOptions) ([]Food, e
 		return fs, err
 	}
 
-	subQuery, err := opts.Build()
+	subQuery, err := opts.BuildWithin(notDeleted)
 	if err != nil {
 		return fs, err
 	}
@@ -93,6 +119,11 @@ func (q *Queries) SelectFoods(ctx context.Context, opts QueryOptions) ([]Food, e
 				&f.FiberG,
 				&f.SodiumG,
 				&f.SaturatedFatG,
+				&f.DeletedAt,
+				&f.BaseAmount,
+				&f.BaseUnit,
+				&f.DensityGPerML,
+				&f.Barcode,
 			); err != nil {
 				return err
 			}
@@ -107,7 +138,7 @@ func (q *Queries) SelectFoods(ctx context.Context, opts QueryOptions) ([]Food, e
 }
 
 const selectFood = `SELECT ` + foodColumns + `
-FROM "foods" WHERE "id" = ? AND "user_id" = ? LIMIT 1`
+FROM "foods" WHERE "id" = ? AND "user_id" = ? AND "deleted_at" IS NULL LIMIT 1`
 
 func (q *Queries) SelectFood(ctx context.Context, id, userID int) (Food, error) {
 	var f Food
@@ -128,6 +159,11 @@ func (q *Queries) SelectFood(ctx context.Context, id, userID int) (Food, error)
 			&f.FiberG,
 			&f.SodiumG,
 			&f.SaturatedFatG,
+			&f.DeletedAt,
+			&f.BaseAmount,
+			&f.BaseUnit,
+			&f.DensityGPerML,
+			&f.Barcode,
 		)
 	})
 
@@ -137,8 +173,8 @@ func (q *Queries) SelectFood(ctx context.Context, id, userID int) (Food, error)
 const insertFood = `
 INSERT INTO "foods"
   ("user_id", "name", "kcal", "protein_g", "carbs_g", "fat_g",
-   "fiber_g", "sodium_g", "saturated_fat_g")
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+   "fiber_g", "sodium_g", "saturated_fat_g", "base_amount", "base_unit", "density_g_per_ml", "barcode")
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING ` + foodColumns
 
 func (q *TxQueries) InsertFood(ctx context.Context, params InsertFoodParams) (Food, error) {
@@ -157,6 +193,10 @@ func (q *TxQueries) InsertFood(ctx context.Context, params InsertFoodParams) (Fo
 			params.FiberG,
 			params.SodiumG,
 			params.SaturatedFatG,
+			params.BaseAmount,
+			params.BaseUnit,
+			params.DensityGPerML,
+			params.Barcode,
 		)
 
 		return row.Scan(
@@ -172,6 +212,11 @@ func (q *TxQueries) InsertFood(ctx context.Context, params InsertFoodParams) (Fo
 			&f.FiberG,
 			&f.SodiumG,
 			&f.SaturatedFatG,
+			&f.DeletedAt,
+			&f.BaseAmount,
+			&f.BaseUnit,
+			&f.DensityGPerML,
+			&f.Barcode,
 		)
 	})
 
@@ -180,17 +225,22 @@ func (q *TxQueries) InsertFood(ctx context.Context, params InsertFoodParams) (Fo
 
 const updateFood = `
 UPDATE "foods"
-SET "name"            = ?,
-    "kcal"            = ?,
-    "protein_g"       = ?,
-    "carbs_g"         = ?,
-    "fat_g"           = ?,
-    "fiber_g"         = ?,
-    "sodium_g"        = ?,
-    "saturated_fat_g" = ?,
-    "updated_at"      = ?
+SET "name"             = ?,
+    "kcal"             = ?,
+    "protein_g"        = ?,
+    "carbs_g"          = ?,
+    "fat_g"            = ?,
+    "fiber_g"          = ?,
+    "sodium_g"         = ?,
+    "saturated_fat_g"  = ?,
+    "base_amount"      = ?,
+    "base_unit"        = ?,
+    "density_g_per_ml" = ?,
+    "barcode"          = ?,
+    "updated_at"       = ?
 WHERE "id" = ?
   AND "user_id" = ?
+  AND "deleted_at" IS NULL
 RETURNING ` + foodColumns
 
 func (q *TxQueries) UpdateFood(
@@ -212,6 +262,10 @@ func (q *TxQueries) UpdateFood(
 			params.FiberG,
 			params.SodiumG,
 			params.SaturatedFatG,
+			params.BaseAmount,
+			params.BaseUnit,
+			params.DensityGPerML,
+			params.Barcode,
 			newUpdatedAt(),
 			params.ID,
 			userID,
@@ -230,13 +284,56 @@ func (q *TxQueries) UpdateFood(
 			&f.FiberG,
 			&f.SodiumG,
 			&f.SaturatedFatG,
+			&f.DeletedAt,
+			&f.BaseAmount,
+			&f.BaseUnit,
+			&f.DensityGPerML,
+			&f.Barcode,
+		)
+	})
+
+	return f, err
+}
+
+const selectFoodByBarcode = `
+SELECT ` + foodColumns + ` FROM "foods"
+WHERE "user_id" = ? AND "barcode" = ? AND "deleted_at" IS NULL
+ORDER BY "id" LIMIT 1`
+
+func (q *Queries) SelectFoodByBarcode(ctx context.Context, userID int, barcode string) (Food, error) {
+	var f Food
+
+	err := q.wrapQuery(selectFoodByBarcode, func() error {
+		row := q.db.QueryRowContext(ctx, selectFoodByBarcode, userID, barcode)
+
+		return row.Scan(
+			&f.ID,
+			&f.UserID,
+			&f.Name,
+			&f.Kcal,
+			&f.ProteinG,
+			&f.CarbsG,
+			&f.FatG,
+			&f.CreatedAt,
+			&f.UpdatedAt,
+			&f.FiberG,
+			&f.SodiumG,
+			&f.SaturatedFatG,
+			&f.DeletedAt,
+			&f.BaseAmount,
+			&f.BaseUnit,
+			&f.DensityGPerML,
+			&f.Barcode,
 		)
 	})
 
 	return f, err
 }
 
-const deleteFood = `DELETE FROM "foods" WHERE "id" = ? AND "user_id" = ? RETURNING "id"`
+const deleteFood = `
+UPDATE "foods" SET "deleted_at" = strftime('%s','now')
+WHERE "id" = ? AND "user_id" = ? AND "deleted_at" IS NULL
+RETURNING "id"`
 
 func (q *Queries) DeleteFood(ctx context.Context, id, userID int) (int, error) {
 	var i int
@@ -250,7 +347,7 @@ func (q *Queries) DeleteFood(ctx context.Context, id, userID int) (int, error) {
 	return i, err
 }
 
-const countFoodsByUser = `SELECT COUNT(*) FROM "foods" WHERE "user_id" = ?`
+const countFoodsByUser = `SELECT COUNT(*) FROM "foods" WHERE "user_id" = ? AND "deleted_at" IS NULL`
 
 func (q *Queries) CountFoodsByUser(ctx context.Context, userID int) (int, error) {
 	var c int
@@ -264,7 +361,9 @@ func (q *Queries) CountFoodsByUser(ctx context.Context, userID int) (int, error)
 	return c, err
 }
 
-const deleteAllFoodsByUser = `DELETE FROM "foods" WHERE "user_id" = ?`
+const deleteAllFoodsByUser = `
+UPDATE "foods" SET "deleted_at" = strftime('%s','now')
+WHERE "user_id" = ? AND "deleted_at" IS NULL`
 
 func (q *TxQueries) DeleteAllFoodsByUser(ctx context.Context, userID int) error {
 	return q.wrapQuery(deleteAllFoodsByUser, func() error {
@@ -288,5 +387,7 @@ func validFoodFields() []string {
 		"fiber_g",
 		"sodium_g",
 		"saturated_fat_g",
+		"base_amount",
+		"base_unit",
 	}
 }
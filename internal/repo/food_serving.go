@@ -0,0 +1,165 @@
+package repo
+
+import "context"
+
+// FoodServing names an amount of a food, so "1 cup" can stand for 185 g.
+type FoodServing struct {
+	ID        int
+	UserID    int
+	FoodID    int
+	Name      string
+	Amount    float64
+	Unit      string
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+type InsertFoodServingParams struct {
+	UserID int
+	FoodID int
+	Name   string
+	Amount float64
+	Unit   string
+}
+
+// foodServingColumns pins the projection order the Scan calls in this file
+// depend on.
+const foodServingColumns = `"id", "user_id", "food_id", "name", "amount", "unit", "created_at", "updated_at"`
+
+// insertFoodServing only inserts when the food is the user's and not in the
+// trash, so a foreign id yields sql.ErrNoRows.
+const insertFoodServing = `
+INSERT INTO "food_servings" ("user_id", "food_id", "name", "amount", "unit")
+SELECT "user_id", "id", ?, ?, ?
+FROM "foods"
+WHERE "id" = ? AND "user_id" = ? AND "deleted_at" IS NULL
+RETURNING ` + foodServingColumns
+
+func (q *Queries) InsertFoodServing(ctx context.Context, params InsertFoodServingParams) (FoodServing, error) {
+	var fs FoodServing
+
+	err := q.wrapQuery(insertFoodServing, func() error {
+		row := q.db.QueryRowContext(
+			ctx,
+			insertFoodServing,
+			params.Name,
+			params.Amount,
+			params.Unit,
+			params.FoodID,
+			params.UserID,
+		)
+
+		return row.Scan(
+			&fs.ID,
+			&fs.UserID,
+			&fs.FoodID,
+			&fs.Name,
+			&fs.Amount,
+			&fs.Unit,
+			&fs.CreatedAt,
+			&fs.UpdatedAt,
+		)
+	})
+
+	return fs, err
+}
+
+const selectFoodServingsByFood = `
+SELECT ` + foodServingColumns + ` FROM "food_servings"
+WHERE "food_id" = ? AND "user_id" = ?
+ORDER BY lower("name")`
+
+func (q *Queries) SelectFoodServingsByFood(ctx context.Context, foodID, userID int) ([]FoodServing, error) {
+	return q.selectFoodServings(ctx, selectFoodServingsByFood, foodID, userID)
+}
+
+const selectFoodServingsByUser = `
+SELECT ` + foodServingColumns + ` FROM "food_servings"
+WHERE "user_id" = ?
+ORDER BY "food_id", lower("name")`
+
+// SelectFoodServingsByUser returns every serving of every food, trashed ones
+// included, grouped by food.
+func (q *Queries) SelectFoodServingsByUser(ctx context.Context, userID int) ([]FoodServing, error) {
+	return q.selectFoodServings(ctx, selectFoodServingsByUser, userID)
+}
+
+const selectFoodServing = `
+SELECT ` + foodServingColumns + ` FROM "food_servings"
+WHERE "id" = ? AND "food_id" = ? AND "user_id" = ? LIMIT 1`
+
+func (q *Queries) SelectFoodServing(ctx context.Context, id, foodID, userID int) (FoodServing, error) {
+	var fs FoodServing
+
+	err := q.wrapQuery(selectFoodServing, func() error {
+		row := q.db.QueryRowContext(ctx, selectFoodServing, id, foodID, userID)
+
+		return row.Scan(
+			&fs.ID,
+			&fs.UserID,
+			&fs.FoodID,
+			&fs.Name,
+			&fs.Amount,
+			&fs.Unit,
+			&fs.CreatedAt,
+			&fs.UpdatedAt,
+		)
+	})
+
+	return fs, err
+}
+
+const deleteFoodServing = `
+DELETE FROM "food_servings" WHERE "id" = ? AND "food_id" = ? AND "user_id" = ?
+RETURNING "id"`
+
+func (q *Queries) DeleteFoodServing(ctx context.Context, id, foodID, userID int) (int, error) {
+	var i int
+
+	err := q.wrapQuery(deleteFoodServing, func() error {
+		row := q.db.QueryRowContext(ctx, deleteFoodServing, id, foodID, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
+
+func (q *Queries) selectFoodServings(ctx context.Context, query string, args ...any) ([]FoodServing, error) {
+	var fss []FoodServing
+
+	err := q.wrapQuery(query, func() error {
+		rows, err := q.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var fs FoodServing
+
+			if err := rows.Scan(
+				&fs.ID,
+				&fs.UserID,
+				&fs.FoodID,
+				&fs.Name,
+				&fs.Amount,
+				&fs.Unit,
+				&fs.CreatedAt,
+				&fs.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			fss = append(fss, fs)
+		}
+
+		return rows.Err()
+	})
+
+	return fss, err
+}
@@ -27,6 +27,7 @@ type MacroEntry struct {
 	FiberG        float64
 	SodiumG       float64
 	SaturatedFatG float64
+	DeletedAt     *int64
 }
 
 type InsertMacroEntryParams struct {
@@ -71,7 +72,7 @@ type MacroDayTotals struct {
 // SELECT * would resolve to whatever order the table happens to have, so an
 // ALTER TABLE could shift values into the wrong struct fields with no error.
 const macroEntryColumns = `"id", "user_id", "name", "kcal", "protein_g", "carbs_g", "fat_g", "date",
-"created_at", "updated_at", "meal_type", "fiber_g", "sodium_g", "saturated_fat_g"`
+"created_at", "updated_at", "meal_type", "fiber_g", "sodium_g", "saturated_fat_g", "deleted_at"`
 
 const selectMacroEntries = `SELECT ` + macroEntryColumns + ` FROM "macro_entries"`
 
@@ -82,7 +83,7 @@ func (q *Queries) SelectMacroEntries(ctx context.Context, opts QueryOptions) ([]
 		return es, err
 	}
 
-	subQuery, err := opts.Build()
+	subQuery, err := opts.BuildWithin(notDeleted)
 	if err != nil {
 		return es, err
 	}
@@ -119,6 +120,7 @@ func (q *Queries) SelectMacroEntries(ctx context.Context, opts QueryOptions) ([]
 				&e.FiberG,
 				&e.SodiumG,
 				&e.SaturatedFatG,
+				&e.DeletedAt,
 			); err != nil {
 				return err
 			}
@@ -137,7 +139,7 @@ const countMacroEntries = `SELECT COUNT(*) FROM "macro_entries"`
 func (q *Queries) CountMacroEntries(ctx context.Context, filters Filters) (int, error) {
 	var c int
 
-	subQuery, err := filters.Build()
+	subQuery, err := filters.BuildWithin(notDeleted)
 	if err != nil {
 		return 0, err
 	}
@@ -155,7 +157,7 @@ func (q *Queries) CountMacroEntries(ctx context.Context, filters Filters) (int,
 }
 
 const selectMacroEntry = `SELECT ` + macroEntryColumns + `
-FROM "macro_entries" WHERE "id" = ? AND "user_id" = ? LIMIT 1`
+FROM "macro_entries" WHERE "id" = ? AND "user_id" = ? AND "deleted_at" IS NULL LIMIT 1`
 
 func (q *Queries) SelectMacroEntry(ctx context.Context, id, userID int) (MacroEntry, error) {
 	var e MacroEntry
@@ -178,6 +180,7 @@ func (q *Queries) SelectMacroEntry(ctx context.Context, id, userID int) (MacroEn
 			&e.FiberG,
 			&e.SodiumG,
 			&e.SaturatedFatG,
+			&e.DeletedAt,
 		)
 	})
 
@@ -226,6 +229,7 @@ func (q *TxQueries) InsertMacroEntry(ctx context.Context, params InsertMacroEntr
 			&e.FiberG,
 			&e.SodiumG,
 			&e.SaturatedFatG,
+			&e.DeletedAt,
 		)
 	})
 
@@ -247,6 +251,7 @@ SET "name"            = ?,
     "updated_at"      = ?
 WHERE "id" = ?
   AND "user_id" = ?
+  AND "deleted_at" IS NULL
 RETURNING ` + macroEntryColumns
 
 func (q *TxQueries) UpdateMacroEntry(
@@ -290,13 +295,17 @@ func (q *TxQueries) UpdateMacroEntry(
 			&e.FiberG,
 			&e.SodiumG,
 			&e.SaturatedFatG,
+			&e.DeletedAt,
 		)
 	})
 
 	return e, err
 }
 
-const deleteMacroEntry = `DELETE FROM "macro_entries" WHERE "id" = ? AND "user_id" = ? RETURNING "id"`
+const deleteMacroEntry = `
+UPDATE "macro_entries" SET "deleted_at" = strftime('%s','now')
+WHERE "id" = ? AND "user_id" = ? AND "deleted_at" IS NULL
+RETURNING "id"`
 
 func (q *Queries) DeleteMacroEntry(ctx context.Context, id, userID int) (int, error) {
 	var i int
@@ -310,7 +319,7 @@ func (q *Queries) DeleteMacroEntry(ctx context.Context, id, userID int) (int, er
 	return i, err
 }
 
-const countMacroEntriesByUser = `SELECT COUNT(*) FROM "macro_entries" WHERE "user_id" = ?`
+const countMacroEntriesByUser = `SELECT COUNT(*) FROM "macro_entries" WHERE "user_id" = ? AND "deleted_at" IS NULL`
 
 func (q *Queries) CountMacroEntriesByUser(ctx context.Context, userID int) (int, error) {
 	var c int
@@ -324,7 +333,9 @@ func (q *Queries) CountMacroEntriesByUser(ctx context.Context, userID int) (int,
 	return c, err
 }
 
-const deleteAllMacroEntriesByUser = `DELETE FROM "macro_entries" WHERE "user_id" = ?`
+const deleteAllMacroEntriesByUser = `
+UPDATE "macro_entries" SET "deleted_at" = strftime('%s','now')
+WHERE "user_id" = ? AND "deleted_at" IS NULL`
 
 func (q *TxQueries) DeleteAllMacroEntriesByUser(ctx context.Context, userID int) error {
 	return q.wrapQuery(deleteAllMacroEntriesByUser, func() error {
@@ -339,7 +350,7 @@ SELECT COALESCE(SUM("kcal"),0), COALESCE(SUM("protein_g"),0),
        COALESCE(SUM("carbs_g"),0), COALESCE(SUM("fat_g"),0),
        COALESCE(SUM("fiber_g"),0), COALESCE(SUM("sodium_g"),0),
        COALESCE(SUM("saturated_fat_g"),0)
-FROM "macro_entries" WHERE "user_id"=? AND "date">=? AND "date"<?`
+FROM "macro_entries" WHERE "user_id"=? AND "date">=? AND "date"<? AND "deleted_at" IS NULL`
 
 const selectMacroDayTotalsByMealType = selectMacroDayTotals + ` AND "meal_type"=?`
 
@@ -376,7 +387,7 @@ SELECT "date",
        COALESCE(SUM("carbs_g"), 0),
        COALESCE(SUM("fat_g"), 0)
 FROM "macro_entries"
-WHERE "user_id" = ? AND "date" >= ? AND "date" < ?
+WHERE "user_id" = ? AND "date" >= ? AND "date" < ? AND "deleted_at" IS NULL
 GROUP BY "date"
 ORDER BY "date" ASC`
 
@@ -0,0 +1,381 @@
+package repo
+
+import "context"
+
+// MealTemplate is a named set of foods logged together, such as a usual
+// breakfast. MealType is the meal its entries are logged under by default.
+type MealTemplate struct {
+	ID        int
+	UserID    int
+	Name      string
+	MealType  string
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+type InsertMealTemplateParams struct {
+	UserID   int
+	Name     string
+	MealType string
+}
+
+type UpdateMealTemplateParams struct {
+	ID       int
+	UserID   int
+	Name     string
+	MealType string
+}
+
+// MealTemplateItem is Quantity of Unit of one food in a meal template.
+type MealTemplateItem struct {
+	ID             int
+	UserID         int
+	MealTemplateID int
+	FoodID         int
+	Quantity       float64
+	Unit           string
+	CreatedAt      int64
+	UpdatedAt      int64
+}
+
+type UpsertMealTemplateItemParams struct {
+	UserID         int
+	MealTemplateID int
+	FoodID         int
+	Quantity       float64
+	Unit           string
+}
+
+// MealTemplateItemFood is an item with its food as it stands now. The food may
+// be in the trash, which leaves it in the template until it is purged.
+type MealTemplateItemFood struct {
+	MealTemplateItem
+	Food Food
+}
+
+// mealTemplateColumns and mealTemplateItemColumns pin the projection order
+// the Scan calls in this file depend on.
+const (
+	mealTemplateColumns = `"id", "user_id", "name", "meal_type", "created_at", "updated_at"`
+
+	mealTemplateItemColumns = `"id", "user_id", "meal_template_id", "food_id", "quantity", "unit",
+"created_at", "updated_at"`
+)
+
+// selectMealTemplateItemFoods joins each item to its food. Its columns are
+// mealTemplateItemColumns then foodColumns, qualified by table.
+const selectMealTemplateItemFoods = `
+SELECT "i"."id", "i"."user_id", "i"."meal_template_id", "i"."food_id", "i"."quantity", "i"."unit",
+       "i"."created_at", "i"."updated_at",
+       "f"."id", "f"."user_id", "f"."name", "f"."kcal", "f"."protein_g", "f"."carbs_g", "f"."fat_g",
+       "f"."created_at", "f"."updated_at", "f"."fiber_g", "f"."sodium_g", "f"."saturated_fat_g",
+       "f"."deleted_at", "f"."base_amount", "f"."base_unit", "f"."density_g_per_ml",
+       "f"."barcode"
+FROM "meal_template_items" AS "i"
+JOIN "foods" AS "f" ON "f"."id" = "i"."food_id"`
+
+const insertMealTemplate = `
+INSERT INTO "meal_templates" ("user_id", "name", "meal_type")
+VALUES (?, ?, ?)
+RETURNING ` + mealTemplateColumns
+
+func (q *Queries) InsertMealTemplate(ctx context.Context, params InsertMealTemplateParams) (MealTemplate, error) {
+	var m MealTemplate
+
+	err := q.wrapQuery(insertMealTemplate, func() error {
+		row := q.db.QueryRowContext(ctx, insertMealTemplate, params.UserID, params.Name, params.MealType)
+
+		return row.Scan(
+			&m.ID,
+			&m.UserID,
+			&m.Name,
+			&m.MealType,
+			&m.CreatedAt,
+			&m.UpdatedAt,
+		)
+	})
+
+	return m, err
+}
+
+const selectMealTemplatesByUser = `
+SELECT ` + mealTemplateColumns + ` FROM "meal_templates" WHERE "user_id" = ? ORDER BY lower("name")`
+
+func (q *Queries) SelectMealTemplatesByUser(ctx context.Context, userID int) ([]MealTemplate, error) {
+	var ms []MealTemplate
+
+	err := q.wrapQuery(selectMealTemplatesByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectMealTemplatesByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var m MealTemplate
+
+			if err := rows.Scan(
+				&m.ID,
+				&m.UserID,
+				&m.Name,
+				&m.MealType,
+				&m.CreatedAt,
+				&m.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			ms = append(ms, m)
+		}
+
+		return rows.Err()
+	})
+
+	return ms, err
+}
+
+const selectMealTemplate = `
+SELECT ` + mealTemplateColumns + ` FROM "meal_templates" WHERE "id" = ? AND "user_id" = ? LIMIT 1`
+
+func (q *Queries) SelectMealTemplate(ctx context.Context, id, userID int) (MealTemplate, error) {
+	var m MealTemplate
+
+	err := q.wrapQuery(selectMealTemplate, func() error {
+		row := q.db.QueryRowContext(ctx, selectMealTemplate, id, userID)
+
+		return row.Scan(
+			&m.ID,
+			&m.UserID,
+			&m.Name,
+			&m.MealType,
+			&m.CreatedAt,
+			&m.UpdatedAt,
+		)
+	})
+
+	return m, err
+}
+
+const updateMealTemplate = `
+UPDATE "meal_templates"
+SET "name"       = ?,
+    "meal_type"  = ?,
+    "updated_at" = ?
+WHERE "id" = ? AND "user_id" = ?
+RETURNING ` + mealTemplateColumns
+
+func (q *Queries) UpdateMealTemplate(ctx context.Context, params UpdateMealTemplateParams) (MealTemplate, error) {
+	var m MealTemplate
+
+	err := q.wrapQuery(updateMealTemplate, func() error {
+		row := q.db.QueryRowContext(
+			ctx,
+			updateMealTemplate,
+			params.Name,
+			params.MealType,
+			newUpdatedAt(),
+			params.ID,
+			params.UserID,
+		)
+
+		return row.Scan(
+			&m.ID,
+			&m.UserID,
+			&m.Name,
+			&m.MealType,
+			&m.CreatedAt,
+			&m.UpdatedAt,
+		)
+	})
+
+	return m, err
+}
+
+// deleteMealTemplate takes the template's items with it through their foreign
+// key. Entries logged from it stay.
+const deleteMealTemplate = `DELETE FROM "meal_templates" WHERE "id" = ? AND "user_id" = ? RETURNING "id"`
+
+func (q *Queries) DeleteMealTemplate(ctx context.Context, id, userID int) (int, error) {
+	var i int
+
+	err := q.wrapQuery(deleteMealTemplate, func() error {
+		row := q.db.QueryRowContext(ctx, deleteMealTemplate, id, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
+
+const countMealTemplatesByUser = `SELECT COUNT(*) FROM "meal_templates" WHERE "user_id" = ?`
+
+func (q *Queries) CountMealTemplatesByUser(ctx context.Context, userID int) (int, error) {
+	var c int
+
+	err := q.wrapQuery(countMealTemplatesByUser, func() error {
+		row := q.db.QueryRowContext(ctx, countMealTemplatesByUser, userID)
+
+		return row.Scan(&c)
+	})
+
+	return c, err
+}
+
+const deleteAllMealTemplatesByUser = `DELETE FROM "meal_templates" WHERE "user_id" = ?`
+
+func (q *TxQueries) DeleteAllMealTemplatesByUser(ctx context.Context, userID int) error {
+	return q.wrapQuery(deleteAllMealTemplatesByUser, func() error {
+		_, err := q.tx.ExecContext(ctx, deleteAllMealTemplatesByUser, userID)
+
+		return err
+	})
+}
+
+// upsertMealTemplateItem only inserts when both the template and a food that
+// is not in the trash belong to the user, so a foreign id yields
+// sql.ErrNoRows. Adding a food the template already has replaces its
+// quantity and unit.
+const upsertMealTemplateItem = `
+INSERT INTO "meal_template_items" ("user_id", "meal_template_id", "food_id", "quantity", "unit")
+SELECT "m"."user_id", "m"."id", "f"."id", ?, ?
+FROM "meal_templates" AS "m"
+JOIN "foods" AS "f" ON "f"."user_id" = "m"."user_id" AND "f"."deleted_at" IS NULL
+WHERE "m"."id" = ? AND "m"."user_id" = ? AND "f"."id" = ?
+ON CONFLICT ("meal_template_id", "food_id") DO UPDATE
+SET "quantity"   = excluded."quantity",
+    "unit"       = excluded."unit",
+    "updated_at" = strftime('%s','now')
+RETURNING ` + mealTemplateItemColumns
+
+func (q *Queries) UpsertMealTemplateItem(
+	ctx context.Context,
+	params UpsertMealTemplateItemParams,
+) (MealTemplateItem, error) {
+	var i MealTemplateItem
+
+	err := q.wrapQuery(upsertMealTemplateItem, func() error {
+		row := q.db.QueryRowContext(
+			ctx,
+			upsertMealTemplateItem,
+			params.Quantity,
+			params.Unit,
+			params.MealTemplateID,
+			params.UserID,
+			params.FoodID,
+		)
+
+		return row.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.MealTemplateID,
+			&i.FoodID,
+			&i.Quantity,
+			&i.Unit,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		)
+	})
+
+	return i, err
+}
+
+const selectMealTemplateItemsByUser = selectMealTemplateItemFoods + `
+WHERE "i"."user_id" = ?
+ORDER BY "i"."meal_template_id", lower("f"."name")`
+
+// SelectMealTemplateItemsByUser returns every item of every template, grouped
+// by template.
+func (q *Queries) SelectMealTemplateItemsByUser(ctx context.Context, userID int) ([]MealTemplateItemFood, error) {
+	return q.selectMealTemplateItemFoods(ctx, selectMealTemplateItemsByUser, userID)
+}
+
+const selectMealTemplateItemsByTemplate = selectMealTemplateItemFoods + `
+WHERE "i"."meal_template_id" = ? AND "i"."user_id" = ?
+ORDER BY lower("f"."name")`
+
+func (q *Queries) SelectMealTemplateItemsByTemplate(
+	ctx context.Context,
+	templateID, userID int,
+) ([]MealTemplateItemFood, error) {
+	return q.selectMealTemplateItemFoods(ctx, selectMealTemplateItemsByTemplate, templateID, userID)
+}
+
+const deleteMealTemplateItem = `
+DELETE FROM "meal_template_items" WHERE "id" = ? AND "meal_template_id" = ? AND "user_id" = ?
+RETURNING "id"`
+
+func (q *Queries) DeleteMealTemplateItem(ctx context.Context, id, templateID, userID int) (int, error) {
+	var i int
+
+	err := q.wrapQuery(deleteMealTemplateItem, func() error {
+		row := q.db.QueryRowContext(ctx, deleteMealTemplateItem, id, templateID, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
+
+func (q *Queries) selectMealTemplateItemFoods(
+	ctx context.Context,
+	query string,
+	args ...any,
+) ([]MealTemplateItemFood, error) {
+	var is []MealTemplateItemFood
+
+	err := q.wrapQuery(query, func() error {
+		rows, err := q.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var i MealTemplateItemFood
+
+			if err := rows.Scan(
+				&i.ID,
+				&i.UserID,
+				&i.MealTemplateID,
+				&i.FoodID,
+				&i.Quantity,
+				&i.Unit,
+				&i.CreatedAt,
+				&i.UpdatedAt,
+				&i.Food.ID,
+				&i.Food.UserID,
+				&i.Food.Name,
+				&i.Food.Kcal,
+				&i.Food.ProteinG,
+				&i.Food.CarbsG,
+				&i.Food.FatG,
+				&i.Food.CreatedAt,
+				&i.Food.UpdatedAt,
+				&i.Food.FiberG,
+				&i.Food.SodiumG,
+				&i.Food.SaturatedFatG,
+				&i.Food.DeletedAt,
+				&i.Food.BaseAmount,
+				&i.Food.BaseUnit,
+				&i.Food.DensityGPerML,
+				&i.Food.Barcode,
+			); err != nil {
+				return err
+			}
+
+			is = append(is, i)
+		}
+
+		return rows.Err()
+	})
+
+	return is, err
+}
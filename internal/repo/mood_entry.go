@@ -13,6 +13,7 @@ type MoodEntry struct {
 	LoggedAt  int64
 	CreatedAt int64
 	UpdatedAt int64
+	DeletedAt *int64
 }
 
 type InsertMoodEntryParams struct {
@@ -33,7 +34,8 @@ type UpdateMoodEntryParams struct {
 // moodEntryColumns pins the projection order the Scan calls in this file depend on.
 // SELECT * would resolve to whatever order the table happens to have, so an
 // ALTER TABLE could shift values into the wrong struct fields with no error.
-const moodEntryColumns = `"id", "user_id", "mood", "notes", "logged_at", "created_at", "updated_at"`
+const moodEntryColumns = `"id", "user_id", "mood", "notes", "logged_at", "created_at", "updated_at",
+"deleted_at"`
 
 const selectMoodEntries = `SELECT ` + moodEntryColumns + ` FROM "mood_entries"`
 
@@ -44,7 +46,7 @@ func (q *Queries) SelectMoodEntries(ctx context.Context, opts QueryOptions) ([]M
 		return es, err
 	}
 
-	subQuery, err := opts.Build()
+	subQuery, err := opts.BuildWithin(notDeleted)
 	if err != nil {
 		return es, err
 	}
@@ -74,6 +76,7 @@ func (q *Queries) SelectMoodEntries(ctx context.Context, opts QueryOptions) ([]M
 				&e.LoggedAt,
 				&e.CreatedAt,
 				&e.UpdatedAt,
+				&e.DeletedAt,
 			); err != nil {
 				return err
 			}
@@ -92,7 +95,7 @@ const countMoodEntries = `SELECT COUNT(*) FROM "mood_entries"`
 func (q *Queries) CountMoodEntries(ctx context.Context, filters Filters) (int, error) {
 	var c int
 
-	subQuery, err := filters.Build()
+	subQuery, err := filters.BuildWithin(notDeleted)
 	if err != nil {
 		return 0, err
 	}
@@ -110,7 +113,7 @@ func (q *Queries) CountMoodEntries(ctx context.Context, filters Filters) (int, e
 }
 
 const selectMoodEntry = `SELECT ` + moodEntryColumns + `
-FROM "mood_entries" WHERE "id" = ? AND "user_id" = ? LIMIT 1`
+FROM "mood_entries" WHERE "id" = ? AND "user_id" = ? AND "deleted_at" IS NULL LIMIT 1`
 
 func (q *Queries) SelectMoodEntry(ctx context.Context, id, userID int) (MoodEntry, error) {
 	var e MoodEntry
@@ -126,6 +129,7 @@ func (q *Queries) SelectMoodEntry(ctx context.Context, id, userID int) (MoodEntr
 			&e.LoggedAt,
 			&e.CreatedAt,
 			&e.UpdatedAt,
+			&e.DeletedAt,
 		)
 	})
 
@@ -158,6 +162,7 @@ func (q *TxQueries) InsertMoodEntry(ctx context.Context, params InsertMoodEntryP
 			&e.LoggedAt,
 			&e.CreatedAt,
 			&e.UpdatedAt,
+			&e.DeletedAt,
 		)
 	})
 
@@ -172,6 +177,7 @@ SET "mood"       = ?,
     "updated_at" = ?
 WHERE "id" = ?
   AND "user_id" = ?
+  AND "deleted_at" IS NULL
 RETURNING ` + moodEntryColumns
 
 func (q *TxQueries) UpdateMoodEntry(ctx context.Context, params UpdateMoodEntryParams) (MoodEntry, error) {
@@ -197,13 +203,17 @@ func (q *TxQueries) UpdateMoodEntry(ctx context.Context, params UpdateMoodEntryP
 			&e.LoggedAt,
 			&e.CreatedAt,
 			&e.UpdatedAt,
+			&e.DeletedAt,
 		)
 	})
 
 	return e, err
 }
 
-const deleteMoodEntry = `DELETE FROM "mood_entries" WHERE "id" = ? AND "user_id" = ? RETURNING "id"`
+const deleteMoodEntry = `
+UPDATE "mood_entries" SET "deleted_at" = strftime('%s','now')
+WHERE "id" = ? AND "user_id" = ? AND "deleted_at" IS NULL
+RETURNING "id"`
 
 func (q *Queries) DeleteMoodEntry(ctx context.Context, id, userID int) (int, error) {
 	var i int
@@ -217,7 +227,7 @@ func (q *Queries) DeleteMoodEntry(ctx context.Context, id, userID int) (int, err
 	return i, err
 }
 
-const countMoodEntriesByUser = `SELECT COUNT(*) FROM "mood_entries" WHERE "user_id" = ?`
+const countMoodEntriesByUser = `SELECT COUNT(*) FROM "mood_entries" WHERE "user_id" = ? AND "deleted_at" IS NULL`
 
 func (q *Queries) CountMoodEntriesByUser(ctx context.Context, userID int) (int, error) {
 	var c int
@@ -231,19 +241,12 @@ func (q *Queries) CountMoodEntriesByUser(ctx context.Context, userID int) (int,
 	return c, err
 }
 
-const deleteMoodEntryTaggingsByUser = `
-DELETE FROM "taggings"
-WHERE "taggable_type" = 'mood_entry'
-  AND "taggable_id" IN (SELECT "id" FROM "mood_entries" WHERE "user_id" = ?)`
-
-const deleteAllMoodEntriesByUser = `DELETE FROM "mood_entries" WHERE "user_id" = ?`
+const deleteAllMoodEntriesByUser = `
+UPDATE "mood_entries" SET "deleted_at" = strftime('%s','now')
+WHERE "user_id" = ? AND "deleted_at" IS NULL`
 
 func (q *TxQueries) DeleteAllMoodEntriesByUser(ctx context.Context, userID int) error {
 	return q.wrapQuery(deleteAllMoodEntriesByUser, func() error {
-		if _, err := q.tx.ExecContext(ctx, deleteMoodEntryTaggingsByUser, userID); err != nil {
-			return err
-		}
-
 		_, err := q.tx.ExecContext(ctx, deleteAllMoodEntriesByUser, userID)
 
 		return err
@@ -264,7 +267,7 @@ GROUP BY "mood"`
 func (q *Queries) SelectMoodEntryCounts(ctx context.Context, filters Filters) ([]MoodCount, error) {
 	var counts []MoodCount
 
-	filterSubQuery, err := filters.Build()
+	filterSubQuery, err := filters.BuildWithin(notDeleted)
 	if err != nil {
 		return counts, err
 	}
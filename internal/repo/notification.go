@@ -0,0 +1,169 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+)
+
+// NotificationKindBudget marks a notification raised when spending reaches a
+// budget threshold.
+const NotificationKindBudget = "budget"
+
+// Notification is an in-app message for one user. DedupeKey names the event
+// it reports, so the same event never raises two. ReadAt is nil until the
+// user dismisses it.
+type Notification struct {
+	ID        int
+	UserID    int
+	Kind      string
+	Message   string
+	Link      string
+	DedupeKey string
+	ReadAt    *int64
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+type InsertNotificationParams struct {
+	UserID    int
+	Kind      string
+	Message   string
+	Link      string
+	DedupeKey string
+}
+
+// notificationColumns pins the projection order the Scan calls in this file
+// depend on, as the other column lists do.
+const notificationColumns = `"id", "user_id", "kind", "message", "link", "dedupe_key", "read_at",
+"created_at", "updated_at"`
+
+// insertNotification returns no row when the event was already raised, read
+// or not, which the caller sees as sql.ErrNoRows.
+const insertNotification = `
+INSERT INTO "notifications" ("user_id", "kind", "message", "link", "dedupe_key")
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT ("user_id", "dedupe_key") DO NOTHING
+RETURNING ` + notificationColumns
+
+func (q *TxQueries) InsertNotification(ctx context.Context, params InsertNotificationParams) (Notification, error) {
+	var n Notification
+
+	err := q.wrapQuery(insertNotification, func() error {
+		row := q.tx.QueryRowContext(
+			ctx,
+			insertNotification,
+			params.UserID,
+			params.Kind,
+			params.Message,
+			params.Link,
+			params.DedupeKey,
+		)
+
+		return row.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.Kind,
+			&n.Message,
+			&n.Link,
+			&n.DedupeKey,
+			&n.ReadAt,
+			&n.CreatedAt,
+			&n.UpdatedAt,
+		)
+	})
+
+	return n, err
+}
+
+const selectUnreadNotificationsByUser = `SELECT ` + notificationColumns + `
+FROM "notifications" WHERE "user_id" = ? AND "read_at" IS NULL
+ORDER BY "created_at" DESC, "id" DESC`
+
+func (q *Queries) SelectUnreadNotificationsByUser(ctx context.Context, userID int) ([]Notification, error) {
+	var res []Notification
+
+	err := q.wrapQuery(selectUnreadNotificationsByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectUnreadNotificationsByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		res, err = scanNotifications(rows)
+
+		return err
+	})
+
+	return res, err
+}
+
+const markNotificationRead = `
+UPDATE "notifications" SET "read_at" = ?, "updated_at" = ?
+WHERE "id" = ? AND "user_id" = ? AND "read_at" IS NULL
+RETURNING "id"`
+
+// MarkNotificationRead dismisses one unread notification. It returns
+// sql.ErrNoRows when there is no such unread notification for the user.
+func (q *Queries) MarkNotificationRead(ctx context.Context, id, userID int) error {
+	return q.wrapQuery(markNotificationRead, func() error {
+		now := newUpdatedAt()
+		row := q.db.QueryRowContext(ctx, markNotificationRead, now, now, id, userID)
+
+		var readID int
+
+		return row.Scan(&readID)
+	})
+}
+
+const markAllNotificationsRead = `
+UPDATE "notifications" SET "read_at" = ?, "updated_at" = ?
+WHERE "user_id" = ? AND "read_at" IS NULL`
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID int) error {
+	return q.wrapQuery(markAllNotificationsRead, func() error {
+		now := newUpdatedAt()
+		_, err := q.db.ExecContext(ctx, markAllNotificationsRead, now, now, userID)
+
+		return err
+	})
+}
+
+const deleteAllNotificationsByUser = `DELETE FROM "notifications" WHERE "user_id" = ?`
+
+func (q *TxQueries) DeleteAllNotificationsByUser(ctx context.Context, userID int) error {
+	return q.wrapQuery(deleteAllNotificationsByUser, func() error {
+		_, err := q.tx.ExecContext(ctx, deleteAllNotificationsByUser, userID)
+
+		return err
+	})
+}
+
+func scanNotifications(rows *sql.Rows) ([]Notification, error) {
+	var res []Notification
+
+	for rows.Next() {
+		var n Notification
+
+		if err := rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.Kind,
+			&n.Message,
+			&n.Link,
+			&n.DedupeKey,
+			&n.ReadAt,
+			&n.CreatedAt,
+			&n.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		res = append(res, n)
+	}
+
+	return res, rows.Err()
+}
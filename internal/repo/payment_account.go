@@ -0,0 +1,361 @@
+package repo
+
+import "context"
+
+// PaymentAccount is where expenses are paid from: a checking account, a credit
+// card or a cash wallet. OpeningBalance is in cents of Currency and may be
+// negative, as a credit card that already owes money is.
+type PaymentAccount struct {
+	ID             int
+	UserID         int
+	Name           string
+	Kind           string
+	Currency       string
+	OpeningBalance int64
+	// ReconciledThrough is the last day the ledger was checked against a
+	// statement, nil until the first reconcile.
+	ReconciledThrough *int64
+	CreatedAt         int64
+	UpdatedAt         int64
+}
+
+type InsertPaymentAccountParams struct {
+	UserID         int
+	Name           string
+	Kind           string
+	Currency       string
+	OpeningBalance int64
+}
+
+type UpdatePaymentAccountParams struct {
+	ID             int
+	UserID         int
+	Name           string
+	Kind           string
+	Currency       string
+	OpeningBalance int64
+}
+
+// PaymentAccountLedgerExpense is one expense paid from an account. Amount is
+// in the expense's own currency and AccountAmount in the account's.
+type PaymentAccountLedgerExpense struct {
+	ExpenseID     int
+	Description   string
+	Date          int64
+	Amount        uint64
+	Currency      string
+	AccountAmount uint64
+}
+
+// paymentAccountColumns pins the projection order the Scan calls in this file
+// depend on, as the other column lists do.
+const paymentAccountColumns = `"id", "user_id", "name", "kind", "currency", "opening_balance",
+"reconciled_through", "created_at", "updated_at"`
+
+const insertPaymentAccount = `
+INSERT INTO "payment_accounts" ("user_id", "name", "kind", "currency", "opening_balance")
+VALUES (?, ?, ?, ?, ?)
+RETURNING ` + paymentAccountColumns
+
+func (q *TxQueries) InsertPaymentAccount(
+	ctx context.Context,
+	params InsertPaymentAccountParams,
+) (PaymentAccount, error) {
+	var a PaymentAccount
+
+	err := q.wrapQuery(insertPaymentAccount, func() error {
+		row := q.tx.QueryRowContext(
+			ctx,
+			insertPaymentAccount,
+			params.UserID,
+			params.Name,
+			params.Kind,
+			params.Currency,
+			params.OpeningBalance,
+		)
+
+		return row.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Name,
+			&a.Kind,
+			&a.Currency,
+			&a.OpeningBalance,
+			&a.ReconciledThrough,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		)
+	})
+
+	return a, err
+}
+
+const selectPaymentAccountsByUser = `
+SELECT ` + paymentAccountColumns + ` FROM "payment_accounts" WHERE "user_id" = ? ORDER BY lower("name")`
+
+func (q *Queries) SelectPaymentAccountsByUser(ctx context.Context, userID int) ([]PaymentAccount, error) {
+	var as []PaymentAccount
+
+	err := q.wrapQuery(selectPaymentAccountsByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectPaymentAccountsByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var a PaymentAccount
+
+			if err := rows.Scan(
+				&a.ID,
+				&a.UserID,
+				&a.Name,
+				&a.Kind,
+				&a.Currency,
+				&a.OpeningBalance,
+				&a.ReconciledThrough,
+				&a.CreatedAt,
+				&a.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			as = append(as, a)
+		}
+
+		return rows.Err()
+	})
+
+	return as, err
+}
+
+const selectPaymentAccount = `
+SELECT ` + paymentAccountColumns + ` FROM "payment_accounts" WHERE "id" = ? AND "user_id" = ? LIMIT 1`
+
+func (q *Queries) SelectPaymentAccount(ctx context.Context, id, userID int) (PaymentAccount, error) {
+	var a PaymentAccount
+
+	err := q.wrapQuery(selectPaymentAccount, func() error {
+		row := q.db.QueryRowContext(ctx, selectPaymentAccount, id, userID)
+
+		return row.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Name,
+			&a.Kind,
+			&a.Currency,
+			&a.OpeningBalance,
+			&a.ReconciledThrough,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		)
+	})
+
+	return a, err
+}
+
+func (q *TxQueries) SelectPaymentAccount(ctx context.Context, id, userID int) (PaymentAccount, error) {
+	var a PaymentAccount
+
+	err := q.wrapQuery(selectPaymentAccount, func() error {
+		row := q.tx.QueryRowContext(ctx, selectPaymentAccount, id, userID)
+
+		return row.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Name,
+			&a.Kind,
+			&a.Currency,
+			&a.OpeningBalance,
+			&a.ReconciledThrough,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		)
+	})
+
+	return a, err
+}
+
+// updatePaymentAccount keeps the current currency when params.Currency is
+// empty, like updateExpense.
+const updatePaymentAccount = `
+UPDATE "payment_accounts"
+SET "name"            = ?,
+    "kind"            = ?,
+    "currency"        = COALESCE(NULLIF(?, ''), "currency"),
+    "opening_balance" = ?,
+    "updated_at"      = ?
+WHERE "id" = ? AND "user_id" = ?
+RETURNING ` + paymentAccountColumns
+
+func (q *Queries) UpdatePaymentAccount(ctx context.Context, params UpdatePaymentAccountParams) (PaymentAccount, error) {
+	var a PaymentAccount
+
+	err := q.wrapQuery(updatePaymentAccount, func() error {
+		row := q.db.QueryRowContext(
+			ctx,
+			updatePaymentAccount,
+			params.Name,
+			params.Kind,
+			params.Currency,
+			params.OpeningBalance,
+			newUpdatedAt(),
+			params.ID,
+			params.UserID,
+		)
+
+		return row.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Name,
+			&a.Kind,
+			&a.Currency,
+			&a.OpeningBalance,
+			&a.ReconciledThrough,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		)
+	})
+
+	return a, err
+}
+
+const updatePaymentAccountReconciledThrough = `
+UPDATE "payment_accounts"
+SET "reconciled_through" = ?,
+    "updated_at"         = ?
+WHERE "id" = ? AND "user_id" = ?
+RETURNING ` + paymentAccountColumns
+
+func (q *Queries) UpdatePaymentAccountReconciledThrough(
+	ctx context.Context,
+	id, userID int,
+	reconciledThrough int64,
+) (PaymentAccount, error) {
+	var a PaymentAccount
+
+	err := q.wrapQuery(updatePaymentAccountReconciledThrough, func() error {
+		row := q.db.QueryRowContext(
+			ctx,
+			updatePaymentAccountReconciledThrough,
+			reconciledThrough,
+			newUpdatedAt(),
+			id,
+			userID,
+		)
+
+		return row.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Name,
+			&a.Kind,
+			&a.Currency,
+			&a.OpeningBalance,
+			&a.ReconciledThrough,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		)
+	})
+
+	return a, err
+}
+
+// deletePaymentAccount leaves the expenses and recurrent expenses paid from the
+// account in place; their foreign keys set the link to NULL.
+const deletePaymentAccount = `DELETE FROM "payment_accounts" WHERE "id" = ? AND "user_id" = ? RETURNING "id"`
+
+func (q *Queries) DeletePaymentAccount(ctx context.Context, id, userID int) (int, error) {
+	var i int
+
+	err := q.wrapQuery(deletePaymentAccount, func() error {
+		row := q.db.QueryRowContext(ctx, deletePaymentAccount, id, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
+
+const countPaymentAccountsByUser = `SELECT COUNT(*) FROM "payment_accounts" WHERE "user_id" = ?`
+
+func (q *Queries) CountPaymentAccountsByUser(ctx context.Context, userID int) (int, error) {
+	var c int
+
+	err := q.wrapQuery(countPaymentAccountsByUser, func() error {
+		row := q.db.QueryRowContext(ctx, countPaymentAccountsByUser, userID)
+
+		return row.Scan(&c)
+	})
+
+	return c, err
+}
+
+const deleteAllPaymentAccountsByUser = `DELETE FROM "payment_accounts" WHERE "user_id" = ?`
+
+func (q *TxQueries) DeleteAllPaymentAccountsByUser(ctx context.Context, userID int) error {
+	return q.wrapQuery(deleteAllPaymentAccountsByUser, func() error {
+		_, err := q.tx.ExecContext(ctx, deleteAllPaymentAccountsByUser, userID)
+
+		return err
+	})
+}
+
+// selectPaymentAccountLedger lists the live expenses paid from an account,
+// oldest first, converting each into the account's currency (?1) the way
+// expenseHomeAmount converts into the home currency. The id breaks ties
+// between expenses on the same day so the running balance is stable.
+const selectPaymentAccountLedger = `
+SELECT "id", "description", "date", "amount", "currency", ` + expenseHomeAmount + `
+FROM "expenses"
+WHERE "payment_account_id" = ?2 AND "user_id" = ?3 AND "deleted_at" IS NULL
+ORDER BY "date", "id"`
+
+func (q *Queries) SelectPaymentAccountLedger(
+	ctx context.Context,
+	account PaymentAccount,
+) ([]PaymentAccountLedgerExpense, error) {
+	var es []PaymentAccountLedgerExpense
+
+	err := q.wrapQuery(selectPaymentAccountLedger, func() error {
+		rows, err := q.db.QueryContext(
+			ctx,
+			selectPaymentAccountLedger,
+			account.Currency,
+			account.ID,
+			account.UserID,
+		)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var e PaymentAccountLedgerExpense
+
+			if err := rows.Scan(
+				&e.ExpenseID,
+				&e.Description,
+				&e.Date,
+				&e.Amount,
+				&e.Currency,
+				&e.AccountAmount,
+			); err != nil {
+				return err
+			}
+
+			es = append(es, e)
+		}
+
+		return rows.Err()
+	})
+
+	return es, err
+}
@@ -0,0 +1,130 @@
+package repo
+
+import "context"
+
+// PendingExpense is a due occurrence of an estimate recurrent expense waiting
+// for the user to confirm its actual amount or skip it. The category,
+// description and currency come from the recurrent expense when it is
+// confirmed.
+type PendingExpense struct {
+	ID                 int
+	UserID             int
+	RecurrentExpenseID int
+	Date               int64
+	CreatedAt          int64
+}
+
+// pendingExpenseColumns pins the projection order the Scan calls in this file
+// depend on, as the other column lists do.
+const pendingExpenseColumns = `"id", "user_id", "recurrent_expense_id", "date", "created_at"`
+
+const insertPendingExpense = `
+INSERT INTO "pending_expenses" ("user_id", "recurrent_expense_id", "date")
+VALUES (?, ?, ?)
+RETURNING ` + pendingExpenseColumns
+
+func (q *TxQueries) InsertPendingExpense(
+	ctx context.Context,
+	userID, recurrentExpenseID int,
+	date int64,
+) (PendingExpense, error) {
+	var p PendingExpense
+
+	err := q.wrapQuery(insertPendingExpense, func() error {
+		row := q.tx.QueryRowContext(ctx, insertPendingExpense, userID, recurrentExpenseID, date)
+
+		return row.Scan(
+			&p.ID,
+			&p.UserID,
+			&p.RecurrentExpenseID,
+			&p.Date,
+			&p.CreatedAt,
+		)
+	})
+
+	return p, err
+}
+
+const selectPendingExpensesByUser = `SELECT ` + pendingExpenseColumns + `
+FROM "pending_expenses" WHERE "user_id" = ?
+ORDER BY "date" ASC, "id" ASC`
+
+func (q *Queries) SelectPendingExpensesByUser(ctx context.Context, userID int) ([]PendingExpense, error) {
+	var res []PendingExpense
+
+	err := q.wrapQuery(selectPendingExpensesByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectPendingExpensesByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var p PendingExpense
+
+			if err := rows.Scan(
+				&p.ID,
+				&p.UserID,
+				&p.RecurrentExpenseID,
+				&p.Date,
+				&p.CreatedAt,
+			); err != nil {
+				return err
+			}
+
+			res = append(res, p)
+		}
+
+		return rows.Err()
+	})
+
+	return res, err
+}
+
+const selectPendingExpense = `SELECT ` + pendingExpenseColumns + `
+FROM "pending_expenses" WHERE "id" = ? AND "user_id" = ?`
+
+func (q *Queries) SelectPendingExpense(ctx context.Context, id, userID int) (PendingExpense, error) {
+	var p PendingExpense
+
+	err := q.wrapQuery(selectPendingExpense, func() error {
+		row := q.db.QueryRowContext(ctx, selectPendingExpense, id, userID)
+
+		return row.Scan(
+			&p.ID,
+			&p.UserID,
+			&p.RecurrentExpenseID,
+			&p.Date,
+			&p.CreatedAt,
+		)
+	})
+
+	return p, err
+}
+
+// deletePendingExpense returns the row it removed, so confirming and skipping
+// both claim the item: a second request for the same one finds no row.
+const deletePendingExpense = `DELETE FROM "pending_expenses" WHERE "id" = ? AND "user_id" = ?
+RETURNING ` + pendingExpenseColumns
+
+func (q *TxQueries) DeletePendingExpense(ctx context.Context, id, userID int) (PendingExpense, error) {
+	var p PendingExpense
+
+	err := q.wrapQuery(deletePendingExpense, func() error {
+		row := q.tx.QueryRowContext(ctx, deletePendingExpense, id, userID)
+
+		return row.Scan(
+			&p.ID,
+			&p.UserID,
+			&p.RecurrentExpenseID,
+			&p.Date,
+			&p.CreatedAt,
+		)
+	})
+
+	return p, err
+}
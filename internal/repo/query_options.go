@@ -41,8 +41,19 @@ type QueryOptions struct {
 	pagination string
 }
 
+// notDeleted is the scope of every list query on a table with "deleted_at":
+// rows in the trash stay out of lists, counts and totals unless a trash query
+// asks for them explicitly.
+const notDeleted = `"deleted_at" IS NULL`
+
 func (q *QueryOptions) Build() (string, error) {
-	filters, err := q.Filters.Build()
+	return q.BuildWithin("")
+}
+
+// BuildWithin is Build with a repo-defined predicate that every row must match
+// on top of the caller's filters. See Filters.BuildWithin.
+func (q *QueryOptions) BuildWithin(scope string) (string, error) {
+	filters, err := q.Filters.BuildWithin(scope)
 	if err != nil {
 		return "", err
 	}
@@ -89,8 +100,19 @@ func (q *QueryOptions) Validate(fields []string) error {
 }
 
 func (f *Filters) Build() (string, error) {
+	return f.BuildWithin("")
+}
+
+// BuildWithin ANDs scope with the filters. The filters are parenthesized
+// because their connector may be OR, which would otherwise let a row outside
+// the scope through. scope takes no arguments, so Values is unchanged.
+func (f *Filters) BuildWithin(scope string) (string, error) {
 	if len(f.FilterFields) == 0 {
-		return "", nil
+		if scope == "" {
+			return "", nil
+		}
+
+		return "WHERE " + scope, nil
 	}
 
 	if len(f.FilterFields) > 1 && !f.validConnector() {
@@ -118,6 +140,9 @@ func (f *Filters) Build() (string, error) {
 	}
 
 	joined := strings.Join(buildFilters, " "+f.Connector+" ")
+	if scope != "" {
+		return "WHERE " + scope + " AND (" + joined + ")", nil
+	}
 
 	return "WHERE " + joined, nil
 }
@@ -56,3 +56,45 @@ func TestSortingBuild(t *testing.T) {
 		})
 	}
 }
+
+func TestFiltersBuildWithin(t *testing.T) {
+	cases := []struct {
+		name    string
+		filters repo.Filters
+		scope   string
+		want    string
+	}{
+		{
+			name:    "should_return_empty_without_scope_or_filters",
+			filters: repo.Filters{},
+			want:    "",
+		},
+		{
+			name:    "should_return_the_scope_alone_without_filters",
+			filters: repo.Filters{},
+			scope:   `"deleted_at" IS NULL`,
+			want:    `WHERE "deleted_at" IS NULL`,
+		},
+		{
+			name: "should_group_or_filters_under_the_scope",
+			filters: repo.Filters{
+				FilterFields: []repo.FilterField{
+					{Name: "user_id", Value: 1, Operator: "="},
+					{Name: "amount", Value: 10, Operator: ">"},
+				},
+				Connector: "OR",
+			},
+			scope: `"deleted_at" IS NULL`,
+			want:  `WHERE "deleted_at" IS NULL AND ("user_id" = ? OR "amount" > ?)`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.filters.BuildWithin(c.scope)
+
+			require.NoError(t, err)
+			require.Equal(t, c.want, got)
+		})
+	}
+}
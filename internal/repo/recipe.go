@@ -0,0 +1,417 @@
+package repo
+
+import "context"
+
+// Recipe is a dish made from foods. Servings is how many portions the whole
+// recipe yields.
+type Recipe struct {
+	ID        int
+	UserID    int
+	Name      string
+	Servings  float64
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+type InsertRecipeParams struct {
+	UserID   int
+	Name     string
+	Servings float64
+}
+
+type UpdateRecipeParams struct {
+	ID       int
+	UserID   int
+	Name     string
+	Servings float64
+}
+
+// RecipeIngredient is QuantityG grams of one food in a recipe.
+type RecipeIngredient struct {
+	ID        int
+	UserID    int
+	RecipeID  int
+	FoodID    int
+	QuantityG float64
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+type UpsertRecipeIngredientParams struct {
+	UserID    int
+	RecipeID  int
+	FoodID    int
+	QuantityG float64
+}
+
+// RecipeIngredientFood is an ingredient with its food as it stands now. The
+// food may be in the trash, which leaves it in the recipe until it is purged.
+type RecipeIngredientFood struct {
+	RecipeIngredient
+	Food Food
+}
+
+// recipeColumns and recipeIngredientColumns pin the projection order the Scan
+// calls in this file depend on, as the other column lists do.
+const (
+	recipeColumns = `"id", "user_id", "name", "servings", "created_at", "updated_at"`
+
+	recipeIngredientColumns = `"id", "user_id", "recipe_id", "food_id", "quantity_g", "created_at", "updated_at"`
+)
+
+// selectRecipeIngredientFoods joins each ingredient to its food. Its columns
+// are recipeIngredientColumns then foodColumns, qualified by table.
+const selectRecipeIngredientFoods = `
+SELECT "i"."id", "i"."user_id", "i"."recipe_id", "i"."food_id", "i"."quantity_g",
+       "i"."created_at", "i"."updated_at",
+       "f"."id", "f"."user_id", "f"."name", "f"."kcal", "f"."protein_g", "f"."carbs_g", "f"."fat_g",
+       "f"."created_at", "f"."updated_at", "f"."fiber_g", "f"."sodium_g", "f"."saturated_fat_g",
+       "f"."deleted_at", "f"."base_amount", "f"."base_unit", "f"."density_g_per_ml",
+       "f"."barcode"
+FROM "recipe_ingredients" AS "i"
+JOIN "foods" AS "f" ON "f"."id" = "i"."food_id"`
+
+const insertRecipe = `
+INSERT INTO "recipes" ("user_id", "name", "servings")
+VALUES (?, ?, ?)
+RETURNING ` + recipeColumns
+
+func (q *Queries) InsertRecipe(ctx context.Context, params InsertRecipeParams) (Recipe, error) {
+	var r Recipe
+
+	err := q.wrapQuery(insertRecipe, func() error {
+		row := q.db.QueryRowContext(ctx, insertRecipe, params.UserID, params.Name, params.Servings)
+
+		return row.Scan(
+			&r.ID,
+			&r.UserID,
+			&r.Name,
+			&r.Servings,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+		)
+	})
+
+	return r, err
+}
+
+const selectRecipesByUser = `
+SELECT ` + recipeColumns + ` FROM "recipes" WHERE "user_id" = ? ORDER BY lower("name")`
+
+func (q *Queries) SelectRecipesByUser(ctx context.Context, userID int) ([]Recipe, error) {
+	var rs []Recipe
+
+	err := q.wrapQuery(selectRecipesByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectRecipesByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var r Recipe
+
+			if err := rows.Scan(
+				&r.ID,
+				&r.UserID,
+				&r.Name,
+				&r.Servings,
+				&r.CreatedAt,
+				&r.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			rs = append(rs, r)
+		}
+
+		return rows.Err()
+	})
+
+	return rs, err
+}
+
+const selectRecipe = `
+SELECT ` + recipeColumns + ` FROM "recipes" WHERE "id" = ? AND "user_id" = ? LIMIT 1`
+
+func (q *Queries) SelectRecipe(ctx context.Context, id, userID int) (Recipe, error) {
+	var r Recipe
+
+	err := q.wrapQuery(selectRecipe, func() error {
+		row := q.db.QueryRowContext(ctx, selectRecipe, id, userID)
+
+		return row.Scan(
+			&r.ID,
+			&r.UserID,
+			&r.Name,
+			&r.Servings,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+		)
+	})
+
+	return r, err
+}
+
+const updateRecipe = `
+UPDATE "recipes"
+SET "name"       = ?,
+    "servings"   = ?,
+    "updated_at" = ?
+WHERE "id" = ? AND "user_id" = ?
+RETURNING ` + recipeColumns
+
+func (q *Queries) UpdateRecipe(ctx context.Context, params UpdateRecipeParams) (Recipe, error) {
+	var r Recipe
+
+	err := q.wrapQuery(updateRecipe, func() error {
+		row := q.db.QueryRowContext(
+			ctx,
+			updateRecipe,
+			params.Name,
+			params.Servings,
+			newUpdatedAt(),
+			params.ID,
+			params.UserID,
+		)
+
+		return row.Scan(
+			&r.ID,
+			&r.UserID,
+			&r.Name,
+			&r.Servings,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+		)
+	})
+
+	return r, err
+}
+
+// deleteRecipe takes the recipe's ingredients with it through their foreign
+// key. Macro entries logged from it are copies and stay.
+const deleteRecipe = `DELETE FROM "recipes" WHERE "id" = ? AND "user_id" = ? RETURNING "id"`
+
+func (q *Queries) DeleteRecipe(ctx context.Context, id, userID int) (int, error) {
+	var i int
+
+	err := q.wrapQuery(deleteRecipe, func() error {
+		row := q.db.QueryRowContext(ctx, deleteRecipe, id, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
+
+const countRecipesByUser = `SELECT COUNT(*) FROM "recipes" WHERE "user_id" = ?`
+
+func (q *Queries) CountRecipesByUser(ctx context.Context, userID int) (int, error) {
+	var c int
+
+	err := q.wrapQuery(countRecipesByUser, func() error {
+		row := q.db.QueryRowContext(ctx, countRecipesByUser, userID)
+
+		return row.Scan(&c)
+	})
+
+	return c, err
+}
+
+const deleteAllRecipesByUser = `DELETE FROM "recipes" WHERE "user_id" = ?`
+
+func (q *TxQueries) DeleteAllRecipesByUser(ctx context.Context, userID int) error {
+	return q.wrapQuery(deleteAllRecipesByUser, func() error {
+		_, err := q.tx.ExecContext(ctx, deleteAllRecipesByUser, userID)
+
+		return err
+	})
+}
+
+// upsertRecipeIngredient only inserts when both the recipe and a food that is
+// not in the trash belong to the user, so a foreign id yields sql.ErrNoRows.
+// Adding a food the recipe already has replaces its quantity. The WHERE clause
+// is what lets SQLite parse ON CONFLICT after a SELECT.
+const upsertRecipeIngredient = `
+INSERT INTO "recipe_ingredients" ("user_id", "recipe_id", "food_id", "quantity_g")
+SELECT "r"."user_id", "r"."id", "f"."id", ?
+FROM "recipes" AS "r"
+JOIN "foods" AS "f" ON "f"."user_id" = "r"."user_id" AND "f"."deleted_at" IS NULL
+WHERE "r"."id" = ? AND "r"."user_id" = ? AND "f"."id" = ?
+ON CONFLICT ("recipe_id", "food_id") DO UPDATE
+SET "quantity_g" = excluded."quantity_g",
+    "updated_at" = strftime('%s','now')
+RETURNING ` + recipeIngredientColumns
+
+func (q *Queries) UpsertRecipeIngredient(
+	ctx context.Context,
+	params UpsertRecipeIngredientParams,
+) (RecipeIngredient, error) {
+	var i RecipeIngredient
+
+	err := q.wrapQuery(upsertRecipeIngredient, func() error {
+		row := q.db.QueryRowContext(
+			ctx,
+			upsertRecipeIngredient,
+			params.QuantityG,
+			params.RecipeID,
+			params.UserID,
+			params.FoodID,
+		)
+
+		return row.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.RecipeID,
+			&i.FoodID,
+			&i.QuantityG,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		)
+	})
+
+	return i, err
+}
+
+const selectRecipeIngredientsByUser = selectRecipeIngredientFoods + `
+WHERE "i"."user_id" = ?
+ORDER BY "i"."recipe_id", lower("f"."name")`
+
+// SelectRecipeIngredientsByUser returns every ingredient of every recipe,
+// grouped by recipe.
+func (q *Queries) SelectRecipeIngredientsByUser(ctx context.Context, userID int) ([]RecipeIngredientFood, error) {
+	var is []RecipeIngredientFood
+
+	err := q.wrapQuery(selectRecipeIngredientsByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectRecipeIngredientsByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var i RecipeIngredientFood
+
+			if err := rows.Scan(
+				&i.ID,
+				&i.UserID,
+				&i.RecipeID,
+				&i.FoodID,
+				&i.QuantityG,
+				&i.CreatedAt,
+				&i.UpdatedAt,
+				&i.Food.ID,
+				&i.Food.UserID,
+				&i.Food.Name,
+				&i.Food.Kcal,
+				&i.Food.ProteinG,
+				&i.Food.CarbsG,
+				&i.Food.FatG,
+				&i.Food.CreatedAt,
+				&i.Food.UpdatedAt,
+				&i.Food.FiberG,
+				&i.Food.SodiumG,
+				&i.Food.SaturatedFatG,
+				&i.Food.DeletedAt,
+				&i.Food.BaseAmount,
+				&i.Food.BaseUnit,
+				&i.Food.DensityGPerML,
+				&i.Food.Barcode,
+			); err != nil {
+				return err
+			}
+
+			is = append(is, i)
+		}
+
+		return rows.Err()
+	})
+
+	return is, err
+}
+
+const selectRecipeIngredientsByRecipe = selectRecipeIngredientFoods + `
+WHERE "i"."recipe_id" = ? AND "i"."user_id" = ?
+ORDER BY lower("f"."name")`
+
+func (q *Queries) SelectRecipeIngredientsByRecipe(
+	ctx context.Context,
+	recipeID, userID int,
+) ([]RecipeIngredientFood, error) {
+	var is []RecipeIngredientFood
+
+	err := q.wrapQuery(selectRecipeIngredientsByRecipe, func() error {
+		rows, err := q.db.QueryContext(ctx, selectRecipeIngredientsByRecipe, recipeID, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var i RecipeIngredientFood
+
+			if err := rows.Scan(
+				&i.ID,
+				&i.UserID,
+				&i.RecipeID,
+				&i.FoodID,
+				&i.QuantityG,
+				&i.CreatedAt,
+				&i.UpdatedAt,
+				&i.Food.ID,
+				&i.Food.UserID,
+				&i.Food.Name,
+				&i.Food.Kcal,
+				&i.Food.ProteinG,
+				&i.Food.CarbsG,
+				&i.Food.FatG,
+				&i.Food.CreatedAt,
+				&i.Food.UpdatedAt,
+				&i.Food.FiberG,
+				&i.Food.SodiumG,
+				&i.Food.SaturatedFatG,
+				&i.Food.DeletedAt,
+				&i.Food.BaseAmount,
+				&i.Food.BaseUnit,
+				&i.Food.DensityGPerML,
+				&i.Food.Barcode,
+			); err != nil {
+				return err
+			}
+
+			is = append(is, i)
+		}
+
+		return rows.Err()
+	})
+
+	return is, err
+}
+
+const deleteRecipeIngredient = `
+DELETE FROM "recipe_ingredients" WHERE "id" = ? AND "recipe_id" = ? AND "user_id" = ?
+RETURNING "id"`
+
+func (q *Queries) DeleteRecipeIngredient(ctx context.Context, id, recipeID, userID int) (int, error) {
+	var i int
+
+	err := q.wrapQuery(deleteRecipeIngredient, func() error {
+		row := q.db.QueryRowContext(ctx, deleteRecipeIngredient, id, recipeID, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
@@ -18,8 +18,17 @@ type recurrentExpense struct {
 	OccurrenceLimit   uint
 	OccurrenceCount   uint
 	ArchivedAt        sql.NullInt64
+	Currency          string
+	Frequency         string
+	AnchorDay         uint
+	StartsAt          sql.NullInt64
+	EndsAt            sql.NullInt64
+	IsEstimate        bool
+	PaymentAccountID  *int
 }
 
+// RecurrentExpense copies itself into an expense every Period units of
+// Frequency. AnchorDay only applies to monthly and yearly rules.
 type RecurrentExpense struct {
 	ID                int
 	UserID            int
@@ -33,6 +42,16 @@ type RecurrentExpense struct {
 	OccurrenceLimit   uint
 	OccurrenceCount   uint
 	ArchivedAt        *int64
+	Currency          string
+	Frequency         string
+	AnchorDay         uint
+	StartsAt          *int64
+	EndsAt            *int64
+	// IsEstimate rules post their copies as pending expenses to be confirmed
+	// with the actual amount.
+	IsEstimate bool
+	// PaymentAccountID is the account its copies are paid from, if any.
+	PaymentAccountID *int
 }
 
 func (re recurrentExpense) toRecurrentExpense() RecurrentExpense {
@@ -61,6 +80,13 @@ func (re recurrentExpense) toRecurrentExpense() RecurrentExpense {
 		OccurrenceLimit:   re.OccurrenceLimit,
 		OccurrenceCount:   re.OccurrenceCount,
 		ArchivedAt:        archivedAt,
+		Currency:          re.Currency,
+		Frequency:         re.Frequency,
+		AnchorDay:         re.AnchorDay,
+		StartsAt:          ptrFromNullInt64(re.StartsAt),
+		EndsAt:            ptrFromNullInt64(re.EndsAt),
+		IsEstimate:        re.IsEstimate,
+		PaymentAccountID:  re.PaymentAccountID,
 	}
 }
 
@@ -72,13 +98,28 @@ func NullInt64FromPtr(value *int64) sql.NullInt64 {
 	return sql.NullInt64{Int64: *value, Valid: true}
 }
 
+func ptrFromNullInt64(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+
+	return &value.Int64
+}
+
 type InsertRecurrentExpenseParams struct {
-	UserID          int
-	CategoryID      int
-	Description     string
-	Amount          uint64
-	Period          uint
-	OccurrenceLimit uint
+	UserID           int
+	CategoryID       int
+	Description      string
+	Amount           uint64
+	Period           uint
+	OccurrenceLimit  uint
+	Currency         string
+	Frequency        string
+	AnchorDay        uint
+	StartsAt         sql.NullInt64
+	EndsAt           sql.NullInt64
+	IsEstimate       bool
+	PaymentAccountID *int
 }
 
 type UpdateRecurrentExpenseParams struct {
@@ -90,6 +131,13 @@ type UpdateRecurrentExpenseParams struct {
 	Period            uint
 	LastCopyCreatedAt sql.NullInt64
 	OccurrenceLimit   uint
+	Currency          string
+	Frequency         string
+	AnchorDay         uint
+	StartsAt          sql.NullInt64
+	EndsAt            sql.NullInt64
+	IsEstimate        bool
+	PaymentAccountID  *int
 }
 
 // RecurrentExpenseArchivedFilter builds the predicate splitting the active list
@@ -107,11 +155,15 @@ func RecurrentExpenseArchivedFilter(archived bool) FilterField {
 // SELECT * would resolve to whatever order the table happens to have, so an
 // ALTER TABLE could shift values into the wrong struct fields with no error.
 const recurrentExpenseColumns = `"id", "user_id", "category_id", "description", "amount", "period",
-"last_copy_created_at", "created_at", "updated_at", "occurrence_limit", "occurrence_count", "archived_at"`
+"last_copy_created_at", "created_at", "updated_at", "occurrence_limit", "occurrence_count", "archived_at",
+"currency", "frequency", "anchor_day", "starts_at", "ends_at", "is_estimate", "payment_account_id"`
 
 const insertRecurrentExpense = `
-INSERT INTO "recurrent_expenses" ("user_id", "category_id", "description", "amount", "period", "occurrence_limit")
-VALUES (?, ?, ?, ?, ?, ?)
+INSERT INTO "recurrent_expenses" (
+  "user_id", "category_id", "description", "amount", "period", "occurrence_limit", "currency",
+  "frequency", "anchor_day", "starts_at", "ends_at", "is_estimate", "payment_account_id"
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING ` + recurrentExpenseColumns
 
 const selectRecurrentExpenses = `SELECT ` + recurrentExpenseColumns + ` FROM "recurrent_expenses"`
@@ -158,6 +210,13 @@ func (q *Queries) SelectRecurrentExpenses(ctx context.Context, opts QueryOptions
 				&re.OccurrenceLimit,
 				&re.OccurrenceCount,
 				&re.ArchivedAt,
+				&re.Currency,
+				&re.Frequency,
+				&re.AnchorDay,
+				&re.StartsAt,
+				&re.EndsAt,
+				&re.IsEstimate,
+				&re.PaymentAccountID,
 			); err != nil {
 				return err
 			}
@@ -209,6 +268,13 @@ func (q *TxQueries) InsertRecurrentExpense(
 			params.Amount,
 			params.Period,
 			params.OccurrenceLimit,
+			params.Currency,
+			params.Frequency,
+			params.AnchorDay,
+			params.StartsAt,
+			params.EndsAt,
+			params.IsEstimate,
+			params.PaymentAccountID,
 		)
 
 		return row.Scan(
@@ -224,6 +290,13 @@ func (q *TxQueries) InsertRecurrentExpense(
 			&re.OccurrenceLimit,
 			&re.OccurrenceCount,
 			&re.ArchivedAt,
+			&re.Currency,
+			&re.Frequency,
+			&re.AnchorDay,
+			&re.StartsAt,
+			&re.EndsAt,
+			&re.IsEstimate,
+			&re.PaymentAccountID,
 		)
 	})
 
@@ -236,7 +309,9 @@ func (q *TxQueries) InsertRecurrentExpense(
 // reordering them would go unnoticed since they carry the same value.
 // "archived_at" is what the cron job reads, so an edit that leaves the row unable
 // to ever run again has to stamp it — otherwise the row reads as active and
-// silently stops generating expenses.
+// silently stops generating expenses. An empty currency keeps the current one;
+// the rule columns and the payment account are always replaced, so a cleared
+// start or end date, or account, sticks.
 const updateRecurrentExpense = `
 UPDATE "recurrent_expenses"
 SET "category_id"          = ?1,
@@ -250,6 +325,13 @@ SET "category_id"          = ?1,
                                THEN COALESCE("archived_at", ?7)
                                ELSE "archived_at"
                              END,
+    "currency"             = COALESCE(NULLIF(?10, ''), "currency"),
+    "frequency"            = ?11,
+    "anchor_day"           = ?12,
+    "starts_at"            = ?13,
+    "ends_at"              = ?14,
+    "is_estimate"          = ?15,
+    "payment_account_id"   = ?16,
     "updated_at"           = ?7
 WHERE "id" = ?8 AND "user_id" = ?9
 RETURNING ` + recurrentExpenseColumns + `;
@@ -277,6 +359,13 @@ func (q *Queries) UpdateRecurrentExpense(
 			now,
 			params.ID,
 			params.UserID,
+			params.Currency,
+			params.Frequency,
+			params.AnchorDay,
+			params.StartsAt,
+			params.EndsAt,
+			params.IsEstimate,
+			params.PaymentAccountID,
 		)
 
 		return row.Scan(
@@ -292,6 +381,13 @@ func (q *Queries) UpdateRecurrentExpense(
 			&re.OccurrenceLimit,
 			&re.OccurrenceCount,
 			&re.ArchivedAt,
+			&re.Currency,
+			&re.Frequency,
+			&re.AnchorDay,
+			&re.StartsAt,
+			&re.EndsAt,
+			&re.IsEstimate,
+			&re.PaymentAccountID,
 		)
 	})
 
@@ -318,6 +414,13 @@ func (q *TxQueries) UpdateRecurrentExpense(
 			now,
 			params.ID,
 			params.UserID,
+			params.Currency,
+			params.Frequency,
+			params.AnchorDay,
+			params.StartsAt,
+			params.EndsAt,
+			params.IsEstimate,
+			params.PaymentAccountID,
 		)
 
 		return row.Scan(
@@ -333,6 +436,13 @@ func (q *TxQueries) UpdateRecurrentExpense(
 			&re.OccurrenceLimit,
 			&re.OccurrenceCount,
 			&re.ArchivedAt,
+			&re.Currency,
+			&re.Frequency,
+			&re.AnchorDay,
+			&re.StartsAt,
+			&re.EndsAt,
+			&re.IsEstimate,
+			&re.PaymentAccountID,
 		)
 	})
 
@@ -408,31 +518,35 @@ func (q *Queries) SelectRecurrentExpense(ctx context.Context, id, userID int) (R
 			&re.OccurrenceLimit,
 			&re.OccurrenceCount,
 			&re.ArchivedAt,
+			&re.Currency,
+			&re.Frequency,
+			&re.AnchorDay,
+			&re.StartsAt,
+			&re.EndsAt,
+			&re.IsEstimate,
+			&re.PaymentAccountID,
 		)
 	})
 
 	return re.toRecurrentExpense(), err
 }
 
-const selectAllDueRecurrentExpenses = `
+// selectActiveRecurrentExpenses narrows the rows the copy task looks at to the
+// ones that could be due. Whether a rule is due depends on its frequency and
+// day clamping, which logic works out from the row.
+const selectActiveRecurrentExpenses = `
 SELECT ` + recurrentExpenseColumns + `
 FROM "recurrent_expenses"
 WHERE "archived_at" IS NULL
-  AND ("last_copy_created_at" IS NULL
-   OR (
-        (CAST(strftime('%Y', datetime(?, 'unixepoch')) AS int) -
-         CAST(strftime('%Y', datetime("last_copy_created_at", 'unixepoch')) AS int)) * 12 +
-        (CAST(strftime('%m', datetime(?, 'unixepoch')) AS int) -
-         CAST(strftime('%m', datetime("last_copy_created_at", 'unixepoch')) AS int))
-      ) >= "period")
+  AND ("starts_at" IS NULL OR "starts_at" <= ?)
 ORDER BY "id" ASC
 `
 
-func (q *Queries) SelectAllDueRecurrentExpenses(ctx context.Context, nowUnix int64) ([]RecurrentExpense, error) {
+func (q *Queries) SelectActiveRecurrentExpenses(ctx context.Context, nowUnix int64) ([]RecurrentExpense, error) {
 	var res []RecurrentExpense
 
-	err := q.wrapQuery(selectAllDueRecurrentExpenses, func() error {
-		rows, err := q.db.QueryContext(ctx, selectAllDueRecurrentExpenses, nowUnix, nowUnix)
+	err := q.wrapQuery(selectActiveRecurrentExpenses, func() error {
+		rows, err := q.db.QueryContext(ctx, selectActiveRecurrentExpenses, nowUnix)
 		if err != nil {
 			return err
 		}
@@ -458,6 +572,13 @@ func (q *Queries) SelectAllDueRecurrentExpenses(ctx context.Context, nowUnix int
 				&re.OccurrenceLimit,
 				&re.OccurrenceCount,
 				&re.ArchivedAt,
+				&re.Currency,
+				&re.Frequency,
+				&re.AnchorDay,
+				&re.StartsAt,
+				&re.EndsAt,
+				&re.IsEstimate,
+				&re.PaymentAccountID,
 			); err != nil {
 				return err
 			}
@@ -473,20 +594,26 @@ func (q *Queries) SelectAllDueRecurrentExpenses(ctx context.Context, nowUnix int
 
 // recordRecurrentExpenseOccurrence closes out one generated copy: it stamps the
 // copy date, bumps the counter and archives the row in the same statement when
-// the bumped counter reaches a non-zero limit. Doing it in one UPDATE keeps the
-// count and the archived flag from disagreeing.
+// the bumped counter reaches a non-zero limit, or when the rule has no
+// occurrence left before its end date. Doing it in one UPDATE keeps the count
+// and the archived flag from disagreeing. It only matches an active row whose
+// last copy is older than this one, so a copy another run already recorded
+// comes back as sql.ErrNoRows instead of being counted twice.
 const recordRecurrentExpenseOccurrence = `
 UPDATE "recurrent_expenses"
 SET "last_copy_created_at" = ?,
     "occurrence_count"     = "occurrence_count" + 1,
     "archived_at"          = CASE
-                               WHEN "occurrence_limit" > 0
-                                AND "occurrence_count" + 1 >= "occurrence_limit"
+                               WHEN ("occurrence_limit" > 0
+                                AND "occurrence_count" + 1 >= "occurrence_limit")
+                                 OR ?
                                THEN ?
                                ELSE "archived_at"
                              END,
     "updated_at"           = ?
 WHERE "id" = ? AND "user_id" = ?
+  AND "archived_at" IS NULL
+  AND ("last_copy_created_at" IS NULL OR "last_copy_created_at" < ?)
 RETURNING ` + recurrentExpenseColumns + `;
 `
 
@@ -494,6 +621,7 @@ func (q *TxQueries) RecordRecurrentExpenseOccurrence(
 	ctx context.Context,
 	id, userID int,
 	copiedAt int64,
+	finished bool,
 ) (RecurrentExpense, error) {
 	var re recurrentExpense
 	now := newUpdatedAt()
@@ -503,10 +631,12 @@ func (q *TxQueries) RecordRecurrentExpenseOccurrence(
 			ctx,
 			recordRecurrentExpenseOccurrence,
 			copiedAt,
+			finished,
 			now,
 			now,
 			id,
 			userID,
+			copiedAt,
 		)
 
 		return row.Scan(
@@ -522,6 +652,13 @@ func (q *TxQueries) RecordRecurrentExpenseOccurrence(
 			&re.OccurrenceLimit,
 			&re.OccurrenceCount,
 			&re.ArchivedAt,
+			&re.Currency,
+			&re.Frequency,
+			&re.AnchorDay,
+			&re.StartsAt,
+			&re.EndsAt,
+			&re.IsEstimate,
+			&re.PaymentAccountID,
 		)
 	})
 
@@ -558,6 +695,13 @@ func (q *TxQueries) UnarchiveRecurrentExpense(ctx context.Context, id, userID in
 			&re.OccurrenceLimit,
 			&re.OccurrenceCount,
 			&re.ArchivedAt,
+			&re.Currency,
+			&re.Frequency,
+			&re.AnchorDay,
+			&re.StartsAt,
+			&re.EndsAt,
+			&re.IsEstimate,
+			&re.PaymentAccountID,
 		)
 	})
 
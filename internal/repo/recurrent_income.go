@@ -0,0 +1,517 @@
+package repo
+
+import "context"
+
+// RecurrentIncome copies itself into an income every Period units of
+// Frequency, on the same rule columns as RecurrentExpense.
+type RecurrentIncome struct {
+	ID                int
+	UserID            int
+	Source            string
+	Amount            uint64
+	Currency          string
+	Period            uint
+	Frequency         string
+	AnchorDay         uint
+	StartsAt          *int64
+	EndsAt            *int64
+	OccurrenceLimit   uint
+	OccurrenceCount   uint
+	LastCopyCreatedAt *int64
+	ArchivedAt        *int64
+	CreatedAt         int64
+	UpdatedAt         int64
+}
+
+type InsertRecurrentIncomeParams struct {
+	UserID          int
+	Source          string
+	Amount          uint64
+	Currency        string
+	Period          uint
+	Frequency       string
+	AnchorDay       uint
+	StartsAt        *int64
+	EndsAt          *int64
+	OccurrenceLimit uint
+}
+
+type UpdateRecurrentIncomeParams struct {
+	ID              int
+	UserID          int
+	Source          string
+	Amount          uint64
+	Currency        string
+	Period          uint
+	Frequency       string
+	AnchorDay       uint
+	StartsAt        *int64
+	EndsAt          *int64
+	OccurrenceLimit uint
+}
+
+// recurrentIncomeColumns pins the projection order the Scan calls in this
+// file depend on, as the other column lists do.
+const recurrentIncomeColumns = `"id", "user_id", "source", "amount", "currency", "period", "frequency",
+"anchor_day", "starts_at", "ends_at", "occurrence_limit", "occurrence_count", "last_copy_created_at",
+"archived_at", "created_at", "updated_at"`
+
+const selectRecurrentIncomes = `SELECT ` + recurrentIncomeColumns + ` FROM "recurrent_incomes"`
+
+func (q *Queries) SelectRecurrentIncomes(ctx context.Context, opts QueryOptions) ([]RecurrentIncome, error) {
+	var res []RecurrentIncome
+
+	if err := opts.Validate(validRecurrentIncomeFields()); err != nil {
+		return res, err
+	}
+
+	subQuery, err := opts.Build()
+	if err != nil {
+		return res, err
+	}
+
+	query := selectRecurrentIncomes + " " + subQuery
+	values := opts.Filters.Values()
+
+	err = q.wrapQuery(query, func() error {
+		rows, err := q.db.QueryContext(ctx, query, values...)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var ri RecurrentIncome
+
+			if err := rows.Scan(
+				&ri.ID,
+				&ri.UserID,
+				&ri.Source,
+				&ri.Amount,
+				&ri.Currency,
+				&ri.Period,
+				&ri.Frequency,
+				&ri.AnchorDay,
+				&ri.StartsAt,
+				&ri.EndsAt,
+				&ri.OccurrenceLimit,
+				&ri.OccurrenceCount,
+				&ri.LastCopyCreatedAt,
+				&ri.ArchivedAt,
+				&ri.CreatedAt,
+				&ri.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			res = append(res, ri)
+		}
+
+		return rows.Err()
+	})
+
+	return res, err
+}
+
+const countRecurrentIncomes = `SELECT COUNT(*) FROM "recurrent_incomes"`
+
+func (q *Queries) CountRecurrentIncomes(ctx context.Context, filters Filters) (int, error) {
+	var c int
+
+	subQuery, err := filters.Build()
+	if err != nil {
+		return 0, err
+	}
+
+	query := countRecurrentIncomes + " " + subQuery
+	values := filters.Values()
+
+	err = q.wrapQuery(query, func() error {
+		row := q.db.QueryRowContext(ctx, query, values...)
+
+		return row.Scan(&c)
+	})
+
+	return c, err
+}
+
+const selectRecurrentIncome = `SELECT ` + recurrentIncomeColumns + `
+FROM "recurrent_incomes" WHERE "id" = ? AND "user_id" = ? LIMIT 1`
+
+func (q *Queries) SelectRecurrentIncome(ctx context.Context, id, userID int) (RecurrentIncome, error) {
+	var ri RecurrentIncome
+
+	err := q.wrapQuery(selectRecurrentIncome, func() error {
+		row := q.db.QueryRowContext(ctx, selectRecurrentIncome, id, userID)
+
+		return row.Scan(
+			&ri.ID,
+			&ri.UserID,
+			&ri.Source,
+			&ri.Amount,
+			&ri.Currency,
+			&ri.Period,
+			&ri.Frequency,
+			&ri.AnchorDay,
+			&ri.StartsAt,
+			&ri.EndsAt,
+			&ri.OccurrenceLimit,
+			&ri.OccurrenceCount,
+			&ri.LastCopyCreatedAt,
+			&ri.ArchivedAt,
+			&ri.CreatedAt,
+			&ri.UpdatedAt,
+		)
+	})
+
+	return ri, err
+}
+
+const insertRecurrentIncome = `
+INSERT INTO "recurrent_incomes" (
+  "user_id", "source", "amount", "currency", "period", "frequency", "anchor_day", "starts_at", "ends_at",
+  "occurrence_limit"
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING ` + recurrentIncomeColumns
+
+func (q *TxQueries) InsertRecurrentIncome(
+	ctx context.Context,
+	params InsertRecurrentIncomeParams,
+) (RecurrentIncome, error) {
+	var ri RecurrentIncome
+
+	err := q.wrapQuery(insertRecurrentIncome, func() error {
+		row := q.tx.QueryRowContext(
+			ctx,
+			insertRecurrentIncome,
+			params.UserID,
+			params.Source,
+			params.Amount,
+			params.Currency,
+			params.Period,
+			params.Frequency,
+			params.AnchorDay,
+			params.StartsAt,
+			params.EndsAt,
+			params.OccurrenceLimit,
+		)
+
+		return row.Scan(
+			&ri.ID,
+			&ri.UserID,
+			&ri.Source,
+			&ri.Amount,
+			&ri.Currency,
+			&ri.Period,
+			&ri.Frequency,
+			&ri.AnchorDay,
+			&ri.StartsAt,
+			&ri.EndsAt,
+			&ri.OccurrenceLimit,
+			&ri.OccurrenceCount,
+			&ri.LastCopyCreatedAt,
+			&ri.ArchivedAt,
+			&ri.CreatedAt,
+			&ri.UpdatedAt,
+		)
+	})
+
+	return ri, err
+}
+
+// updateRecurrentIncome follows updateRecurrentExpense: an edited limit that
+// is already met archives the row, an empty currency keeps the current one
+// and the rule columns are always replaced.
+const updateRecurrentIncome = `
+UPDATE "recurrent_incomes"
+SET "source"           = ?1,
+    "amount"           = ?2,
+    "currency"         = COALESCE(NULLIF(?3, ''), "currency"),
+    "period"           = ?4,
+    "frequency"        = ?5,
+    "anchor_day"       = ?6,
+    "starts_at"        = ?7,
+    "ends_at"          = ?8,
+    "occurrence_limit" = ?9,
+    "archived_at"      = CASE
+                           WHEN ?9 > 0 AND ?9 <= "occurrence_count"
+                           THEN COALESCE("archived_at", ?10)
+                           ELSE "archived_at"
+                         END,
+    "updated_at"       = ?10
+WHERE "id" = ?11 AND "user_id" = ?12
+RETURNING ` + recurrentIncomeColumns
+
+func (q *TxQueries) UpdateRecurrentIncome(
+	ctx context.Context,
+	params UpdateRecurrentIncomeParams,
+) (RecurrentIncome, error) {
+	var ri RecurrentIncome
+
+	err := q.wrapQuery(updateRecurrentIncome, func() error {
+		row := q.tx.QueryRowContext(
+			ctx,
+			updateRecurrentIncome,
+			params.Source,
+			params.Amount,
+			params.Currency,
+			params.Period,
+			params.Frequency,
+			params.AnchorDay,
+			params.StartsAt,
+			params.EndsAt,
+			params.OccurrenceLimit,
+			newUpdatedAt(),
+			params.ID,
+			params.UserID,
+		)
+
+		return row.Scan(
+			&ri.ID,
+			&ri.UserID,
+			&ri.Source,
+			&ri.Amount,
+			&ri.Currency,
+			&ri.Period,
+			&ri.Frequency,
+			&ri.AnchorDay,
+			&ri.StartsAt,
+			&ri.EndsAt,
+			&ri.OccurrenceLimit,
+			&ri.OccurrenceCount,
+			&ri.LastCopyCreatedAt,
+			&ri.ArchivedAt,
+			&ri.CreatedAt,
+			&ri.UpdatedAt,
+		)
+	})
+
+	return ri, err
+}
+
+const deleteRecurrentIncome = `DELETE FROM "recurrent_incomes" WHERE "id" = ? AND "user_id" = ? RETURNING "id"`
+
+func (q *TxQueries) DeleteRecurrentIncome(ctx context.Context, id, userID int) (int, error) {
+	var i int
+
+	err := q.wrapQuery(deleteRecurrentIncome, func() error {
+		row := q.tx.QueryRowContext(ctx, deleteRecurrentIncome, id, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
+
+const countRecurrentIncomesByUser = `SELECT COUNT(*) FROM "recurrent_incomes" WHERE "user_id" = ?`
+
+func (q *Queries) CountRecurrentIncomesByUser(ctx context.Context, userID int) (int, error) {
+	var c int
+
+	err := q.wrapQuery(countRecurrentIncomesByUser, func() error {
+		row := q.db.QueryRowContext(ctx, countRecurrentIncomesByUser, userID)
+
+		return row.Scan(&c)
+	})
+
+	return c, err
+}
+
+const deleteRecurrentIncomeTaggingsByUser = `
+DELETE FROM "taggings"
+WHERE "taggable_type" = 'recurrent_income'
+  AND "taggable_id" IN (SELECT "id" FROM "recurrent_incomes" WHERE "user_id" = ?)`
+
+const deleteAllRecurrentIncomesByUser = `DELETE FROM "recurrent_incomes" WHERE "user_id" = ?`
+
+func (q *TxQueries) DeleteAllRecurrentIncomesByUser(ctx context.Context, userID int) error {
+	return q.wrapQuery(deleteAllRecurrentIncomesByUser, func() error {
+		if _, err := q.tx.ExecContext(ctx, deleteRecurrentIncomeTaggingsByUser, userID); err != nil {
+			return err
+		}
+
+		_, err := q.tx.ExecContext(ctx, deleteAllRecurrentIncomesByUser, userID)
+
+		return err
+	})
+}
+
+// selectActiveRecurrentIncomes narrows the rows the copy task looks at, like
+// selectActiveRecurrentExpenses.
+const selectActiveRecurrentIncomes = `
+SELECT ` + recurrentIncomeColumns + `
+FROM "recurrent_incomes"
+WHERE "archived_at" IS NULL
+  AND ("starts_at" IS NULL OR "starts_at" <= ?)
+ORDER BY "id" ASC`
+
+func (q *Queries) SelectActiveRecurrentIncomes(ctx context.Context, nowUnix int64) ([]RecurrentIncome, error) {
+	var res []RecurrentIncome
+
+	err := q.wrapQuery(selectActiveRecurrentIncomes, func() error {
+		rows, err := q.db.QueryContext(ctx, selectActiveRecurrentIncomes, nowUnix)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var ri RecurrentIncome
+
+			if err := rows.Scan(
+				&ri.ID,
+				&ri.UserID,
+				&ri.Source,
+				&ri.Amount,
+				&ri.Currency,
+				&ri.Period,
+				&ri.Frequency,
+				&ri.AnchorDay,
+				&ri.StartsAt,
+				&ri.EndsAt,
+				&ri.OccurrenceLimit,
+				&ri.OccurrenceCount,
+				&ri.LastCopyCreatedAt,
+				&ri.ArchivedAt,
+				&ri.CreatedAt,
+				&ri.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			res = append(res, ri)
+		}
+
+		return rows.Err()
+	})
+
+	return res, err
+}
+
+// recordRecurrentIncomeOccurrence is recordRecurrentExpenseOccurrence for
+// incomes: one UPDATE stamps the copy, bumps the counter and archives the row
+// when it is done, and a copy another run already recorded comes back as
+// sql.ErrNoRows.
+const recordRecurrentIncomeOccurrence = `
+UPDATE "recurrent_incomes"
+SET "last_copy_created_at" = ?1,
+    "occurrence_count"     = "occurrence_count" + 1,
+    "archived_at"          = CASE
+                               WHEN ("occurrence_limit" > 0
+                                AND "occurrence_count" + 1 >= "occurrence_limit")
+                                 OR ?2
+                               THEN ?3
+                               ELSE "archived_at"
+                             END,
+    "updated_at"           = ?3
+WHERE "id" = ?4 AND "user_id" = ?5
+  AND "archived_at" IS NULL
+  AND ("last_copy_created_at" IS NULL OR "last_copy_created_at" < ?1)
+RETURNING ` + recurrentIncomeColumns
+
+func (q *TxQueries) RecordRecurrentIncomeOccurrence(
+	ctx context.Context,
+	id, userID int,
+	copiedAt int64,
+	finished bool,
+) (RecurrentIncome, error) {
+	var ri RecurrentIncome
+
+	err := q.wrapQuery(recordRecurrentIncomeOccurrence, func() error {
+		row := q.tx.QueryRowContext(
+			ctx,
+			recordRecurrentIncomeOccurrence,
+			copiedAt,
+			finished,
+			newUpdatedAt(),
+			id,
+			userID,
+		)
+
+		return row.Scan(
+			&ri.ID,
+			&ri.UserID,
+			&ri.Source,
+			&ri.Amount,
+			&ri.Currency,
+			&ri.Period,
+			&ri.Frequency,
+			&ri.AnchorDay,
+			&ri.StartsAt,
+			&ri.EndsAt,
+			&ri.OccurrenceLimit,
+			&ri.OccurrenceCount,
+			&ri.LastCopyCreatedAt,
+			&ri.ArchivedAt,
+			&ri.CreatedAt,
+			&ri.UpdatedAt,
+		)
+	})
+
+	return ri, err
+}
+
+// unarchiveRecurrentIncome resets the counter along with the flag, as
+// unarchiveRecurrentExpense does.
+const unarchiveRecurrentIncome = `
+UPDATE "recurrent_incomes"
+SET "archived_at"      = NULL,
+    "occurrence_count" = 0,
+    "updated_at"       = ?
+WHERE "id" = ? AND "user_id" = ? AND "archived_at" IS NOT NULL
+RETURNING ` + recurrentIncomeColumns
+
+func (q *TxQueries) UnarchiveRecurrentIncome(ctx context.Context, id, userID int) (RecurrentIncome, error) {
+	var ri RecurrentIncome
+
+	err := q.wrapQuery(unarchiveRecurrentIncome, func() error {
+		row := q.tx.QueryRowContext(ctx, unarchiveRecurrentIncome, newUpdatedAt(), id, userID)
+
+		return row.Scan(
+			&ri.ID,
+			&ri.UserID,
+			&ri.Source,
+			&ri.Amount,
+			&ri.Currency,
+			&ri.Period,
+			&ri.Frequency,
+			&ri.AnchorDay,
+			&ri.StartsAt,
+			&ri.EndsAt,
+			&ri.OccurrenceLimit,
+			&ri.OccurrenceCount,
+			&ri.LastCopyCreatedAt,
+			&ri.ArchivedAt,
+			&ri.CreatedAt,
+			&ri.UpdatedAt,
+		)
+	})
+
+	return ri, err
+}
+
+func validRecurrentIncomeFields() []string {
+	return []string{
+		"id",
+		"user_id",
+		"source",
+		"amount",
+		"currency",
+		"period",
+		"occurrence_limit",
+		"occurrence_count",
+		"last_copy_created_at",
+		"archived_at",
+		"created_at",
+		"updated_at",
+	}
+}
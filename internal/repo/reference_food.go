@@ -0,0 +1,219 @@
+package repo
+
+import "context"
+
+// ReferenceFood is a row of the shared catalogue imported from a nutrition
+// database dump. Nutrients are per 100 g.
+type ReferenceFood struct {
+	ID            int
+	Source        string
+	SourceID      string
+	Name          string
+	Brand         string
+	Kcal          float64
+	ProteinG      float64
+	CarbsG        float64
+	FatG          float64
+	FiberG        float64
+	SodiumG       float64
+	SaturatedFatG float64
+	CreatedAt     int64
+	UpdatedAt     int64
+	Barcode       *string
+}
+
+type UpsertReferenceFoodParams struct {
+	Source        string
+	SourceID      string
+	Name          string
+	Brand         string
+	Kcal          float64
+	ProteinG      float64
+	CarbsG        float64
+	FatG          float64
+	FiberG        float64
+	SodiumG       float64
+	SaturatedFatG float64
+	Barcode       *string
+}
+
+// referenceFoodColumns pins the projection order the Scan calls in this file
+// depend on.
+const referenceFoodColumns = `"id", "source", "source_id", "name", "brand", "kcal", "protein_g", "carbs_g",
+"fat_g", "fiber_g", "sodium_g", "saturated_fat_g", "created_at", "updated_at", "barcode"`
+
+// upsertReferenceFood replaces a row imported before from the same source, so
+// loading a newer dump refreshes the catalogue instead of duplicating it.
+const upsertReferenceFood = `
+INSERT INTO "reference_foods" (
+  "source", "source_id", "name", "brand", "kcal", "protein_g", "carbs_g", "fat_g",
+  "fiber_g", "sodium_g", "saturated_fat_g", "barcode"
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT ("source", "source_id") DO UPDATE SET
+  "name"            = excluded."name",
+  "brand"           = excluded."brand",
+  "kcal"            = excluded."kcal",
+  "protein_g"       = excluded."protein_g",
+  "carbs_g"         = excluded."carbs_g",
+  "fat_g"           = excluded."fat_g",
+  "fiber_g"         = excluded."fiber_g",
+  "sodium_g"        = excluded."sodium_g",
+  "saturated_fat_g" = excluded."saturated_fat_g",
+  "barcode"         = excluded."barcode",
+  "updated_at"      = strftime('%s','now')`
+
+func (q *TxQueries) UpsertReferenceFood(ctx context.Context, params UpsertReferenceFoodParams) error {
+	return q.wrapQuery(upsertReferenceFood, func() error {
+		_, err := q.tx.ExecContext(
+			ctx,
+			upsertReferenceFood,
+			params.Source,
+			params.SourceID,
+			params.Name,
+			params.Brand,
+			params.Kcal,
+			params.ProteinG,
+			params.CarbsG,
+			params.FatG,
+			params.FiberG,
+			params.SodiumG,
+			params.SaturatedFatG,
+			params.Barcode,
+		)
+
+		return err
+	})
+}
+
+// searchReferenceFoods matches names and brands that start with the term.
+// Each half is a range walk of its own index, cut at the limit before the two
+// are merged, so a search reads a page of rows however large the catalogue;
+// a substring match would read every row.
+const searchReferenceFoods = `
+SELECT ` + referenceFoodColumns + ` FROM "reference_foods"
+WHERE "id" IN (
+  SELECT "id" FROM (
+    SELECT "id" FROM "reference_foods" WHERE "name" LIKE ?1 ESCAPE '\'
+    ORDER BY "name" COLLATE NOCASE, "id" LIMIT ?2
+  )
+  UNION
+  SELECT "id" FROM (
+    SELECT "id" FROM "reference_foods" WHERE "brand" LIKE ?1 ESCAPE '\'
+    ORDER BY "brand" COLLATE NOCASE, "name" COLLATE NOCASE, "id" LIMIT ?2
+  )
+)
+ORDER BY "name" COLLATE NOCASE, "id"
+LIMIT ?2`
+
+func (q *Queries) SearchReferenceFoods(ctx context.Context, term string, limit int) ([]ReferenceFood, error) {
+	var rfs []ReferenceFood
+
+	err := q.wrapQuery(searchReferenceFoods, func() error {
+		rows, err := q.db.QueryContext(ctx, searchReferenceFoods, escapeLikePattern(term)+"%", limit)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var rf ReferenceFood
+
+			if err := rows.Scan(
+				&rf.ID,
+				&rf.Source,
+				&rf.SourceID,
+				&rf.Name,
+				&rf.Brand,
+				&rf.Kcal,
+				&rf.ProteinG,
+				&rf.CarbsG,
+				&rf.FatG,
+				&rf.FiberG,
+				&rf.SodiumG,
+				&rf.SaturatedFatG,
+				&rf.CreatedAt,
+				&rf.UpdatedAt,
+				&rf.Barcode,
+			); err != nil {
+				return err
+			}
+
+			rfs = append(rfs, rf)
+		}
+
+		return rows.Err()
+	})
+
+	return rfs, err
+}
+
+// selectReferenceFoodByBarcode prefers the most recently imported row when
+// both sources carry the code.
+const selectReferenceFoodByBarcode = `
+SELECT ` + referenceFoodColumns + ` FROM "reference_foods"
+WHERE "barcode" = ?
+ORDER BY "updated_at" DESC, "id" DESC LIMIT 1`
+
+func (q *Queries) SelectReferenceFoodByBarcode(ctx context.Context, barcode string) (ReferenceFood, error) {
+	var rf ReferenceFood
+
+	err := q.wrapQuery(selectReferenceFoodByBarcode, func() error {
+		row := q.db.QueryRowContext(ctx, selectReferenceFoodByBarcode, barcode)
+
+		return row.Scan(
+			&rf.ID,
+			&rf.Source,
+			&rf.SourceID,
+			&rf.Name,
+			&rf.Brand,
+			&rf.Kcal,
+			&rf.ProteinG,
+			&rf.CarbsG,
+			&rf.FatG,
+			&rf.FiberG,
+			&rf.SodiumG,
+			&rf.SaturatedFatG,
+			&rf.CreatedAt,
+			&rf.UpdatedAt,
+			&rf.Barcode,
+		)
+	})
+
+	return rf, err
+}
+
+const selectReferenceFood = `
+SELECT ` + referenceFoodColumns + ` FROM "reference_foods" WHERE "id" = ? LIMIT 1`
+
+func (q *Queries) SelectReferenceFood(ctx context.Context, id int) (ReferenceFood, error) {
+	var rf ReferenceFood
+
+	err := q.wrapQuery(selectReferenceFood, func() error {
+		row := q.db.QueryRowContext(ctx, selectReferenceFood, id)
+
+		return row.Scan(
+			&rf.ID,
+			&rf.Source,
+			&rf.SourceID,
+			&rf.Name,
+			&rf.Brand,
+			&rf.Kcal,
+			&rf.ProteinG,
+			&rf.CarbsG,
+			&rf.FatG,
+			&rf.FiberG,
+			&rf.SodiumG,
+			&rf.SaturatedFatG,
+			&rf.CreatedAt,
+			&rf.UpdatedAt,
+			&rf.Barcode,
+		)
+	})
+
+	return rf, err
+}
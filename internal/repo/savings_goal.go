@@ -0,0 +1,401 @@
+package repo
+
+import "context"
+
+// SavingsGoal is an amount to set aside by TargetDate, in cents of the owner's
+// home currency.
+type SavingsGoal struct {
+	ID           int
+	UserID       int
+	Name         string
+	TargetAmount uint64
+	TargetDate   int64
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+type InsertSavingsGoalParams struct {
+	UserID       int
+	Name         string
+	TargetAmount uint64
+	TargetDate   int64
+}
+
+type UpdateSavingsGoalParams struct {
+	ID           int
+	UserID       int
+	Name         string
+	TargetAmount uint64
+	TargetDate   int64
+}
+
+// SavingsContribution is money set aside toward a goal on Date.
+type SavingsContribution struct {
+	ID            int
+	UserID        int
+	SavingsGoalID int
+	Amount        uint64
+	Date          int64
+	Note          string
+	CreatedAt     int64
+	UpdatedAt     int64
+}
+
+type InsertSavingsContributionParams struct {
+	UserID        int
+	SavingsGoalID int
+	Amount        uint64
+	Date          int64
+	Note          string
+}
+
+// savingsGoalColumns and savingsContributionColumns pin the projection order
+// the Scan calls in this file depend on, as the other column lists do.
+const (
+	savingsGoalColumns = `"id", "user_id", "name", "target_amount", "target_date", "created_at", "updated_at"`
+
+	savingsContributionColumns = `"id", "user_id", "savings_goal_id", "amount", "date", "note",
+"created_at", "updated_at"`
+)
+
+const insertSavingsGoal = `
+INSERT INTO "savings_goals" ("user_id", "name", "target_amount", "target_date")
+VALUES (?, ?, ?, ?)
+RETURNING ` + savingsGoalColumns
+
+func (q *Queries) InsertSavingsGoal(ctx context.Context, params InsertSavingsGoalParams) (SavingsGoal, error) {
+	var g SavingsGoal
+
+	err := q.wrapQuery(insertSavingsGoal, func() error {
+		row := q.db.QueryRowContext(
+			ctx,
+			insertSavingsGoal,
+			params.UserID,
+			params.Name,
+			params.TargetAmount,
+			params.TargetDate,
+		)
+
+		return row.Scan(
+			&g.ID,
+			&g.UserID,
+			&g.Name,
+			&g.TargetAmount,
+			&g.TargetDate,
+			&g.CreatedAt,
+			&g.UpdatedAt,
+		)
+	})
+
+	return g, err
+}
+
+// selectSavingsGoalsByUser lists the nearest deadline first, which is the goal
+// that most needs attention.
+const selectSavingsGoalsByUser = `
+SELECT ` + savingsGoalColumns + ` FROM "savings_goals" WHERE "user_id" = ?
+ORDER BY "target_date", lower("name")`
+
+func (q *Queries) SelectSavingsGoalsByUser(ctx context.Context, userID int) ([]SavingsGoal, error) {
+	var gs []SavingsGoal
+
+	err := q.wrapQuery(selectSavingsGoalsByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectSavingsGoalsByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var g SavingsGoal
+
+			if err := rows.Scan(
+				&g.ID,
+				&g.UserID,
+				&g.Name,
+				&g.TargetAmount,
+				&g.TargetDate,
+				&g.CreatedAt,
+				&g.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			gs = append(gs, g)
+		}
+
+		return rows.Err()
+	})
+
+	return gs, err
+}
+
+const selectSavingsGoal = `
+SELECT ` + savingsGoalColumns + ` FROM "savings_goals" WHERE "id" = ? AND "user_id" = ? LIMIT 1`
+
+func (q *Queries) SelectSavingsGoal(ctx context.Context, id, userID int) (SavingsGoal, error) {
+	var g SavingsGoal
+
+	err := q.wrapQuery(selectSavingsGoal, func() error {
+		row := q.db.QueryRowContext(ctx, selectSavingsGoal, id, userID)
+
+		return row.Scan(
+			&g.ID,
+			&g.UserID,
+			&g.Name,
+			&g.TargetAmount,
+			&g.TargetDate,
+			&g.CreatedAt,
+			&g.UpdatedAt,
+		)
+	})
+
+	return g, err
+}
+
+func (q *TxQueries) SelectSavingsGoal(ctx context.Context, id, userID int) (SavingsGoal, error) {
+	var g SavingsGoal
+
+	err := q.wrapQuery(selectSavingsGoal, func() error {
+		row := q.tx.QueryRowContext(ctx, selectSavingsGoal, id, userID)
+
+		return row.Scan(
+			&g.ID,
+			&g.UserID,
+			&g.Name,
+			&g.TargetAmount,
+			&g.TargetDate,
+			&g.CreatedAt,
+			&g.UpdatedAt,
+		)
+	})
+
+	return g, err
+}
+
+const updateSavingsGoal = `
+UPDATE "savings_goals"
+SET "name"          = ?,
+    "target_amount" = ?,
+    "target_date"   = ?,
+    "updated_at"    = ?
+WHERE "id" = ? AND "user_id" = ?
+RETURNING ` + savingsGoalColumns
+
+func (q *Queries) UpdateSavingsGoal(ctx context.Context, params UpdateSavingsGoalParams) (SavingsGoal, error) {
+	var g SavingsGoal
+
+	err := q.wrapQuery(updateSavingsGoal, func() error {
+		row := q.db.QueryRowContext(
+			ctx,
+			updateSavingsGoal,
+			params.Name,
+			params.TargetAmount,
+			params.TargetDate,
+			newUpdatedAt(),
+			params.ID,
+			params.UserID,
+		)
+
+		return row.Scan(
+			&g.ID,
+			&g.UserID,
+			&g.Name,
+			&g.TargetAmount,
+			&g.TargetDate,
+			&g.CreatedAt,
+			&g.UpdatedAt,
+		)
+	})
+
+	return g, err
+}
+
+// deleteSavingsGoal takes the goal's contributions with it through their
+// foreign key.
+const deleteSavingsGoal = `DELETE FROM "savings_goals" WHERE "id" = ? AND "user_id" = ? RETURNING "id"`
+
+func (q *Queries) DeleteSavingsGoal(ctx context.Context, id, userID int) (int, error) {
+	var i int
+
+	err := q.wrapQuery(deleteSavingsGoal, func() error {
+		row := q.db.QueryRowContext(ctx, deleteSavingsGoal, id, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
+
+const countSavingsGoalsByUser = `SELECT COUNT(*) FROM "savings_goals" WHERE "user_id" = ?`
+
+func (q *Queries) CountSavingsGoalsByUser(ctx context.Context, userID int) (int, error) {
+	var c int
+
+	err := q.wrapQuery(countSavingsGoalsByUser, func() error {
+		row := q.db.QueryRowContext(ctx, countSavingsGoalsByUser, userID)
+
+		return row.Scan(&c)
+	})
+
+	return c, err
+}
+
+const deleteAllSavingsGoalsByUser = `DELETE FROM "savings_goals" WHERE "user_id" = ?`
+
+func (q *TxQueries) DeleteAllSavingsGoalsByUser(ctx context.Context, userID int) error {
+	return q.wrapQuery(deleteAllSavingsGoalsByUser, func() error {
+		_, err := q.tx.ExecContext(ctx, deleteAllSavingsGoalsByUser, userID)
+
+		return err
+	})
+}
+
+const insertSavingsContribution = `
+INSERT INTO "savings_contributions" ("user_id", "savings_goal_id", "amount", "date", "note")
+VALUES (?, ?, ?, ?, ?)
+RETURNING ` + savingsContributionColumns
+
+func (q *TxQueries) InsertSavingsContribution(
+	ctx context.Context,
+	params InsertSavingsContributionParams,
+) (SavingsContribution, error) {
+	var c SavingsContribution
+
+	err := q.wrapQuery(insertSavingsContribution, func() error {
+		row := q.tx.QueryRowContext(
+			ctx,
+			insertSavingsContribution,
+			params.UserID,
+			params.SavingsGoalID,
+			params.Amount,
+			params.Date,
+			params.Note,
+		)
+
+		return row.Scan(
+			&c.ID,
+			&c.UserID,
+			&c.SavingsGoalID,
+			&c.Amount,
+			&c.Date,
+			&c.Note,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+		)
+	})
+
+	return c, err
+}
+
+// selectSavingsContributionsByUser returns every contribution grouped by goal,
+// oldest first within each, the order progress is measured in.
+const selectSavingsContributionsByUser = `
+SELECT ` + savingsContributionColumns + ` FROM "savings_contributions" WHERE "user_id" = ?
+ORDER BY "savings_goal_id", "date", "id"`
+
+func (q *Queries) SelectSavingsContributionsByUser(ctx context.Context, userID int) ([]SavingsContribution, error) {
+	var cs []SavingsContribution
+
+	err := q.wrapQuery(selectSavingsContributionsByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectSavingsContributionsByUser, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var c SavingsContribution
+
+			if err := rows.Scan(
+				&c.ID,
+				&c.UserID,
+				&c.SavingsGoalID,
+				&c.Amount,
+				&c.Date,
+				&c.Note,
+				&c.CreatedAt,
+				&c.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			cs = append(cs, c)
+		}
+
+		return rows.Err()
+	})
+
+	return cs, err
+}
+
+const selectSavingsContributionsByGoal = `
+SELECT ` + savingsContributionColumns + ` FROM "savings_contributions"
+WHERE "savings_goal_id" = ? AND "user_id" = ?
+ORDER BY "date", "id"`
+
+func (q *Queries) SelectSavingsContributionsByGoal(
+	ctx context.Context,
+	goalID, userID int,
+) ([]SavingsContribution, error) {
+	var cs []SavingsContribution
+
+	err := q.wrapQuery(selectSavingsContributionsByGoal, func() error {
+		rows, err := q.db.QueryContext(ctx, selectSavingsContributionsByGoal, goalID, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var c SavingsContribution
+
+			if err := rows.Scan(
+				&c.ID,
+				&c.UserID,
+				&c.SavingsGoalID,
+				&c.Amount,
+				&c.Date,
+				&c.Note,
+				&c.CreatedAt,
+				&c.UpdatedAt,
+			); err != nil {
+				return err
+			}
+
+			cs = append(cs, c)
+		}
+
+		return rows.Err()
+	})
+
+	return cs, err
+}
+
+const deleteSavingsContribution = `
+DELETE FROM "savings_contributions" WHERE "id" = ? AND "savings_goal_id" = ? AND "user_id" = ?
+RETURNING "id"`
+
+func (q *Queries) DeleteSavingsContribution(ctx context.Context, id, goalID, userID int) (int, error) {
+	var i int
+
+	err := q.wrapQuery(deleteSavingsContribution, func() error {
+		row := q.db.QueryRowContext(ctx, deleteSavingsContribution, id, goalID, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
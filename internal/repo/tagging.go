@@ -10,8 +10,11 @@ import (
 
 const (
 	TaggableTypeExpense          = "expense"
+	TaggableTypeExpenseSplit     = "expense_split"
+	TaggableTypeIncome           = "income"
 	TaggableTypeMoodEntry        = "mood_entry"
 	TaggableTypeRecurrentExpense = "recurrent_expense"
+	TaggableTypeRecurrentIncome  = "recurrent_income"
 )
 
 type Tagging struct {
@@ -76,7 +76,7 @@ func TestSelectTagRowsExceedsParameterLimit(t *testing.T) {
 		Email:        "tag_rows_limit_user@example.com",
 		PasswordHash: []byte("tag_rows_limit_hash"),
 	})
-	category := s.CreateCategory(t, "tag_rows_limit_category")
+	category := s.CreateCategory(t, user.ID, "tag_rows_limit_category")
 
 	expense := s.CreateExpense(t, user.ID, logic.ExpenseParams{
 		ExpenseBaseParams: logic.ExpenseBaseParams{
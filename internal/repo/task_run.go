@@ -0,0 +1,177 @@
+package repo
+
+import "context"
+
+type TaskRun struct {
+	ID             int
+	Name           string
+	StartedAt      int64
+	FinishedAt     *int64
+	SucceededCount int
+	FailedCount    int
+}
+
+// TaskRunFailure is one row a task run could not process. SubjectID is the id
+// of that row in whatever table the task works on.
+type TaskRunFailure struct {
+	ID        int
+	TaskRunID int
+	SubjectID int
+	Error     string
+	CreatedAt int64
+}
+
+// taskRunColumns and taskRunFailureColumns pin the projection order the Scan
+// calls in this file depend on. SELECT * would resolve to whatever order the
+// table happens to have, so an ALTER TABLE could shift values into the wrong
+// struct fields with no error.
+const taskRunColumns = `"id", "name", "started_at", "finished_at", "succeeded_count", "failed_count"`
+
+const taskRunFailureColumns = `"id", "task_run_id", "subject_id", "error", "created_at"`
+
+const insertTaskRun = `
+INSERT INTO "task_runs" ("name", "started_at")
+VALUES (?, ?)
+RETURNING ` + taskRunColumns
+
+func (q *Queries) InsertTaskRun(ctx context.Context, name string, startedAt int64) (TaskRun, error) {
+	var r TaskRun
+
+	err := q.wrapQuery(insertTaskRun, func() error {
+		row := q.db.QueryRowContext(ctx, insertTaskRun, name, startedAt)
+
+		return row.Scan(
+			&r.ID,
+			&r.Name,
+			&r.StartedAt,
+			&r.FinishedAt,
+			&r.SucceededCount,
+			&r.FailedCount,
+		)
+	})
+
+	return r, err
+}
+
+const finishTaskRun = `
+UPDATE "task_runs"
+SET "finished_at"     = ?,
+    "succeeded_count" = ?,
+    "failed_count"    = ?
+WHERE "id" = ?
+RETURNING ` + taskRunColumns
+
+func (q *Queries) FinishTaskRun(
+	ctx context.Context,
+	id int,
+	finishedAt int64,
+	succeeded, failed int,
+) (TaskRun, error) {
+	var r TaskRun
+
+	err := q.wrapQuery(finishTaskRun, func() error {
+		row := q.db.QueryRowContext(ctx, finishTaskRun, finishedAt, succeeded, failed, id)
+
+		return row.Scan(
+			&r.ID,
+			&r.Name,
+			&r.StartedAt,
+			&r.FinishedAt,
+			&r.SucceededCount,
+			&r.FailedCount,
+		)
+	})
+
+	return r, err
+}
+
+const selectLatestTaskRun = `SELECT ` + taskRunColumns + `
+FROM "task_runs" WHERE "name" = ?
+ORDER BY "started_at" DESC, "id" DESC
+LIMIT 1`
+
+func (q *Queries) SelectLatestTaskRun(ctx context.Context, name string) (TaskRun, error) {
+	var r TaskRun
+
+	err := q.wrapQuery(selectLatestTaskRun, func() error {
+		row := q.db.QueryRowContext(ctx, selectLatestTaskRun, name)
+
+		return row.Scan(
+			&r.ID,
+			&r.Name,
+			&r.StartedAt,
+			&r.FinishedAt,
+			&r.SucceededCount,
+			&r.FailedCount,
+		)
+	})
+
+	return r, err
+}
+
+const insertTaskRunFailure = `
+INSERT INTO "task_run_failures" ("task_run_id", "subject_id", "error")
+VALUES (?, ?, ?)
+RETURNING ` + taskRunFailureColumns
+
+func (q *Queries) InsertTaskRunFailure(
+	ctx context.Context,
+	taskRunID, subjectID int,
+	errText string,
+) (TaskRunFailure, error) {
+	var f TaskRunFailure
+
+	err := q.wrapQuery(insertTaskRunFailure, func() error {
+		row := q.db.QueryRowContext(ctx, insertTaskRunFailure, taskRunID, subjectID, errText)
+
+		return row.Scan(
+			&f.ID,
+			&f.TaskRunID,
+			&f.SubjectID,
+			&f.Error,
+			&f.CreatedAt,
+		)
+	})
+
+	return f, err
+}
+
+const selectTaskRunFailures = `SELECT ` + taskRunFailureColumns + `
+FROM "task_run_failures" WHERE "task_run_id" = ?
+ORDER BY "id" ASC`
+
+func (q *Queries) SelectTaskRunFailures(ctx context.Context, taskRunID int) ([]TaskRunFailure, error) {
+	var res []TaskRunFailure
+
+	err := q.wrapQuery(selectTaskRunFailures, func() error {
+		rows, err := q.db.QueryContext(ctx, selectTaskRunFailures, taskRunID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var f TaskRunFailure
+
+			if err := rows.Scan(
+				&f.ID,
+				&f.TaskRunID,
+				&f.SubjectID,
+				&f.Error,
+				&f.CreatedAt,
+			); err != nil {
+				return err
+			}
+
+			res = append(res, f)
+		}
+
+		return rows.Err()
+	})
+
+	return res, err
+}
@@ -0,0 +1,239 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+)
+
+// Trash kinds name the tables that soft delete. The expense and mood entry
+// kinds equal their taggable types, so a purge can clear taggings by kind.
+const (
+	TrashKindExpense    = TaggableTypeExpense
+	TrashKindMacroEntry = "macro_entry"
+	TrashKindFood       = "food"
+	TrashKindMoodEntry  = TaggableTypeMoodEntry
+)
+
+type TrashedRow struct {
+	Kind      string
+	ID        int
+	Label     string
+	DeletedAt int64
+}
+
+// trashTables maps each kind to its table. Only these names are ever
+// formatted into the queries below.
+var trashTables = map[string]string{ //nolint:gochecknoglobals // static lookup table
+	TrashKindExpense:    "expenses",
+	TrashKindMacroEntry: "macro_entries",
+	TrashKindFood:       "foods",
+	TrashKindMoodEntry:  "mood_entries",
+}
+
+// trashTaggableKinds are the kinds whose rows can carry taggings.
+var trashTaggableKinds = []string{TrashKindExpense, TrashKindMoodEntry} //nolint:gochecknoglobals // static list
+
+// ValidTrashKind reports whether kind names a table with a trash.
+func ValidTrashKind(kind string) bool {
+	_, ok := trashTables[kind]
+
+	return ok
+}
+
+const selectTrashByUser = `
+SELECT 'expense' AS "kind", "id", "description" AS "label", "deleted_at"
+FROM "expenses" WHERE "user_id" = ? AND "deleted_at" IS NOT NULL
+UNION ALL
+SELECT 'macro_entry', "id", "name", "deleted_at"
+FROM "macro_entries" WHERE "user_id" = ? AND "deleted_at" IS NOT NULL
+UNION ALL
+SELECT 'food', "id", "name", "deleted_at"
+FROM "foods" WHERE "user_id" = ? AND "deleted_at" IS NOT NULL
+UNION ALL
+SELECT 'mood_entry', "id", "mood", "deleted_at"
+FROM "mood_entries" WHERE "user_id" = ? AND "deleted_at" IS NOT NULL
+ORDER BY "deleted_at" DESC, "id" DESC`
+
+// SelectTrashByUser lists every trashed row of the user, newest first.
+func (q *Queries) SelectTrashByUser(ctx context.Context, userID int) ([]TrashedRow, error) {
+	var trashed []TrashedRow
+
+	err := q.wrapQuery(selectTrashByUser, func() error {
+		rows, err := q.db.QueryContext(ctx, selectTrashByUser, userID, userID, userID, userID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var t TrashedRow
+
+			if err := rows.Scan(&t.Kind, &t.ID, &t.Label, &t.DeletedAt); err != nil {
+				return err
+			}
+
+			trashed = append(trashed, t)
+		}
+
+		return rows.Err()
+	})
+
+	return trashed, err
+}
+
+const restoreTrashedBase = `
+UPDATE "%s" SET "deleted_at" = NULL
+WHERE "id" = ? AND "user_id" = ? AND "deleted_at" IS NOT NULL
+RETURNING "id"`
+
+// RestoreTrashed takes a row out of the trash. Its taggings were never
+// removed, so the tags come back with it.
+func (q *Queries) RestoreTrashed(ctx context.Context, kind string, id, userID int) (int, error) {
+	var i int
+
+	table, ok := trashTables[kind]
+	if !ok {
+		return 0, ErrInvalidTrashKind
+	}
+
+	query := fmt.Sprintf(restoreTrashedBase, table)
+
+	err := q.wrapQuery(query, func() error {
+		row := q.db.QueryRowContext(ctx, query, id, userID)
+
+		return row.Scan(&i)
+	})
+
+	return i, err
+}
+
+const purgeTrashedBase = `
+DELETE FROM "%s"
+WHERE "id" = ? AND "user_id" = ? AND "deleted_at" IS NOT NULL
+RETURNING "id"`
+
+const purgeTrashedSplitTaggings = `
+DELETE FROM "taggings"
+WHERE "taggable_type" = '` + TaggableTypeExpenseSplit + `'
+  AND "taggable_id" IN (
+    SELECT "s"."id" FROM "expense_splits" AS "s"
+    JOIN "expenses" AS "e" ON "e"."id" = "s"."expense_id"
+    WHERE "e"."id" = ? AND "e"."user_id" = ? AND "e"."deleted_at" IS NOT NULL
+  )`
+
+// PurgeTrashed deletes a trashed row for good, along with its taggings. Rows
+// that are not in the trash are left alone and yield sql.ErrNoRows.
+func (q *TxQueries) PurgeTrashed(ctx context.Context, kind string, id, userID int) (int, error) {
+	var i int
+
+	table, ok := trashTables[kind]
+	if !ok {
+		return 0, ErrInvalidTrashKind
+	}
+
+	query := fmt.Sprintf(purgeTrashedBase, table)
+
+	// The expense's splits cascade with it, but their taggings would not.
+	if kind == TrashKindExpense {
+		err := q.wrapQuery(purgeTrashedSplitTaggings, func() error {
+			_, err := q.tx.ExecContext(ctx, purgeTrashedSplitTaggings, id, userID)
+
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err := q.wrapQuery(query, func() error {
+		row := q.tx.QueryRowContext(ctx, query, id, userID)
+
+		return row.Scan(&i)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return i, q.DeleteTaggingsByTarget(ctx, kind, i)
+}
+
+// PurgeTrashByUser empties the user's trash and returns how many rows went.
+func (q *TxQueries) PurgeTrashByUser(ctx context.Context, userID int) (int, error) {
+	return q.purgeTrash(ctx, `"user_id" = ?`, userID)
+}
+
+// PurgeTrashBefore empties every user's trash of rows deleted before cutoff.
+func (q *TxQueries) PurgeTrashBefore(ctx context.Context, cutoff int64) (int, error) {
+	return q.purgeTrash(ctx, `"deleted_at" < ?`, cutoff)
+}
+
+const purgeTrashTaggingsBase = `
+DELETE FROM "taggings"
+WHERE "taggable_type" = ?
+  AND "taggable_id" IN (SELECT "id" FROM "%s" WHERE "deleted_at" IS NOT NULL AND %s)`
+
+const purgeTrashSplitTaggingsBase = `
+DELETE FROM "taggings"
+WHERE "taggable_type" = '` + TaggableTypeExpenseSplit + `'
+  AND "taggable_id" IN (
+    SELECT "id" FROM "expense_splits"
+    WHERE "expense_id" IN (SELECT "id" FROM "expenses" WHERE "deleted_at" IS NOT NULL AND %s)
+  )`
+
+const purgeTrashBase = `DELETE FROM "%s" WHERE "deleted_at" IS NOT NULL AND %s`
+
+// purgeTrash deletes the trashed rows matching where, a repo-defined predicate
+// with a single placeholder. Taggings go first, split taggings included, while
+// their rows still exist to be matched.
+func (q *TxQueries) purgeTrash(ctx context.Context, where string, arg any) (int, error) {
+	for _, kind := range trashTaggableKinds {
+		query := fmt.Sprintf(purgeTrashTaggingsBase, trashTables[kind], where)
+
+		err := q.wrapQuery(query, func() error {
+			_, err := q.tx.ExecContext(ctx, query, kind, arg)
+
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	splitQuery := fmt.Sprintf(purgeTrashSplitTaggingsBase, where)
+
+	err := q.wrapQuery(splitQuery, func() error {
+		_, err := q.tx.ExecContext(ctx, splitQuery, arg)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+
+	for _, kind := range []string{TrashKindExpense, TrashKindMacroEntry, TrashKindFood, TrashKindMoodEntry} {
+		query := fmt.Sprintf(purgeTrashBase, trashTables[kind], where)
+
+		err := q.wrapQuery(query, func() error {
+			res, err := q.tx.ExecContext(ctx, query, arg)
+			if err != nil {
+				return err
+			}
+
+			n, err := res.RowsAffected()
+			purged += n
+
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int(purged), nil
+}
@@ -0,0 +1,132 @@
+package repo
+
+import "context"
+
+// USDAImportFood is a food staged from a FoodData Central download, with the
+// tracked nutrients staged for it by nutrient id.
+type USDAImportFood struct {
+	ID        int
+	FDCID     string
+	Name      string
+	Brand     string
+	Barcode   *string
+	Nutrients map[int]float64
+}
+
+const clearUSDAImportFoods = `DELETE FROM "usda_import_foods"`
+
+const clearUSDAImportNutrients = `DELETE FROM "usda_import_nutrients"`
+
+// ClearUSDAImport empties the staging tables.
+func (q *TxQueries) ClearUSDAImport(ctx context.Context) error {
+	for _, query := range []string{clearUSDAImportFoods, clearUSDAImportNutrients} {
+		err := q.wrapQuery(query, func() error {
+			_, err := q.tx.ExecContext(ctx, query)
+
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stageUSDAImportFood keeps a food listed twice at its first position, under
+// its last name, as a re-read of the file would.
+const stageUSDAImportFood = `
+INSERT INTO "usda_import_foods" ("fdc_id", "name") VALUES (?, ?)
+ON CONFLICT ("fdc_id") DO UPDATE SET "name" = excluded."name"`
+
+func (q *TxQueries) StageUSDAImportFood(ctx context.Context, fdcID, name string) error {
+	return q.wrapQuery(stageUSDAImportFood, func() error {
+		_, err := q.tx.ExecContext(ctx, stageUSDAImportFood, fdcID, name)
+
+		return err
+	})
+}
+
+const stageUSDAImportNutrient = `
+INSERT INTO "usda_import_nutrients" ("fdc_id", "nutrient_id", "amount") VALUES (?, ?, ?)
+ON CONFLICT ("fdc_id", "nutrient_id") DO UPDATE SET "amount" = excluded."amount"`
+
+func (q *TxQueries) StageUSDAImportNutrient(ctx context.Context, fdcID string, nutrientID int, amount float64) error {
+	return q.wrapQuery(stageUSDAImportNutrient, func() error {
+		_, err := q.tx.ExecContext(ctx, stageUSDAImportNutrient, fdcID, nutrientID, amount)
+
+		return err
+	})
+}
+
+// stageUSDAImportBranding only touches foods already staged from food.csv.
+const stageUSDAImportBranding = `
+UPDATE "usda_import_foods" SET "brand" = ?, "barcode" = ? WHERE "fdc_id" = ?`
+
+func (q *TxQueries) StageUSDAImportBranding(ctx context.Context, fdcID, brand string, barcode *string) error {
+	return q.wrapQuery(stageUSDAImportBranding, func() error {
+		_, err := q.tx.ExecContext(ctx, stageUSDAImportBranding, brand, barcode, fdcID)
+
+		return err
+	})
+}
+
+// selectUSDAImportFoods reads one page of staged foods after ?1, in file
+// order, joined with their nutrients. A food without any comes back once with
+// a NULL nutrient.
+const selectUSDAImportFoods = `
+SELECT "f"."id", "f"."fdc_id", "f"."name", "f"."brand", "f"."barcode", "n"."nutrient_id", "n"."amount"
+FROM (
+  SELECT * FROM "usda_import_foods" WHERE "id" > ?1 ORDER BY "id" LIMIT ?2
+) AS "f"
+LEFT JOIN "usda_import_nutrients" AS "n" ON "n"."fdc_id" = "f"."fdc_id"
+ORDER BY "f"."id"`
+
+// SelectUSDAImportFoods returns up to limit staged foods with an id above
+// afterID, so a caller pages through the staging table without holding a
+// read open while it writes.
+func (q *Queries) SelectUSDAImportFoods(ctx context.Context, afterID, limit int) ([]USDAImportFood, error) {
+	var foods []USDAImportFood
+
+	err := q.wrapQuery(selectUSDAImportFoods, func() error {
+		rows, err := q.db.QueryContext(ctx, selectUSDAImportFoods, afterID, limit)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				q.app.Logger.Error(closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var f USDAImportFood
+			var nutrientID *int
+			var amount *float64
+
+			if err := rows.Scan(
+				&f.ID,
+				&f.FDCID,
+				&f.Name,
+				&f.Brand,
+				&f.Barcode,
+				&nutrientID,
+				&amount,
+			); err != nil {
+				return err
+			}
+
+			if len(foods) == 0 || foods[len(foods)-1].ID != f.ID {
+				f.Nutrients = map[int]float64{}
+				foods = append(foods, f)
+			}
+			if nutrientID != nil && amount != nil {
+				foods[len(foods)-1].Nutrients[*nutrientID] = *amount
+			}
+		}
+
+		return rows.Err()
+	})
+
+	return foods, err
+}
@@ -11,6 +11,7 @@ type User struct {
 	PasswordHash []byte
 	CreatedAt    int64
 	UpdatedAt    int64
+	HomeCurrency string
 }
 
 type InsertUserParams struct {
@@ -22,18 +23,18 @@ type InsertUserParams struct {
 // userColumns pins the projection order the Scan calls in this file depend on.
 // SELECT * would resolve to whatever order the table happens to have, so an
 // ALTER TABLE could shift values into the wrong struct fields with no error.
-const userColumns = `"id", "username", "email", "password_hash", "created_at", "updated_at"`
+const userColumns = `"id", "username", "email", "password_hash", "created_at", "updated_at", "home_currency"`
 
 const insertUser = `
 INSERT INTO "users" ("username", "email", "password_hash")
 VALUES (?, ?, ?)
 RETURNING ` + userColumns
 
-func (q *Queries) InsertUser(ctx context.Context, params InsertUserParams) (User, error) {
+func (q *TxQueries) InsertUser(ctx context.Context, params InsertUserParams) (User, error) {
 	var u User
 
 	err := q.wrapQuery(insertUser, func() error {
-		row := q.db.QueryRowContext(
+		row := q.tx.QueryRowContext(
 			ctx,
 			insertUser,
 			params.Username,
@@ -48,6 +49,7 @@ func (q *Queries) InsertUser(ctx context.Context, params InsertUserParams) (User
 			&u.PasswordHash,
 			&u.CreatedAt,
 			&u.UpdatedAt,
+			&u.HomeCurrency,
 		)
 	})
 
@@ -69,6 +71,7 @@ func (q *Queries) SelectUser(ctx context.Context, id int) (User, error) {
 			&u.PasswordHash,
 			&u.CreatedAt,
 			&u.UpdatedAt,
+			&u.HomeCurrency,
 		)
 	})
 
@@ -90,8 +93,42 @@ func (q *Queries) SelectUserByEmail(ctx context.Context, email string) (User, er
 			&u.PasswordHash,
 			&u.CreatedAt,
 			&u.UpdatedAt,
+			&u.HomeCurrency,
 		)
 	})
 
 	return u, err
 }
+
+const updateUserHomeCurrency = `
+UPDATE "users" SET "home_currency" = ?, "updated_at" = ? WHERE "id" = ?`
+
+func (q *Queries) UpdateUserHomeCurrency(ctx context.Context, id int, currency string) error {
+	return q.wrapQuery(updateUserHomeCurrency, func() error {
+		_, err := q.db.ExecContext(ctx, updateUserHomeCurrency, currency, newUpdatedAt(), id)
+
+		return err
+	})
+}
+
+const selectUserHomeCurrency = `SELECT "home_currency" FROM "users" WHERE "id" = ? LIMIT 1`
+
+func (q *Queries) SelectUserHomeCurrency(ctx context.Context, id int) (string, error) {
+	var currency string
+
+	err := q.wrapQuery(selectUserHomeCurrency, func() error {
+		return q.db.QueryRowContext(ctx, selectUserHomeCurrency, id).Scan(&currency)
+	})
+
+	return currency, err
+}
+
+func (q *TxQueries) SelectUserHomeCurrency(ctx context.Context, id int) (string, error) {
+	var currency string
+
+	err := q.wrapQuery(selectUserHomeCurrency, func() error {
+		return q.tx.QueryRowContext(ctx, selectUserHomeCurrency, id).Scan(&currency)
+	})
+
+	return currency, err
+}
@@ -46,7 +46,8 @@ func (s *Server) AuthMiddleware(next http.Handler) http.Handler {
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
-		isSignedIn := s.Session.GetBool(r.Context(), handlers.SessionIsUserSignedIn)
+		_, hasAPIToken := handlers.APITokenFrom(r)
+		isSignedIn := hasAPIToken || s.Session.GetBool(r.Context(), handlers.SessionIsUserSignedIn)
 
 		if guestRoutes[path] {
 			if isSignedIn {
@@ -67,6 +68,12 @@ func (s *Server) AuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		if !isSignedIn {
+			if handlers.IsAPIRequest(r) {
+				s.handlers.APIUnauthorized(w, r)
+
+				return
+			}
+
 			http.Redirect(w, r, "/login", http.StatusSeeOther)
 
 			return
@@ -80,6 +87,24 @@ func (s *Server) csrf(next http.Handler) http.Handler {
 	csrfHandler := nosurf.New(next)
 	// Browsers post CSP reports automatically with no CSRF token.
 	csrfHandler.ExemptPath(cspReportPath)
+	// A bearer token is never attached by the browser on its own, so a request
+	// carrying one cannot be a forged cross-site submission. apiTokenAuth only
+	// resolves tokens on API paths, which keeps the exemption off the HTML forms.
+	csrfHandler.ExemptFunc(func(r *http.Request) bool {
+		_, ok := handlers.APITokenFrom(r)
+
+		return ok
+	})
+	// API clients read a JSON error; the HTML routes keep nosurf's bare 400.
+	csrfHandler.SetFailureHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handlers.IsAPIRequest(r) {
+			s.handlers.APICSRFFailure(w, r)
+
+			return
+		}
+
+		http.Error(w, http.StatusText(nosurf.FailureCode), nosurf.FailureCode)
+	}))
 	csrfHandler.SetBaseCookie(http.Cookie{
 		HttpOnly: true,
 		Path:     "/",
@@ -99,6 +124,10 @@ func (s *Server) setTmplData(next http.Handler) http.Handler {
 		var currentUser *logic.User
 		isUserSignedIn := s.Session.GetBool(ctx, handlers.SessionIsUserSignedIn)
 		id := s.Session.GetInt(ctx, handlers.SessionUserID)
+		if token, ok := handlers.APITokenFrom(r); ok {
+			isUserSignedIn = true
+			id = token.UserID
+		}
 		if isUserSignedIn {
 			user, err := s.store.FindUser(ctx, id)
 			currentUser = &user
@@ -128,6 +157,54 @@ func (s *Server) setTmplData(next http.Handler) http.Handler {
 	})
 }
 
+// apiTokenAuth resolves an "Authorization: Bearer" token on API routes and
+// stores it in the request context, where setTmplData and AuthMiddleware treat
+// it as a signed-in user. A request without the header falls through to the
+// session. A bad token is refused outright rather than falling back, so a
+// script never silently acts as whoever's cookie happens to be attached.
+func (s *Server) apiTokenAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !handlers.IsAPIRequest(r) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		rawToken, ok := bearerToken(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		token, err := s.store.AuthenticateAPIToken(r.Context(), rawToken)
+		if errors.Is(err, logic.ErrInvalidAPIToken) {
+			s.handlers.APIUnauthorized(w, r)
+
+			return
+		}
+		if err != nil {
+			s.handlers.APIInternalError(w, r, err)
+
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), handlers.KeyAPIToken, &token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, value, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	value = strings.TrimSpace(value)
+
+	return value, value != ""
+}
+
 const maxRequestBodySize = 1 << 20 // 1 MB
 
 func (*Server) limitRequestBody(next http.Handler) http.Handler {
@@ -326,6 +403,8 @@ func (s *Server) setUpAppMiddlewares(root chi.Router) {
 	root.Use(s.WithTimeout(5 * time.Second))
 
 	root.Use(s.contentSecurityPolicy)
+	// Before csrf, which exempts token-authenticated requests.
+	root.Use(s.apiTokenAuth)
 	root.Use(s.csrf)
 
 	root.Use(s.setTmplData)
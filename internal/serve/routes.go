@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/ad9311/ninete/internal/logic"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -38,18 +39,96 @@ func (s *Server) setUpRoutes() {
 			account.Get("/", s.handlers.GetAccount)
 			account.Post("/expenses/delete-all", s.handlers.PostAccountDeleteExpenses)
 			account.Post("/recurrent-expenses/delete-all", s.handlers.PostAccountDeleteRecurrentExpenses)
+			account.Post("/incomes/delete-all", s.handlers.PostAccountDeleteIncomes)
+			account.Post("/recurrent-incomes/delete-all", s.handlers.PostAccountDeleteRecurrentIncomes)
+			account.Post("/payment-accounts/delete-all", s.handlers.PostAccountDeletePaymentAccounts)
+			account.Post("/savings-goals/delete-all", s.handlers.PostAccountDeleteSavingsGoals)
 			account.Post("/macro-entries/delete-all", s.handlers.PostAccountDeleteMacroEntries)
 			account.Post("/macro-goals/delete-all", s.handlers.PostAccountDeleteMacroGoals)
 			account.Post("/expense-budgets/delete-all", s.handlers.PostAccountDeleteExpenseBudgets)
 			account.Post("/foods/delete-all", s.handlers.PostAccountDeleteFoods)
+			account.Post("/recipes/delete-all", s.handlers.PostAccountDeleteRecipes)
+			account.Post("/meal-templates/delete-all", s.handlers.PostAccountDeleteMealTemplates)
 			account.Post("/moods/delete-all", s.handlers.PostAccountDeleteMoodEntries)
 			account.Post("/tags/delete-all", s.handlers.PostAccountDeleteTags)
 			account.Post("/delete-all", s.handlers.PostAccountDeleteAll)
+			account.Post("/restore", s.handlers.PostAccountRestore)
+			account.Post("/home-currency", s.handlers.PostAccountHomeCurrency)
+			account.Post("/api-tokens", s.handlers.PostAccountAPITokens)
+			account.Post("/api-tokens/{id}/delete", s.handlers.PostAccountAPITokensDelete)
+		})
+
+		root.Route("/pending-expenses/{id}", func(pending chi.Router) {
+			pending.Post("/confirm", s.handlers.PostPendingExpenseConfirm)
+			pending.Post("/skip", s.handlers.PostPendingExpenseSkip)
+		})
+
+		root.Route("/notifications", func(notifications chi.Router) {
+			notifications.Post("/read-all", s.handlers.PostNotificationsReadAll)
+			notifications.Post("/{id}/read", s.handlers.PostNotificationRead)
+		})
+
+		root.Route("/trash", func(trash chi.Router) {
+			trash.Get("/", s.handlers.GetTrash)
+			trash.Post("/empty", s.handlers.PostTrashEmpty)
+			trash.Post("/{kind}/{id}/restore", s.handlers.PostTrashRestore)
+			trash.Post("/{kind}/{id}/undo", s.handlers.PostTrashUndo)
+			trash.Post("/{kind}/{id}/purge", s.handlers.PostTrashPurge)
+		})
+
+		root.Route("/api/v1", func(api chi.Router) {
+			// JSON clients get JSON errors, not the HTML fallbacks above.
+			api.NotFound(s.handlers.APINotFound)
+			api.MethodNotAllowed(s.handlers.APIMethodNotAllowed)
+
+			api.Route("/expenses", func(expenses chi.Router) {
+				expenses.Use(s.handlers.APIScope(logic.APITokenAreaExpenses))
+
+				expenses.Get("/", s.handlers.GetAPIExpenses)
+				expenses.Post("/", s.handlers.PostAPIExpenses)
+				expenses.Route("/{id}", func(expenses chi.Router) {
+					expenses.Use(s.handlers.APIExpenseContext)
+
+					expenses.Get("/", s.handlers.GetAPIExpense)
+					expenses.Put("/", s.handlers.PutAPIExpense)
+					expenses.Delete("/", s.handlers.DeleteAPIExpense)
+				})
+			})
+
+			api.Route("/macros", func(macros chi.Router) {
+				macros.Use(s.handlers.APIScope(logic.APITokenAreaMacros))
+
+				macros.Get("/", s.handlers.GetAPIMacroEntries)
+				macros.Post("/", s.handlers.PostAPIMacroEntries)
+			})
+
+			api.Route("/moods", func(moods chi.Router) {
+				moods.Use(s.handlers.APIScope(logic.APITokenAreaMoods))
+
+				moods.Get("/", s.handlers.GetAPIMoodEntries)
+				moods.Post("/", s.handlers.PostAPIMoodEntries)
+			})
 		})
 
 		root.Route("/exports", func(exports chi.Router) {
 			exports.Get("/", s.handlers.GetExports)
 			exports.Get("/expenses.json", s.handlers.GetExportsExpenses)
+			exports.Get("/backup.zip", s.handlers.GetExportsBackup)
+			exports.Get("/{area}.{format}", s.handlers.GetExportsArea)
+		})
+
+		root.Route("/categories", func(categories chi.Router) {
+			categories.Get("/", s.handlers.GetCategories)
+			categories.Post("/", s.handlers.PostCategories)
+			categories.Route("/{id}", func(categories chi.Router) {
+				categories.Use(s.handlers.CategoryContext)
+
+				categories.Post("/", s.handlers.PostCategoryUpdate)
+				categories.Post("/archive", s.handlers.PostCategoryArchive)
+				categories.Post("/unarchive", s.handlers.PostCategoryUnarchive)
+				categories.Post("/merge", s.handlers.PostCategoryMerge)
+				categories.Post("/parent", s.handlers.PostCategoryParent)
+			})
 		})
 
 		root.Route("/expenses", func(expenses chi.Router) {
@@ -60,6 +139,11 @@ func (s *Server) setUpRoutes() {
 			expenses.Get("/stats", s.handlers.GetExpensesStats)
 			expenses.Get("/budgets", s.handlers.GetExpensesBudgets)
 			expenses.Post("/budgets", s.handlers.PostExpensesBudgets)
+			expenses.Get("/import", s.handlers.GetExpensesImport)
+			expenses.Post("/import", s.handlers.PostExpensesImport)
+			expenses.Post("/import/preview", s.handlers.PostExpensesImportPreview)
+			expenses.Get("/duplicates", s.handlers.GetExpensesDuplicates)
+			expenses.Post("/duplicates/merge", s.handlers.PostExpensesDuplicatesMerge)
 			expenses.Route("/{id}", func(expenses chi.Router) {
 				expenses.Use(s.handlers.ExpenseContext)
 
@@ -75,6 +159,7 @@ func (s *Server) setUpRoutes() {
 			recurrentExpenses.Post("/", s.handlers.PostRecurrentExpenses)
 			recurrentExpenses.Get("/new", s.handlers.GetRecurrentExpensesNew)
 			recurrentExpenses.Get("/archived", s.handlers.GetRecurrentExpensesArchived)
+			recurrentExpenses.Get("/forecast", s.handlers.GetRecurrentExpensesForecast)
 			recurrentExpenses.Route("/{id}", func(recurrentExpenses chi.Router) {
 				recurrentExpenses.Use(s.handlers.RecurrentExpenseContext)
 
@@ -86,12 +171,78 @@ func (s *Server) setUpRoutes() {
 			})
 		})
 
+		root.Route("/payment-accounts", func(paymentAccounts chi.Router) {
+			paymentAccounts.Get("/", s.handlers.GetPaymentAccounts)
+			paymentAccounts.Post("/", s.handlers.PostPaymentAccounts)
+			paymentAccounts.Get("/new", s.handlers.GetPaymentAccountsNew)
+			paymentAccounts.Route("/{id}", func(paymentAccounts chi.Router) {
+				paymentAccounts.Use(s.handlers.PaymentAccountContext)
+
+				paymentAccounts.Get("/", s.handlers.GetPaymentAccount)
+				paymentAccounts.Post("/", s.handlers.PostPaymentAccountsUpdate)
+				paymentAccounts.Get("/edit", s.handlers.GetPaymentAccountsEdit)
+				paymentAccounts.Post("/reconcile", s.handlers.PostPaymentAccountsReconcile)
+				paymentAccounts.Post("/delete", s.handlers.PostPaymentAccountsDelete)
+			})
+		})
+
+		root.Route("/savings-goals", func(savingsGoals chi.Router) {
+			savingsGoals.Get("/", s.handlers.GetSavingsGoals)
+			savingsGoals.Post("/", s.handlers.PostSavingsGoals)
+			savingsGoals.Get("/new", s.handlers.GetSavingsGoalsNew)
+			savingsGoals.Route("/{id}", func(savingsGoals chi.Router) {
+				savingsGoals.Use(s.handlers.SavingsGoalContext)
+
+				savingsGoals.Get("/", s.handlers.GetSavingsGoal)
+				savingsGoals.Post("/", s.handlers.PostSavingsGoalsUpdate)
+				savingsGoals.Get("/edit", s.handlers.GetSavingsGoalsEdit)
+				savingsGoals.Post("/delete", s.handlers.PostSavingsGoalsDelete)
+				savingsGoals.Post("/contributions", s.handlers.PostSavingsGoalContributions)
+				savingsGoals.Post(
+					"/contributions/{contributionID}/delete",
+					s.handlers.PostSavingsGoalContributionDelete,
+				)
+			})
+		})
+
+		root.Route("/incomes", func(incomes chi.Router) {
+			incomes.Get("/", s.handlers.GetIncomes)
+			incomes.Post("/", s.handlers.PostIncomes)
+			incomes.Get("/new", s.handlers.GetIncomesNew)
+			incomes.Route("/{id}", func(incomes chi.Router) {
+				incomes.Use(s.handlers.IncomeContext)
+
+				incomes.Get("/", s.handlers.GetIncome)
+				incomes.Post("/", s.handlers.PostIncomesUpdate)
+				incomes.Get("/edit", s.handlers.GetIncomesEdit)
+				incomes.Post("/delete", s.handlers.PostIncomesDelete)
+			})
+		})
+
+		root.Route("/recurrent-incomes", func(recurrentIncomes chi.Router) {
+			recurrentIncomes.Get("/", s.handlers.GetRecurrentIncomes)
+			recurrentIncomes.Post("/", s.handlers.PostRecurrentIncomes)
+			recurrentIncomes.Get("/new", s.handlers.GetRecurrentIncomesNew)
+			recurrentIncomes.Route("/{id}", func(recurrentIncomes chi.Router) {
+				recurrentIncomes.Use(s.handlers.RecurrentIncomeContext)
+
+				recurrentIncomes.Get("/", s.handlers.GetRecurrentIncome)
+				recurrentIncomes.Post("/", s.handlers.PostRecurrentIncomesUpdate)
+				recurrentIncomes.Get("/edit", s.handlers.GetRecurrentIncomesEdit)
+				recurrentIncomes.Post("/delete", s.handlers.PostRecurrentIncomesDelete)
+				recurrentIncomes.Post("/unarchive", s.handlers.PostRecurrentIncomesUnarchive)
+			})
+		})
+
+		root.Get("/cash-flow", s.handlers.GetCashFlow)
+
 		root.Route("/macros", func(r chi.Router) {
 			r.Get("/", s.handlers.GetMacros)
 			r.Post("/", s.handlers.PostMacros)
 			r.Get("/new", s.handlers.GetMacrosNew)
 			r.Get("/goals", s.handlers.GetMacrosGoals)
 			r.Post("/goals", s.handlers.PostMacrosGoals)
+			r.Post("/copy-meal", s.handlers.PostMacrosCopyMeal)
 			r.Get("/stats", s.handlers.GetMacrosStats)
 			r.Route("/{id}", func(r chi.Router) {
 				r.Use(s.handlers.MacroEntryContext)
@@ -106,6 +257,8 @@ func (s *Server) setUpRoutes() {
 			foods.Get("/", s.handlers.GetFoods)
 			foods.Post("/", s.handlers.PostFoods)
 			foods.Get("/new", s.handlers.GetFoodsNew)
+			foods.Get("/barcode/{code}", s.handlers.GetFoodBarcode)
+			foods.Post("/reference/{referenceID}/copy", s.handlers.PostReferenceFoodCopy)
 			foods.Route("/{id}", func(foods chi.Router) {
 				foods.Use(s.handlers.FoodContext)
 
@@ -113,6 +266,42 @@ func (s *Server) setUpRoutes() {
 				foods.Post("/", s.handlers.PostFoodUpdate)
 				foods.Get("/edit", s.handlers.GetFoodEdit)
 				foods.Post("/delete", s.handlers.PostFoodDelete)
+				foods.Post("/servings", s.handlers.PostFoodServings)
+				foods.Post("/servings/{servingID}/delete", s.handlers.PostFoodServingDelete)
+			})
+		})
+
+		root.Route("/recipes", func(recipes chi.Router) {
+			recipes.Get("/", s.handlers.GetRecipes)
+			recipes.Post("/", s.handlers.PostRecipes)
+			recipes.Get("/new", s.handlers.GetRecipesNew)
+			recipes.Route("/{id}", func(recipes chi.Router) {
+				recipes.Use(s.handlers.RecipeContext)
+
+				recipes.Get("/", s.handlers.GetRecipe)
+				recipes.Post("/", s.handlers.PostRecipesUpdate)
+				recipes.Get("/edit", s.handlers.GetRecipesEdit)
+				recipes.Post("/delete", s.handlers.PostRecipesDelete)
+				recipes.Post("/ingredients", s.handlers.PostRecipeIngredients)
+				recipes.Post("/ingredients/{ingredientID}/delete", s.handlers.PostRecipeIngredientDelete)
+				recipes.Post("/log", s.handlers.PostRecipeLog)
+			})
+		})
+
+		root.Route("/meal-templates", func(templates chi.Router) {
+			templates.Get("/", s.handlers.GetMealTemplates)
+			templates.Post("/", s.handlers.PostMealTemplates)
+			templates.Get("/new", s.handlers.GetMealTemplatesNew)
+			templates.Route("/{id}", func(templates chi.Router) {
+				templates.Use(s.handlers.MealTemplateContext)
+
+				templates.Get("/", s.handlers.GetMealTemplate)
+				templates.Post("/", s.handlers.PostMealTemplatesUpdate)
+				templates.Get("/edit", s.handlers.GetMealTemplatesEdit)
+				templates.Post("/delete", s.handlers.PostMealTemplatesDelete)
+				templates.Post("/items", s.handlers.PostMealTemplateItems)
+				templates.Post("/items/{itemID}/delete", s.handlers.PostMealTemplateItemDelete)
+				templates.Post("/log", s.handlers.PostMealTemplateLog)
 			})
 		})
 
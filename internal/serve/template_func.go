@@ -21,6 +21,8 @@ func TemplateFuncMap() template.FuncMap {
 	return template.FuncMap{
 		"currency":         currency,
 		"signedCurrency":   signedCurrency,
+		"money":            money,
+		"signedMoney":      signedMoney,
 		"sumAmount":        sumAmount,
 		"sumTotal":         sumTotal,
 		"timeStamp":        timeStamp,
@@ -56,6 +58,33 @@ func signedCurrency(v int64) string {
 	return p.Sprintf("$%.2f", float64(v)/100.0)
 }
 
+// money formats an amount in the given ISO 4217 currency. Dollars keep the
+// familiar "$" prefix; any other code is written after the figure, e.g.
+// "1,234.50 EUR", which avoids guessing at symbols shared by several
+// currencies.
+func money(v uint64, code string) string {
+	if code == "" || code == "USD" {
+		return currency(v)
+	}
+
+	p := message.NewPrinter(language.AmericanEnglish)
+
+	return p.Sprintf("%.2f %s", float64(v)/100.0, code)
+}
+
+// signedMoney is money for derived figures that can go negative.
+func signedMoney(v int64, code string) string {
+	if code == "" || code == "USD" {
+		return signedCurrency(v)
+	}
+
+	if v < 0 {
+		return "-" + money(uint64(-v), code)
+	}
+
+	return money(uint64(v), code)
+}
+
 func timeStamp(v int64) string {
 	return prog.UnixToStringDate(v, time.DateOnly)
 }
@@ -148,6 +177,11 @@ func searchParams(pg handlers.PaginationData) string {
 		dateField = ""
 	}
 
+	paymentAccountID := ""
+	if pg.PaymentAccountID > 0 {
+		paymentAccountID = strconv.Itoa(pg.PaymentAccountID)
+	}
+
 	var params strings.Builder
 	for _, pair := range []struct{ key, value string }{
 		{"q", pg.Search},
@@ -155,6 +189,7 @@ func searchParams(pg handlers.PaginationData) string {
 		{"date_from", pg.DateFrom},
 		{"date_to", pg.DateTo},
 		{"date_field", dateField},
+		{"payment_account_id", paymentAccountID},
 	} {
 		if pair.value != "" {
 			params.WriteByte('&')
@@ -85,6 +85,41 @@ func TestCurrency(t *testing.T) {
 	}
 }
 
+func TestMoney(t *testing.T) {
+	tmpl := newTestTemplate(t, `{{ money .Amount .Code }}`)
+	signedTmpl := newTestTemplate(t, `{{ signedMoney .Amount .Code }}`)
+
+	cases := []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{"dollars use the symbol", func(t *testing.T) {
+			data := map[string]any{"Amount": uint64(123456), "Code": "USD"}
+			require.Equal(t, "$1,234.56", renderTemplate(t, tmpl, data))
+		}},
+		{"empty code falls back to dollars", func(t *testing.T) {
+			data := map[string]any{"Amount": uint64(999), "Code": ""}
+			require.Equal(t, "$9.99", renderTemplate(t, tmpl, data))
+		}},
+		{"other codes follow the figure", func(t *testing.T) {
+			data := map[string]any{"Amount": uint64(123456), "Code": "EUR"}
+			require.Equal(t, "1,234.56 EUR", renderTemplate(t, tmpl, data))
+		}},
+		{"negative other code", func(t *testing.T) {
+			data := map[string]any{"Amount": int64(-2550), "Code": "GBP"}
+			require.Equal(t, "-25.50 GBP", renderTemplate(t, signedTmpl, data))
+		}},
+		{"negative dollars", func(t *testing.T) {
+			data := map[string]any{"Amount": int64(-2550), "Code": "USD"}
+			require.Equal(t, "-$25.50", renderTemplate(t, signedTmpl, data))
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
 func TestSumAmount(t *testing.T) {
 	type row struct{ Amount uint64 }
 
@@ -18,10 +18,10 @@ func (s *Spec) CreateUser(t *testing.T, params repo.InsertUserParams) logic.User
 	return user
 }
 
-func (s *Spec) CreateCategory(t *testing.T, name string) repo.Category {
+func (s *Spec) CreateCategory(t *testing.T, userID int, name string) repo.Category {
 	t.Helper()
 
-	category, err := s.Store.CreateCategory(t.Context(), name)
+	category, err := s.Store.CreateCategory(t.Context(), userID, logic.CategoryParams{Name: name})
 	require.NoError(t, err)
 
 	return category
@@ -86,6 +86,11 @@ func (s *Spec) SetRecurrentExpenseLastCopy(
 		Period:            re.Period,
 		LastCopyCreatedAt: sql.NullInt64{Int64: lastCopy, Valid: true},
 		OccurrenceLimit:   re.OccurrenceLimit,
+		Frequency:         re.Frequency,
+		AnchorDay:         re.AnchorDay,
+		StartsAt:          repo.NullInt64FromPtr(re.StartsAt),
+		EndsAt:            repo.NullInt64FromPtr(re.EndsAt),
+		IsEstimate:        re.IsEstimate,
 	})
 	require.NoError(t, err)
 
@@ -150,3 +155,20 @@ func (s *Spec) SaveExpenseBudgets(t *testing.T, userID int, amountByCategoryID m
 
 	require.NoError(t, s.Store.SaveExpenseBudgets(t.Context(), userID, amountByCategoryID))
 }
+
+// CreateAPIToken mints a token with the given expenses scope and returns it
+// with its raw value. Moods are granted read so that an expenses scope of
+// "none" still leaves the token valid.
+func (s *Spec) CreateAPIToken(t *testing.T, userID int, expensesScope string) (repo.APIToken, string) {
+	t.Helper()
+
+	token, rawToken, err := s.Store.CreateAPIToken(t.Context(), userID, logic.APITokenParams{
+		Name:          "spec token",
+		ExpensesScope: expensesScope,
+		MacrosScope:   logic.APITokenScopeNone,
+		MoodsScope:    logic.APITokenScopeRead,
+	})
+	require.NoError(t, err)
+
+	return token, rawToken
+}
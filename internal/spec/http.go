@@ -1,7 +1,10 @@
 package spec
 
 import (
+	"bytes"
 	"html"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -88,6 +91,26 @@ func NewPostRequest(url, formBody string, cookies []*http.Cookie, csrfToken stri
 	return req
 }
 
+// NewJSONRequest builds an API request with a JSON body, CSRF header,
+// same-origin fetch metadata, and the given cookies. An empty body sends none.
+func NewJSONRequest(method, url, body string, cookies []*http.Cookie, csrfToken string) *http.Request {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req := httptest.NewRequest(method, url, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	req.Header.Set("X-CSRF-Token", csrfToken)
+
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+
+	return req
+}
+
 // mergeCookies merges new cookies into existing ones, replacing by name.
 func mergeCookies(existing, newer []*http.Cookie) []*http.Cookie {
 	idx := make(map[string]int, len(existing))
@@ -109,3 +132,34 @@ func mergeCookies(existing, newer []*http.Cookie) []*http.Cookie {
 
 	return out
 }
+
+// NewUploadRequest builds a multipart POST carrying content as the file part
+// named field, with the CSRF header, same-origin fetch metadata, and the given
+// cookies.
+func NewUploadRequest(
+	t *testing.T,
+	url, field, fileName, content string,
+	cookies []*http.Cookie,
+	csrfToken string,
+) *http.Request {
+	t.Helper()
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(field, fileName)
+	require.NoError(t, err)
+	_, err = io.WriteString(part, content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, url, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	req.Header.Set("X-CSRF-Token", csrfToken)
+
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+
+	return req
+}
@@ -3,8 +3,14 @@ package task
 import (
 	"bufio"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/ad9311/ninete/internal/logic"
@@ -37,24 +43,324 @@ func CreateInvitationCode(app *prog.App, store *logic.Store) error {
 	return nil
 }
 
+// CopyDueRecurrentExpenses creates an expense for every occurrence that has
+// come due, catching up on any periods missed since the last run. A row that
+// fails is recorded in task_runs and makes the task exit non-zero once the
+// other rows are copied. With --dry-run it only prints the copies it would
+// create.
 func CopyDueRecurrentExpenses(app *prog.App, store *logic.Store) error {
+	flags := flag.NewFlagSet("copy_due_recurrent_expenses", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "list the expenses that would be created without creating them")
+	if err := flags.Parse(taskArgs()); err != nil {
+		return err
+	}
+
 	ctx, cancel := newContext()
 	defer cancel()
 
 	now := time.Now().UTC()
 
+	if *dryRun {
+		due, err := store.FindDueRecurrentExpenses(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		count := 0
+		for _, d := range due {
+			// Estimates land in the pending inbox rather than as expenses.
+			kind := "expense"
+			if d.IsEstimate {
+				kind = "pending"
+			}
+
+			for _, date := range d.Dates {
+				fmt.Printf(
+					"%s  %s recurrent_expense=%d user=%d amount=%d %s  %s\n",
+					date.Format(time.DateOnly), kind, d.ID, d.UserID, d.Amount, d.Currency, d.Description,
+				)
+				count++
+			}
+		}
+		app.Logger.Logf("Dry run: would create %d expense(s) from %d recurrent expense(s)", count, len(due))
+
+		return nil
+	}
+
+	// A failed row still leaves the others copied, so the count is logged
+	// either way; the error makes the task exit non-zero.
 	copied, err := store.CopyDueRecurrentExpenses(ctx, now)
+	app.Logger.Logf("Created %d expense(s) or pending expense(s) from due recurrent expenses", copied)
+
+	return err
+}
+
+// CopyDueRecurrentIncomes creates an income for every occurrence of a
+// recurrent income that has come due, the way CopyDueRecurrentExpenses does
+// for expenses, --dry-run included.
+func CopyDueRecurrentIncomes(app *prog.App, store *logic.Store) error {
+	flags := flag.NewFlagSet("copy_due_recurrent_incomes", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "list the incomes that would be created without creating them")
+	if err := flags.Parse(taskArgs()); err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	now := time.Now().UTC()
+
+	if *dryRun {
+		due, err := store.FindDueRecurrentIncomes(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		count := 0
+		for _, d := range due {
+			for _, date := range d.Dates {
+				fmt.Printf(
+					"%s  recurrent_income=%d user=%d amount=%d %s  %s\n",
+					date.Format(time.DateOnly), d.ID, d.UserID, d.Amount, d.Currency, d.Source,
+				)
+				count++
+			}
+		}
+		app.Logger.Logf("Dry run: would create %d income(s) from %d recurrent income(s)", count, len(due))
+
+		return nil
+	}
+
+	copied, err := store.CopyDueRecurrentIncomes(ctx, now)
+	app.Logger.Logf("Created %d income(s) from due recurrent incomes", copied)
+
+	return err
+}
+
+// PurgeTrash deletes trashed rows older than TRASH_RETENTION_DAYS (30 when
+// unset) from every account. It is meant to run on a schedule.
+func PurgeTrash(app *prog.App, store *logic.Store) error {
+	days, err := prog.SetInt("TRASH_RETENTION_DAYS", logic.DefaultTrashRetentionDays)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	purged, err := store.PurgeTrash(ctx, time.Now().UTC(), days)
+	if err != nil {
+		return err
+	}
+
+	app.Logger.Logf("Purged %d row(s) trashed more than %d day(s) ago", purged, days)
+
+	return nil
+}
+
+// RestoreBackup restores a backup archive into an existing, empty account. It
+// has no upload limit, so it is the path for archives too large for the
+// account page.
+func RestoreBackup(app *prog.App, store *logic.Store) error {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("Account email: ")
+	email, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	fmt.Print("Backup archive path: ")
+	path, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(strings.TrimSpace(path))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			app.Logger.Errorf("failed to close backup archive: %v", err)
+		}
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	user, err := store.FindUserForAuth(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return err
+	}
+
+	counts, err := store.RestoreBackup(ctx, user.ID, file, info.Size())
 	if err != nil {
 		return err
 	}
 
-	app.Logger.Logf("Copied %d recurrent expense(s) into expenses", copied)
+	app.Logger.Logf(
+		"Restored backup [expenses=%d recurrent_expenses=%d budgets=%d tags=%d "+
+			"macro_entries=%d macro_goals=%d foods=%d mood_entries=%d incomes=%d recurrent_incomes=%d "+
+			"payment_accounts=%d savings_goals=%d recipes=%d meal_templates=%d]",
+		counts.Expenses, counts.RecurrentExpenses, counts.ExpenseBudgets, counts.Tags,
+		counts.MacroEntries, counts.MacroGoals, counts.Foods, counts.MoodEntries,
+		counts.Incomes, counts.RecurrentIncomes, counts.PaymentAccounts, counts.SavingsGoals, counts.Recipes,
+		counts.MealTemplates,
+	)
 
 	return nil
 }
 
+// ImportExchangeRates loads a CSV of daily exchange rates (date,
+// base_currency, quote_currency, rate) used to convert totals into each
+// user's home currency.
+func ImportExchangeRates(app *prog.App, store *logic.Store) error {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("Exchange rates CSV path: ")
+	path, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(strings.TrimSpace(path))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			app.Logger.Errorf("failed to close exchange rates CSV: %v", err)
+		}
+	}()
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	count, err := store.ImportExchangeRates(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	app.Logger.Logf("Imported %d exchange rate(s)", count)
+
+	return nil
+}
+
+// ImportReferenceFoods loads a nutrition database dump into the shared
+// reference food catalogue. For usda the path is the unzipped FoodData Central
+// CSV download, holding food.csv and food_nutrient.csv; for off it is an Open
+// Food Facts JSONL file.
+func ImportReferenceFoods(app *prog.App, store *logic.Store) error {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("Source (usda or off): ")
+	source, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	fmt.Print("Dump path: ")
+	path, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	path = strings.TrimSpace(path)
+
+	// Dumps take minutes to load, far past newContext's timeout, so the import
+	// runs until it finishes or is interrupted.
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	var result logic.ReferenceFoodImport
+	switch strings.ToLower(strings.TrimSpace(source)) {
+	case logic.ReferenceSourceUSDA:
+		result, err = importUSDAFoods(ctx, app, store, path)
+	case logic.ReferenceSourceOFF:
+		result, err = importOpenFoodFacts(ctx, app, store, path)
+	default:
+		return logic.ErrReferenceFoodsSource
+	}
+	if err != nil {
+		return err
+	}
+
+	app.Logger.Logf("Imported %d reference food(s), skipped %d", result.Imported, result.Skipped)
+
+	return nil
+}
+
+// taskArgs returns the arguments after the task name, e.g. the --dry-run in
+// `task copy_due_recurrent_expenses --dry-run`.
+func taskArgs() []string {
+	if len(os.Args) < 3 {
+		return nil
+	}
+
+	return os.Args[2:]
+}
+
 func newContext() (context.Context, context.CancelFunc) {
 	ctx := context.Background()
 
 	return context.WithTimeout(ctx, 30*time.Second)
 }
+
+func importUSDAFoods(
+	ctx context.Context,
+	app *prog.App,
+	store *logic.Store,
+	dir string,
+) (logic.ReferenceFoodImport, error) {
+	foods, err := os.Open(filepath.Join(dir, "food.csv"))
+	if err != nil {
+		return logic.ReferenceFoodImport{}, err
+	}
+	defer closeFile(app, foods)
+
+	nutrients, err := os.Open(filepath.Join(dir, "food_nutrient.csv"))
+	if err != nil {
+		return logic.ReferenceFoodImport{}, err
+	}
+	defer closeFile(app, nutrients)
+
+	dump := logic.USDADump{Foods: foods, Nutrients: nutrients}
+
+	// Only the Branded Foods download has branded_food.csv.
+	branded, err := os.Open(filepath.Join(dir, "branded_food.csv"))
+	switch {
+	case err == nil:
+		defer closeFile(app, branded)
+		dump.BrandedFoods = branded
+	case !errors.Is(err, fs.ErrNotExist):
+		return logic.ReferenceFoodImport{}, err
+	}
+
+	return store.ImportUSDAFoods(ctx, dump)
+}
+
+func importOpenFoodFacts(
+	ctx context.Context,
+	app *prog.App,
+	store *logic.Store,
+	path string,
+) (logic.ReferenceFoodImport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return logic.ReferenceFoodImport{}, err
+	}
+	defer closeFile(app, file)
+
+	return store.ImportOpenFoodFacts(ctx, file)
+}
+
+func closeFile(app *prog.App, file *os.File) {
+	if err := file.Close(); err != nil {
+		app.Logger.Errorf("failed to close %s: %v", file.Name(), err)
+	}
+}
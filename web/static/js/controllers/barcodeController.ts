@@ -0,0 +1,85 @@
+import { Controller } from "@hotwired/stimulus";
+import * as Turbo from "@hotwired/turbo";
+
+interface BarcodeFood {
+  name: string;
+  brand?: string;
+  kcal: number;
+  base_amount: number;
+  base_unit: string;
+}
+
+interface BarcodeLookup {
+  barcode?: string;
+  match?: "food" | "reference_food" | "none";
+  food?: BarcodeFood;
+  log_url?: string;
+  copy_url?: string;
+  create_url?: string;
+  error?: string;
+}
+
+// Looks a typed or scanned barcode up on the macro entry form. One of the
+// user's foods opens straight in the form; a database match is offered for
+// copying into the library, and no match links to a new food with the code.
+export default class extends Controller {
+  static targets = ["code", "status", "copy", "create"];
+
+  declare readonly codeTarget: HTMLInputElement;
+  declare readonly statusTarget: HTMLElement;
+  declare readonly copyTarget: HTMLFormElement;
+  declare readonly createTarget: HTMLAnchorElement;
+
+  async lookup(event: Event) {
+    event.preventDefault();
+    this.reset();
+
+    const code = this.codeTarget.value.trim();
+    if (code === "") return;
+
+    let body: BarcodeLookup;
+    try {
+      const response = await fetch(
+        `/foods/barcode/${encodeURIComponent(code)}`,
+        { headers: { Accept: "application/json" } },
+      );
+      body = (await response.json()) as BarcodeLookup;
+    } catch {
+      this.statusTarget.textContent = "The lookup failed, try again.";
+      return;
+    }
+
+    switch (body.match) {
+      case "food":
+        if (body.log_url) Turbo.visit(body.log_url);
+        break;
+      case "reference_food":
+        if (body.food && body.copy_url) {
+          this.statusTarget.textContent = describe(body.food);
+          this.copyTarget.action = body.copy_url;
+          this.copyTarget.hidden = false;
+        }
+        break;
+      case "none":
+        this.statusTarget.textContent = `No food has the barcode ${body.barcode}.`;
+        if (body.create_url) {
+          this.createTarget.href = body.create_url;
+          this.createTarget.hidden = false;
+        }
+        break;
+      default:
+        this.statusTarget.textContent = body.error ?? "The lookup failed.";
+    }
+  }
+
+  private reset() {
+    this.statusTarget.textContent = "";
+    this.copyTarget.hidden = true;
+    this.createTarget.hidden = true;
+  }
+}
+
+function describe(food: BarcodeFood): string {
+  const name = food.brand ? `${food.name} (${food.brand})` : food.name;
+  return `${name}: ${food.kcal} kcal per ${food.base_amount} ${food.base_unit}`;
+}
@@ -13,6 +13,7 @@ const CREATED_DATE_FIELD = "created_at";
 export default class extends Controller {
   static targets = [
     "categoryId",
+    "paymentAccountId",
     "dateRange",
     "perPage",
     "search",
@@ -23,6 +24,8 @@ export default class extends Controller {
   ];
   declare readonly hasCategoryIdTarget: boolean;
   declare readonly categoryIdTarget: HTMLSelectElement;
+  declare readonly hasPaymentAccountIdTarget: boolean;
+  declare readonly paymentAccountIdTarget: HTMLSelectElement;
   declare readonly hasDateRangeTarget: boolean;
   declare readonly dateRangeTarget: HTMLSelectElement;
   declare readonly hasPerPageTarget: boolean;
@@ -48,6 +51,14 @@ export default class extends Controller {
       this.setOrDelete(params, "category_id", this.categoryIdTarget.value);
     }
 
+    if (this.hasPaymentAccountIdTarget) {
+      this.setOrDelete(
+        params,
+        "payment_account_id",
+        this.paymentAccountIdTarget.value,
+      );
+    }
+
     if (this.hasDateRangeTarget) {
       this.setOrDelete(params, "date_range", this.dateRangeTarget.value);
     }
@@ -7,6 +7,7 @@ export default class extends Controller {
     baseCarbs: Number,
     baseFat: Number,
     baseAmount: Number,
+    unit: String,
     foodId: Number,
   };
 
@@ -17,6 +18,7 @@ export default class extends Controller {
   declare readonly baseCarbsValue: number;
   declare readonly baseFatValue: number;
   declare readonly baseAmountValue: number;
+  declare readonly unitValue: string;
   declare readonly foodIdValue: number;
 
   declare readonly amountTarget: HTMLInputElement;
@@ -50,7 +52,8 @@ export default class extends Controller {
     if (this.hasUseLinkTarget) {
       const params = new URLSearchParams({
         from_food: this.foodIdValue.toString(),
-        amount: actual.toString(),
+        quantity: actual.toString(),
+        unit: this.unitValue,
       });
       this.useLinkTarget.href = `/macros/new?${params.toString()}`;
     }
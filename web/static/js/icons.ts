@@ -3,23 +3,30 @@ import {
   AlignLeft,
   Apple,
   Calendar,
+  CalendarClock,
   CalendarRange,
   ChartColumn,
+  ChefHat,
   ChevronDown,
+  Copy,
   Download,
   Eye,
   Info,
+  PiggyBank,
   Plus,
   Repeat,
   Rows3,
   Salad,
+  ScanBarcode,
   Search,
   Smile,
+  Split,
   SquareArrowOutUpRight,
   SquarePen,
   Tag,
   Target,
   Trash2,
+  Upload,
   Utensils,
   Wallet,
 } from "lucide";
@@ -28,23 +35,30 @@ const icons = {
   AlignLeft,
   Apple,
   Calendar,
+  CalendarClock,
   CalendarRange,
   ChartColumn,
+  ChefHat,
   ChevronDown,
+  Copy,
   Download,
   Eye,
   Info,
+  PiggyBank,
   Plus,
   Repeat,
   Rows3,
   Salad,
+  ScanBarcode,
   Search,
   Smile,
+  Split,
   SquareArrowOutUpRight,
   SquarePen,
   Tag,
   Target,
   Trash2,
+  Upload,
   Utensils,
   Wallet,
 };
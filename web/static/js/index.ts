@@ -33,6 +33,7 @@ import QuickExpenseController from "./controllers/quickExpenseController";
 import DateHelpController from "./controllers/dateHelpController";
 import SubmitOnChangeController from "./controllers/submitOnChangeController";
 import SearchPanelController from "./controllers/searchPanelController";
+import BarcodeController from "./controllers/barcodeController";
 import { initIcons } from "./icons";
 
 window.Stimulus = Application.start();
@@ -54,6 +55,7 @@ window.Stimulus.register("quick-expense", QuickExpenseController);
 window.Stimulus.register("date-help", DateHelpController);
 window.Stimulus.register("submit-on-change", SubmitOnChangeController);
 window.Stimulus.register("search-panel", SearchPanelController);
+window.Stimulus.register("barcode", BarcodeController);
 
 // turbo:load covers full-page visits; turbo:render also fires when Turbo
 // re-renders a form response (including non-2xx error re-renders), which